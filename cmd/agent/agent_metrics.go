@@ -0,0 +1,111 @@
+/*
+Copyright (C) 2022 Traefik Labs
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	stdlog "log"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/traefik/hub-agent-kubernetes/pkg/health"
+	"github.com/traefik/hub-agent-kubernetes/pkg/logger"
+	"github.com/traefik/hub-agent-kubernetes/pkg/tracing"
+	"k8s.io/client-go/tools/cache"
+)
+
+const agentMetricsNamespace = "hub_agent"
+
+var admissionReviewDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+	Namespace: agentMetricsNamespace,
+	Subsystem: "admission",
+	Name:      "review_duration_seconds",
+	Help:      "Time taken to answer admission reviews, by webhook and status code.",
+	Buckets:   prometheus.DefBuckets,
+}, []string{"webhook", "code"})
+
+func init() {
+	prometheus.MustRegister(admissionReviewDuration)
+}
+
+// instrumentAdmission records the time taken by the given webhook to answer admission reviews, and traces them.
+func instrumentAdmission(webhook string, next http.Handler) http.Handler {
+	return promhttp.InstrumentHandlerDuration(admissionReviewDuration.MustCurryWith(prometheus.Labels{"webhook": webhook}),
+		tracing.Handler("admission."+webhook, next))
+}
+
+// registerInformerCacheSize exposes the number of objects held by the cache of the given informer.
+func registerInformerCacheSize(resource string, informer cache.SharedIndexInformer) error {
+	return prometheus.Register(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace:   agentMetricsNamespace,
+		Subsystem:   "informer",
+		Name:        "cache_objects",
+		Help:        "Number of objects held by the informer cache, by resource.",
+		ConstLabels: prometheus.Labels{"resource": resource},
+	}, func() float64 {
+		return float64(len(informer.GetStore().ListKeys()))
+	}))
+}
+
+// runAgentMetricsServer exposes the agent own Prometheus metrics, along with the readiness of its subsystems and its
+// read-only logger configuration, until the given context is done.
+func runAgentMetricsServer(ctx context.Context, listenAddr string, readiness *health.Registry) error {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+	mux.Handle("/readyz", readiness)
+	mux.Handle("/logger", logger.Handler())
+
+	server := &http.Server{
+		Addr:     listenAddr,
+		Handler:  mux,
+		ErrorLog: stdlog.New(log.Logger.Level(zerolog.DebugLevel), "", 0),
+	}
+
+	srvDone := make(chan struct{})
+
+	go func() {
+		log.Info().Str("addr", listenAddr).Msg("Starting agent metrics server")
+		if err := server.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+			log.Err(err).Msg("Unable to listen and serve agent metrics requests")
+		}
+		close(srvDone)
+	}()
+
+	select {
+	case <-ctx.Done():
+		gracefulCtx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+		defer cancel()
+
+		if err := server.Shutdown(gracefulCtx); err != nil {
+			log.Error().Err(err).Msg("Failed to shutdown agent metrics server gracefully")
+			if err = server.Close(); err != nil {
+				return fmt.Errorf("close agent metrics server: %w", err)
+			}
+		}
+	case <-srvDone:
+		return errors.New("agent metrics server stopped")
+	}
+
+	return nil
+}
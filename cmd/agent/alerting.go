@@ -19,14 +19,22 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"time"
 
 	"github.com/hashicorp/go-retryablehttp"
 	"github.com/rs/zerolog/log"
 	"github.com/traefik/hub-agent-kubernetes/pkg/alerting"
+	hubv1alpha1 "github.com/traefik/hub-agent-kubernetes/pkg/crd/api/hub/v1alpha1"
+	hubclientset "github.com/traefik/hub-agent-kubernetes/pkg/crd/generated/client/hub/clientset/versioned"
+	hubinformer "github.com/traefik/hub-agent-kubernetes/pkg/crd/generated/client/hub/informers/externalversions"
+	"github.com/traefik/hub-agent-kubernetes/pkg/kube"
 	"github.com/traefik/hub-agent-kubernetes/pkg/logger"
 	"github.com/traefik/hub-agent-kubernetes/pkg/metrics"
 	"github.com/traefik/hub-agent-kubernetes/pkg/topology/state"
+	clientset "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
 )
 
 const (
@@ -39,7 +47,7 @@ const (
 	alertSchedulerInterval = time.Minute
 )
 
-func runAlerting(ctx context.Context, token, platformURL string, store *metrics.Store, fetcher *state.Fetcher) error {
+func runAlerting(ctx context.Context, kubeCfg *rest.Config, kubeClient clientset.Interface, token, platformURL string, store *metrics.Store, fetcher *state.Fetcher, notifierCfg alerting.NotifierConfig, namespaces []string) error {
 	retryableClient := retryablehttp.NewClient()
 	retryableClient.RetryWaitMin = time.Second
 	retryableClient.RetryWaitMax = 10 * time.Second
@@ -53,14 +61,44 @@ func runAlerting(ctx context.Context, token, platformURL string, store *metrics.
 		return err
 	}
 
-	threshProc := alerting.NewThresholdProcessor(metrics.NewDataPointView(store), fetcher)
+	hubClientSet, err := hubclientset.NewForConfig(kubeCfg)
+	if err != nil {
+		return fmt.Errorf("create Hub client set: %w", err)
+	}
+
+	hubInformer := hubinformer.NewSharedInformerFactory(hubClientSet, 5*time.Minute)
+	if len(namespaces) > 0 {
+		hubInformer.InformerFor(&hubv1alpha1.AlertSilence{}, func(_ hubclientset.Interface, resync time.Duration) cache.SharedIndexInformer {
+			return kube.NewNamespacedInformer(hubClientSet.HubV1alpha1().RESTClient(), "alertsilences", &hubv1alpha1.AlertSilence{}, namespaces, resync, nil)
+		})
+	}
+	alertSilences := hubInformer.Hub().V1alpha1().AlertSilences()
+	alertSilences.Informer()
+	hubInformer.Start(ctx.Done())
+
+	for t, ok := range hubInformer.WaitForCacheSync(ctx.Done()) {
+		if !ok {
+			return fmt.Errorf("wait for Hub informer cache sync: %s: %w", t, ctx.Err())
+		}
+	}
+
+	// Silences are defined either on the platform or with AlertSilence resources.
+	notifierCfg.Silences = []alerting.SilenceProvider{client, alerting.NewCRDSilenceProvider(alertSilences.Lister())}
+
+	dataPoints := metrics.NewDataPointView(store)
+	threshProc := alerting.NewThresholdProcessor(dataPoints, fetcher)
+	exprProc := alerting.NewExpressionProcessor(dataPoints, fetcher)
+	certProc := alerting.NewCertificateExpiryProcessor(fetcher, kube.NewEventRecorder(kubeClient, "hub-agent-controller"))
 
 	mgr := alerting.NewManager(client,
 		map[string]alerting.Processor{
-			alerting.ThresholdType: threshProc,
+			alerting.ThresholdType:         threshProc,
+			alerting.ExpressionType:        exprProc,
+			alerting.CertificateExpiryType: certProc,
 		},
 		alertRefreshInterval,
 		alertSchedulerInterval,
+		notifierCfg,
 	)
 
 	return mgr.Run(ctx)
@@ -30,7 +30,8 @@ import (
 	"github.com/traefik/hub-agent-kubernetes/pkg/acp/auth"
 	hubclientset "github.com/traefik/hub-agent-kubernetes/pkg/crd/generated/client/hub/clientset/versioned"
 	hubinformer "github.com/traefik/hub-agent-kubernetes/pkg/crd/generated/client/hub/informers/externalversions"
-	"github.com/traefik/hub-agent-kubernetes/pkg/kube"
+	"github.com/traefik/hub-agent-kubernetes/pkg/edgeingress"
+	"github.com/traefik/hub-agent-kubernetes/pkg/health"
 	"github.com/traefik/hub-agent-kubernetes/pkg/logger"
 	"github.com/traefik/hub-agent-kubernetes/pkg/version"
 	"github.com/urfave/cli/v2"
@@ -48,9 +49,18 @@ func newAuthServerCmd() authServerCmd {
 			EnvVars: []string{"AUTH_SERVER_LISTEN_ADDR"},
 			Value:   "0.0.0.0:80",
 		},
+		&cli.StringFlag{
+			Name:    "metrics-listen-addr",
+			Usage:   "Address on which the auth server exposes its own Prometheus metrics (disabled if empty)",
+			EnvVars: []string{"AUTH_SERVER_METRICS_LISTEN_ADDR"},
+		},
 	}
 
 	flgs = append(flgs, globalFlags()...)
+	flgs = append(flgs, tracingFlags()...)
+	flgs = append(flgs, shutdownFlags()...)
+	flgs = append(flgs, kubeconfigFlags()...)
+	flgs = append(flgs, fipsFlags()...)
 
 	return authServerCmd{
 		flags: flgs,
@@ -70,10 +80,15 @@ func (c authServerCmd) run(cliCtx *cli.Context) error {
 	logger.Setup(cliCtx.String("log-level"), cliCtx.String("log-format"))
 
 	version.Log()
+	setupFIPS(cliCtx)
+
+	if err := setupTracing(cliCtx.Context, cliCtx, "hub-agent-auth-server"); err != nil {
+		return err
+	}
 
-	config, err := kube.InClusterConfigWithRetrier(2)
+	config, err := kubeConfig(cliCtx)
 	if err != nil {
-		return fmt.Errorf("create Kubernetes in-cluster configuration: %w", err)
+		return fmt.Errorf("create Kubernetes configuration: %w", err)
 	}
 
 	hubClientSet, err := hubclientset.NewForConfig(config)
@@ -96,6 +111,17 @@ func (c authServerCmd) run(cliCtx *cli.Context) error {
 
 	go acpWatcher.Run(cliCtx.Context)
 
+	readiness := health.NewRegistry()
+	readiness.Register("acp", acpWatcher.Ready)
+
+	if metricsListenAddr := cliCtx.String("metrics-listen-addr"); metricsListenAddr != "" {
+		go func() {
+			if err := runAgentMetricsServer(cliCtx.Context, metricsListenAddr, readiness); err != nil {
+				log.Error().Err(err).Msg("Unable to run auth server metrics server")
+			}
+		}()
+	}
+
 	listenAddr := cliCtx.String("listen-addr")
 
 	mux := http.NewServeMux()
@@ -103,9 +129,10 @@ func (c authServerCmd) run(cliCtx *cli.Context) error {
 	mux.Handle("/_live", http.HandlerFunc(func(rw http.ResponseWriter, request *http.Request) {
 		rw.WriteHeader(http.StatusOK)
 	}))
-	mux.Handle("/_ready", http.HandlerFunc(func(rw http.ResponseWriter, request *http.Request) {
-		rw.WriteHeader(http.StatusOK)
-	}))
+	mux.Handle("/_ready", readiness)
+	mux.Handle("/readyz", readiness)
+
+	mux.Handle(edgeingress.MaintenancePath, edgeingress.MaintenanceHandler())
 
 	mux.Handle("/", switcher)
 
@@ -127,7 +154,8 @@ func (c authServerCmd) run(cliCtx *cli.Context) error {
 
 	select {
 	case <-cliCtx.Context.Done():
-		gracefulCtx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+		// New auth requests are refused while the in-flight ones are drained.
+		gracefulCtx, cancel := context.WithTimeout(context.Background(), cliCtx.Duration(flagShutdownGracePeriod))
 		defer cancel()
 
 		if err = server.Shutdown(gracefulCtx); err != nil {
@@ -136,6 +164,7 @@ func (c authServerCmd) run(cliCtx *cli.Context) error {
 				return fmt.Errorf("close auth server: %w", err)
 			}
 		}
+		log.Info().Msg("Successfully shutdown auth server")
 	case <-srvDone:
 		return errors.New("auth server stopped")
 	}
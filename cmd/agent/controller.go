@@ -25,10 +25,15 @@ import (
 	"time"
 
 	"github.com/ettle/strcase"
+	"github.com/traefik/hub-agent-kubernetes/pkg/alerting"
+	"github.com/traefik/hub-agent-kubernetes/pkg/health"
 	"github.com/traefik/hub-agent-kubernetes/pkg/heartbeat"
 	"github.com/traefik/hub-agent-kubernetes/pkg/kube"
 	"github.com/traefik/hub-agent-kubernetes/pkg/logger"
+	"github.com/traefik/hub-agent-kubernetes/pkg/metrics"
 	"github.com/traefik/hub-agent-kubernetes/pkg/platform"
+	"github.com/traefik/hub-agent-kubernetes/pkg/topology"
+	"github.com/traefik/hub-agent-kubernetes/pkg/topology/s3store"
 	"github.com/traefik/hub-agent-kubernetes/pkg/topology/state"
 	"github.com/traefik/hub-agent-kubernetes/pkg/topology/store"
 	"github.com/traefik/hub-agent-kubernetes/pkg/version"
@@ -39,10 +44,49 @@ import (
 )
 
 const (
-	pidFilePath           = "/var/run/hub-agent-kubernetes.pid"
-	flagPlatformURL       = "platform-url"
-	flagToken             = "token"
-	flagTraefikMetricsURL = "traefik.metrics-url"
+	pidFilePath                    = "/var/run/hub-agent-kubernetes.pid"
+	flagPlatformURL                = "platform-url"
+	flagToken                      = "token"
+	flagTraefikMetricsURL          = "traefik.metrics-url"
+	flagTraefikAPIPort             = "traefik.api-port"
+	flagTraefikAPIInterval         = "traefik.api-interval"
+	flagMetricsListenAddr          = "metrics.listen-addr"
+	flagMetricsOTLPURL             = "metrics.otlp-url"
+	flagMetricsOTLPHeader          = "metrics.otlp-header"
+	flagMetricsOTLPOnly            = "metrics.otlp-only"
+	flagMetricsMaxGroups           = "metrics.max-groups"
+	flagMetricsCardinalityStrategy = "metrics.cardinality-strategy"
+	flagMetricsDogStatsDAddr       = "metrics.dogstatsd-addr"
+	flagMetricsDogStatsDPrefix     = "metrics.dogstatsd-prefix"
+	flagProbeNamespaces            = "probe.namespaces"
+	flagProbeInterval              = "probe.interval"
+
+	flagTopologyMaxWriteRetries      = "topology.max-write-retries"
+	flagTopologyRetryInitialInterval = "topology.retry-initial-interval"
+	flagTopologyRetryMaxElapsedTime  = "topology.retry-max-elapsed-time"
+	flagTopologyWriteTimeout         = "topology.write-timeout"
+	flagTopologyGitRemoteURL         = "topology.git-remote-url"
+	flagTopologyGitBranch            = "topology.git-branch"
+	flagTopologyGitSSHKeyFile        = "topology.git-ssh-key-file"
+	flagTopologyGitSSHKnownHostsFile = "topology.git-ssh-known-hosts-file"
+	flagTopologyGitCloneDepth        = "topology.git-clone-depth"
+	flagTopologyGitAuthorName        = "topology.git-author-name"
+	flagTopologyGitAuthorEmail       = "topology.git-author-email"
+	flagTopologyGitSigningFormat     = "topology.git-signing-format"
+	flagTopologyGitSigningKeyFile    = "topology.git-signing-key-file"
+	flagTopologyS3Endpoint           = "topology.s3-endpoint"
+	flagTopologyS3Region             = "topology.s3-region"
+	flagTopologyS3Bucket             = "topology.s3-bucket"
+	flagTopologyS3Prefix             = "topology.s3-prefix"
+	flagTopologyS3AccessKeyID        = "topology.s3-access-key-id"
+	flagTopologyS3SecretAccessKey    = "topology.s3-secret-access-key"
+	flagTopologyS3SnapshotInterval   = "topology.s3-snapshot-interval"
+	flagTopologyWriteDebounce        = "topology.write-debounce"
+	flagTopologyWriteMaxStaleness    = "topology.write-max-staleness"
+	flagTopologySizeBudget           = "topology.size-budget"
+
+	flagAlertingDedupWindow = "alerting.dedup-window"
+	flagAlertingGroupWindow = "alerting.group-window"
 )
 
 type controllerCmd struct {
@@ -69,10 +113,220 @@ func newControllerCmd() controllerCmd {
 			Usage:   "The url used by Traefik to expose metrics",
 			EnvVars: []string{strcase.ToSNAKE(flagTraefikMetricsURL)},
 		},
+		&cli.StringFlag{
+			Name:    flagTraefikAPIPort,
+			Usage:   "The port on which Traefik exposes its API, used to capture its runtime configuration (disabled if empty)",
+			EnvVars: []string{strcase.ToSNAKE(flagTraefikAPIPort)},
+		},
+		&cli.DurationFlag{
+			Name:    flagTraefikAPIInterval,
+			Usage:   "Interval at which the runtime configuration of Traefik is captured from its API",
+			EnvVars: []string{strcase.ToSNAKE(flagTraefikAPIInterval)},
+			Value:   30 * time.Second,
+		},
+		&cli.StringFlag{
+			Name:    flagMetricsListenAddr,
+			Usage:   "The address on which the agent exposes its own Prometheus metrics, its readiness and its read-only logger configuration (disabled if empty)",
+			EnvVars: []string{strcase.ToSNAKE(flagMetricsListenAddr)},
+		},
+		&cli.StringFlag{
+			Name:    flagMetricsOTLPURL,
+			Usage:   "The URL of an OpenTelemetry collector to which export collected metrics using OTLP/HTTP (e.g. http://otel-collector:4318)",
+			EnvVars: []string{strcase.ToSNAKE(flagMetricsOTLPURL)},
+		},
+		&cli.StringSliceFlag{
+			Name:    flagMetricsOTLPHeader,
+			Usage:   "A header, formatted as name=value, to add to OTLP export requests",
+			EnvVars: []string{strcase.ToSNAKE(flagMetricsOTLPHeader)},
+		},
+		&cli.BoolFlag{
+			Name:    flagMetricsOTLPOnly,
+			Usage:   "Export collected metrics through OTLP only, instead of sending them to the Hub platform",
+			EnvVars: []string{strcase.ToSNAKE(flagMetricsOTLPOnly)},
+		},
+		&cli.IntFlag{
+			Name:    flagMetricsMaxGroups,
+			Usage:   "Maximum number of services, ingresses and edge ingresses, of each kind, whose metrics are sent in full detail, the others being aggregated as \"other\" (0 for no limit)",
+			EnvVars: []string{strcase.ToSNAKE(flagMetricsMaxGroups)},
+		},
+		&cli.StringFlag{
+			Name:    flagMetricsCardinalityStrategy,
+			Usage:   "How services, ingresses and edge ingresses are ranked to select the ones sent in full detail (requests, errors or responseTime)",
+			EnvVars: []string{strcase.ToSNAKE(flagMetricsCardinalityStrategy)},
+			Value:   string(metrics.CardinalityStrategyRequests),
+		},
+		&cli.StringFlag{
+			Name:    flagMetricsDogStatsDAddr,
+			Usage:   "The UDP address on which the agent receives the metrics pushed by Traefik with the Datadog metrics provider (disabled if empty)",
+			EnvVars: []string{strcase.ToSNAKE(flagMetricsDogStatsDAddr)},
+		},
+		&cli.StringFlag{
+			Name:    flagMetricsDogStatsDPrefix,
+			Usage:   "The prefix of the metrics pushed by Traefik with the Datadog metrics provider",
+			EnvVars: []string{strcase.ToSNAKE(flagMetricsDogStatsDPrefix)},
+			Value:   "traefik",
+		},
+		&cli.StringSliceFlag{
+			Name:    flagProbeNamespaces,
+			Usage:   "Namespaces in which the public endpoints of Ingresses and EdgeIngresses are probed (disabled if empty)",
+			EnvVars: []string{strcase.ToSNAKE(flagProbeNamespaces)},
+		},
+		&cli.DurationFlag{
+			Name:    flagProbeInterval,
+			Usage:   "Interval at which public endpoints are probed",
+			EnvVars: []string{strcase.ToSNAKE(flagProbeInterval)},
+			Value:   time.Minute,
+		},
+		&cli.IntFlag{
+			Name:    flagTopologyMaxWriteRetries,
+			Usage:   "Number of times pushing the topology is retried before giving up",
+			EnvVars: []string{strcase.ToSNAKE(flagTopologyMaxWriteRetries)},
+			Value:   store.DefaultRetryConfig().MaxWriteRetries,
+		},
+		&cli.DurationFlag{
+			Name:    flagTopologyRetryInitialInterval,
+			Usage:   "Interval before retrying a failed topology operation for the first time, growing exponentially between retries",
+			EnvVars: []string{strcase.ToSNAKE(flagTopologyRetryInitialInterval)},
+			Value:   store.DefaultRetryConfig().InitialInterval,
+		},
+		&cli.DurationFlag{
+			Name:    flagTopologyRetryMaxElapsedTime,
+			Usage:   "Time after which a failed topology operation is no longer retried (0 to retry indefinitely)",
+			EnvVars: []string{strcase.ToSNAKE(flagTopologyRetryMaxElapsedTime)},
+			Value:   store.DefaultRetryConfig().MaxElapsedTime,
+		},
+		&cli.DurationFlag{
+			Name:    flagTopologyWriteTimeout,
+			Usage:   "Overall deadline of a topology write, retries included (0 for no deadline)",
+			EnvVars: []string{strcase.ToSNAKE(flagTopologyWriteTimeout)},
+			Value:   time.Minute,
+		},
+		&cli.StringFlag{
+			Name:    flagTopologyGitRemoteURL,
+			Usage:   "The URL of the Git repository to push the topology to, instead of the Hub platform one",
+			EnvVars: []string{strcase.ToSNAKE(flagTopologyGitRemoteURL)},
+		},
+		&cli.StringFlag{
+			Name:    flagTopologyGitBranch,
+			Usage:   "The Git branch to push the topology to (defaults to the cluster ID)",
+			EnvVars: []string{strcase.ToSNAKE(flagTopologyGitBranch)},
+		},
+		&cli.StringFlag{
+			Name:    flagTopologyGitSSHKeyFile,
+			Usage:   "Path of the SSH private key used to authenticate against the Git remote, typically mounted from a Secret",
+			EnvVars: []string{strcase.ToSNAKE(flagTopologyGitSSHKeyFile)},
+		},
+		&cli.StringFlag{
+			Name:    flagTopologyGitSSHKnownHostsFile,
+			Usage:   "Path of the known_hosts file used to verify the Git remote SSH host key (required for SSH remotes)",
+			EnvVars: []string{strcase.ToSNAKE(flagTopologyGitSSHKnownHostsFile)},
+		},
+		&cli.IntFlag{
+			Name:    flagTopologyGitCloneDepth,
+			Usage:   "Depth of the topology repository clone (0 for a full clone)",
+			EnvVars: []string{strcase.ToSNAKE(flagTopologyGitCloneDepth)},
+			Value:   1,
+		},
+		&cli.StringFlag{
+			Name:    flagTopologyGitAuthorName,
+			Usage:   "The name of the author of the topology commits",
+			EnvVars: []string{strcase.ToSNAKE(flagTopologyGitAuthorName)},
+			Value:   "Hub Agent",
+		},
+		&cli.StringFlag{
+			Name:    flagTopologyGitAuthorEmail,
+			Usage:   "The email of the author of the topology commits",
+			EnvVars: []string{strcase.ToSNAKE(flagTopologyGitAuthorEmail)},
+			Value:   "hubagent@traefik.io",
+		},
+		&cli.StringFlag{
+			Name:    flagTopologyGitSigningFormat,
+			Usage:   "The format of the key used to sign topology commits: openpgp or ssh",
+			EnvVars: []string{strcase.ToSNAKE(flagTopologyGitSigningFormat)},
+			Value:   store.SigningFormatOpenPGP,
+		},
+		&cli.StringFlag{
+			Name:    flagTopologyGitSigningKeyFile,
+			Usage:   "Path of the key used to sign topology commits, typically mounted from a Secret (commits are not signed if empty). OpenPGP keys must have a UID matching the author email",
+			EnvVars: []string{strcase.ToSNAKE(flagTopologyGitSigningKeyFile)},
+		},
+		&cli.StringFlag{
+			Name:    flagTopologyS3Endpoint,
+			Usage:   "The URL of the S3-compatible service to write topology snapshots to",
+			EnvVars: []string{strcase.ToSNAKE(flagTopologyS3Endpoint)},
+			Value:   "https://s3.amazonaws.com",
+		},
+		&cli.StringFlag{
+			Name:    flagTopologyS3Region,
+			Usage:   "The region of the S3 bucket",
+			EnvVars: []string{strcase.ToSNAKE(flagTopologyS3Region)},
+			Value:   "us-east-1",
+		},
+		&cli.StringFlag{
+			Name:    flagTopologyS3Bucket,
+			Usage:   "The S3 bucket to write topology snapshots to (disabled if empty)",
+			EnvVars: []string{strcase.ToSNAKE(flagTopologyS3Bucket)},
+		},
+		&cli.StringFlag{
+			Name:    flagTopologyS3Prefix,
+			Usage:   "The prefix of the topology snapshot object keys",
+			EnvVars: []string{strcase.ToSNAKE(flagTopologyS3Prefix)},
+		},
+		&cli.StringFlag{
+			Name:    flagTopologyS3AccessKeyID,
+			Usage:   "The access key ID used to authenticate against the S3-compatible service",
+			EnvVars: []string{strcase.ToSNAKE(flagTopologyS3AccessKeyID)},
+		},
+		&cli.StringFlag{
+			Name:    flagTopologyS3SecretAccessKey,
+			Usage:   "The secret access key used to authenticate against the S3-compatible service",
+			EnvVars: []string{strcase.ToSNAKE(flagTopologyS3SecretAccessKey)},
+		},
+		&cli.DurationFlag{
+			Name:    flagTopologyS3SnapshotInterval,
+			Usage:   "Interval at which a full topology snapshot is written to S3, only differences are written in between",
+			EnvVars: []string{strcase.ToSNAKE(flagTopologyS3SnapshotInterval)},
+			Value:   time.Hour,
+		},
+		&cli.DurationFlag{
+			Name:    flagTopologyWriteDebounce,
+			Usage:   "Duration during which a topology change must remain stable before being written, to coalesce bursts of changes (0 to write at each change)",
+			EnvVars: []string{strcase.ToSNAKE(flagTopologyWriteDebounce)},
+		},
+		&cli.DurationFlag{
+			Name:    flagTopologyWriteMaxStaleness,
+			Usage:   "Maximum duration a topology change can wait before being written, whatever the debounce",
+			EnvVars: []string{strcase.ToSNAKE(flagTopologyWriteMaxStaleness)},
+			Value:   time.Minute,
+		},
+		&cli.IntFlag{
+			Name:    flagTopologySizeBudget,
+			Usage:   "Maximum size in bytes of the topology, beyond which lower-priority data is dropped (0 for no limit)",
+			EnvVars: []string{strcase.ToSNAKE(flagTopologySizeBudget)},
+		},
+		&cli.DurationFlag{
+			Name:    flagAlertingDedupWindow,
+			Usage:   "Duration during which an alert identical to an already sent one is not sent again (0 to disable)",
+			EnvVars: []string{strcase.ToSNAKE(flagAlertingDedupWindow)},
+			Value:   time.Hour,
+		},
+		&cli.DurationFlag{
+			Name:    flagAlertingGroupWindow,
+			Usage:   "Duration during which alerts on the same service or namespace are held to be sent together (0 to disable)",
+			EnvVars: []string{strcase.ToSNAKE(flagAlertingGroupWindow)},
+			Value:   time.Minute,
+		},
 	}
 
 	flgs = append(flgs, globalFlags()...)
 	flgs = append(flgs, acpFlags()...)
+	flgs = append(flgs, tracingFlags()...)
+	flgs = append(flgs, leaderElectionFlags()...)
+	flgs = append(flgs, shardingFlags()...)
+	flgs = append(flgs, watchNamespacesFlags()...)
+	flgs = append(flgs, kubeconfigFlags()...)
+	flgs = append(flgs, fipsFlags()...)
+	flgs = append(flgs, shutdownFlags()...)
 
 	return controllerCmd{
 		flags: flgs,
@@ -92,16 +346,21 @@ func (c controllerCmd) run(cliCtx *cli.Context) error {
 	logger.Setup(cliCtx.String("log-level"), cliCtx.String("log-format"))
 
 	version.Log()
+	setupFIPS(cliCtx)
 
 	if err := writePID(); err != nil {
 		return fmt.Errorf("write pid: %w", err)
 	}
 
+	if err := setupTracing(cliCtx.Context, cliCtx, "hub-agent-controller"); err != nil {
+		return err
+	}
+
 	platformURL, token := cliCtx.String(flagPlatformURL), cliCtx.String(flagToken)
 
-	kubeCfg, err := kube.InClusterConfigWithRetrier(2)
+	kubeCfg, err := kubeConfig(cliCtx)
 	if err != nil {
-		return fmt.Errorf("create Kubernetes in-cluster configuration: %w", err)
+		return fmt.Errorf("create Kubernetes configuration: %w", err)
 	}
 
 	kubeClient, err := clientset.NewForConfig(kubeCfg)
@@ -123,19 +382,70 @@ func (c controllerCmd) run(cliCtx *cli.Context) error {
 		return fmt.Errorf("setup agent: %w", err)
 	}
 
+	watchLoggingConfig(agentCfg.Logging, configWatcher)
+
 	storeCfg := store.Config{
 		TopologyConfig: agentCfg.Topology,
 		Token:          token,
+		Retry: store.RetryConfig{
+			MaxWriteRetries: cliCtx.Int(flagTopologyMaxWriteRetries),
+			InitialInterval: cliCtx.Duration(flagTopologyRetryInitialInterval),
+			MaxElapsedTime:  cliCtx.Duration(flagTopologyRetryMaxElapsedTime),
+		},
+		WriteTimeout: cliCtx.Duration(flagTopologyWriteTimeout),
+		Git: store.GitConfig{
+			RemoteURL:         cliCtx.String(flagTopologyGitRemoteURL),
+			Branch:            cliCtx.String(flagTopologyGitBranch),
+			SSHKeyFile:        cliCtx.String(flagTopologyGitSSHKeyFile),
+			SSHKnownHostsFile: cliCtx.String(flagTopologyGitSSHKnownHostsFile),
+			CloneDepth:        cliCtx.Int(flagTopologyGitCloneDepth),
+			AuthorName:        cliCtx.String(flagTopologyGitAuthorName),
+			AuthorEmail:       cliCtx.String(flagTopologyGitAuthorEmail),
+			SigningFormat:     cliCtx.String(flagTopologyGitSigningFormat),
+			SigningKeyFile:    cliCtx.String(flagTopologyGitSigningKeyFile),
+		},
+	}
+	fetcherCfg := state.FetcherConfig{
+		TraefikAPIPort:     cliCtx.String(flagTraefikAPIPort),
+		TraefikAPIInterval: cliCtx.Duration(flagTraefikAPIInterval),
+		ProbeNamespaces:    cliCtx.StringSlice(flagProbeNamespaces),
+		ProbeInterval:      cliCtx.Duration(flagProbeInterval),
+		Namespaces:         watchNamespaces(cliCtx),
 	}
-	topoFetcher, err := state.NewFetcher(cliCtx.Context, hubClusterID)
+	topoFetcher, err := state.NewFetcherWithKubeConfig(cliCtx.Context, kubeCfg, hubClusterID, fetcherCfg)
 	if err != nil {
 		return err
 	}
-	topoWatch, err := newTopologyWatcher(cliCtx.Context, topoFetcher, storeCfg)
+	s3Cfg := s3store.Config{
+		Endpoint:             cliCtx.String(flagTopologyS3Endpoint),
+		Region:               cliCtx.String(flagTopologyS3Region),
+		Bucket:               cliCtx.String(flagTopologyS3Bucket),
+		Prefix:               cliCtx.String(flagTopologyS3Prefix),
+		AccessKeyID:          cliCtx.String(flagTopologyS3AccessKeyID),
+		SecretAccessKey:      cliCtx.String(flagTopologyS3SecretAccessKey),
+		FullSnapshotInterval: cliCtx.Duration(flagTopologyS3SnapshotInterval),
+	}
+	watcherCfg := topology.WatcherConfig{
+		WriteDebounce:     cliCtx.Duration(flagTopologyWriteDebounce),
+		WriteMaxStaleness: cliCtx.Duration(flagTopologyWriteMaxStaleness),
+		SizeBudget:        cliCtx.Int(flagTopologySizeBudget),
+		FlushTimeout:      cliCtx.Duration(flagShutdownGracePeriod),
+	}
+	topoWatch, err := newTopologyWatcher(cliCtx.Context, kubeClient, topoFetcher, storeCfg, s3Cfg, watcherCfg)
 	if err != nil {
 		return err
 	}
 
+	readiness := health.NewRegistry()
+
+	// Outdated agents are reported on their Pod, so they can be found across a fleet of clusters.
+	versionChecker := version.NewChecker(kube.NewEventRecorder(kubeClient, "hub-agent-controller"), agentPodRef())
+	versionChecker.SetLatest(agentCfg.Agent.LatestVersion)
+	configWatcher.AddListener(func(cfg platform.Config) {
+		versionChecker.SetLatest(cfg.Agent.LatestVersion)
+	})
+	readiness.RegisterInfo("version", versionChecker.Info)
+
 	group, ctx := errgroup.WithContext(cliCtx.Context)
 
 	group.Go(func() error {
@@ -143,31 +453,74 @@ func (c controllerCmd) run(cliCtx *cli.Context) error {
 		return nil
 	})
 
-	group.Go(func() error {
+	// Watchers and platform writers only run on the leader replica, while admission requests are served by all of them.
+	// Readiness checks of the leader tasks are registered once they start, so followers are ready to serve.
+	leader := &leaderTasks{}
+
+	leader.Go(func(ctx context.Context) error {
 		heartbeater.Run(ctx)
 		return nil
 	})
 
-	if cliCtx.String(flagTraefikMetricsURL) != "" {
-		mtrcsMgr, mtrcsStore, err := newMetrics(topoWatch, token, platformURL, cliCtx.String(flagTraefikMetricsURL), agentCfg.Metrics, configWatcher)
-		if err != nil {
-			return err
-		}
+	// Metrics of other ingress controllers are scraped from the URLs found in the topology, so metrics are collected even
+	// when no Traefik metrics URL is given.
+	otlpCfg, err := otlpConfig(cliCtx)
+	if err != nil {
+		return err
+	}
 
-		group.Go(func() error {
-			return mtrcsMgr.Run(ctx)
-		})
+	limit, err := cardinalityLimit(cliCtx)
+	if err != nil {
+		return err
+	}
+
+	mtrcsMgr, mtrcsStore, err := newMetrics(kubeClient, topoWatch, token, platformURL, cliCtx.String(flagTraefikMetricsURL), agentCfg.Metrics, otlpCfg, limit, configWatcher)
+	if err != nil {
+		return err
+	}
+	mtrcsMgr.SetFlushTimeout(cliCtx.Duration(flagShutdownGracePeriod))
 
-		group.Go(func() error { return runAlerting(ctx, token, platformURL, mtrcsStore, topoFetcher) })
+	// Traefik instances whose Prometheus endpoint is disabled can push their metrics in the DogStatsD format instead.
+	if addr := cliCtx.String(flagMetricsDogStatsDAddr); addr != "" {
+		dogStatsD := metrics.NewDogStatsD(addr, cliCtx.String(flagMetricsDogStatsDPrefix))
+		mtrcsMgr.SetDogStatsD(dogStatsD)
+
+		leader.Go(dogStatsD.Run)
 	}
 
-	group.Go(func() error {
+	leader.Go(func(ctx context.Context) error {
+		readiness.Register("metrics", mtrcsMgr.Ready)
+		return mtrcsMgr.Run(ctx)
+	})
+
+	alertingCfg := alerting.NotifierConfig{
+		DedupWindow: cliCtx.Duration(flagAlertingDedupWindow),
+		GroupWindow: cliCtx.Duration(flagAlertingGroupWindow),
+	}
+	leader.Go(func(ctx context.Context) error {
+		return runAlerting(ctx, kubeCfg, kubeClient, token, platformURL, mtrcsStore, topoFetcher, alertingCfg, watchNamespaces(cliCtx))
+	})
+
+	trafficView := metrics.NewDataPointView(mtrcsStore)
+
+	leader.Go(func(ctx context.Context) error {
+		readiness.Register("topology", topoWatch.Ready)
 		topoWatch.Start(ctx)
 		return nil
 	})
 
+	if listenAddr := cliCtx.String(flagMetricsListenAddr); listenAddr != "" {
+		group.Go(func() error {
+			return runAgentMetricsServer(ctx, listenAddr, readiness)
+		})
+	}
+
+	group.Go(func() error {
+		return webhookAdmission(ctx, cliCtx, kubeCfg, platformClient, trafficView, agentCfg.EdgeIngress, configWatcher, readiness, leader)
+	})
+
 	group.Go(func() error {
-		return webhookAdmission(ctx, cliCtx, platformClient)
+		return runLeaderTasks(ctx, cliCtx, kubeClient, leader)
 	})
 
 	return group.Wait()
@@ -0,0 +1,162 @@
+/*
+Copyright (C) 2022 Traefik Labs
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/traefik/hub-agent-kubernetes/pkg/diagnose"
+	"github.com/traefik/hub-agent-kubernetes/pkg/kube"
+	"github.com/traefik/hub-agent-kubernetes/pkg/logger"
+	"github.com/traefik/hub-agent-kubernetes/pkg/topology/state"
+	"github.com/urfave/cli/v2"
+	"k8s.io/client-go/dynamic"
+	clientset "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+const (
+	flagDiagnoseNamespace = "namespace"
+	flagDiagnoseSelector  = "selector"
+	flagDiagnoseLogLines  = "log-lines"
+)
+
+type diagnoseCmd struct {
+	flags []cli.Flag
+}
+
+func newDiagnoseCmd() diagnoseCmd {
+	flgs := []cli.Flag{
+		&cli.StringFlag{
+			Name:  flagKubeconfig,
+			Usage: "Path of the kubeconfig file to use (defaults to $KUBECONFIG or ~/.kube/config)",
+		},
+		&cli.StringFlag{
+			Name:  flagKubeContext,
+			Usage: "The kubeconfig context to use (defaults to the current context)",
+		},
+		&cli.StringFlag{
+			Name:    flagDiagnoseNamespace,
+			Aliases: []string{"n"},
+			Usage:   "Namespace the agent is installed in",
+			Value:   "hub-agent",
+		},
+		&cli.StringFlag{
+			Name:  flagDiagnoseSelector,
+			Usage: "Label selector of the agent Pods",
+			Value: "app=hub-agent",
+		},
+		&cli.Int64Flag{
+			Name:  flagDiagnoseLogLines,
+			Usage: "Number of lines to collect from the end of the logs of each agent container",
+			Value: 1000,
+		},
+		&cli.StringFlag{
+			Name:    flagOutput,
+			Aliases: []string{"o"},
+			Usage:   "Path of the bundle to write (defaults to hub-agent-diagnose-<date>.tar.gz)",
+		},
+		&cli.DurationFlag{
+			Name:  flagTimeout,
+			Usage: "Maximum time to wait for the bundle to be collected",
+			Value: 2 * time.Minute,
+		},
+	}
+
+	flgs = append(flgs, globalFlags()...)
+
+	return diagnoseCmd{
+		flags: flgs,
+	}
+}
+
+func (c diagnoseCmd) build() *cli.Command {
+	return &cli.Command{
+		Name:   "diagnose",
+		Usage:  "Collects the configuration, health, logs and topology of the Hub agent into a bundle for support",
+		Flags:  c.flags,
+		Action: c.run,
+	}
+}
+
+func (c diagnoseCmd) run(cliCtx *cli.Context) error {
+	logger.Setup(cliCtx.String(flagLogLevel), cliCtx.String(flagLogFormat))
+
+	kubeCfg, err := kube.LoadKubeConfig(cliCtx.String(flagKubeconfig), cliCtx.String(flagKubeContext))
+	if err != nil {
+		return err
+	}
+
+	clientSet, err := clientset.NewForConfig(kubeCfg)
+	if err != nil {
+		return fmt.Errorf("create Kubernetes client set: %w", err)
+	}
+
+	dynClient, err := dynamic.NewForConfig(kubeCfg)
+	if err != nil {
+		return fmt.Errorf("create Kubernetes dynamic client: %w", err)
+	}
+
+	path := cliCtx.String(flagOutput)
+	if path == "" {
+		path = fmt.Sprintf("hub-agent-diagnose-%s.tar.gz", time.Now().Format("20060102-150405"))
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("create output file: %w", err)
+	}
+	defer func() { _ = file.Close() }()
+
+	ctx, cancel := context.WithTimeout(cliCtx.Context, cliCtx.Duration(flagTimeout))
+	defer cancel()
+
+	collector := diagnose.NewCollector(clientSet, dynClient, fetchTopology(kubeCfg), diagnose.Config{
+		Namespace: cliCtx.String(flagDiagnoseNamespace),
+		Selector:  cliCtx.String(flagDiagnoseSelector),
+		LogLines:  cliCtx.Int64(flagDiagnoseLogLines),
+	})
+
+	if err = collector.Collect(ctx, file); err != nil {
+		return fmt.Errorf("collect diagnostic bundle: %w", err)
+	}
+
+	if err = file.Close(); err != nil {
+		return fmt.Errorf("close output file: %w", err)
+	}
+
+	log.Info().Str("path", path).Msg("Diagnostic bundle written")
+
+	return nil
+}
+
+// fetchTopology returns a function building the topology of the cluster, as the controller does.
+func fetchTopology(kubeCfg *rest.Config) diagnose.TopologyFunc {
+	return func(ctx context.Context) (*state.Cluster, error) {
+		fetcher, err := state.NewFetcherWithKubeConfig(ctx, kubeCfg, "", state.FetcherConfig{})
+		if err != nil {
+			return nil, fmt.Errorf("create topology fetcher: %w", err)
+		}
+
+		return fetcher.FetchState()
+	}
+}
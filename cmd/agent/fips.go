@@ -0,0 +1,48 @@
+/*
+Copyright (C) 2022 Traefik Labs
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package main
+
+import (
+	"github.com/ettle/strcase"
+	"github.com/rs/zerolog/log"
+	"github.com/traefik/hub-agent-kubernetes/pkg/fips"
+	"github.com/urfave/cli/v2"
+)
+
+const flagFIPS = "fips"
+
+func fipsFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.BoolFlag{
+			Name:    flagFIPS,
+			Usage:   "Restrict the cryptography of access control policies to FIPS-approved algorithms, rejecting the non-compliant ones",
+			EnvVars: []string{strcase.ToSNAKE(flagFIPS)},
+		},
+	}
+}
+
+// setupFIPS enables FIPS mode if requested. It is always enabled in binaries built with the "fips" build tag.
+func setupFIPS(cliCtx *cli.Context) {
+	if cliCtx.Bool(flagFIPS) {
+		fips.Enable()
+	}
+
+	if fips.Enabled() {
+		log.Info().Msg("FIPS mode enabled")
+	}
+}
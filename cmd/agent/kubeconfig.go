@@ -0,0 +1,60 @@
+/*
+Copyright (C) 2022 Traefik Labs
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/ettle/strcase"
+	"github.com/traefik/hub-agent-kubernetes/pkg/kube"
+	"github.com/urfave/cli/v2"
+	"k8s.io/client-go/rest"
+)
+
+const flagKubeContext = "kube-context"
+
+func kubeconfigFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:    flagKubeconfig,
+			Usage:   "Path of the kubeconfig file of the cluster to manage, when the agent doesn't run in this cluster",
+			EnvVars: []string{strcase.ToSNAKE(flagKubeconfig)},
+		},
+		&cli.StringFlag{
+			Name:    flagKubeContext,
+			Usage:   "The kubeconfig context to use (defaults to the current context)",
+			EnvVars: []string{strcase.ToSNAKE(flagKubeContext)},
+		},
+	}
+}
+
+// kubeConfig returns the configuration of the Kubernetes cluster managed by the agent: the cluster described by the
+// kubeconfig file if one is given, the cluster the agent runs in otherwise.
+func kubeConfig(cliCtx *cli.Context) (*rest.Config, error) {
+	path := cliCtx.String(flagKubeconfig)
+	if path == "" {
+		return kube.InClusterConfigWithRetrier(2)
+	}
+
+	cfg, err := kube.KubeConfigWithRetrier(path, cliCtx.String(flagKubeContext), 2)
+	if err != nil {
+		return nil, fmt.Errorf("create Kubernetes configuration from %q: %w", path, err)
+	}
+
+	return cfg, nil
+}
@@ -0,0 +1,142 @@
+/*
+Copyright (C) 2022 Traefik Labs
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/ettle/strcase"
+	"github.com/traefik/hub-agent-kubernetes/pkg/kube"
+	"github.com/urfave/cli/v2"
+	"golang.org/x/sync/errgroup"
+	clientset "k8s.io/client-go/kubernetes"
+)
+
+const (
+	flagLeaderElection              = "leader-election"
+	flagLeaderElectionLeaseName     = "leader-election.lease-name"
+	flagLeaderElectionLeaseDuration = "leader-election.lease-duration"
+	flagLeaderElectionRenewDeadline = "leader-election.renew-deadline"
+	flagLeaderElectionRetryPeriod   = "leader-election.retry-period"
+)
+
+func leaderElectionFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.BoolFlag{
+			Name:    flagLeaderElection,
+			Usage:   "Elect a leader among the controller replicas to run the watchers and the platform writers, required to run more than one replica",
+			EnvVars: []string{strcase.ToSNAKE(flagLeaderElection)},
+		},
+		&cli.StringFlag{
+			Name:    flagLeaderElectionLeaseName,
+			Usage:   "The name of the Lease held by the leader, in the agent namespace",
+			EnvVars: []string{strcase.ToSNAKE(flagLeaderElectionLeaseName)},
+			Value:   "hub-agent-controller",
+		},
+		&cli.DurationFlag{
+			Name:    flagLeaderElectionLeaseDuration,
+			Usage:   "The time replicas wait before taking over a leader which stopped renewing its Lease",
+			EnvVars: []string{strcase.ToSNAKE(flagLeaderElectionLeaseDuration)},
+			Value:   15 * time.Second,
+		},
+		&cli.DurationFlag{
+			Name:    flagLeaderElectionRenewDeadline,
+			Usage:   "The time during which the leader retries renewing its Lease before giving up the leadership",
+			EnvVars: []string{strcase.ToSNAKE(flagLeaderElectionRenewDeadline)},
+			Value:   10 * time.Second,
+		},
+		&cli.DurationFlag{
+			Name:    flagLeaderElectionRetryPeriod,
+			Usage:   "The interval between attempts to acquire or renew the Lease",
+			EnvVars: []string{strcase.ToSNAKE(flagLeaderElectionRetryPeriod)},
+			Value:   2 * time.Second,
+		},
+	}
+}
+
+// leaderTasks holds the tasks which must only run on the leader replica, as they write to the cluster or to the
+// platform. Tasks added before the replica leads are started once it does.
+type leaderTasks struct {
+	mu      sync.Mutex
+	pending []func(ctx context.Context) error
+	group   *errgroup.Group
+	ctx     context.Context
+}
+
+// Go runs the given task once the replica leads, right away if it already does.
+func (l *leaderTasks) Go(task func(ctx context.Context) error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.group == nil {
+		l.pending = append(l.pending, task)
+		return
+	}
+
+	ctx := l.ctx
+	l.group.Go(func() error {
+		return task(ctx)
+	})
+}
+
+// lead runs the tasks until ctx is done or one of them fails.
+func (l *leaderTasks) lead(ctx context.Context) error {
+	group, groupCtx := errgroup.WithContext(ctx)
+
+	l.mu.Lock()
+	l.group, l.ctx = group, groupCtx
+	for _, task := range l.pending {
+		task := task
+		group.Go(func() error {
+			return task(groupCtx)
+		})
+	}
+	l.pending = nil
+	l.mu.Unlock()
+
+	return group.Wait()
+}
+
+// runLeaderTasks runs the leader tasks once the replica is elected leader, or right away if leader election is
+// disabled.
+func runLeaderTasks(ctx context.Context, cliCtx *cli.Context, kubeClient clientset.Interface, tasks *leaderTasks) error {
+	if !cliCtx.Bool(flagLeaderElection) {
+		return tasks.lead(ctx)
+	}
+
+	// The hostname of a container is the name of its Pod.
+	identity, err := os.Hostname()
+	if err != nil {
+		return fmt.Errorf("get leader election identity: %w", err)
+	}
+
+	cfg := kube.LeaderElectionConfig{
+		LeaseName:      cliCtx.String(flagLeaderElectionLeaseName),
+		LeaseNamespace: currentNamespace(),
+		Identity:       identity,
+		LeaseDuration:  cliCtx.Duration(flagLeaderElectionLeaseDuration),
+		RenewDeadline:  cliCtx.Duration(flagLeaderElectionRenewDeadline),
+		RetryPeriod:    cliCtx.Duration(flagLeaderElectionRetryPeriod),
+	}
+
+	return kube.RunLeaderElection(ctx, kubeClient, cfg, tasks.lead)
+}
@@ -0,0 +1,51 @@
+/*
+Copyright (C) 2022 Traefik Labs
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package main
+
+import (
+	"sync"
+
+	"github.com/rs/zerolog/log"
+	"github.com/traefik/hub-agent-kubernetes/pkg/logger"
+	"github.com/traefik/hub-agent-kubernetes/pkg/platform"
+)
+
+// watchLoggingConfig applies the logging configuration given by the platform, and the following changes of it. The
+// platform configuration is the only source of changes: the `refresh-config` command (SIGHUP) only makes the agent
+// fetch it right away.
+func watchLoggingConfig(cfg platform.LoggingConfig, cfgWatcher *platform.ConfigWatcher) {
+	if err := logger.Update(cfg.Level, cfg.Format); err != nil {
+		log.Error().Err(err).Msg("Unable to apply logging configuration")
+	}
+
+	var mu sync.Mutex
+	current := cfg
+	cfgWatcher.AddListener(func(cfg platform.Config) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if cfg.Logging == current {
+			return
+		}
+		current = cfg.Logging
+
+		if err := logger.Update(cfg.Logging.Level, cfg.Logging.Format); err != nil {
+			log.Error().Err(err).Msg("Unable to apply logging configuration")
+		}
+	})
+}
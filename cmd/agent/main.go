@@ -25,6 +25,7 @@ import (
 
 	"github.com/ettle/strcase"
 	"github.com/rs/zerolog/log"
+	"github.com/traefik/hub-agent-kubernetes/pkg/configfile"
 	"github.com/traefik/hub-agent-kubernetes/pkg/version"
 	"github.com/urfave/cli/v2"
 )
@@ -47,10 +48,12 @@ func run() error {
 		Usage:   "Manages a Traefik Hub agent installation",
 		Version: version.String(),
 		Commands: []*cli.Command{
-			newControllerCmd().build(),
-			newAuthServerCmd().build(),
+			configfile.Wrap(newControllerCmd().build()),
+			configfile.Wrap(newAuthServerCmd().build()),
 			newRefreshConfigCmd().build(),
-			newTunnelCmd().build(),
+			configfile.Wrap(newTunnelCmd().build()),
+			newTopologyCmd().build(),
+			newDiagnoseCmd().build(),
 			newVersionCmd().build(),
 		},
 	}
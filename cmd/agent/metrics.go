@@ -18,6 +18,8 @@ along with this program. If not, see <https://www.gnu.org/licenses/>.
 package main
 
 import (
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/hashicorp/go-retryablehttp"
@@ -26,9 +28,69 @@ import (
 	"github.com/traefik/hub-agent-kubernetes/pkg/metrics"
 	"github.com/traefik/hub-agent-kubernetes/pkg/platform"
 	"github.com/traefik/hub-agent-kubernetes/pkg/topology"
+	"github.com/urfave/cli/v2"
+	ktypes "k8s.io/apimachinery/pkg/types"
+	clientset "k8s.io/client-go/kubernetes"
 )
 
-func newMetrics(watch *topology.Watcher, token, platformURL, traefikURL string, cfg platform.MetricsConfig, cfgWatcher *platform.ConfigWatcher) (*metrics.Manager, *metrics.Store, error) {
+// metricsOTLPConfig holds the configuration of the OTLP export of metrics.
+type metricsOTLPConfig struct {
+	URL     string
+	Headers map[string]string
+	Only    bool
+}
+
+func otlpConfig(cliCtx *cli.Context) (metricsOTLPConfig, error) {
+	cfg := metricsOTLPConfig{
+		URL:  cliCtx.String(flagMetricsOTLPURL),
+		Only: cliCtx.Bool(flagMetricsOTLPOnly),
+	}
+
+	if cfg.Only && cfg.URL == "" {
+		return metricsOTLPConfig{}, fmt.Errorf("%s requires %s to be set", flagMetricsOTLPOnly, flagMetricsOTLPURL)
+	}
+
+	headers, err := parseOTLPHeaders(flagMetricsOTLPHeader, cliCtx.StringSlice(flagMetricsOTLPHeader))
+	if err != nil {
+		return metricsOTLPConfig{}, err
+	}
+	cfg.Headers = headers
+
+	return cfg, nil
+}
+
+// cardinalityLimit returns the limit of data point groups sent, given by flags.
+func cardinalityLimit(cliCtx *cli.Context) (metrics.CardinalityLimit, error) {
+	limit := metrics.CardinalityLimit{
+		MaxGroups: cliCtx.Int(flagMetricsMaxGroups),
+		Strategy:  metrics.CardinalityStrategy(cliCtx.String(flagMetricsCardinalityStrategy)),
+	}
+
+	if limit.MaxGroups < 0 {
+		return metrics.CardinalityLimit{}, fmt.Errorf("invalid %s %d, must be positive", flagMetricsMaxGroups, limit.MaxGroups)
+	}
+	if err := limit.Strategy.Validate(); err != nil {
+		return metrics.CardinalityLimit{}, fmt.Errorf("invalid %s: %w", flagMetricsCardinalityStrategy, err)
+	}
+
+	return limit, nil
+}
+
+// parseOTLPHeaders parses the headers given to the given flag, formatted as name=value.
+func parseOTLPHeaders(flag string, values []string) (map[string]string, error) {
+	headers := make(map[string]string)
+	for _, header := range values {
+		parts := strings.SplitN(header, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, fmt.Errorf("invalid %s %q, must be formatted as name=value", flag, header)
+		}
+		headers[parts[0]] = parts[1]
+	}
+
+	return headers, nil
+}
+
+func newMetrics(kubeClient clientset.Interface, watch *topology.Watcher, token, platformURL, traefikURL string, cfg platform.MetricsConfig, otlpCfg metricsOTLPConfig, limit metrics.CardinalityLimit, cfgWatcher *platform.ConfigWatcher) (*metrics.Manager, *metrics.Store, error) {
 	rc := retryablehttp.NewClient()
 	rc.RetryWaitMin = time.Second
 	rc.RetryWaitMax = 10 * time.Second
@@ -49,12 +111,59 @@ func newMetrics(watch *topology.Watcher, token, platformURL, traefikURL string,
 	mgr := metrics.NewManager(client, traefikURL, store, scraper)
 
 	mgr.SetConfig(cfg.Interval, cfg.Tables)
+	mgr.SetCardinalityLimit(limit)
+
+	credentials := metrics.NewSecretCredentials(kubeClient)
+	credentials.SetConfig(scrapeAuths(cfg.ScrapeAuth))
+	mgr.SetCredentialsProvider(credentials)
+
+	if otlpCfg.URL != "" {
+		exporter, err := metrics.NewOTLPExporter(httpClient, otlpCfg.URL, otlpCfg.Headers)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		mgr.SetOTLPExporter(exporter, otlpCfg.Only)
+	}
 
 	watch.AddListener(mgr.TopologyStateChanged)
 
 	cfgWatcher.AddListener(func(cfg platform.Config) {
 		mgr.SetConfig(cfg.Metrics.Interval, cfg.Metrics.Tables)
+		credentials.SetConfig(scrapeAuths(cfg.Metrics.ScrapeAuth))
 	})
 
 	return mgr, store, nil
 }
+
+func scrapeAuths(cfgs []platform.ScrapeAuthConfig) []metrics.ScrapeAuth {
+	auths := make([]metrics.ScrapeAuth, 0, len(cfgs))
+	for _, cfg := range cfgs {
+		auth := metrics.ScrapeAuth{
+			IngressControllerType: cfg.IngressControllerType,
+			IngressController:     cfg.IngressController,
+			BearerToken:           secretKey(cfg.BearerToken),
+			CA:                    secretKey(cfg.CA),
+			ServerName:            cfg.ServerName,
+			InsecureSkipVerify:    cfg.InsecureSkipVerify,
+		}
+		if cfg.ClientCertSecret != nil {
+			auth.ClientCertSecret = &ktypes.NamespacedName{
+				Namespace: cfg.ClientCertSecret.Namespace,
+				Name:      cfg.ClientCertSecret.Name,
+			}
+		}
+
+		auths = append(auths, auth)
+	}
+
+	return auths
+}
+
+func secretKey(ref *platform.SecretKeyRef) *metrics.SecretKey {
+	if ref == nil {
+		return nil
+	}
+
+	return &metrics.SecretKey{Namespace: ref.Namespace, Name: ref.Name, Key: ref.Key}
+}
@@ -0,0 +1,53 @@
+/*
+Copyright (C) 2022 Traefik Labs
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package main
+
+import (
+	"github.com/ettle/strcase"
+	"github.com/urfave/cli/v2"
+)
+
+const flagWatchNamespaces = "watch-namespaces"
+
+func watchNamespacesFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringSliceFlag{
+			Name:    flagWatchNamespaces,
+			Usage:   "Namespaces the agent is restricted to, so it only requires namespace-scoped permissions on namespaced resources (all namespaces if empty)",
+			EnvVars: []string{strcase.ToSNAKE(flagWatchNamespaces)},
+		},
+	}
+}
+
+// watchNamespaces returns the namespaces the agent is restricted to, or nil if it watches all of them.
+// The agent namespace is always watched, as the resources generated by the agent are created in it.
+func watchNamespaces(cliCtx *cli.Context) []string {
+	namespaces := cliCtx.StringSlice(flagWatchNamespaces)
+	if len(namespaces) == 0 {
+		return nil
+	}
+
+	agentNamespace := currentNamespace()
+	for _, namespace := range namespaces {
+		if namespace == agentNamespace {
+			return namespaces
+		}
+	}
+
+	return append(namespaces, agentNamespace)
+}
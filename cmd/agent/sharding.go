@@ -0,0 +1,84 @@
+/*
+Copyright (C) 2022 Traefik Labs
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/ettle/strcase"
+	"github.com/traefik/hub-agent-kubernetes/pkg/shard"
+	"github.com/urfave/cli/v2"
+)
+
+const (
+	flagSharding              = "sharding"
+	flagShardingLeaseDuration = "sharding.lease-duration"
+	flagShardingRenewInterval = "sharding.renew-interval"
+)
+
+func shardingFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.BoolFlag{
+			Name:    flagSharding,
+			Usage:   "Spread the EdgeIngress reconciliation across the controller replicas, by namespace (the topology is still collected by the leader only)",
+			EnvVars: []string{strcase.ToSNAKE(flagSharding)},
+		},
+		&cli.DurationFlag{
+			Name:    flagShardingLeaseDuration,
+			Usage:   "The time after which the namespaces of a replica which stopped renewing its Lease are taken over",
+			EnvVars: []string{strcase.ToSNAKE(flagShardingLeaseDuration)},
+			Value:   30 * time.Second,
+		},
+		&cli.DurationFlag{
+			Name:    flagShardingRenewInterval,
+			Usage:   "The interval at which replicas renew their Lease and refresh the namespaces they own",
+			EnvVars: []string{strcase.ToSNAKE(flagShardingRenewInterval)},
+			Value:   10 * time.Second,
+		},
+	}
+}
+
+// shardConfig returns the shard group configuration of the replica, or nil if sharding is disabled.
+func shardConfig(cliCtx *cli.Context) (*shard.Config, error) {
+	if !cliCtx.Bool(flagSharding) {
+		return nil, nil
+	}
+
+	leaseDuration := cliCtx.Duration(flagShardingLeaseDuration)
+	renewInterval := cliCtx.Duration(flagShardingRenewInterval)
+	if renewInterval <= 0 || renewInterval >= leaseDuration {
+		return nil, errors.New("sharding renew interval must be positive and lower than the lease duration")
+	}
+
+	// The hostname of a container is the name of its Pod.
+	identity, err := os.Hostname()
+	if err != nil {
+		return nil, fmt.Errorf("get shard identity: %w", err)
+	}
+
+	return &shard.Config{
+		Group:         "hub-agent-controller",
+		Namespace:     currentNamespace(),
+		Identity:      identity,
+		LeaseDuration: leaseDuration,
+		RenewInterval: renewInterval,
+	}, nil
+}
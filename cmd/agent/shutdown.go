@@ -0,0 +1,38 @@
+/*
+Copyright (C) 2022 Traefik Labs
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package main
+
+import (
+	"time"
+
+	"github.com/ettle/strcase"
+	"github.com/urfave/cli/v2"
+)
+
+const flagShutdownGracePeriod = "shutdown.grace-period"
+
+func shutdownFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.DurationFlag{
+			Name:    flagShutdownGracePeriod,
+			Usage:   "The time given on shutdown to drain in-flight requests and streams and to flush pending writes, should be lower than the Pod termination grace period",
+			EnvVars: []string{strcase.ToSNAKE(flagShutdownGracePeriod)},
+			Value:   25 * time.Second,
+		},
+	}
+}
@@ -19,17 +19,67 @@ package main
 
 import (
 	"context"
+	"fmt"
+	"os"
 
+	"github.com/rs/zerolog/log"
+	"github.com/traefik/hub-agent-kubernetes/pkg/kube"
 	"github.com/traefik/hub-agent-kubernetes/pkg/topology"
+	"github.com/traefik/hub-agent-kubernetes/pkg/topology/s3store"
 	"github.com/traefik/hub-agent-kubernetes/pkg/topology/state"
 	"github.com/traefik/hub-agent-kubernetes/pkg/topology/store"
+	corev1 "k8s.io/api/core/v1"
+	clientset "k8s.io/client-go/kubernetes"
 )
 
-func newTopologyWatcher(ctx context.Context, fetcher *state.Fetcher, storeCfg store.Config) (*topology.Watcher, error) {
+func newTopologyWatcher(ctx context.Context, kubeClient clientset.Interface, fetcher *state.Fetcher, storeCfg store.Config, s3Cfg s3store.Config, watcherCfg topology.WatcherConfig) (*topology.Watcher, error) {
+	recorder := kube.NewEventRecorder(kubeClient, "hub-agent-controller")
+	agentRef := agentPodRef()
+
+	storeCfg.Recorder = recorder
+	storeCfg.AgentRef = agentRef
+
 	s, err := store.New(ctx, storeCfg)
 	if err != nil {
 		return nil, err
 	}
 
-	return topology.NewWatcher(fetcher, s), nil
+	watcherCfg.Recorder = recorder
+	watcherCfg.AgentRef = agentRef
+
+	watcher := topology.NewWatcher(fetcher, s, watcherCfg)
+
+	notifier := topology.NewChangeNotifier(recorder)
+	watcher.AddListener(notifier.TopologyStateChanged)
+
+	if s3Cfg.Bucket != "" {
+		s3Store, err := s3store.New(s3Cfg)
+		if err != nil {
+			return nil, fmt.Errorf("create S3 topology store: %w", err)
+		}
+
+		watcher.AddListener(func(ctx context.Context, cluster *state.Cluster) {
+			if err := s3Store.Write(ctx, cluster); err != nil {
+				log.Error().Err(err).Msg("Unable to write topology to S3")
+			}
+		})
+	}
+
+	return watcher, nil
+}
+
+// agentPodRef returns a reference to the Pod the agent is running in, to attach events about the agent itself.
+func agentPodRef() *corev1.ObjectReference {
+	// The hostname of a container is the name of its Pod.
+	name, err := os.Hostname()
+	if err != nil {
+		log.Warn().Err(err).Msg("Unable to get agent Pod name")
+		return nil
+	}
+
+	return &corev1.ObjectReference{
+		Kind:      "Pod",
+		Name:      name,
+		Namespace: currentNamespace(),
+	}
 }
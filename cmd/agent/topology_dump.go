@@ -0,0 +1,130 @@
+/*
+Copyright (C) 2022 Traefik Labs
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/ettle/strcase"
+	"github.com/traefik/hub-agent-kubernetes/pkg/kube"
+	"github.com/traefik/hub-agent-kubernetes/pkg/logger"
+	"github.com/traefik/hub-agent-kubernetes/pkg/topology/state"
+	"github.com/urfave/cli/v2"
+)
+
+const (
+	flagKubeconfig = "kubeconfig"
+	flagClusterID  = "cluster-id"
+	flagOutput     = "output"
+	flagTimeout    = "timeout"
+)
+
+type topologyCmd struct {
+	dumpFlags []cli.Flag
+}
+
+func newTopologyCmd() topologyCmd {
+	flgs := []cli.Flag{
+		&cli.StringFlag{
+			Name:  flagKubeconfig,
+			Usage: "Path of the kubeconfig file to use (defaults to $KUBECONFIG or ~/.kube/config)",
+		},
+		&cli.StringFlag{
+			Name:    flagClusterID,
+			Usage:   "The cluster ID to set in the topology",
+			EnvVars: []string{strcase.ToSNAKE(flagClusterID)},
+		},
+		&cli.StringFlag{
+			Name:    flagOutput,
+			Aliases: []string{"o"},
+			Usage:   "Path of the file to write the topology to (defaults to stdout)",
+		},
+		&cli.DurationFlag{
+			Name:  flagTimeout,
+			Usage: "Maximum time to wait for the topology to be built",
+			Value: time.Minute,
+		},
+	}
+
+	flgs = append(flgs, globalFlags()...)
+
+	return topologyCmd{
+		dumpFlags: flgs,
+	}
+}
+
+func (c topologyCmd) build() *cli.Command {
+	return &cli.Command{
+		Name:  "topology",
+		Usage: "Inspects the topology collected by the Hub agent",
+		Subcommands: []*cli.Command{
+			{
+				Name:   "dump",
+				Usage:  "Builds the cluster topology from the kubeconfig and writes it without contacting the Hub platform",
+				Flags:  c.dumpFlags,
+				Action: c.dump,
+			},
+		},
+	}
+}
+
+func (c topologyCmd) dump(cliCtx *cli.Context) error {
+	logger.Setup(cliCtx.String(flagLogLevel), cliCtx.String(flagLogFormat))
+
+	kubeCfg, err := kube.LoadKubeConfig(cliCtx.String(flagKubeconfig), "")
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(cliCtx.Context, cliCtx.Duration(flagTimeout))
+	defer cancel()
+
+	fetcher, err := state.NewFetcherWithKubeConfig(ctx, kubeCfg, cliCtx.String(flagClusterID), state.FetcherConfig{})
+	if err != nil {
+		return fmt.Errorf("create topology fetcher: %w", err)
+	}
+
+	cluster, err := fetcher.FetchState()
+	if err != nil {
+		return fmt.Errorf("fetch topology: %w", err)
+	}
+
+	var out io.Writer = cliCtx.App.Writer
+	if path := cliCtx.String(flagOutput); path != "" {
+		file, err := os.Create(path)
+		if err != nil {
+			return fmt.Errorf("create output file: %w", err)
+		}
+		defer func() { _ = file.Close() }()
+
+		out = file
+	}
+
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "\t")
+	if err = enc.Encode(cluster); err != nil {
+		return fmt.Errorf("write topology: %w", err)
+	}
+
+	return nil
+}
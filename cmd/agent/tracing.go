@@ -0,0 +1,90 @@
+/*
+Copyright (C) 2022 Traefik Labs
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/ettle/strcase"
+	"github.com/rs/zerolog/log"
+	"github.com/traefik/hub-agent-kubernetes/pkg/tracing"
+	"github.com/urfave/cli/v2"
+)
+
+const (
+	flagTracingOTLPURL     = "tracing.otlp-url"
+	flagTracingOTLPHeader  = "tracing.otlp-header"
+	flagTracingSampleRatio = "tracing.sample-ratio"
+)
+
+func tracingFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:    flagTracingOTLPURL,
+			Usage:   "The URL of an OpenTelemetry collector to which export traces of admission reviews and ACP decisions using OTLP/HTTP (disabled if empty)",
+			EnvVars: []string{strcase.ToSNAKE(flagTracingOTLPURL)},
+		},
+		&cli.StringSliceFlag{
+			Name:    flagTracingOTLPHeader,
+			Usage:   "A header, formatted as name=value, to add to OTLP trace export requests",
+			EnvVars: []string{strcase.ToSNAKE(flagTracingOTLPHeader)},
+		},
+		&cli.Float64Flag{
+			Name:    flagTracingSampleRatio,
+			Usage:   "Ratio, between 0 and 1, of the traces recorded when not started by a sampled caller",
+			EnvVars: []string{strcase.ToSNAKE(flagTracingSampleRatio)},
+			Value:   1,
+		},
+	}
+}
+
+// setupTracing sets up the export of traces, if enabled, until the given context is done.
+func setupTracing(ctx context.Context, cliCtx *cli.Context, serviceName string) error {
+	otlpURL := cliCtx.String(flagTracingOTLPURL)
+	if otlpURL == "" {
+		return nil
+	}
+
+	sampleRatio := cliCtx.Float64(flagTracingSampleRatio)
+	if sampleRatio < 0 || sampleRatio > 1 {
+		return fmt.Errorf("invalid %s %v, must be between 0 and 1", flagTracingSampleRatio, sampleRatio)
+	}
+
+	headers, err := parseOTLPHeaders(flagTracingOTLPHeader, cliCtx.StringSlice(flagTracingOTLPHeader))
+	if err != nil {
+		return err
+	}
+
+	// Export requests are not traced themselves.
+	exporter, err := tracing.NewOTLPExporter(&http.Client{Timeout: 10 * time.Second}, otlpURL, headers, serviceName)
+	if err != nil {
+		return fmt.Errorf("create OTLP trace exporter: %w", err)
+	}
+
+	tracer := tracing.NewTracer(exporter, sampleRatio)
+	tracing.SetTracer(tracer)
+
+	go tracer.Run(ctx)
+
+	log.Info().Str("url", otlpURL).Float64("sample_ratio", sampleRatio).Msg("Exporting traces")
+
+	return nil
+}
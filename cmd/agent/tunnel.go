@@ -19,12 +19,17 @@ package main
 
 import (
 	"fmt"
+	"math"
 	"net"
 
 	"github.com/ettle/strcase"
+	"github.com/rs/zerolog/log"
+	"github.com/traefik/hub-agent-kubernetes/pkg/health"
+	"github.com/traefik/hub-agent-kubernetes/pkg/kube"
 	"github.com/traefik/hub-agent-kubernetes/pkg/logger"
 	"github.com/traefik/hub-agent-kubernetes/pkg/tunnel"
 	"github.com/urfave/cli/v2"
+	clientset "k8s.io/client-go/kubernetes"
 )
 
 type tunnelCmd struct {
@@ -32,8 +37,21 @@ type tunnelCmd struct {
 }
 
 const (
-	flagTraefikTunnelHost = "traefik.tunnel-host"
-	flagTraefikTunnelPort = "traefik.tunnel-port"
+	flagTraefikTunnelHost             = "traefik.tunnel-host"
+	flagTraefikTunnelPort             = "traefik.tunnel-port"
+	flagTunnelPoolSize                = "tunnel.pool-size"
+	flagTunnelMaxStreamsPerConnection = "tunnel.max-streams-per-connection"
+	flagTunnelKeepAliveInterval       = "tunnel.keepalive-interval"
+	flagTunnelKeepAliveTimeout        = "tunnel.keepalive-timeout"
+	flagTunnelStreamWindowSize        = "tunnel.stream-window-size"
+	flagTunnelStreamIdleTimeout       = "tunnel.stream-idle-timeout"
+	flagTunnelReconnectMinInterval    = "tunnel.reconnect-min-interval"
+	flagTunnelReconnectMaxInterval    = "tunnel.reconnect-max-interval"
+	flagTunnelMaxOutage               = "tunnel.max-outage"
+	flagTunnelProxyURL                = "tunnel.proxy-url"
+	flagTunnelBrokerProbeInterval     = "tunnel.broker-probe-interval"
+	flagTunnelProxyUsername           = "tunnel.proxy-username"
+	flagTunnelProxyPassword           = "tunnel.proxy-password"
 )
 
 func newTunnelCmd() tunnelCmd {
@@ -64,9 +82,90 @@ func newTunnelCmd() tunnelCmd {
 			Value:    "9901",
 			Required: false,
 		},
+		&cli.IntFlag{
+			Name:    flagTunnelPoolSize,
+			Usage:   "The number of connections opened with the broker for each tunnel, across which the traffic is multiplexed",
+			EnvVars: []string{strcase.ToSNAKE(flagTunnelPoolSize)},
+			Value:   tunnel.DefaultConfig().PoolSize,
+		},
+		&cli.IntFlag{
+			Name:    flagTunnelMaxStreamsPerConnection,
+			Usage:   "The maximum number of streams proxied concurrently on a tunnel connection (0 for no limit)",
+			EnvVars: []string{strcase.ToSNAKE(flagTunnelMaxStreamsPerConnection)},
+		},
+		&cli.DurationFlag{
+			Name:    flagTunnelKeepAliveInterval,
+			Usage:   "The interval at which tunnel connections are pinged to check they are alive",
+			EnvVars: []string{strcase.ToSNAKE(flagTunnelKeepAliveInterval)},
+			Value:   tunnel.DefaultConfig().KeepAliveInterval,
+		},
+		&cli.DurationFlag{
+			Name:    flagTunnelKeepAliveTimeout,
+			Usage:   "The time after which a tunnel connection not answering a ping is considered dead and replaced",
+			EnvVars: []string{strcase.ToSNAKE(flagTunnelKeepAliveTimeout)},
+			Value:   tunnel.DefaultConfig().KeepAliveTimeout,
+		},
+		&cli.UintFlag{
+			Name:    flagTunnelStreamWindowSize,
+			Usage:   "The maximum receive window of a tunnel stream, in bytes, bounding the data buffered for a slow stream",
+			EnvVars: []string{strcase.ToSNAKE(flagTunnelStreamWindowSize)},
+			Value:   uint(tunnel.DefaultConfig().StreamWindowSize),
+		},
+		&cli.DurationFlag{
+			Name:    flagTunnelStreamIdleTimeout,
+			Usage:   "The time after which a tunnel stream with no traffic is closed (0 for no timeout)",
+			EnvVars: []string{strcase.ToSNAKE(flagTunnelStreamIdleTimeout)},
+			Value:   tunnel.DefaultConfig().StreamIdleTimeout,
+		},
+		&cli.DurationFlag{
+			Name:    flagTunnelReconnectMinInterval,
+			Usage:   "The initial interval between tunnel reconnection attempts, growing exponentially with jitter",
+			EnvVars: []string{strcase.ToSNAKE(flagTunnelReconnectMinInterval)},
+			Value:   tunnel.DefaultConfig().ReconnectInitialInterval,
+		},
+		&cli.DurationFlag{
+			Name:    flagTunnelReconnectMaxInterval,
+			Usage:   "The maximum interval between tunnel reconnection attempts",
+			EnvVars: []string{strcase.ToSNAKE(flagTunnelReconnectMaxInterval)},
+			Value:   tunnel.DefaultConfig().ReconnectMaxInterval,
+		},
+		&cli.DurationFlag{
+			Name:    flagTunnelMaxOutage,
+			Usage:   "The time a tunnel may stay disconnected before a warning event is reported",
+			EnvVars: []string{strcase.ToSNAKE(flagTunnelMaxOutage)},
+			Value:   tunnel.DefaultConfig().MaxOutage,
+		},
+		&cli.DurationFlag{
+			Name:    flagTunnelBrokerProbeInterval,
+			Usage:   "The interval at which the latency to the tunnel brokers is measured to switch to a faster one (0 to disable)",
+			EnvVars: []string{strcase.ToSNAKE(flagTunnelBrokerProbeInterval)},
+			Value:   tunnel.DefaultConfig().BrokerProbeInterval,
+		},
+		&cli.StringFlag{
+			Name:    flagTunnelProxyURL,
+			Usage:   "The URL of the HTTP(S) proxy through which tunnels reach the brokers (read from HTTPS_PROXY, HTTP_PROXY and NO_PROXY if empty)",
+			EnvVars: []string{strcase.ToSNAKE(flagTunnelProxyURL)},
+		},
+		&cli.StringFlag{
+			Name:    flagTunnelProxyUsername,
+			Usage:   "The username used to authenticate on the tunnel proxy",
+			EnvVars: []string{strcase.ToSNAKE(flagTunnelProxyUsername)},
+		},
+		&cli.StringFlag{
+			Name:    flagTunnelProxyPassword,
+			Usage:   "The password used to authenticate on the tunnel proxy",
+			EnvVars: []string{strcase.ToSNAKE(flagTunnelProxyPassword)},
+		},
+		&cli.StringFlag{
+			Name:    flagMetricsListenAddr,
+			Usage:   "The address on which the tunnel exposes its own Prometheus metrics and readiness (disabled if empty)",
+			EnvVars: []string{strcase.ToSNAKE(flagMetricsListenAddr)},
+		},
 	}
 
 	flags = append(flags, globalFlags()...)
+	flags = append(flags, shutdownFlags()...)
+	flags = append(flags, kubeconfigFlags()...)
 
 	return tunnelCmd{
 		flags: flags,
@@ -95,8 +194,57 @@ func (c tunnelCmd) run(cliCtx *cli.Context) error {
 		return fmt.Errorf("create tunnel client: %w", err)
 	}
 
+	streamWindowSize := cliCtx.Uint(flagTunnelStreamWindowSize)
+	if uint64(streamWindowSize) > math.MaxUint32 {
+		return fmt.Errorf("invalid stream window size %d, must be at most %d", streamWindowSize, uint32(math.MaxUint32))
+	}
+
+	tunnelCfg := tunnel.Config{
+		PoolSize:                 cliCtx.Int(flagTunnelPoolSize),
+		MaxStreamsPerConnection:  cliCtx.Int(flagTunnelMaxStreamsPerConnection),
+		KeepAliveInterval:        cliCtx.Duration(flagTunnelKeepAliveInterval),
+		KeepAliveTimeout:         cliCtx.Duration(flagTunnelKeepAliveTimeout),
+		StreamWindowSize:         uint32(streamWindowSize),
+		StreamIdleTimeout:        cliCtx.Duration(flagTunnelStreamIdleTimeout),
+		DrainTimeout:             cliCtx.Duration(flagShutdownGracePeriod),
+		ReconnectInitialInterval: cliCtx.Duration(flagTunnelReconnectMinInterval),
+		ReconnectMaxInterval:     cliCtx.Duration(flagTunnelReconnectMaxInterval),
+		MaxOutage:                cliCtx.Duration(flagTunnelMaxOutage),
+		BrokerProbeInterval:      cliCtx.Duration(flagTunnelBrokerProbeInterval),
+		Proxy: tunnel.ProxyConfig{
+			URL:      cliCtx.String(flagTunnelProxyURL),
+			Username: cliCtx.String(flagTunnelProxyUsername),
+			Password: cliCtx.String(flagTunnelProxyPassword),
+		},
+	}
+	if err = tunnelCfg.Validate(); err != nil {
+		return fmt.Errorf("invalid tunnel configuration: %w", err)
+	}
+
+	// Events are best effort: the tunnel doesn't need to reach the Kubernetes API to run.
+	if kubeCfg, kubeErr := kubeConfig(cliCtx); kubeErr != nil {
+		log.Warn().Err(kubeErr).Msg("Unable to create Kubernetes configuration, tunnel events are disabled")
+	} else if kubeClient, kubeErr := clientset.NewForConfig(kubeCfg); kubeErr != nil {
+		log.Warn().Err(kubeErr).Msg("Unable to create Kubernetes client set, tunnel events are disabled")
+	} else {
+		tunnelCfg.Recorder = kube.NewEventRecorder(kubeClient, "hub-agent-tunnel")
+		tunnelCfg.AgentRef = agentPodRef()
+	}
+
 	traefikAddr := net.JoinHostPort(cliCtx.String(flagTraefikTunnelHost), cliCtx.String(flagTraefikTunnelPort))
-	tunnelManager := tunnel.NewManager(tunnelClient, traefikAddr, token)
+	tunnelManager := tunnel.NewManager(tunnelClient, traefikAddr, token, tunnelCfg)
+
+	if listenAddr := cliCtx.String(flagMetricsListenAddr); listenAddr != "" {
+		readiness := health.NewRegistry()
+		readiness.Register("tunnel", tunnelManager.Ready)
+
+		go func() {
+			if err := runAgentMetricsServer(ctx, listenAddr, readiness); err != nil {
+				log.Error().Err(err).Msg("Unable to run tunnel metrics server")
+			}
+		}()
+	}
+
 	tunnelManager.Run(ctx)
 
 	return nil
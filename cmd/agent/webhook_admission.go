@@ -19,6 +19,7 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	stdlog "log"
@@ -36,20 +37,28 @@ import (
 	"github.com/traefik/hub-agent-kubernetes/pkg/acp/admission"
 	"github.com/traefik/hub-agent-kubernetes/pkg/acp/admission/ingclass"
 	"github.com/traefik/hub-agent-kubernetes/pkg/acp/admission/reviewer"
+	hubv1alpha1 "github.com/traefik/hub-agent-kubernetes/pkg/crd/api/hub/v1alpha1"
+	"github.com/traefik/hub-agent-kubernetes/pkg/crd/conversion"
 	hubclientset "github.com/traefik/hub-agent-kubernetes/pkg/crd/generated/client/hub/clientset/versioned"
 	hubinformer "github.com/traefik/hub-agent-kubernetes/pkg/crd/generated/client/hub/informers/externalversions"
 	traefikclientset "github.com/traefik/hub-agent-kubernetes/pkg/crd/generated/client/traefik/clientset/versioned"
 	"github.com/traefik/hub-agent-kubernetes/pkg/edgeingress"
 	edgeadmission "github.com/traefik/hub-agent-kubernetes/pkg/edgeingress/admission"
+	"github.com/traefik/hub-agent-kubernetes/pkg/health"
 	"github.com/traefik/hub-agent-kubernetes/pkg/kube"
 	"github.com/traefik/hub-agent-kubernetes/pkg/kubevers"
 	"github.com/traefik/hub-agent-kubernetes/pkg/platform"
+	"github.com/traefik/hub-agent-kubernetes/pkg/shard"
+	"github.com/traefik/hub-agent-kubernetes/pkg/tunnel"
 	"github.com/urfave/cli/v2"
+	corev1 "k8s.io/api/core/v1"
 	netv1 "k8s.io/api/networking/v1"
 	kerror "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ktypes "k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/informers"
 	clientset "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/cache"
 )
 
@@ -60,6 +69,11 @@ const (
 	flagACPServerAuthServerAddr = "acp-server.auth-server-addr"
 	flagIngressClassName        = "ingress-class-name"
 	flagTraefikEntryPoint       = "traefik.entryPoint"
+	flagUseIngressRoute         = "edge-ingress.use-ingress-route"
+	flagCertificateNamespaces   = "edge-ingress.certificate-namespaces"
+	flagFallbackDomain          = "edge-ingress.fallback.domain"
+	flagFallbackEntryPoint      = "edge-ingress.fallback.entry-point"
+	flagFallbackThreshold       = "edge-ingress.fallback.threshold"
 )
 
 func acpFlags() []cli.Flag {
@@ -99,10 +113,37 @@ func acpFlags() []cli.Flag {
 			EnvVars: []string{strcase.ToSNAKE(flagTraefikEntryPoint)},
 			Value:   "traefikhub-tunl",
 		},
+		&cli.BoolFlag{
+			Name:    flagUseIngressRoute,
+			Usage:   "Expose EdgeIngresses using Traefik IngressRoutes instead of Kubernetes Ingresses",
+			EnvVars: []string{strcase.ToSNAKE(flagUseIngressRoute)},
+		},
+		&cli.StringSliceFlag{
+			Name:    flagCertificateNamespaces,
+			Usage:   "Additional namespaces the wildcard certificate Secret is replicated to",
+			EnvVars: []string{strcase.ToSNAKE(flagCertificateNamespaces)},
+		},
+		&cli.StringFlag{
+			Name:    flagFallbackDomain,
+			Usage:   "Local domain EdgeIngresses are exposed on while the platform or the tunnel is unavailable, disabled if empty",
+			EnvVars: []string{strcase.ToSNAKE(flagFallbackDomain)},
+		},
+		&cli.StringFlag{
+			Name:    flagFallbackEntryPoint,
+			Usage:   "The Traefik entry point EdgeIngresses are exposed on while the platform or the tunnel is unavailable",
+			EnvVars: []string{strcase.ToSNAKE(flagFallbackEntryPoint)},
+			Value:   "websecure",
+		},
+		&cli.DurationFlag{
+			Name:    flagFallbackThreshold,
+			Usage:   "How long the platform or the tunnel must be unavailable before exposing EdgeIngresses on the fallback domain",
+			EnvVars: []string{strcase.ToSNAKE(flagFallbackThreshold)},
+			Value:   5 * time.Minute,
+		},
 	}
 }
 
-func webhookAdmission(ctx context.Context, cliCtx *cli.Context, platformClient *platform.Client) error {
+func webhookAdmission(ctx context.Context, cliCtx *cli.Context, kubeCfg *rest.Config, platformClient *platform.Client, trafficView edgeingress.TrafficView, edgeIngressCfg platform.EdgeIngressConfig, cfgWatcher *platform.ConfigWatcher, readiness *health.Registry, leader *leaderTasks) error {
 	var (
 		listenAddr     = cliCtx.String(flagACPServerListenAddr)
 		certFile       = cliCtx.String(flagACPServerCertificate)
@@ -116,17 +157,38 @@ func webhookAdmission(ctx context.Context, cliCtx *cli.Context, platformClient *
 
 	ingressClassName := cliCtx.String(flagIngressClassName)
 	traefikEntryPoint := cliCtx.String(flagTraefikEntryPoint)
-	acpAdmission, edgeIngressAdmission, err := setupAdmissionHandlers(ctx, platformClient, authServerAddr, ingressClassName, traefikEntryPoint)
+	useIngressRoute := cliCtx.Bool(flagUseIngressRoute)
+	certNamespaces := cliCtx.StringSlice(flagCertificateNamespaces)
+	namespaces := watchNamespaces(cliCtx)
+	fallbackCfg := edgeingress.FallbackConfig{
+		Domain:     cliCtx.String(flagFallbackDomain),
+		EntryPoint: cliCtx.String(flagFallbackEntryPoint),
+		Threshold:  cliCtx.Duration(flagFallbackThreshold),
+	}
+
+	shardCfg, err := shardConfig(cliCtx)
 	if err != nil {
+		return fmt.Errorf("configure sharding: %w", err)
+	}
+
+	var admissionStatus health.Status
+	readiness.Register("admission", admissionStatus.Check)
+
+	acpAdmission, edgeIngressAdmission, edgeIngressQuotaAdmission, err := setupAdmissionHandlers(ctx, kubeCfg, platformClient, cliCtx.String(flagPlatformURL), cliCtx.String(flagToken), authServerAddr, ingressClassName, traefikEntryPoint, useIngressRoute, certNamespaces, namespaces, trafficView, edgeIngressCfg, cfgWatcher, fallbackCfg, shardCfg, readiness, leader)
+	if err != nil {
+		admissionStatus.Set(fmt.Errorf("create admission handler: %w", err))
 		return fmt.Errorf("create admission handler: %w", err)
 	}
 
 	webAdmissionACP := admission.NewACPHandler(platformClient)
 
 	router := chi.NewRouter()
-	router.Handle("/edge-ingress", edgeIngressAdmission)
-	router.Handle("/ingress", acpAdmission)
-	router.Handle("/acp", webAdmissionACP)
+	router.Handle("/edge-ingress", instrumentAdmission("edge-ingress", edgeIngressAdmission))
+	router.Handle("/edge-ingress-quota", instrumentAdmission("edge-ingress-quota", edgeIngressQuotaAdmission))
+	router.Handle("/ingress", instrumentAdmission("ingress", acpAdmission))
+	router.Handle("/acp", instrumentAdmission("acp", webAdmissionACP))
+	router.Handle("/conversion", instrumentAdmission("conversion", conversion.NewHandler()))
+	router.Handle("/readyz", readiness)
 
 	server := &http.Server{
 		Addr:     listenAddr,
@@ -137,15 +199,18 @@ func webhookAdmission(ctx context.Context, cliCtx *cli.Context, platformClient *
 
 	go func() {
 		log.Info().Str("addr", listenAddr).Msg("Starting admission server")
+		admissionStatus.SetReady()
 		if err = server.ListenAndServeTLS(certFile, keyFile); !errors.Is(err, http.ErrServerClosed) {
 			log.Err(err).Msg("Unable to listen and serve admission requests")
+			admissionStatus.Set(errors.New("admission server stopped"))
 		}
 		close(srvDone)
 	}()
 
 	select {
 	case <-ctx.Done():
-		gracefulCtx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+		// New admission reviews are refused while the in-flight ones are drained.
+		gracefulCtx, cancel := context.WithTimeout(context.Background(), cliCtx.Duration(flagShutdownGracePeriod))
 		defer cancel()
 
 		if err = server.Shutdown(gracefulCtx); err != nil {
@@ -162,115 +227,248 @@ func webhookAdmission(ctx context.Context, cliCtx *cli.Context, platformClient *
 	return nil
 }
 
-func setupAdmissionHandlers(ctx context.Context, platformClient *platform.Client, authServerAddr, ingressClassName, traefikEntryPoint string) (acpHdl, edgeIngressHdl http.Handler, err error) {
-	config, err := kube.InClusterConfigWithRetrier(2)
+func setupAdmissionHandlers(ctx context.Context, config *rest.Config, platformClient *platform.Client, platformURL, token, authServerAddr, ingressClassName, traefikEntryPoint string, useIngressRoute bool, certNamespaces, namespaces []string, trafficView edgeingress.TrafficView, edgeIngressCfg platform.EdgeIngressConfig, cfgWatcher *platform.ConfigWatcher, fallbackCfg edgeingress.FallbackConfig, shardCfg *shard.Config, readiness *health.Registry, leader *leaderTasks) (acpHdl, edgeIngressHdl, edgeIngressQuotaHdl http.Handler, err error) {
+	clientSet, err := clientset.NewForConfig(config)
 	if err != nil {
-		return nil, nil, fmt.Errorf("create Kubernetes in-cluster configuration: %w", err)
+		return nil, nil, nil, fmt.Errorf("create Kubernetes client set: %w", err)
 	}
 
-	clientSet, err := clientset.NewForConfig(config)
+	kubeVers, err := clientSet.Discovery().ServerVersion()
 	if err != nil {
-		return nil, nil, fmt.Errorf("create Kubernetes client set: %w", err)
+		return nil, nil, nil, fmt.Errorf("detect Kubernetes version: %w", err)
+	}
+
+	if !kubevers.IsSupported(kubeVers.GitVersion) {
+		return nil, nil, nil, fmt.Errorf("unsupported Kubernetes version %s, the minimum supported version is %s", kubeVers.GitVersion, kubevers.MinVersion)
 	}
 
 	if ingressClassName == "" {
 		ingressClassName = "traefik-hub"
-		if err = initIngressClass(ctx, clientSet, ingressClassName); err != nil {
-			return nil, nil, fmt.Errorf("initatilize ingressClass: %w", err)
+
+		// IngressClasses are cluster-scoped, it must be created beforehand when restricted to some namespaces.
+		if len(namespaces) == 0 {
+			if err = initIngressClass(ctx, clientSet, kubeVers.GitVersion, ingressClassName); err != nil {
+				return nil, nil, nil, fmt.Errorf("initatilize ingressClass: %w", err)
+			}
 		}
 	}
 
 	hubClientSet, err := hubclientset.NewForConfig(config)
 	if err != nil {
-		return nil, nil, fmt.Errorf("create Hub client set: %w", err)
-	}
-
-	kubeVers, err := clientSet.Discovery().ServerVersion()
-	if err != nil {
-		return nil, nil, fmt.Errorf("detect Kubernetes version: %w", err)
+		return nil, nil, nil, fmt.Errorf("create Hub client set: %w", err)
 	}
 
 	kubeInformer := informers.NewSharedInformerFactory(clientSet, 5*time.Minute)
 	hubInformer := hubinformer.NewSharedInformerFactory(hubClientSet, 5*time.Minute)
+	if len(namespaces) > 0 {
+		restrictAdmissionInformers(namespaces, clientSet, hubClientSet, kubeInformer, hubInformer)
+	}
 
-	ingressUpdater := admission.NewIngressUpdater(kubeInformer, clientSet, kubeVers.GitVersion)
-
-	go ingressUpdater.Run(ctx)
-
+	ingressUpdater := admission.NewIngressUpdater(kubeInformer, clientSet)
 	acpEventHandler := admission.NewEventHandler(ingressUpdater)
 	ingClassWatcher := ingclass.NewWatcher()
 
-	err = startKubeInformer(ctx, kubeVers.GitVersion, kubeInformer, ingClassWatcher)
+	err = startKubeInformer(ctx, kubeInformer, ingClassWatcher)
 	if err != nil {
-		return nil, nil, fmt.Errorf("start kube informer: %w", err)
+		return nil, nil, nil, fmt.Errorf("start kube informer: %w", err)
 	}
 
 	hubInformer.Hub().V1alpha1().IngressClasses().Informer().AddEventHandler(ingClassWatcher)
-	hubInformer.Hub().V1alpha1().AccessControlPolicies().Informer().AddEventHandler(acpEventHandler)
+	hubInformer.Hub().V1alpha1().AccessControlPolicies().Informer()
 	hubInformer.Hub().V1alpha1().EdgeIngresses().Informer()
 
 	hubInformer.Start(ctx.Done())
 
 	for t, ok := range hubInformer.WaitForCacheSync(ctx.Done()) {
 		if !ok {
-			return nil, nil, fmt.Errorf("wait for Hub informer cache sync: %s: %w", t, ctx.Err())
+			return nil, nil, nil, fmt.Errorf("wait for Hub informer cache sync: %s: %w", t, ctx.Err())
 		}
 	}
 
+	hubInformers := map[string]cache.SharedIndexInformer{
+		"ingressclasses":        hubInformer.Hub().V1alpha1().IngressClasses().Informer(),
+		"accesscontrolpolicies": hubInformer.Hub().V1alpha1().AccessControlPolicies().Informer(),
+		"edgeingresses":         hubInformer.Hub().V1alpha1().EdgeIngresses().Informer(),
+	}
+	for resource, informer := range hubInformers {
+		if err = registerInformerCacheSize(resource, informer); err != nil {
+			return nil, nil, nil, fmt.Errorf("register %s informer cache size metric: %w", resource, err)
+		}
+	}
+
+	// Ingresses are updated on ACP changes by the leader only. The updater must run before the handler is registered,
+	// as it blocks until the updater receives the change.
+	leader.Go(func(ctx context.Context) error {
+		go ingressUpdater.Run(ctx)
+		hubInformer.Hub().V1alpha1().AccessControlPolicies().Informer().AddEventHandler(acpEventHandler)
+
+		<-ctx.Done()
+		return nil
+	})
+
 	acpWatcher := acp.NewWatcher(time.Minute, platformClient, hubClientSet, hubInformer)
-	go func() {
+	leader.Go(func(ctx context.Context) error {
 		acpWatcher.Run(ctx)
-	}()
+		return nil
+	})
 
 	traefikClientSet, err := traefikclientset.NewForConfig(config)
 	if err != nil {
-		return nil, nil, fmt.Errorf("create Traefik client set: %w", err)
+		return nil, nil, nil, fmt.Errorf("create Traefik client set: %w", err)
+	}
+
+	polGetter := reviewer.NewPolGetter(hubInformer)
+
+	fwdAuthMdlwrs := reviewer.NewFwdAuthMiddlewares(authServerAddr, polGetter, traefikClientSet.TraefikV1alpha1())
+
+	tunnelClient, err := tunnel.NewClient(platformURL, token)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("create tunnel client: %w", err)
 	}
 
+	recorder := kube.NewEventRecorder(clientSet, "hub-agent-controller")
+
 	watcherCfg := edgeingress.WatcherConfig{
 		IngressClassName:        ingressClassName,
 		TraefikEntryPoint:       traefikEntryPoint,
+		UseIngressRoute:         useIngressRoute,
 		AgentNamespace:          currentNamespace(),
 		EdgeIngressSyncInterval: time.Minute,
 		CertRetryInterval:       time.Minute,
 		CertSyncInterval:        time.Hour,
+		FwdAuthMiddlewares:      fwdAuthMdlwrs,
+		AuthServerAddr:          authServerAddr,
+		Tunnels:                 tunnelClient,
+		Recorder:                recorder,
+		Fallback:                fallbackCfg,
+		Namespaces:              namespaces,
+	}
+
+	// When sharding, EdgeIngresses are reconciled by every replica for the namespaces it owns instead of by the leader.
+	runEdgeIngressWatcher := leader.Go
+	if shardCfg != nil {
+		membership := shard.NewMembership(clientSet, *shardCfg)
+		go membership.Run(ctx)
+
+		watcherCfg.Shard = membership
+		runEdgeIngressWatcher = func(task func(ctx context.Context) error) {
+			go func() {
+				if err := task(ctx); err != nil {
+					log.Error().Err(err).Msg("Unable to run the edge ingress watcher")
+				}
+			}()
+		}
 	}
+
 	edgeIngressWatcher, err := edgeingress.NewWatcher(platformClient, hubClientSet, clientSet, traefikClientSet.TraefikV1alpha1(), hubInformer, watcherCfg)
 	if err != nil {
-		return nil, nil, fmt.Errorf("create edge ingress watcher: %w", err)
+		return nil, nil, nil, fmt.Errorf("create edge ingress watcher: %w", err)
 	}
-	go func() {
-		edgeIngressWatcher.Run(ctx)
-	}()
-
-	polGetter := reviewer.NewPolGetter(hubInformer)
+	runEdgeIngressWatcher(func(ctx context.Context) error {
+		if err := startGeneratedResourcesInformer(ctx, clientSet, namespaces, edgeIngressWatcher); err != nil {
+			return fmt.Errorf("start generated resources informer: %w", err)
+		}
 
-	fwdAuthMdlwrs := reviewer.NewFwdAuthMiddlewares(authServerAddr, polGetter, traefikClientSet.TraefikV1alpha1())
+		readiness.Register("edge-ingresses", edgeIngressWatcher.Ready)
+		edgeIngressWatcher.Run(ctx)
+		return nil
+	})
+
+	certRenewer := edgeingress.NewCertRenewer(platformClient, clientSet, edgeingress.CertRenewerConfig{
+		AgentNamespace: currentNamespace(),
+		Namespaces:     certNamespaces,
+		RenewBefore:    30 * 24 * time.Hour,
+		CheckInterval:  time.Hour,
+		RetryInterval:  time.Minute,
+		Recorder:       recorder,
+	})
+	leader.Go(func(ctx context.Context) error {
+		certRenewer.Run(ctx)
+		return nil
+	})
+
+	// Traffic can only be reported when the ingress controller metrics are scraped.
+	if trafficView != nil {
+		trafficReporter := edgeingress.NewTrafficReporter(trafficView, hubClientSet, edgeingress.TrafficReporterConfig{
+			Window:     10 * time.Minute,
+			Interval:   time.Minute,
+			Namespaces: namespaces,
+		})
+		leader.Go(func(ctx context.Context) error {
+			trafficReporter.Run(ctx)
+			return nil
+		})
+	}
 
 	reviewers := []admission.Reviewer{
 		reviewer.NewTraefikIngress(ingClassWatcher, fwdAuthMdlwrs),
+		reviewer.NewTraefikIngressRoute(fwdAuthMdlwrs),
+	}
+
+	domainCache := platform.NewDomainCache(platformClient, 5*time.Minute)
+	if err = domainCache.WarmUp(ctx); err != nil {
+		log.Error().Err(err).Msg("Unable to list verified domains")
+	}
+	go domainCache.Run(ctx)
+
+	quotas := edgeadmission.NewQuotas(hubInformer.Hub().V1alpha1().EdgeIngresses().Lister(), edgeIngressCfg)
+	cfgWatcher.AddListener(func(cfg platform.Config) {
+		quotas.SetConfig(cfg.EdgeIngress)
+	})
+
+	return admission.NewHandler(reviewers), edgeadmission.NewHandler(platformClient, domainCache, quotas), edgeadmission.NewQuotaHandler(quotas), nil
+}
+
+func startKubeInformer(ctx context.Context, kubeInformer informers.SharedInformerFactory, ingClassEventHandler cache.ResourceEventHandler) error {
+	kubeInformer.Networking().V1().IngressClasses().Informer().AddEventHandler(ingClassEventHandler)
+	kubeInformer.Networking().V1().Ingresses().Informer()
+
+	kubeInformer.Start(ctx.Done())
+
+	for t, ok := range kubeInformer.WaitForCacheSync(ctx.Done()) {
+		if !ok {
+			return fmt.Errorf("wait for cache Kubernetes sync: %s: %w", t, ctx.Err())
+		}
 	}
 
-	return admission.NewHandler(reviewers), edgeadmission.NewHandler(platformClient), nil
+	return nil
+}
+
+// restrictAdmissionInformers makes the informer factories watch the Ingresses and EdgeIngresses of the given
+// namespaces only.
+func restrictAdmissionInformers(namespaces []string, clientSet clientset.Interface, hubClientSet hubclientset.Interface, kubeInformer informers.SharedInformerFactory, hubInformer hubinformer.SharedInformerFactory) {
+	kubeInformer.InformerFor(&netv1.Ingress{}, func(_ clientset.Interface, resync time.Duration) cache.SharedIndexInformer {
+		return kube.NewNamespacedInformer(clientSet.NetworkingV1().RESTClient(), "ingresses", &netv1.Ingress{}, namespaces, resync, nil)
+	})
+
+	hubInformer.InformerFor(&hubv1alpha1.EdgeIngress{}, func(_ hubclientset.Interface, resync time.Duration) cache.SharedIndexInformer {
+		return kube.NewNamespacedInformer(hubClientSet.HubV1alpha1().RESTClient(), "edgeingresses", &hubv1alpha1.EdgeIngress{}, namespaces, resync, nil)
+	})
 }
 
-func startKubeInformer(ctx context.Context, kubeVers string, kubeInformer informers.SharedInformerFactory, ingClassEventHandler cache.ResourceEventHandler) error {
-	if kubevers.SupportsNetV1IngressClasses(kubeVers) {
-		kubeInformer.Networking().V1().IngressClasses().Informer().AddEventHandler(ingClassEventHandler)
-	} else if kubevers.SupportsNetV1Beta1IngressClasses(kubeVers) {
-		kubeInformer.Networking().V1beta1().IngressClasses().Informer().AddEventHandler(ingClassEventHandler)
+// startGeneratedResourcesInformer watches the Ingresses and Secrets generated by the agent, so the edge ingress
+// watcher can revert the changes made to them. When namespaces is not empty, only those namespaces are watched.
+func startGeneratedResourcesInformer(ctx context.Context, clientSet clientset.Interface, namespaces []string, eventHandler cache.ResourceEventHandler) error {
+	managedByHub := func(opts *metav1.ListOptions) {
+		opts.LabelSelector = "app.kubernetes.io/managed-by=traefik-hub"
 	}
 
-	if kubevers.SupportsNetV1Ingresses(kubeVers) {
-		kubeInformer.Networking().V1().Ingresses().Informer()
-	} else {
-		// Since we only support Kubernetes v1.14 and up, we should always at least have net v1beta1 Ingresses.
-		kubeInformer.Networking().V1beta1().Ingresses().Informer()
+	informer := informers.NewSharedInformerFactoryWithOptions(clientSet, 5*time.Minute, informers.WithTweakListOptions(managedByHub))
+	if len(namespaces) > 0 {
+		informer.InformerFor(&netv1.Ingress{}, func(_ clientset.Interface, resync time.Duration) cache.SharedIndexInformer {
+			return kube.NewNamespacedInformer(clientSet.NetworkingV1().RESTClient(), "ingresses", &netv1.Ingress{}, namespaces, resync, managedByHub)
+		})
+		informer.InformerFor(&corev1.Secret{}, func(_ clientset.Interface, resync time.Duration) cache.SharedIndexInformer {
+			return kube.NewNamespacedInformer(clientSet.CoreV1().RESTClient(), "secrets", &corev1.Secret{}, namespaces, resync, managedByHub)
+		})
 	}
 
-	kubeInformer.Start(ctx.Done())
+	informer.Networking().V1().Ingresses().Informer().AddEventHandler(eventHandler)
+	informer.Core().V1().Secrets().Informer().AddEventHandler(eventHandler)
 
-	for t, ok := range kubeInformer.WaitForCacheSync(ctx.Done()) {
+	informer.Start(ctx.Done())
+
+	for t, ok := range informer.WaitForCacheSync(ctx.Done()) {
 		if !ok {
 			return fmt.Errorf("wait for cache Kubernetes sync: %s: %w", t, ctx.Err())
 		}
@@ -279,8 +477,12 @@ func startKubeInformer(ctx context.Context, kubeVers string, kubeInformer inform
 	return nil
 }
 
-func initIngressClass(ctx context.Context, clientSet clientset.Interface, ingressClassName string) error {
+func initIngressClass(ctx context.Context, clientSet clientset.Interface, kubeVers, ingressClassName string) error {
 	ic := &netv1.IngressClass{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: netv1.SchemeGroupVersion.String(),
+			Kind:       "IngressClass",
+		},
 		ObjectMeta: metav1.ObjectMeta{
 			Name: ingressClassName,
 		},
@@ -288,6 +490,20 @@ func initIngressClass(ctx context.Context, clientSet clientset.Interface, ingres
 			Controller: "traefik.io/ingress-controller",
 		},
 	}
+
+	// Server-side apply lets the agent own the IngressClass fields it sets, even if the IngressClass already exists.
+	if kubevers.SupportsServerSideApply(kubeVers) {
+		data, err := json.Marshal(ic)
+		if err != nil {
+			return fmt.Errorf("encode IngressClass: %w", err)
+		}
+
+		force := true
+		_, err = clientSet.NetworkingV1().IngressClasses().Patch(ctx, ingressClassName, ktypes.ApplyPatchType, data, metav1.PatchOptions{FieldManager: "hub-agent", Force: &force})
+
+		return err
+	}
+
 	if _, err := clientSet.NetworkingV1().IngressClasses().Create(ctx, ic, metav1.CreateOptions{}); err != nil {
 		if !kerror.IsAlreadyExists(err) {
 			return err
@@ -25,12 +25,10 @@ import (
 	"github.com/rs/zerolog/log"
 	hubv1alpha1 "github.com/traefik/hub-agent-kubernetes/pkg/crd/api/hub/v1alpha1"
 	netv1 "k8s.io/api/networking/v1"
-	netv1beta1 "k8s.io/api/networking/v1beta1"
 	ktypes "k8s.io/apimachinery/pkg/types"
 )
 
-// ingressClass is an internal representation of either a netv1.IngressClass,
-// netv1beta1.IngressClass or a hubv1alpha1.IngressClass.
+// ingressClass is an internal representation of either a netv1.IngressClass or a hubv1alpha1.IngressClass.
 type ingressClass struct {
 	Name       string
 	Controller string
@@ -46,7 +44,7 @@ const (
 
 // Watcher watches for IngressClass resources, maintaining a local cache of these resources,
 // updated as they are created, modified or deleted.
-// It watches for netv1.IngressClass, netv1beta1.IngressClass and hubv1alpha1.IngressClass.
+// It watches for netv1.IngressClass and hubv1alpha1.IngressClass.
 type Watcher struct {
 	mu             sync.RWMutex
 	ingressClasses map[ktypes.UID]ingressClass
@@ -77,8 +75,6 @@ func (w *Watcher) OnDelete(obj interface{}) {
 	switch v := obj.(type) {
 	case *netv1.IngressClass:
 		delete(w.ingressClasses, v.ObjectMeta.UID)
-	case *netv1beta1.IngressClass:
-		delete(w.ingressClasses, v.ObjectMeta.UID)
 	case *hubv1alpha1.IngressClass:
 		delete(w.ingressClasses, v.ObjectMeta.UID)
 	default:
@@ -100,12 +96,6 @@ func (w *Watcher) upsert(obj interface{}) {
 			Controller: v.Spec.Controller,
 			IsDefault:  v.ObjectMeta.Annotations[annotationDefaultIngressClass] == "true",
 		}
-	case *netv1beta1.IngressClass:
-		w.ingressClasses[v.ObjectMeta.UID] = ingressClass{
-			Name:       v.ObjectMeta.Name,
-			Controller: v.Spec.Controller,
-			IsDefault:  v.ObjectMeta.Annotations[annotationDefaultIngressClass] == "true",
-		}
 	case *hubv1alpha1.IngressClass:
 		w.ingressClasses[v.ObjectMeta.UID] = ingressClass{
 			Name:       v.ObjectMeta.Name,
@@ -30,7 +30,6 @@ import (
 	hubkubemock "github.com/traefik/hub-agent-kubernetes/pkg/crd/generated/client/hub/clientset/versioned/fake"
 	hubinformer "github.com/traefik/hub-agent-kubernetes/pkg/crd/generated/client/hub/informers/externalversions"
 	netv1 "k8s.io/api/networking/v1"
-	netv1beta1 "k8s.io/api/networking/v1beta1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/informers"
@@ -41,7 +40,6 @@ import (
 func setupEnv(clientSet clientset.Interface, hubClientSet hubclientset.Interface, watcher *Watcher) error {
 	kubeInformer := informers.NewSharedInformerFactoryWithOptions(clientSet, 5*time.Minute)
 	kubeInformer.Networking().V1().IngressClasses().Informer().AddEventHandler(watcher)
-	kubeInformer.Networking().V1beta1().IngressClasses().Informer().AddEventHandler(watcher)
 
 	ctx := context.Background()
 	syncCtx, c := context.WithTimeout(ctx, 5*time.Second)
@@ -75,26 +73,20 @@ func TestWatcher_GetController(t *testing.T) {
 			Controller: ControllerTypeTraefik,
 		},
 	}
-	legacyIng := netv1beta1.IngressClass{
-		ObjectMeta: metav1.ObjectMeta{UID: "2", Name: "ing-class-2"},
-		Spec: netv1beta1.IngressClassSpec{
-			Controller: ControllerTypeTraefik,
-		},
-	}
 	customIng := hubv1alpha1.IngressClass{
-		ObjectMeta: metav1.ObjectMeta{UID: "3", Name: "ing-class-3"},
+		ObjectMeta: metav1.ObjectMeta{UID: "2", Name: "ing-class-2"},
 		Spec: hubv1alpha1.IngressClassSpec{
 			Controller: ControllerTypeTraefik,
 		},
 	}
-	clientSet := kubemock.NewSimpleClientset(&ing, &legacyIng)
+	clientSet := kubemock.NewSimpleClientset(&ing)
 	hubClientSet := hubkubemock.NewSimpleClientset(&customIng)
 	watcher := NewWatcher()
 
 	err := setupEnv(clientSet, hubClientSet, watcher)
 	require.NoError(t, err)
 
-	err = waitForIngressClasses(watcher, 3)
+	err = waitForIngressClasses(watcher, 2)
 	require.NoError(t, err)
 
 	ctrlr, err := watcher.GetController("ing-class-1")
@@ -103,15 +95,10 @@ func TestWatcher_GetController(t *testing.T) {
 	ctrlr, err = watcher.GetController("ing-class-2")
 	assert.NoError(t, err)
 	assert.Equal(t, ControllerTypeTraefik, ctrlr)
-	ctrlr, err = watcher.GetController("ing-class-3")
-	assert.NoError(t, err)
-	assert.Equal(t, ControllerTypeTraefik, ctrlr)
 
 	err = clientSet.NetworkingV1().IngressClasses().Delete(context.Background(), "ing-class-1", metav1.DeleteOptions{})
 	require.NoError(t, err)
-	err = clientSet.NetworkingV1beta1().IngressClasses().Delete(context.Background(), "ing-class-2", metav1.DeleteOptions{})
-	require.NoError(t, err)
-	err = hubClientSet.HubV1alpha1().IngressClasses().Delete(context.Background(), "ing-class-3", metav1.DeleteOptions{})
+	err = hubClientSet.HubV1alpha1().IngressClasses().Delete(context.Background(), "ing-class-2", metav1.DeleteOptions{})
 	require.NoError(t, err)
 
 	err = waitForIngressClasses(watcher, 0)
@@ -123,16 +110,12 @@ func TestWatcher_GetController(t *testing.T) {
 	ctrlr, err = watcher.GetController("ing-class-2")
 	assert.Error(t, err)
 	assert.Equal(t, "", ctrlr)
-	ctrlr, err = watcher.GetController("ing-class-3")
-	assert.Error(t, err)
-	assert.Equal(t, "", ctrlr)
 }
 
 func TestWatcher_GetDefaultController(t *testing.T) {
 	tests := []struct {
 		desc           string
 		ingClass       bool
-		legacyIngClass bool
 		customIngClass bool
 		wantErr        bool
 	}{
@@ -141,11 +124,6 @@ func TestWatcher_GetDefaultController(t *testing.T) {
 			ingClass: true,
 			wantErr:  false,
 		},
-		{
-			desc:           "handles a single legacy IngressClass flagged as default",
-			legacyIngClass: true,
-			wantErr:        false,
-		},
 		{
 			desc:           "handles a single custom IngressClass flagged as default",
 			customIngClass: true,
@@ -154,7 +132,7 @@ func TestWatcher_GetDefaultController(t *testing.T) {
 		{
 			desc:           "fails if there more than one IngressClass is flagged as default",
 			ingClass:       true,
-			legacyIngClass: true,
+			customIngClass: true,
 			wantErr:        true,
 		},
 	}
@@ -178,16 +156,6 @@ func TestWatcher_GetDefaultController(t *testing.T) {
 					Spec: netv1.IngressClassSpec{Controller: ControllerTypeTraefik},
 				})
 			}
-			if test.legacyIngClass {
-				resources = append(resources, &netv1beta1.IngressClass{
-					ObjectMeta: metav1.ObjectMeta{
-						UID:         "2",
-						Name:        "ing-class-2",
-						Annotations: map[string]string{annotationDefaultIngressClass: "true"},
-					},
-					Spec: netv1beta1.IngressClassSpec{Controller: ControllerTypeTraefik},
-				})
-			}
 			if test.customIngClass {
 				customResources = append(customResources, &hubv1alpha1.IngressClass{
 					ObjectMeta: metav1.ObjectMeta{
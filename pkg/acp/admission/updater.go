@@ -23,7 +23,6 @@ import (
 
 	"github.com/rs/zerolog/log"
 	"github.com/traefik/hub-agent-kubernetes/pkg/acp/admission/reviewer"
-	"github.com/traefik/hub-agent-kubernetes/pkg/kubevers"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/client-go/informers"
@@ -38,18 +37,15 @@ type IngressUpdater struct {
 	cancelUpd map[string]context.CancelFunc
 
 	polNameCh chan string
-
-	supportsNetV1Ingresses bool
 }
 
 // NewIngressUpdater return a new IngressUpdater.
-func NewIngressUpdater(informer informers.SharedInformerFactory, clientSet clientset.Interface, kubeVersion string) *IngressUpdater {
+func NewIngressUpdater(informer informers.SharedInformerFactory, clientSet clientset.Interface) *IngressUpdater {
 	return &IngressUpdater{
-		informer:               informer,
-		clientSet:              clientSet,
-		cancelUpd:              map[string]context.CancelFunc{},
-		polNameCh:              make(chan string),
-		supportsNetV1Ingresses: kubevers.SupportsNetV1Ingresses(kubeVersion),
+		informer:  informer,
+		clientSet: clientSet,
+		cancelUpd: map[string]context.CancelFunc{},
+		polNameCh: make(chan string),
 	}
 }
 
@@ -86,14 +82,6 @@ func (u *IngressUpdater) Update(polName string) {
 }
 
 func (u *IngressUpdater) updateIngresses(ctx context.Context, polName string) error {
-	if !u.supportsNetV1Ingresses {
-		return u.updateV1beta1Ingresses(ctx, polName)
-	}
-
-	return u.updateV1Ingresses(ctx, polName)
-}
-
-func (u *IngressUpdater) updateV1Ingresses(ctx context.Context, polName string) error {
 	ingList, err := u.informer.Networking().V1().Ingresses().Lister().List(labels.Everything())
 	if err != nil {
 		return fmt.Errorf("list ingresses: %w", err)
@@ -128,42 +116,6 @@ func (u *IngressUpdater) updateV1Ingresses(ctx context.Context, polName string)
 	return nil
 }
 
-func (u *IngressUpdater) updateV1beta1Ingresses(ctx context.Context, polName string) error {
-	// As the minimum supported version is 1.14, we don't need to support the extension group.
-	ingList, err := u.informer.Networking().V1beta1().Ingresses().Lister().List(labels.Everything())
-	if err != nil {
-		return fmt.Errorf("list legacy ingresses: %w", err)
-	}
-
-	log.Debug().Int("ingress_number", len(ingList)).Msg("Updating legacy ingresses")
-
-	for _, ing := range ingList {
-		// Don't continue if the context was canceled to prevent being spammed
-		// with context canceled errors on every request we would send otherwise.
-		select {
-		case <-ctx.Done():
-			return nil
-		default:
-		}
-
-		ok := shouldUpdate(ing.Annotations[reviewer.AnnotationHubAuth], polName)
-		if err != nil {
-			log.Error().Err(err).Str("ingress_name", ing.Name).Str("ingress_namespace", ing.Namespace).Msg("Unable to determine if legacy ingress should be updated")
-			continue
-		}
-		if !ok {
-			continue
-		}
-
-		_, err = u.clientSet.NetworkingV1beta1().Ingresses(ing.Namespace).Update(ctx, ing, metav1.UpdateOptions{FieldManager: "hub-auth"})
-		if err != nil {
-			log.Error().Err(err).Str("ingress_name", ing.Name).Str("ingress_namespace", ing.Namespace).Msg("Unable to update legacy ingress")
-			continue
-		}
-	}
-	return nil
-}
-
 func shouldUpdate(hubAuthAnno, polName string) bool {
 	if hubAuthAnno == "" {
 		return false
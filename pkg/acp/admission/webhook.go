@@ -26,6 +26,7 @@ import (
 
 	"github.com/rs/zerolog/log"
 	"github.com/traefik/hub-agent-kubernetes/pkg/acp/admission/reviewer"
+	"github.com/traefik/hub-agent-kubernetes/pkg/tracing"
 	admv1 "k8s.io/api/admission/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
@@ -102,11 +103,26 @@ func (h Handler) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
 	}
 }
 
-func (h Handler) review(ctx context.Context, ar admv1.AdmissionReview) ([]byte, error) {
+func (h Handler) review(ctx context.Context, ar admv1.AdmissionReview) (patch []byte, err error) {
+	ctx, span := tracing.Start(ctx, "admission.review")
+	defer func() {
+		var warn *reviewerWarning
+		if !errors.As(err, &warn) {
+			span.RecordError(err)
+		}
+		span.End()
+	}()
+
+	span.SetAttribute("resource.kind", ar.Request.Kind.String())
+	span.SetAttribute("resource.name", ar.Request.Name)
+	span.SetAttribute("resource.namespace", ar.Request.Namespace)
+	span.SetAttribute("operation", string(ar.Request.Operation))
+
 	usesACP, err := isUsingACP(ar)
 	if err != nil {
 		return nil, fmt.Errorf("unable to determine if resource uses ACP: %w", err)
 	}
+	span.SetAttribute("uses_acp", usesACP)
 
 	rev, revErr := findReviewer(h.reviewers, ar)
 	if revErr != nil {
@@ -131,6 +147,8 @@ func (h Handler) review(ctx context.Context, ar admv1.AdmissionReview) ([]byte,
 			ar.Request.Name, ar.Request.Kind, ar.Request.Namespace)
 	}
 
+	span.SetAttribute("reviewer", fmt.Sprintf("%T", rev))
+
 	resourcePatch, err := rev.Review(ctx, ar)
 	if err != nil {
 		return nil, fmt.Errorf("reviewing resource %q of kind %q in namespace %q: %w", ar.Request.Name, ar.Request.Kind, ar.Request.Namespace, err)
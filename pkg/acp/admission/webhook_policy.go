@@ -28,7 +28,9 @@ import (
 	"github.com/rs/zerolog/log"
 	"github.com/traefik/hub-agent-kubernetes/pkg/acp"
 	hubv1alpha1 "github.com/traefik/hub-agent-kubernetes/pkg/crd/api/hub/v1alpha1"
+	"github.com/traefik/hub-agent-kubernetes/pkg/fips"
 	"github.com/traefik/hub-agent-kubernetes/pkg/platform"
+	"github.com/traefik/hub-agent-kubernetes/pkg/tracing"
 	admv1 "k8s.io/api/admission/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
@@ -81,7 +83,17 @@ func (h ACPHandler) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
 	}
 	ctx := l.WithContext(req.Context())
 
+	ctx, span := tracing.Start(ctx, "admission.review")
+	if ar.Request != nil {
+		span.SetAttribute("resource.kind", ar.Request.Kind.String())
+		span.SetAttribute("resource.name", ar.Request.Name)
+		span.SetAttribute("resource.namespace", ar.Request.Namespace)
+		span.SetAttribute("operation", string(ar.Request.Operation))
+	}
+
 	patches, err := h.review(ctx, ar.Request)
+	span.RecordError(err)
+	span.End()
 	if err != nil {
 		log.Ctx(ctx).Error().Err(err).Msg("Unable to handle admission request")
 
@@ -134,6 +146,13 @@ func (h ACPHandler) review(ctx context.Context, req *admv1.AdmissionRequest) ([]
 		}
 	}
 
+	// Policies which can't be enforced by the auth server are rejected upfront.
+	if newACP != nil && fips.Enabled() {
+		if err = acp.CheckFIPS(acp.ConfigFromPolicy(newACP)); err != nil {
+			return nil, fmt.Errorf("FIPS mode: %w", err)
+		}
+	}
+
 	switch req.Operation {
 	case admv1.Create:
 		logger.Info().Msg("Creating AccessControlPolicy resource")
@@ -0,0 +1,85 @@
+/*
+Copyright (C) 2022 Traefik Labs
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package auth
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/traefik/hub-agent-kubernetes/pkg/acp/upstream"
+)
+
+const (
+	metricsNamespace = "hub_agent"
+	metricsSubsystem = "auth_server"
+)
+
+// decisionBuckets are the upper bounds, in seconds, of the decision latency buckets. Decisions are expected to be
+// taken well under a millisecond unless an upstream service is called.
+var decisionBuckets = []float64{0.0001, 0.00025, 0.0005, 0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5}
+
+var (
+	decisionsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Subsystem: metricsSubsystem,
+		Name:      "decisions_total",
+		Help:      "Number of authentication decisions taken, by ACP and status code returned.",
+	}, []string{"acp", "code"})
+	decisionDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Subsystem: metricsSubsystem,
+		Name:      "decision_duration_seconds",
+		Help:      "Latency added by the forward-auth hop to take an authentication decision, upstream calls included, by ACP.",
+		Buckets:   decisionBuckets,
+	}, []string{"acp", "type"})
+	upstreamDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Subsystem: metricsSubsystem,
+		Name:      "upstream_duration_seconds",
+		Help:      "Time spent waiting on upstream services, such as identity providers, by decisions calling them, by ACP.",
+		Buckets:   decisionBuckets,
+	}, []string{"acp", "type"})
+)
+
+func init() {
+	prometheus.MustRegister(decisionsTotal, decisionDuration, upstreamDuration)
+}
+
+// instrumentDecisions records the decisions taken by the handler of the given ACP, and the time taken to take them.
+func instrumentDecisions(acpName, acpType string, next http.Handler) http.Handler {
+	lbls := prometheus.Labels{"acp": acpName, "type": acpType}
+	duration := decisionDuration.With(lbls)
+	upstreamDur := upstreamDuration.With(lbls)
+
+	next = promhttp.InstrumentHandlerCounter(decisionsTotal.MustCurryWith(prometheus.Labels{"acp": acpName}), next)
+
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		ctx, timer := upstream.WithTimer(req.Context())
+
+		start := time.Now()
+		next.ServeHTTP(rw, req.WithContext(ctx))
+		duration.Observe(time.Since(start).Seconds())
+
+		// Only decisions calling upstream services are recorded, not to dilute their latency with the cached ones.
+		if d, calls := timer.Duration(); calls > 0 {
+			upstreamDur.Observe(d.Seconds())
+		}
+	})
+}
@@ -0,0 +1,72 @@
+/*
+Copyright (C) 2022 Traefik Labs
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/traefik/hub-agent-kubernetes/pkg/acp/upstream"
+)
+
+func TestInstrumentDecisions(t *testing.T) {
+	handler := instrumentDecisions("my-acp", "jwt", http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if req.Header.Get("Authorization") == "" {
+			upstream.Observe(req.Context(), 50*time.Millisecond)
+		}
+
+		rw.WriteHeader(http.StatusOK)
+	}))
+
+	for _, authz := range []string{"", "Bearer token", "Bearer token"} {
+		req := httptest.NewRequest(http.MethodGet, "/my-acp", http.NoBody)
+		if authz != "" {
+			req.Header.Set("Authorization", authz)
+		}
+
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	lbls := prometheus.Labels{"acp": "my-acp", "type": "jwt"}
+
+	decisions := histogram(t, decisionDuration.With(lbls))
+	assert.Equal(t, uint64(3), decisions.GetSampleCount())
+	assert.GreaterOrEqual(t, decisions.GetSampleSum(), 0.0)
+
+	upstreams := histogram(t, upstreamDuration.With(lbls))
+	assert.Equal(t, uint64(1), upstreams.GetSampleCount())
+	assert.InDelta(t, 0.05, upstreams.GetSampleSum(), 1e-9)
+}
+
+func histogram(t *testing.T, observer prometheus.Observer) *dto.Histogram {
+	t.Helper()
+
+	metric, ok := observer.(prometheus.Metric)
+	require.True(t, ok)
+
+	var m dto.Metric
+	require.NoError(t, metric.Write(&m))
+
+	return m.GetHistogram()
+}
@@ -0,0 +1,33 @@
+/*
+Copyright (C) 2022 Traefik Labs
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package auth
+
+import (
+	"net/http"
+
+	"github.com/traefik/hub-agent-kubernetes/pkg/tracing"
+)
+
+// traceDecisions traces the decisions taken by the handler of the given ACP.
+func traceDecisions(acpName, acpType string, next http.Handler) http.Handler {
+	return tracing.Handler("acp."+acpType, http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		tracing.FromContext(req.Context()).SetAttribute("acp.name", acpName)
+
+		next.ServeHTTP(rw, req)
+	}))
+}
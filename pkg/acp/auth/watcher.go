@@ -30,6 +30,7 @@ import (
 	"github.com/traefik/hub-agent-kubernetes/pkg/acp/basicauth"
 	"github.com/traefik/hub-agent-kubernetes/pkg/acp/jwt"
 	hubv1alpha1 "github.com/traefik/hub-agent-kubernetes/pkg/crd/api/hub/v1alpha1"
+	"github.com/traefik/hub-agent-kubernetes/pkg/health"
 )
 
 // NOTE: if we use the same watcher for all resources, then we need to restart it when new CRDs are
@@ -47,6 +48,8 @@ type Watcher struct {
 	refresh chan struct{}
 
 	switcher *HTTPHandlerSwitcher
+
+	ready health.Status
 }
 
 // NewWatcher returns a new watcher to track ACP resources. It calls the given Updater when an ACP is modified at most
@@ -59,8 +62,15 @@ func NewWatcher(switcher *HTTPHandlerSwitcher) *Watcher {
 	}
 }
 
+// Ready reports whether the ACP handlers are up-to-date with the access control policies.
+func (w *Watcher) Ready(ctx context.Context) error {
+	return w.ready.Check(ctx)
+}
+
 // Run launches listener if the watcher is dirty.
 func (w *Watcher) Run(ctx context.Context) {
+	w.ready.SetReady()
+
 	for {
 		select {
 		case <-w.refresh:
@@ -85,10 +95,12 @@ func (w *Watcher) Run(ctx context.Context) {
 			routes, err := buildRoutes(cfgs)
 			if err != nil {
 				log.Error().Err(err).Msg("Unable to switch ACP handlers")
+				w.ready.Set(fmt.Errorf("build ACP handlers: %w", err))
 				continue
 			}
 
 			w.switcher.UpdateHandler(routes)
+			w.ready.SetReady()
 
 		case <-ctx.Done():
 			return
@@ -176,7 +188,7 @@ func buildRoutes(cfgs map[string]*acp.Config) (http.Handler, error) {
 
 			log.Debug().Str("acp_name", name).Str("path", path).Msg("Registering JWT ACP handler")
 
-			mux.Handle(path, jwtHandler)
+			mux.Handle(path, instrumentDecisions(name, "jwt", traceDecisions(name, "jwt", jwtHandler)))
 
 		case cfg.BasicAuth != nil:
 			h, err := basicauth.NewHandler(cfg.BasicAuth, name)
@@ -185,7 +197,7 @@ func buildRoutes(cfgs map[string]*acp.Config) (http.Handler, error) {
 			}
 			path := "/" + name
 			log.Debug().Str("acp_name", name).Str("path", path).Msg("Registering basic auth ACP handler")
-			mux.Handle(path, h)
+			mux.Handle(path, instrumentDecisions(name, "basic_auth", traceDecisions(name, "basic_auth", h)))
 
 		default:
 			return nil, errors.New("unknown ACP handler type")
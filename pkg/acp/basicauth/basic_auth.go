@@ -1,16 +1,22 @@
 package basicauth
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"strings"
 
 	goauth "github.com/abbot/go-http-auth"
 	"github.com/rs/zerolog/log"
+	"github.com/traefik/hub-agent-kubernetes/pkg/fips"
 )
 
 const defaultRealm = "hub"
 
+// ErrNotFIPSApproved is returned when creating a handler in FIPS mode, as none of the supported password hashes
+// (bcrypt, MD5 and SHA-1) is FIPS-approved.
+var ErrNotFIPSApproved = errors.New("basic auth password hashes are not FIPS-approved")
+
 // Users holds a list of users.
 type Users []string
 
@@ -33,6 +39,10 @@ type Handler struct {
 
 // NewHandler creates a new basic auth ACP Handler.
 func NewHandler(cfg *Config, name string) (*Handler, error) {
+	if fips.Enabled() {
+		return nil, ErrNotFIPSApproved
+	}
+
 	users, err := getUsers(cfg.Users, basicUserParser)
 	if err != nil {
 		return nil, err
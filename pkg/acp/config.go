@@ -18,6 +18,8 @@ along with this program. If not, see <https://www.gnu.org/licenses/>.
 package acp
 
 import (
+	"fmt"
+
 	"github.com/traefik/hub-agent-kubernetes/pkg/acp/basicauth"
 	"github.com/traefik/hub-agent-kubernetes/pkg/acp/jwt"
 	hubv1alpha1 "github.com/traefik/hub-agent-kubernetes/pkg/crd/api/hub/v1alpha1"
@@ -29,6 +31,23 @@ type Config struct {
 	BasicAuth *basicauth.Config
 }
 
+// CheckFIPS returns an error if the given configuration can't be enforced in FIPS mode.
+func CheckFIPS(cfg *Config) error {
+	switch {
+	case cfg.JWT != nil:
+		if err := jwt.CheckFIPS(cfg.JWT); err != nil {
+			return fmt.Errorf("JWT: %w", err)
+		}
+		return nil
+
+	case cfg.BasicAuth != nil:
+		return basicauth.ErrNotFIPSApproved
+
+	default:
+		return nil
+	}
+}
+
 // ConfigFromPolicy returns an ACP configuration for the given policy.
 func ConfigFromPolicy(policy *hubv1alpha1.AccessControlPolicy) *Config {
 	switch {
@@ -28,6 +28,8 @@ import (
 	"time"
 
 	"github.com/pquerna/cachecontrol"
+	"github.com/traefik/hub-agent-kubernetes/pkg/acp/upstream"
+	"github.com/traefik/hub-agent-kubernetes/pkg/tracing"
 	"gopkg.in/square/go-jose.v2"
 )
 
@@ -172,13 +174,13 @@ func NewRemoteKeySet(url string) *RemoteKeySet {
 	return &RemoteKeySet{
 		url: url,
 		client: &http.Client{
-			Transport: &http.Transport{
+			Transport: tracing.Transport(&http.Transport{
 				DialContext: (&net.Dialer{
 					Timeout:   30 * time.Second,
 					KeepAlive: 30 * time.Second,
 				}).DialContext,
 				TLSHandshakeTimeout: 10 * time.Second,
-			},
+			}),
 			Timeout: 5 * time.Second,
 		},
 	}
@@ -228,6 +230,10 @@ func (s *RemoteKeySet) updateKeySet(ctx context.Context) error {
 	updating := s.updating
 	s.mu.Unlock()
 
+	// The request is held until the key set is fetched from the identity provider.
+	start := time.Now()
+	defer func() { upstream.Observe(ctx, time.Since(start)) }()
+
 	return updating.Wait(ctx)
 }
 
@@ -33,6 +33,7 @@ import (
 	jwtreq "github.com/golang-jwt/jwt/v4/request"
 	"github.com/rs/zerolog/log"
 	"github.com/traefik/hub-agent-kubernetes/pkg/acp/jwt/expr"
+	"github.com/traefik/hub-agent-kubernetes/pkg/fips"
 )
 
 // Config configures a JWT ACP handler.
@@ -87,27 +88,14 @@ func NewHandler(cfg *Config, polName string) (*Handler, error) {
 		}
 	}
 
-	signingSecret := cfg.SigningSecret
-	if cfg.SigningSecretBase64Encoded {
-		var b []byte
-		b, err = base64.StdEncoding.DecodeString(signingSecret)
-		if err != nil {
-			return nil, fmt.Errorf("decode base64-encoded signing secret: %w", err)
-		}
-		signingSecret = string(b)
+	signingSecret, err := decodeSigningSecret(cfg)
+	if err != nil {
+		return nil, err
 	}
 
-	var pubKey interface{}
-	if cfg.PublicKey != "" {
-		block, _ := pem.Decode([]byte(cfg.PublicKey))
-		if block == nil {
-			return nil, errors.New("empty or ill-formatted public key")
-		}
-
-		pubKey, err = x509.ParsePKIXPublicKey(block.Bytes)
-		if err != nil {
-			return nil, fmt.Errorf("parse public key: %w", err)
-		}
+	pubKey, err := parsePublicKey(cfg)
+	if err != nil {
+		return nil, err
 	}
 
 	tokenQueryKey := "jwt"
@@ -120,6 +108,12 @@ func NewHandler(cfg *Config, polName string) (*Handler, error) {
 		return nil, err
 	}
 
+	if fips.Enabled() {
+		if err = checkFIPS(signingSecret, pubKey, ks); err != nil {
+			return nil, fmt.Errorf("FIPS mode: %w", err)
+		}
+	}
+
 	return &Handler{
 		name:                 polName,
 		signingSecret:        signingSecret,
@@ -134,6 +128,82 @@ func NewHandler(cfg *Config, polName string) (*Handler, error) {
 	}, nil
 }
 
+// CheckFIPS returns an error if the given configuration uses keys which aren't FIPS-approved. Keys of JWK sets read
+// from a file or a URL can only be checked when they are used.
+func CheckFIPS(cfg *Config) error {
+	signingSecret, err := decodeSigningSecret(cfg)
+	if err != nil {
+		return err
+	}
+
+	pubKey, err := parsePublicKey(cfg)
+	if err != nil {
+		return err
+	}
+
+	ks, err := keySet(cfg)
+	if err != nil {
+		return err
+	}
+
+	return checkFIPS(signingSecret, pubKey, ks)
+}
+
+func checkFIPS(signingSecret string, pubKey interface{}, ks KeySet) error {
+	if signingSecret != "" {
+		if err := fips.CheckHMACKey([]byte(signingSecret)); err != nil {
+			return fmt.Errorf("signing secret: %w", err)
+		}
+	}
+
+	if pubKey != nil {
+		if err := fips.CheckPublicKey(pubKey); err != nil {
+			return fmt.Errorf("public key: %w", err)
+		}
+	}
+
+	if cks, ok := ks.(*ContentKeySet); ok {
+		for _, key := range cks.keySet.Keys {
+			if err := fips.CheckPublicKey(key.Key); err != nil {
+				return fmt.Errorf("JWK %q: %w", key.KeyID, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+func decodeSigningSecret(cfg *Config) (string, error) {
+	if !cfg.SigningSecretBase64Encoded {
+		return cfg.SigningSecret, nil
+	}
+
+	b, err := base64.StdEncoding.DecodeString(cfg.SigningSecret)
+	if err != nil {
+		return "", fmt.Errorf("decode base64-encoded signing secret: %w", err)
+	}
+
+	return string(b), nil
+}
+
+func parsePublicKey(cfg *Config) (interface{}, error) {
+	if cfg.PublicKey == "" {
+		return nil, nil
+	}
+
+	block, _ := pem.Decode([]byte(cfg.PublicKey))
+	if block == nil {
+		return nil, errors.New("empty or ill-formatted public key")
+	}
+
+	pubKey, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("parse public key: %w", err)
+	}
+
+	return pubKey, nil
+}
+
 func keySet(src *Config) (KeySet, error) {
 	if src.JWKsFile != "" {
 		if src.JWKsFile.IsPath() {
@@ -283,6 +353,14 @@ func (h *Handler) resolveKey(ctx context.Context, tok *jwt.Token, kid string) (k
 	if k == nil {
 		return nil, fmt.Errorf("no key with id %q found", kid)
 	}
+
+	// Keys of JWK sets read from a file or a URL can change, they are checked each time they are used.
+	if fips.Enabled() {
+		if err = fips.CheckPublicKey(k.Key); err != nil {
+			return nil, fmt.Errorf("FIPS mode: JWK %q: %w", kid, err)
+		}
+	}
+
 	return k.Key, nil
 }
 
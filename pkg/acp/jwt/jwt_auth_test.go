@@ -24,6 +24,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"os"
 	"testing"
 	"time"
 
@@ -384,6 +385,13 @@ e+lf4s4OxQawWD79J9/5d3Ry0vbV3Am1FtGJiJvOwRsIfVChDpYStTcHTCMqtvWb
 V6L11BWkpzGXSW4Hv43qa+GSYOD2QU68Mb59oSk2OB+BtOLpJofmbGEGgvmwyCI9
 MwIDAQAB
 -----END PUBLIC KEY-----
+`
+	rsa1024PubKey = `-----BEGIN PUBLIC KEY-----
+MIGfMA0GCSqGSIb3DQEBAQUAA4GNADCBiQKBgQDGH+96dG++K5com2JJfouXHSMX
+2GZAM+YLFgQ3PIURhGH15r1Hj7NMCieXC1rSodmp6PXmgdx8v9MkT0nuEqCH2jPu
+VzuvDIuNC2Uo6eKbSK4UKQMyRct35jMb3u9dXDJrJ7cvqcM5MQsQQ2BbIf/sZ5xB
+3BpwRbPc234oHwa/yQIDAQAB
+-----END PUBLIC KEY-----
 `
 )
 
@@ -448,3 +456,61 @@ func Test_JWTAuthNew(t *testing.T) {
 		})
 	}
 }
+
+func TestCheckFIPS(t *testing.T) {
+	jwks, err := os.ReadFile("./testdata/jwks.json")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		jwtCfg  Config
+		wantErr assert.ErrorAssertionFunc
+	}{
+		{
+			name:    "short signing secret",
+			jwtCfg:  Config{SigningSecret: "foobar"},
+			wantErr: assert.Error,
+		},
+		{
+			name:    "signing secret",
+			jwtCfg:  Config{SigningSecret: "a-long-enough-secret"},
+			wantErr: assert.NoError,
+		},
+		{
+			name: "short base64 signing secret",
+			jwtCfg: Config{
+				SigningSecret: base64.StdEncoding.EncodeToString([]byte("foobar")), SigningSecretBase64Encoded: true,
+			},
+			wantErr: assert.Error,
+		},
+		{
+			name:    "RSA 2048 bits public key",
+			jwtCfg:  Config{PublicKey: validPubKey},
+			wantErr: assert.NoError,
+		},
+		{
+			name:    "RSA 1024 bits public key",
+			jwtCfg:  Config{PublicKey: rsa1024PubKey},
+			wantErr: assert.Error,
+		},
+		{
+			name:    "JWKs content",
+			jwtCfg:  Config{JWKsFile: FileOrContent(jwks)},
+			wantErr: assert.NoError,
+		},
+		{
+			name:    "JWKs URL",
+			jwtCfg:  Config{JWKsURL: "http://example.com"},
+			wantErr: assert.NoError,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			test.wantErr(t, CheckFIPS(&test.jwtCfg))
+		})
+	}
+}
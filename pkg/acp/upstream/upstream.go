@@ -0,0 +1,64 @@
+/*
+Copyright (C) 2022 Traefik Labs
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+// Package upstream measures the time ACP handlers spend waiting on upstream services, such as identity providers, while
+// taking a decision.
+package upstream
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type timerKey struct{}
+
+// Timer accumulates the time spent calling upstream services during a request.
+type Timer struct {
+	mu       sync.Mutex
+	duration time.Duration
+	calls    int
+}
+
+// WithTimer returns a context carrying a new timer, in which upstream calls made with this context are accumulated.
+func WithTimer(ctx context.Context) (context.Context, *Timer) {
+	t := &Timer{}
+
+	return context.WithValue(ctx, timerKey{}, t), t
+}
+
+// Observe records an upstream call of the given duration in the timer of the context, if any.
+func Observe(ctx context.Context, d time.Duration) {
+	t, ok := ctx.Value(timerKey{}).(*Timer)
+	if !ok {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.duration += d
+	t.calls++
+}
+
+// Duration returns the total time spent calling upstream services and the number of calls made.
+func (t *Timer) Duration() (time.Duration, int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.duration, t.calls
+}
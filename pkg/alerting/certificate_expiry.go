@@ -0,0 +1,179 @@
+/*
+Copyright (C) 2022 Traefik Labs
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package alerting
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/traefik/hub-agent-kubernetes/pkg/topology/state"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+const reasonCertificateExpiring = "CertificateExpiring"
+
+// CertificatesProvider provides the TLS certificates observed in the cluster.
+type CertificatesProvider interface {
+	GetCertificates() (map[string]*state.Certificate, error)
+}
+
+// CertificateExpiryProcessor processes certificate expiry rules.
+type CertificateExpiryProcessor struct {
+	certificates CertificatesProvider
+	recorder     record.EventRecorder
+
+	// notifiedMu protects notified, which holds the number of days left before expiry last reported in an event for
+	// each rule and certificate, so events are recorded once a day instead of at each check.
+	notifiedMu sync.Mutex
+	notified   map[string]int
+
+	nowFunc func() time.Time
+}
+
+// NewCertificateExpiryProcessor returns a certificate expiry processor. Expiring certificates are also reported as
+// warning events on their Secret when a recorder is given.
+func NewCertificateExpiryProcessor(certificates CertificatesProvider, recorder record.EventRecorder) *CertificateExpiryProcessor {
+	return &CertificateExpiryProcessor{
+		certificates: certificates,
+		recorder:     recorder,
+		notified:     make(map[string]int),
+		nowFunc:      time.Now,
+	}
+}
+
+// Process processes a certificate expiry rule returning an alert or nil.
+func (p *CertificateExpiryProcessor) Process(_ context.Context, rule *Rule) (*Alert, error) {
+	if rule.CertificateExpiry.Days <= 0 {
+		return nil, fmt.Errorf("invalid number of days: %d", rule.CertificateExpiry.Days)
+	}
+
+	certs, err := p.certificates.GetCertificates()
+	if err != nil {
+		return nil, fmt.Errorf("get certificates: %w", err)
+	}
+
+	now := p.nowFunc().UTC()
+	limit := now.Add(time.Duration(rule.CertificateExpiry.Days) * 24 * time.Hour)
+
+	var expiring []Certificate
+	for _, cert := range certs {
+		if rule.Ingress != "" && !usedByIngress(cert, rule.Ingress) {
+			continue
+		}
+		if cert.NotAfter.After(limit) {
+			continue
+		}
+
+		expiring = append(expiring, Certificate{
+			Name:      cert.Name,
+			Namespace: cert.Namespace,
+			Domains:   cert.Domains,
+			NotAfter:  cert.NotAfter,
+		})
+	}
+
+	p.recordEvents(rule.ID, expiring, now)
+
+	if len(expiring) == 0 {
+		return nil, nil
+	}
+
+	sort.Slice(expiring, func(i, j int) bool {
+		if !expiring[i].NotAfter.Equal(expiring[j].NotAfter) {
+			return expiring[i].NotAfter.Before(expiring[j].NotAfter)
+		}
+		return expiring[i].Namespace+"/"+expiring[i].Name < expiring[j].Namespace+"/"+expiring[j].Name
+	})
+
+	return &Alert{
+		RuleID:            rule.ID,
+		Ingress:           rule.Ingress,
+		Service:           rule.Service,
+		Points:            []Point{{Timestamp: now.Unix(), Value: float64(daysLeft(expiring[0].NotAfter, now))}},
+		CertificateExpiry: rule.CertificateExpiry,
+		Certificates:      expiring,
+	}, nil
+}
+
+// recordEvents records a warning event on the Secret of each expiring certificate, once a day.
+func (p *CertificateExpiryProcessor) recordEvents(ruleID string, expiring []Certificate, now time.Time) {
+	p.notifiedMu.Lock()
+	defer p.notifiedMu.Unlock()
+
+	seen := make(map[string]struct{}, len(expiring))
+	for _, cert := range expiring {
+		key := ruleID + "/" + cert.Name + "@" + cert.Namespace
+		seen[key] = struct{}{}
+
+		days := daysLeft(cert.NotAfter, now)
+		if last, ok := p.notified[key]; ok && last == days {
+			continue
+		}
+		p.notified[key] = days
+
+		if p.recorder == nil {
+			continue
+		}
+
+		ref := &corev1.ObjectReference{
+			Kind:       "Secret",
+			APIVersion: "v1",
+			Name:       cert.Name,
+			Namespace:  cert.Namespace,
+		}
+
+		msg := fmt.Sprintf("Certificate for %s expires in %d days, at %s", strings.Join(cert.Domains, ", "), days, cert.NotAfter.Format(time.RFC3339))
+		if days <= 0 {
+			msg = fmt.Sprintf("Certificate for %s expired at %s", strings.Join(cert.Domains, ", "), cert.NotAfter.Format(time.RFC3339))
+		}
+
+		p.recorder.Event(ref, corev1.EventTypeWarning, reasonCertificateExpiring, msg)
+	}
+
+	// Forget the certificates of this rule which are not expiring anymore, so they are reported again if they do.
+	for key := range p.notified {
+		if strings.HasPrefix(key, ruleID+"/") {
+			if _, ok := seen[key]; !ok {
+				delete(p.notified, key)
+			}
+		}
+	}
+}
+
+// usedByIngress returns whether the given certificate is used by the given Ingress or IngressRoute, identified either
+// by its topology key or by its name and namespace.
+func usedByIngress(cert *state.Certificate, ingress string) bool {
+	for _, key := range cert.Ingresses {
+		if key == ingress || strings.HasPrefix(key, ingress+".") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// daysLeft returns the number of days, rounded up, before the given expiry date.
+func daysLeft(notAfter, now time.Time) int {
+	return int(math.Ceil(notAfter.Sub(now).Hours() / 24))
+}
@@ -0,0 +1,159 @@
+/*
+Copyright (C) 2022 Traefik Labs
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package alerting
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/traefik/hub-agent-kubernetes/pkg/topology/state"
+	"k8s.io/client-go/tools/record"
+)
+
+type certificatesProviderFunc func() (map[string]*state.Certificate, error)
+
+func (f certificatesProviderFunc) GetCertificates() (map[string]*state.Certificate, error) {
+	return f()
+}
+
+func TestCertificateExpiryProcessor_Process(t *testing.T) {
+	now := time.Date(2022, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	certs := map[string]*state.Certificate{
+		"expiring@myns": {
+			Name:      "expiring",
+			Namespace: "myns",
+			Domains:   []string{"whoami.example.com"},
+			NotAfter:  now.Add(5 * 24 * time.Hour),
+			Ingresses: []string{"myIngress@myns.ingress.networking.k8s.io"},
+		},
+		"hub-certificate@hub-agent": {
+			Name:      "hub-certificate",
+			Namespace: "hub-agent",
+			Domains:   []string{"*.hub.example.com"},
+			NotAfter:  now.Add(-time.Hour),
+		},
+		"valid@myns": {
+			Name:      "valid",
+			Namespace: "myns",
+			Domains:   []string{"valid.example.com"},
+			NotAfter:  now.Add(60 * 24 * time.Hour),
+			Ingresses: []string{"myIngress@myns.ingress.networking.k8s.io"},
+		},
+	}
+
+	tests := []struct {
+		desc       string
+		rule       *Rule
+		wantAlert  *Alert
+		wantEvents []string
+		requireErr require.ErrorAssertionFunc
+	}{
+		{
+			desc: "Alert: certificates expiring within the given number of days",
+			rule: &Rule{ID: "rule-1", CertificateExpiry: &CertificateExpiry{Days: 7}},
+			wantAlert: &Alert{
+				RuleID:            "rule-1",
+				Points:            []Point{{Timestamp: now.Unix(), Value: 0}},
+				CertificateExpiry: &CertificateExpiry{Days: 7},
+				Certificates: []Certificate{
+					{Name: "hub-certificate", Namespace: "hub-agent", Domains: []string{"*.hub.example.com"}, NotAfter: now.Add(-time.Hour)},
+					{Name: "expiring", Namespace: "myns", Domains: []string{"whoami.example.com"}, NotAfter: now.Add(5 * 24 * time.Hour)},
+				},
+			},
+			wantEvents: []string{
+				"Warning CertificateExpiring Certificate for whoami.example.com expires in 5 days, at 2022-06-06T12:00:00Z",
+				"Warning CertificateExpiring Certificate for *.hub.example.com expired at 2022-06-01T11:00:00Z",
+			},
+			requireErr: require.NoError,
+		},
+		{
+			desc: "Alert: certificates of an ingress expiring within the given number of days",
+			rule: &Rule{ID: "rule-1", Ingress: "myIngress@myns", CertificateExpiry: &CertificateExpiry{Days: 7}},
+			wantAlert: &Alert{
+				RuleID:            "rule-1",
+				Ingress:           "myIngress@myns",
+				Points:            []Point{{Timestamp: now.Unix(), Value: 5}},
+				CertificateExpiry: &CertificateExpiry{Days: 7},
+				Certificates: []Certificate{
+					{Name: "expiring", Namespace: "myns", Domains: []string{"whoami.example.com"}, NotAfter: now.Add(5 * 24 * time.Hour)},
+				},
+			},
+			wantEvents: []string{
+				"Warning CertificateExpiring Certificate for whoami.example.com expires in 5 days, at 2022-06-06T12:00:00Z",
+			},
+			requireErr: require.NoError,
+		},
+		{
+			desc:       "No alert: no certificate expiring within the given number of days",
+			rule:       &Rule{ID: "rule-1", Ingress: "otherIngress@myns", CertificateExpiry: &CertificateExpiry{Days: 7}},
+			requireErr: require.NoError,
+		},
+		{
+			desc:       "No alert: invalid number of days",
+			rule:       &Rule{ID: "rule-1", CertificateExpiry: &CertificateExpiry{Days: 0}},
+			requireErr: require.Error,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			recorder := record.NewFakeRecorder(10)
+			provider := certificatesProviderFunc(func() (map[string]*state.Certificate, error) {
+				return certs, nil
+			})
+
+			proc := NewCertificateExpiryProcessor(provider, recorder)
+			proc.nowFunc = func() time.Time { return now }
+
+			alert, err := proc.Process(context.Background(), test.rule)
+			test.requireErr(t, err)
+			assert.Equal(t, test.wantAlert, alert)
+			assert.ElementsMatch(t, test.wantEvents, drainEvents(recorder))
+
+			// Events are not recorded again until the number of days left changes.
+			_, err = proc.Process(context.Background(), test.rule)
+			test.requireErr(t, err)
+			assert.Empty(t, drainEvents(recorder))
+
+			proc.nowFunc = func() time.Time { return now.Add(24 * time.Hour) }
+			_, err = proc.Process(context.Background(), test.rule)
+			test.requireErr(t, err)
+			assert.Len(t, drainEvents(recorder), len(test.wantEvents))
+		})
+	}
+}
+
+func drainEvents(recorder *record.FakeRecorder) []string {
+	var events []string
+	for {
+		select {
+		case event := <-recorder.Events:
+			events = append(events, event)
+		default:
+			return events
+		}
+	}
+}
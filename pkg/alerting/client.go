@@ -87,6 +87,43 @@ func (c *Client) GetRules(ctx context.Context) ([]Rule, error) {
 	return rules, nil
 }
 
+// GetSilences returns the silences configured on the platform.
+func (c *Client) GetSilences(ctx context.Context) ([]Silence, error) {
+	endpoint, err := c.baseURL.Parse(path.Join(c.baseURL.Path, "silences"))
+	if err != nil {
+		return nil, fmt.Errorf("creating alerting silences url: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint.String(), http.NoBody)
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+
+	c.setAuthHeader(req)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("getting alerting silences: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("reading response body: %w", err)
+	}
+
+	if resp.StatusCode/100 != 2 {
+		return nil, fmt.Errorf("getting alerting silences got %d: %s", resp.StatusCode, string(body))
+	}
+
+	var silences []Silence
+	if err = json.Unmarshal(body, &silences); err != nil {
+		return nil, fmt.Errorf("unmarshaling response: %w: %s", err, string(body))
+	}
+
+	return silences, nil
+}
+
 type descriptor struct {
 	ID      int    `json:"id"`
 	RuleID  string `json:"ruleId"`
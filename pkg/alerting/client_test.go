@@ -69,6 +69,37 @@ func TestClient_GetRules(t *testing.T) {
 	assert.Equal(t, want, got)
 }
 
+func TestClient_GetSilences(t *testing.T) {
+	want := []alerting.Silence{
+		{
+			RuleID:   "123",
+			Service:  "svc@ns",
+			StartsAt: time.Date(2021, 1, 1, 8, 0, 0, 0, time.UTC),
+			EndsAt:   time.Date(2021, 1, 1, 10, 0, 0, 0, time.UTC),
+		},
+	}
+
+	var silencesCallCount int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		silencesCallCount++
+		assert.Equal(t, "/silences", r.URL.Path)
+		assert.Equal(t, "Bearer some_test_token", r.Header.Get("Authorization"))
+
+		err := json.NewEncoder(w).Encode(want)
+		require.NoError(t, err)
+	}))
+	t.Cleanup(srv.Close)
+
+	client, err := alerting.NewClient(http.DefaultClient, srv.URL, "some_test_token")
+	require.NoError(t, err)
+
+	got, err := client.GetSilences(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, silencesCallCount)
+
+	assert.Equal(t, want, got)
+}
+
 type descriptor struct {
 	ID      int    `json:"id"`
 	RuleID  string `json:"ruleId"`
@@ -0,0 +1,450 @@
+/*
+Copyright (C) 2022 Traefik Labs
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package alerting
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/traefik/hub-agent-kubernetes/pkg/metrics"
+)
+
+// compiledExpression is a parsed expression, comparing two arithmetic expressions over the data points of a time range.
+type compiledExpression struct {
+	left  exprNode
+	op    string
+	right exprNode
+}
+
+// evaluate evaluates the expression over the given data points. It returns the value of the left-hand side of the
+// comparison and whether the comparison holds. Comparisons involving undefined values, such as divisions by zero,
+// never hold.
+func (e compiledExpression) evaluate(pnts metrics.DataPoints) (float64, bool) {
+	left, right := e.left.eval(pnts), e.right.eval(pnts)
+	if math.IsNaN(left) || math.IsNaN(right) || math.IsInf(left, 0) || math.IsInf(right, 0) {
+		return left, false
+	}
+
+	switch e.op {
+	case ">":
+		return left, left > right
+	case ">=":
+		return left, left >= right
+	case "<":
+		return left, left < right
+	case "<=":
+		return left, left <= right
+	case "==":
+		return left, left == right
+	case "!=":
+		return left, left != right
+	default:
+		return left, false
+	}
+}
+
+type exprNode interface {
+	eval(pnts metrics.DataPoints) float64
+}
+
+type numberNode float64
+
+func (n numberNode) eval(metrics.DataPoints) float64 {
+	return float64(n)
+}
+
+type negNode struct {
+	operand exprNode
+}
+
+func (n negNode) eval(pnts metrics.DataPoints) float64 {
+	return -n.operand.eval(pnts)
+}
+
+type binaryNode struct {
+	op          byte
+	left, right exprNode
+}
+
+func (n binaryNode) eval(pnts metrics.DataPoints) float64 {
+	left, right := n.left.eval(pnts), n.right.eval(pnts)
+
+	switch n.op {
+	case '+':
+		return left + right
+	case '-':
+		return left - right
+	case '*':
+		return left * right
+	case '/':
+		if right == 0 {
+			return math.NaN()
+		}
+		return left / right
+	default:
+		return math.NaN()
+	}
+}
+
+// aggregationNode aggregates the values of a metric over all data points.
+type aggregationNode struct {
+	fn     string
+	metric string
+}
+
+func (n aggregationNode) eval(pnts metrics.DataPoints) float64 {
+	if n.fn == "rate" {
+		var sum, seconds float64
+		for _, pnt := range pnts {
+			// The metric has been validated while parsing.
+			value, _ := getValue(n.metric, pnt)
+			sum += value
+			seconds += float64(pnt.Seconds)
+		}
+		if seconds == 0 {
+			return math.NaN()
+		}
+
+		return sum / seconds
+	}
+
+	if len(pnts) == 0 {
+		if n.fn == "sum" {
+			return 0
+		}
+		return math.NaN()
+	}
+
+	var res float64
+	for i, pnt := range pnts {
+		value, _ := getValue(n.metric, pnt)
+
+		switch {
+		case i == 0:
+			res = value
+		case n.fn == "min":
+			res = math.Min(res, value)
+		case n.fn == "max":
+			res = math.Max(res, value)
+		default:
+			res += value
+		}
+	}
+
+	if n.fn == "avg" {
+		return res / float64(len(pnts))
+	}
+
+	return res
+}
+
+// quantileNode estimates a response time quantile over all data points.
+type quantileNode struct {
+	q float64
+}
+
+func (n quantileNode) eval(pnts metrics.DataPoints) float64 {
+	pnt := pnts.Aggregate()
+	if len(pnt.ResponseTimeBuckets) == 0 {
+		return math.NaN()
+	}
+
+	return pnt.ResponseTimePercentile(n.q)
+}
+
+// parseExpression parses an expression made of a comparison between two arithmetic expressions. Arithmetic
+// expressions combine numbers and the following functions with the +, -, * and / operators:
+//   - sum, avg, min and max of a metric over the data points of the time range, e.g. avg(requestsPerSecond)
+//   - rate of a counter over the time range, in occurrences per second, e.g. rate(requestErrors)
+//   - histogram_quantile of the response times over the time range, e.g. histogram_quantile(0.99, responseTime)
+func parseExpression(expr string) (compiledExpression, error) {
+	tokens, err := tokenize(expr)
+	if err != nil {
+		return compiledExpression{}, err
+	}
+
+	p := &exprParser{tokens: tokens}
+
+	left, err := p.parseAdditive()
+	if err != nil {
+		return compiledExpression{}, err
+	}
+
+	op := p.next()
+	if op.kind != tokenComparison {
+		return compiledExpression{}, fmt.Errorf("expected comparison operator at position %d, got %q", op.pos, op.text)
+	}
+
+	right, err := p.parseAdditive()
+	if err != nil {
+		return compiledExpression{}, err
+	}
+
+	if tok := p.next(); tok.kind != tokenEOF {
+		return compiledExpression{}, fmt.Errorf("unexpected %q at position %d", tok.text, tok.pos)
+	}
+
+	return compiledExpression{left: left, op: op.text, right: right}, nil
+}
+
+type exprParser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *exprParser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *exprParser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokenEOF {
+		p.pos++
+	}
+
+	return tok
+}
+
+func (p *exprParser) expect(kind tokenKind, text string) error {
+	tok := p.next()
+	if tok.kind != kind {
+		return fmt.Errorf("expected %q at position %d, got %q", text, tok.pos, tok.text)
+	}
+
+	return nil
+}
+
+func (p *exprParser) parseAdditive() (exprNode, error) {
+	left, err := p.parseMultiplicative()
+	if err != nil {
+		return nil, err
+	}
+
+	for tok := p.peek(); tok.kind == tokenOperator && (tok.text == "+" || tok.text == "-"); tok = p.peek() {
+		p.next()
+
+		right, err := p.parseMultiplicative()
+		if err != nil {
+			return nil, err
+		}
+
+		left = binaryNode{op: tok.text[0], left: left, right: right}
+	}
+
+	return left, nil
+}
+
+func (p *exprParser) parseMultiplicative() (exprNode, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+
+	for tok := p.peek(); tok.kind == tokenOperator && (tok.text == "*" || tok.text == "/"); tok = p.peek() {
+		p.next()
+
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+
+		left = binaryNode{op: tok.text[0], left: left, right: right}
+	}
+
+	return left, nil
+}
+
+func (p *exprParser) parseUnary() (exprNode, error) {
+	if tok := p.peek(); tok.kind == tokenOperator && tok.text == "-" {
+		p.next()
+
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+
+		return negNode{operand: operand}, nil
+	}
+
+	return p.parsePrimary()
+}
+
+func (p *exprParser) parsePrimary() (exprNode, error) {
+	tok := p.next()
+
+	switch tok.kind {
+	case tokenNumber:
+		value, err := strconv.ParseFloat(tok.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q at position %d", tok.text, tok.pos)
+		}
+
+		return numberNode(value), nil
+
+	case tokenLParen:
+		node, err := p.parseAdditive()
+		if err != nil {
+			return nil, err
+		}
+		if err = p.expect(tokenRParen, ")"); err != nil {
+			return nil, err
+		}
+
+		return node, nil
+
+	case tokenIdent:
+		return p.parseCall(tok)
+
+	default:
+		return nil, fmt.Errorf("unexpected %q at position %d", tok.text, tok.pos)
+	}
+}
+
+func (p *exprParser) parseCall(fn token) (exprNode, error) {
+	if p.peek().kind != tokenLParen {
+		return nil, fmt.Errorf("metric %q at position %d must be aggregated, e.g. avg(%s)", fn.text, fn.pos, fn.text)
+	}
+	p.next()
+
+	var node exprNode
+	switch fn.text {
+	case "sum", "avg", "min", "max", "rate":
+		metric := p.next()
+		if metric.kind != tokenIdent {
+			return nil, fmt.Errorf("expected metric at position %d, got %q", metric.pos, metric.text)
+		}
+		if _, err := getValue(metric.text, metrics.DataPoint{}); err != nil {
+			return nil, err
+		}
+
+		node = aggregationNode{fn: fn.text, metric: metric.text}
+
+	case "histogram_quantile":
+		q := p.next()
+		if q.kind != tokenNumber {
+			return nil, fmt.Errorf("expected quantile at position %d, got %q", q.pos, q.text)
+		}
+		value, err := strconv.ParseFloat(q.text, 64)
+		if err != nil || value < 0 || value > 1 {
+			return nil, fmt.Errorf("invalid quantile %q at position %d, must be between 0 and 1", q.text, q.pos)
+		}
+
+		if err = p.expect(tokenComma, ","); err != nil {
+			return nil, err
+		}
+
+		metric := p.next()
+		if metric.kind != tokenIdent || metric.text != "responseTime" {
+			return nil, fmt.Errorf("expected responseTime at position %d, got %q", metric.pos, metric.text)
+		}
+
+		node = quantileNode{q: value}
+
+	default:
+		return nil, fmt.Errorf("unknown function %q at position %d", fn.text, fn.pos)
+	}
+
+	if err := p.expect(tokenRParen, ")"); err != nil {
+		return nil, err
+	}
+
+	return node, nil
+}
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenNumber
+	tokenIdent
+	tokenOperator
+	tokenComparison
+	tokenLParen
+	tokenRParen
+	tokenComma
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+func tokenize(expr string) ([]token, error) {
+	var tokens []token
+
+	for i := 0; i < len(expr); {
+		c := rune(expr[i])
+
+		switch {
+		case unicode.IsSpace(c):
+			i++
+
+		case unicode.IsDigit(c) || c == '.':
+			start := i
+			for i < len(expr) && (unicode.IsDigit(rune(expr[i])) || expr[i] == '.') {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenNumber, text: expr[start:i], pos: start})
+
+		case unicode.IsLetter(c) || c == '_':
+			start := i
+			for i < len(expr) && (unicode.IsLetter(rune(expr[i])) || unicode.IsDigit(rune(expr[i])) || expr[i] == '_') {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenIdent, text: expr[start:i], pos: start})
+
+		case strings.ContainsRune("+-*/", c):
+			tokens = append(tokens, token{kind: tokenOperator, text: string(c), pos: i})
+			i++
+
+		case strings.ContainsRune("<>=!", c):
+			start := i
+			i++
+			if i < len(expr) && expr[i] == '=' {
+				i++
+			}
+
+			op := expr[start:i]
+			if op == "=" || op == "!" {
+				return nil, fmt.Errorf("invalid operator %q at position %d", op, start)
+			}
+			tokens = append(tokens, token{kind: tokenComparison, text: op, pos: start})
+
+		case c == '(':
+			tokens = append(tokens, token{kind: tokenLParen, text: "(", pos: i})
+			i++
+
+		case c == ')':
+			tokens = append(tokens, token{kind: tokenRParen, text: ")", pos: i})
+			i++
+
+		case c == ',':
+			tokens = append(tokens, token{kind: tokenComma, text: ",", pos: i})
+			i++
+
+		default:
+			return nil, fmt.Errorf("unexpected character %q at position %d", c, i)
+		}
+	}
+
+	return append(tokens, token{kind: tokenEOF, text: "end of expression", pos: len(expr)}), nil
+}
@@ -0,0 +1,157 @@
+/*
+Copyright (C) 2022 Traefik Labs
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package alerting
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/traefik/hub-agent-kubernetes/pkg/metrics"
+)
+
+func TestParseExpression_evaluate(t *testing.T) {
+	pnts := metrics.DataPoints{
+		{
+			Seconds:             60,
+			Requests:            100,
+			RequestErrs:         2,
+			ReqPerS:             100.0 / 60,
+			AvgResponseTime:     0.1,
+			ResponseTimeBuckets: []int64{0, 0, 0, 0, 0, 0, 100, 0, 0, 0, 0, 0},
+		},
+		{
+			Seconds:             60,
+			Requests:            100,
+			RequestErrs:         18,
+			ReqPerS:             100.0 / 60,
+			AvgResponseTime:     0.3,
+			ResponseTimeBuckets: []int64{0, 0, 0, 0, 0, 0, 0, 100, 0, 0, 0, 0},
+		},
+	}
+
+	tests := []struct {
+		desc          string
+		expr          string
+		wantValue     float64
+		wantTriggered bool
+	}{
+		{
+			desc:          "error ratio above threshold",
+			expr:          "sum(requestErrors) / sum(requests) > 0.05",
+			wantValue:     0.1,
+			wantTriggered: true,
+		},
+		{
+			desc:      "error ratio below threshold",
+			expr:      "sum(requestErrors) / sum(requests) > 0.2",
+			wantValue: 0.1,
+		},
+		{
+			desc:          "error percentage with precedence",
+			expr:          "100 * sum(requestErrors) / sum(requests) >= 10",
+			wantValue:     10,
+			wantTriggered: true,
+		},
+		{
+			desc:          "rate",
+			expr:          "rate(requestErrors) > 0.1",
+			wantValue:     20.0 / 120,
+			wantTriggered: true,
+		},
+		{
+			desc:          "average",
+			expr:          "avg(averageResponseTime) < 0.25",
+			wantValue:     0.2,
+			wantTriggered: true,
+		},
+		{
+			desc:          "min and max",
+			expr:          "max(averageResponseTime) - min(averageResponseTime) > 0.1",
+			wantValue:     0.2,
+			wantTriggered: true,
+		},
+		{
+			desc:          "response time quantile",
+			expr:          "histogram_quantile(0.99, responseTime) > 0.5",
+			wantValue:     0.99,
+			wantTriggered: true,
+		},
+		{
+			desc:          "parentheses and negation",
+			expr:          "-(sum(requests) - 250) == 50",
+			wantValue:     50,
+			wantTriggered: true,
+		},
+		{
+			desc:      "division by zero never triggers",
+			expr:      "sum(requests) / 0 > 1",
+			wantValue: math.NaN(),
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			expr, err := parseExpression(test.expr)
+			require.NoError(t, err)
+
+			value, triggered := expr.evaluate(pnts)
+
+			if math.IsNaN(test.wantValue) {
+				assert.True(t, math.IsNaN(value))
+			} else {
+				assert.InDelta(t, test.wantValue, value, 1e-9)
+			}
+			assert.Equal(t, test.wantTriggered, triggered)
+		})
+	}
+}
+
+func TestParseExpression_invalid(t *testing.T) {
+	tests := []struct {
+		desc string
+		expr string
+	}{
+		{desc: "empty", expr: ""},
+		{desc: "no comparison", expr: "sum(requests)"},
+		{desc: "two comparisons", expr: "sum(requests) > 1 > 2"},
+		{desc: "unknown function", expr: "median(requests) > 1"},
+		{desc: "unknown metric", expr: "sum(potatoes) > 1"},
+		{desc: "metric not aggregated", expr: "requests > 1"},
+		{desc: "missing closing parenthesis", expr: "sum(requests > 1"},
+		{desc: "quantile out of range", expr: "histogram_quantile(1.5, responseTime) > 1"},
+		{desc: "quantile of another metric", expr: "histogram_quantile(0.5, requests) > 1"},
+		{desc: "invalid operator", expr: "sum(requests) = 1"},
+		{desc: "invalid character", expr: "sum(requests) > 1 % 2"},
+		{desc: "invalid number", expr: "sum(requests) > 1.2.3"},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := parseExpression(test.expr)
+			assert.Error(t, err)
+		})
+	}
+}
@@ -47,6 +47,8 @@ type Manager struct {
 
 	procs map[string]Processor
 
+	notifier *notifier
+
 	refreshInterval   time.Duration
 	schedulerInterval time.Duration
 
@@ -56,10 +58,12 @@ type Manager struct {
 // NewManager returns an alert manager.
 // The alertRefreshInterval is the interval to fetch configuration, including alert rules.
 // The alertSchedulerInterval is the interval at which the scheduler runs rule checks.
-func NewManager(backend Backend, procs map[string]Processor, refreshInterval, schedulerInterval time.Duration) *Manager {
+// The notifierCfg configures how alerts are silenced, deduplicated and grouped before being sent.
+func NewManager(backend Backend, procs map[string]Processor, refreshInterval, schedulerInterval time.Duration, notifierCfg NotifierConfig) *Manager {
 	return &Manager{
 		backend:           backend,
 		procs:             procs,
+		notifier:          newNotifier(notifierCfg),
 		refreshInterval:   refreshInterval,
 		schedulerInterval: schedulerInterval,
 		nowFunc:           time.Now,
@@ -158,6 +162,9 @@ func (m *Manager) checkAlerts(ctx context.Context) error {
 		return fmt.Errorf("send preflight alerts: %w", err)
 	}
 
+	// Alerts are processed even if there is none to send, to flush the pending groups.
+	now := m.nowFunc()
+	sendAlerts = m.notifier.prepare(ctx, sendAlerts, now)
 	if len(sendAlerts) == 0 {
 		return nil
 	}
@@ -168,5 +175,7 @@ func (m *Manager) checkAlerts(ctx context.Context) error {
 		return fmt.Errorf("send alerts: %w", err)
 	}
 
+	m.notifier.markSent(sendAlerts, now)
+
 	return nil
 }
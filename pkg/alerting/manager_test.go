@@ -81,7 +81,7 @@ func TestManager_refreshRules(t *testing.T) {
 	backend := newBackendMock(t)
 	backend.OnGetRules().TypedReturns(rules, nil).Once()
 
-	mgr := NewManager(backend, nil, alertRefreshInterval, alertSchedulerInterval)
+	mgr := NewManager(backend, nil, alertRefreshInterval, alertSchedulerInterval, NotifierConfig{})
 
 	err := mgr.refreshRules(context.Background())
 	require.NoError(t, err)
@@ -96,7 +96,7 @@ func TestManager_refreshRules_handlesClientError(t *testing.T) {
 		TypedReturns(nil, errors.New("boom")).
 		Once()
 
-	mgr := NewManager(backend, nil, alertRefreshInterval, alertSchedulerInterval)
+	mgr := NewManager(backend, nil, alertRefreshInterval, alertSchedulerInterval, NotifierConfig{})
 
 	err := mgr.refreshRules(context.Background())
 	require.Error(t, err)
@@ -671,7 +671,7 @@ func TestManager_checkAlerts(t *testing.T) {
 
 			processors, backend := test.setup(t, test.rules)
 
-			mgr := NewManager(backend, processors, time.Second, time.Second)
+			mgr := NewManager(backend, processors, time.Second, time.Second, NotifierConfig{})
 			mgr.rules = test.rules
 
 			err := mgr.checkAlerts(context.Background())
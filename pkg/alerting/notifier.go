@@ -0,0 +1,184 @@
+/*
+Copyright (C) 2022 Traefik Labs
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package alerting
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// NotifierConfig configures how alerts accepted by the platform are processed before being sent.
+// The zero value sends every alert as soon as it is raised.
+type NotifierConfig struct {
+	// DedupWindow is the duration during which an alert identical to an already sent one is dropped.
+	DedupWindow time.Duration
+	// GroupWindow is the duration during which alerts on the same service, or the same namespace, are held to be
+	// sent together.
+	GroupWindow time.Duration
+	// Silences provide the periods during which matching alerts are dropped.
+	Silences []SilenceProvider
+}
+
+// notifier silences, deduplicates and groups alerts.
+type notifier struct {
+	cfg NotifierConfig
+
+	// sent holds when alerts were last sent, by alert key.
+	sent map[string]time.Time
+	// pending holds the alerts waiting for their group window to elapse, by group.
+	pending map[string]*pendingGroup
+	// groups holds the pending groups in the order they were created.
+	groups []string
+}
+
+type pendingGroup struct {
+	since  time.Time
+	keys   []string
+	alerts map[string]Alert
+}
+
+func newNotifier(cfg NotifierConfig) *notifier {
+	return &notifier{
+		cfg:     cfg,
+		sent:    make(map[string]time.Time),
+		pending: make(map[string]*pendingGroup),
+	}
+}
+
+// prepare returns the alerts to send now, among the given ones and the pending ones.
+func (n *notifier) prepare(ctx context.Context, alerts []Alert, now time.Time) []Alert {
+	silences := n.getSilences(ctx)
+
+	for key, sentAt := range n.sent {
+		if now.Sub(sentAt) >= n.cfg.DedupWindow {
+			delete(n.sent, key)
+		}
+	}
+
+	for _, alert := range alerts {
+		if silenced(silences, alert, now) {
+			log.Debug().Str("rule_id", alert.RuleID).Msg("Alert silenced")
+			continue
+		}
+
+		key := alertKey(alert)
+		if _, ok := n.sent[key]; ok {
+			log.Debug().Str("rule_id", alert.RuleID).Msg("Duplicated alert dropped")
+			continue
+		}
+
+		group := alertGroup(alert)
+		pending, ok := n.pending[group]
+		if !ok {
+			pending = &pendingGroup{since: now, alerts: make(map[string]Alert)}
+			n.pending[group] = pending
+			n.groups = append(n.groups, group)
+		}
+
+		// Identical alerts raised within the group window are merged, keeping the latest one.
+		if _, ok = pending.alerts[key]; !ok {
+			pending.keys = append(pending.keys, key)
+		}
+		pending.alerts[key] = alert
+	}
+
+	var (
+		ready  []Alert
+		groups []string
+	)
+	for _, group := range n.groups {
+		pending := n.pending[group]
+		if now.Sub(pending.since) < n.cfg.GroupWindow {
+			groups = append(groups, group)
+			continue
+		}
+		delete(n.pending, group)
+
+		for _, key := range pending.keys {
+			alert := pending.alerts[key]
+			// A silence may have been created while the alert was pending.
+			if silenced(silences, alert, now) {
+				continue
+			}
+
+			if n.cfg.GroupWindow > 0 {
+				alert.Group = group
+			}
+			ready = append(ready, alert)
+		}
+	}
+	n.groups = groups
+
+	return ready
+}
+
+// markSent records the given alerts as sent, for them to be deduplicated.
+func (n *notifier) markSent(alerts []Alert, now time.Time) {
+	if n.cfg.DedupWindow <= 0 {
+		return
+	}
+
+	for _, alert := range alerts {
+		n.sent[alertKey(alert)] = now
+	}
+}
+
+func (n *notifier) getSilences(ctx context.Context) []Silence {
+	var silences []Silence
+	for _, provider := range n.cfg.Silences {
+		s, err := provider.GetSilences(ctx)
+		if err != nil {
+			log.Error().Err(err).Msg("Unable to get silences")
+			continue
+		}
+
+		silences = append(silences, s...)
+	}
+
+	return silences
+}
+
+func silenced(silences []Silence, alert Alert, now time.Time) bool {
+	for _, silence := range silences {
+		if silence.Matches(alert, now) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// alertKey identifies identical alerts.
+func alertKey(alert Alert) string {
+	return alert.RuleID + "/" + alert.Ingress + "/" + alert.Service
+}
+
+// alertGroup returns the group of the given alert: its service if any, otherwise its namespace,
+// and its rule if it targets neither a service nor an ingress.
+func alertGroup(alert Alert) string {
+	if alert.Service != "" {
+		return alert.Service
+	}
+	if ns := alertNamespace(alert); ns != "" {
+		return ns
+	}
+
+	return alert.RuleID
+}
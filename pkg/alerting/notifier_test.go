@@ -0,0 +1,120 @@
+/*
+Copyright (C) 2022 Traefik Labs
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package alerting
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type silenceProviderFunc func(ctx context.Context) ([]Silence, error)
+
+func (f silenceProviderFunc) GetSilences(ctx context.Context) ([]Silence, error) {
+	return f(ctx)
+}
+
+func TestNotifier_prepare_passthrough(t *testing.T) {
+	now := time.Date(2021, 1, 1, 9, 0, 0, 0, time.UTC)
+	alerts := []Alert{
+		{RuleID: "rule-2", Service: "whoami@default"},
+		{RuleID: "rule-1", Ingress: "whoami@default.ingress.networking.k8s.io"},
+	}
+
+	n := newNotifier(NotifierConfig{})
+
+	got := n.prepare(context.Background(), alerts, now)
+	assert.Equal(t, alerts, got)
+
+	n.markSent(got, now)
+
+	got = n.prepare(context.Background(), alerts, now.Add(time.Minute))
+	assert.Equal(t, alerts, got)
+}
+
+func TestNotifier_prepare_silences(t *testing.T) {
+	now := time.Date(2021, 1, 1, 9, 0, 0, 0, time.UTC)
+
+	n := newNotifier(NotifierConfig{
+		Silences: []SilenceProvider{
+			silenceProviderFunc(func(context.Context) ([]Silence, error) {
+				return []Silence{{RuleID: "rule-1", EndsAt: now.Add(time.Hour)}}, nil
+			}),
+			silenceProviderFunc(func(context.Context) ([]Silence, error) {
+				return nil, errors.New("boom")
+			}),
+		},
+	})
+
+	got := n.prepare(context.Background(), []Alert{
+		{RuleID: "rule-1", Service: "whoami@default"},
+		{RuleID: "rule-2", Service: "whoami@default"},
+	}, now)
+
+	assert.Equal(t, []Alert{{RuleID: "rule-2", Service: "whoami@default"}}, got)
+}
+
+func TestNotifier_prepare_deduplicates(t *testing.T) {
+	now := time.Date(2021, 1, 1, 9, 0, 0, 0, time.UTC)
+	alert := Alert{RuleID: "rule-1", Service: "whoami@default", Points: []Point{{Timestamp: 1, Value: 10}}}
+
+	n := newNotifier(NotifierConfig{DedupWindow: time.Hour})
+
+	got := n.prepare(context.Background(), []Alert{alert, alert}, now)
+	assert.Equal(t, []Alert{alert}, got)
+
+	n.markSent(got, now)
+
+	got = n.prepare(context.Background(), []Alert{alert}, now.Add(30*time.Minute))
+	assert.Empty(t, got)
+
+	got = n.prepare(context.Background(), []Alert{alert}, now.Add(time.Hour))
+	assert.Equal(t, []Alert{alert}, got)
+}
+
+func TestNotifier_prepare_groups(t *testing.T) {
+	now := time.Date(2021, 1, 1, 9, 0, 0, 0, time.UTC)
+
+	n := newNotifier(NotifierConfig{GroupWindow: 2 * time.Minute})
+
+	got := n.prepare(context.Background(), []Alert{
+		{RuleID: "rule-1", Service: "whoami@default"},
+		{RuleID: "rule-2", Ingress: "whoami@other.ingress.networking.k8s.io"},
+	}, now)
+	assert.Empty(t, got)
+
+	got = n.prepare(context.Background(), []Alert{
+		{RuleID: "rule-1", Service: "whoami@default", Points: []Point{{Timestamp: 2, Value: 20}}},
+		{RuleID: "rule-3", Service: "whoami@default"},
+		{RuleID: "rule-4"},
+	}, now.Add(time.Minute))
+	assert.Empty(t, got)
+
+	got = n.prepare(context.Background(), nil, now.Add(2*time.Minute))
+	assert.Equal(t, []Alert{
+		{RuleID: "rule-1", Service: "whoami@default", Points: []Point{{Timestamp: 2, Value: 20}}, Group: "whoami@default"},
+		{RuleID: "rule-3", Service: "whoami@default", Group: "whoami@default"},
+		{RuleID: "rule-2", Ingress: "whoami@other.ingress.networking.k8s.io", Group: "other"},
+	}, got)
+
+	got = n.prepare(context.Background(), nil, now.Add(3*time.Minute))
+	assert.Equal(t, []Alert{{RuleID: "rule-4", Group: "rule-4"}}, got)
+}
@@ -74,22 +74,9 @@ func (p *ThresholdProcessor) Process(ctx context.Context, rule *Rule) (*Alert, e
 	to := p.nowFunc().UTC().Truncate(granularity).Add(-granularity)
 	from := to.Add(-rule.Threshold.TimeRange)
 
-	var dataPoints metrics.DataPoints
-
-	switch {
-	case rule.Ingress != "" && rule.Service != "":
-		var err error
-
-		dataPoints, err = p.dataPoints.FindByIngressAndService(table, rule.Ingress, rule.Service, from, to)
-		if err != nil {
-			return nil, err
-		}
-	case rule.Service != "":
-		dataPoints = p.dataPoints.FindByService(table, rule.Service, from, to)
-	case rule.Ingress != "":
-		dataPoints = p.dataPoints.FindByIngress(table, rule.Ingress, from, to)
-	default:
-		return nil, errors.New("invalid rule")
+	dataPoints, err := findDataPoints(p.dataPoints, rule, table, from, to)
+	if err != nil {
+		return nil, err
 	}
 
 	var points []Point
@@ -112,7 +99,7 @@ func (p *ThresholdProcessor) Process(ctx context.Context, rule *Rule) (*Alert, e
 	}
 
 	// Grab pod logs selected by the service if there are some.
-	logs, err := p.getLogs(ctx, rule.Service)
+	logs, err := getLogs(ctx, p.logs, rule.Service)
 	if err != nil {
 		log.Error().Err(err).Str("service", rule.Service).Msg("Unable to get logs")
 	}
@@ -140,7 +127,82 @@ func (p *ThresholdProcessor) countOccurrences(rule *Rule, pnts []Point) int {
 	return count
 }
 
-func (p *ThresholdProcessor) getLogs(ctx context.Context, service string) ([]byte, error) {
+// ExpressionProcessor processes custom expression rules.
+type ExpressionProcessor struct {
+	dataPoints DataPointsFinder
+	logs       LogProvider
+
+	nowFunc func() time.Time
+}
+
+// NewExpressionProcessor returns an expression processor.
+func NewExpressionProcessor(dataPoints DataPointsFinder, logs LogProvider) *ExpressionProcessor {
+	return &ExpressionProcessor{
+		dataPoints: dataPoints,
+		logs:       logs,
+		nowFunc:    time.Now,
+	}
+}
+
+// Process processes an expression rule returning an alert or nil.
+func (p *ExpressionProcessor) Process(ctx context.Context, rule *Rule) (*Alert, error) {
+	expr, err := parseExpression(rule.Expression.Expr)
+	if err != nil {
+		return nil, fmt.Errorf("parse expression %q: %w", rule.Expression.Expr, err)
+	}
+
+	table := rule.Expression.Table()
+	granularity := rule.Expression.Granularity()
+
+	// Compute the time range (inclusive) the alert wants to be triggered on. The granularity is subtracted to
+	// avoid capturing the last data point which is not yet complete.
+	to := p.nowFunc().UTC().Truncate(granularity).Add(-granularity)
+	from := to.Add(-rule.Expression.TimeRange)
+
+	dataPoints, err := findDataPoints(p.dataPoints, rule, table, from, to)
+	if err != nil {
+		return nil, err
+	}
+	if len(dataPoints) == 0 {
+		return nil, nil
+	}
+
+	value, triggered := expr.evaluate(dataPoints)
+	if !triggered {
+		return nil, nil
+	}
+
+	// Grab pod logs selected by the service if there are some.
+	logs, err := getLogs(ctx, p.logs, rule.Service)
+	if err != nil {
+		log.Error().Err(err).Str("service", rule.Service).Msg("Unable to get logs")
+	}
+
+	return &Alert{
+		RuleID:     rule.ID,
+		Ingress:    rule.Ingress,
+		Service:    rule.Service,
+		Points:     []Point{{Timestamp: to.Unix(), Value: value}},
+		Logs:       logs,
+		Expression: rule.Expression,
+	}, nil
+}
+
+// findDataPoints finds the data points of the ingress and/or service targeted by the given rule.
+func findDataPoints(finder DataPointsFinder, rule *Rule, table string, from, to time.Time) (metrics.DataPoints, error) {
+	switch {
+	case rule.Ingress != "" && rule.Service != "":
+		return finder.FindByIngressAndService(table, rule.Ingress, rule.Service, from, to)
+	case rule.Service != "":
+		return finder.FindByService(table, rule.Service, from, to), nil
+	case rule.Ingress != "":
+		return finder.FindByIngress(table, rule.Ingress, from, to), nil
+	default:
+		return nil, errors.New("invalid rule")
+	}
+}
+
+func getLogs(ctx context.Context, provider LogProvider, service string) ([]byte, error) {
 	if service == "" {
 		return nil, nil
 	}
@@ -150,7 +212,7 @@ func (p *ThresholdProcessor) getLogs(ctx context.Context, service string) ([]byt
 		return nil, fmt.Errorf("invalid service name %q", service)
 	}
 
-	logs, err := p.logs.GetServiceLogs(ctx, parts[1], parts[0], logLines, logMaxLineLength)
+	logs, err := provider.GetServiceLogs(ctx, parts[1], parts[0], logLines, logMaxLineLength)
 	if err != nil {
 		return nil, fmt.Errorf("fetch service logs: %w", err)
 	}
@@ -173,6 +235,18 @@ func getValue(metric string, pnt metrics.DataPoint) (float64, error) {
 		return pnt.RequestClientErrPerS, nil
 	case "averageResponseTime":
 		return pnt.AvgResponseTime, nil
+	case "requests":
+		return float64(pnt.Requests), nil
+	case "requestErrors":
+		return float64(pnt.RequestErrs), nil
+	case "requestClientErrors":
+		return float64(pnt.RequestClientErrs), nil
+	case "responseTimeSum":
+		return pnt.ResponseTimeSum, nil
+	case "responseTimeCount":
+		return float64(pnt.ResponseTimeCount), nil
+	case "seconds":
+		return float64(pnt.Seconds), nil
 	default:
 		return 0, fmt.Errorf("invalid metric type: %s", metric)
 	}
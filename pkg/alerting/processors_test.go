@@ -820,6 +820,87 @@ func TestThresholdProcessor_Process(t *testing.T) {
 	}
 }
 
+func TestExpressionProcessor_Process(t *testing.T) {
+	now := time.Date(2021, 1, 1, 8, 21, 43, 0, time.UTC)
+	from := time.Date(2021, 1, 1, 8, 15, 0, 0, time.UTC)
+	to := time.Date(2021, 1, 1, 8, 20, 0, 0, time.UTC)
+
+	expr := &Expression{
+		Expr:      "sum(requestErrors) / sum(requests) > 0.05",
+		TimeRange: 5 * time.Minute,
+	}
+
+	tests := []struct {
+		desc       string
+		rule       *Rule
+		dataPoints metrics.DataPoints
+		wantAlert  *Alert
+		requireErr require.ErrorAssertionFunc
+	}{
+		{
+			desc: "Alert: error ratio above threshold",
+			rule: &Rule{ID: "rule-1", Ingress: "ingress@myns", Expression: expr},
+			dataPoints: metrics.DataPoints{
+				{Timestamp: now.Add(-4 * time.Minute).Unix(), Requests: 100, RequestErrs: 2},
+				{Timestamp: now.Add(-3 * time.Minute).Unix(), Requests: 100, RequestErrs: 18},
+			},
+			wantAlert: &Alert{
+				RuleID:     "rule-1",
+				Ingress:    "ingress@myns",
+				Points:     []Point{{Timestamp: to.Unix(), Value: 0.1}},
+				Expression: expr,
+			},
+			requireErr: require.NoError,
+		},
+		{
+			desc: "No alert: error ratio below threshold",
+			rule: &Rule{ID: "rule-1", Ingress: "ingress@myns", Expression: expr},
+			dataPoints: metrics.DataPoints{
+				{Timestamp: now.Add(-4 * time.Minute).Unix(), Requests: 100, RequestErrs: 2},
+				{Timestamp: now.Add(-3 * time.Minute).Unix(), Requests: 100, RequestErrs: 3},
+			},
+			requireErr: require.NoError,
+		},
+		{
+			desc:       "No alert: no data points",
+			rule:       &Rule{ID: "rule-1", Ingress: "ingress@myns", Expression: expr},
+			requireErr: require.NoError,
+		},
+		{
+			desc: "No alert: invalid expression",
+			rule: &Rule{ID: "rule-1", Ingress: "ingress@myns", Expression: &Expression{
+				Expr:      "sum(potatoes) > 1",
+				TimeRange: 5 * time.Minute,
+			}},
+			requireErr: require.Error,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			view := newDataPointsFinderMock(t)
+			if test.rule.Expression == expr {
+				view.
+					OnFindByIngress("1m", "ingress@myns", from, to).
+					TypedReturns(test.dataPoints).
+					Once()
+			}
+
+			exprProc := NewExpressionProcessor(view, newLogProviderMock(t))
+			exprProc.nowFunc = func() time.Time { return now }
+
+			alert, err := exprProc.Process(context.Background(), test.rule)
+			test.requireErr(t, err)
+
+			assert.Equal(t, test.wantAlert, alert)
+		})
+	}
+}
+
 func TestGetValue(t *testing.T) {
 	type expected struct {
 		value float64
@@ -856,6 +937,12 @@ func TestGetValue(t *testing.T) {
 			point:    metrics.DataPoint{AvgResponseTime: 100},
 			expected: expected{value: 100},
 		},
+		{
+			desc:     "with requests counter",
+			metric:   "requests",
+			point:    metrics.DataPoint{Requests: 100},
+			expected: expected{value: 100},
+		},
 		{
 			desc:   "with unknown metric",
 			metric: "requestsPerPotatoes",
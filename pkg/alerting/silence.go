@@ -0,0 +1,129 @@
+/*
+Copyright (C) 2022 Traefik Labs
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package alerting
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	hublistersv1alpha1 "github.com/traefik/hub-agent-kubernetes/pkg/crd/generated/client/hub/listers/hub/v1alpha1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// SilenceProvider provides silences.
+type SilenceProvider interface {
+	GetSilences(ctx context.Context) ([]Silence, error)
+}
+
+// Silence is a period during which matching alerts are not sent.
+// An alert matches a silence if it matches every field set on the silence.
+type Silence struct {
+	RuleID    string `json:"ruleId,omitempty"`
+	Namespace string `json:"namespace,omitempty"`
+	// Ingress is the ingress key, optionally without its kind and group (e.g. whoami@default).
+	Ingress  string    `json:"ingress,omitempty"`
+	Service  string    `json:"service,omitempty"`
+	StartsAt time.Time `json:"startsAt"`
+	EndsAt   time.Time `json:"endsAt"`
+}
+
+// Matches returns whether the given alert is silenced at the given time.
+func (s Silence) Matches(alert Alert, now time.Time) bool {
+	if now.Before(s.StartsAt) || !now.Before(s.EndsAt) {
+		return false
+	}
+
+	if s.RuleID != "" && s.RuleID != alert.RuleID {
+		return false
+	}
+	if s.Namespace != "" && s.Namespace != alertNamespace(alert) {
+		return false
+	}
+	if s.Ingress != "" && s.Ingress != alert.Ingress && !strings.HasPrefix(alert.Ingress, s.Ingress+".") {
+		return false
+	}
+	if s.Service != "" && s.Service != alert.Service {
+		return false
+	}
+
+	return true
+}
+
+// alertNamespace returns the namespace of the service or ingress targeted by the given alert,
+// or an empty string if it does not target any.
+func alertNamespace(alert Alert) string {
+	key := alert.Service
+	if key == "" {
+		key = alert.Ingress
+	}
+
+	i := strings.Index(key, "@")
+	if i < 0 {
+		return ""
+	}
+
+	ns := key[i+1:]
+	// Ingress keys are suffixed by their kind and group.
+	if j := strings.Index(ns, "."); j >= 0 {
+		ns = ns[:j]
+	}
+
+	return ns
+}
+
+// CRDSilenceProvider provides silences defined by AlertSilence resources.
+type CRDSilenceProvider struct {
+	lister hublistersv1alpha1.AlertSilenceLister
+}
+
+// NewCRDSilenceProvider returns a silence provider reading AlertSilence resources from the given lister.
+func NewCRDSilenceProvider(lister hublistersv1alpha1.AlertSilenceLister) *CRDSilenceProvider {
+	return &CRDSilenceProvider{lister: lister}
+}
+
+// GetSilences returns the silences defined by AlertSilence resources.
+func (p *CRDSilenceProvider) GetSilences(_ context.Context) ([]Silence, error) {
+	alertSilences, err := p.lister.List(labels.Everything())
+	if err != nil {
+		return nil, fmt.Errorf("list alert silences: %w", err)
+	}
+
+	silences := make([]Silence, 0, len(alertSilences))
+	for _, alertSilence := range alertSilences {
+		silence := Silence{
+			RuleID:    alertSilence.Spec.RuleID,
+			Namespace: alertSilence.Namespace,
+			EndsAt:    alertSilence.Spec.EndsAt.Time,
+		}
+		if alertSilence.Spec.StartsAt != nil {
+			silence.StartsAt = alertSilence.Spec.StartsAt.Time
+		}
+		if alertSilence.Spec.Ingress != "" {
+			silence.Ingress = alertSilence.Spec.Ingress + "@" + alertSilence.Namespace
+		}
+		if alertSilence.Spec.Service != "" {
+			silence.Service = alertSilence.Spec.Service + "@" + alertSilence.Namespace
+		}
+
+		silences = append(silences, silence)
+	}
+
+	return silences, nil
+}
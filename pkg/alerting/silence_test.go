@@ -0,0 +1,148 @@
+/*
+Copyright (C) 2022 Traefik Labs
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package alerting
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	hubv1alpha1 "github.com/traefik/hub-agent-kubernetes/pkg/crd/api/hub/v1alpha1"
+	hubkubemock "github.com/traefik/hub-agent-kubernetes/pkg/crd/generated/client/hub/clientset/versioned/fake"
+	hubinformer "github.com/traefik/hub-agent-kubernetes/pkg/crd/generated/client/hub/informers/externalversions"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+func TestSilence_Matches(t *testing.T) {
+	now := time.Date(2021, 1, 1, 9, 0, 0, 0, time.UTC)
+	alert := Alert{
+		RuleID:  "rule-1",
+		Ingress: "whoami@default.ingress.networking.k8s.io",
+		Service: "whoami@default",
+	}
+
+	tests := []struct {
+		desc    string
+		silence Silence
+		want    bool
+	}{
+		{
+			desc:    "matches everything",
+			silence: Silence{EndsAt: now.Add(time.Hour)},
+			want:    true,
+		},
+		{
+			desc:    "not started",
+			silence: Silence{StartsAt: now.Add(time.Minute), EndsAt: now.Add(time.Hour)},
+		},
+		{
+			desc:    "ended",
+			silence: Silence{StartsAt: now.Add(-time.Hour), EndsAt: now},
+		},
+		{
+			desc:    "matching rule",
+			silence: Silence{RuleID: "rule-1", EndsAt: now.Add(time.Hour)},
+			want:    true,
+		},
+		{
+			desc:    "other rule",
+			silence: Silence{RuleID: "rule-2", EndsAt: now.Add(time.Hour)},
+		},
+		{
+			desc:    "matching namespace",
+			silence: Silence{Namespace: "default", EndsAt: now.Add(time.Hour)},
+			want:    true,
+		},
+		{
+			desc:    "other namespace",
+			silence: Silence{Namespace: "other", EndsAt: now.Add(time.Hour)},
+		},
+		{
+			desc:    "matching ingress key",
+			silence: Silence{Ingress: "whoami@default.ingress.networking.k8s.io", EndsAt: now.Add(time.Hour)},
+			want:    true,
+		},
+		{
+			desc:    "matching ingress without kind and group",
+			silence: Silence{Ingress: "whoami@default", EndsAt: now.Add(time.Hour)},
+			want:    true,
+		},
+		{
+			desc:    "other ingress",
+			silence: Silence{Ingress: "whoami@other", EndsAt: now.Add(time.Hour)},
+		},
+		{
+			desc:    "other service",
+			silence: Silence{RuleID: "rule-1", Service: "other@default", EndsAt: now.Add(time.Hour)},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, test.want, test.silence.Matches(alert, now))
+		})
+	}
+}
+
+func TestCRDSilenceProvider_GetSilences(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	startsAt := metav1.NewTime(time.Date(2021, 1, 1, 8, 0, 0, 0, time.UTC))
+	endsAt := metav1.NewTime(time.Date(2021, 1, 1, 10, 0, 0, 0, time.UTC))
+
+	alertSilence := &hubv1alpha1.AlertSilence{
+		ObjectMeta: metav1.ObjectMeta{Name: "maintenance", Namespace: "default"},
+		Spec: hubv1alpha1.AlertSilenceSpec{
+			RuleID:   "rule-1",
+			Ingress:  "whoami",
+			Service:  "whoami",
+			StartsAt: &startsAt,
+			EndsAt:   endsAt,
+			Comment:  "Database migration",
+		},
+	}
+
+	hubInformer := hubinformer.NewSharedInformerFactory(hubkubemock.NewSimpleClientset(alertSilence), 0)
+	alertSilenceInformer := hubInformer.Hub().V1alpha1().AlertSilences().Informer()
+	hubInformer.Start(ctx.Done())
+	cache.WaitForCacheSync(ctx.Done(), alertSilenceInformer.HasSynced)
+
+	provider := NewCRDSilenceProvider(hubInformer.Hub().V1alpha1().AlertSilences().Lister())
+
+	got, err := provider.GetSilences(ctx)
+	require.NoError(t, err)
+
+	want := []Silence{
+		{
+			RuleID:    "rule-1",
+			Namespace: "default",
+			Ingress:   "whoami@default",
+			Service:   "whoami@default",
+			StartsAt:  startsAt.Time,
+			EndsAt:    endsAt.Time,
+		},
+	}
+	assert.Equal(t, want, got)
+}
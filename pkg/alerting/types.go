@@ -4,8 +4,10 @@ import "time"
 
 // Rule types.
 const (
-	UnknownType   = "unknown"
-	ThresholdType = "threshold"
+	UnknownType           = "unknown"
+	ThresholdType         = "threshold"
+	ExpressionType        = "expression"
+	CertificateExpiryType = "certificateExpiry"
 )
 
 // Rule defines evaluation configuration for alerting
@@ -15,15 +17,23 @@ type Rule struct {
 	Ingress string `json:"ingress"`
 	Service string `json:"service"`
 
-	Threshold *Threshold `json:"threshold"`
+	Threshold         *Threshold         `json:"threshold"`
+	Expression        *Expression        `json:"expression"`
+	CertificateExpiry *CertificateExpiry `json:"certificateExpiry"`
 }
 
-// Type returns the rule type (for now, only threshold).
+// Type returns the rule type.
 func (r *Rule) Type() string {
-	if r.Threshold != nil {
+	switch {
+	case r.Threshold != nil:
 		return ThresholdType
+	case r.Expression != nil:
+		return ExpressionType
+	case r.CertificateExpiry != nil:
+		return CertificateExpiryType
+	default:
+		return UnknownType
 	}
-	return UnknownType
 }
 
 // Threshold contains a threshold and its direction.
@@ -36,38 +46,70 @@ type Threshold struct {
 
 // Table returns the metrics table containing the data points.
 func (t Threshold) Table() string {
+	return table(t.TimeRange)
+}
+
+// Granularity returns the metrics point granularity.
+func (t Threshold) Granularity() time.Duration {
+	return granularity(t.TimeRange)
+}
+
+// ThresholdCondition contains a threshold condition.
+type ThresholdCondition struct {
+	Above bool    `json:"above"`
+	Value float64 `json:"value"`
+}
+
+// Expression contains a custom expression evaluated over the data points of a time range. It compares two
+// arithmetic expressions over aggregated metrics, for instance:
+//   - sum(requestErrors) / sum(requests) > 0.05
+//   - histogram_quantile(0.99, responseTime) > 0.5
+type Expression struct {
+	Expr      string        `json:"expr"`
+	TimeRange time.Duration `json:"timeRange"`
+}
+
+// Table returns the metrics table containing the data points.
+func (e Expression) Table() string {
+	return table(e.TimeRange)
+}
+
+// Granularity returns the metrics point granularity.
+func (e Expression) Granularity() time.Duration {
+	return granularity(e.TimeRange)
+}
+
+// CertificateExpiry alerts on the TLS certificates expiring within the given number of days.
+type CertificateExpiry struct {
+	Days int `json:"days"`
+}
+
+func table(timeRange time.Duration) string {
 	switch {
-	case t.TimeRange > 24*time.Hour:
+	case timeRange > 24*time.Hour:
 		return "1d"
-	case t.TimeRange > time.Hour:
+	case timeRange > time.Hour:
 		return "1h"
-	case t.TimeRange > 10*time.Minute:
+	case timeRange > 10*time.Minute:
 		return "10m"
 	default:
 		return "1m"
 	}
 }
 
-// Granularity returns the metrics point granularity.
-func (t Threshold) Granularity() time.Duration {
+func granularity(timeRange time.Duration) time.Duration {
 	switch {
-	case t.TimeRange > 24*time.Hour:
+	case timeRange > 24*time.Hour:
 		return 24 * time.Hour
-	case t.TimeRange > time.Hour:
+	case timeRange > time.Hour:
 		return time.Hour
-	case t.TimeRange > 10*time.Minute:
+	case timeRange > 10*time.Minute:
 		return 10 * time.Minute
 	default:
 		return time.Minute
 	}
 }
 
-// ThresholdCondition contains a threshold condition.
-type ThresholdCondition struct {
-	Above bool    `json:"above"`
-	Value float64 `json:"value"`
-}
-
 // Alert contains alert information.
 type Alert struct {
 	RuleID    string     `json:"ruleId"`
@@ -76,6 +118,22 @@ type Alert struct {
 	Points    []Point    `json:"points"`
 	Logs      []byte     `json:"logs"`
 	Threshold *Threshold `json:"threshold"`
+	// Expression is set for alerts raised by expression rules, in which case Points holds the evaluated value.
+	Expression *Expression `json:"expression,omitempty"`
+	// CertificateExpiry is set for alerts raised by certificate expiry rules, in which case Certificates holds the
+	// expiring certificates, and Points the number of days before the first one expires.
+	CertificateExpiry *CertificateExpiry `json:"certificateExpiry,omitempty"`
+	Certificates      []Certificate      `json:"certificates,omitempty"`
+	// Group is set when alerts are grouped, to the key shared by the alerts sent together.
+	Group string `json:"group,omitempty"`
+}
+
+// Certificate describes a TLS certificate.
+type Certificate struct {
+	Name      string    `json:"name"`
+	Namespace string    `json:"namespace"`
+	Domains   []string  `json:"domains,omitempty"`
+	NotAfter  time.Time `json:"notAfter"`
 }
 
 // Point contains a point and its timestamp.
@@ -0,0 +1,178 @@
+/*
+Copyright (C) 2022 Traefik Labs
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package configfile
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/ettle/strcase"
+	"github.com/urfave/cli/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// FlagName is the name of the flag giving the configuration file.
+const FlagName = "config"
+
+// Wrap makes the flags of the given command configurable from a YAML or TOML file, given by the config flag. Settings
+// are named after the flags, either nested or dotted. Flags set on the command line or through environment variables
+// take precedence over the file.
+func Wrap(cmd *cli.Command) *cli.Command {
+	// Required flags are checked before the file is loaded, they are checked once it is instead.
+	var required []string
+	for _, flg := range cmd.Flags {
+		if strFlag, ok := flg.(*cli.StringFlag); ok && strFlag.Required {
+			strFlag.Required = false
+			required = append(required, strFlag.Name)
+		}
+	}
+
+	cmd.Flags = append(cmd.Flags, &cli.PathFlag{
+		Name:    FlagName,
+		Usage:   "Path to a YAML or TOML configuration file setting the flags of the command",
+		EnvVars: []string{strcase.ToSNAKE(FlagName)},
+	})
+
+	before := cmd.Before
+	cmd.Before = func(cliCtx *cli.Context) error {
+		if err := load(cliCtx, cmd.Flags); err != nil {
+			return fmt.Errorf("load configuration file: %w", err)
+		}
+
+		for _, name := range required {
+			if !cliCtx.IsSet(name) {
+				return fmt.Errorf("required flag %q not set", name)
+			}
+		}
+
+		if before != nil {
+			return before(cliCtx)
+		}
+		return nil
+	}
+
+	return cmd
+}
+
+// load sets the flags which are not already set from the configuration file, if any.
+func load(cliCtx *cli.Context, flags []cli.Flag) error {
+	path := cliCtx.Path(FlagName)
+	if path == "" {
+		return nil
+	}
+
+	settings, err := read(path)
+	if err != nil {
+		return err
+	}
+
+	known := make(map[string]cli.Flag)
+	for _, flg := range flags {
+		for _, name := range flg.Names() {
+			known[name] = flg
+		}
+	}
+
+	names := make([]string, 0, len(settings))
+	for name := range settings {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		flg, ok := known[name]
+		if !ok || name == FlagName {
+			return fmt.Errorf("unknown setting %q", name)
+		}
+
+		if cliCtx.IsSet(name) {
+			continue
+		}
+
+		values, isList := settings[name].([]interface{})
+		if !isList {
+			values = []interface{}{settings[name]}
+		} else if _, ok = flg.(*cli.StringSliceFlag); !ok {
+			return fmt.Errorf("setting %q does not accept a list", name)
+		}
+
+		for _, value := range values {
+			if err = cliCtx.Set(name, fmt.Sprint(value)); err != nil {
+				return fmt.Errorf("invalid value for setting %q: %w", name, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// read reads the settings of the given configuration file, whose format is deduced from its extension. Nested settings
+// are flattened into dotted names.
+func read(path string) (map[string]interface{}, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read file: %w", err)
+	}
+
+	raw := make(map[string]interface{})
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &raw)
+	case ".toml":
+		err = toml.Unmarshal(data, &raw)
+	default:
+		return nil, fmt.Errorf("unsupported file extension %q, must be .yaml, .yml or .toml", ext)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("decode file: %w", err)
+	}
+
+	settings := make(map[string]interface{})
+	if err = flatten(settings, "", raw); err != nil {
+		return nil, err
+	}
+
+	return settings, nil
+}
+
+// flatten adds the given raw settings to settings, naming nested settings after their parents.
+func flatten(settings map[string]interface{}, prefix string, raw map[string]interface{}) error {
+	for key, value := range raw {
+		name := prefix + key
+
+		switch v := value.(type) {
+		case map[string]interface{}:
+			if err := flatten(settings, name+".", v); err != nil {
+				return err
+			}
+		case nil:
+			return fmt.Errorf("setting %q has no value", name)
+		default:
+			if _, ok := settings[name]; ok {
+				return fmt.Errorf("setting %q is defined twice", name)
+			}
+			settings[name] = v
+		}
+	}
+
+	return nil
+}
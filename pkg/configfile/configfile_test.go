@@ -0,0 +1,269 @@
+/*
+Copyright (C) 2022 Traefik Labs
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package configfile_test
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/traefik/hub-agent-kubernetes/pkg/configfile"
+	"github.com/urfave/cli/v2"
+)
+
+func TestWrap(t *testing.T) {
+	tests := []struct {
+		desc    string
+		file    string
+		content string
+		args    []string
+		want    map[string]interface{}
+		wantErr string
+	}{
+		{
+			desc: "YAML file",
+			file: "config.yaml",
+			content: `
+token: file-token
+log-level: debug
+tunnel:
+  pool-size: 2
+watch-namespaces:
+  - ns1
+  - ns2
+`,
+			want: map[string]interface{}{
+				"token":            "file-token",
+				"log-level":        "debug",
+				"tunnel.pool-size": 2,
+				"watch-namespaces": []string{"ns1", "ns2"},
+			},
+		},
+		{
+			desc: "TOML file",
+			file: "config.toml",
+			content: `
+token = "file-token"
+log-level = "debug"
+watch-namespaces = ["ns1", "ns2"]
+
+[tunnel]
+pool-size = 2
+`,
+			want: map[string]interface{}{
+				"token":            "file-token",
+				"log-level":        "debug",
+				"tunnel.pool-size": 2,
+				"watch-namespaces": []string{"ns1", "ns2"},
+			},
+		},
+		{
+			desc: "dotted settings",
+			file: "config.yaml",
+			content: `
+token: file-token
+tunnel.pool-size: 2
+`,
+			want: map[string]interface{}{
+				"token":            "file-token",
+				"log-level":        "info",
+				"tunnel.pool-size": 2,
+				"watch-namespaces": []string(nil),
+			},
+		},
+		{
+			desc: "setting both nested and dotted",
+			file: "config.yaml",
+			content: `
+token: file-token
+tunnel.pool-size: 2
+tunnel:
+  pool-size: 3
+`,
+			wantErr: `load configuration file: setting "tunnel.pool-size" is defined twice`,
+		},
+		{
+			desc: "flags take precedence over the file",
+			file: "config.yaml",
+			content: `
+token: file-token
+log-level: debug
+watch-namespaces: [ns1, ns2]
+`,
+			args: []string{"--log-level", "warn", "--watch-namespaces", "ns3"},
+			want: map[string]interface{}{
+				"token":            "file-token",
+				"log-level":        "warn",
+				"tunnel.pool-size": 1,
+				"watch-namespaces": []string{"ns3"},
+			},
+		},
+		{
+			desc: "single value for a list flag",
+			file: "config.yaml",
+			content: `
+token: file-token
+watch-namespaces: ns1
+`,
+			want: map[string]interface{}{
+				"token":            "file-token",
+				"log-level":        "info",
+				"tunnel.pool-size": 1,
+				"watch-namespaces": []string{"ns1"},
+			},
+		},
+		{
+			desc: "list for a flag which is not a list",
+			file: "config.yaml",
+			content: `
+token: [token1, token2]
+`,
+			wantErr: `load configuration file: setting "token" does not accept a list`,
+		},
+		{
+			desc: "required flag set on the command line",
+			file: "config.yaml",
+			content: `
+log-level: debug
+`,
+			args: []string{"--token", "flag-token"},
+			want: map[string]interface{}{
+				"token":            "flag-token",
+				"log-level":        "debug",
+				"tunnel.pool-size": 1,
+				"watch-namespaces": []string(nil),
+			},
+		},
+		{
+			desc: "required flag not set",
+			file: "config.yaml",
+			content: `
+log-level: debug
+`,
+			wantErr: `required flag "token" not set`,
+		},
+		{
+			desc:    "unknown setting",
+			file:    "config.yaml",
+			content: `token: file-token` + "\n" + `unknown: value`,
+			wantErr: `load configuration file: unknown setting "unknown"`,
+		},
+		{
+			desc:    "config setting",
+			file:    "config.yaml",
+			content: `config: other.yaml`,
+			wantErr: `load configuration file: unknown setting "config"`,
+		},
+		{
+			desc:    "invalid value",
+			file:    "config.yaml",
+			content: `token: file-token` + "\n" + `tunnel.pool-size: many`,
+			wantErr: `load configuration file: invalid value for setting "tunnel.pool-size": parse error`,
+		},
+		{
+			desc:    "unsupported extension",
+			file:    "config.json",
+			content: `{"token": "file-token"}`,
+			wantErr: `load configuration file: unsupported file extension ".json", must be .yaml, .yml or .toml`,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			path := filepath.Join(t.TempDir(), test.file)
+			require.NoError(t, os.WriteFile(path, []byte(test.content), 0o600))
+
+			got, err := run(t, append([]string{"--config", path}, test.args...))
+			if test.wantErr != "" {
+				assert.EqualError(t, err, test.wantErr)
+				return
+			}
+			require.NoError(t, err)
+
+			assert.Equal(t, test.want, got)
+		})
+	}
+}
+
+func TestWrap_envOverFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("token: file-token\nlog-level: debug\n"), 0o600))
+
+	t.Setenv("CONFIG", path)
+	t.Setenv("LOG_LEVEL", "error")
+
+	got, err := run(t, nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, "file-token", got["token"])
+	assert.Equal(t, "error", got["log-level"])
+}
+
+func TestWrap_noFile(t *testing.T) {
+	got, err := run(t, []string{"--token", "flag-token"})
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]interface{}{
+		"token":            "flag-token",
+		"log-level":        "info",
+		"tunnel.pool-size": 1,
+		"watch-namespaces": []string(nil),
+	}, got)
+}
+
+// run runs a command wrapped by configfile.Wrap with the given arguments, and returns the values of its flags.
+func run(t *testing.T, args []string) (map[string]interface{}, error) {
+	t.Helper()
+
+	var got map[string]interface{}
+	cmd := configfile.Wrap(&cli.Command{
+		Name: "cmd",
+		Flags: []cli.Flag{
+			&cli.StringFlag{Name: "token", EnvVars: []string{"TOKEN"}, Required: true},
+			&cli.StringFlag{Name: "log-level", EnvVars: []string{"LOG_LEVEL"}, Value: "info"},
+			&cli.IntFlag{Name: "tunnel.pool-size", Value: 1},
+			&cli.StringSliceFlag{Name: "watch-namespaces"},
+		},
+		Action: func(cliCtx *cli.Context) error {
+			got = map[string]interface{}{
+				"token":            cliCtx.String("token"),
+				"log-level":        cliCtx.String("log-level"),
+				"tunnel.pool-size": cliCtx.Int("tunnel.pool-size"),
+				"watch-namespaces": cliCtx.StringSlice("watch-namespaces"),
+			}
+			return nil
+		},
+	})
+
+	app := &cli.App{
+		Name:      "app",
+		Writer:    io.Discard,
+		ErrWriter: io.Discard,
+		Commands:  []*cli.Command{cmd},
+	}
+
+	err := app.Run(append([]string{"app", "cmd"}, args...))
+
+	return got, err
+}
@@ -32,6 +32,7 @@ import (
 
 // AccessControlPolicy defines an access control policy.
 // +kubebuilder:resource:scope=Cluster
+// +kubebuilder:storageversion
 type AccessControlPolicy struct {
 	metav1.TypeMeta `json:",inline"`
 	// +optional
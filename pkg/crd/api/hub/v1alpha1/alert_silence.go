@@ -0,0 +1,75 @@
+/*
+Copyright (C) 2022 Traefik Labs
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package v1alpha1
+
+import metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+// +genclient
+// +genclient:noStatus
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// AlertSilence defines a period during which matching alerts are not sent.
+// +kubebuilder:printcolumn:name="Rule",type=string,JSONPath=`.spec.ruleId`
+// +kubebuilder:printcolumn:name="Service",type=string,JSONPath=`.spec.service`
+// +kubebuilder:printcolumn:name="Starts At",type=date,JSONPath=`.spec.startsAt`
+// +kubebuilder:printcolumn:name="Ends At",type=date,JSONPath=`.spec.endsAt`
+type AlertSilence struct {
+	metav1.TypeMeta `json:",inline"`
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec AlertSilenceSpec `json:"spec,omitempty"`
+}
+
+// AlertSilenceSpec configures an alert silence.
+// Alerts are matched in the namespace of the silence, on every field that is set.
+type AlertSilenceSpec struct {
+	// RuleID is the ID of the silenced alert rule. All rules are silenced if empty.
+	// +optional
+	RuleID string `json:"ruleId,omitempty"`
+
+	// Ingress is the name of the silenced ingress, in the namespace of the silence.
+	// +optional
+	Ingress string `json:"ingress,omitempty"`
+
+	// Service is the name of the silenced service, in the namespace of the silence.
+	// +optional
+	Service string `json:"service,omitempty"`
+
+	// StartsAt is when the silence starts. The silence starts immediately if unset.
+	// +optional
+	StartsAt *metav1.Time `json:"startsAt,omitempty"`
+
+	// EndsAt is when the silence ends.
+	EndsAt metav1.Time `json:"endsAt"`
+
+	// Comment explains why alerts are silenced.
+	// +optional
+	Comment string `json:"comment,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// AlertSilenceList defines a list of alert silences.
+type AlertSilenceList struct {
+	metav1.TypeMeta `json:",inline"`
+	// +optional
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []AlertSilence `json:"items"`
+}
@@ -22,6 +22,7 @@ limitations under the License.
 package v1alpha1
 
 import (
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
@@ -173,6 +174,87 @@ func (in *AccessControlPolicyStatus) DeepCopy() *AccessControlPolicyStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AlertSilence) DeepCopyInto(out *AlertSilence) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AlertSilence.
+func (in *AlertSilence) DeepCopy() *AlertSilence {
+	if in == nil {
+		return nil
+	}
+	out := new(AlertSilence)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *AlertSilence) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AlertSilenceList) DeepCopyInto(out *AlertSilenceList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]AlertSilence, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AlertSilenceList.
+func (in *AlertSilenceList) DeepCopy() *AlertSilenceList {
+	if in == nil {
+		return nil
+	}
+	out := new(AlertSilenceList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *AlertSilenceList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AlertSilenceSpec) DeepCopyInto(out *AlertSilenceSpec) {
+	*out = *in
+	if in.StartsAt != nil {
+		in, out := &in.StartsAt, &out.StartsAt
+		*out = (*in).DeepCopy()
+	}
+	in.EndsAt.DeepCopyInto(&out.EndsAt)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AlertSilenceSpec.
+func (in *AlertSilenceSpec) DeepCopy() *AlertSilenceSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(AlertSilenceSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *EdgeIngress) DeepCopyInto(out *EdgeIngress) {
 	*out = *in
@@ -217,6 +299,97 @@ func (in *EdgeIngressACP) DeepCopy() *EdgeIngressACP {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EdgeIngressBandwidth) DeepCopyInto(out *EdgeIngressBandwidth) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EdgeIngressBandwidth.
+func (in *EdgeIngressBandwidth) DeepCopy() *EdgeIngressBandwidth {
+	if in == nil {
+		return nil
+	}
+	out := new(EdgeIngressBandwidth)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EdgeIngressCompression) DeepCopyInto(out *EdgeIngressCompression) {
+	*out = *in
+	if in.ExcludedContentTypes != nil {
+		in, out := &in.ExcludedContentTypes, &out.ExcludedContentTypes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EdgeIngressCompression.
+func (in *EdgeIngressCompression) DeepCopy() *EdgeIngressCompression {
+	if in == nil {
+		return nil
+	}
+	out := new(EdgeIngressCompression)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EdgeIngressHeaderRules) DeepCopyInto(out *EdgeIngressHeaderRules) {
+	*out = *in
+	if in.Set != nil {
+		in, out := &in.Set, &out.Set
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Remove != nil {
+		in, out := &in.Remove, &out.Remove
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EdgeIngressHeaderRules.
+func (in *EdgeIngressHeaderRules) DeepCopy() *EdgeIngressHeaderRules {
+	if in == nil {
+		return nil
+	}
+	out := new(EdgeIngressHeaderRules)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EdgeIngressHeaders) DeepCopyInto(out *EdgeIngressHeaders) {
+	*out = *in
+	if in.Request != nil {
+		in, out := &in.Request, &out.Request
+		*out = new(EdgeIngressHeaderRules)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Response != nil {
+		in, out := &in.Response, &out.Response
+		*out = new(EdgeIngressHeaderRules)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EdgeIngressHeaders.
+func (in *EdgeIngressHeaders) DeepCopy() *EdgeIngressHeaders {
+	if in == nil {
+		return nil
+	}
+	out := new(EdgeIngressHeaders)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *EdgeIngressList) DeepCopyInto(out *EdgeIngressList) {
 	*out = *in
@@ -250,6 +423,64 @@ func (in *EdgeIngressList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EdgeIngressMaintenance) DeepCopyInto(out *EdgeIngressMaintenance) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EdgeIngressMaintenance.
+func (in *EdgeIngressMaintenance) DeepCopy() *EdgeIngressMaintenance {
+	if in == nil {
+		return nil
+	}
+	out := new(EdgeIngressMaintenance)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EdgeIngressMiddlewares) DeepCopyInto(out *EdgeIngressMiddlewares) {
+	*out = *in
+	if in.RateLimit != nil {
+		in, out := &in.RateLimit, &out.RateLimit
+		*out = new(EdgeIngressRateLimit)
+		**out = **in
+	}
+	if in.Compress != nil {
+		in, out := &in.Compress, &out.Compress
+		*out = new(EdgeIngressCompression)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EdgeIngressMiddlewares.
+func (in *EdgeIngressMiddlewares) DeepCopy() *EdgeIngressMiddlewares {
+	if in == nil {
+		return nil
+	}
+	out := new(EdgeIngressMiddlewares)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EdgeIngressRateLimit) DeepCopyInto(out *EdgeIngressRateLimit) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EdgeIngressRateLimit.
+func (in *EdgeIngressRateLimit) DeepCopy() *EdgeIngressRateLimit {
+	if in == nil {
+		return nil
+	}
+	out := new(EdgeIngressRateLimit)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *EdgeIngressService) DeepCopyInto(out *EdgeIngressService) {
 	*out = *in
@@ -275,6 +506,75 @@ func (in *EdgeIngressSpec) DeepCopyInto(out *EdgeIngressSpec) {
 		*out = new(EdgeIngressACP)
 		**out = **in
 	}
+	if in.ACPs != nil {
+		in, out := &in.ACPs, &out.ACPs
+		*out = make([]EdgeIngressACP, len(*in))
+		copy(*out, *in)
+	}
+	if in.Middlewares != nil {
+		in, out := &in.Middlewares, &out.Middlewares
+		*out = new(EdgeIngressMiddlewares)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.IngressAnnotations != nil {
+		in, out := &in.IngressAnnotations, &out.IngressAnnotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.IngressLabels != nil {
+		in, out := &in.IngressLabels, &out.IngressLabels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Services != nil {
+		in, out := &in.Services, &out.Services
+		*out = make([]EdgeIngressWeightedService, len(*in))
+		copy(*out, *in)
+	}
+	if in.Sticky != nil {
+		in, out := &in.Sticky, &out.Sticky
+		*out = new(EdgeIngressSticky)
+		**out = **in
+	}
+	if in.CustomDomains != nil {
+		in, out := &in.CustomDomains, &out.CustomDomains
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.EntryPoints != nil {
+		in, out := &in.EntryPoints, &out.EntryPoints
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.TLS != nil {
+		in, out := &in.TLS, &out.TLS
+		*out = new(EdgeIngressTLS)
+		**out = **in
+	}
+	if in.AllowedSourceIPs != nil {
+		in, out := &in.AllowedSourceIPs, &out.AllowedSourceIPs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Headers != nil {
+		in, out := &in.Headers, &out.Headers
+		*out = new(EdgeIngressHeaders)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Maintenance != nil {
+		in, out := &in.Maintenance, &out.Maintenance
+		*out = new(EdgeIngressMaintenance)
+		**out = **in
+	}
+	if in.Bandwidth != nil {
+		in, out := &in.Bandwidth, &out.Bandwidth
+		*out = new(EdgeIngressBandwidth)
+		**out = **in
+	}
 	return
 }
 
@@ -292,6 +592,28 @@ func (in *EdgeIngressSpec) DeepCopy() *EdgeIngressSpec {
 func (in *EdgeIngressStatus) DeepCopyInto(out *EdgeIngressStatus) {
 	*out = *in
 	in.SyncedAt.DeepCopyInto(&out.SyncedAt)
+	if in.CustomDomains != nil {
+		in, out := &in.CustomDomains, &out.CustomDomains
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Traffic != nil {
+		in, out := &in.Traffic, &out.Traffic
+		*out = new(EdgeIngressTraffic)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Tunnel != nil {
+		in, out := &in.Tunnel, &out.Tunnel
+		*out = new(EdgeIngressTunnel)
+		**out = **in
+	}
 	return
 }
 
@@ -305,6 +627,90 @@ func (in *EdgeIngressStatus) DeepCopy() *EdgeIngressStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EdgeIngressSticky) DeepCopyInto(out *EdgeIngressSticky) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EdgeIngressSticky.
+func (in *EdgeIngressSticky) DeepCopy() *EdgeIngressSticky {
+	if in == nil {
+		return nil
+	}
+	out := new(EdgeIngressSticky)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EdgeIngressTLS) DeepCopyInto(out *EdgeIngressTLS) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EdgeIngressTLS.
+func (in *EdgeIngressTLS) DeepCopy() *EdgeIngressTLS {
+	if in == nil {
+		return nil
+	}
+	out := new(EdgeIngressTLS)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EdgeIngressTraffic) DeepCopyInto(out *EdgeIngressTraffic) {
+	*out = *in
+	out.Window = in.Window
+	out.AverageResponseTime = in.AverageResponseTime
+	in.UpdatedAt.DeepCopyInto(&out.UpdatedAt)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EdgeIngressTraffic.
+func (in *EdgeIngressTraffic) DeepCopy() *EdgeIngressTraffic {
+	if in == nil {
+		return nil
+	}
+	out := new(EdgeIngressTraffic)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EdgeIngressTunnel) DeepCopyInto(out *EdgeIngressTunnel) {
+	*out = *in
+	out.RTT = in.RTT
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EdgeIngressTunnel.
+func (in *EdgeIngressTunnel) DeepCopy() *EdgeIngressTunnel {
+	if in == nil {
+		return nil
+	}
+	out := new(EdgeIngressTunnel)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EdgeIngressWeightedService) DeepCopyInto(out *EdgeIngressWeightedService) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EdgeIngressWeightedService.
+func (in *EdgeIngressWeightedService) DeepCopy() *EdgeIngressWeightedService {
+	if in == nil {
+		return nil
+	}
+	out := new(EdgeIngressWeightedService)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *IngressClass) DeepCopyInto(out *IngressClass) {
 	*out = *in
@@ -0,0 +1,100 @@
+/*
+Copyright (C) 2022 Traefik Labs
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package v1alpha2
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// AccessControlPolicy defines an access control policy.
+// +kubebuilder:resource:scope=Cluster
+type AccessControlPolicy struct {
+	metav1.TypeMeta `json:",inline"`
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec AccessControlPolicySpec `json:"spec,omitempty"`
+
+	// The current status of this access control policy.
+	// +optional
+	Status AccessControlPolicyStatus `json:"status,omitempty"`
+}
+
+// AccessControlPolicySpec configures an access control policy.
+// +kubebuilder:validation:XValidation:rule="has(self.jwt) != has(self.basicAuth)",message="exactly one of jwt or basicAuth must be set"
+type AccessControlPolicySpec struct {
+	JWT       *AccessControlPolicyJWT       `json:"jwt,omitempty"`
+	BasicAuth *AccessControlPolicyBasicAuth `json:"basicAuth,omitempty"`
+}
+
+// AccessControlPolicyJWT configures a JWT access control policy.
+type AccessControlPolicyJWT struct {
+	// SigningSecret is the secret used to verify the signature of HMAC-signed JWTs.
+	// +optional
+	SigningSecret *SecretValue `json:"signingSecret,omitempty"`
+
+	PublicKey                string            `json:"publicKey,omitempty"`
+	JWKsFile                 string            `json:"jwksFile,omitempty"`
+	JWKsURL                  string            `json:"jwksUrl,omitempty"`
+	StripAuthorizationHeader bool              `json:"stripAuthorizationHeader,omitempty"`
+	ForwardHeaders           map[string]string `json:"forwardHeaders,omitempty"`
+	TokenQueryKey            string            `json:"tokenQueryKey,omitempty"`
+	Claims                   string            `json:"claims,omitempty"`
+}
+
+// AccessControlPolicyBasicAuth holds the HTTP basic authentication configuration.
+type AccessControlPolicyBasicAuth struct {
+	// Users are the users allowed to authenticate, in the htpasswd format.
+	// +optional
+	Users []string `json:"users,omitempty"`
+
+	Realm                    string `json:"realm,omitempty"`
+	StripAuthorizationHeader bool   `json:"stripAuthorizationHeader,omitempty"`
+	ForwardUsernameHeader    string `json:"forwardUsernameHeader,omitempty"`
+}
+
+// SecretValue is a sensitive value.
+type SecretValue struct {
+	// Value is the inline value.
+	// +optional
+	Value string `json:"value,omitempty"`
+
+	// Base64Encoded tells whether the value is base64-encoded.
+	// +optional
+	Base64Encoded bool `json:"base64Encoded,omitempty"`
+}
+
+// AccessControlPolicyStatus is the status of the access control policy.
+type AccessControlPolicyStatus struct {
+	Version  string      `json:"version,omitempty"`
+	SyncedAt metav1.Time `json:"syncedAt,omitempty"`
+	SpecHash string      `json:"specHash,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// AccessControlPolicyList defines a list of access control policy.
+type AccessControlPolicyList struct {
+	metav1.TypeMeta `json:",inline"`
+	// +optional
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []AccessControlPolicy `json:"items"`
+}
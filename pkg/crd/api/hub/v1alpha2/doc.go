@@ -0,0 +1,6 @@
+// +k8s:deepcopy-gen=package
+// +groupName=hub.traefik.io
+
+// Package v1alpha2 is the v1alpha2 version of the hub API. Objects are stored in v1alpha1, and converted by the
+// conversion webhook of the agent. Types unchanged since v1alpha1 are shared with it.
+package v1alpha2
@@ -0,0 +1,362 @@
+/*
+Copyright (C) 2022 Traefik Labs
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package v1alpha2
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// EdgeIngress defines an edge ingress.
+// +kubebuilder:printcolumn:name="Mode",type=string,JSONPath=`.spec.mode`,priority=1
+// +kubebuilder:printcolumn:name="Service",type=string,JSONPath=`.spec.service.name`
+// +kubebuilder:printcolumn:name="Port",type=string,JSONPath=`.spec.service.port`
+// +kubebuilder:printcolumn:name="ACPs",type=string,JSONPath=`.spec.acps[*].name`,priority=1
+// +kubebuilder:printcolumn:name="URL",type=string,JSONPath=`.status.url`
+// +kubebuilder:printcolumn:name="Connection",type=string,JSONPath=`.status.connection`
+// +kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+// +kubebuilder:printcolumn:name="Reason",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].reason`,priority=1
+type EdgeIngress struct {
+	metav1.TypeMeta `json:",inline"`
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// The desired behavior of this edge ingress.
+	Spec EdgeIngressSpec `json:"spec,omitempty"`
+
+	// The current status of this edge ingress.
+	// +optional
+	Status EdgeIngressStatus `json:"status,omitempty"`
+}
+
+// EdgeIngressSpec configures an edge ingress. The single ACP of v1alpha1 is replaced by the ACPs list.
+type EdgeIngressSpec struct {
+	// Mode is the kind of traffic exposed on the edge. Defaults to HTTP.
+	// +optional
+	// +kubebuilder:validation:Enum=http;tcp
+	Mode EdgeIngressMode `json:"mode,omitempty"`
+
+	Service EdgeIngressService `json:"service"`
+
+	// ACPs are access control policies applied in order.
+	// +optional
+	ACPs []EdgeIngressACP `json:"acps,omitempty"`
+
+	// Middlewares are additional middlewares applied after the ACPs.
+	// +optional
+	Middlewares *EdgeIngressMiddlewares `json:"middlewares,omitempty"`
+
+	// IngressAnnotations are added to the resources generated to expose the service,
+	// for instance to set controller-specific options.
+	// +optional
+	IngressAnnotations map[string]string `json:"ingressAnnotations,omitempty"`
+
+	// IngressLabels are added to the resources generated to expose the service.
+	// +optional
+	IngressLabels map[string]string `json:"ingressLabels,omitempty"`
+
+	// Services are weighted services to load-balance between, in place of Service.
+	// +optional
+	Services []EdgeIngressWeightedService `json:"services,omitempty"`
+
+	// Sticky enables sticky sessions, keeping a client on the same backend.
+	// +optional
+	Sticky *EdgeIngressSticky `json:"sticky,omitempty"`
+
+	// CustomDomains are the custom domains for accessing the exposed service.
+	// Each domain must be verified on the platform.
+	// +optional
+	CustomDomains []string `json:"customDomains,omitempty"`
+
+	// EntryPoints are the Traefik entry points the generated route listens on.
+	// Defaults to the entry point used by Traefik to expose tunnels.
+	// +optional
+	EntryPoints []string `json:"entryPoints,omitempty"`
+
+	// TLS configures how the generated route handles TLS.
+	// +optional
+	TLS *EdgeIngressTLS `json:"tls,omitempty"`
+
+	// AllowedSourceIPs are the IPs or CIDR ranges allowed to reach the exposed service.
+	// They are enforced on the edge and by Traefik. All sources are allowed if empty.
+	// +optional
+	AllowedSourceIPs []string `json:"allowedSourceIPs,omitempty"`
+
+	// Headers configures the headers set on or removed from requests and responses.
+	// +optional
+	Headers *EdgeIngressHeaders `json:"headers,omitempty"`
+
+	// Paused stops forwarding requests to the exposed service while keeping the edge ingress and its URL.
+	// +optional
+	Paused bool `json:"paused,omitempty"`
+
+	// Maintenance configures how requests are handled while the edge ingress is paused.
+	// +optional
+	Maintenance *EdgeIngressMaintenance `json:"maintenance,omitempty"`
+
+	// Bandwidth caps the bandwidth used by the edge ingress on the tunnel shared with the other edge ingresses.
+	// +optional
+	Bandwidth *EdgeIngressBandwidth `json:"bandwidth,omitempty"`
+}
+
+// EdgeIngressMiddlewares configures the middlewares applied on the exposed service.
+type EdgeIngressMiddlewares struct {
+	// +optional
+	RateLimit *EdgeIngressRateLimit `json:"rateLimit,omitempty"`
+
+	// Compress enables the compression of responses by Traefik, before they are sent through the tunnel.
+	// An empty object enables it with the default options.
+	// +optional
+	Compress *EdgeIngressCompression `json:"compress,omitempty"`
+}
+
+// EdgeIngressMode is the kind of traffic exposed by an edge ingress.
+type EdgeIngressMode string
+
+// Edge ingress modes.
+const (
+	// EdgeIngressModeHTTP exposes an HTTP service, routed on the Host header.
+	EdgeIngressModeHTTP EdgeIngressMode = "http"
+	// EdgeIngressModeTCP exposes a raw TCP service, routed on the TLS SNI.
+	EdgeIngressModeTCP EdgeIngressMode = "tcp"
+)
+
+// EdgeIngressService configures the service to exposed on the edge.
+type EdgeIngressService struct {
+	Name string `json:"name"`
+	Port int    `json:"port"`
+
+	// Protocol is the protocol spoken by the service, and by the weighted services if any. Defaults to HTTP.
+	// +optional
+	// +kubebuilder:validation:Enum=http;h2c;grpc
+	Protocol EdgeIngressServiceProtocol `json:"protocol,omitempty"`
+}
+
+// EdgeIngressServiceProtocol is the protocol spoken by an exposed service.
+type EdgeIngressServiceProtocol string
+
+// Service protocols.
+const (
+	// EdgeIngressServiceProtocolHTTP is HTTP/1.1, the default.
+	EdgeIngressServiceProtocolHTTP EdgeIngressServiceProtocol = "http"
+	// EdgeIngressServiceProtocolH2C is HTTP/2 over cleartext.
+	EdgeIngressServiceProtocolH2C EdgeIngressServiceProtocol = "h2c"
+	// EdgeIngressServiceProtocolGRPC is gRPC over cleartext HTTP/2.
+	EdgeIngressServiceProtocolGRPC EdgeIngressServiceProtocol = "grpc"
+)
+
+// EdgeIngressWeightedService configures a service receiving a share of the traffic.
+type EdgeIngressWeightedService struct {
+	Name   string `json:"name"`
+	Port   int    `json:"port"`
+	Weight int    `json:"weight"`
+}
+
+// EdgeIngressSticky configures sticky sessions.
+type EdgeIngressSticky struct {
+	// CookieName is the name of the cookie used to keep a client on the same backend.
+	CookieName string `json:"cookieName,omitempty"`
+
+	// TTL is the lifetime of the cookie, in seconds. The cookie lasts for the browser session if unset.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	TTL int `json:"ttl,omitempty"`
+}
+
+// EdgeIngressTLS configures how TLS is handled on the exposed service.
+type EdgeIngressTLS struct {
+	// Options is the name of the Traefik TLSOption, in the namespace of the edge ingress, used by the generated route.
+	// +optional
+	Options string `json:"options,omitempty"`
+
+	// RedirectHTTP redirects plain HTTP requests received on the entry points to HTTPS.
+	// +optional
+	RedirectHTTP bool `json:"redirectHTTP,omitempty"`
+}
+
+// EdgeIngressMaintenance configures how requests are handled while an edge ingress is paused.
+type EdgeIngressMaintenance struct {
+	// Message is the body of the 503 Service Unavailable response served while the edge ingress is paused.
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// RemoveRoute removes the generated route instead of serving a maintenance response.
+	// Requests are then handled as if the edge ingress didn't exist. Always the case in TCP mode.
+	// +optional
+	RemoveRoute bool `json:"removeRoute,omitempty"`
+}
+
+// EdgeIngressBandwidth caps the bandwidth used by an edge ingress on the tunnel.
+type EdgeIngressBandwidth struct {
+	// EgressBytesPerSecond is the maximum rate, in bytes per second, of the traffic sent back to the clients.
+	EgressBytesPerSecond int64 `json:"egressBytesPerSecond"`
+
+	// BurstBytes is the number of bytes which can be sent at once above the rate. Defaults to EgressBytesPerSecond.
+	// +optional
+	BurstBytes int64 `json:"burstBytes,omitempty"`
+}
+
+// EdgeIngressACP configures the ACP to use on the Ingress.
+type EdgeIngressACP struct {
+	Name string `json:"name"`
+}
+
+// EdgeIngressCompression configures the compression of responses. Responses are only compressed for clients
+// accepting it, and already compressed content types, like images or archives, are never compressed.
+type EdgeIngressCompression struct {
+	// ExcludedContentTypes are additional content types whose responses are not compressed.
+	// +optional
+	ExcludedContentTypes []string `json:"excludedContentTypes,omitempty"`
+
+	// MinResponseBodyBytes is the minimum size of the responses to compress.
+	// +optional
+	MinResponseBodyBytes int `json:"minResponseBodyBytes,omitempty"`
+}
+
+// EdgeIngressRateLimit configures the rate limiting of requests.
+type EdgeIngressRateLimit struct {
+	// Average is the maximum average number of requests per second.
+	Average int64 `json:"average"`
+
+	// Burst is the maximum number of requests allowed to go through at once.
+	// +optional
+	Burst int64 `json:"burst,omitempty"`
+}
+
+// EdgeIngressHeaders configures the headers manipulated on the exposed service.
+type EdgeIngressHeaders struct {
+	// Request configures the headers of the requests forwarded to the service.
+	// +optional
+	Request *EdgeIngressHeaderRules `json:"request,omitempty"`
+
+	// Response configures the headers of the responses sent back to the clients.
+	// +optional
+	Response *EdgeIngressHeaderRules `json:"response,omitempty"`
+}
+
+// EdgeIngressHeaderRules configures the headers to set and remove.
+type EdgeIngressHeaderRules struct {
+	// Set are the headers to set, overriding any existing value.
+	// +optional
+	Set map[string]string `json:"set,omitempty"`
+
+	// Remove are the names of the headers to remove.
+	// +optional
+	Remove []string `json:"remove,omitempty"`
+}
+
+// EdgeIngressConnectionStatus is the status of the underlying connection to the edge.
+type EdgeIngressConnectionStatus string
+
+// Connection statuses.
+const (
+	EdgeIngressConnectionDown EdgeIngressConnectionStatus = "DOWN"
+	EdgeIngressConnectionUp   EdgeIngressConnectionStatus = "UP"
+)
+
+// EdgeIngressStatus is the status of the EdgeIngress.
+type EdgeIngressStatus struct {
+	Version  string      `json:"version,omitempty"`
+	SyncedAt metav1.Time `json:"syncedAt,omitempty"`
+
+	// Domain is the Domain for accessing the exposed service.
+	Domain string `json:"domain,omitempty"`
+
+	// URL is the URL for accessing the exposed service.
+	URL string `json:"url,omitempty"`
+
+	// CustomDomains are the verified custom domains for accessing the exposed service.
+	CustomDomains []string `json:"customDomains,omitempty"`
+
+	// Connection is the status of the underlying connection to the edge.
+	Connection EdgeIngressConnectionStatus `json:"connection,omitempty"`
+
+	// SpecHash is a hash representing the the EdgeIngressSpec
+	SpecHash string `json:"specHash,omitempty"`
+
+	// Conditions explain why the exposed service is reachable or not.
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// Traffic summarizes the traffic recently received by the exposed service.
+	// +optional
+	Traffic *EdgeIngressTraffic `json:"traffic,omitempty"`
+
+	// Tunnel summarizes the state of the tunnels through which the exposed service is reached.
+	// +optional
+	Tunnel *EdgeIngressTunnel `json:"tunnel,omitempty"`
+}
+
+// EdgeIngressTunnel summarizes the state of the tunnels opened for the cluster, as reported by the agent tunnel.
+type EdgeIngressTunnel struct {
+	// Connected is the number of tunnels connected to the edge.
+	Connected int `json:"connected"`
+
+	// BytesReceived is the number of bytes received from the edge.
+	BytesReceived int64 `json:"bytesReceived"`
+
+	// BytesSent is the number of bytes sent to the edge.
+	BytesSent int64 `json:"bytesSent"`
+
+	// ActiveStreams is the number of streams currently proxied.
+	ActiveStreams int64 `json:"activeStreams"`
+
+	// RTT is the highest round-trip time measured to the edge.
+	RTT metav1.Duration `json:"rtt"`
+
+	// Reconnections is the number of times the tunnels reconnected after being disconnected.
+	Reconnections int64 `json:"reconnections"`
+
+	// HandshakeFailures is the number of connections to the edge which couldn't be established.
+	HandshakeFailures int64 `json:"handshakeFailures"`
+}
+
+// EdgeIngressTraffic summarizes the traffic received by the exposed service, as seen by the ingress controller.
+type EdgeIngressTraffic struct {
+	// Window is the period over which the traffic is summarized.
+	Window metav1.Duration `json:"window"`
+
+	// Requests is the number of requests received.
+	Requests int64 `json:"requests"`
+
+	// ServerErrors is the number of requests answered with a 5XX status code.
+	ServerErrors int64 `json:"serverErrors"`
+
+	// ClientErrors is the number of requests answered with a 4XX status code.
+	ClientErrors int64 `json:"clientErrors"`
+
+	// AverageResponseTime is the average time taken to answer requests.
+	AverageResponseTime metav1.Duration `json:"averageResponseTime"`
+
+	// UpdatedAt is the last time the summary has changed.
+	UpdatedAt metav1.Time `json:"updatedAt"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// EdgeIngressList defines a list of edge ingress.
+type EdgeIngressList struct {
+	metav1.TypeMeta `json:",inline"`
+	// +optional
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []EdgeIngress `json:"items"`
+}
@@ -19,6 +19,7 @@ package v1alpha1
 
 import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
 // +genclient
@@ -39,6 +40,53 @@ type MiddlewareSpec struct {
 	ForwardAuth      *ForwardAuth      `json:"forwardAuth,omitempty"`
 	StripPrefixRegex *StripPrefixRegex `json:"stripPrefixRegex,omitempty"`
 	AddPrefix        *AddPrefix        `json:"addPrefix,omitempty"`
+	RateLimit        *RateLimit        `json:"rateLimit,omitempty"`
+	Compress         *Compress         `json:"compress,omitempty"`
+	RedirectScheme   *RedirectScheme   `json:"redirectScheme,omitempty"`
+	IPWhiteList      *IPWhiteList      `json:"ipWhiteList,omitempty"`
+	Headers          *Headers          `json:"headers,omitempty"`
+}
+
+// +k8s:deepcopy-gen=true
+
+// RateLimit holds the rate limiting configuration for a given router.
+type RateLimit struct {
+	Average int64               `json:"average,omitempty"`
+	Period  *intstr.IntOrString `json:"period,omitempty"`
+	Burst   *int64              `json:"burst,omitempty"`
+}
+
+// +k8s:deepcopy-gen=true
+
+// Compress holds the compress configuration.
+type Compress struct {
+	ExcludedContentTypes []string `json:"excludedContentTypes,omitempty"`
+	MinResponseBodyBytes int      `json:"minResponseBodyBytes,omitempty"`
+}
+
+// +k8s:deepcopy-gen=true
+
+// RedirectScheme holds the scheme redirection configuration.
+type RedirectScheme struct {
+	Scheme    string `json:"scheme,omitempty"`
+	Port      string `json:"port,omitempty"`
+	Permanent bool   `json:"permanent,omitempty"`
+}
+
+// +k8s:deepcopy-gen=true
+
+// IPWhiteList holds the IP allowlist configuration.
+type IPWhiteList struct {
+	SourceRange []string `json:"sourceRange,omitempty"`
+}
+
+// +k8s:deepcopy-gen=true
+
+// Headers holds the custom headers configuration.
+// An empty value removes the header.
+type Headers struct {
+	CustomRequestHeaders  map[string]string `json:"customRequestHeaders,omitempty"`
+	CustomResponseHeaders map[string]string `json:"customResponseHeaders,omitempty"`
 }
 
 // +k8s:deepcopy-gen=true
@@ -35,6 +35,8 @@ func addKnownTypes(scheme *runtime.Scheme) error {
 	scheme.AddKnownTypes(SchemeGroupVersion,
 		&IngressRoute{},
 		&IngressRouteList{},
+		&IngressRouteTCP{},
+		&IngressRouteTCPList{},
 		&TraefikService{},
 		&TraefikServiceList{},
 		&Middleware{},
@@ -0,0 +1,668 @@
+/*
+Copyright (C) 2022 Traefik Labs
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package conversion
+
+import (
+	"encoding/json"
+	"fmt"
+
+	hubv1alpha1 "github.com/traefik/hub-agent-kubernetes/pkg/crd/api/hub/v1alpha1"
+	hubv1alpha2 "github.com/traefik/hub-agent-kubernetes/pkg/crd/api/hub/v1alpha2"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// annotationSpecPrefix prefixes the annotations holding a spec which can't be represented in the version of the object
+// it has been converted to. The annotation is suffixed with the version of the spec.
+const annotationSpecPrefix = "conversion.hub.traefik.io/spec."
+
+type conversionKey struct {
+	kind string
+	from string
+	to   string
+}
+
+var (
+	v1alpha1 = hubv1alpha1.SchemeGroupVersion
+	v1alpha2 = hubv1alpha2.SchemeGroupVersion
+)
+
+var converters = map[conversionKey]func(raw []byte) (interface{}, error){
+	{kind: "AccessControlPolicy", from: v1alpha1.String(), to: v1alpha2.String()}: convertACPToV1alpha2,
+	{kind: "AccessControlPolicy", from: v1alpha2.String(), to: v1alpha1.String()}: convertACPToV1alpha1,
+	{kind: "EdgeIngress", from: v1alpha1.String(), to: v1alpha2.String()}:         convertEdgeIngressToV1alpha2,
+	{kind: "EdgeIngress", from: v1alpha2.String(), to: v1alpha1.String()}:         convertEdgeIngressToV1alpha1,
+}
+
+func convertACPToV1alpha2(raw []byte) (interface{}, error) {
+	var in hubv1alpha1.AccessControlPolicy
+	if err := json.Unmarshal(raw, &in); err != nil {
+		return nil, fmt.Errorf("decode: %w", err)
+	}
+
+	out := &hubv1alpha2.AccessControlPolicy{
+		TypeMeta:   metav1.TypeMeta{APIVersion: v1alpha2.String(), Kind: in.Kind},
+		ObjectMeta: in.ObjectMeta,
+		Spec:       acpSpecToV1alpha2(in.Spec),
+		Status:     acpStatusToV1alpha2(in.Status),
+	}
+
+	var stored hubv1alpha2.AccessControlPolicySpec
+	if restoreSpec(&out.ObjectMeta, v1alpha2.Version, &stored) && equality.Semantic.DeepEqual(acpSpecToV1alpha1(stored), in.Spec) {
+		out.Spec = stored
+	}
+
+	if !equality.Semantic.DeepEqual(acpSpecToV1alpha1(out.Spec), in.Spec) {
+		if err := preserveSpec(&out.ObjectMeta, v1alpha1.Version, in.Spec); err != nil {
+			return nil, err
+		}
+	}
+
+	return out, nil
+}
+
+func convertACPToV1alpha1(raw []byte) (interface{}, error) {
+	var in hubv1alpha2.AccessControlPolicy
+	if err := json.Unmarshal(raw, &in); err != nil {
+		return nil, fmt.Errorf("decode: %w", err)
+	}
+
+	out := &hubv1alpha1.AccessControlPolicy{
+		TypeMeta:   metav1.TypeMeta{APIVersion: v1alpha1.String(), Kind: in.Kind},
+		ObjectMeta: in.ObjectMeta,
+		Spec:       acpSpecToV1alpha1(in.Spec),
+		Status:     acpStatusToV1alpha1(in.Status),
+	}
+
+	var stored hubv1alpha1.AccessControlPolicySpec
+	if restoreSpec(&out.ObjectMeta, v1alpha1.Version, &stored) && equality.Semantic.DeepEqual(acpSpecToV1alpha2(stored), in.Spec) {
+		out.Spec = stored
+	}
+
+	if !equality.Semantic.DeepEqual(acpSpecToV1alpha2(out.Spec), in.Spec) {
+		if err := preserveSpec(&out.ObjectMeta, v1alpha2.Version, in.Spec); err != nil {
+			return nil, err
+		}
+	}
+
+	return out, nil
+}
+
+func convertEdgeIngressToV1alpha2(raw []byte) (interface{}, error) {
+	var in hubv1alpha1.EdgeIngress
+	if err := json.Unmarshal(raw, &in); err != nil {
+		return nil, fmt.Errorf("decode: %w", err)
+	}
+
+	out := &hubv1alpha2.EdgeIngress{
+		TypeMeta:   metav1.TypeMeta{APIVersion: v1alpha2.String(), Kind: in.Kind},
+		ObjectMeta: in.ObjectMeta,
+		Spec:       edgeIngressSpecToV1alpha2(in.Spec),
+		Status:     edgeIngressStatusToV1alpha2(in.Status),
+	}
+
+	var stored hubv1alpha2.EdgeIngressSpec
+	if restoreSpec(&out.ObjectMeta, v1alpha2.Version, &stored) && equality.Semantic.DeepEqual(edgeIngressSpecToV1alpha1(stored), in.Spec) {
+		out.Spec = stored
+	}
+
+	if !equality.Semantic.DeepEqual(edgeIngressSpecToV1alpha1(out.Spec), in.Spec) {
+		if err := preserveSpec(&out.ObjectMeta, v1alpha1.Version, in.Spec); err != nil {
+			return nil, err
+		}
+	}
+
+	return out, nil
+}
+
+func convertEdgeIngressToV1alpha1(raw []byte) (interface{}, error) {
+	var in hubv1alpha2.EdgeIngress
+	if err := json.Unmarshal(raw, &in); err != nil {
+		return nil, fmt.Errorf("decode: %w", err)
+	}
+
+	out := &hubv1alpha1.EdgeIngress{
+		TypeMeta:   metav1.TypeMeta{APIVersion: v1alpha1.String(), Kind: in.Kind},
+		ObjectMeta: in.ObjectMeta,
+		Spec:       edgeIngressSpecToV1alpha1(in.Spec),
+		Status:     edgeIngressStatusToV1alpha1(in.Status),
+	}
+
+	var stored hubv1alpha1.EdgeIngressSpec
+	if restoreSpec(&out.ObjectMeta, v1alpha1.Version, &stored) && equality.Semantic.DeepEqual(edgeIngressSpecToV1alpha2(stored), in.Spec) {
+		out.Spec = stored
+	}
+
+	if !equality.Semantic.DeepEqual(edgeIngressSpecToV1alpha2(out.Spec), in.Spec) {
+		if err := preserveSpec(&out.ObjectMeta, v1alpha2.Version, in.Spec); err != nil {
+			return nil, err
+		}
+	}
+
+	return out, nil
+}
+
+func acpSpecToV1alpha2(in hubv1alpha1.AccessControlPolicySpec) hubv1alpha2.AccessControlPolicySpec {
+	var out hubv1alpha2.AccessControlPolicySpec
+
+	if in.JWT != nil {
+		out.JWT = &hubv1alpha2.AccessControlPolicyJWT{
+			PublicKey:                in.JWT.PublicKey,
+			JWKsFile:                 in.JWT.JWKsFile,
+			JWKsURL:                  in.JWT.JWKsURL,
+			StripAuthorizationHeader: in.JWT.StripAuthorizationHeader,
+			ForwardHeaders:           in.JWT.ForwardHeaders,
+			TokenQueryKey:            in.JWT.TokenQueryKey,
+			Claims:                   in.JWT.Claims,
+		}
+
+		if in.JWT.SigningSecret != "" || in.JWT.SigningSecretBase64Encoded {
+			out.JWT.SigningSecret = &hubv1alpha2.SecretValue{
+				Value:         in.JWT.SigningSecret,
+				Base64Encoded: in.JWT.SigningSecretBase64Encoded,
+			}
+		}
+	}
+
+	if in.BasicAuth != nil {
+		out.BasicAuth = &hubv1alpha2.AccessControlPolicyBasicAuth{
+			Users:                    in.BasicAuth.Users,
+			Realm:                    in.BasicAuth.Realm,
+			StripAuthorizationHeader: in.BasicAuth.StripAuthorizationHeader,
+			ForwardUsernameHeader:    in.BasicAuth.ForwardUsernameHeader,
+		}
+	}
+
+	return out
+}
+
+func acpSpecToV1alpha1(in hubv1alpha2.AccessControlPolicySpec) hubv1alpha1.AccessControlPolicySpec {
+	var out hubv1alpha1.AccessControlPolicySpec
+
+	if in.JWT != nil {
+		out.JWT = &hubv1alpha1.AccessControlPolicyJWT{
+			PublicKey:                in.JWT.PublicKey,
+			JWKsFile:                 in.JWT.JWKsFile,
+			JWKsURL:                  in.JWT.JWKsURL,
+			StripAuthorizationHeader: in.JWT.StripAuthorizationHeader,
+			ForwardHeaders:           in.JWT.ForwardHeaders,
+			TokenQueryKey:            in.JWT.TokenQueryKey,
+			Claims:                   in.JWT.Claims,
+		}
+
+		if in.JWT.SigningSecret != nil {
+			out.JWT.SigningSecret = in.JWT.SigningSecret.Value
+			out.JWT.SigningSecretBase64Encoded = in.JWT.SigningSecret.Base64Encoded
+		}
+	}
+
+	if in.BasicAuth != nil {
+		out.BasicAuth = &hubv1alpha1.AccessControlPolicyBasicAuth{
+			Users:                    in.BasicAuth.Users,
+			Realm:                    in.BasicAuth.Realm,
+			StripAuthorizationHeader: in.BasicAuth.StripAuthorizationHeader,
+			ForwardUsernameHeader:    in.BasicAuth.ForwardUsernameHeader,
+		}
+	}
+
+	return out
+}
+
+// edgeIngressSpecToV1alpha2 converts an edge ingress spec to v1alpha2, where the ACP comes first in the ACPs.
+func edgeIngressSpecToV1alpha2(in hubv1alpha1.EdgeIngressSpec) hubv1alpha2.EdgeIngressSpec {
+	acps := in.ACPs
+	if in.ACP != nil {
+		acps = append([]hubv1alpha1.EdgeIngressACP{*in.ACP}, in.ACPs...)
+	}
+
+	return hubv1alpha2.EdgeIngressSpec{
+		Mode: hubv1alpha2.EdgeIngressMode(in.Mode),
+		Service: hubv1alpha2.EdgeIngressService{
+			Name:     in.Service.Name,
+			Port:     in.Service.Port,
+			Protocol: hubv1alpha2.EdgeIngressServiceProtocol(in.Service.Protocol),
+		},
+		ACPs:               acpsToV1alpha2(acps),
+		Middlewares:        middlewaresToV1alpha2(in.Middlewares),
+		IngressAnnotations: in.IngressAnnotations,
+		IngressLabels:      in.IngressLabels,
+		Services:           weightedServicesToV1alpha2(in.Services),
+		Sticky:             stickyToV1alpha2(in.Sticky),
+		CustomDomains:      in.CustomDomains,
+		EntryPoints:        in.EntryPoints,
+		TLS:                tlsToV1alpha2(in.TLS),
+		AllowedSourceIPs:   in.AllowedSourceIPs,
+		Headers:            headersToV1alpha2(in.Headers),
+		Paused:             in.Paused,
+		Maintenance:        maintenanceToV1alpha2(in.Maintenance),
+		Bandwidth:          bandwidthToV1alpha2(in.Bandwidth),
+	}
+}
+
+func edgeIngressSpecToV1alpha1(in hubv1alpha2.EdgeIngressSpec) hubv1alpha1.EdgeIngressSpec {
+	return hubv1alpha1.EdgeIngressSpec{
+		Mode: hubv1alpha1.EdgeIngressMode(in.Mode),
+		Service: hubv1alpha1.EdgeIngressService{
+			Name:     in.Service.Name,
+			Port:     in.Service.Port,
+			Protocol: hubv1alpha1.EdgeIngressServiceProtocol(in.Service.Protocol),
+		},
+		ACPs:               acpsToV1alpha1(in.ACPs),
+		Middlewares:        middlewaresToV1alpha1(in.Middlewares),
+		IngressAnnotations: in.IngressAnnotations,
+		IngressLabels:      in.IngressLabels,
+		Services:           weightedServicesToV1alpha1(in.Services),
+		Sticky:             stickyToV1alpha1(in.Sticky),
+		CustomDomains:      in.CustomDomains,
+		EntryPoints:        in.EntryPoints,
+		TLS:                tlsToV1alpha1(in.TLS),
+		AllowedSourceIPs:   in.AllowedSourceIPs,
+		Headers:            headersToV1alpha1(in.Headers),
+		Paused:             in.Paused,
+		Maintenance:        maintenanceToV1alpha1(in.Maintenance),
+		Bandwidth:          bandwidthToV1alpha1(in.Bandwidth),
+	}
+}
+
+func middlewaresToV1alpha2(in *hubv1alpha1.EdgeIngressMiddlewares) *hubv1alpha2.EdgeIngressMiddlewares {
+	if in == nil {
+		return nil
+	}
+
+	return &hubv1alpha2.EdgeIngressMiddlewares{
+		RateLimit: rateLimitToV1alpha2(in.RateLimit),
+		Compress:  compressionToV1alpha2(in.Compress),
+	}
+}
+
+func middlewaresToV1alpha1(in *hubv1alpha2.EdgeIngressMiddlewares) *hubv1alpha1.EdgeIngressMiddlewares {
+	if in == nil {
+		return nil
+	}
+
+	return &hubv1alpha1.EdgeIngressMiddlewares{
+		RateLimit: rateLimitToV1alpha1(in.RateLimit),
+		Compress:  compressionToV1alpha1(in.Compress),
+	}
+}
+
+func acpStatusToV1alpha2(in hubv1alpha1.AccessControlPolicyStatus) hubv1alpha2.AccessControlPolicyStatus {
+	return hubv1alpha2.AccessControlPolicyStatus{
+		Version:  in.Version,
+		SyncedAt: in.SyncedAt,
+		SpecHash: in.SpecHash,
+	}
+}
+
+func acpStatusToV1alpha1(in hubv1alpha2.AccessControlPolicyStatus) hubv1alpha1.AccessControlPolicyStatus {
+	return hubv1alpha1.AccessControlPolicyStatus{
+		Version:  in.Version,
+		SyncedAt: in.SyncedAt,
+		SpecHash: in.SpecHash,
+	}
+}
+
+func edgeIngressStatusToV1alpha2(in hubv1alpha1.EdgeIngressStatus) hubv1alpha2.EdgeIngressStatus {
+	out := hubv1alpha2.EdgeIngressStatus{
+		Version:       in.Version,
+		SyncedAt:      in.SyncedAt,
+		Domain:        in.Domain,
+		URL:           in.URL,
+		CustomDomains: in.CustomDomains,
+		Connection:    hubv1alpha2.EdgeIngressConnectionStatus(in.Connection),
+		SpecHash:      in.SpecHash,
+		Conditions:    in.Conditions,
+	}
+
+	if in.Traffic != nil {
+		out.Traffic = &hubv1alpha2.EdgeIngressTraffic{
+			Window:              in.Traffic.Window,
+			Requests:            in.Traffic.Requests,
+			ServerErrors:        in.Traffic.ServerErrors,
+			ClientErrors:        in.Traffic.ClientErrors,
+			AverageResponseTime: in.Traffic.AverageResponseTime,
+			UpdatedAt:           in.Traffic.UpdatedAt,
+		}
+	}
+
+	if in.Tunnel != nil {
+		out.Tunnel = &hubv1alpha2.EdgeIngressTunnel{
+			Connected:         in.Tunnel.Connected,
+			BytesReceived:     in.Tunnel.BytesReceived,
+			BytesSent:         in.Tunnel.BytesSent,
+			ActiveStreams:     in.Tunnel.ActiveStreams,
+			RTT:               in.Tunnel.RTT,
+			Reconnections:     in.Tunnel.Reconnections,
+			HandshakeFailures: in.Tunnel.HandshakeFailures,
+		}
+	}
+
+	return out
+}
+
+func edgeIngressStatusToV1alpha1(in hubv1alpha2.EdgeIngressStatus) hubv1alpha1.EdgeIngressStatus {
+	out := hubv1alpha1.EdgeIngressStatus{
+		Version:       in.Version,
+		SyncedAt:      in.SyncedAt,
+		Domain:        in.Domain,
+		URL:           in.URL,
+		CustomDomains: in.CustomDomains,
+		Connection:    hubv1alpha1.EdgeIngressConnectionStatus(in.Connection),
+		SpecHash:      in.SpecHash,
+		Conditions:    in.Conditions,
+	}
+
+	if in.Traffic != nil {
+		out.Traffic = &hubv1alpha1.EdgeIngressTraffic{
+			Window:              in.Traffic.Window,
+			Requests:            in.Traffic.Requests,
+			ServerErrors:        in.Traffic.ServerErrors,
+			ClientErrors:        in.Traffic.ClientErrors,
+			AverageResponseTime: in.Traffic.AverageResponseTime,
+			UpdatedAt:           in.Traffic.UpdatedAt,
+		}
+	}
+
+	if in.Tunnel != nil {
+		out.Tunnel = &hubv1alpha1.EdgeIngressTunnel{
+			Connected:         in.Tunnel.Connected,
+			BytesReceived:     in.Tunnel.BytesReceived,
+			BytesSent:         in.Tunnel.BytesSent,
+			ActiveStreams:     in.Tunnel.ActiveStreams,
+			RTT:               in.Tunnel.RTT,
+			Reconnections:     in.Tunnel.Reconnections,
+			HandshakeFailures: in.Tunnel.HandshakeFailures,
+		}
+	}
+
+	return out
+}
+
+func acpsToV1alpha2(in []hubv1alpha1.EdgeIngressACP) []hubv1alpha2.EdgeIngressACP {
+	if in == nil {
+		return nil
+	}
+
+	out := make([]hubv1alpha2.EdgeIngressACP, 0, len(in))
+	for _, acp := range in {
+		out = append(out, hubv1alpha2.EdgeIngressACP{Name: acp.Name})
+	}
+
+	return out
+}
+
+func acpsToV1alpha1(in []hubv1alpha2.EdgeIngressACP) []hubv1alpha1.EdgeIngressACP {
+	if in == nil {
+		return nil
+	}
+
+	out := make([]hubv1alpha1.EdgeIngressACP, 0, len(in))
+	for _, acp := range in {
+		out = append(out, hubv1alpha1.EdgeIngressACP{Name: acp.Name})
+	}
+
+	return out
+}
+
+func weightedServicesToV1alpha2(in []hubv1alpha1.EdgeIngressWeightedService) []hubv1alpha2.EdgeIngressWeightedService {
+	if in == nil {
+		return nil
+	}
+
+	out := make([]hubv1alpha2.EdgeIngressWeightedService, 0, len(in))
+	for _, svc := range in {
+		out = append(out, hubv1alpha2.EdgeIngressWeightedService{
+			Name:   svc.Name,
+			Port:   svc.Port,
+			Weight: svc.Weight,
+		})
+	}
+
+	return out
+}
+
+func weightedServicesToV1alpha1(in []hubv1alpha2.EdgeIngressWeightedService) []hubv1alpha1.EdgeIngressWeightedService {
+	if in == nil {
+		return nil
+	}
+
+	out := make([]hubv1alpha1.EdgeIngressWeightedService, 0, len(in))
+	for _, svc := range in {
+		out = append(out, hubv1alpha1.EdgeIngressWeightedService{
+			Name:   svc.Name,
+			Port:   svc.Port,
+			Weight: svc.Weight,
+		})
+	}
+
+	return out
+}
+
+func stickyToV1alpha2(in *hubv1alpha1.EdgeIngressSticky) *hubv1alpha2.EdgeIngressSticky {
+	if in == nil {
+		return nil
+	}
+
+	return &hubv1alpha2.EdgeIngressSticky{
+		CookieName: in.CookieName,
+		TTL:        in.TTL,
+	}
+}
+
+func stickyToV1alpha1(in *hubv1alpha2.EdgeIngressSticky) *hubv1alpha1.EdgeIngressSticky {
+	if in == nil {
+		return nil
+	}
+
+	return &hubv1alpha1.EdgeIngressSticky{
+		CookieName: in.CookieName,
+		TTL:        in.TTL,
+	}
+}
+
+func tlsToV1alpha2(in *hubv1alpha1.EdgeIngressTLS) *hubv1alpha2.EdgeIngressTLS {
+	if in == nil {
+		return nil
+	}
+
+	return &hubv1alpha2.EdgeIngressTLS{
+		Options:      in.Options,
+		RedirectHTTP: in.RedirectHTTP,
+	}
+}
+
+func tlsToV1alpha1(in *hubv1alpha2.EdgeIngressTLS) *hubv1alpha1.EdgeIngressTLS {
+	if in == nil {
+		return nil
+	}
+
+	return &hubv1alpha1.EdgeIngressTLS{
+		Options:      in.Options,
+		RedirectHTTP: in.RedirectHTTP,
+	}
+}
+
+func headersToV1alpha2(in *hubv1alpha1.EdgeIngressHeaders) *hubv1alpha2.EdgeIngressHeaders {
+	if in == nil {
+		return nil
+	}
+
+	return &hubv1alpha2.EdgeIngressHeaders{
+		Request:  headerRulesToV1alpha2(in.Request),
+		Response: headerRulesToV1alpha2(in.Response),
+	}
+}
+
+func headersToV1alpha1(in *hubv1alpha2.EdgeIngressHeaders) *hubv1alpha1.EdgeIngressHeaders {
+	if in == nil {
+		return nil
+	}
+
+	return &hubv1alpha1.EdgeIngressHeaders{
+		Request:  headerRulesToV1alpha1(in.Request),
+		Response: headerRulesToV1alpha1(in.Response),
+	}
+}
+
+func headerRulesToV1alpha2(in *hubv1alpha1.EdgeIngressHeaderRules) *hubv1alpha2.EdgeIngressHeaderRules {
+	if in == nil {
+		return nil
+	}
+
+	return &hubv1alpha2.EdgeIngressHeaderRules{
+		Set:    in.Set,
+		Remove: in.Remove,
+	}
+}
+
+func headerRulesToV1alpha1(in *hubv1alpha2.EdgeIngressHeaderRules) *hubv1alpha1.EdgeIngressHeaderRules {
+	if in == nil {
+		return nil
+	}
+
+	return &hubv1alpha1.EdgeIngressHeaderRules{
+		Set:    in.Set,
+		Remove: in.Remove,
+	}
+}
+
+func maintenanceToV1alpha2(in *hubv1alpha1.EdgeIngressMaintenance) *hubv1alpha2.EdgeIngressMaintenance {
+	if in == nil {
+		return nil
+	}
+
+	return &hubv1alpha2.EdgeIngressMaintenance{
+		Message:     in.Message,
+		RemoveRoute: in.RemoveRoute,
+	}
+}
+
+func maintenanceToV1alpha1(in *hubv1alpha2.EdgeIngressMaintenance) *hubv1alpha1.EdgeIngressMaintenance {
+	if in == nil {
+		return nil
+	}
+
+	return &hubv1alpha1.EdgeIngressMaintenance{
+		Message:     in.Message,
+		RemoveRoute: in.RemoveRoute,
+	}
+}
+
+func bandwidthToV1alpha2(in *hubv1alpha1.EdgeIngressBandwidth) *hubv1alpha2.EdgeIngressBandwidth {
+	if in == nil {
+		return nil
+	}
+
+	return &hubv1alpha2.EdgeIngressBandwidth{
+		EgressBytesPerSecond: in.EgressBytesPerSecond,
+		BurstBytes:           in.BurstBytes,
+	}
+}
+
+func bandwidthToV1alpha1(in *hubv1alpha2.EdgeIngressBandwidth) *hubv1alpha1.EdgeIngressBandwidth {
+	if in == nil {
+		return nil
+	}
+
+	return &hubv1alpha1.EdgeIngressBandwidth{
+		EgressBytesPerSecond: in.EgressBytesPerSecond,
+		BurstBytes:           in.BurstBytes,
+	}
+}
+
+func rateLimitToV1alpha2(in *hubv1alpha1.EdgeIngressRateLimit) *hubv1alpha2.EdgeIngressRateLimit {
+	if in == nil {
+		return nil
+	}
+
+	return &hubv1alpha2.EdgeIngressRateLimit{
+		Average: in.Average,
+		Burst:   in.Burst,
+	}
+}
+
+func rateLimitToV1alpha1(in *hubv1alpha2.EdgeIngressRateLimit) *hubv1alpha1.EdgeIngressRateLimit {
+	if in == nil {
+		return nil
+	}
+
+	return &hubv1alpha1.EdgeIngressRateLimit{
+		Average: in.Average,
+		Burst:   in.Burst,
+	}
+}
+
+func compressionToV1alpha2(in *hubv1alpha1.EdgeIngressCompression) *hubv1alpha2.EdgeIngressCompression {
+	if in == nil {
+		return nil
+	}
+
+	return &hubv1alpha2.EdgeIngressCompression{
+		ExcludedContentTypes: in.ExcludedContentTypes,
+		MinResponseBodyBytes: in.MinResponseBodyBytes,
+	}
+}
+
+func compressionToV1alpha1(in *hubv1alpha2.EdgeIngressCompression) *hubv1alpha1.EdgeIngressCompression {
+	if in == nil {
+		return nil
+	}
+
+	return &hubv1alpha1.EdgeIngressCompression{
+		ExcludedContentTypes: in.ExcludedContentTypes,
+		MinResponseBodyBytes: in.MinResponseBodyBytes,
+	}
+}
+
+// preserveSpec stores the given spec, of the given version, in an annotation of the converted object, so converting
+// the object back to this version gives the spec back.
+func preserveSpec(meta *metav1.ObjectMeta, version string, spec interface{}) error {
+	b, err := json.Marshal(spec)
+	if err != nil {
+		return fmt.Errorf("encode %s spec: %w", version, err)
+	}
+
+	if meta.Annotations == nil {
+		meta.Annotations = make(map[string]string)
+	}
+	meta.Annotations[annotationSpecPrefix+version] = string(b)
+
+	return nil
+}
+
+// restoreSpec removes the spec of the given version stored by a previous conversion from the annotations of the
+// object, and decodes it into spec. It returns false if there is no such spec, or it can't be decoded.
+func restoreSpec(meta *metav1.ObjectMeta, version string, spec interface{}) bool {
+	stored, ok := meta.Annotations[annotationSpecPrefix+version]
+	if !ok {
+		return false
+	}
+
+	annotations := make(map[string]string, len(meta.Annotations)-1)
+	for key, value := range meta.Annotations {
+		if key != annotationSpecPrefix+version {
+			annotations[key] = value
+		}
+	}
+	meta.Annotations = annotations
+	if len(annotations) == 0 {
+		meta.Annotations = nil
+	}
+
+	return json.Unmarshal([]byte(stored), spec) == nil
+}
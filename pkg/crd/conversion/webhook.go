@@ -0,0 +1,135 @@
+/*
+Copyright (C) 2022 Traefik Labs
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+// Package conversion implements the conversion webhook converting hub resources between the versions of the hub API.
+package conversion
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/rs/zerolog/log"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// Review is a conversion review, as defined by the apiextensions.k8s.io/v1 API.
+type Review struct {
+	metav1.TypeMeta `json:",inline"`
+
+	Request  *Request  `json:"request,omitempty"`
+	Response *Response `json:"response,omitempty"`
+}
+
+// Request is a request to convert objects to another version.
+type Request struct {
+	UID               types.UID              `json:"uid"`
+	DesiredAPIVersion string                 `json:"desiredAPIVersion"`
+	Objects           []runtime.RawExtension `json:"objects"`
+}
+
+// Response holds the objects converted to the desired version, in the order of the request.
+type Response struct {
+	UID              types.UID              `json:"uid"`
+	ConvertedObjects []runtime.RawExtension `json:"convertedObjects"`
+	Result           metav1.Status          `json:"result"`
+}
+
+// Handler is an HTTP handler that can be used as a Kubernetes CRD conversion webhook.
+type Handler struct{}
+
+// NewHandler returns a new Handler.
+func NewHandler() *Handler {
+	return &Handler{}
+}
+
+// ServeHTTP implements http.Handler.
+func (h Handler) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	var review Review
+	if err := json.NewDecoder(req.Body).Decode(&review); err != nil {
+		log.Error().Err(err).Msg("Unable to decode conversion request")
+		http.Error(rw, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	if review.Request == nil {
+		http.Error(rw, "missing conversion request", http.StatusUnprocessableEntity)
+		return
+	}
+
+	review.Response = h.review(review.Request)
+	review.Request = nil
+
+	if err := json.NewEncoder(rw).Encode(review); err != nil {
+		log.Error().Err(err).Msg("Unable to encode conversion response")
+		http.Error(rw, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+func (h Handler) review(req *Request) *Response {
+	resp := &Response{
+		UID:              req.UID,
+		ConvertedObjects: make([]runtime.RawExtension, 0, len(req.Objects)),
+	}
+
+	for _, obj := range req.Objects {
+		converted, err := convert(obj.Raw, req.DesiredAPIVersion)
+		if err != nil {
+			log.Error().Err(err).Str("uid", string(req.UID)).Msg("Unable to convert object")
+
+			resp.ConvertedObjects = nil
+			resp.Result = metav1.Status{
+				Status:  metav1.StatusFailure,
+				Message: err.Error(),
+			}
+			return resp
+		}
+
+		resp.ConvertedObjects = append(resp.ConvertedObjects, runtime.RawExtension{Raw: converted})
+	}
+
+	resp.Result = metav1.Status{Status: metav1.StatusSuccess}
+
+	return resp
+}
+
+// convert converts the given object to the desired API version.
+func convert(raw []byte, desiredAPIVersion string) ([]byte, error) {
+	var typeMeta metav1.TypeMeta
+	if err := json.Unmarshal(raw, &typeMeta); err != nil {
+		return nil, fmt.Errorf("decode type: %w", err)
+	}
+
+	if typeMeta.APIVersion == desiredAPIVersion {
+		return raw, nil
+	}
+
+	conv, ok := converters[conversionKey{kind: typeMeta.Kind, from: typeMeta.APIVersion, to: desiredAPIVersion}]
+	if !ok {
+		return nil, fmt.Errorf("unsupported conversion of %s from %q to %q", typeMeta.Kind, typeMeta.APIVersion, desiredAPIVersion)
+	}
+
+	converted, err := conv(raw)
+	if err != nil {
+		return nil, fmt.Errorf("convert %s from %q to %q: %w", typeMeta.Kind, typeMeta.APIVersion, desiredAPIVersion, err)
+	}
+
+	return json.Marshal(converted)
+}
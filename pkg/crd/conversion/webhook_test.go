@@ -0,0 +1,355 @@
+/*
+Copyright (C) 2022 Traefik Labs
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package conversion
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	hubv1alpha1 "github.com/traefik/hub-agent-kubernetes/pkg/crd/api/hub/v1alpha1"
+	hubv1alpha2 "github.com/traefik/hub-agent-kubernetes/pkg/crd/api/hub/v1alpha2"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestHandler_ServeHTTP(t *testing.T) {
+	acp := hubv1alpha1.AccessControlPolicy{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "hub.traefik.io/v1alpha1", Kind: "AccessControlPolicy"},
+		ObjectMeta: metav1.ObjectMeta{Name: "my-acp"},
+		Spec: hubv1alpha1.AccessControlPolicySpec{
+			JWT: &hubv1alpha1.AccessControlPolicyJWT{
+				SigningSecret:              "c2VjcmV0",
+				SigningSecretBase64Encoded: true,
+			},
+		},
+	}
+
+	tests := []struct {
+		desc       string
+		kind       string
+		wantStatus string
+		wantSpec   *hubv1alpha2.AccessControlPolicySpec
+	}{
+		{
+			desc:       "converts objects",
+			kind:       "AccessControlPolicy",
+			wantStatus: metav1.StatusSuccess,
+			wantSpec: &hubv1alpha2.AccessControlPolicySpec{
+				JWT: &hubv1alpha2.AccessControlPolicyJWT{
+					SigningSecret: &hubv1alpha2.SecretValue{Value: "c2VjcmV0", Base64Encoded: true},
+				},
+			},
+		},
+		{
+			desc:       "unsupported kind",
+			kind:       "Unknown",
+			wantStatus: metav1.StatusFailure,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			obj := acp
+			obj.Kind = test.kind
+			raw, err := json.Marshal(obj)
+			require.NoError(t, err)
+
+			b, err := json.Marshal(Review{
+				TypeMeta: metav1.TypeMeta{APIVersion: "apiextensions.k8s.io/v1", Kind: "ConversionReview"},
+				Request: &Request{
+					UID:               "uid",
+					DesiredAPIVersion: "hub.traefik.io/v1alpha2",
+					Objects:           []runtime.RawExtension{{Raw: raw}},
+				},
+			})
+			require.NoError(t, err)
+
+			rw := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(b))
+
+			NewHandler().ServeHTTP(rw, req)
+
+			require.Equal(t, http.StatusOK, rw.Code)
+
+			var review Review
+			err = json.Unmarshal(rw.Body.Bytes(), &review)
+			require.NoError(t, err)
+
+			assert.Nil(t, review.Request)
+			require.NotNil(t, review.Response)
+			assert.Equal(t, "uid", string(review.Response.UID))
+			assert.Equal(t, test.wantStatus, review.Response.Result.Status)
+
+			if test.wantSpec == nil {
+				assert.Empty(t, review.Response.ConvertedObjects)
+				return
+			}
+
+			require.Len(t, review.Response.ConvertedObjects, 1)
+
+			var got hubv1alpha2.AccessControlPolicy
+			err = json.Unmarshal(review.Response.ConvertedObjects[0].Raw, &got)
+			require.NoError(t, err)
+
+			assert.Equal(t, "hub.traefik.io/v1alpha2", got.APIVersion)
+			assert.Equal(t, "my-acp", got.Name)
+			assert.Empty(t, got.Annotations)
+			assert.Equal(t, *test.wantSpec, got.Spec)
+		})
+	}
+}
+
+func TestHandler_ServeHTTP_invalidRequest(t *testing.T) {
+	rw := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader([]byte(`{}`)))
+
+	NewHandler().ServeHTTP(rw, req)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, rw.Code)
+}
+
+func TestConvert_roundTrip(t *testing.T) {
+	tests := []struct {
+		desc string
+		from string
+		to   string
+		obj  interface{}
+	}{
+		{
+			desc: "v1alpha1 ACP",
+			from: "hub.traefik.io/v1alpha1",
+			to:   "hub.traefik.io/v1alpha2",
+			obj: hubv1alpha1.AccessControlPolicy{
+				TypeMeta:   metav1.TypeMeta{APIVersion: "hub.traefik.io/v1alpha1", Kind: "AccessControlPolicy"},
+				ObjectMeta: metav1.ObjectMeta{Name: "my-acp", Annotations: map[string]string{"foo": "bar"}},
+				Spec: hubv1alpha1.AccessControlPolicySpec{
+					BasicAuth: &hubv1alpha1.AccessControlPolicyBasicAuth{
+						Users: []string{"user:password"},
+						Realm: "realm",
+					},
+				},
+				Status: hubv1alpha1.AccessControlPolicyStatus{Version: "version", SpecHash: "hash"},
+			},
+		},
+		{
+			desc: "v1alpha2 ACP with a base64 encoded signing secret",
+			from: "hub.traefik.io/v1alpha2",
+			to:   "hub.traefik.io/v1alpha1",
+			obj: hubv1alpha2.AccessControlPolicy{
+				TypeMeta:   metav1.TypeMeta{APIVersion: "hub.traefik.io/v1alpha2", Kind: "AccessControlPolicy"},
+				ObjectMeta: metav1.ObjectMeta{Name: "my-acp"},
+				Spec: hubv1alpha2.AccessControlPolicySpec{
+					JWT: &hubv1alpha2.AccessControlPolicyJWT{
+						SigningSecret: &hubv1alpha2.SecretValue{Value: "c2VjcmV0", Base64Encoded: true},
+					},
+				},
+			},
+		},
+		{
+			desc: "v1alpha1 edge ingress with an ACP",
+			from: "hub.traefik.io/v1alpha1",
+			to:   "hub.traefik.io/v1alpha2",
+			obj: hubv1alpha1.EdgeIngress{
+				TypeMeta:   metav1.TypeMeta{APIVersion: "hub.traefik.io/v1alpha1", Kind: "EdgeIngress"},
+				ObjectMeta: metav1.ObjectMeta{Name: "my-edge-ingress", Namespace: "default"},
+				Spec: hubv1alpha1.EdgeIngressSpec{
+					Service: hubv1alpha1.EdgeIngressService{Name: "whoami", Port: 80},
+					ACP:     &hubv1alpha1.EdgeIngressACP{Name: "acp-1"},
+					ACPs:    []hubv1alpha1.EdgeIngressACP{{Name: "acp-2"}},
+				},
+				Status: hubv1alpha1.EdgeIngressStatus{Version: "version", Domain: "domain.hub.traefik.io"},
+			},
+		},
+		{
+			desc: "v1alpha2 edge ingress",
+			from: "hub.traefik.io/v1alpha2",
+			to:   "hub.traefik.io/v1alpha1",
+			obj: hubv1alpha2.EdgeIngress{
+				TypeMeta:   metav1.TypeMeta{APIVersion: "hub.traefik.io/v1alpha2", Kind: "EdgeIngress"},
+				ObjectMeta: metav1.ObjectMeta{Name: "my-edge-ingress", Namespace: "default"},
+				Spec: hubv1alpha2.EdgeIngressSpec{
+					Service: hubv1alpha2.EdgeIngressService{Name: "whoami", Port: 80},
+					ACPs:    []hubv1alpha2.EdgeIngressACP{{Name: "acp-1"}, {Name: "acp-2"}},
+				},
+			},
+		},
+		{
+			desc: "v1alpha1 edge ingress with compression enabled",
+			from: "hub.traefik.io/v1alpha1",
+			to:   "hub.traefik.io/v1alpha2",
+			obj: hubv1alpha1.EdgeIngress{
+				TypeMeta:   metav1.TypeMeta{APIVersion: "hub.traefik.io/v1alpha1", Kind: "EdgeIngress"},
+				ObjectMeta: metav1.ObjectMeta{Name: "my-edge-ingress", Namespace: "default"},
+				Spec: hubv1alpha1.EdgeIngressSpec{
+					Service:     hubv1alpha1.EdgeIngressService{Name: "whoami", Port: 80},
+					Middlewares: &hubv1alpha1.EdgeIngressMiddlewares{Compress: &hubv1alpha1.EdgeIngressCompression{}},
+				},
+			},
+		},
+		{
+			desc: "v1alpha2 edge ingress with compression options",
+			from: "hub.traefik.io/v1alpha2",
+			to:   "hub.traefik.io/v1alpha1",
+			obj: hubv1alpha2.EdgeIngress{
+				TypeMeta:   metav1.TypeMeta{APIVersion: "hub.traefik.io/v1alpha2", Kind: "EdgeIngress"},
+				ObjectMeta: metav1.ObjectMeta{Name: "my-edge-ingress", Namespace: "default"},
+				Spec: hubv1alpha2.EdgeIngressSpec{
+					Service: hubv1alpha2.EdgeIngressService{Name: "whoami", Port: 80},
+					Middlewares: &hubv1alpha2.EdgeIngressMiddlewares{
+						Compress: &hubv1alpha2.EdgeIngressCompression{MinResponseBodyBytes: 1024},
+					},
+				},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			raw, err := json.Marshal(test.obj)
+			require.NoError(t, err)
+
+			converted, err := convert(raw, test.to)
+			require.NoError(t, err)
+
+			var typeMeta metav1.TypeMeta
+			err = json.Unmarshal(converted, &typeMeta)
+			require.NoError(t, err)
+			assert.Equal(t, test.to, typeMeta.APIVersion)
+
+			got, err := convert(converted, test.from)
+			require.NoError(t, err)
+
+			assert.JSONEq(t, string(raw), string(got))
+		})
+	}
+}
+
+func TestConvert_edgeIngressACP(t *testing.T) {
+	raw, err := json.Marshal(hubv1alpha1.EdgeIngress{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "hub.traefik.io/v1alpha1", Kind: "EdgeIngress"},
+		ObjectMeta: metav1.ObjectMeta{Name: "my-edge-ingress"},
+		Spec: hubv1alpha1.EdgeIngressSpec{
+			ACP:  &hubv1alpha1.EdgeIngressACP{Name: "acp-1"},
+			ACPs: []hubv1alpha1.EdgeIngressACP{{Name: "acp-2"}},
+		},
+	})
+	require.NoError(t, err)
+
+	converted, err := convert(raw, "hub.traefik.io/v1alpha2")
+	require.NoError(t, err)
+
+	var got hubv1alpha2.EdgeIngress
+	err = json.Unmarshal(converted, &got)
+	require.NoError(t, err)
+
+	assert.Equal(t, []hubv1alpha2.EdgeIngressACP{{Name: "acp-1"}, {Name: "acp-2"}}, got.Spec.ACPs)
+	assert.Contains(t, got.Annotations, "conversion.hub.traefik.io/spec.v1alpha1")
+}
+
+func TestConvert_edgeIngressAllFields(t *testing.T) {
+	now := metav1.Unix(1600000000, 0)
+	in := hubv1alpha1.EdgeIngress{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "hub.traefik.io/v1alpha1", Kind: "EdgeIngress"},
+		ObjectMeta: metav1.ObjectMeta{Name: "my-edge-ingress", Namespace: "default"},
+		Spec: hubv1alpha1.EdgeIngressSpec{
+			Mode:    hubv1alpha1.EdgeIngressModeHTTP,
+			Service: hubv1alpha1.EdgeIngressService{Name: "whoami", Port: 80, Protocol: hubv1alpha1.EdgeIngressServiceProtocolH2C},
+			ACPs:    []hubv1alpha1.EdgeIngressACP{{Name: "acp-1"}},
+			Middlewares: &hubv1alpha1.EdgeIngressMiddlewares{
+				RateLimit: &hubv1alpha1.EdgeIngressRateLimit{Average: 10, Burst: 20},
+				Compress:  &hubv1alpha1.EdgeIngressCompression{ExcludedContentTypes: []string{"text/event-stream"}, MinResponseBodyBytes: 1024},
+			},
+			IngressAnnotations: map[string]string{"foo": "bar"},
+			IngressLabels:      map[string]string{"bar": "baz"},
+			Services:           []hubv1alpha1.EdgeIngressWeightedService{{Name: "whoami-v2", Port: 80, Weight: 1}},
+			Sticky:             &hubv1alpha1.EdgeIngressSticky{CookieName: "sticky", TTL: 60},
+			CustomDomains:      []string{"whoami.example.com"},
+			EntryPoints:        []string{"websecure"},
+			TLS:                &hubv1alpha1.EdgeIngressTLS{Options: "modern", RedirectHTTP: true},
+			AllowedSourceIPs:   []string{"10.0.0.0/8"},
+			Headers: &hubv1alpha1.EdgeIngressHeaders{
+				Request:  &hubv1alpha1.EdgeIngressHeaderRules{Set: map[string]string{"X-Foo": "foo"}},
+				Response: &hubv1alpha1.EdgeIngressHeaderRules{Remove: []string{"Server"}},
+			},
+			Paused:      true,
+			Maintenance: &hubv1alpha1.EdgeIngressMaintenance{Message: "maintenance", RemoveRoute: true},
+			Bandwidth:   &hubv1alpha1.EdgeIngressBandwidth{EgressBytesPerSecond: 1000, BurstBytes: 2000},
+		},
+		Status: hubv1alpha1.EdgeIngressStatus{
+			Version:       "version",
+			SyncedAt:      now,
+			Domain:        "domain.hub.traefik.io",
+			URL:           "https://domain.hub.traefik.io",
+			CustomDomains: []string{"whoami.example.com"},
+			Connection:    hubv1alpha1.EdgeIngressConnectionUp,
+			SpecHash:      "hash",
+			Conditions:    []metav1.Condition{{Type: "Ready", Status: metav1.ConditionTrue, LastTransitionTime: now, Reason: "Ready"}},
+			Traffic: &hubv1alpha1.EdgeIngressTraffic{
+				Window:              metav1.Duration{Duration: time.Minute},
+				Requests:            1,
+				ServerErrors:        2,
+				ClientErrors:        3,
+				AverageResponseTime: metav1.Duration{Duration: time.Second},
+				UpdatedAt:           now,
+			},
+			Tunnel: &hubv1alpha1.EdgeIngressTunnel{
+				Connected:         1,
+				BytesReceived:     2,
+				BytesSent:         3,
+				ActiveStreams:     4,
+				RTT:               metav1.Duration{Duration: time.Millisecond},
+				Reconnections:     5,
+				HandshakeFailures: 6,
+			},
+		},
+	}
+	raw, err := json.Marshal(in)
+	require.NoError(t, err)
+
+	converted, err := convert(raw, "hub.traefik.io/v1alpha2")
+	require.NoError(t, err)
+
+	var got hubv1alpha2.EdgeIngress
+	err = json.Unmarshal(converted, &got)
+	require.NoError(t, err)
+
+	// Every field has its counterpart in v1alpha2, so nothing has to be kept in an annotation.
+	assert.Empty(t, got.Annotations)
+
+	wantStatus, err := json.Marshal(in.Status)
+	require.NoError(t, err)
+	gotStatus, err := json.Marshal(got.Status)
+	require.NoError(t, err)
+	assert.JSONEq(t, string(wantStatus), string(gotStatus))
+
+	back, err := convert(converted, "hub.traefik.io/v1alpha1")
+	require.NoError(t, err)
+	assert.JSONEq(t, string(raw), string(back))
+}
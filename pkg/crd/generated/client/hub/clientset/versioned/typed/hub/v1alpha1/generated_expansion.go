@@ -20,6 +20,8 @@ package v1alpha1
 
 type AccessControlPolicyExpansion interface{}
 
+type AlertSilenceExpansion interface{}
+
 type EdgeIngressExpansion interface{}
 
 type IngressClassExpansion interface{}
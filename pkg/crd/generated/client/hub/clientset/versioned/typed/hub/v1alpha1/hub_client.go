@@ -27,6 +27,7 @@ import (
 type HubV1alpha1Interface interface {
 	RESTClient() rest.Interface
 	AccessControlPoliciesGetter
+	AlertSilencesGetter
 	EdgeIngressesGetter
 	IngressClassesGetter
 }
@@ -40,6 +41,10 @@ func (c *HubV1alpha1Client) AccessControlPolicies() AccessControlPolicyInterface
 	return newAccessControlPolicies(c)
 }
 
+func (c *HubV1alpha1Client) AlertSilences(namespace string) AlertSilenceInterface {
+	return newAlertSilences(c, namespace)
+}
+
 func (c *HubV1alpha1Client) EdgeIngresses(namespace string) EdgeIngressInterface {
 	return newEdgeIngresses(c, namespace)
 }
@@ -26,6 +26,8 @@ import (
 type Interface interface {
 	// AccessControlPolicies returns a AccessControlPolicyInformer.
 	AccessControlPolicies() AccessControlPolicyInformer
+	// AlertSilences returns a AlertSilenceInformer.
+	AlertSilences() AlertSilenceInformer
 	// EdgeIngresses returns a EdgeIngressInformer.
 	EdgeIngresses() EdgeIngressInformer
 	// IngressClasses returns a IngressClassInformer.
@@ -48,6 +50,11 @@ func (v *version) AccessControlPolicies() AccessControlPolicyInformer {
 	return &accessControlPolicyInformer{factory: v.factory, tweakListOptions: v.tweakListOptions}
 }
 
+// AlertSilences returns a AlertSilenceInformer.
+func (v *version) AlertSilences() AlertSilenceInformer {
+	return &alertSilenceInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
+}
+
 // EdgeIngresses returns a EdgeIngressInformer.
 func (v *version) EdgeIngresses() EdgeIngressInformer {
 	return &edgeIngressInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
@@ -22,6 +22,14 @@ package v1alpha1
 // AccessControlPolicyLister.
 type AccessControlPolicyListerExpansion interface{}
 
+// AlertSilenceListerExpansion allows custom methods to be added to
+// AlertSilenceLister.
+type AlertSilenceListerExpansion interface{}
+
+// AlertSilenceNamespaceListerExpansion allows custom methods to be added to
+// AlertSilenceNamespaceLister.
+type AlertSilenceNamespaceListerExpansion interface{}
+
 // EdgeIngressListerExpansion allows custom methods to be added to
 // EdgeIngressLister.
 type EdgeIngressListerExpansion interface{}
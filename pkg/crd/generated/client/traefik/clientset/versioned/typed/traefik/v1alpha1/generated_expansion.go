@@ -20,6 +20,8 @@ package v1alpha1
 
 type IngressRouteExpansion interface{}
 
+type IngressRouteTCPExpansion interface{}
+
 type MiddlewareExpansion interface{}
 
 type TLSOptionExpansion interface{}
@@ -0,0 +1,363 @@
+/*
+Copyright (C) 2022 Traefik Labs
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+// Package diagnose collects the state of a Hub agent installation into a bundle to send to the support.
+package diagnose
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/traefik/hub-agent-kubernetes/pkg/topology/state"
+	"github.com/traefik/hub-agent-kubernetes/pkg/version"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	clientset "k8s.io/client-go/kubernetes"
+)
+
+// crdResource is the resource of CustomResourceDefinitions, read with a dynamic client as there is no typed client for
+// them in this module.
+var crdResource = schema.GroupVersionResource{Group: "apiextensions.k8s.io", Version: "v1", Resource: "customresourcedefinitions"}
+
+// crdGroups are the groups of the CRDs used by the agent.
+var crdGroups = map[string]struct{}{
+	"hub.traefik.io":      {},
+	"traefik.containo.us": {},
+}
+
+// Config configures a Collector.
+type Config struct {
+	// Namespace is the namespace the agent is installed in.
+	Namespace string
+	// Selector is the label selector of the agent Pods.
+	Selector string
+	// LogLines is the number of lines collected from the end of the logs of each agent container.
+	LogLines int64
+}
+
+// TopologyFunc builds the topology of the cluster.
+type TopologyFunc func(ctx context.Context) (*state.Cluster, error)
+
+// Collector collects diagnostic bundles.
+type Collector struct {
+	clientSet clientset.Interface
+	dynClient dynamic.Interface
+	topology  TopologyFunc
+	cfg       Config
+}
+
+// NewCollector returns a new Collector. The topology is not collected if topology is nil.
+func NewCollector(clientSet clientset.Interface, dynClient dynamic.Interface, topology TopologyFunc, cfg Config) *Collector {
+	return &Collector{
+		clientSet: clientSet,
+		dynClient: dynClient,
+		topology:  topology,
+		cfg:       cfg,
+	}
+}
+
+// Collect writes a diagnostic bundle, as a gzipped tarball, to w. Failing to collect a part of the bundle doesn't fail
+// the collection: the error is written in the errors.txt file of the bundle instead.
+func (c *Collector) Collect(ctx context.Context, w io.Writer) error {
+	gz := gzip.NewWriter(w)
+	b := &bundle{tw: tar.NewWriter(gz), modTime: time.Now()}
+
+	c.collectInfo(b)
+	c.collectAgents(ctx, b)
+	c.collectWebhooks(ctx, b)
+	c.collectCRDs(ctx, b)
+	c.collectTopology(ctx, b)
+
+	if len(b.errs) > 0 {
+		b.add("errors.txt", []byte(strings.Join(b.errs, "\n")+"\n"))
+	}
+
+	if b.err != nil {
+		return b.err
+	}
+
+	if err := b.tw.Close(); err != nil {
+		return fmt.Errorf("close tarball: %w", err)
+	}
+
+	if err := gz.Close(); err != nil {
+		return fmt.Errorf("close gzip stream: %w", err)
+	}
+
+	return nil
+}
+
+type info struct {
+	CollectedAt       time.Time `json:"collectedAt"`
+	DiagnoseVersion   string    `json:"diagnoseVersion"`
+	KubernetesVersion string    `json:"kubernetesVersion,omitempty"`
+	Namespace         string    `json:"namespace"`
+	Selector          string    `json:"selector"`
+}
+
+func (c *Collector) collectInfo(b *bundle) {
+	i := info{
+		CollectedAt:     b.modTime,
+		DiagnoseVersion: version.String(),
+		Namespace:       c.cfg.Namespace,
+		Selector:        c.cfg.Selector,
+	}
+
+	serverVersion, err := c.clientSet.Discovery().ServerVersion()
+	if err != nil {
+		b.fail("get Kubernetes version", err)
+	} else {
+		i.KubernetesVersion = serverVersion.GitVersion
+	}
+
+	b.addJSON("info.json", i)
+}
+
+func (c *Collector) collectAgents(ctx context.Context, b *bundle) {
+	pods, err := c.clientSet.CoreV1().Pods(c.cfg.Namespace).List(ctx, metav1.ListOptions{LabelSelector: c.cfg.Selector})
+	if err != nil {
+		b.fail("list agent Pods", err)
+		return
+	}
+
+	agents := make([]agentPod, 0, len(pods.Items))
+	for _, pod := range pods.Items {
+		agents = append(agents, newAgentPod(pod))
+
+		c.collectHealth(ctx, b, pod)
+		c.collectLogs(ctx, b, pod)
+	}
+
+	b.addJSON("agent/pods.json", agents)
+}
+
+// collectHealth collects the verbose readiness of the agent subsystems, from each port of the Pod serving it.
+func (c *Collector) collectHealth(ctx context.Context, b *bundle, pod corev1.Pod) {
+	if pod.Status.Phase != corev1.PodRunning {
+		return
+	}
+
+	for _, container := range pod.Spec.Containers {
+		for _, port := range container.Ports {
+			if port.Protocol != "" && port.Protocol != corev1.ProtocolTCP {
+				continue
+			}
+
+			scheme := "http"
+			if port.ContainerPort == 443 || strings.Contains(port.Name, "https") {
+				scheme = "https"
+			}
+
+			portNumber := strconv.Itoa(int(port.ContainerPort))
+			resp := c.clientSet.CoreV1().Pods(pod.Namespace).ProxyGet(scheme, pod.Name, portNumber, "/readyz", map[string]string{"verbose": "true"})
+			if resp == nil {
+				continue
+			}
+
+			// The readiness is served with an error status when the agent is not ready, the body is kept anyway.
+			body, err := resp.DoRaw(ctx)
+			if err != nil && len(body) == 0 {
+				b.fail(fmt.Sprintf("get readiness of Pod %s on port %s", pod.Name, portNumber), err)
+				continue
+			}
+
+			b.add(fmt.Sprintf("agent/health/%s-%s.txt", pod.Name, portNumber), body)
+		}
+	}
+}
+
+// collectLogs collects the end of the logs of each container of the Pod, along with the logs of their previous
+// instance if they restarted.
+func (c *Collector) collectLogs(ctx context.Context, b *bundle, pod corev1.Pod) {
+	restarted := make(map[string]bool)
+	for _, status := range pod.Status.ContainerStatuses {
+		restarted[status.Name] = status.RestartCount > 0
+	}
+
+	for _, container := range pod.Spec.Containers {
+		c.collectContainerLogs(ctx, b, pod, container.Name, false)
+
+		if restarted[container.Name] {
+			c.collectContainerLogs(ctx, b, pod, container.Name, true)
+		}
+	}
+}
+
+func (c *Collector) collectContainerLogs(ctx context.Context, b *bundle, pod corev1.Pod, container string, previous bool) {
+	opts := &corev1.PodLogOptions{
+		Container: container,
+		Previous:  previous,
+	}
+	if c.cfg.LogLines > 0 {
+		opts.TailLines = &c.cfg.LogLines
+	}
+
+	logs, err := c.clientSet.CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, opts).DoRaw(ctx)
+	if err != nil {
+		b.fail(fmt.Sprintf("get logs of container %s of Pod %s", container, pod.Name), err)
+		return
+	}
+
+	name := fmt.Sprintf("agent/logs/%s/%s.log", pod.Name, container)
+	if previous {
+		name = fmt.Sprintf("agent/logs/%s/%s.previous.log", pod.Name, container)
+	}
+
+	b.add(name, logs)
+}
+
+func (c *Collector) collectWebhooks(ctx context.Context, b *bundle) {
+	var webhooks []webhookConfiguration
+
+	mutatingCfgs, err := c.clientSet.AdmissionregistrationV1().MutatingWebhookConfigurations().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		b.fail("list mutating webhook configurations", err)
+	} else {
+		for _, cfg := range mutatingCfgs.Items {
+			if whCfg, ok := newMutatingWebhookConfiguration(cfg, c.cfg.Namespace); ok {
+				webhooks = append(webhooks, whCfg)
+			}
+		}
+	}
+
+	validatingCfgs, err := c.clientSet.AdmissionregistrationV1().ValidatingWebhookConfigurations().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		b.fail("list validating webhook configurations", err)
+	} else {
+		for _, cfg := range validatingCfgs.Items {
+			if whCfg, ok := newValidatingWebhookConfiguration(cfg, c.cfg.Namespace); ok {
+				webhooks = append(webhooks, whCfg)
+			}
+		}
+	}
+
+	b.addJSON("cluster/webhooks.json", webhooks)
+}
+
+func (c *Collector) collectCRDs(ctx context.Context, b *bundle) {
+	list, err := c.dynClient.Resource(crdResource).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		b.fail("list CRDs", err)
+		return
+	}
+
+	var crds []crd
+	for _, item := range list.Items {
+		var def crd
+		if err = runtime.DefaultUnstructuredConverter.FromUnstructured(item.Object, &def); err != nil {
+			b.fail(fmt.Sprintf("decode CRD %s", item.GetName()), err)
+			continue
+		}
+		def.Name = item.GetName()
+
+		if _, ok := crdGroups[def.Spec.Group]; ok {
+			crds = append(crds, def)
+		}
+	}
+
+	b.addJSON("cluster/crds.json", crds)
+}
+
+type topologySummary struct {
+	Overview state.Overview `json:"overview"`
+	Counts   map[string]int `json:"counts"`
+}
+
+func (c *Collector) collectTopology(ctx context.Context, b *bundle) {
+	if c.topology == nil {
+		return
+	}
+
+	cluster, err := c.topology(ctx)
+	if err != nil {
+		b.fail("build topology", err)
+		return
+	}
+
+	b.addJSON("topology/summary.json", topologySummary{
+		Overview: cluster.Overview,
+		Counts: map[string]int{
+			"namespaces":            len(cluster.Namespaces),
+			"apps":                  len(cluster.Apps),
+			"services":              len(cluster.Services),
+			"ingresses":             len(cluster.Ingresses),
+			"ingressRoutes":         len(cluster.IngressRoutes),
+			"ingressControllers":    len(cluster.IngressControllers),
+			"accessControlPolicies": len(cluster.AccessControlPolicies),
+			"tlsOptions":            len(cluster.TLSOptions),
+			"certificates":          len(cluster.Certificates),
+			"reachabilityProbes":    len(cluster.ReachabilityProbes),
+		},
+	})
+}
+
+// bundle writes the files of a diagnostic bundle in a tarball.
+type bundle struct {
+	tw      *tar.Writer
+	modTime time.Time
+
+	// errs are the errors encountered while collecting the bundle.
+	errs []string
+	// err is the first error encountered while writing the tarball.
+	err error
+}
+
+func (b *bundle) fail(part string, err error) {
+	b.errs = append(b.errs, fmt.Sprintf("%s: %v", part, err))
+}
+
+func (b *bundle) addJSON(name string, v interface{}) {
+	data, err := json.MarshalIndent(v, "", "\t")
+	if err != nil {
+		b.fail("encode "+name, err)
+		return
+	}
+
+	b.add(name, data)
+}
+
+func (b *bundle) add(name string, data []byte) {
+	if b.err != nil {
+		return
+	}
+
+	hdr := &tar.Header{
+		Name:    name,
+		Mode:    0o644,
+		Size:    int64(len(data)),
+		ModTime: b.modTime,
+	}
+
+	if err := b.tw.WriteHeader(hdr); err != nil {
+		b.err = fmt.Errorf("write header of %s: %w", name, err)
+		return
+	}
+
+	if _, err := b.tw.Write(data); err != nil {
+		b.err = fmt.Errorf("write %s: %w", name, err)
+	}
+}
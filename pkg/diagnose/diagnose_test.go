@@ -0,0 +1,312 @@
+/*
+Copyright (C) 2022 Traefik Labs
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package diagnose
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/traefik/hub-agent-kubernetes/pkg/topology/state"
+	admv1 "k8s.io/api/admissionregistration/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	fakedynamic "k8s.io/client-go/dynamic/fake"
+	kubemock "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
+	kubetesting "k8s.io/client-go/testing"
+)
+
+func TestCollector_Collect(t *testing.T) {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "hub-agent-controller",
+			Namespace: "hub-agent",
+			Labels:    map[string]string{"app": "hub-agent", "component": "controller"},
+		},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{
+				{
+					Name:  "hub-agent",
+					Image: "ghcr.io/traefik/hub-agent-kubernetes:v1.0.0",
+					Args:  []string{"controller", "--token=my-token", "--metrics.otlp-header", "Authorization=Bearer secret", "--log-level=debug"},
+					Env: []corev1.EnvVar{
+						{Name: "TOKEN", Value: "my-token"},
+						{Name: "LOG_FORMAT", Value: "json"},
+						{
+							Name: "PLATFORM_TOKEN",
+							ValueFrom: &corev1.EnvVarSource{SecretKeyRef: &corev1.SecretKeySelector{
+								LocalObjectReference: corev1.LocalObjectReference{Name: "hub-agent-token"},
+								Key:                  "token",
+							}},
+						},
+					},
+					Ports: []corev1.ContainerPort{{Name: "https", ContainerPort: 443}},
+				},
+			},
+		},
+		Status: corev1.PodStatus{
+			Phase: corev1.PodRunning,
+			ContainerStatuses: []corev1.ContainerStatus{
+				{Name: "hub-agent", Ready: true, RestartCount: 1},
+			},
+		},
+	}
+
+	failurePolicy := admv1.Fail
+	webhooks := []runtime.Object{
+		&admv1.MutatingWebhookConfiguration{
+			ObjectMeta: metav1.ObjectMeta{Name: "hub-acp"},
+			Webhooks: []admv1.MutatingWebhook{
+				{
+					Name: "admission.traefik.svc",
+					ClientConfig: admv1.WebhookClientConfig{
+						Service:  &admv1.ServiceReference{Namespace: "hub-agent", Name: "admission"},
+						CABundle: []byte("ca"),
+					},
+					FailurePolicy: &failurePolicy,
+				},
+			},
+		},
+		&admv1.ValidatingWebhookConfiguration{
+			ObjectMeta: metav1.ObjectMeta{Name: "other"},
+			Webhooks: []admv1.ValidatingWebhook{
+				{
+					Name:         "other.svc",
+					ClientConfig: admv1.WebhookClientConfig{Service: &admv1.ServiceReference{Namespace: "other", Name: "other"}},
+				},
+			},
+		},
+	}
+
+	kubeClient := kubemock.NewSimpleClientset(append(webhooks, pod)...)
+	kubeClient.PrependProxyReactor("pods", func(action kubetesting.Action) (bool, rest.ResponseWrapper, error) {
+		proxy := action.(kubetesting.ProxyGetAction)
+		if proxy.GetScheme() != "https" || proxy.GetPort() != "443" || proxy.GetPath() != "/readyz" {
+			return true, response{err: errors.New("not found")}, nil
+		}
+
+		return true, response{body: []byte("[+]topology ok\nreadyz check passed\n")}, nil
+	})
+
+	dynClient := fakedynamic.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{crdResource: "CustomResourceDefinitionList"},
+		newCRD("edgeingresses.hub.traefik.io", "hub.traefik.io"),
+		newCRD("certificates.cert-manager.io", "cert-manager.io"),
+	)
+
+	topology := func(context.Context) (*state.Cluster, error) {
+		return &state.Cluster{
+			Overview: state.Overview{IngressCount: 2, ServiceCount: 3},
+			Services: map[string]*state.Service{"whoami@default": {}},
+		}, nil
+	}
+
+	collector := NewCollector(kubeClient, dynClient, topology, Config{
+		Namespace: "hub-agent",
+		Selector:  "app=hub-agent",
+		LogLines:  100,
+	})
+
+	var buf bytes.Buffer
+	err := collector.Collect(context.Background(), &buf)
+	require.NoError(t, err)
+
+	files := readBundle(t, &buf)
+
+	assert.ElementsMatch(t, []string{
+		"info.json",
+		"agent/pods.json",
+		"agent/health/hub-agent-controller-443.txt",
+		"agent/logs/hub-agent-controller/hub-agent.log",
+		"agent/logs/hub-agent-controller/hub-agent.previous.log",
+		"cluster/webhooks.json",
+		"cluster/crds.json",
+		"topology/summary.json",
+	}, keys(files))
+
+	var agents []agentPod
+	require.NoError(t, json.Unmarshal(files["agent/pods.json"], &agents))
+	require.Len(t, agents, 1)
+	require.Len(t, agents[0].Containers, 1)
+
+	container := agents[0].Containers[0]
+	assert.Equal(t, []string{"controller", "--token=REDACTED", "--metrics.otlp-header", "REDACTED", "--log-level=debug"}, container.Args)
+	assert.Equal(t, []envVar{
+		{Name: "TOKEN", Value: "REDACTED"},
+		{Name: "LOG_FORMAT", Value: "json"},
+		{Name: "PLATFORM_TOKEN", ValueFrom: "secret:hub-agent-token/token"},
+	}, container.Env)
+	assert.NotContains(t, string(files["agent/pods.json"]), "my-token")
+
+	assert.Equal(t, "[+]topology ok\nreadyz check passed\n", string(files["agent/health/hub-agent-controller-443.txt"]))
+	assert.Equal(t, "fake logs", string(files["agent/logs/hub-agent-controller/hub-agent.log"]))
+
+	var gotWebhooks []webhookConfiguration
+	require.NoError(t, json.Unmarshal(files["cluster/webhooks.json"], &gotWebhooks))
+	assert.Equal(t, []webhookConfiguration{
+		{
+			Kind: "MutatingWebhookConfiguration",
+			Name: "hub-acp",
+			Webhooks: []webhook{
+				{Name: "admission.traefik.svc", Service: "hub-agent/admission", HasCABundle: true, FailurePolicy: "Fail"},
+			},
+		},
+	}, gotWebhooks)
+
+	var crds []crd
+	require.NoError(t, json.Unmarshal(files["cluster/crds.json"], &crds))
+	require.Len(t, crds, 1)
+	assert.Equal(t, "edgeingresses.hub.traefik.io", crds[0].Name)
+	assert.Equal(t, []string{"v1alpha1"}, crds[0].Status.StoredVersions)
+
+	var summary topologySummary
+	require.NoError(t, json.Unmarshal(files["topology/summary.json"], &summary))
+	assert.Equal(t, 2, summary.Overview.IngressCount)
+	assert.Equal(t, 1, summary.Counts["services"])
+}
+
+func TestCollector_Collect_recordsErrors(t *testing.T) {
+	kubeClient := kubemock.NewSimpleClientset()
+	dynClient := fakedynamic.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{crdResource: "CustomResourceDefinitionList"},
+	)
+
+	topology := func(context.Context) (*state.Cluster, error) {
+		return nil, errors.New("boom")
+	}
+
+	var buf bytes.Buffer
+	err := NewCollector(kubeClient, dynClient, topology, Config{Namespace: "hub-agent"}).Collect(context.Background(), &buf)
+	require.NoError(t, err)
+
+	files := readBundle(t, &buf)
+
+	assert.NotContains(t, files, "topology/summary.json")
+	assert.Equal(t, "build topology: boom\n", string(files["errors.txt"]))
+}
+
+func TestRedactArgs(t *testing.T) {
+	tests := []struct {
+		desc string
+		args []string
+		want []string
+	}{
+		{
+			desc: "no args",
+		},
+		{
+			desc: "inline value",
+			args: []string{"--token=abc", "--platform-url=https://platform.hub.traefik.io"},
+			want: []string{"--token=REDACTED", "--platform-url=https://platform.hub.traefik.io"},
+		},
+		{
+			desc: "separate value",
+			args: []string{"-token", "abc", "--log-level", "debug"},
+			want: []string{"-token", "REDACTED", "--log-level", "debug"},
+		},
+		{
+			desc: "boolean sensitive flag",
+			args: []string{"--tls.insecure-skip-verify-key", "--log-level=debug"},
+			want: []string{"--tls.insecure-skip-verify-key", "--log-level=debug"},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, test.want, redactArgs(test.args))
+		})
+	}
+}
+
+type response struct {
+	body []byte
+	err  error
+}
+
+func (r response) DoRaw(context.Context) ([]byte, error) {
+	return r.body, r.err
+}
+
+func (r response) Stream(context.Context) (io.ReadCloser, error) {
+	return ioutil.NopCloser(bytes.NewReader(r.body)), r.err
+}
+
+func newCRD(name, group string) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "apiextensions.k8s.io/v1",
+		"kind":       "CustomResourceDefinition",
+		"metadata":   map[string]interface{}{"name": name},
+		"spec": map[string]interface{}{
+			"group": group,
+			"versions": []interface{}{
+				map[string]interface{}{"name": "v1alpha1", "served": true, "storage": true},
+			},
+		},
+		"status": map[string]interface{}{
+			"storedVersions": []interface{}{"v1alpha1"},
+		},
+	}}
+}
+
+func readBundle(t *testing.T, r io.Reader) map[string][]byte {
+	t.Helper()
+
+	gz, err := gzip.NewReader(r)
+	require.NoError(t, err)
+
+	files := make(map[string][]byte)
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		require.NoError(t, err)
+
+		data, err := ioutil.ReadAll(tr)
+		require.NoError(t, err)
+
+		files[hdr.Name] = data
+	}
+
+	return files
+}
+
+func keys(files map[string][]byte) []string {
+	var names []string
+	for name := range files {
+		names = append(names, name)
+	}
+
+	return names
+}
@@ -0,0 +1,278 @@
+/*
+Copyright (C) 2022 Traefik Labs
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package diagnose
+
+import (
+	"strings"
+
+	admv1 "k8s.io/api/admissionregistration/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// redacted replaces the sensitive values of the agent configuration in the bundle.
+const redacted = "REDACTED"
+
+// sensitiveWords are the words which, found in the name of a flag or an environment variable, mark its value as
+// sensitive.
+var sensitiveWords = []string{"token", "secret", "password", "key", "credential", "header"}
+
+type agentPod struct {
+	Name       string                `json:"name"`
+	Node       string                `json:"node,omitempty"`
+	Labels     map[string]string     `json:"labels,omitempty"`
+	Phase      corev1.PodPhase       `json:"phase"`
+	Conditions []corev1.PodCondition `json:"conditions,omitempty"`
+	Containers []agentContainer      `json:"containers"`
+}
+
+type agentContainer struct {
+	Name         string                `json:"name"`
+	Image        string                `json:"image"`
+	Command      []string              `json:"command,omitempty"`
+	Args         []string              `json:"args,omitempty"`
+	Env          []envVar              `json:"env,omitempty"`
+	Ready        bool                  `json:"ready"`
+	RestartCount int32                 `json:"restartCount"`
+	State        corev1.ContainerState `json:"state"`
+	LastState    corev1.ContainerState `json:"lastState"`
+}
+
+type envVar struct {
+	Name      string `json:"name"`
+	Value     string `json:"value,omitempty"`
+	ValueFrom string `json:"valueFrom,omitempty"`
+}
+
+func newAgentPod(pod corev1.Pod) agentPod {
+	statuses := make(map[string]corev1.ContainerStatus)
+	for _, status := range pod.Status.ContainerStatuses {
+		statuses[status.Name] = status
+	}
+
+	agent := agentPod{
+		Name:       pod.Name,
+		Node:       pod.Spec.NodeName,
+		Labels:     pod.Labels,
+		Phase:      pod.Status.Phase,
+		Conditions: pod.Status.Conditions,
+	}
+
+	for _, container := range pod.Spec.Containers {
+		status := statuses[container.Name]
+
+		agent.Containers = append(agent.Containers, agentContainer{
+			Name:         container.Name,
+			Image:        container.Image,
+			Command:      redactArgs(container.Command),
+			Args:         redactArgs(container.Args),
+			Env:          redactEnv(container.Env),
+			Ready:        status.Ready,
+			RestartCount: status.RestartCount,
+			State:        status.State,
+			LastState:    status.LastTerminationState,
+		})
+	}
+
+	return agent
+}
+
+// redactArgs redacts the values of the sensitive flags of the given arguments, given either as `--flag=value` or as
+// `--flag value`.
+func redactArgs(args []string) []string {
+	if args == nil {
+		return nil
+	}
+
+	result := make([]string, len(args))
+	var redactNext bool
+	for i, arg := range args {
+		if redactNext && !strings.HasPrefix(arg, "-") {
+			result[i] = redacted
+			redactNext = false
+			continue
+		}
+		redactNext = false
+
+		if !strings.HasPrefix(arg, "-") {
+			result[i] = arg
+			continue
+		}
+
+		name, _, hasValue := cut(strings.TrimLeft(arg, "-"), "=")
+		if !isSensitive(name) {
+			result[i] = arg
+			continue
+		}
+
+		if hasValue {
+			result[i] = arg[:strings.Index(arg, "=")+1] + redacted
+			continue
+		}
+
+		result[i] = arg
+		redactNext = true
+	}
+
+	return result
+}
+
+// redactEnv redacts the values of the sensitive environment variables. References to the value of other resources are
+// kept, as they don't hold the value themselves.
+func redactEnv(env []corev1.EnvVar) []envVar {
+	var result []envVar
+	for _, e := range env {
+		v := envVar{Name: e.Name, Value: e.Value}
+		if v.Value != "" && isSensitive(e.Name) {
+			v.Value = redacted
+		}
+
+		if from := e.ValueFrom; from != nil {
+			switch {
+			case from.SecretKeyRef != nil:
+				v.ValueFrom = "secret:" + from.SecretKeyRef.Name + "/" + from.SecretKeyRef.Key
+			case from.ConfigMapKeyRef != nil:
+				v.ValueFrom = "configmap:" + from.ConfigMapKeyRef.Name + "/" + from.ConfigMapKeyRef.Key
+			case from.FieldRef != nil:
+				v.ValueFrom = "field:" + from.FieldRef.FieldPath
+			case from.ResourceFieldRef != nil:
+				v.ValueFrom = "resource:" + from.ResourceFieldRef.Resource
+			}
+		}
+
+		result = append(result, v)
+	}
+
+	return result
+}
+
+func isSensitive(name string) bool {
+	name = strings.ToLower(name)
+	for _, word := range sensitiveWords {
+		if strings.Contains(name, word) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// cut slices s around the first instance of sep.
+func cut(s, sep string) (before, after string, found bool) {
+	if i := strings.Index(s, sep); i >= 0 {
+		return s[:i], s[i+len(sep):], true
+	}
+
+	return s, "", false
+}
+
+type webhookConfiguration struct {
+	Kind     string    `json:"kind"`
+	Name     string    `json:"name"`
+	Webhooks []webhook `json:"webhooks"`
+}
+
+type webhook struct {
+	Name              string                     `json:"name"`
+	Service           string                     `json:"service,omitempty"`
+	URL               string                     `json:"url,omitempty"`
+	HasCABundle       bool                       `json:"hasCABundle"`
+	FailurePolicy     string                     `json:"failurePolicy,omitempty"`
+	NamespaceSelector *metav1.LabelSelector      `json:"namespaceSelector,omitempty"`
+	Rules             []admv1.RuleWithOperations `json:"rules,omitempty"`
+}
+
+// newMutatingWebhookConfiguration returns the given configuration, if one of its webhooks is served by the agent.
+func newMutatingWebhookConfiguration(cfg admv1.MutatingWebhookConfiguration, namespace string) (webhookConfiguration, bool) {
+	whCfg := webhookConfiguration{Kind: "MutatingWebhookConfiguration", Name: cfg.Name}
+
+	var fromAgent bool
+	for _, wh := range cfg.Webhooks {
+		fromAgent = fromAgent || isServedBy(wh.ClientConfig, namespace)
+		whCfg.Webhooks = append(whCfg.Webhooks, newWebhook(wh.Name, wh.ClientConfig, wh.FailurePolicy, wh.NamespaceSelector, wh.Rules))
+	}
+
+	return whCfg, fromAgent
+}
+
+// newValidatingWebhookConfiguration returns the given configuration, if one of its webhooks is served by the agent.
+func newValidatingWebhookConfiguration(cfg admv1.ValidatingWebhookConfiguration, namespace string) (webhookConfiguration, bool) {
+	whCfg := webhookConfiguration{Kind: "ValidatingWebhookConfiguration", Name: cfg.Name}
+
+	var fromAgent bool
+	for _, wh := range cfg.Webhooks {
+		fromAgent = fromAgent || isServedBy(wh.ClientConfig, namespace)
+		whCfg.Webhooks = append(whCfg.Webhooks, newWebhook(wh.Name, wh.ClientConfig, wh.FailurePolicy, wh.NamespaceSelector, wh.Rules))
+	}
+
+	return whCfg, fromAgent
+}
+
+func newWebhook(name string, clientCfg admv1.WebhookClientConfig, failurePolicy *admv1.FailurePolicyType, nsSelector *metav1.LabelSelector, rules []admv1.RuleWithOperations) webhook {
+	wh := webhook{
+		Name:              name,
+		HasCABundle:       len(clientCfg.CABundle) > 0,
+		NamespaceSelector: nsSelector,
+		Rules:             rules,
+	}
+
+	if clientCfg.URL != nil {
+		wh.URL = *clientCfg.URL
+	}
+
+	if svc := clientCfg.Service; svc != nil {
+		wh.Service = svc.Namespace + "/" + svc.Name
+		if svc.Path != nil {
+			wh.Service += *svc.Path
+		}
+	}
+
+	if failurePolicy != nil {
+		wh.FailurePolicy = string(*failurePolicy)
+	}
+
+	return wh
+}
+
+func isServedBy(clientCfg admv1.WebhookClientConfig, namespace string) bool {
+	return clientCfg.Service != nil && clientCfg.Service.Namespace == namespace
+}
+
+type crd struct {
+	Name string `json:"name"`
+	Spec struct {
+		Group    string `json:"group"`
+		Versions []struct {
+			Name    string `json:"name"`
+			Served  bool   `json:"served"`
+			Storage bool   `json:"storage"`
+		} `json:"versions"`
+		Conversion *struct {
+			Strategy string `json:"strategy"`
+		} `json:"conversion,omitempty"`
+	} `json:"spec"`
+	Status struct {
+		StoredVersions []string `json:"storedVersions,omitempty"`
+		Conditions     []struct {
+			Type    string `json:"type"`
+			Status  string `json:"status"`
+			Reason  string `json:"reason,omitempty"`
+			Message string `json:"message,omitempty"`
+		} `json:"conditions,omitempty"`
+	} `json:"status"`
+}
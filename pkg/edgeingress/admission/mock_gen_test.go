@@ -354,3 +354,108 @@ func (_c *backendUpdateEdgeIngressCall) OnDeleteEdgeIngressRaw(namespace interfa
 func (_c *backendUpdateEdgeIngressCall) OnUpdateEdgeIngressRaw(namespace interface{}, name interface{}, lastKnownVersion interface{}, updateReq interface{}) *backendUpdateEdgeIngressCall {
 	return _c.Parent.OnUpdateEdgeIngressRaw(namespace, name, lastKnownVersion, updateReq)
 }
+
+// domainListerMock mock of DomainLister.
+type domainListerMock struct{ mock.Mock }
+
+// newDomainListerMock creates a new domainListerMock.
+func newDomainListerMock(tb testing.TB) *domainListerMock {
+	tb.Helper()
+
+	m := &domainListerMock{}
+	m.Mock.Test(tb)
+
+	tb.Cleanup(func() { m.AssertExpectations(tb) })
+
+	return m
+}
+
+func (_m *domainListerMock) ListVerifiedDomains(_ context.Context) []string {
+	_ret := _m.Called()
+
+	if _rf, ok := _ret.Get(0).(func() []string); ok {
+		return _rf()
+	}
+
+	_ra0, _ := _ret.Get(0).([]string)
+
+	return _ra0
+}
+
+func (_m *domainListerMock) OnListVerifiedDomains() *domainListerListVerifiedDomainsCall {
+	return &domainListerListVerifiedDomainsCall{Call: _m.Mock.On("ListVerifiedDomains"), Parent: _m}
+}
+
+func (_m *domainListerMock) OnListVerifiedDomainsRaw() *domainListerListVerifiedDomainsCall {
+	return &domainListerListVerifiedDomainsCall{Call: _m.Mock.On("ListVerifiedDomains"), Parent: _m}
+}
+
+type domainListerListVerifiedDomainsCall struct {
+	*mock.Call
+	Parent *domainListerMock
+}
+
+func (_c *domainListerListVerifiedDomainsCall) Panic(msg string) *domainListerListVerifiedDomainsCall {
+	_c.Call = _c.Call.Panic(msg)
+	return _c
+}
+
+func (_c *domainListerListVerifiedDomainsCall) Once() *domainListerListVerifiedDomainsCall {
+	_c.Call = _c.Call.Once()
+	return _c
+}
+
+func (_c *domainListerListVerifiedDomainsCall) Twice() *domainListerListVerifiedDomainsCall {
+	_c.Call = _c.Call.Twice()
+	return _c
+}
+
+func (_c *domainListerListVerifiedDomainsCall) Times(i int) *domainListerListVerifiedDomainsCall {
+	_c.Call = _c.Call.Times(i)
+	return _c
+}
+
+func (_c *domainListerListVerifiedDomainsCall) WaitUntil(w <-chan time.Time) *domainListerListVerifiedDomainsCall {
+	_c.Call = _c.Call.WaitUntil(w)
+	return _c
+}
+
+func (_c *domainListerListVerifiedDomainsCall) After(d time.Duration) *domainListerListVerifiedDomainsCall {
+	_c.Call = _c.Call.After(d)
+	return _c
+}
+
+func (_c *domainListerListVerifiedDomainsCall) Run(fn func(args mock.Arguments)) *domainListerListVerifiedDomainsCall {
+	_c.Call = _c.Call.Run(fn)
+	return _c
+}
+
+func (_c *domainListerListVerifiedDomainsCall) Maybe() *domainListerListVerifiedDomainsCall {
+	_c.Call = _c.Call.Maybe()
+	return _c
+}
+
+func (_c *domainListerListVerifiedDomainsCall) TypedReturns(a []string) *domainListerListVerifiedDomainsCall {
+	_c.Call = _c.Return(a)
+	return _c
+}
+
+func (_c *domainListerListVerifiedDomainsCall) ReturnsFn(fn func() []string) *domainListerListVerifiedDomainsCall {
+	_c.Call = _c.Return(fn)
+	return _c
+}
+
+func (_c *domainListerListVerifiedDomainsCall) TypedRun(fn func()) *domainListerListVerifiedDomainsCall {
+	_c.Call = _c.Call.Run(func(args mock.Arguments) {
+		fn()
+	})
+	return _c
+}
+
+func (_c *domainListerListVerifiedDomainsCall) OnListVerifiedDomains() *domainListerListVerifiedDomainsCall {
+	return _c.Parent.OnListVerifiedDomains()
+}
+
+func (_c *domainListerListVerifiedDomainsCall) OnListVerifiedDomainsRaw() *domainListerListVerifiedDomainsCall {
+	return _c.Parent.OnListVerifiedDomainsRaw()
+}
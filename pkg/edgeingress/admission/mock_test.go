@@ -18,3 +18,4 @@ along with this program. If not, see <https://www.gnu.org/licenses/>.
 package admission
 
 // mocktail:Backend
+// mocktail:DomainLister
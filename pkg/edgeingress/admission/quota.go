@@ -0,0 +1,137 @@
+/*
+Copyright (C) 2022 Traefik Labs
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package admission
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/rs/zerolog/log"
+	hublistersv1alpha1 "github.com/traefik/hub-agent-kubernetes/pkg/crd/generated/client/hub/listers/hub/v1alpha1"
+	"github.com/traefik/hub-agent-kubernetes/pkg/platform"
+	admv1 "k8s.io/api/admission/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// QuotaChecker checks whether a new EdgeIngress can be created in a namespace.
+type QuotaChecker interface {
+	Check(namespace string) error
+}
+
+// Quotas enforces the maximum number of EdgeIngresses per namespace configured on the platform, preventing a single
+// namespace from using the whole edge ingress allowance of the workspace.
+type Quotas struct {
+	edgeIngresses hublistersv1alpha1.EdgeIngressLister
+
+	cfgMu sync.RWMutex
+	cfg   platform.EdgeIngressConfig
+}
+
+// NewQuotas returns new Quotas.
+func NewQuotas(edgeIngresses hublistersv1alpha1.EdgeIngressLister, cfg platform.EdgeIngressConfig) *Quotas {
+	return &Quotas{
+		edgeIngresses: edgeIngresses,
+		cfg:           cfg,
+	}
+}
+
+// SetConfig updates the quotas configuration.
+func (q *Quotas) SetConfig(cfg platform.EdgeIngressConfig) {
+	q.cfgMu.Lock()
+	defer q.cfgMu.Unlock()
+
+	q.cfg = cfg
+}
+
+// Check returns an error if the given namespace reached its EdgeIngress quota.
+func (q *Quotas) Check(namespace string) error {
+	q.cfgMu.RLock()
+	quota := q.cfg.NamespaceQuota
+	if nsQuota, ok := q.cfg.NamespaceQuotas[namespace]; ok {
+		quota = nsQuota
+	}
+	q.cfgMu.RUnlock()
+
+	if quota <= 0 {
+		return nil
+	}
+
+	edgeIngs, err := q.edgeIngresses.EdgeIngresses(namespace).List(labels.Everything())
+	if err != nil {
+		return fmt.Errorf("list edge ingresses: %w", err)
+	}
+
+	if len(edgeIngs) >= quota {
+		return fmt.Errorf("namespace %q reached its quota of %d edge ingresses", namespace, quota)
+	}
+
+	return nil
+}
+
+// QuotaHandler is an HTTP handler that can be used as a Kubernetes Validating Admission Controller, rejecting the
+// creation of EdgeIngresses in namespaces which reached their quota.
+type QuotaHandler struct {
+	quotas QuotaChecker
+}
+
+// NewQuotaHandler returns a new QuotaHandler.
+func NewQuotaHandler(quotas QuotaChecker) *QuotaHandler {
+	return &QuotaHandler{quotas: quotas}
+}
+
+// ServeHTTP implements http.Handler.
+func (h QuotaHandler) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	var ar admv1.AdmissionReview
+	if err := json.NewDecoder(req.Body).Decode(&ar); err != nil {
+		log.Error().Err(err).Msg("Unable to decode admission request")
+		http.Error(rw, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	if err := h.review(ar.Request); err != nil {
+		log.Error().
+			Err(err).
+			Str("resource_name", ar.Request.Name).
+			Str("resource_namespace", ar.Request.Namespace).
+			Msg("EdgeIngress creation rejected")
+
+		setReviewErrorResponse(&ar, err)
+	} else {
+		setReviewResponse(&ar, nil)
+	}
+
+	if err := json.NewEncoder(rw).Encode(ar); err != nil {
+		log.Error().Err(err).Msg("Unable to encode admission response")
+		http.Error(rw, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+func (h QuotaHandler) review(req *admv1.AdmissionRequest) error {
+	if !isEdgeIngressRequest(req.Kind) {
+		return fmt.Errorf("unsupported resource %s", req.Kind.String())
+	}
+
+	if req.Operation != admv1.Create {
+		return nil
+	}
+
+	return h.quotas.Check(req.Namespace)
+}
@@ -0,0 +1,239 @@
+/*
+Copyright (C) 2022 Traefik Labs
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package admission
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	hubv1alpha1 "github.com/traefik/hub-agent-kubernetes/pkg/crd/api/hub/v1alpha1"
+	hublistersv1alpha1 "github.com/traefik/hub-agent-kubernetes/pkg/crd/generated/client/hub/listers/hub/v1alpha1"
+	"github.com/traefik/hub-agent-kubernetes/pkg/platform"
+	admv1 "k8s.io/api/admission/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/cache"
+)
+
+func TestQuotas_Check(t *testing.T) {
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	for _, edgeIng := range []*hubv1alpha1.EdgeIngress{
+		{ObjectMeta: metav1.ObjectMeta{Name: "a", Namespace: "team-a"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "b", Namespace: "team-a"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "a", Namespace: "team-b"}},
+	} {
+		require.NoError(t, indexer.Add(edgeIng))
+	}
+	lister := hublistersv1alpha1.NewEdgeIngressLister(indexer)
+
+	tests := []struct {
+		desc      string
+		cfg       platform.EdgeIngressConfig
+		namespace string
+		wantErr   string
+	}{
+		{
+			desc:      "no quota",
+			namespace: "team-a",
+		},
+		{
+			desc:      "under default quota",
+			cfg:       platform.EdgeIngressConfig{NamespaceQuota: 2},
+			namespace: "team-b",
+		},
+		{
+			desc:      "default quota reached",
+			cfg:       platform.EdgeIngressConfig{NamespaceQuota: 2},
+			namespace: "team-a",
+			wantErr:   `namespace "team-a" reached its quota of 2 edge ingresses`,
+		},
+		{
+			desc: "namespace quota overrides default quota",
+			cfg: platform.EdgeIngressConfig{
+				NamespaceQuota:  2,
+				NamespaceQuotas: map[string]int{"team-a": 3},
+			},
+			namespace: "team-a",
+		},
+		{
+			desc: "namespace quota reached",
+			cfg: platform.EdgeIngressConfig{
+				NamespaceQuotas: map[string]int{"team-b": 1},
+			},
+			namespace: "team-b",
+			wantErr:   `namespace "team-b" reached its quota of 1 edge ingresses`,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			err := NewQuotas(lister, test.cfg).Check(test.namespace)
+			if test.wantErr != "" {
+				assert.EqualError(t, err, test.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+		})
+	}
+}
+
+type quotaCheckerFunc func(namespace string) error
+
+func (f quotaCheckerFunc) Check(namespace string) error {
+	return f(namespace)
+}
+
+func TestQuotaHandler_ServeHTTP(t *testing.T) {
+	quotas := quotaCheckerFunc(func(namespace string) error {
+		if namespace == "team-a" {
+			return assert.AnError
+		}
+		return nil
+	})
+
+	tests := []struct {
+		desc      string
+		namespace string
+		operation admv1.Operation
+		wantResp  admv1.AdmissionResponse
+	}{
+		{
+			desc:      "create under quota",
+			namespace: "team-b",
+			operation: admv1.Create,
+			wantResp:  admv1.AdmissionResponse{UID: "id", Allowed: true},
+		},
+		{
+			desc:      "create over quota",
+			namespace: "team-a",
+			operation: admv1.Create,
+			wantResp: admv1.AdmissionResponse{
+				UID:     "id",
+				Allowed: false,
+				Result: &metav1.Status{
+					Status:  "Failure",
+					Message: assert.AnError.Error(),
+				},
+			},
+		},
+		{
+			desc:      "update over quota",
+			namespace: "team-a",
+			operation: admv1.Update,
+			wantResp:  admv1.AdmissionResponse{UID: "id", Allowed: true},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			b := mustMarshal(t, admv1.AdmissionReview{
+				Request: &admv1.AdmissionRequest{
+					UID: "id",
+					Kind: metav1.GroupVersionKind{
+						Group:   "hub.traefik.io",
+						Version: "v1alpha1",
+						Kind:    "EdgeIngress",
+					},
+					Name:      "whoami",
+					Namespace: test.namespace,
+					Operation: test.operation,
+					Object: runtime.RawExtension{
+						Raw: []byte("{}"),
+					},
+				},
+				Response: &admv1.AdmissionResponse{},
+			})
+
+			rec := httptest.NewRecorder()
+			req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, "/", bytes.NewBuffer(b))
+			require.NoError(t, err)
+
+			NewQuotaHandler(quotas).ServeHTTP(rec, req)
+
+			var gotAr admv1.AdmissionReview
+			err = json.NewDecoder(rec.Body).Decode(&gotAr)
+			require.NoError(t, err)
+
+			assert.Equal(t, &test.wantResp, gotAr.Response)
+		})
+	}
+}
+
+func TestHandler_ServeHTTP_createOperationOverQuota(t *testing.T) {
+	edgeIngress := hubv1alpha1.EdgeIngress{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "EdgeIngress",
+			APIVersion: "hub.traefik.io/v1alpha1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "edge-ingress",
+			Namespace: "default",
+		},
+		Spec: hubv1alpha1.EdgeIngressSpec{
+			Service: hubv1alpha1.EdgeIngressService{Name: "whoami", Port: 8081},
+		},
+	}
+	b := mustMarshal(t, admv1.AdmissionReview{
+		Request: &admv1.AdmissionRequest{
+			UID: "id",
+			Kind: metav1.GroupVersionKind{
+				Group:   "hub.traefik.io",
+				Version: "v1alpha1",
+				Kind:    "EdgeIngress",
+			},
+			Name:      "edge-ingress",
+			Namespace: "default",
+			Operation: admv1.Create,
+			Object: runtime.RawExtension{
+				Raw: mustMarshal(t, edgeIngress),
+			},
+		},
+		Response: &admv1.AdmissionResponse{},
+	})
+
+	// The edge ingress must not be created on the platform.
+	h := NewHandler(newBackendMock(t), nil, quotaCheckerFunc(func(string) error {
+		return assert.AnError
+	}))
+
+	rec := httptest.NewRecorder()
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, "/", bytes.NewBuffer(b))
+	require.NoError(t, err)
+
+	h.ServeHTTP(rec, req)
+
+	var gotAr admv1.AdmissionReview
+	err = json.NewDecoder(rec.Body).Decode(&gotAr)
+	require.NoError(t, err)
+
+	assert.False(t, gotAr.Response.Allowed)
+	assert.Equal(t, assert.AnError.Error(), gotAr.Response.Result.Message)
+}
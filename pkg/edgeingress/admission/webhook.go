@@ -22,13 +22,18 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"mime"
+	"net"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/rs/zerolog/log"
+	"github.com/traefik/hub-agent-kubernetes/pkg/acp/admission/reviewer"
 	hubv1alpha1 "github.com/traefik/hub-agent-kubernetes/pkg/crd/api/hub/v1alpha1"
 	"github.com/traefik/hub-agent-kubernetes/pkg/edgeingress"
 	"github.com/traefik/hub-agent-kubernetes/pkg/platform"
+	"github.com/traefik/hub-agent-kubernetes/pkg/tracing"
 	admv1 "k8s.io/api/admission/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
@@ -40,16 +45,25 @@ type Backend interface {
 	DeleteEdgeIngress(ctx context.Context, namespace, name, lastKnownVersion string) error
 }
 
+// DomainLister lists the domains verified on the platform.
+type DomainLister interface {
+	ListVerifiedDomains(ctx context.Context) []string
+}
+
 // Handler is an HTTP handler that can be used as a Kubernetes Mutating Admission Controller.
 type Handler struct {
 	backend Backend
+	domains DomainLister
+	quotas  QuotaChecker
 	now     func() time.Time
 }
 
 // NewHandler returns a new Handler.
-func NewHandler(backend Backend) *Handler {
+func NewHandler(backend Backend, domains DomainLister, quotas QuotaChecker) *Handler {
 	return &Handler{
 		backend: backend,
+		domains: domains,
+		quotas:  quotas,
 		now:     time.Now,
 	}
 }
@@ -75,7 +89,17 @@ func (h Handler) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
 	}
 	ctx := l.WithContext(req.Context())
 
+	ctx, span := tracing.Start(ctx, "admission.review")
+	if ar.Request != nil {
+		span.SetAttribute("resource.kind", ar.Request.Kind.String())
+		span.SetAttribute("resource.name", ar.Request.Name)
+		span.SetAttribute("resource.namespace", ar.Request.Namespace)
+		span.SetAttribute("operation", string(ar.Request.Operation))
+	}
+
 	patches, err := h.review(ctx, ar.Request)
+	span.RecordError(err)
+	span.End()
 	if err != nil {
 		log.Ctx(ctx).Error().Err(err).Msg("Unable to handle admission request")
 
@@ -130,6 +154,53 @@ func (h Handler) review(ctx context.Context, req *admv1.AdmissionRequest) ([]byt
 		}
 	}
 
+	// Updates made while the EdgeIngress is being deleted, such as removing its finalizer, don't need to be reviewed.
+	if newEdgeIng != nil && newEdgeIng.DeletionTimestamp != nil {
+		return nil, nil
+	}
+
+	if newEdgeIng != nil && req.Operation != admv1.Delete {
+		if err = validateServices(newEdgeIng.Spec); err != nil {
+			return nil, err
+		}
+
+		if err = validateMode(newEdgeIng.Spec); err != nil {
+			return nil, err
+		}
+
+		if err = validateMiddlewares(newEdgeIng.Spec); err != nil {
+			return nil, err
+		}
+
+		if err = validateIngressMetadata(newEdgeIng.Spec); err != nil {
+			return nil, err
+		}
+
+		if err = validateEntryPoints(newEdgeIng.Spec); err != nil {
+			return nil, err
+		}
+
+		if err = validateAllowedSourceIPs(newEdgeIng.Spec); err != nil {
+			return nil, err
+		}
+
+		if err = validateHeaders(newEdgeIng.Spec); err != nil {
+			return nil, err
+		}
+
+		if err = validateMaintenance(newEdgeIng.Spec); err != nil {
+			return nil, err
+		}
+
+		if err = validateBandwidth(newEdgeIng.Spec); err != nil {
+			return nil, err
+		}
+
+		if err = h.validateCustomDomains(ctx, newEdgeIng.Spec.CustomDomains); err != nil {
+			return nil, err
+		}
+	}
+
 	switch req.Operation {
 	case admv1.Create:
 		return h.reviewCreateOperation(ctx, newEdgeIng)
@@ -145,47 +216,105 @@ func (h Handler) review(ctx context.Context, req *admv1.AdmissionRequest) ([]byt
 func (h Handler) reviewCreateOperation(ctx context.Context, edgeIng *hubv1alpha1.EdgeIngress) ([]byte, error) {
 	log.Ctx(ctx).Info().Msg("Creating EdgeIngress resource")
 
+	// Quotas are also enforced by a validating webhook, but it runs after this one: the edge ingress would already be
+	// created on the platform.
+	if h.quotas != nil {
+		if err := h.quotas.Check(edgeIng.Namespace); err != nil {
+			return nil, err
+		}
+	}
+
 	createReq := &platform.CreateEdgeIngressReq{
 		Name:      edgeIng.Name,
 		Namespace: edgeIng.Namespace,
+		Mode:      string(edgeIng.Spec.Mode),
 		Service: platform.Service{
-			Name: edgeIng.Spec.Service.Name,
-			Port: edgeIng.Spec.Service.Port,
+			Name:     edgeIng.Spec.Service.Name,
+			Port:     edgeIng.Spec.Service.Port,
+			Protocol: string(edgeIng.Spec.Service.Protocol),
 		},
+		CustomDomains: edgeIng.Spec.CustomDomains,
+		Services:      buildWeightedServices(edgeIng.Spec.Services),
 	}
 	if edgeIng.Spec.ACP != nil {
 		createReq.ACP = &platform.ACP{Name: edgeIng.Spec.ACP.Name}
 	}
+	if edgeIng.Spec.Sticky != nil {
+		createReq.Sticky = &platform.Sticky{
+			CookieName: edgeIng.Spec.Sticky.CookieName,
+			TTL:        edgeIng.Spec.Sticky.TTL,
+		}
+	}
+	createReq.ACPs, createReq.Middlewares = buildMiddlewares(edgeIng.Spec)
+	createReq.IngressAnnotations = edgeIng.Spec.IngressAnnotations
+	createReq.IngressLabels = edgeIng.Spec.IngressLabels
+	createReq.EntryPoints = edgeIng.Spec.EntryPoints
+	createReq.AllowedSourceIPs = edgeIng.Spec.AllowedSourceIPs
+	createReq.Headers = buildHeaders(edgeIng.Spec.Headers)
+	createReq.Paused = edgeIng.Spec.Paused
+	createReq.Maintenance = buildMaintenance(edgeIng.Spec.Maintenance)
+	createReq.Bandwidth = buildBandwidth(edgeIng.Spec.Bandwidth)
+	if edgeIng.Spec.TLS != nil {
+		createReq.TLS = &platform.TLS{
+			Options:      edgeIng.Spec.TLS.Options,
+			RedirectHTTP: edgeIng.Spec.TLS.RedirectHTTP,
+		}
+	}
 
 	createdEdgeIng, err := h.backend.CreateEdgeIngress(ctx, createReq)
 	if err != nil {
 		return nil, fmt.Errorf("create edge ingress: %w", err)
 	}
 
-	return h.buildPatches(createdEdgeIng)
+	return h.buildPatches(createdEdgeIng, edgeIng.Finalizers)
 }
 
 func (h Handler) reviewUpdateOperation(ctx context.Context, oldEdgeIng, newEdgeIng *hubv1alpha1.EdgeIngress) ([]byte, error) {
 	log.Ctx(ctx).Info().Msg("Updating EdgeIngress resource")
 
 	updateReq := &platform.UpdateEdgeIngressReq{
+		Mode: string(newEdgeIng.Spec.Mode),
 		Service: platform.Service{
-			Name: newEdgeIng.Spec.Service.Name,
-			Port: newEdgeIng.Spec.Service.Port,
+			Name:     newEdgeIng.Spec.Service.Name,
+			Port:     newEdgeIng.Spec.Service.Port,
+			Protocol: string(newEdgeIng.Spec.Service.Protocol),
 		},
+		CustomDomains: newEdgeIng.Spec.CustomDomains,
+		Services:      buildWeightedServices(newEdgeIng.Spec.Services),
 	}
 	if newEdgeIng.Spec.ACP != nil {
 		updateReq.ACP = &platform.ACP{
 			Name: newEdgeIng.Spec.ACP.Name,
 		}
 	}
+	if newEdgeIng.Spec.Sticky != nil {
+		updateReq.Sticky = &platform.Sticky{
+			CookieName: newEdgeIng.Spec.Sticky.CookieName,
+			TTL:        newEdgeIng.Spec.Sticky.TTL,
+		}
+	}
+	updateReq.ACPs, updateReq.Middlewares = buildMiddlewares(newEdgeIng.Spec)
+	updateReq.IngressAnnotations = newEdgeIng.Spec.IngressAnnotations
+	updateReq.IngressLabels = newEdgeIng.Spec.IngressLabels
+	updateReq.EntryPoints = newEdgeIng.Spec.EntryPoints
+	updateReq.AllowedSourceIPs = newEdgeIng.Spec.AllowedSourceIPs
+	updateReq.Headers = buildHeaders(newEdgeIng.Spec.Headers)
+	updateReq.Paused = newEdgeIng.Spec.Paused
+	updateReq.Maintenance = buildMaintenance(newEdgeIng.Spec.Maintenance)
+	updateReq.Bandwidth = buildBandwidth(newEdgeIng.Spec.Bandwidth)
+	if newEdgeIng.Spec.TLS != nil {
+		updateReq.TLS = &platform.TLS{
+			Options:      newEdgeIng.Spec.TLS.Options,
+			RedirectHTTP: newEdgeIng.Spec.TLS.RedirectHTTP,
+		}
+	}
 
 	updatedEdgeIng, err := h.backend.UpdateEdgeIngress(ctx, oldEdgeIng.Namespace, oldEdgeIng.Name, oldEdgeIng.Status.Version, updateReq)
 	if err != nil {
 		return nil, fmt.Errorf("update edge ingress: %w", err)
 	}
 
-	return h.buildPatches(updatedEdgeIng)
+	return h.buildPatches(updatedEdgeIng, newEdgeIng.Finalizers)
 }
 
 func (h Handler) reviewDeleteOperation(ctx context.Context, oldEdgeIng *hubv1alpha1.EdgeIngress) ([]byte, error) {
@@ -197,21 +326,369 @@ func (h Handler) reviewDeleteOperation(ctx context.Context, oldEdgeIng *hubv1alp
 	return nil, nil
 }
 
+// validateServices makes sure the spec either exposes a single service or load-balances between weighted services.
+func validateServices(spec hubv1alpha1.EdgeIngressSpec) error {
+	if spec.Sticky != nil && spec.Sticky.TTL < 0 {
+		return errors.New("sticky sessions TTL must not be negative")
+	}
+
+	switch spec.Service.Protocol {
+	case "", hubv1alpha1.EdgeIngressServiceProtocolHTTP, hubv1alpha1.EdgeIngressServiceProtocolH2C, hubv1alpha1.EdgeIngressServiceProtocolGRPC:
+	default:
+		return fmt.Errorf("unsupported service protocol %q", spec.Service.Protocol)
+	}
+
+	if len(spec.Services) == 0 {
+		return nil
+	}
+
+	if spec.Service.Name != "" {
+		return errors.New("service and services are mutually exclusive")
+	}
+
+	for _, service := range spec.Services {
+		if service.Name == "" {
+			return errors.New("weighted service name is required")
+		}
+		if service.Weight < 0 {
+			return fmt.Errorf("weighted service %q has a negative weight", service.Name)
+		}
+	}
+
+	return nil
+}
+
+// validateMode makes sure the spec only uses features supported by its mode.
+func validateMode(spec hubv1alpha1.EdgeIngressSpec) error {
+	switch spec.Mode {
+	case "", hubv1alpha1.EdgeIngressModeHTTP:
+		return nil
+	case hubv1alpha1.EdgeIngressModeTCP:
+		if spec.ACP != nil || len(spec.ACPs) > 0 {
+			return errors.New("ACPs are not supported in tcp mode")
+		}
+		if spec.Middlewares != nil {
+			return errors.New("middlewares are not supported in tcp mode")
+		}
+		if len(spec.Services) > 0 {
+			return errors.New("weighted services are not supported in tcp mode")
+		}
+		if spec.Sticky != nil {
+			return errors.New("sticky sessions are not supported in tcp mode")
+		}
+		if spec.TLS != nil && spec.TLS.RedirectHTTP {
+			return errors.New("HTTP redirection is not supported in tcp mode")
+		}
+		if spec.Headers != nil {
+			return errors.New("headers are not supported in tcp mode")
+		}
+		if spec.Service.Protocol != "" {
+			return errors.New("service protocol is not supported in tcp mode")
+		}
+		if spec.Maintenance != nil && spec.Maintenance.Message != "" {
+			return errors.New("maintenance responses are not supported in tcp mode")
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported mode %q", spec.Mode)
+	}
+}
+
+// validateMiddlewares makes sure the ACPs and middlewares applied on the exposed service are consistent.
+func validateMiddlewares(spec hubv1alpha1.EdgeIngressSpec) error {
+	if spec.ACP != nil && len(spec.ACPs) > 0 {
+		return errors.New("acp and acps are mutually exclusive")
+	}
+
+	for _, policy := range spec.ACPs {
+		if policy.Name == "" {
+			return errors.New("ACP name is required")
+		}
+	}
+
+	if spec.Middlewares != nil && spec.Middlewares.RateLimit != nil && spec.Middlewares.RateLimit.Average <= 0 {
+		return errors.New("rate limit average must be positive")
+	}
+
+	if spec.Middlewares != nil && spec.Middlewares.Compress != nil {
+		compression := spec.Middlewares.Compress
+		if compression.MinResponseBodyBytes < 0 {
+			return errors.New("compression min response body bytes must be positive")
+		}
+
+		for _, contentType := range compression.ExcludedContentTypes {
+			mediaType, _, err := mime.ParseMediaType(contentType)
+			if err != nil || !strings.Contains(mediaType, "/") {
+				return fmt.Errorf("invalid excluded content type %q", contentType)
+			}
+		}
+	}
+
+	return nil
+}
+
+// validateEntryPoints makes sure the entry points the generated route listens on are named.
+func validateEntryPoints(spec hubv1alpha1.EdgeIngressSpec) error {
+	for _, entryPoint := range spec.EntryPoints {
+		if entryPoint == "" {
+			return errors.New("entry point name is required")
+		}
+	}
+
+	return nil
+}
+
+// validateAllowedSourceIPs makes sure the allowed sources are IPs or CIDR ranges.
+func validateAllowedSourceIPs(spec hubv1alpha1.EdgeIngressSpec) error {
+	for _, source := range spec.AllowedSourceIPs {
+		if net.ParseIP(source) != nil {
+			continue
+		}
+		if _, _, err := net.ParseCIDR(source); err != nil {
+			return fmt.Errorf("allowed source %q is neither an IP nor a CIDR range", source)
+		}
+	}
+
+	return nil
+}
+
+// validateHeaders makes sure each header is either set to a value or removed.
+func validateHeaders(spec hubv1alpha1.EdgeIngressSpec) error {
+	if spec.Headers == nil {
+		return nil
+	}
+
+	if err := validateHeaderRules(spec.Headers.Request); err != nil {
+		return fmt.Errorf("request headers: %w", err)
+	}
+
+	if err := validateHeaderRules(spec.Headers.Response); err != nil {
+		return fmt.Errorf("response headers: %w", err)
+	}
+
+	return nil
+}
+
+// validateMaintenance makes sure a maintenance message is only set when a maintenance response is served.
+func validateMaintenance(spec hubv1alpha1.EdgeIngressSpec) error {
+	if spec.Maintenance != nil && spec.Maintenance.RemoveRoute && spec.Maintenance.Message != "" {
+		return errors.New("maintenance message can't be set when the route is removed")
+	}
+
+	return nil
+}
+
+// validateBandwidth makes sure the bandwidth caps are positive.
+func validateBandwidth(spec hubv1alpha1.EdgeIngressSpec) error {
+	if spec.Bandwidth == nil {
+		return nil
+	}
+
+	if spec.Bandwidth.EgressBytesPerSecond <= 0 {
+		return errors.New("bandwidth egress bytes per second must be positive")
+	}
+	if spec.Bandwidth.BurstBytes < 0 {
+		return errors.New("bandwidth burst bytes must be positive")
+	}
+
+	return nil
+}
+
+func validateHeaderRules(rules *hubv1alpha1.EdgeIngressHeaderRules) error {
+	if rules == nil {
+		return nil
+	}
+
+	// Header names are case-insensitive.
+	set := make(map[string]struct{}, len(rules.Set))
+	for name, value := range rules.Set {
+		if name == "" {
+			return errors.New("header name is required")
+		}
+		if value == "" {
+			return fmt.Errorf("header %q must have a value, use remove to remove it", name)
+		}
+
+		set[http.CanonicalHeaderKey(name)] = struct{}{}
+	}
+
+	for _, name := range rules.Remove {
+		if name == "" {
+			return errors.New("header name is required")
+		}
+		if _, ok := set[http.CanonicalHeaderKey(name)]; ok {
+			return fmt.Errorf("header %q cannot be both set and removed", name)
+		}
+	}
+
+	return nil
+}
+
+// reservedAnnotations are the annotations managed by the agent on the generated resources.
+var reservedAnnotations = []string{
+	reviewer.AnnotationHubAuth,
+	"traefik.ingress.kubernetes.io/router.tls",
+	"traefik.ingress.kubernetes.io/router.tls.options",
+	"traefik.ingress.kubernetes.io/router.entrypoints",
+	"traefik.ingress.kubernetes.io/router.middlewares",
+}
+
+// validateIngressMetadata makes sure the annotations and labels to add on the generated resources don't collide with
+// the ones managed by the agent.
+func validateIngressMetadata(spec hubv1alpha1.EdgeIngressSpec) error {
+	for _, annotation := range reservedAnnotations {
+		if _, ok := spec.IngressAnnotations[annotation]; ok {
+			return fmt.Errorf("annotation %q is managed by the agent", annotation)
+		}
+	}
+
+	if _, ok := spec.IngressLabels["app.kubernetes.io/managed-by"]; ok {
+		return errors.New(`label "app.kubernetes.io/managed-by" is managed by the agent`)
+	}
+
+	return nil
+}
+
+func buildMiddlewares(spec hubv1alpha1.EdgeIngressSpec) ([]platform.ACP, *platform.Middlewares) {
+	var acps []platform.ACP
+	for _, policy := range spec.ACPs {
+		acps = append(acps, platform.ACP{Name: policy.Name})
+	}
+
+	if spec.Middlewares == nil {
+		return acps, nil
+	}
+
+	middlewares := &platform.Middlewares{}
+	if spec.Middlewares.RateLimit != nil {
+		middlewares.RateLimit = &platform.RateLimit{
+			Average: spec.Middlewares.RateLimit.Average,
+			Burst:   spec.Middlewares.RateLimit.Burst,
+		}
+	}
+	if spec.Middlewares.Compress != nil {
+		middlewares.Compress = &platform.Compression{
+			ExcludedContentTypes: spec.Middlewares.Compress.ExcludedContentTypes,
+			MinResponseBodyBytes: spec.Middlewares.Compress.MinResponseBodyBytes,
+		}
+	}
+
+	return acps, middlewares
+}
+
+func buildHeaders(headers *hubv1alpha1.EdgeIngressHeaders) *platform.Headers {
+	if headers == nil {
+		return nil
+	}
+
+	return &platform.Headers{
+		Request:  buildHeaderRules(headers.Request),
+		Response: buildHeaderRules(headers.Response),
+	}
+}
+
+func buildHeaderRules(rules *hubv1alpha1.EdgeIngressHeaderRules) *platform.HeaderRules {
+	if rules == nil {
+		return nil
+	}
+
+	return &platform.HeaderRules{
+		Set:    rules.Set,
+		Remove: rules.Remove,
+	}
+}
+
+func buildMaintenance(maintenance *hubv1alpha1.EdgeIngressMaintenance) *platform.Maintenance {
+	if maintenance == nil {
+		return nil
+	}
+
+	return &platform.Maintenance{
+		Message:     maintenance.Message,
+		RemoveRoute: maintenance.RemoveRoute,
+	}
+}
+
+func buildBandwidth(bandwidth *hubv1alpha1.EdgeIngressBandwidth) *platform.Bandwidth {
+	if bandwidth == nil {
+		return nil
+	}
+
+	return &platform.Bandwidth{
+		EgressBytesPerSecond: bandwidth.EgressBytesPerSecond,
+		BurstBytes:           bandwidth.BurstBytes,
+	}
+}
+
+func buildWeightedServices(services []hubv1alpha1.EdgeIngressWeightedService) []platform.WeightedService {
+	var weighted []platform.WeightedService
+	for _, service := range services {
+		weighted = append(weighted, platform.WeightedService{
+			Name:   service.Name,
+			Port:   service.Port,
+			Weight: service.Weight,
+		})
+	}
+
+	return weighted
+}
+
+// validateCustomDomains makes sure all the given custom domains are verified on the platform.
+func (h Handler) validateCustomDomains(ctx context.Context, customDomains []string) error {
+	if len(customDomains) == 0 {
+		return nil
+	}
+
+	verified := make(map[string]struct{})
+	for _, domain := range h.domains.ListVerifiedDomains(ctx) {
+		verified[strings.ToLower(domain)] = struct{}{}
+	}
+
+	var unverified []string
+	for _, domain := range customDomains {
+		if _, ok := verified[strings.ToLower(domain)]; !ok {
+			unverified = append(unverified, domain)
+		}
+	}
+
+	if len(unverified) > 0 {
+		return fmt.Errorf("custom domains %q are not verified", strings.Join(unverified, ","))
+	}
+
+	return nil
+}
+
 type patch struct {
 	Op    string      `json:"op"`
 	Path  string      `json:"path"`
 	Value interface{} `json:"value,omitempty"`
 }
 
-func (h Handler) buildPatches(edgeIng *edgeingress.EdgeIngress) ([]byte, error) {
+// buildPatches builds the patches setting the status of the reviewed edge ingress, and making sure its deletion is
+// protected by the edge ingress finalizer.
+func (h Handler) buildPatches(edgeIng *edgeingress.EdgeIngress, finalizers []string) ([]byte, error) {
 	res, err := edgeIng.Resource()
 	if err != nil {
 		return nil, fmt.Errorf("build resource: %w", err)
 	}
 
-	return json.Marshal([]patch{
+	patches := []patch{
 		{Op: "replace", Path: "/status", Value: res.Status},
+	}
+
+	for _, finalizer := range finalizers {
+		if finalizer == edgeingress.Finalizer {
+			return json.Marshal(patches)
+		}
+	}
+
+	patches = append(patches, patch{
+		Op:    "add",
+		Path:  "/metadata/finalizers",
+		Value: append(finalizers, edgeingress.Finalizer),
 	})
+
+	return json.Marshal(patches)
 }
 
 // parseRawEdgeIngresses parses raw objects from admission requests into edge ingress resources.
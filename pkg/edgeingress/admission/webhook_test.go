@@ -104,7 +104,7 @@ func TestHandler_ServeHTTP_createOperation(t *testing.T) {
 	client := newBackendMock(t)
 	client.OnCreateEdgeIngress(wantCreateReq).TypedReturns(createdEdgeIngress, nil).Once()
 
-	h := NewHandler(client)
+	h := NewHandler(client, nil, nil)
 	h.now = func() time.Time { return now.Time }
 
 	b := mustMarshal(t, admissionRev)
@@ -133,6 +133,7 @@ func TestHandler_ServeHTTP_createOperation(t *testing.T) {
 				SpecHash:   "NexiGZBcal8NDre24JKd5LKyxF4=",
 				Connection: hubv1alpha1.EdgeIngressConnectionDown,
 			}},
+			{Op: "add", Path: "/metadata/finalizers", Value: []string{edgeingress.Finalizer}},
 		}),
 	}
 
@@ -180,7 +181,7 @@ func TestHandler_ServeHTTP_createOperationConflict(t *testing.T) {
 	client := newBackendMock(t)
 	client.OnCreateEdgeIngressRaw(mock.Anything).TypedReturns(nil, platform.ErrVersionConflict).Once()
 
-	h := NewHandler(client)
+	h := NewHandler(client, nil, nil)
 
 	b := mustMarshal(t, admissionRev)
 	rec := httptest.NewRecorder()
@@ -205,6 +206,382 @@ func TestHandler_ServeHTTP_createOperationConflict(t *testing.T) {
 	assert.Equal(t, &wantResp, gotAr.Response)
 }
 
+func TestHandler_ServeHTTP_createOperationCustomDomains(t *testing.T) {
+	tests := []struct {
+		desc            string
+		customDomains   []string
+		verifiedDomains []string
+		wantCreate      bool
+		wantAllowed     bool
+		wantMessage     string
+	}{
+		{
+			desc:            "verified custom domains",
+			customDomains:   []string{"hello.example.com", "Welcome.Example.com"},
+			verifiedDomains: []string{"hello.example.com", "welcome.example.com"},
+			wantCreate:      true,
+			wantAllowed:     true,
+		},
+		{
+			desc:            "unverified custom domains",
+			customDomains:   []string{"hello.example.com", "unverified.example.com"},
+			verifiedDomains: []string{"hello.example.com"},
+			wantMessage:     `custom domains "unverified.example.com" are not verified`,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			admissionRev := admv1.AdmissionReview{
+				Request: &admv1.AdmissionRequest{
+					UID: "id",
+					Kind: metav1.GroupVersionKind{
+						Group:   "hub.traefik.io",
+						Version: "v1alpha1",
+						Kind:    "EdgeIngress",
+					},
+					Name:      "edge-ingress",
+					Namespace: "default",
+					Operation: admv1.Create,
+					Object: runtime.RawExtension{
+						Raw: mustMarshal(t, hubv1alpha1.EdgeIngress{
+							TypeMeta: metav1.TypeMeta{
+								Kind:       "EdgeIngress",
+								APIVersion: "hub.traefik.io/v1alpha1",
+							},
+							ObjectMeta: metav1.ObjectMeta{
+								Name:      "edge-ingress",
+								Namespace: "default",
+							},
+							Spec: hubv1alpha1.EdgeIngressSpec{
+								Service: hubv1alpha1.EdgeIngressService{
+									Name: "whoami",
+									Port: 8081,
+								},
+								CustomDomains: test.customDomains,
+							},
+						}),
+					},
+				},
+				Response: &admv1.AdmissionResponse{},
+			}
+
+			client := newBackendMock(t)
+			if test.wantCreate {
+				wantCreateReq := &platform.CreateEdgeIngressReq{
+					Name:      "edge-ingress",
+					Namespace: "default",
+					Service: platform.Service{
+						Name: "whoami",
+						Port: 8081,
+					},
+					CustomDomains: test.customDomains,
+				}
+				createdEdgeIngress := &edgeingress.EdgeIngress{
+					Name:      "edge-ingress",
+					Namespace: "default",
+					Domain:    "majestic-beaver-123.hub-traefik.io",
+					Version:   "version-1",
+					Service:   edgeingress.Service{Name: "whoami", Port: 8081},
+				}
+				for _, domain := range test.customDomains {
+					createdEdgeIngress.CustomDomains = append(createdEdgeIngress.CustomDomains, edgeingress.CustomDomain{Name: domain, Verified: true})
+				}
+
+				client.OnCreateEdgeIngress(wantCreateReq).TypedReturns(createdEdgeIngress, nil).Once()
+			}
+
+			domains := newDomainListerMock(t)
+			domains.OnListVerifiedDomains().TypedReturns(test.verifiedDomains).Once()
+
+			h := NewHandler(client, domains, nil)
+
+			b := mustMarshal(t, admissionRev)
+			rec := httptest.NewRecorder()
+			req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, "/", bytes.NewBuffer(b))
+			require.NoError(t, err)
+
+			h.ServeHTTP(rec, req)
+
+			var gotAr admv1.AdmissionReview
+			err = json.NewDecoder(rec.Body).Decode(&gotAr)
+			require.NoError(t, err)
+
+			assert.Equal(t, test.wantAllowed, gotAr.Response.Allowed)
+			if !test.wantAllowed {
+				require.NotNil(t, gotAr.Response.Result)
+				assert.Equal(t, test.wantMessage, gotAr.Response.Result.Message)
+			}
+		})
+	}
+}
+
+func TestHandler_ServeHTTP_invalidSpec(t *testing.T) {
+	tests := []struct {
+		desc        string
+		spec        hubv1alpha1.EdgeIngressSpec
+		wantMessage string
+	}{
+		{
+			desc: "service and services",
+			spec: hubv1alpha1.EdgeIngressSpec{
+				Service:  hubv1alpha1.EdgeIngressService{Name: "whoami", Port: 8081},
+				Services: []hubv1alpha1.EdgeIngressWeightedService{{Name: "whoami-v2", Port: 8081, Weight: 1}},
+			},
+			wantMessage: "service and services are mutually exclusive",
+		},
+		{
+			desc: "negative weight",
+			spec: hubv1alpha1.EdgeIngressSpec{
+				Services: []hubv1alpha1.EdgeIngressWeightedService{{Name: "whoami-v2", Port: 8081, Weight: -1}},
+			},
+			wantMessage: `weighted service "whoami-v2" has a negative weight`,
+		},
+		{
+			desc: "negative sticky sessions TTL",
+			spec: hubv1alpha1.EdgeIngressSpec{
+				Service: hubv1alpha1.EdgeIngressService{Name: "whoami", Port: 8081},
+				Sticky:  &hubv1alpha1.EdgeIngressSticky{CookieName: "sticky", TTL: -1},
+			},
+			wantMessage: "sticky sessions TTL must not be negative",
+		},
+		{
+			desc: "sticky sessions in tcp mode",
+			spec: hubv1alpha1.EdgeIngressSpec{
+				Mode:    hubv1alpha1.EdgeIngressModeTCP,
+				Service: hubv1alpha1.EdgeIngressService{Name: "postgres", Port: 5432},
+				Sticky:  &hubv1alpha1.EdgeIngressSticky{CookieName: "sticky"},
+			},
+			wantMessage: "sticky sessions are not supported in tcp mode",
+		},
+		{
+			desc: "ACP in tcp mode",
+			spec: hubv1alpha1.EdgeIngressSpec{
+				Mode:    hubv1alpha1.EdgeIngressModeTCP,
+				Service: hubv1alpha1.EdgeIngressService{Name: "postgres", Port: 5432},
+				ACP:     &hubv1alpha1.EdgeIngressACP{Name: "acp"},
+			},
+			wantMessage: "ACPs are not supported in tcp mode",
+		},
+		{
+			desc: "acp and acps",
+			spec: hubv1alpha1.EdgeIngressSpec{
+				Service: hubv1alpha1.EdgeIngressService{Name: "whoami", Port: 8081},
+				ACP:     &hubv1alpha1.EdgeIngressACP{Name: "acp"},
+				ACPs:    []hubv1alpha1.EdgeIngressACP{{Name: "acp-1"}, {Name: "acp-2"}},
+			},
+			wantMessage: "acp and acps are mutually exclusive",
+		},
+		{
+			desc: "invalid rate limit",
+			spec: hubv1alpha1.EdgeIngressSpec{
+				Service: hubv1alpha1.EdgeIngressService{Name: "whoami", Port: 8081},
+				Middlewares: &hubv1alpha1.EdgeIngressMiddlewares{
+					RateLimit: &hubv1alpha1.EdgeIngressRateLimit{Burst: 10},
+				},
+			},
+			wantMessage: "rate limit average must be positive",
+		},
+		{
+			desc: "invalid compression min response body bytes",
+			spec: hubv1alpha1.EdgeIngressSpec{
+				Service: hubv1alpha1.EdgeIngressService{Name: "whoami", Port: 8081},
+				Middlewares: &hubv1alpha1.EdgeIngressMiddlewares{
+					Compress: &hubv1alpha1.EdgeIngressCompression{MinResponseBodyBytes: -1},
+				},
+			},
+			wantMessage: "compression min response body bytes must be positive",
+		},
+		{
+			desc: "invalid compression excluded content type",
+			spec: hubv1alpha1.EdgeIngressSpec{
+				Service: hubv1alpha1.EdgeIngressService{Name: "whoami", Port: 8081},
+				Middlewares: &hubv1alpha1.EdgeIngressMiddlewares{
+					Compress: &hubv1alpha1.EdgeIngressCompression{ExcludedContentTypes: []string{"text"}},
+				},
+			},
+			wantMessage: `invalid excluded content type "text"`,
+		},
+		{
+			desc: "reserved annotation",
+			spec: hubv1alpha1.EdgeIngressSpec{
+				Service: hubv1alpha1.EdgeIngressService{Name: "whoami", Port: 8081},
+				IngressAnnotations: map[string]string{
+					"traefik.ingress.kubernetes.io/router.entrypoints": "websecure",
+				},
+			},
+			wantMessage: `annotation "traefik.ingress.kubernetes.io/router.entrypoints" is managed by the agent`,
+		},
+		{
+			desc: "unsupported mode",
+			spec: hubv1alpha1.EdgeIngressSpec{
+				Mode:    "udp",
+				Service: hubv1alpha1.EdgeIngressService{Name: "dns", Port: 53},
+			},
+			wantMessage: `unsupported mode "udp"`,
+		},
+		{
+			desc: "HTTP redirection in tcp mode",
+			spec: hubv1alpha1.EdgeIngressSpec{
+				Mode:    hubv1alpha1.EdgeIngressModeTCP,
+				Service: hubv1alpha1.EdgeIngressService{Name: "postgres", Port: 5432},
+				TLS:     &hubv1alpha1.EdgeIngressTLS{RedirectHTTP: true},
+			},
+			wantMessage: "HTTP redirection is not supported in tcp mode",
+		},
+		{
+			desc: "empty entry point",
+			spec: hubv1alpha1.EdgeIngressSpec{
+				Service:     hubv1alpha1.EdgeIngressService{Name: "whoami", Port: 8081},
+				EntryPoints: []string{"websecure", ""},
+			},
+			wantMessage: "entry point name is required",
+		},
+		{
+			desc: "invalid allowed source",
+			spec: hubv1alpha1.EdgeIngressSpec{
+				Service:          hubv1alpha1.EdgeIngressService{Name: "whoami", Port: 8081},
+				AllowedSourceIPs: []string{"10.0.0.0/8", "office"},
+			},
+			wantMessage: `allowed source "office" is neither an IP nor a CIDR range`,
+		},
+		{
+			desc: "unsupported service protocol",
+			spec: hubv1alpha1.EdgeIngressSpec{
+				Service: hubv1alpha1.EdgeIngressService{Name: "whoami", Port: 8081, Protocol: "http3"},
+			},
+			wantMessage: `unsupported service protocol "http3"`,
+		},
+		{
+			desc: "service protocol in tcp mode",
+			spec: hubv1alpha1.EdgeIngressSpec{
+				Mode:    hubv1alpha1.EdgeIngressModeTCP,
+				Service: hubv1alpha1.EdgeIngressService{Name: "whoami", Port: 8081, Protocol: hubv1alpha1.EdgeIngressServiceProtocolGRPC},
+			},
+			wantMessage: "service protocol is not supported in tcp mode",
+		},
+		{
+			desc: "maintenance message in tcp mode",
+			spec: hubv1alpha1.EdgeIngressSpec{
+				Mode:        hubv1alpha1.EdgeIngressModeTCP,
+				Service:     hubv1alpha1.EdgeIngressService{Name: "whoami", Port: 8081},
+				Paused:      true,
+				Maintenance: &hubv1alpha1.EdgeIngressMaintenance{Message: "Back at noon"},
+			},
+			wantMessage: "maintenance responses are not supported in tcp mode",
+		},
+		{
+			desc: "maintenance message with route removal",
+			spec: hubv1alpha1.EdgeIngressSpec{
+				Service:     hubv1alpha1.EdgeIngressService{Name: "whoami", Port: 8081},
+				Maintenance: &hubv1alpha1.EdgeIngressMaintenance{Message: "Back at noon", RemoveRoute: true},
+			},
+			wantMessage: "maintenance message can't be set when the route is removed",
+		},
+		{
+			desc: "bandwidth without egress rate",
+			spec: hubv1alpha1.EdgeIngressSpec{
+				Service:   hubv1alpha1.EdgeIngressService{Name: "whoami", Port: 8081},
+				Bandwidth: &hubv1alpha1.EdgeIngressBandwidth{BurstBytes: 1024},
+			},
+			wantMessage: "bandwidth egress bytes per second must be positive",
+		},
+		{
+			desc: "negative bandwidth burst",
+			spec: hubv1alpha1.EdgeIngressSpec{
+				Service:   hubv1alpha1.EdgeIngressService{Name: "whoami", Port: 8081},
+				Bandwidth: &hubv1alpha1.EdgeIngressBandwidth{EgressBytesPerSecond: 1024, BurstBytes: -1},
+			},
+			wantMessage: "bandwidth burst bytes must be positive",
+		},
+		{
+			desc: "header set to an empty value",
+			spec: hubv1alpha1.EdgeIngressSpec{
+				Service: hubv1alpha1.EdgeIngressService{Name: "whoami", Port: 8081},
+				Headers: &hubv1alpha1.EdgeIngressHeaders{
+					Request: &hubv1alpha1.EdgeIngressHeaderRules{Set: map[string]string{"X-Foo": ""}},
+				},
+			},
+			wantMessage: `request headers: header "X-Foo" must have a value, use remove to remove it`,
+		},
+		{
+			desc: "header both set and removed",
+			spec: hubv1alpha1.EdgeIngressSpec{
+				Service: hubv1alpha1.EdgeIngressService{Name: "whoami", Port: 8081},
+				Headers: &hubv1alpha1.EdgeIngressHeaders{
+					Response: &hubv1alpha1.EdgeIngressHeaderRules{
+						Set:    map[string]string{"X-Foo": "bar"},
+						Remove: []string{"x-foo"},
+					},
+				},
+			},
+			wantMessage: `response headers: header "x-foo" cannot be both set and removed`,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			admissionRev := admv1.AdmissionReview{
+				Request: &admv1.AdmissionRequest{
+					UID: "id",
+					Kind: metav1.GroupVersionKind{
+						Group:   "hub.traefik.io",
+						Version: "v1alpha1",
+						Kind:    "EdgeIngress",
+					},
+					Name:      "edge-ingress",
+					Namespace: "default",
+					Operation: admv1.Create,
+					Object: runtime.RawExtension{
+						Raw: mustMarshal(t, hubv1alpha1.EdgeIngress{
+							TypeMeta: metav1.TypeMeta{
+								Kind:       "EdgeIngress",
+								APIVersion: "hub.traefik.io/v1alpha1",
+							},
+							ObjectMeta: metav1.ObjectMeta{
+								Name:      "edge-ingress",
+								Namespace: "default",
+							},
+							Spec: test.spec,
+						}),
+					},
+				},
+				Response: &admv1.AdmissionResponse{},
+			}
+
+			h := NewHandler(newBackendMock(t), nil, nil)
+
+			b := mustMarshal(t, admissionRev)
+			rec := httptest.NewRecorder()
+			req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, "/", bytes.NewBuffer(b))
+			require.NoError(t, err)
+
+			h.ServeHTTP(rec, req)
+
+			var gotAr admv1.AdmissionReview
+			err = json.NewDecoder(rec.Body).Decode(&gotAr)
+			require.NoError(t, err)
+
+			wantResp := admv1.AdmissionResponse{
+				UID:     "id",
+				Allowed: false,
+				Result: &metav1.Status{
+					Status:  "Failure",
+					Message: test.wantMessage,
+				},
+			}
+
+			assert.Equal(t, &wantResp, gotAr.Response)
+		})
+	}
+}
+
 func TestHandler_ServeHTTP_updateOperation(t *testing.T) {
 	now := metav1.Now()
 
@@ -220,8 +597,9 @@ func TestHandler_ServeHTTP_updateOperation(t *testing.T) {
 			APIVersion: "hub.traefik.io/v1alpha1",
 		},
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      edgeIngName,
-			Namespace: edgeIngNamespace,
+			Name:       edgeIngName,
+			Namespace:  edgeIngNamespace,
+			Finalizers: []string{edgeingress.Finalizer},
 		},
 		Spec: hubv1alpha1.EdgeIngressSpec{
 			Service: hubv1alpha1.EdgeIngressService{
@@ -300,7 +678,7 @@ func TestHandler_ServeHTTP_updateOperation(t *testing.T) {
 	client.OnUpdateEdgeIngress(edgeIngNamespace, edgeIngName, version, wantUpdateReq).
 		TypedReturns(updatedEdgeIngress, nil).Once()
 
-	h := NewHandler(client)
+	h := NewHandler(client, nil, nil)
 	h.now = func() time.Time { return now.Time }
 
 	b := mustMarshal(t, admissionRev)
@@ -410,7 +788,7 @@ func TestHandler_ServeHTTP_updateOperationConflict(t *testing.T) {
 	client.OnUpdateEdgeIngressRaw(mock.Anything, mock.Anything, mock.Anything, mock.Anything).
 		TypedReturns(nil, platform.ErrVersionConflict).Once()
 
-	h := NewHandler(client)
+	h := NewHandler(client, nil, nil)
 
 	b := mustMarshal(t, admissionRev)
 	rec := httptest.NewRecorder()
@@ -488,7 +866,7 @@ func TestHandler_ServeHTTP_deleteOperation(t *testing.T) {
 	client.OnDeleteEdgeIngress(edgeIngNamespace, edgeIngName, version).
 		TypedReturns(nil).Once()
 
-	h := NewHandler(client)
+	h := NewHandler(client, nil, nil)
 
 	b := mustMarshal(t, admissionRev)
 	rec := httptest.NewRecorder()
@@ -562,7 +940,7 @@ func TestHandler_ServeHTTP_deleteOperationConflict(t *testing.T) {
 	client.OnDeleteEdgeIngressRaw(mock.Anything, mock.Anything, mock.Anything).
 		TypedReturns(platform.ErrVersionConflict).Once()
 
-	h := NewHandler(client)
+	h := NewHandler(client, nil, nil)
 
 	b := mustMarshal(t, admissionRev)
 	rec := httptest.NewRecorder()
@@ -606,7 +984,7 @@ func TestHandler_ServeHTTP_notAnEdgeIngress(t *testing.T) {
 		Response: &admv1.AdmissionResponse{},
 	})
 
-	h := NewHandler(nil)
+	h := NewHandler(nil, nil, nil)
 
 	rec := httptest.NewRecorder()
 	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, "/", bytes.NewBuffer(b))
@@ -649,7 +1027,7 @@ func TestHandler_ServeHTTP_unsupportedOperation(t *testing.T) {
 		Response: &admv1.AdmissionResponse{},
 	})
 
-	h := NewHandler(nil)
+	h := NewHandler(nil, nil, nil)
 
 	rec := httptest.NewRecorder()
 	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, "/", bytes.NewBuffer(b))
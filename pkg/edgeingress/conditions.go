@@ -0,0 +1,287 @@
+/*
+Copyright (C) 2022 Traefik Labs
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package edgeingress
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	hubv1alpha1 "github.com/traefik/hub-agent-kubernetes/pkg/crd/api/hub/v1alpha1"
+	"github.com/traefik/hub-agent-kubernetes/pkg/tunnel"
+	kerror "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// TunnelLister lists the tunnels opened for the cluster.
+type TunnelLister interface {
+	ListClusterTunnelEndpoints(ctx context.Context) ([]tunnel.Endpoint, error)
+}
+
+// updateTunnelCondition refreshes the tunnel condition and summary shared by all EdgeIngresses.
+func (w *Watcher) updateTunnelCondition(ctx context.Context) {
+	if w.config.Tunnels == nil {
+		return
+	}
+
+	endpoints, err := w.config.Tunnels.ListClusterTunnelEndpoints(ctx)
+	if err == nil {
+		w.tunnelSummary = summarizeTunnels(endpoints)
+	}
+
+	switch {
+	case err != nil:
+		log.Error().Err(err).Msg("Unable to list tunnel endpoints")
+		w.tunnelCondition = &metav1.Condition{
+			Type:    hubv1alpha1.EdgeIngressConditionTunnelConnected,
+			Status:  metav1.ConditionUnknown,
+			Reason:  "TunnelUnknown",
+			Message: "Unable to list tunnel endpoints",
+		}
+	case len(endpoints) == 0:
+		w.tunnelCondition = &metav1.Condition{
+			Type:    hubv1alpha1.EdgeIngressConditionTunnelConnected,
+			Status:  metav1.ConditionFalse,
+			Reason:  "NoTunnel",
+			Message: "No tunnel is opened for this cluster",
+		}
+	default:
+		w.tunnelCondition = &metav1.Condition{
+			Type:    hubv1alpha1.EdgeIngressConditionTunnelConnected,
+			Status:  metav1.ConditionTrue,
+			Reason:  "TunnelUp",
+			Message: fmt.Sprintf("%d tunnel(s) opened for this cluster", len(endpoints)),
+		}
+	}
+}
+
+// summarizeTunnels summarizes the statistics reported by the given tunnels, or returns nil if none reported any.
+func summarizeTunnels(endpoints []tunnel.Endpoint) *hubv1alpha1.EdgeIngressTunnel {
+	var summary *hubv1alpha1.EdgeIngressTunnel
+	for _, endpoint := range endpoints {
+		if endpoint.Status == nil || endpoint.Status.Stats == nil {
+			continue
+		}
+		if summary == nil {
+			summary = &hubv1alpha1.EdgeIngressTunnel{}
+		}
+
+		stats := endpoint.Status.Stats
+		summary.Connected++
+		summary.BytesReceived += stats.BytesReceived
+		summary.BytesSent += stats.BytesSent
+		summary.ActiveStreams += stats.ActiveStreams
+		summary.Reconnections += stats.Reconnections
+		summary.HandshakeFailures += stats.HandshakeFailures
+
+		if rtt := time.Duration(stats.RTTMilliseconds) * time.Millisecond; rtt > summary.RTT.Duration {
+			summary.RTT = metav1.Duration{Duration: rtt}
+		}
+	}
+
+	return summary
+}
+
+// refreshConditions updates the conditions and the tunnel summary of the given EdgeIngress, if they changed.
+func (w *Watcher) refreshConditions(ctx context.Context, edgeIng *hubv1alpha1.EdgeIngress) error {
+	conditions := make([]metav1.Condition, len(edgeIng.Status.Conditions))
+	copy(conditions, edgeIng.Status.Conditions)
+	tunnelSummary := edgeIng.Status.Tunnel
+
+	w.setConditions(ctx, edgeIng)
+
+	if reflect.DeepEqual(conditions, edgeIng.Status.Conditions) && reflect.DeepEqual(tunnelSummary, edgeIng.Status.Tunnel) {
+		return nil
+	}
+
+	ctxUpdate, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	_, err := w.hubClientSet.HubV1alpha1().EdgeIngresses(edgeIng.Namespace).Update(ctxUpdate, edgeIng, metav1.UpdateOptions{})
+	if err != nil {
+		return fmt.Errorf("updating EdgeIngress: %w", err)
+	}
+
+	return nil
+}
+
+// setConditions computes the conditions of the given EdgeIngress, and sets its tunnel summary.
+func (w *Watcher) setConditions(ctx context.Context, edgeIng *hubv1alpha1.EdgeIngress) {
+	if w.config.Tunnels != nil {
+		edgeIng.Status.Tunnel = w.tunnelSummary
+	}
+
+	conditions := []metav1.Condition{
+		w.certificateCondition(ctx, edgeIng),
+		w.backendCondition(ctx, edgeIng),
+	}
+	if w.tunnelCondition != nil {
+		conditions = append([]metav1.Condition{*w.tunnelCondition}, conditions...)
+	}
+
+	ready := metav1.Condition{
+		Type:    hubv1alpha1.EdgeIngressConditionReady,
+		Status:  metav1.ConditionTrue,
+		Reason:  "Ready",
+		Message: "The EdgeIngress is ready to serve traffic",
+	}
+	for _, condition := range conditions {
+		if condition.Status != metav1.ConditionTrue {
+			ready.Status = condition.Status
+			ready.Reason = condition.Reason
+			ready.Message = condition.Message
+			break
+		}
+	}
+
+	// The paused condition is only reported while the EdgeIngress is paused, and then takes precedence.
+	if edgeIng.Spec.Paused {
+		paused := pausedCondition(edgeIng)
+		conditions = append(conditions, paused)
+
+		ready.Status = metav1.ConditionFalse
+		ready.Reason = paused.Reason
+		ready.Message = paused.Message
+	} else if meta.FindStatusCondition(edgeIng.Status.Conditions, hubv1alpha1.EdgeIngressConditionPaused) != nil {
+		// RemoveStatusCondition panics on empty conditions in this apimachinery version.
+		meta.RemoveStatusCondition(&edgeIng.Status.Conditions, hubv1alpha1.EdgeIngressConditionPaused)
+	}
+
+	for _, condition := range append(conditions, ready) {
+		condition.ObservedGeneration = edgeIng.Generation
+		meta.SetStatusCondition(&edgeIng.Status.Conditions, condition)
+	}
+}
+
+func pausedCondition(edgeIng *hubv1alpha1.EdgeIngress) metav1.Condition {
+	msg := "Requests are answered with a maintenance response"
+	if removesRouteWhenPaused(edgeIng) {
+		msg = "Routes are removed"
+	}
+
+	return metav1.Condition{
+		Type:    hubv1alpha1.EdgeIngressConditionPaused,
+		Status:  metav1.ConditionTrue,
+		Reason:  "Paused",
+		Message: "The EdgeIngress is paused: " + msg,
+	}
+}
+
+func (w *Watcher) certificateCondition(ctx context.Context, edgeIng *hubv1alpha1.EdgeIngress) metav1.Condition {
+	secrets := map[string]string{secretName: w.config.AgentNamespace}
+	if len(edgeIng.Status.CustomDomains) > 0 {
+		secrets[secretCustomDomainsName+"-"+edgeIng.Name] = edgeIng.Namespace
+	}
+
+	for name, namespace := range secrets {
+		secret, err := w.clientSet.CoreV1().Secrets(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil && !kerror.IsNotFound(err) {
+			return metav1.Condition{
+				Type:    hubv1alpha1.EdgeIngressConditionCertificateReady,
+				Status:  metav1.ConditionUnknown,
+				Reason:  "CertificateUnknown",
+				Message: fmt.Sprintf("Unable to get certificate secret %s/%s", namespace, name),
+			}
+		}
+
+		if kerror.IsNotFound(err) || len(secret.Data["tls.crt"]) == 0 {
+			return metav1.Condition{
+				Type:    hubv1alpha1.EdgeIngressConditionCertificateReady,
+				Status:  metav1.ConditionFalse,
+				Reason:  "CertificateMissing",
+				Message: fmt.Sprintf("Certificate secret %s/%s is not available yet", namespace, name),
+			}
+		}
+	}
+
+	return metav1.Condition{
+		Type:    hubv1alpha1.EdgeIngressConditionCertificateReady,
+		Status:  metav1.ConditionTrue,
+		Reason:  "CertificateReady",
+		Message: "Certificates are available",
+	}
+}
+
+func (w *Watcher) backendCondition(ctx context.Context, edgeIng *hubv1alpha1.EdgeIngress) metav1.Condition {
+	services := []string{edgeIng.Spec.Service.Name}
+	if len(edgeIng.Spec.Services) > 0 {
+		services = nil
+		for _, service := range edgeIng.Spec.Services {
+			services = append(services, service.Name)
+		}
+	}
+
+	for _, name := range services {
+		_, err := w.clientSet.CoreV1().Services(edgeIng.Namespace).Get(ctx, name, metav1.GetOptions{})
+		if kerror.IsNotFound(err) {
+			return metav1.Condition{
+				Type:    hubv1alpha1.EdgeIngressConditionBackendReady,
+				Status:  metav1.ConditionFalse,
+				Reason:  "ServiceNotFound",
+				Message: fmt.Sprintf("Service %s/%s does not exist", edgeIng.Namespace, name),
+			}
+		}
+		if err != nil {
+			return metav1.Condition{
+				Type:    hubv1alpha1.EdgeIngressConditionBackendReady,
+				Status:  metav1.ConditionUnknown,
+				Reason:  "BackendUnknown",
+				Message: fmt.Sprintf("Unable to get service %s/%s", edgeIng.Namespace, name),
+			}
+		}
+
+		endpoints, err := w.clientSet.CoreV1().Endpoints(edgeIng.Namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil && !kerror.IsNotFound(err) {
+			return metav1.Condition{
+				Type:    hubv1alpha1.EdgeIngressConditionBackendReady,
+				Status:  metav1.ConditionUnknown,
+				Reason:  "BackendUnknown",
+				Message: fmt.Sprintf("Unable to get endpoints of service %s/%s", edgeIng.Namespace, name),
+			}
+		}
+
+		var ready bool
+		if err == nil {
+			for _, subset := range endpoints.Subsets {
+				if len(subset.Addresses) > 0 {
+					ready = true
+					break
+				}
+			}
+		}
+
+		if !ready {
+			return metav1.Condition{
+				Type:    hubv1alpha1.EdgeIngressConditionBackendReady,
+				Status:  metav1.ConditionFalse,
+				Reason:  "NoReadyEndpoints",
+				Message: fmt.Sprintf("Service %s/%s has no ready endpoints", edgeIng.Namespace, name),
+			}
+		}
+	}
+
+	return metav1.Condition{
+		Type:    hubv1alpha1.EdgeIngressConditionBackendReady,
+		Status:  metav1.ConditionTrue,
+		Reason:  "EndpointsReady",
+		Message: "Services have ready endpoints",
+	}
+}
@@ -0,0 +1,236 @@
+/*
+Copyright (C) 2022 Traefik Labs
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package edgeingress
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	hubv1alpha1 "github.com/traefik/hub-agent-kubernetes/pkg/crd/api/hub/v1alpha1"
+	"github.com/traefik/hub-agent-kubernetes/pkg/tunnel"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubemock "k8s.io/client-go/kubernetes/fake"
+)
+
+type tunnelListerFunc func() ([]tunnel.Endpoint, error)
+
+func (f tunnelListerFunc) ListClusterTunnelEndpoints(_ context.Context) ([]tunnel.Endpoint, error) {
+	return f()
+}
+
+func TestWatcher_setConditions(t *testing.T) {
+	certificate := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: secretName, Namespace: "hub-agent"},
+		Data:       map[string][]byte{"tls.crt": []byte("cert")},
+	}
+	service := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "whoami", Namespace: "default"},
+	}
+	readyEndpoints := &corev1.Endpoints{
+		ObjectMeta: metav1.ObjectMeta{Name: "whoami", Namespace: "default"},
+		Subsets: []corev1.EndpointSubset{
+			{Addresses: []corev1.EndpointAddress{{IP: "10.0.0.1"}}},
+		},
+	}
+	notReadyEndpoints := &corev1.Endpoints{
+		ObjectMeta: metav1.ObjectMeta{Name: "whoami", Namespace: "default"},
+		Subsets: []corev1.EndpointSubset{
+			{NotReadyAddresses: []corev1.EndpointAddress{{IP: "10.0.0.1"}}},
+		},
+	}
+
+	tests := []struct {
+		desc       string
+		objects    []runtime.Object
+		tunnels    tunnelListerFunc
+		paused     bool
+		want       map[string]metav1.ConditionStatus
+		wantReason string
+		wantTunnel *hubv1alpha1.EdgeIngressTunnel
+	}{
+		{
+			desc:    "ready",
+			objects: []runtime.Object{certificate, service, readyEndpoints},
+			tunnels: func() ([]tunnel.Endpoint, error) {
+				return []tunnel.Endpoint{{TunnelID: "id", BrokerEndpoint: "wss://broker"}}, nil
+			},
+			want: map[string]metav1.ConditionStatus{
+				hubv1alpha1.EdgeIngressConditionReady:            metav1.ConditionTrue,
+				hubv1alpha1.EdgeIngressConditionTunnelConnected:  metav1.ConditionTrue,
+				hubv1alpha1.EdgeIngressConditionCertificateReady: metav1.ConditionTrue,
+				hubv1alpha1.EdgeIngressConditionBackendReady:     metav1.ConditionTrue,
+			},
+			wantReason: "Ready",
+		},
+		{
+			desc:    "ready with tunnel statistics",
+			objects: []runtime.Object{certificate, service, readyEndpoints},
+			tunnels: func() ([]tunnel.Endpoint, error) {
+				return []tunnel.Endpoint{
+					{
+						TunnelID:       "id-1",
+						BrokerEndpoint: "wss://broker",
+						Status: &tunnel.Status{
+							Transport: tunnel.TransportDirect,
+							Stats: &tunnel.Stats{
+								BytesReceived:     100,
+								BytesSent:         200,
+								ActiveStreams:     1,
+								RTTMilliseconds:   20,
+								Reconnections:     1,
+								HandshakeFailures: 2,
+							},
+						},
+					},
+					{
+						TunnelID:       "id-2",
+						BrokerEndpoint: "wss://broker",
+						Status: &tunnel.Status{
+							Transport: tunnel.TransportFallback,
+							Stats: &tunnel.Stats{
+								BytesReceived:   50,
+								BytesSent:       50,
+								ActiveStreams:   2,
+								RTTMilliseconds: 80,
+							},
+						},
+					},
+					{TunnelID: "id-3", BrokerEndpoint: "wss://broker"},
+				}, nil
+			},
+			want: map[string]metav1.ConditionStatus{
+				hubv1alpha1.EdgeIngressConditionReady:            metav1.ConditionTrue,
+				hubv1alpha1.EdgeIngressConditionTunnelConnected:  metav1.ConditionTrue,
+				hubv1alpha1.EdgeIngressConditionCertificateReady: metav1.ConditionTrue,
+				hubv1alpha1.EdgeIngressConditionBackendReady:     metav1.ConditionTrue,
+			},
+			wantReason: "Ready",
+			wantTunnel: &hubv1alpha1.EdgeIngressTunnel{
+				Connected:         2,
+				BytesReceived:     150,
+				BytesSent:         250,
+				ActiveStreams:     3,
+				RTT:               metav1.Duration{Duration: 80 * time.Millisecond},
+				Reconnections:     1,
+				HandshakeFailures: 2,
+			},
+		},
+		{
+			desc:    "no tunnel",
+			objects: []runtime.Object{certificate, service, readyEndpoints},
+			tunnels: func() ([]tunnel.Endpoint, error) {
+				return nil, nil
+			},
+			want: map[string]metav1.ConditionStatus{
+				hubv1alpha1.EdgeIngressConditionReady:            metav1.ConditionFalse,
+				hubv1alpha1.EdgeIngressConditionTunnelConnected:  metav1.ConditionFalse,
+				hubv1alpha1.EdgeIngressConditionCertificateReady: metav1.ConditionTrue,
+				hubv1alpha1.EdgeIngressConditionBackendReady:     metav1.ConditionTrue,
+			},
+			wantReason: "NoTunnel",
+		},
+		{
+			desc:    "unknown tunnel state and missing certificate",
+			objects: []runtime.Object{service, readyEndpoints},
+			tunnels: func() ([]tunnel.Endpoint, error) {
+				return nil, errors.New("boom")
+			},
+			want: map[string]metav1.ConditionStatus{
+				hubv1alpha1.EdgeIngressConditionReady:            metav1.ConditionUnknown,
+				hubv1alpha1.EdgeIngressConditionTunnelConnected:  metav1.ConditionUnknown,
+				hubv1alpha1.EdgeIngressConditionCertificateReady: metav1.ConditionFalse,
+				hubv1alpha1.EdgeIngressConditionBackendReady:     metav1.ConditionTrue,
+			},
+			wantReason: "TunnelUnknown",
+		},
+		{
+			desc:    "missing service",
+			objects: []runtime.Object{certificate},
+			want: map[string]metav1.ConditionStatus{
+				hubv1alpha1.EdgeIngressConditionReady:            metav1.ConditionFalse,
+				hubv1alpha1.EdgeIngressConditionCertificateReady: metav1.ConditionTrue,
+				hubv1alpha1.EdgeIngressConditionBackendReady:     metav1.ConditionFalse,
+			},
+			wantReason: "ServiceNotFound",
+		},
+		{
+			desc:    "no ready endpoints",
+			objects: []runtime.Object{certificate, service, notReadyEndpoints},
+			want: map[string]metav1.ConditionStatus{
+				hubv1alpha1.EdgeIngressConditionReady:            metav1.ConditionFalse,
+				hubv1alpha1.EdgeIngressConditionCertificateReady: metav1.ConditionTrue,
+				hubv1alpha1.EdgeIngressConditionBackendReady:     metav1.ConditionFalse,
+			},
+			wantReason: "NoReadyEndpoints",
+		},
+		{
+			desc:    "paused",
+			objects: []runtime.Object{certificate, service, readyEndpoints},
+			paused:  true,
+			want: map[string]metav1.ConditionStatus{
+				hubv1alpha1.EdgeIngressConditionReady:            metav1.ConditionFalse,
+				hubv1alpha1.EdgeIngressConditionCertificateReady: metav1.ConditionTrue,
+				hubv1alpha1.EdgeIngressConditionBackendReady:     metav1.ConditionTrue,
+				hubv1alpha1.EdgeIngressConditionPaused:           metav1.ConditionTrue,
+			},
+			wantReason: "Paused",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := context.Background()
+
+			w := &Watcher{
+				config:    WatcherConfig{AgentNamespace: "hub-agent"},
+				clientSet: kubemock.NewSimpleClientset(test.objects...),
+			}
+			if test.tunnels != nil {
+				w.config.Tunnels = test.tunnels
+			}
+			w.updateTunnelCondition(ctx)
+
+			edgeIng := &hubv1alpha1.EdgeIngress{
+				ObjectMeta: metav1.ObjectMeta{Name: "edge-ingress", Namespace: "default"},
+				Spec: hubv1alpha1.EdgeIngressSpec{
+					Service: hubv1alpha1.EdgeIngressService{Name: "whoami", Port: 80},
+					Paused:  test.paused,
+				},
+			}
+
+			w.setConditions(ctx, edgeIng)
+
+			assertConditions(t, test.want, edgeIng.Status.Conditions)
+			assert.Equal(t, test.wantTunnel, edgeIng.Status.Tunnel)
+
+			for _, condition := range edgeIng.Status.Conditions {
+				if condition.Type == hubv1alpha1.EdgeIngressConditionReady {
+					assert.Equal(t, test.wantReason, condition.Reason)
+				}
+			}
+		})
+	}
+}
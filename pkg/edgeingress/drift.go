@@ -0,0 +1,263 @@
+/*
+Copyright (C) 2022 Traefik Labs
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package edgeingress
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/rs/zerolog/log"
+	hubv1alpha1 "github.com/traefik/hub-agent-kubernetes/pkg/crd/api/hub/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	netv1 "k8s.io/api/networking/v1"
+	kerror "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ktypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/cache"
+)
+
+// drifts tracks the generated resources which have been modified or deleted outside of the agent, so the watcher can
+// revert them without waiting for the next full sync.
+type drifts struct {
+	mu           sync.Mutex
+	edgeIngs     map[ktypes.NamespacedName]struct{}
+	certificate  bool
+	notification chan struct{}
+}
+
+func newDrifts() *drifts {
+	return &drifts{
+		edgeIngs:     make(map[ktypes.NamespacedName]struct{}),
+		notification: make(chan struct{}, 1),
+	}
+}
+
+func (d *drifts) addEdgeIngress(name ktypes.NamespacedName) {
+	d.mu.Lock()
+	d.edgeIngs[name] = struct{}{}
+	d.mu.Unlock()
+
+	d.notify()
+}
+
+func (d *drifts) addCertificate() {
+	d.mu.Lock()
+	d.certificate = true
+	d.mu.Unlock()
+
+	d.notify()
+}
+
+func (d *drifts) notify() {
+	select {
+	case d.notification <- struct{}{}:
+	default:
+	}
+}
+
+// pop returns the drifted resources and forgets about them.
+func (d *drifts) pop() (edgeIngs []ktypes.NamespacedName, certificate bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for name := range d.edgeIngs {
+		edgeIngs = append(edgeIngs, name)
+	}
+	certificate = d.certificate
+
+	d.edgeIngs = make(map[ktypes.NamespacedName]struct{})
+	d.certificate = false
+
+	return edgeIngs, certificate
+}
+
+// OnAdd implements Kubernetes cache.ResourceEventHandler so it can be used as an informer event handler.
+// Generated resources are created by the watcher itself, their creation is therefore never a drift.
+func (w *Watcher) OnAdd(_ interface{}) {}
+
+// OnUpdate implements Kubernetes cache.ResourceEventHandler so it can be used as an informer event handler.
+func (w *Watcher) OnUpdate(oldObj, newObj interface{}) {
+	if !contentChanged(oldObj, newObj) {
+		return
+	}
+
+	w.trackDrift(newObj)
+}
+
+// OnDelete implements Kubernetes cache.ResourceEventHandler so it can be used as an informer event handler.
+func (w *Watcher) OnDelete(obj interface{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+
+	w.trackDrift(obj)
+}
+
+// trackDrift records the EdgeIngress, or the wildcard certificate, from which the given resource has been generated.
+func (w *Watcher) trackDrift(obj interface{}) {
+	if w.drifts == nil {
+		return
+	}
+
+	switch v := obj.(type) {
+	case *netv1.Ingress:
+		if v.Name == catchAllName && v.Namespace == w.config.AgentNamespace {
+			w.drifts.addCertificate()
+			return
+		}
+
+		// Fallback ingresses are managed by syncFallback, regardless of the generated route.
+		if _, ok := v.Labels[labelFallback]; ok {
+			return
+		}
+
+		for _, ref := range v.OwnerReferences {
+			if ref.Kind == "EdgeIngress" {
+				w.drifts.addEdgeIngress(ktypes.NamespacedName{Namespace: v.Namespace, Name: ref.Name})
+				return
+			}
+		}
+	case *corev1.Secret:
+		if v.Name == secretName && v.Namespace == w.config.AgentNamespace {
+			w.drifts.addCertificate()
+			return
+		}
+
+		if name := strings.TrimPrefix(v.Name, secretCustomDomainsName+"-"); name != v.Name {
+			w.drifts.addEdgeIngress(ktypes.NamespacedName{Namespace: v.Namespace, Name: name})
+		}
+	default:
+		log.Error().
+			Str("component", "edge_ingress_watcher").
+			Str("type", fmt.Sprintf("%T", obj)).
+			Msg("Received unsupported object")
+	}
+}
+
+// contentChanged reports whether the content of a generated resource has changed. Periodic resyncs and status
+// updates, for instance made by the ingress controller, are not drifts.
+func contentChanged(oldObj, newObj interface{}) bool {
+	switch newV := newObj.(type) {
+	case *netv1.Ingress:
+		oldV, ok := oldObj.(*netv1.Ingress)
+		return !ok || !metadataEqual(oldV.ObjectMeta, newV.ObjectMeta) || !reflect.DeepEqual(oldV.Spec, newV.Spec)
+	case *corev1.Secret:
+		oldV, ok := oldObj.(*corev1.Secret)
+		return !ok || !metadataEqual(oldV.ObjectMeta, newV.ObjectMeta) || oldV.Type != newV.Type || !reflect.DeepEqual(oldV.Data, newV.Data)
+	default:
+		return true
+	}
+}
+
+func metadataEqual(oldMeta, newMeta metav1.ObjectMeta) bool {
+	return reflect.DeepEqual(oldMeta.Labels, newMeta.Labels) &&
+		reflect.DeepEqual(oldMeta.Annotations, newMeta.Annotations) &&
+		reflect.DeepEqual(oldMeta.OwnerReferences, newMeta.OwnerReferences)
+}
+
+// revertDrifts reconciles the resources which have drifted since the last call.
+func (w *Watcher) revertDrifts(ctx context.Context) {
+	edgeIngs, certificate := w.drifts.pop()
+
+	if certificate && w.owns(w.config.AgentNamespace) {
+		if err := w.syncCertificate(ctx); err != nil {
+			log.Error().Err(err).Msg("Unable to revert changes made to the wildcard certificate")
+		}
+	}
+
+	for _, name := range edgeIngs {
+		if !w.owns(name.Namespace) {
+			continue
+		}
+
+		if err := w.revertEdgeIngressDrift(ctx, name); err != nil {
+			log.Error().Err(err).
+				Str("name", name.Name).
+				Str("namespace", name.Namespace).
+				Msg("Unable to revert changes made to the resources generated for the EdgeIngress")
+		}
+	}
+}
+
+func (w *Watcher) revertEdgeIngressDrift(ctx context.Context, name ktypes.NamespacedName) error {
+	edgeIng, err := w.hubClientSet.HubV1alpha1().EdgeIngresses(name.Namespace).Get(ctx, name.Name, metav1.GetOptions{})
+	if kerror.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("get edge ingress: %w", err)
+	}
+
+	// Resources of EdgeIngresses being deleted or not synced yet are handled by the regular sync.
+	if edgeIng.DeletionTimestamp != nil || edgeIng.Status.Connection != hubv1alpha1.EdgeIngressConnectionUp {
+		return nil
+	}
+
+	before, err := w.generatedVersions(ctx, edgeIng)
+	if err != nil {
+		return fmt.Errorf("get generated resources: %w", err)
+	}
+
+	if err = w.syncChildAndUpdateConnectionStatus(ctx, edgeIng, edgeIng.Status.CustomDomains); err != nil {
+		return err
+	}
+
+	after, err := w.generatedVersions(ctx, edgeIng)
+	if err != nil {
+		return fmt.Errorf("get generated resources: %w", err)
+	}
+
+	for resource, version := range after {
+		beforeVersion, ok := before[resource]
+		switch {
+		case !ok:
+			w.event(edgeIng, corev1.EventTypeWarning, reasonDriftReverted, resource+" was deleted outside of the agent and has been recreated")
+		case beforeVersion != version:
+			w.event(edgeIng, corev1.EventTypeWarning, reasonDriftReverted, resource+" was modified outside of the agent and has been reverted")
+		}
+	}
+
+	return nil
+}
+
+// generatedVersions returns the resource versions of the Ingress and Secret generated for the given EdgeIngress,
+// indexed by a description of the resource.
+func (w *Watcher) generatedVersions(ctx context.Context, edgeIng *hubv1alpha1.EdgeIngress) (map[string]string, error) {
+	versions := make(map[string]string)
+
+	ing, err := w.clientSet.NetworkingV1().Ingresses(edgeIng.Namespace).Get(ctx, edgeIng.Name, metav1.GetOptions{})
+	if err != nil && !kerror.IsNotFound(err) {
+		return nil, fmt.Errorf("get ingress: %w", err)
+	}
+	if err == nil {
+		versions["Ingress "+ing.Name] = ing.ResourceVersion
+	}
+
+	secret, err := w.clientSet.CoreV1().Secrets(edgeIng.Namespace).Get(ctx, secretCustomDomainsName+"-"+edgeIng.Name, metav1.GetOptions{})
+	if err != nil && !kerror.IsNotFound(err) {
+		return nil, fmt.Errorf("get secret: %w", err)
+	}
+	if err == nil {
+		versions["Secret "+secret.Name] = secret.ResourceVersion
+	}
+
+	return versions, nil
+}
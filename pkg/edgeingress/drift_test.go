@@ -0,0 +1,166 @@
+/*
+Copyright (C) 2022 Traefik Labs
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package edgeingress
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	hubv1alpha1 "github.com/traefik/hub-agent-kubernetes/pkg/crd/api/hub/v1alpha1"
+	hubkubemock "github.com/traefik/hub-agent-kubernetes/pkg/crd/generated/client/hub/clientset/versioned/fake"
+	traefikkubemock "github.com/traefik/hub-agent-kubernetes/pkg/crd/generated/client/traefik/clientset/versioned/fake"
+	corev1 "k8s.io/api/core/v1"
+	netv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ktypes "k8s.io/apimachinery/pkg/types"
+	kubemock "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestWatcher_trackDrift(t *testing.T) {
+	ownedIngress := &netv1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "whoami",
+			Namespace:       "default",
+			OwnerReferences: []metav1.OwnerReference{{Kind: "EdgeIngress", Name: "whoami"}},
+		},
+	}
+	editedIngress := ownedIngress.DeepCopy()
+	editedIngress.Annotations = map[string]string{"foo": "bar"}
+	ingressWithStatus := ownedIngress.DeepCopy()
+	ingressWithStatus.Status.LoadBalancer.Ingress = []corev1.LoadBalancerIngress{{IP: "1.2.3.4"}}
+
+	customDomainsSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "hub-certificate-custom-domains-whoami", Namespace: "default"},
+		Data:       map[string][]byte{"tls.crt": []byte("crt")},
+	}
+	editedSecret := customDomainsSecret.DeepCopy()
+	editedSecret.Data["tls.crt"] = []byte("other")
+
+	wildcardSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "hub-certificate", Namespace: "hub-agent"},
+	}
+
+	tests := []struct {
+		desc            string
+		event           func(w *Watcher)
+		wantEdgeIngs    []ktypes.NamespacedName
+		wantCertificate bool
+	}{
+		{
+			desc:         "generated ingress edited",
+			event:        func(w *Watcher) { w.OnUpdate(ownedIngress, editedIngress) },
+			wantEdgeIngs: []ktypes.NamespacedName{{Namespace: "default", Name: "whoami"}},
+		},
+		{
+			desc:  "generated ingress resynced",
+			event: func(w *Watcher) { w.OnUpdate(ownedIngress, ownedIngress) },
+		},
+		{
+			desc:  "generated ingress status updated",
+			event: func(w *Watcher) { w.OnUpdate(ownedIngress, ingressWithStatus) },
+		},
+		{
+			desc: "generated ingress deleted",
+			event: func(w *Watcher) {
+				w.OnDelete(cache.DeletedFinalStateUnknown{Key: "default/whoami", Obj: ownedIngress})
+			},
+			wantEdgeIngs: []ktypes.NamespacedName{{Namespace: "default", Name: "whoami"}},
+		},
+		{
+			desc:         "custom domains secret edited",
+			event:        func(w *Watcher) { w.OnUpdate(customDomainsSecret, editedSecret) },
+			wantEdgeIngs: []ktypes.NamespacedName{{Namespace: "default", Name: "whoami"}},
+		},
+		{
+			desc:            "wildcard certificate secret deleted",
+			event:           func(w *Watcher) { w.OnDelete(wildcardSecret) },
+			wantCertificate: true,
+		},
+		{
+			desc: "wildcard certificate secret in another namespace deleted",
+			event: func(w *Watcher) {
+				w.OnDelete(&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "hub-certificate", Namespace: "default"}})
+			},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			w := &Watcher{
+				config: WatcherConfig{AgentNamespace: "hub-agent"},
+				drifts: newDrifts(),
+			}
+
+			test.event(w)
+
+			edgeIngs, certificate := w.drifts.pop()
+			assert.Equal(t, test.wantEdgeIngs, edgeIngs)
+			assert.Equal(t, test.wantCertificate, certificate)
+		})
+	}
+}
+
+func TestWatcher_revertEdgeIngressDrift(t *testing.T) {
+	ctx := context.Background()
+
+	edgeIng := &hubv1alpha1.EdgeIngress{
+		ObjectMeta: metav1.ObjectMeta{Name: "whoami", Namespace: "default", UID: "uid"},
+		Spec: hubv1alpha1.EdgeIngressSpec{
+			Service: hubv1alpha1.EdgeIngressService{Name: "whoami", Port: 80},
+		},
+		Status: hubv1alpha1.EdgeIngressStatus{
+			Domain:     "whoami.hub.example.com",
+			Connection: hubv1alpha1.EdgeIngressConnectionUp,
+		},
+	}
+
+	clientSet := kubemock.NewSimpleClientset()
+	recorder := record.NewFakeRecorder(10)
+
+	w := &Watcher{
+		client:           newPlatformClientMock(t),
+		hubClientSet:     hubkubemock.NewSimpleClientset(edgeIng),
+		clientSet:        clientSet,
+		traefikClientSet: traefikkubemock.NewSimpleClientset().TraefikV1alpha1(),
+		config:           WatcherConfig{Recorder: recorder},
+	}
+
+	err := w.revertEdgeIngressDrift(ctx, ktypes.NamespacedName{Namespace: "default", Name: "whoami"})
+	require.NoError(t, err)
+
+	_, err = clientSet.NetworkingV1().Ingresses("default").Get(ctx, "whoami", metav1.GetOptions{})
+	require.NoError(t, err)
+
+	close(recorder.Events)
+
+	var gotEvents []string
+	for event := range recorder.Events {
+		gotEvents = append(gotEvents, event)
+	}
+	assert.Equal(t, []string{
+		"Normal IngressGenerated Ingress whoami generated",
+		"Warning DriftReverted Ingress whoami was deleted outside of the agent and has been recreated",
+	}, gotEvents)
+}
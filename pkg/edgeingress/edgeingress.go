@@ -41,9 +41,29 @@ type EdgeIngress struct {
 	Domain        string         `json:"domain"`
 	CustomDomains []CustomDomain `json:"customDomains"`
 
-	Version string  `json:"version"`
-	Service Service `json:"service"`
-	ACP     *ACP    `json:"acp,omitempty"`
+	Version  string            `json:"version"`
+	Mode     string            `json:"mode,omitempty"`
+	Service  Service           `json:"service"`
+	Services []WeightedService `json:"services,omitempty"`
+	Sticky   *Sticky           `json:"sticky,omitempty"`
+	ACP      *ACP              `json:"acp,omitempty"`
+	ACPs     []ACP             `json:"acps,omitempty"`
+
+	Middlewares *Middlewares `json:"middlewares,omitempty"`
+
+	IngressAnnotations map[string]string `json:"ingressAnnotations,omitempty"`
+	IngressLabels      map[string]string `json:"ingressLabels,omitempty"`
+
+	EntryPoints []string `json:"entryPoints,omitempty"`
+	TLS         *TLS     `json:"tls,omitempty"`
+
+	AllowedSourceIPs []string `json:"allowedSourceIPs,omitempty"`
+	Headers          *Headers `json:"headers,omitempty"`
+
+	Paused      bool         `json:"paused,omitempty"`
+	Maintenance *Maintenance `json:"maintenance,omitempty"`
+
+	Bandwidth *Bandwidth `json:"bandwidth,omitempty"`
 
 	CreatedAt time.Time `json:"createdAt"`
 	UpdatedAt time.Time `json:"updatedAt"`
@@ -57,8 +77,22 @@ type CustomDomain struct {
 
 // Service is a service used by the edge ingress.
 type Service struct {
-	Name string `json:"name"`
-	Port int    `json:"port"`
+	Name     string `json:"name"`
+	Port     int    `json:"port"`
+	Protocol string `json:"protocol,omitempty"`
+}
+
+// WeightedService is a service receiving a share of the traffic of the edge ingress.
+type WeightedService struct {
+	Name   string `json:"name"`
+	Port   int    `json:"port"`
+	Weight int    `json:"weight"`
+}
+
+// Sticky is the sticky sessions configuration of the edge ingress.
+type Sticky struct {
+	CookieName string `json:"cookieName,omitempty"`
+	TTL        int    `json:"ttl,omitempty"`
 }
 
 // ACP is an ACP used by the edge ingress.
@@ -66,20 +100,57 @@ type ACP struct {
 	Name string `json:"name"`
 }
 
+// Middlewares are the middlewares applied by the edge ingress.
+type Middlewares struct {
+	RateLimit *RateLimit   `json:"rateLimit,omitempty"`
+	Compress  *Compression `json:"compress,omitempty"`
+}
+
+// Compression is the compression of responses applied by the edge ingress.
+type Compression struct {
+	ExcludedContentTypes []string `json:"excludedContentTypes,omitempty"`
+	MinResponseBodyBytes int      `json:"minResponseBodyBytes,omitempty"`
+}
+
+// RateLimit is the rate limiting applied by the edge ingress.
+type RateLimit struct {
+	Average int64 `json:"average"`
+	Burst   int64 `json:"burst,omitempty"`
+}
+
+// TLS is the TLS configuration of the edge ingress.
+type TLS struct {
+	Options      string `json:"options,omitempty"`
+	RedirectHTTP bool   `json:"redirectHTTP,omitempty"`
+}
+
+// Headers are the headers manipulated by the edge ingress.
+type Headers struct {
+	Request  *HeaderRules `json:"request,omitempty"`
+	Response *HeaderRules `json:"response,omitempty"`
+}
+
+// HeaderRules are the headers to set and remove.
+type HeaderRules struct {
+	Set    map[string]string `json:"set,omitempty"`
+	Remove []string          `json:"remove,omitempty"`
+}
+
+// Bandwidth is the bandwidth caps of the edge ingress on the tunnel.
+type Bandwidth struct {
+	EgressBytesPerSecond int64 `json:"egressBytesPerSecond"`
+	BurstBytes           int64 `json:"burstBytes,omitempty"`
+}
+
+// Maintenance is how requests are handled while the edge ingress is paused.
+type Maintenance struct {
+	Message     string `json:"message,omitempty"`
+	RemoveRoute bool   `json:"removeRoute,omitempty"`
+}
+
 // Resource builds the v1alpha1 EdgeIngress resource.
 func (e *EdgeIngress) Resource() (*hubv1alpha1.EdgeIngress, error) {
-	spec := hubv1alpha1.EdgeIngressSpec{
-		Service: hubv1alpha1.EdgeIngressService{
-			Name: e.Service.Name,
-			Port: e.Service.Port,
-		},
-	}
-
-	if e.ACP != nil {
-		spec.ACP = &hubv1alpha1.EdgeIngressACP{
-			Name: e.ACP.Name,
-		}
-	}
+	spec := buildResourceSpec(e)
 
 	specHash, err := spec.Hash()
 	if err != nil {
@@ -93,12 +164,33 @@ func (e *EdgeIngress) Resource() (*hubv1alpha1.EdgeIngress, error) {
 		},
 		Spec: spec,
 		Status: hubv1alpha1.EdgeIngressStatus{
-			Version:    e.Version,
-			SyncedAt:   metav1.Now(),
-			Domain:     e.Domain,
-			URL:        "https://" + e.Domain,
-			Connection: hubv1alpha1.EdgeIngressConnectionDown,
-			SpecHash:   specHash,
+			Version:       e.Version,
+			SyncedAt:      metav1.Now(),
+			Domain:        e.Domain,
+			URL:           e.url(),
+			CustomDomains: e.VerifiedCustomDomains(),
+			Connection:    hubv1alpha1.EdgeIngressConnectionDown,
+			SpecHash:      specHash,
 		},
 	}, nil
 }
+
+func (e *EdgeIngress) url() string {
+	if e.Mode == string(hubv1alpha1.EdgeIngressModeTCP) {
+		return "tls://" + e.Domain + ":443"
+	}
+
+	return "https://" + e.Domain
+}
+
+// VerifiedCustomDomains returns the names of the custom domains verified on the platform.
+func (e *EdgeIngress) VerifiedCustomDomains() []string {
+	var domains []string
+	for _, customDomain := range e.CustomDomains {
+		if customDomain.Verified {
+			domains = append(domains, customDomain.Name)
+		}
+	}
+
+	return domains
+}
@@ -0,0 +1,74 @@
+/*
+Copyright (C) 2022 Traefik Labs
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package edgeingress
+
+import (
+	"strings"
+
+	hubv1alpha1 "github.com/traefik/hub-agent-kubernetes/pkg/crd/api/hub/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Reasons of the events recorded on EdgeIngresses.
+const (
+	reasonSynced             = "Synced"
+	reasonCertificateFetched = "CertificateFetched"
+	reasonIngressGenerated   = "IngressGenerated"
+	reasonACPAttached        = "ACPAttached"
+	reasonSyncFailed         = "SyncFailed"
+	reasonFinalizeFailed     = "FinalizeFailed"
+	reasonDriftReverted      = "DriftReverted"
+	reasonPaused             = "Paused"
+	reasonFallbackExposed    = "FallbackExposed"
+)
+
+// event records an event on the given EdgeIngress, if the watcher has been configured with a recorder.
+func (w *Watcher) event(edgeIng *hubv1alpha1.EdgeIngress, eventType, reason, msg string) {
+	if w.config.Recorder == nil {
+		return
+	}
+
+	// EdgeIngresses are not registered in the client-go scheme, the reference must therefore be built by hand.
+	ref := &corev1.ObjectReference{
+		APIVersion:      "hub.traefik.io/v1alpha1",
+		Kind:            "EdgeIngress",
+		Namespace:       edgeIng.Namespace,
+		Name:            edgeIng.Name,
+		UID:             edgeIng.UID,
+		ResourceVersion: edgeIng.ResourceVersion,
+	}
+
+	w.config.Recorder.Event(ref, eventType, reason, msg)
+}
+
+// acpEvent records which ACPs have been attached to the route generated for the given EdgeIngress.
+func (w *Watcher) acpEvent(edgeIng *hubv1alpha1.EdgeIngress) {
+	var names []string
+	if edgeIng.Spec.ACP != nil {
+		names = append(names, edgeIng.Spec.ACP.Name)
+	}
+	for _, acp := range edgeIng.Spec.ACPs {
+		names = append(names, acp.Name)
+	}
+
+	if len(names) == 0 {
+		return
+	}
+
+	w.event(edgeIng, corev1.EventTypeNormal, reasonACPAttached, "ACP "+strings.Join(names, ", ")+" attached")
+}
@@ -0,0 +1,120 @@
+/*
+Copyright (C) 2022 Traefik Labs
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package edgeingress
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	hubv1alpha1 "github.com/traefik/hub-agent-kubernetes/pkg/crd/api/hub/v1alpha1"
+	hubkubemock "github.com/traefik/hub-agent-kubernetes/pkg/crd/generated/client/hub/clientset/versioned/fake"
+	traefikkubemock "github.com/traefik/hub-agent-kubernetes/pkg/crd/generated/client/traefik/clientset/versioned/fake"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubemock "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestWatcher_syncChildAndUpdateConnectionStatus_events(t *testing.T) {
+	tests := []struct {
+		desc          string
+		customDomains []string
+		certErr       error
+		wantErr       bool
+		wantEvents    []string
+	}{
+		{
+			desc: "ingress generated with ACP",
+			wantEvents: []string{
+				"Normal IngressGenerated Ingress whoami generated",
+				"Normal ACPAttached ACP my-acp attached",
+			},
+		},
+		{
+			desc:          "certificate fetched",
+			customDomains: []string{"hello.example.com"},
+			wantEvents: []string{
+				"Normal CertificateFetched Certificate fetched for hello.example.com",
+				"Normal IngressGenerated Ingress whoami generated",
+				"Normal ACPAttached ACP my-acp attached",
+			},
+		},
+		{
+			desc:          "certificate fetching failure",
+			customDomains: []string{"hello.example.com"},
+			certErr:       errors.New("boom"),
+			wantErr:       true,
+			wantEvents: []string{
+				`Warning SyncFailed get certificate by domains "hello.example.com": boom`,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := context.Background()
+
+			edgeIng := &hubv1alpha1.EdgeIngress{
+				ObjectMeta: metav1.ObjectMeta{Name: "whoami", Namespace: "default", UID: "uid"},
+				Spec: hubv1alpha1.EdgeIngressSpec{
+					Service: hubv1alpha1.EdgeIngressService{Name: "whoami", Port: 80},
+					ACP:     &hubv1alpha1.EdgeIngressACP{Name: "my-acp"},
+				},
+				Status: hubv1alpha1.EdgeIngressStatus{Domain: "whoami.hub.example.com"},
+			}
+
+			client := newPlatformClientMock(t)
+			if len(test.customDomains) > 0 {
+				client.OnGetCertificateByDomains(test.customDomains).TypedReturns(Certificate{}, test.certErr).Once()
+			}
+
+			recorder := record.NewFakeRecorder(10)
+
+			w := &Watcher{
+				client:           client,
+				hubClientSet:     hubkubemock.NewSimpleClientset(edgeIng),
+				clientSet:        kubemock.NewSimpleClientset(),
+				traefikClientSet: traefikkubemock.NewSimpleClientset().TraefikV1alpha1(),
+				config: WatcherConfig{
+					FwdAuthMiddlewares: fwdAuthMiddlewaresFunc(func(polName string) string { return "zz-" + polName }),
+					Recorder:           recorder,
+				},
+			}
+
+			err := w.syncChildAndUpdateConnectionStatus(ctx, edgeIng, test.customDomains)
+			if test.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+
+			close(recorder.Events)
+
+			var gotEvents []string
+			for event := range recorder.Events {
+				gotEvents = append(gotEvents, event)
+			}
+			assert.Equal(t, test.wantEvents, gotEvents)
+		})
+	}
+}
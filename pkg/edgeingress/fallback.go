@@ -0,0 +1,215 @@
+/*
+Copyright (C) 2022 Traefik Labs
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package edgeingress
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	hubv1alpha1 "github.com/traefik/hub-agent-kubernetes/pkg/crd/api/hub/v1alpha1"
+	traefikv1alpha1 "github.com/traefik/hub-agent-kubernetes/pkg/crd/api/traefik/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	netv1 "k8s.io/api/networking/v1"
+	kerror "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// labelFallback is set on the Ingresses exposing EdgeIngresses locally while the tunnel is unavailable.
+const labelFallback = "hub.traefik.io/fallback"
+
+// FallbackConfig configures the local exposure of EdgeIngresses while the platform or the tunnel is unavailable.
+type FallbackConfig struct {
+	// Domain is the local domain EdgeIngresses are exposed on, as <name>-<namespace>.<domain>.
+	// The fallback exposure is disabled if empty.
+	Domain string
+	// EntryPoint is the Traefik entry point the fallback routes listen on, typically exposed by a LoadBalancer.
+	EntryPoint string
+	// Threshold is how long the platform or the tunnel must be unavailable before exposing EdgeIngresses locally.
+	Threshold time.Duration
+}
+
+// trackAvailability records whether the platform and the tunnel are available, to know for how long they have been
+// unavailable.
+func (w *Watcher) trackAvailability(available bool) {
+	if w.config.Fallback.Domain == "" {
+		return
+	}
+
+	switch {
+	case available:
+		w.unavailableSince = time.Time{}
+	case w.unavailableSince.IsZero():
+		w.unavailableSince = w.now()
+	}
+}
+
+// syncFallback exposes the EdgeIngresses on the fallback domain once the platform or the tunnel has been unavailable
+// for longer than the configured threshold, and removes this exposure as soon as they are available again.
+func (w *Watcher) syncFallback(ctx context.Context) {
+	if w.config.Fallback.Domain == "" {
+		return
+	}
+
+	active := !w.unavailableSince.IsZero() && w.now().Sub(w.unavailableSince) >= w.config.Fallback.Threshold
+	if active != w.fallbackActive {
+		if active {
+			log.Warn().
+				Time("unavailable_since", w.unavailableSince).
+				Str("domain", w.config.Fallback.Domain).
+				Msg("Platform or tunnel unavailable, exposing EdgeIngresses on the fallback domain")
+		} else {
+			log.Info().Msg("Platform and tunnel available again, removing the fallback exposure of EdgeIngresses")
+		}
+	}
+	w.fallbackActive = active
+
+	if !active {
+		if err := w.deleteFallbackIngresses(ctx); err != nil {
+			log.Error().Err(err).Msg("Unable to delete fallback ingresses")
+		}
+		return
+	}
+
+	edgeIngs, err := w.hubInformer.Hub().V1alpha1().EdgeIngresses().Lister().List(labels.Everything())
+	if err != nil {
+		log.Error().Err(err).Msg("Unable to obtain EdgeIngresses")
+		return
+	}
+
+	for _, edgeIng := range edgeIngs {
+		if !w.owns(edgeIng.Namespace) {
+			continue
+		}
+
+		var err error
+		// Raw TCP services can't be routed on a domain without the TLS passthrough set up for the tunnel.
+		if edgeIng.DeletionTimestamp != nil || edgeIng.Spec.Paused || edgeIng.Spec.Mode == hubv1alpha1.EdgeIngressModeTCP {
+			err = w.deleteFallbackIngress(ctx, edgeIng)
+		} else {
+			err = w.upsertFallbackIngress(ctx, edgeIng)
+		}
+
+		if err != nil {
+			log.Error().Err(err).
+				Str("name", edgeIng.Name).
+				Str("namespace", edgeIng.Namespace).
+				Msg("Unable to sync fallback ingress")
+		}
+	}
+}
+
+func (w *Watcher) upsertFallbackIngress(ctx context.Context, edgeIng *hubv1alpha1.EdgeIngress) error {
+	middlewares, err := w.setupMiddlewares(ctx, edgeIng)
+	if err != nil {
+		return fmt.Errorf("setup middlewares: %w", err)
+	}
+
+	ing, err := w.clientSet.NetworkingV1().Ingresses(edgeIng.Namespace).Get(ctx, fallbackIngressName(edgeIng), metav1.GetOptions{})
+	if err != nil && !kerror.IsNotFound(err) {
+		return fmt.Errorf("get fallback ingress: %w", err)
+	}
+
+	if kerror.IsNotFound(err) {
+		ing = w.buildFallbackIngress(edgeIng, &netv1.Ingress{}, middlewares)
+		if _, err = w.clientSet.NetworkingV1().Ingresses(edgeIng.Namespace).Create(ctx, ing, metav1.CreateOptions{}); err != nil {
+			return fmt.Errorf("create fallback ingress: %w", err)
+		}
+
+		w.event(edgeIng, corev1.EventTypeWarning, reasonFallbackExposed, fmt.Sprintf("Platform or tunnel unavailable, exposed at https://%s until they are back", ing.Spec.Rules[0].Host))
+		return nil
+	}
+
+	ing = w.buildFallbackIngress(edgeIng, ing, middlewares)
+	if _, err = w.clientSet.NetworkingV1().Ingresses(edgeIng.Namespace).Update(ctx, ing, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("update fallback ingress: %w", err)
+	}
+
+	return nil
+}
+
+// buildFallbackIngress builds the Ingress exposing the given EdgeIngress on the fallback domain. It applies the same
+// middlewares as the route exposing it on the edge, but only forwards requests to its main service.
+func (w *Watcher) buildFallbackIngress(edgeIng *hubv1alpha1.EdgeIngress, ing *netv1.Ingress, middlewares []traefikv1alpha1.MiddlewareRef) *netv1.Ingress {
+	fallback := edgeIng.DeepCopy()
+	fallback.Status.Domain = edgeIng.Name + "-" + edgeIng.Namespace + "." + w.config.Fallback.Domain
+	if w.config.Fallback.EntryPoint != "" {
+		fallback.Spec.EntryPoints = nil
+	}
+
+	resourceVersion := ing.ResourceVersion
+	ing = buildIngress(fallback, ing, w.config.IngressClassName, w.config.Fallback.EntryPoint, nil, middlewares)
+	ing.Name = fallbackIngressName(edgeIng)
+	ing.ResourceVersion = resourceVersion
+	ing.Labels[labelFallback] = "true"
+
+	return ing
+}
+
+func (w *Watcher) deleteFallbackIngress(ctx context.Context, edgeIng *hubv1alpha1.EdgeIngress) error {
+	err := w.clientSet.NetworkingV1().Ingresses(edgeIng.Namespace).Delete(ctx, fallbackIngressName(edgeIng), metav1.DeleteOptions{})
+	if err != nil && !kerror.IsNotFound(err) {
+		return fmt.Errorf("delete fallback ingress: %w", err)
+	}
+
+	return nil
+}
+
+// deleteFallbackIngresses deletes all the fallback ingresses, including the ones left by a previous run of the agent.
+func (w *Watcher) deleteFallbackIngresses(ctx context.Context) error {
+	namespaces := w.config.Namespaces
+	if len(namespaces) == 0 {
+		namespaces = []string{metav1.NamespaceAll}
+	}
+
+	var ings []netv1.Ingress
+	for _, namespace := range namespaces {
+		list, err := w.clientSet.NetworkingV1().Ingresses(namespace).List(ctx, metav1.ListOptions{
+			LabelSelector: labelFallback + "=true",
+		})
+		if err != nil {
+			return fmt.Errorf("list fallback ingresses: %w", err)
+		}
+
+		ings = append(ings, list.Items...)
+	}
+
+	for _, ing := range ings {
+		if !w.owns(ing.Namespace) {
+			continue
+		}
+
+		err := w.clientSet.NetworkingV1().Ingresses(ing.Namespace).Delete(ctx, ing.Name, metav1.DeleteOptions{})
+		if err != nil && !kerror.IsNotFound(err) {
+			return fmt.Errorf("delete fallback ingress %s/%s: %w", ing.Namespace, ing.Name, err)
+		}
+
+		log.Debug().
+			Str("name", ing.Name).
+			Str("namespace", ing.Namespace).
+			Msg("Fallback Ingress deleted")
+	}
+
+	return nil
+}
+
+func fallbackIngressName(edgeIng *hubv1alpha1.EdgeIngress) string {
+	return edgeIng.Name + "-fallback"
+}
@@ -0,0 +1,109 @@
+/*
+Copyright (C) 2022 Traefik Labs
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package edgeingress
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	hubv1alpha1 "github.com/traefik/hub-agent-kubernetes/pkg/crd/api/hub/v1alpha1"
+	hubkubemock "github.com/traefik/hub-agent-kubernetes/pkg/crd/generated/client/hub/clientset/versioned/fake"
+	hubinformer "github.com/traefik/hub-agent-kubernetes/pkg/crd/generated/client/hub/informers/externalversions"
+	traefikkubemock "github.com/traefik/hub-agent-kubernetes/pkg/crd/generated/client/traefik/clientset/versioned/fake"
+	kerror "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubemock "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/cache"
+)
+
+func TestWatcher_syncFallback(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	httpEdgeIng := &hubv1alpha1.EdgeIngress{
+		ObjectMeta: metav1.ObjectMeta{Name: "whoami", Namespace: "default", UID: "uid"},
+		Spec: hubv1alpha1.EdgeIngressSpec{
+			Service: hubv1alpha1.EdgeIngressService{Name: "whoami", Port: 80},
+		},
+		Status: hubv1alpha1.EdgeIngressStatus{Domain: "whoami.hub.example.com"},
+	}
+	tcpEdgeIng := &hubv1alpha1.EdgeIngress{
+		ObjectMeta: metav1.ObjectMeta{Name: "postgres", Namespace: "default", UID: "uid"},
+		Spec: hubv1alpha1.EdgeIngressSpec{
+			Mode:    hubv1alpha1.EdgeIngressModeTCP,
+			Service: hubv1alpha1.EdgeIngressService{Name: "postgres", Port: 5432},
+		},
+	}
+
+	hubInformer := hubinformer.NewSharedInformerFactory(hubkubemock.NewSimpleClientset(httpEdgeIng, tcpEdgeIng), 0)
+	edgeIngressInformer := hubInformer.Hub().V1alpha1().EdgeIngresses().Informer()
+	hubInformer.Start(ctx.Done())
+	cache.WaitForCacheSync(ctx.Done(), edgeIngressInformer.HasSynced)
+
+	clientSet := kubemock.NewSimpleClientset()
+	now := time.Date(2022, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	w := &Watcher{
+		config: WatcherConfig{
+			IngressClassName: "traefik-hub",
+			Fallback: FallbackConfig{
+				Domain:     "apps.internal.example.com",
+				EntryPoint: "websecure",
+				Threshold:  5 * time.Minute,
+			},
+		},
+		hubInformer:      hubInformer,
+		clientSet:        clientSet,
+		traefikClientSet: traefikkubemock.NewSimpleClientset().TraefikV1alpha1(),
+		now:              func() time.Time { return now },
+	}
+
+	// The tunnel just went down: EdgeIngresses are not exposed locally yet.
+	w.trackAvailability(false)
+	w.syncFallback(ctx)
+
+	_, err := clientSet.NetworkingV1().Ingresses("default").Get(ctx, "whoami-fallback", metav1.GetOptions{})
+	assert.True(t, kerror.IsNotFound(err))
+
+	// The tunnel is down for longer than the threshold.
+	now = now.Add(5 * time.Minute)
+	w.trackAvailability(false)
+	w.syncFallback(ctx)
+
+	ing, err := clientSet.NetworkingV1().Ingresses("default").Get(ctx, "whoami-fallback", metav1.GetOptions{})
+	require.NoError(t, err)
+
+	assert.Equal(t, "whoami-default.apps.internal.example.com", ing.Spec.Rules[0].Host)
+	assert.Equal(t, "whoami", ing.Spec.Rules[0].HTTP.Paths[0].Backend.Service.Name)
+	assert.Equal(t, "websecure", ing.Annotations["traefik.ingress.kubernetes.io/router.entrypoints"])
+	assert.Equal(t, "true", ing.Labels[labelFallback])
+	assert.Equal(t, ownerReferences(httpEdgeIng), ing.OwnerReferences)
+
+	_, err = clientSet.NetworkingV1().Ingresses("default").Get(ctx, "postgres-fallback", metav1.GetOptions{})
+	assert.True(t, kerror.IsNotFound(err))
+
+	// The tunnel is back.
+	w.trackAvailability(true)
+	w.syncFallback(ctx)
+
+	_, err = clientSet.NetworkingV1().Ingresses("default").Get(ctx, "whoami-fallback", metav1.GetOptions{})
+	assert.True(t, kerror.IsNotFound(err))
+}
@@ -0,0 +1,176 @@
+/*
+Copyright (C) 2022 Traefik Labs
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package edgeingress
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/rs/zerolog/log"
+	hubv1alpha1 "github.com/traefik/hub-agent-kubernetes/pkg/crd/api/hub/v1alpha1"
+	kerror "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Finalizer is the finalizer set on EdgeIngresses, making sure the edge ingress is removed from the platform and the
+// resources generated for it are deleted before the EdgeIngress disappears.
+const Finalizer = "hub.traefik.io/edge-ingress"
+
+// finalizeEdgeIngress deletes the edge ingress from the platform along with the resources generated for it, and
+// removes the finalizer once done.
+func (w *Watcher) finalizeEdgeIngress(ctx context.Context, edgeIng *hubv1alpha1.EdgeIngress) error {
+	if !hasFinalizer(edgeIng) {
+		return nil
+	}
+
+	if err := w.client.DeleteEdgeIngress(ctx, edgeIng.Namespace, edgeIng.Name, edgeIng.Status.Version); err != nil {
+		return fmt.Errorf("delete edge ingress from platform: %w", err)
+	}
+
+	if err := w.deleteChildren(ctx, edgeIng); err != nil {
+		return fmt.Errorf("delete child resources: %w", err)
+	}
+
+	var finalizers []string
+	for _, finalizer := range edgeIng.Finalizers {
+		if finalizer != Finalizer {
+			finalizers = append(finalizers, finalizer)
+		}
+	}
+	edgeIng.Finalizers = finalizers
+
+	_, err := w.hubClientSet.HubV1alpha1().EdgeIngresses(edgeIng.Namespace).Update(ctx, edgeIng, metav1.UpdateOptions{})
+	if err != nil && !kerror.IsNotFound(err) {
+		return fmt.Errorf("remove finalizer: %w", err)
+	}
+
+	log.Debug().
+		Str("name", edgeIng.Name).
+		Str("namespace", edgeIng.Namespace).
+		Msg("EdgeIngress finalized")
+
+	return nil
+}
+
+// ensureFinalizer adds the finalizer on EdgeIngresses created before it was introduced.
+func (w *Watcher) ensureFinalizer(ctx context.Context, edgeIng *hubv1alpha1.EdgeIngress) (*hubv1alpha1.EdgeIngress, error) {
+	if hasFinalizer(edgeIng) {
+		return edgeIng, nil
+	}
+
+	edgeIng = edgeIng.DeepCopy()
+	edgeIng.Finalizers = append(edgeIng.Finalizers, Finalizer)
+
+	edgeIng, err := w.hubClientSet.HubV1alpha1().EdgeIngresses(edgeIng.Namespace).Update(ctx, edgeIng, metav1.UpdateOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("add finalizer: %w", err)
+	}
+
+	return edgeIng, nil
+}
+
+// deleteChildren deletes every resource the watcher may have generated for the given EdgeIngress.
+func (w *Watcher) deleteChildren(ctx context.Context, edgeIng *hubv1alpha1.EdgeIngress) error {
+	if err := w.deleteRoutes(ctx, edgeIng); err != nil {
+		return err
+	}
+
+	if err := w.deleteFallbackIngress(ctx, edgeIng); err != nil {
+		return err
+	}
+
+	for _, suffix := range []string{"-maintenance", "-ip-allowlist", "-rate-limit", "-compress", "-headers", "-redirect-https"} {
+		if _, err := w.syncMiddleware(ctx, edgeIng, edgeIng.Name+suffix, nil); err != nil {
+			return fmt.Errorf("delete middleware: %w", err)
+		}
+	}
+
+	err := w.clientSet.CoreV1().Secrets(edgeIng.Namespace).Delete(ctx, secretCustomDomainsName+"-"+edgeIng.Name, metav1.DeleteOptions{})
+	if err != nil && !kerror.IsNotFound(err) {
+		return fmt.Errorf("delete custom domains secret: %w", err)
+	}
+
+	return nil
+}
+
+// deleteRoutes deletes the routes generated for the given EdgeIngress, whatever its mode.
+func (w *Watcher) deleteRoutes(ctx context.Context, edgeIng *hubv1alpha1.EdgeIngress) error {
+	err := w.clientSet.NetworkingV1().Ingresses(edgeIng.Namespace).Delete(ctx, edgeIng.Name, metav1.DeleteOptions{})
+	if err != nil && !kerror.IsNotFound(err) {
+		return fmt.Errorf("delete ingress: %w", err)
+	}
+
+	if err = w.deleteRoute(ctx, edgeIng); err != nil {
+		return fmt.Errorf("delete route: %w", err)
+	}
+
+	if err = w.deleteTCPRoute(ctx, edgeIng); err != nil {
+		return fmt.Errorf("delete TCP route: %w", err)
+	}
+
+	err = w.traefikClientSet.IngressRoutes(edgeIng.Namespace).Delete(ctx, edgeIng.Name+"-redirect-https", metav1.DeleteOptions{})
+	if err != nil && !kerror.IsNotFound(err) {
+		return fmt.Errorf("delete redirect ingress route: %w", err)
+	}
+
+	return nil
+}
+
+// isOrphan returns whether the given platform edge ingress can't have an EdgeIngress anymore, as its namespace has
+// been deleted, or is being deleted.
+func (w *Watcher) isOrphan(ctx context.Context, edgeIng *EdgeIngress) (bool, error) {
+	ns, err := w.clientSet.CoreV1().Namespaces().Get(ctx, edgeIng.Namespace, metav1.GetOptions{})
+	if kerror.IsNotFound(err) {
+		return true, nil
+	}
+	// In namespace-scoped mode, the agent may not be allowed to read the watched Namespaces. The edge ingress is then
+	// assumed not to be orphaned, and is left on the platform.
+	if kerror.IsForbidden(err) && len(w.config.Namespaces) > 0 {
+		log.Debug().Err(err).Str("namespace", edgeIng.Namespace).Msg("Unable to check whether the edge ingress is orphaned")
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("get namespace: %w", err)
+	}
+
+	return ns.DeletionTimestamp != nil, nil
+}
+
+// cleanOrphan deletes from the platform an edge ingress which can't have an EdgeIngress anymore.
+func (w *Watcher) cleanOrphan(ctx context.Context, edgeIng *EdgeIngress) error {
+	if err := w.client.DeleteEdgeIngress(ctx, edgeIng.Namespace, edgeIng.Name, edgeIng.Version); err != nil {
+		return fmt.Errorf("delete edge ingress from platform: %w", err)
+	}
+
+	log.Info().
+		Str("name", edgeIng.Name).
+		Str("namespace", edgeIng.Namespace).
+		Msg("Orphaned edge ingress deleted from platform")
+
+	return nil
+}
+
+func hasFinalizer(edgeIng *hubv1alpha1.EdgeIngress) bool {
+	for _, finalizer := range edgeIng.Finalizers {
+		if finalizer == Finalizer {
+			return true
+		}
+	}
+
+	return false
+}
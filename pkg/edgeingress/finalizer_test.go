@@ -0,0 +1,180 @@
+/*
+Copyright (C) 2022 Traefik Labs
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package edgeingress
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	hubv1alpha1 "github.com/traefik/hub-agent-kubernetes/pkg/crd/api/hub/v1alpha1"
+	traefikv1alpha1 "github.com/traefik/hub-agent-kubernetes/pkg/crd/api/traefik/v1alpha1"
+	hubkubemock "github.com/traefik/hub-agent-kubernetes/pkg/crd/generated/client/hub/clientset/versioned/fake"
+	traefikkubemock "github.com/traefik/hub-agent-kubernetes/pkg/crd/generated/client/traefik/clientset/versioned/fake"
+	corev1 "k8s.io/api/core/v1"
+	netv1 "k8s.io/api/networking/v1"
+	kerror "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubemock "k8s.io/client-go/kubernetes/fake"
+	ktesting "k8s.io/client-go/testing"
+)
+
+func TestWatcher_finalizeEdgeIngress(t *testing.T) {
+	ctx := context.Background()
+
+	now := metav1.NewTime(time.Now())
+	edgeIng := &hubv1alpha1.EdgeIngress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "whoami",
+			Namespace:         "default",
+			Finalizers:        []string{"other", Finalizer},
+			DeletionTimestamp: &now,
+		},
+		Status: hubv1alpha1.EdgeIngressStatus{Version: "version-1"},
+	}
+
+	hubClientSet := hubkubemock.NewSimpleClientset(edgeIng)
+	clientSet := kubemock.NewSimpleClientset(
+		&netv1.Ingress{ObjectMeta: metav1.ObjectMeta{Name: "whoami", Namespace: "default"}},
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "hub-certificate-custom-domains-whoami", Namespace: "default"}},
+	)
+	traefikClientSet := traefikkubemock.NewSimpleClientset(
+		&traefikv1alpha1.Middleware{ObjectMeta: metav1.ObjectMeta{Name: "whoami-rate-limit", Namespace: "default"}},
+	)
+
+	client := newPlatformClientMock(t)
+	client.OnDeleteEdgeIngress("default", "whoami", "version-1").TypedReturns(nil).Once()
+
+	w := &Watcher{
+		client:           client,
+		hubClientSet:     hubClientSet,
+		clientSet:        clientSet,
+		traefikClientSet: traefikClientSet.TraefikV1alpha1(),
+	}
+
+	err := w.finalizeEdgeIngress(ctx, edgeIng.DeepCopy())
+	require.NoError(t, err)
+
+	_, err = clientSet.NetworkingV1().Ingresses("default").Get(ctx, "whoami", metav1.GetOptions{})
+	assert.True(t, kerror.IsNotFound(err))
+	_, err = clientSet.CoreV1().Secrets("default").Get(ctx, "hub-certificate-custom-domains-whoami", metav1.GetOptions{})
+	assert.True(t, kerror.IsNotFound(err))
+	_, err = traefikClientSet.TraefikV1alpha1().Middlewares("default").Get(ctx, "whoami-rate-limit", metav1.GetOptions{})
+	assert.True(t, kerror.IsNotFound(err))
+
+	got, err := hubClientSet.HubV1alpha1().EdgeIngresses("default").Get(ctx, "whoami", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"other"}, got.Finalizers)
+}
+
+func TestWatcher_createOrCleanEdgeIngress(t *testing.T) {
+	tests := []struct {
+		desc        string
+		namespace   *corev1.Namespace
+		namespaces  []string
+		forbidden   bool
+		wantCreated bool
+		wantErr     bool
+	}{
+		{
+			desc:        "namespace exists",
+			namespace:   &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "default"}},
+			wantCreated: true,
+		},
+		{
+			desc: "namespace is being deleted",
+			namespace: &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+				Name:              "default",
+				DeletionTimestamp: &metav1.Time{Time: time.Now()},
+			}},
+		},
+		{
+			desc: "namespace is deleted",
+		},
+		{
+			desc:        "namespace can't be read in namespace-scoped mode",
+			namespaces:  []string{"default"},
+			forbidden:   true,
+			wantCreated: true,
+		},
+		{
+			desc:      "namespace can't be read",
+			forbidden: true,
+			wantErr:   true,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := context.Background()
+
+			clientSet := kubemock.NewSimpleClientset()
+			if test.namespace != nil {
+				clientSet = kubemock.NewSimpleClientset(test.namespace)
+			}
+			if test.forbidden {
+				clientSet.PrependReactor("get", "namespaces", func(ktesting.Action) (bool, runtime.Object, error) {
+					return true, nil, kerror.NewForbidden(corev1.Resource("namespaces"), "default", errors.New("forbidden"))
+				})
+			}
+			hubClientSet := hubkubemock.NewSimpleClientset()
+
+			client := newPlatformClientMock(t)
+			if !test.wantCreated && !test.wantErr {
+				client.OnDeleteEdgeIngress("default", "whoami", "version-1").TypedReturns(nil).Once()
+			}
+
+			w := &Watcher{
+				config:           WatcherConfig{Namespaces: test.namespaces},
+				client:           client,
+				hubClientSet:     hubClientSet,
+				clientSet:        clientSet,
+				traefikClientSet: traefikkubemock.NewSimpleClientset().TraefikV1alpha1(),
+			}
+
+			err := w.createOrCleanEdgeIngress(ctx, &EdgeIngress{
+				Name:      "whoami",
+				Namespace: "default",
+				Domain:    "sad-bat-123.hub-traefik.io",
+				Version:   "version-1",
+				Service:   Service{Name: "whoami", Port: 80},
+			})
+			if test.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			got, err := hubClientSet.HubV1alpha1().EdgeIngresses("default").Get(ctx, "whoami", metav1.GetOptions{})
+			if !test.wantCreated {
+				assert.True(t, kerror.IsNotFound(err))
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, []string{Finalizer}, got.Finalizers)
+		})
+	}
+}
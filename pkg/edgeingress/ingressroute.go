@@ -0,0 +1,281 @@
+/*
+Copyright (C) 2022 Traefik Labs
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package edgeingress
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"github.com/traefik/hub-agent-kubernetes/pkg/acp/admission/reviewer"
+	hubv1alpha1 "github.com/traefik/hub-agent-kubernetes/pkg/crd/api/hub/v1alpha1"
+	traefikv1alpha1 "github.com/traefik/hub-agent-kubernetes/pkg/crd/api/traefik/v1alpha1"
+	kerror "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// upsertRoute exposes an EdgeIngress through a Traefik IngressRoute instead of a networking/v1 Ingress. This is
+// required to load-balance between weighted services or to enable sticky sessions, which Ingresses cannot configure.
+func (w *Watcher) upsertRoute(ctx context.Context, edgeIng *hubv1alpha1.EdgeIngress, customDomains []string, middlewares []traefikv1alpha1.MiddlewareRef) error {
+	if len(edgeIng.Spec.Services) > 0 {
+		if err := w.upsertTraefikService(ctx, edgeIng); err != nil {
+			return fmt.Errorf("upsert traefik service: %w", err)
+		}
+	} else {
+		err := w.traefikClientSet.TraefikServices(edgeIng.Namespace).Delete(ctx, edgeIng.Name, metav1.DeleteOptions{})
+		if err != nil && !kerror.IsNotFound(err) {
+			return fmt.Errorf("delete traefik service: %w", err)
+		}
+	}
+
+	if err := w.upsertIngressRoute(ctx, edgeIng, customDomains, middlewares); err != nil {
+		return fmt.Errorf("upsert ingress route: %w", err)
+	}
+
+	// The EdgeIngress may have been exposed through an Ingress before.
+	err := w.clientSet.NetworkingV1().Ingresses(edgeIng.Namespace).Delete(ctx, edgeIng.Name, metav1.DeleteOptions{})
+	if err != nil && !kerror.IsNotFound(err) {
+		return fmt.Errorf("delete ingress: %w", err)
+	}
+
+	return nil
+}
+
+// deleteRoute removes the resources generated by upsertRoute, if any.
+func (w *Watcher) deleteRoute(ctx context.Context, edgeIng *hubv1alpha1.EdgeIngress) error {
+	err := w.traefikClientSet.IngressRoutes(edgeIng.Namespace).Delete(ctx, edgeIng.Name, metav1.DeleteOptions{})
+	if err != nil && !kerror.IsNotFound(err) {
+		return fmt.Errorf("delete ingress route: %w", err)
+	}
+
+	err = w.traefikClientSet.TraefikServices(edgeIng.Namespace).Delete(ctx, edgeIng.Name, metav1.DeleteOptions{})
+	if err != nil && !kerror.IsNotFound(err) {
+		return fmt.Errorf("delete traefik service: %w", err)
+	}
+
+	return nil
+}
+
+func (w *Watcher) upsertTraefikService(ctx context.Context, edgeIng *hubv1alpha1.EdgeIngress) error {
+	svc, err := w.traefikClientSet.TraefikServices(edgeIng.Namespace).Get(ctx, edgeIng.Name, metav1.GetOptions{})
+	if err != nil && !kerror.IsNotFound(err) {
+		return fmt.Errorf("get traefik service: %w", err)
+	}
+
+	if kerror.IsNotFound(err) {
+		svc = buildTraefikService(edgeIng, &traefikv1alpha1.TraefikService{})
+		_, err = w.traefikClientSet.TraefikServices(edgeIng.Namespace).Create(ctx, svc, metav1.CreateOptions{})
+		if err != nil {
+			return fmt.Errorf("create traefik service: %w", err)
+		}
+
+		log.Debug().
+			Str("name", svc.Name).
+			Str("namespace", svc.Namespace).
+			Msg("TraefikService created")
+
+		return nil
+	}
+
+	svc = buildTraefikService(edgeIng, svc)
+	_, err = w.traefikClientSet.TraefikServices(edgeIng.Namespace).Update(ctx, svc, metav1.UpdateOptions{})
+	if err != nil {
+		return fmt.Errorf("update traefik service: %w", err)
+	}
+
+	log.Debug().
+		Str("name", svc.Name).
+		Str("namespace", svc.Namespace).
+		Msg("TraefikService updated")
+
+	return nil
+}
+
+func (w *Watcher) upsertIngressRoute(ctx context.Context, edgeIng *hubv1alpha1.EdgeIngress, customDomains []string, middlewares []traefikv1alpha1.MiddlewareRef) error {
+	ingRoute, err := w.traefikClientSet.IngressRoutes(edgeIng.Namespace).Get(ctx, edgeIng.Name, metav1.GetOptions{})
+	if err != nil && !kerror.IsNotFound(err) {
+		return fmt.Errorf("get ingress route: %w", err)
+	}
+
+	if kerror.IsNotFound(err) {
+		ingRoute = buildIngressRoute(edgeIng, &traefikv1alpha1.IngressRoute{}, w.config.TraefikEntryPoint, customDomains, middlewares)
+		_, err = w.traefikClientSet.IngressRoutes(edgeIng.Namespace).Create(ctx, ingRoute, metav1.CreateOptions{})
+		if err != nil {
+			return fmt.Errorf("create ingress route: %w", err)
+		}
+
+		log.Debug().
+			Str("name", ingRoute.Name).
+			Str("namespace", ingRoute.Namespace).
+			Msg("IngressRoute created")
+
+		return nil
+	}
+
+	ingRoute = buildIngressRoute(edgeIng, ingRoute, w.config.TraefikEntryPoint, customDomains, middlewares)
+	_, err = w.traefikClientSet.IngressRoutes(edgeIng.Namespace).Update(ctx, ingRoute, metav1.UpdateOptions{})
+	if err != nil {
+		return fmt.Errorf("update ingress route: %w", err)
+	}
+
+	log.Debug().
+		Str("name", ingRoute.Name).
+		Str("namespace", ingRoute.Namespace).
+		Msg("IngressRoute updated")
+
+	return nil
+}
+
+func buildTraefikService(edgeIng *hubv1alpha1.EdgeIngress, svc *traefikv1alpha1.TraefikService) *traefikv1alpha1.TraefikService {
+	svc.ObjectMeta = metav1.ObjectMeta{
+		Name:            edgeIng.Name,
+		Namespace:       edgeIng.Namespace,
+		ResourceVersion: svc.ResourceVersion,
+		Labels: map[string]string{
+			"app.kubernetes.io/managed-by": "traefik-hub",
+		},
+		OwnerReferences: ownerReferences(edgeIng),
+	}
+
+	weighted := &traefikv1alpha1.WeightedRoundRobin{Sticky: buildSticky(edgeIng)}
+	for _, service := range edgeIng.Spec.Services {
+		weight := service.Weight
+		weighted.Services = append(weighted.Services, traefikv1alpha1.Service{
+			LoadBalancerSpec: traefikv1alpha1.LoadBalancerSpec{
+				Name:   service.Name,
+				Kind:   "Service",
+				Port:   intstr.FromInt(service.Port),
+				Weight: &weight,
+				Scheme: backendScheme(edgeIng),
+			},
+		})
+	}
+
+	svc.Spec = traefikv1alpha1.ServiceSpec{Weighted: weighted}
+
+	return svc
+}
+
+func buildIngressRoute(edgeIng *hubv1alpha1.EdgeIngress, ingRoute *traefikv1alpha1.IngressRoute, entryPoint string, customDomains []string, middlewares []traefikv1alpha1.MiddlewareRef) *traefikv1alpha1.IngressRoute {
+	var annotations map[string]string
+	if edgeIng.Spec.ACP != nil && edgeIng.Spec.ACP.Name != "" {
+		annotations = map[string]string{reviewer.AnnotationHubAuth: edgeIng.Spec.ACP.Name}
+	}
+
+	ingRoute.ObjectMeta = metav1.ObjectMeta{
+		Name:            edgeIng.Name,
+		Namespace:       edgeIng.Namespace,
+		ResourceVersion: ingRoute.ResourceVersion,
+		Annotations:     generatedAnnotations(edgeIng, annotations),
+		Labels:          generatedLabels(edgeIng),
+		OwnerReferences: ownerReferences(edgeIng),
+	}
+
+	hosts := make([]string, 0, len(customDomains)+1)
+	for _, host := range append([]string{edgeIng.Status.Domain}, customDomains...) {
+		hosts = append(hosts, "`"+host+"`")
+	}
+
+	// The hub domain is served with the wildcard certificate configured in the catch-all ingress.
+	tls := &traefikv1alpha1.TLS{}
+	if len(customDomains) > 0 {
+		tls.SecretName = secretCustomDomainsName + "-" + edgeIng.Name
+	}
+	if edgeIng.Spec.TLS != nil && edgeIng.Spec.TLS.Options != "" {
+		tls.Options = &traefikv1alpha1.TLSOptionRef{Name: edgeIng.Spec.TLS.Options, Namespace: edgeIng.Namespace}
+	}
+
+	ingRoute.Spec = traefikv1alpha1.IngressRouteSpec{
+		EntryPoints: routeEntryPoints(edgeIng, entryPoint),
+		Routes: []traefikv1alpha1.Route{
+			{
+				Match:       "Host(" + strings.Join(hosts, ",") + ")",
+				Kind:        "Rule",
+				Middlewares: middlewares,
+				Services:    []traefikv1alpha1.Service{routeService(edgeIng)},
+			},
+		},
+		TLS: tls,
+	}
+
+	return ingRoute
+}
+
+// routeService returns the service targeted by the IngressRoute of the given EdgeIngress: the generated TraefikService
+// when load-balancing between weighted services, the exposed Kubernetes Service, with its sticky sessions, otherwise.
+func routeService(edgeIng *hubv1alpha1.EdgeIngress) traefikv1alpha1.Service {
+	if len(edgeIng.Spec.Services) > 0 {
+		return traefikv1alpha1.Service{
+			LoadBalancerSpec: traefikv1alpha1.LoadBalancerSpec{
+				Name: edgeIng.Name,
+				Kind: "TraefikService",
+			},
+		}
+	}
+
+	return traefikv1alpha1.Service{
+		LoadBalancerSpec: traefikv1alpha1.LoadBalancerSpec{
+			Name:   edgeIng.Spec.Service.Name,
+			Kind:   "Service",
+			Port:   intstr.FromInt(edgeIng.Spec.Service.Port),
+			Sticky: buildSticky(edgeIng),
+			Scheme: backendScheme(edgeIng),
+		},
+	}
+}
+
+// backendScheme returns the scheme Traefik must use to reach the services of the given EdgeIngress. Ingresses can't
+// set it, as Traefik reads it from an annotation on the Kubernetes Service, so a scheme requires an IngressRoute.
+func backendScheme(edgeIng *hubv1alpha1.EdgeIngress) string {
+	switch edgeIng.Spec.Service.Protocol {
+	case hubv1alpha1.EdgeIngressServiceProtocolH2C, hubv1alpha1.EdgeIngressServiceProtocolGRPC:
+		return "h2c"
+	default:
+		return ""
+	}
+}
+
+// buildSticky returns the sticky sessions configuration of the given EdgeIngress, if any.
+func buildSticky(edgeIng *hubv1alpha1.EdgeIngress) *traefikv1alpha1.Sticky {
+	if edgeIng.Spec.Sticky == nil {
+		return nil
+	}
+
+	return &traefikv1alpha1.Sticky{
+		Cookie: &traefikv1alpha1.Cookie{
+			Name:     edgeIng.Spec.Sticky.CookieName,
+			Secure:   true,
+			HTTPOnly: true,
+			MaxAge:   edgeIng.Spec.Sticky.TTL,
+		},
+	}
+}
+
+// ownerReferences returns the OwnerReferences allowing to delete resources owned by an edgeIngress.
+func ownerReferences(edgeIng *hubv1alpha1.EdgeIngress) []metav1.OwnerReference {
+	return []metav1.OwnerReference{
+		{
+			APIVersion: "hub.traefik.io/v1alpha1",
+			Kind:       "EdgeIngress",
+			Name:       edgeIng.Name,
+			UID:        edgeIng.UID,
+		},
+	}
+}
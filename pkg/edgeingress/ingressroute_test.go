@@ -0,0 +1,194 @@
+/*
+Copyright (C) 2022 Traefik Labs
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package edgeingress
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	hubv1alpha1 "github.com/traefik/hub-agent-kubernetes/pkg/crd/api/hub/v1alpha1"
+	traefikv1alpha1 "github.com/traefik/hub-agent-kubernetes/pkg/crd/api/traefik/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func TestBuildTraefikService(t *testing.T) {
+	edgeIng := &hubv1alpha1.EdgeIngress{
+		ObjectMeta: metav1.ObjectMeta{Name: "canary", Namespace: "default", UID: "uid"},
+		Spec: hubv1alpha1.EdgeIngressSpec{
+			Services: []hubv1alpha1.EdgeIngressWeightedService{
+				{Name: "app-v1", Port: 80, Weight: 90},
+				{Name: "app-v2", Port: 8080, Weight: 10},
+			},
+			Sticky: &hubv1alpha1.EdgeIngressSticky{CookieName: "canary", TTL: 3600},
+		},
+	}
+
+	got := buildTraefikService(edgeIng, &traefikv1alpha1.TraefikService{})
+
+	weightV1, weightV2 := 90, 10
+	assert.Equal(t, &traefikv1alpha1.TraefikService{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "canary",
+			Namespace: "default",
+			Labels: map[string]string{
+				"app.kubernetes.io/managed-by": "traefik-hub",
+			},
+			OwnerReferences: []metav1.OwnerReference{
+				{
+					APIVersion: "hub.traefik.io/v1alpha1",
+					Kind:       "EdgeIngress",
+					Name:       "canary",
+					UID:        "uid",
+				},
+			},
+		},
+		Spec: traefikv1alpha1.ServiceSpec{
+			Weighted: &traefikv1alpha1.WeightedRoundRobin{
+				Services: []traefikv1alpha1.Service{
+					{LoadBalancerSpec: traefikv1alpha1.LoadBalancerSpec{Name: "app-v1", Kind: "Service", Port: intstr.FromInt(80), Weight: &weightV1}},
+					{LoadBalancerSpec: traefikv1alpha1.LoadBalancerSpec{Name: "app-v2", Kind: "Service", Port: intstr.FromInt(8080), Weight: &weightV2}},
+				},
+				Sticky: &traefikv1alpha1.Sticky{
+					Cookie: &traefikv1alpha1.Cookie{Name: "canary", Secure: true, HTTPOnly: true, MaxAge: 3600},
+				},
+			},
+		},
+	}, got)
+}
+
+func TestBuildIngressRoute(t *testing.T) {
+	tests := []struct {
+		desc            string
+		acp             *hubv1alpha1.EdgeIngressACP
+		protocol        hubv1alpha1.EdgeIngressServiceProtocol
+		services        []hubv1alpha1.EdgeIngressWeightedService
+		sticky          *hubv1alpha1.EdgeIngressSticky
+		entryPoints     []string
+		tls             *hubv1alpha1.EdgeIngressTLS
+		customDomains   []string
+		wantAnnots      map[string]string
+		wantEntryPoints []string
+		wantMatch       string
+		wantService     traefikv1alpha1.LoadBalancerSpec
+		wantTLS         *traefikv1alpha1.TLS
+	}{
+		{
+			desc:        "hub domain only",
+			wantMatch:   "Host(`sad-bat-123.hub-traefik.io`)",
+			wantService: traefikv1alpha1.LoadBalancerSpec{Name: "app", Kind: "Service", Port: intstr.FromInt(80)},
+			wantTLS:     &traefikv1alpha1.TLS{},
+		},
+		{
+			desc: "weighted services",
+			services: []hubv1alpha1.EdgeIngressWeightedService{
+				{Name: "app-v1", Port: 80, Weight: 1},
+			},
+			wantMatch:   "Host(`sad-bat-123.hub-traefik.io`)",
+			wantService: traefikv1alpha1.LoadBalancerSpec{Name: "canary", Kind: "TraefikService"},
+			wantTLS:     &traefikv1alpha1.TLS{},
+		},
+		{
+			desc:          "with custom domains and ACP",
+			acp:           &hubv1alpha1.EdgeIngressACP{Name: "my-acp"},
+			customDomains: []string{"canary.example.com"},
+			wantAnnots:    map[string]string{"hub.traefik.io/access-control-policy": "my-acp"},
+			wantMatch:     "Host(`sad-bat-123.hub-traefik.io`,`canary.example.com`)",
+			wantService:   traefikv1alpha1.LoadBalancerSpec{Name: "app", Kind: "Service", Port: intstr.FromInt(80)},
+			wantTLS:       &traefikv1alpha1.TLS{SecretName: "hub-certificate-custom-domains-canary"},
+		},
+		{
+			desc:      "sticky sessions",
+			sticky:    &hubv1alpha1.EdgeIngressSticky{CookieName: "session", TTL: 600},
+			wantMatch: "Host(`sad-bat-123.hub-traefik.io`)",
+			wantService: traefikv1alpha1.LoadBalancerSpec{
+				Name: "app",
+				Kind: "Service",
+				Port: intstr.FromInt(80),
+				Sticky: &traefikv1alpha1.Sticky{
+					Cookie: &traefikv1alpha1.Cookie{Name: "session", Secure: true, HTTPOnly: true, MaxAge: 600},
+				},
+			},
+			wantTLS: &traefikv1alpha1.TLS{},
+		},
+		{
+			desc:        "gRPC service",
+			protocol:    hubv1alpha1.EdgeIngressServiceProtocolGRPC,
+			wantMatch:   "Host(`sad-bat-123.hub-traefik.io`)",
+			wantService: traefikv1alpha1.LoadBalancerSpec{Name: "app", Kind: "Service", Port: intstr.FromInt(80), Scheme: "h2c"},
+			wantTLS:     &traefikv1alpha1.TLS{},
+		},
+		{
+			desc:            "with entry points and TLS options",
+			entryPoints:     []string{"websecure"},
+			tls:             &hubv1alpha1.EdgeIngressTLS{Options: "modern"},
+			wantEntryPoints: []string{"websecure"},
+			wantMatch:       "Host(`sad-bat-123.hub-traefik.io`)",
+			wantService:     traefikv1alpha1.LoadBalancerSpec{Name: "app", Kind: "Service", Port: intstr.FromInt(80)},
+			wantTLS: &traefikv1alpha1.TLS{
+				Options: &traefikv1alpha1.TLSOptionRef{Name: "modern", Namespace: "default"},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			edgeIng := &hubv1alpha1.EdgeIngress{
+				ObjectMeta: metav1.ObjectMeta{Name: "canary", Namespace: "default", UID: "uid"},
+				Spec: hubv1alpha1.EdgeIngressSpec{
+					Service: hubv1alpha1.EdgeIngressService{
+						Name:     "app",
+						Port:     80,
+						Protocol: test.protocol,
+					},
+					ACP:         test.acp,
+					Services:    test.services,
+					Sticky:      test.sticky,
+					EntryPoints: test.entryPoints,
+					TLS:         test.tls,
+				},
+				Status: hubv1alpha1.EdgeIngressStatus{Domain: "sad-bat-123.hub-traefik.io"},
+			}
+
+			got := buildIngressRoute(edgeIng, &traefikv1alpha1.IngressRoute{}, "traefikhub-tunl", test.customDomains, nil)
+
+			wantEntryPoints := test.wantEntryPoints
+			if wantEntryPoints == nil {
+				wantEntryPoints = []string{"traefikhub-tunl"}
+			}
+
+			assert.Equal(t, test.wantAnnots, got.Annotations)
+			assert.Equal(t, traefikv1alpha1.IngressRouteSpec{
+				EntryPoints: wantEntryPoints,
+				Routes: []traefikv1alpha1.Route{
+					{
+						Match: test.wantMatch,
+						Kind:  "Rule",
+						Services: []traefikv1alpha1.Service{
+							{LoadBalancerSpec: test.wantService},
+						},
+					},
+				},
+				TLS: test.wantTLS,
+			}, got.Spec)
+		})
+	}
+}
@@ -0,0 +1,78 @@
+/*
+Copyright (C) 2022 Traefik Labs
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package edgeingress
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	hubv1alpha1 "github.com/traefik/hub-agent-kubernetes/pkg/crd/api/hub/v1alpha1"
+	traefikv1alpha1 "github.com/traefik/hub-agent-kubernetes/pkg/crd/api/traefik/v1alpha1"
+)
+
+// MaintenancePath is the path on which the auth server answers the requests sent to paused EdgeIngresses.
+const MaintenancePath = "/_maintenance"
+
+const defaultMaintenanceMessage = "Service temporarily unavailable for maintenance"
+
+// MaintenanceHandler answers the forwardAuth requests of paused EdgeIngresses with a 503 Service Unavailable
+// response. Traefik sends this response back to the client instead of forwarding the request to the service.
+func MaintenanceHandler() http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		msg := req.URL.Query().Get("message")
+		if msg == "" {
+			msg = defaultMaintenanceMessage
+		}
+
+		rw.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		rw.WriteHeader(http.StatusServiceUnavailable)
+		_, _ = fmt.Fprintln(rw, msg)
+	})
+}
+
+// syncMaintenanceMiddleware makes sure the middleware serving the maintenance response exists if the given
+// EdgeIngress is paused, and deletes it otherwise.
+func (w *Watcher) syncMaintenanceMiddleware(ctx context.Context, edgeIng *hubv1alpha1.EdgeIngress) (*traefikv1alpha1.MiddlewareRef, error) {
+	var spec *traefikv1alpha1.MiddlewareSpec
+	if edgeIng.Spec.Paused {
+		var msg string
+		if edgeIng.Spec.Maintenance != nil {
+			msg = edgeIng.Spec.Maintenance.Message
+		}
+
+		address := w.config.AuthServerAddr + MaintenancePath
+		if msg != "" {
+			address += "?" + url.Values{"message": {msg}}.Encode()
+		}
+
+		spec = &traefikv1alpha1.MiddlewareSpec{
+			ForwardAuth: &traefikv1alpha1.ForwardAuth{Address: address},
+		}
+	}
+
+	return w.syncMiddleware(ctx, edgeIng, edgeIng.Name+"-maintenance", spec)
+}
+
+// removesRouteWhenPaused returns whether the routes of the given EdgeIngress are removed while it is paused, rather
+// than serving a maintenance response. Maintenance responses can't be served on raw TCP connections.
+func removesRouteWhenPaused(edgeIng *hubv1alpha1.EdgeIngress) bool {
+	return edgeIng.Spec.Mode == hubv1alpha1.EdgeIngressModeTCP ||
+		(edgeIng.Spec.Maintenance != nil && edgeIng.Spec.Maintenance.RemoveRoute)
+}
@@ -0,0 +1,158 @@
+/*
+Copyright (C) 2022 Traefik Labs
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package edgeingress
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	hubv1alpha1 "github.com/traefik/hub-agent-kubernetes/pkg/crd/api/hub/v1alpha1"
+	traefikv1alpha1 "github.com/traefik/hub-agent-kubernetes/pkg/crd/api/traefik/v1alpha1"
+	hubkubemock "github.com/traefik/hub-agent-kubernetes/pkg/crd/generated/client/hub/clientset/versioned/fake"
+	traefikkubemock "github.com/traefik/hub-agent-kubernetes/pkg/crd/generated/client/traefik/clientset/versioned/fake"
+	netv1 "k8s.io/api/networking/v1"
+	kerror "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubemock "k8s.io/client-go/kubernetes/fake"
+)
+
+func TestMaintenanceHandler(t *testing.T) {
+	tests := []struct {
+		desc     string
+		target   string
+		wantBody string
+	}{
+		{
+			desc:     "default message",
+			target:   MaintenancePath,
+			wantBody: "Service temporarily unavailable for maintenance\n",
+		},
+		{
+			desc:     "custom message",
+			target:   MaintenancePath + "?message=Back+at+noon",
+			wantBody: "Back at noon\n",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			rw := httptest.NewRecorder()
+			MaintenanceHandler().ServeHTTP(rw, httptest.NewRequest(http.MethodGet, test.target, nil))
+
+			assert.Equal(t, http.StatusServiceUnavailable, rw.Code)
+			assert.Equal(t, test.wantBody, rw.Body.String())
+		})
+	}
+}
+
+func TestWatcher_setupMiddlewares_paused(t *testing.T) {
+	ctx := context.Background()
+
+	traefikClientSet := traefikkubemock.NewSimpleClientset()
+
+	w := &Watcher{
+		config:           WatcherConfig{AuthServerAddr: "http://hub-agent-auth-server.hub-agent.svc.cluster.local"},
+		traefikClientSet: traefikClientSet.TraefikV1alpha1(),
+	}
+
+	edgeIng := &hubv1alpha1.EdgeIngress{
+		ObjectMeta: metav1.ObjectMeta{Name: "edge-ingress", Namespace: "default", UID: "uid"},
+		Spec: hubv1alpha1.EdgeIngressSpec{
+			AllowedSourceIPs: []string{"10.0.0.0/8"},
+			Paused:           true,
+			Maintenance:      &hubv1alpha1.EdgeIngressMaintenance{Message: "Back at noon"},
+		},
+	}
+
+	refs, err := w.setupMiddlewares(ctx, edgeIng)
+	require.NoError(t, err)
+
+	assert.Equal(t, []traefikv1alpha1.MiddlewareRef{
+		{Name: "edge-ingress-maintenance", Namespace: "default"},
+		{Name: "edge-ingress-ip-allowlist", Namespace: "default"},
+	}, refs)
+
+	maintenance, err := traefikClientSet.TraefikV1alpha1().Middlewares("default").Get(ctx, "edge-ingress-maintenance", metav1.GetOptions{})
+	require.NoError(t, err)
+
+	assert.Equal(t, traefikv1alpha1.MiddlewareSpec{
+		ForwardAuth: &traefikv1alpha1.ForwardAuth{
+			Address: "http://hub-agent-auth-server.hub-agent.svc.cluster.local/_maintenance?message=Back+at+noon",
+		},
+	}, maintenance.Spec)
+
+	// Resuming the EdgeIngress removes the maintenance middleware.
+	edgeIng.Spec.Paused = false
+
+	refs, err = w.setupMiddlewares(ctx, edgeIng)
+	require.NoError(t, err)
+
+	assert.Equal(t, []traefikv1alpha1.MiddlewareRef{{Name: "edge-ingress-ip-allowlist", Namespace: "default"}}, refs)
+
+	_, err = traefikClientSet.TraefikV1alpha1().Middlewares("default").Get(ctx, "edge-ingress-maintenance", metav1.GetOptions{})
+	assert.True(t, kerror.IsNotFound(err))
+}
+
+func TestWatcher_syncChild_pausedRemovesRoute(t *testing.T) {
+	ctx := context.Background()
+
+	edgeIng := &hubv1alpha1.EdgeIngress{
+		ObjectMeta: metav1.ObjectMeta{Name: "whoami", Namespace: "default", UID: "uid"},
+		Spec: hubv1alpha1.EdgeIngressSpec{
+			Service:     hubv1alpha1.EdgeIngressService{Name: "whoami", Port: 80},
+			Paused:      true,
+			Maintenance: &hubv1alpha1.EdgeIngressMaintenance{RemoveRoute: true},
+		},
+		Status: hubv1alpha1.EdgeIngressStatus{Domain: "whoami.hub.example.com"},
+	}
+	ing := &netv1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{Name: "whoami", Namespace: "default"},
+	}
+
+	clientSet := kubemock.NewSimpleClientset(ing)
+	hubClientSet := hubkubemock.NewSimpleClientset(edgeIng)
+
+	w := &Watcher{
+		hubClientSet:     hubClientSet,
+		clientSet:        clientSet,
+		traefikClientSet: traefikkubemock.NewSimpleClientset().TraefikV1alpha1(),
+	}
+
+	err := w.syncChild(ctx, edgeIng, nil)
+	require.NoError(t, err)
+
+	_, err = clientSet.NetworkingV1().Ingresses("default").Get(ctx, "whoami", metav1.GetOptions{})
+	assert.True(t, kerror.IsNotFound(err))
+
+	got, err := hubClientSet.HubV1alpha1().EdgeIngresses("default").Get(ctx, "whoami", metav1.GetOptions{})
+	require.NoError(t, err)
+
+	assert.Equal(t, hubv1alpha1.EdgeIngressConnectionUp, got.Status.Connection)
+
+	ready := meta.FindStatusCondition(got.Status.Conditions, hubv1alpha1.EdgeIngressConditionReady)
+	require.NotNil(t, ready)
+	assert.Equal(t, "Paused", ready.Reason)
+}
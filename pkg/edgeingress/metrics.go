@@ -0,0 +1,78 @@
+/*
+Copyright (C) 2022 Traefik Labs
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package edgeingress
+
+import "github.com/prometheus/client_golang/prometheus"
+
+const (
+	metricsNamespace            = "hub_agent"
+	certificateMetricsSubsystem = "certificate"
+	edgeIngressMetricsSubsystem = "edge_ingress"
+)
+
+var (
+	certificateExpiryTimestamp = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Subsystem: certificateMetricsSubsystem,
+		Name:      "expiry_timestamp_seconds",
+		Help:      "Unix timestamp at which the wildcard certificate expires.",
+	})
+	certificateRenewalsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Subsystem: certificateMetricsSubsystem,
+		Name:      "renewals_total",
+		Help:      "Number of wildcard certificate renewals, by result.",
+	}, []string{"result"})
+
+	edgeIngressRequestsPerSecond = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Subsystem: edgeIngressMetricsSubsystem,
+		Name:      "requests_per_second",
+		Help:      "Average number of requests per second received by the edge ingress over the traffic window.",
+	}, []string{"namespace", "name"})
+	edgeIngressErrorRatio = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Subsystem: edgeIngressMetricsSubsystem,
+		Name:      "error_ratio",
+		Help:      "Ratio of requests received by the edge ingress answered with an error over the traffic window, by error class.",
+	}, []string{"namespace", "name", "class"})
+	edgeIngressResponseTime = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Subsystem: edgeIngressMetricsSubsystem,
+		Name:      "average_response_time_seconds",
+		Help:      "Average time taken to answer the requests received by the edge ingress over the traffic window.",
+	}, []string{"namespace", "name"})
+	edgeIngressSyncDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Subsystem: edgeIngressMetricsSubsystem,
+		Name:      "sync_duration_seconds",
+		Help:      "Time taken to synchronize edge ingresses with the Hub platform.",
+		Buckets:   prometheus.DefBuckets,
+	})
+)
+
+func init() {
+	prometheus.MustRegister(
+		certificateExpiryTimestamp,
+		certificateRenewalsTotal,
+		edgeIngressRequestsPerSecond,
+		edgeIngressErrorRatio,
+		edgeIngressResponseTime,
+		edgeIngressSyncDuration,
+	)
+}
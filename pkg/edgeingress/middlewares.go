@@ -0,0 +1,285 @@
+/*
+Copyright (C) 2022 Traefik Labs
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package edgeingress
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	hubv1alpha1 "github.com/traefik/hub-agent-kubernetes/pkg/crd/api/hub/v1alpha1"
+	traefikv1alpha1 "github.com/traefik/hub-agent-kubernetes/pkg/crd/api/traefik/v1alpha1"
+	kerror "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// FwdAuthMiddlewares sets up the forwardAuth middlewares enforcing ACPs.
+type FwdAuthMiddlewares interface {
+	Setup(ctx context.Context, polName, namespace string) (string, error)
+}
+
+// setupMiddlewares makes sure the middlewares configured on the given EdgeIngress exist and returns them in the order
+// they must be applied: the maintenance response of paused EdgeIngresses first, then the IP allowlist, the ACPs,
+// the rate limit, the compression and the headers.
+func (w *Watcher) setupMiddlewares(ctx context.Context, edgeIng *hubv1alpha1.EdgeIngress) ([]traefikv1alpha1.MiddlewareRef, error) {
+	var refs []traefikv1alpha1.MiddlewareRef
+
+	ref, err := w.syncMaintenanceMiddleware(ctx, edgeIng)
+	if err != nil {
+		return nil, fmt.Errorf("sync maintenance middleware: %w", err)
+	}
+	if ref != nil {
+		refs = append(refs, *ref)
+	}
+
+	var ipWhiteList *traefikv1alpha1.MiddlewareSpec
+	if len(edgeIng.Spec.AllowedSourceIPs) > 0 {
+		ipWhiteList = &traefikv1alpha1.MiddlewareSpec{
+			IPWhiteList: &traefikv1alpha1.IPWhiteList{SourceRange: edgeIng.Spec.AllowedSourceIPs},
+		}
+	}
+
+	ref, err = w.syncMiddleware(ctx, edgeIng, edgeIng.Name+"-ip-allowlist", ipWhiteList)
+	if err != nil {
+		return nil, fmt.Errorf("sync IP allowlist middleware: %w", err)
+	}
+	if ref != nil {
+		refs = append(refs, *ref)
+	}
+
+	for _, policy := range edgeIng.Spec.ACPs {
+		if w.config.FwdAuthMiddlewares == nil {
+			return nil, errors.New("no forwardAuth middlewares configured")
+		}
+
+		name, err := w.config.FwdAuthMiddlewares.Setup(ctx, policy.Name, edgeIng.Namespace)
+		if err != nil {
+			return nil, fmt.Errorf("setup ACP %q: %w", policy.Name, err)
+		}
+
+		refs = append(refs, traefikv1alpha1.MiddlewareRef{Name: name, Namespace: edgeIng.Namespace})
+	}
+
+	mdlwrs := edgeIng.Spec.Middlewares
+	if mdlwrs == nil {
+		mdlwrs = &hubv1alpha1.EdgeIngressMiddlewares{}
+	}
+
+	var rateLimit *traefikv1alpha1.MiddlewareSpec
+	if mdlwrs.RateLimit != nil {
+		rateLimit = &traefikv1alpha1.MiddlewareSpec{
+			RateLimit: &traefikv1alpha1.RateLimit{Average: mdlwrs.RateLimit.Average},
+		}
+		if mdlwrs.RateLimit.Burst > 0 {
+			burst := mdlwrs.RateLimit.Burst
+			rateLimit.RateLimit.Burst = &burst
+		}
+	}
+
+	ref, err = w.syncMiddleware(ctx, edgeIng, edgeIng.Name+"-rate-limit", rateLimit)
+	if err != nil {
+		return nil, fmt.Errorf("sync rate limit middleware: %w", err)
+	}
+	if ref != nil {
+		refs = append(refs, *ref)
+	}
+
+	ref, err = w.syncMiddleware(ctx, edgeIng, edgeIng.Name+"-compress", buildCompress(mdlwrs))
+	if err != nil {
+		return nil, fmt.Errorf("sync compress middleware: %w", err)
+	}
+	if ref != nil {
+		refs = append(refs, *ref)
+	}
+
+	ref, err = w.syncMiddleware(ctx, edgeIng, edgeIng.Name+"-headers", buildHeaders(edgeIng.Spec.Headers))
+	if err != nil {
+		return nil, fmt.Errorf("sync headers middleware: %w", err)
+	}
+	if ref != nil {
+		refs = append(refs, *ref)
+	}
+
+	return refs, nil
+}
+
+// compressedContentTypes are the content types of responses which are already compressed, and are not worth
+// compressing again.
+var compressedContentTypes = []string{
+	"application/gzip",
+	"application/x-7z-compressed",
+	"application/x-bzip2",
+	"application/x-gzip",
+	"application/x-rar-compressed",
+	"application/x-xz",
+	"application/zip",
+	"application/zstd",
+	"audio/aac",
+	"audio/mpeg",
+	"audio/ogg",
+	"font/woff",
+	"font/woff2",
+	"image/avif",
+	"image/gif",
+	"image/jpeg",
+	"image/png",
+	"image/webp",
+	"video/mp4",
+	"video/mpeg",
+	"video/webm",
+}
+
+// buildCompress builds the spec of the compress middleware, if compression is enabled. Traefik only compresses
+// responses for clients accepting it, and leaves responses already encoded by the service untouched.
+func buildCompress(mdlwrs *hubv1alpha1.EdgeIngressMiddlewares) *traefikv1alpha1.MiddlewareSpec {
+	if mdlwrs.Compress == nil {
+		return nil
+	}
+
+	compress := &traefikv1alpha1.Compress{
+		ExcludedContentTypes: append([]string{}, compressedContentTypes...),
+		MinResponseBodyBytes: mdlwrs.Compress.MinResponseBodyBytes,
+	}
+
+	excluded := make(map[string]struct{}, len(compressedContentTypes))
+	for _, contentType := range compressedContentTypes {
+		excluded[contentType] = struct{}{}
+	}
+
+	for _, contentType := range mdlwrs.Compress.ExcludedContentTypes {
+		contentType = strings.ToLower(contentType)
+		if _, ok := excluded[contentType]; ok {
+			continue
+		}
+
+		excluded[contentType] = struct{}{}
+		compress.ExcludedContentTypes = append(compress.ExcludedContentTypes, contentType)
+	}
+
+	return &traefikv1alpha1.MiddlewareSpec{Compress: compress}
+}
+
+// buildHeaders builds the spec of the headers middleware applying the given rules. Traefik removes the headers
+// configured with an empty value.
+func buildHeaders(headers *hubv1alpha1.EdgeIngressHeaders) *traefikv1alpha1.MiddlewareSpec {
+	if headers == nil {
+		return nil
+	}
+
+	reqHeaders := buildCustomHeaders(headers.Request)
+	respHeaders := buildCustomHeaders(headers.Response)
+	if len(reqHeaders) == 0 && len(respHeaders) == 0 {
+		return nil
+	}
+
+	return &traefikv1alpha1.MiddlewareSpec{
+		Headers: &traefikv1alpha1.Headers{
+			CustomRequestHeaders:  reqHeaders,
+			CustomResponseHeaders: respHeaders,
+		},
+	}
+}
+
+func buildCustomHeaders(rules *hubv1alpha1.EdgeIngressHeaderRules) map[string]string {
+	if rules == nil || len(rules.Set)+len(rules.Remove) == 0 {
+		return nil
+	}
+
+	headers := make(map[string]string, len(rules.Set)+len(rules.Remove))
+	for name, value := range rules.Set {
+		headers[name] = value
+	}
+	for _, name := range rules.Remove {
+		headers[name] = ""
+	}
+
+	return headers
+}
+
+// syncMiddleware creates or updates the middleware with the given spec. If the spec is nil, the middleware is deleted.
+func (w *Watcher) syncMiddleware(ctx context.Context, edgeIng *hubv1alpha1.EdgeIngress, name string, spec *traefikv1alpha1.MiddlewareSpec) (*traefikv1alpha1.MiddlewareRef, error) {
+	if spec == nil {
+		err := w.traefikClientSet.Middlewares(edgeIng.Namespace).Delete(ctx, name, metav1.DeleteOptions{})
+		if err != nil && !kerror.IsNotFound(err) {
+			return nil, fmt.Errorf("delete middleware: %w", err)
+		}
+
+		return nil, nil
+	}
+
+	ref := &traefikv1alpha1.MiddlewareRef{Name: name, Namespace: edgeIng.Namespace}
+
+	mdlwr, err := w.traefikClientSet.Middlewares(edgeIng.Namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil && !kerror.IsNotFound(err) {
+		return nil, fmt.Errorf("get middleware: %w", err)
+	}
+
+	if kerror.IsNotFound(err) {
+		mdlwr = &traefikv1alpha1.Middleware{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: edgeIng.Namespace,
+				Labels: map[string]string{
+					"app.kubernetes.io/managed-by": "traefik-hub",
+				},
+				OwnerReferences: ownerReferences(edgeIng),
+			},
+			Spec: *spec,
+		}
+
+		if _, err = w.traefikClientSet.Middlewares(edgeIng.Namespace).Create(ctx, mdlwr, metav1.CreateOptions{}); err != nil {
+			return nil, fmt.Errorf("create middleware: %w", err)
+		}
+
+		log.Debug().
+			Str("name", mdlwr.Name).
+			Str("namespace", mdlwr.Namespace).
+			Msg("Middleware created")
+
+		return ref, nil
+	}
+
+	if reflect.DeepEqual(mdlwr.Spec, *spec) {
+		return ref, nil
+	}
+
+	mdlwr.Spec = *spec
+	if _, err = w.traefikClientSet.Middlewares(edgeIng.Namespace).Update(ctx, mdlwr, metav1.UpdateOptions{}); err != nil {
+		return nil, fmt.Errorf("update middleware: %w", err)
+	}
+
+	log.Debug().
+		Str("name", mdlwr.Name).
+		Str("namespace", mdlwr.Namespace).
+		Msg("Middleware updated")
+
+	return ref, nil
+}
+
+// routerMiddlewares returns the value of the router middlewares annotation referencing the given middlewares.
+func routerMiddlewares(refs []traefikv1alpha1.MiddlewareRef) string {
+	names := make([]string, 0, len(refs))
+	for _, ref := range refs {
+		names = append(names, ref.Namespace+"-"+ref.Name+"@kubernetescrd")
+	}
+
+	return strings.Join(names, ",")
+}
@@ -0,0 +1,158 @@
+/*
+Copyright (C) 2022 Traefik Labs
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package edgeingress
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	hubv1alpha1 "github.com/traefik/hub-agent-kubernetes/pkg/crd/api/hub/v1alpha1"
+	traefikv1alpha1 "github.com/traefik/hub-agent-kubernetes/pkg/crd/api/traefik/v1alpha1"
+	traefikkubemock "github.com/traefik/hub-agent-kubernetes/pkg/crd/generated/client/traefik/clientset/versioned/fake"
+	kerror "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type fwdAuthMiddlewaresFunc func(polName string) string
+
+func (f fwdAuthMiddlewaresFunc) Setup(_ context.Context, polName, _ string) (string, error) {
+	return f(polName), nil
+}
+
+func TestWatcher_setupMiddlewares(t *testing.T) {
+	ctx := context.Background()
+
+	staleCompress := &traefikv1alpha1.Middleware{
+		ObjectMeta: metav1.ObjectMeta{Name: "edge-ingress-compress", Namespace: "default"},
+		Spec:       traefikv1alpha1.MiddlewareSpec{Compress: &traefikv1alpha1.Compress{}},
+	}
+	traefikClientSet := traefikkubemock.NewSimpleClientset(staleCompress)
+
+	w := &Watcher{
+		config: WatcherConfig{
+			FwdAuthMiddlewares: fwdAuthMiddlewaresFunc(func(polName string) string { return "zz-" + polName }),
+		},
+		traefikClientSet: traefikClientSet.TraefikV1alpha1(),
+	}
+
+	edgeIng := &hubv1alpha1.EdgeIngress{
+		ObjectMeta: metav1.ObjectMeta{Name: "edge-ingress", Namespace: "default", UID: "uid"},
+		Spec: hubv1alpha1.EdgeIngressSpec{
+			AllowedSourceIPs: []string{"10.0.0.0/8", "192.168.1.1"},
+			ACPs:             []hubv1alpha1.EdgeIngressACP{{Name: "acp-2"}, {Name: "acp-1"}},
+			Middlewares: &hubv1alpha1.EdgeIngressMiddlewares{
+				RateLimit: &hubv1alpha1.EdgeIngressRateLimit{Average: 100, Burst: 50},
+			},
+			Headers: &hubv1alpha1.EdgeIngressHeaders{
+				Request: &hubv1alpha1.EdgeIngressHeaderRules{
+					Set:    map[string]string{"X-Forwarded-Env": "prod"},
+					Remove: []string{"X-Debug"},
+				},
+				Response: &hubv1alpha1.EdgeIngressHeaderRules{Remove: []string{"Server"}},
+			},
+		},
+	}
+
+	refs, err := w.setupMiddlewares(ctx, edgeIng)
+	require.NoError(t, err)
+
+	assert.Equal(t, []traefikv1alpha1.MiddlewareRef{
+		{Name: "edge-ingress-ip-allowlist", Namespace: "default"},
+		{Name: "zz-acp-2", Namespace: "default"},
+		{Name: "zz-acp-1", Namespace: "default"},
+		{Name: "edge-ingress-rate-limit", Namespace: "default"},
+		{Name: "edge-ingress-headers", Namespace: "default"},
+	}, refs)
+	assert.Equal(t, "default-edge-ingress-ip-allowlist@kubernetescrd,default-zz-acp-2@kubernetescrd,default-zz-acp-1@kubernetescrd,default-edge-ingress-rate-limit@kubernetescrd,default-edge-ingress-headers@kubernetescrd", routerMiddlewares(refs))
+
+	rateLimit, err := traefikClientSet.TraefikV1alpha1().Middlewares("default").Get(ctx, "edge-ingress-rate-limit", metav1.GetOptions{})
+	require.NoError(t, err)
+
+	burst := int64(50)
+	assert.Equal(t, traefikv1alpha1.MiddlewareSpec{
+		RateLimit: &traefikv1alpha1.RateLimit{Average: 100, Burst: &burst},
+	}, rateLimit.Spec)
+	assert.Equal(t, ownerReferences(edgeIng), rateLimit.OwnerReferences)
+
+	ipAllowList, err := traefikClientSet.TraefikV1alpha1().Middlewares("default").Get(ctx, "edge-ingress-ip-allowlist", metav1.GetOptions{})
+	require.NoError(t, err)
+
+	assert.Equal(t, traefikv1alpha1.MiddlewareSpec{
+		IPWhiteList: &traefikv1alpha1.IPWhiteList{SourceRange: []string{"10.0.0.0/8", "192.168.1.1"}},
+	}, ipAllowList.Spec)
+
+	headers, err := traefikClientSet.TraefikV1alpha1().Middlewares("default").Get(ctx, "edge-ingress-headers", metav1.GetOptions{})
+	require.NoError(t, err)
+
+	assert.Equal(t, traefikv1alpha1.MiddlewareSpec{
+		Headers: &traefikv1alpha1.Headers{
+			CustomRequestHeaders:  map[string]string{"X-Forwarded-Env": "prod", "X-Debug": ""},
+			CustomResponseHeaders: map[string]string{"Server": ""},
+		},
+	}, headers.Spec)
+
+	// The compress middleware is not configured anymore and must be removed.
+	_, err = traefikClientSet.TraefikV1alpha1().Middlewares("default").Get(ctx, "edge-ingress-compress", metav1.GetOptions{})
+	assert.True(t, kerror.IsNotFound(err))
+}
+
+func TestBuildCompress(t *testing.T) {
+	tests := []struct {
+		desc   string
+		mdlwrs *hubv1alpha1.EdgeIngressMiddlewares
+		want   *traefikv1alpha1.MiddlewareSpec
+	}{
+		{
+			desc:   "disabled",
+			mdlwrs: &hubv1alpha1.EdgeIngressMiddlewares{},
+		},
+		{
+			desc:   "enabled",
+			mdlwrs: &hubv1alpha1.EdgeIngressMiddlewares{Compress: &hubv1alpha1.EdgeIngressCompression{}},
+			want: &traefikv1alpha1.MiddlewareSpec{
+				Compress: &traefikv1alpha1.Compress{ExcludedContentTypes: compressedContentTypes},
+			},
+		},
+		{
+			desc: "configured",
+			mdlwrs: &hubv1alpha1.EdgeIngressMiddlewares{
+				Compress: &hubv1alpha1.EdgeIngressCompression{
+					ExcludedContentTypes: []string{"image/PNG", "application/vnd.custom+json"},
+					MinResponseBodyBytes: 2048,
+				},
+			},
+			want: &traefikv1alpha1.MiddlewareSpec{
+				Compress: &traefikv1alpha1.Compress{
+					ExcludedContentTypes: append(append([]string{}, compressedContentTypes...), "application/vnd.custom+json"),
+					MinResponseBodyBytes: 2048,
+				},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, test.want, buildCompress(test.mdlwrs))
+		})
+	}
+}
@@ -116,6 +116,10 @@ func (_c *platformClientGetCertificateCall) OnGetEdgeIngresses() *platformClient
 	return _c.Parent.OnGetEdgeIngresses()
 }
 
+func (_c *platformClientGetCertificateCall) OnDeleteEdgeIngress(namespace string, name string, lastKnownVersion string) *platformClientDeleteEdgeIngressCall {
+	return _c.Parent.OnDeleteEdgeIngress(namespace, name, lastKnownVersion)
+}
+
 func (_c *platformClientGetCertificateCall) OnGetCertificateRaw() *platformClientGetCertificateCall {
 	return _c.Parent.OnGetCertificateRaw()
 }
@@ -128,6 +132,10 @@ func (_c *platformClientGetCertificateCall) OnGetEdgeIngressesRaw() *platformCli
 	return _c.Parent.OnGetEdgeIngressesRaw()
 }
 
+func (_c *platformClientGetCertificateCall) OnDeleteEdgeIngressRaw(namespace interface{}, name interface{}, lastKnownVersion interface{}) *platformClientDeleteEdgeIngressCall {
+	return _c.Parent.OnDeleteEdgeIngressRaw(namespace, name, lastKnownVersion)
+}
+
 func (_m *platformClientMock) GetCertificateByDomains(_ context.Context, domains []string) (Certificate, error) {
 	_ret := _m.Called(domains)
 
@@ -224,6 +232,10 @@ func (_c *platformClientGetCertificateByDomainsCall) OnGetEdgeIngresses() *platf
 	return _c.Parent.OnGetEdgeIngresses()
 }
 
+func (_c *platformClientGetCertificateByDomainsCall) OnDeleteEdgeIngress(namespace string, name string, lastKnownVersion string) *platformClientDeleteEdgeIngressCall {
+	return _c.Parent.OnDeleteEdgeIngress(namespace, name, lastKnownVersion)
+}
+
 func (_c *platformClientGetCertificateByDomainsCall) OnGetCertificateRaw() *platformClientGetCertificateCall {
 	return _c.Parent.OnGetCertificateRaw()
 }
@@ -236,6 +248,10 @@ func (_c *platformClientGetCertificateByDomainsCall) OnGetEdgeIngressesRaw() *pl
 	return _c.Parent.OnGetEdgeIngressesRaw()
 }
 
+func (_c *platformClientGetCertificateByDomainsCall) OnDeleteEdgeIngressRaw(namespace interface{}, name interface{}, lastKnownVersion interface{}) *platformClientDeleteEdgeIngressCall {
+	return _c.Parent.OnDeleteEdgeIngressRaw(namespace, name, lastKnownVersion)
+}
+
 func (_m *platformClientMock) GetEdgeIngresses(_ context.Context) ([]EdgeIngress, error) {
 	_ret := _m.Called()
 
@@ -327,6 +343,10 @@ func (_c *platformClientGetEdgeIngressesCall) OnGetEdgeIngresses() *platformClie
 	return _c.Parent.OnGetEdgeIngresses()
 }
 
+func (_c *platformClientGetEdgeIngressesCall) OnDeleteEdgeIngress(namespace string, name string, lastKnownVersion string) *platformClientDeleteEdgeIngressCall {
+	return _c.Parent.OnDeleteEdgeIngress(namespace, name, lastKnownVersion)
+}
+
 func (_c *platformClientGetEdgeIngressesCall) OnGetCertificateRaw() *platformClientGetCertificateCall {
 	return _c.Parent.OnGetCertificateRaw()
 }
@@ -338,3 +358,124 @@ func (_c *platformClientGetEdgeIngressesCall) OnGetCertificateByDomainsRaw(domai
 func (_c *platformClientGetEdgeIngressesCall) OnGetEdgeIngressesRaw() *platformClientGetEdgeIngressesCall {
 	return _c.Parent.OnGetEdgeIngressesRaw()
 }
+
+func (_c *platformClientGetEdgeIngressesCall) OnDeleteEdgeIngressRaw(namespace interface{}, name interface{}, lastKnownVersion interface{}) *platformClientDeleteEdgeIngressCall {
+	return _c.Parent.OnDeleteEdgeIngressRaw(namespace, name, lastKnownVersion)
+}
+
+func (_m *platformClientMock) DeleteEdgeIngress(_ context.Context, namespace string, name string, lastKnownVersion string) error {
+	_ret := _m.Called(namespace, name, lastKnownVersion)
+
+	if _rf, ok := _ret.Get(0).(func(string, string, string) error); ok {
+		return _rf(namespace, name, lastKnownVersion)
+	}
+
+	_ra0 := _ret.Error(0)
+
+	return _ra0
+}
+
+func (_m *platformClientMock) OnDeleteEdgeIngress(namespace string, name string, lastKnownVersion string) *platformClientDeleteEdgeIngressCall {
+	return &platformClientDeleteEdgeIngressCall{Call: _m.Mock.On("DeleteEdgeIngress", namespace, name, lastKnownVersion), Parent: _m}
+}
+
+func (_m *platformClientMock) OnDeleteEdgeIngressRaw(namespace interface{}, name interface{}, lastKnownVersion interface{}) *platformClientDeleteEdgeIngressCall {
+	return &platformClientDeleteEdgeIngressCall{Call: _m.Mock.On("DeleteEdgeIngress", namespace, name, lastKnownVersion), Parent: _m}
+}
+
+type platformClientDeleteEdgeIngressCall struct {
+	*mock.Call
+	Parent *platformClientMock
+}
+
+func (_c *platformClientDeleteEdgeIngressCall) Panic(msg string) *platformClientDeleteEdgeIngressCall {
+	_c.Call = _c.Call.Panic(msg)
+	return _c
+}
+
+func (_c *platformClientDeleteEdgeIngressCall) Once() *platformClientDeleteEdgeIngressCall {
+	_c.Call = _c.Call.Once()
+	return _c
+}
+
+func (_c *platformClientDeleteEdgeIngressCall) Twice() *platformClientDeleteEdgeIngressCall {
+	_c.Call = _c.Call.Twice()
+	return _c
+}
+
+func (_c *platformClientDeleteEdgeIngressCall) Times(i int) *platformClientDeleteEdgeIngressCall {
+	_c.Call = _c.Call.Times(i)
+	return _c
+}
+
+func (_c *platformClientDeleteEdgeIngressCall) WaitUntil(w <-chan time.Time) *platformClientDeleteEdgeIngressCall {
+	_c.Call = _c.Call.WaitUntil(w)
+	return _c
+}
+
+func (_c *platformClientDeleteEdgeIngressCall) After(d time.Duration) *platformClientDeleteEdgeIngressCall {
+	_c.Call = _c.Call.After(d)
+	return _c
+}
+
+func (_c *platformClientDeleteEdgeIngressCall) Run(fn func(args mock.Arguments)) *platformClientDeleteEdgeIngressCall {
+	_c.Call = _c.Call.Run(fn)
+	return _c
+}
+
+func (_c *platformClientDeleteEdgeIngressCall) Maybe() *platformClientDeleteEdgeIngressCall {
+	_c.Call = _c.Call.Maybe()
+	return _c
+}
+
+func (_c *platformClientDeleteEdgeIngressCall) TypedReturns(a error) *platformClientDeleteEdgeIngressCall {
+	_c.Call = _c.Return(a)
+	return _c
+}
+
+func (_c *platformClientDeleteEdgeIngressCall) ReturnsFn(fn func(string, string, string) error) *platformClientDeleteEdgeIngressCall {
+	_c.Call = _c.Return(fn)
+	return _c
+}
+
+func (_c *platformClientDeleteEdgeIngressCall) TypedRun(fn func(string, string, string)) *platformClientDeleteEdgeIngressCall {
+	_c.Call = _c.Call.Run(func(args mock.Arguments) {
+		_namespace := args.String(0)
+		_name := args.String(1)
+		_lastKnownVersion := args.String(2)
+		fn(_namespace, _name, _lastKnownVersion)
+	})
+	return _c
+}
+
+func (_c *platformClientDeleteEdgeIngressCall) OnGetCertificate() *platformClientGetCertificateCall {
+	return _c.Parent.OnGetCertificate()
+}
+
+func (_c *platformClientDeleteEdgeIngressCall) OnGetCertificateByDomains(domains []string) *platformClientGetCertificateByDomainsCall {
+	return _c.Parent.OnGetCertificateByDomains(domains)
+}
+
+func (_c *platformClientDeleteEdgeIngressCall) OnGetEdgeIngresses() *platformClientGetEdgeIngressesCall {
+	return _c.Parent.OnGetEdgeIngresses()
+}
+
+func (_c *platformClientDeleteEdgeIngressCall) OnDeleteEdgeIngress(namespace string, name string, lastKnownVersion string) *platformClientDeleteEdgeIngressCall {
+	return _c.Parent.OnDeleteEdgeIngress(namespace, name, lastKnownVersion)
+}
+
+func (_c *platformClientDeleteEdgeIngressCall) OnGetCertificateRaw() *platformClientGetCertificateCall {
+	return _c.Parent.OnGetCertificateRaw()
+}
+
+func (_c *platformClientDeleteEdgeIngressCall) OnGetCertificateByDomainsRaw(domains interface{}) *platformClientGetCertificateByDomainsCall {
+	return _c.Parent.OnGetCertificateByDomainsRaw(domains)
+}
+
+func (_c *platformClientDeleteEdgeIngressCall) OnGetEdgeIngressesRaw() *platformClientGetEdgeIngressesCall {
+	return _c.Parent.OnGetEdgeIngressesRaw()
+}
+
+func (_c *platformClientDeleteEdgeIngressCall) OnDeleteEdgeIngressRaw(namespace interface{}, name interface{}, lastKnownVersion interface{}) *platformClientDeleteEdgeIngressCall {
+	return _c.Parent.OnDeleteEdgeIngressRaw(namespace, name, lastKnownVersion)
+}
@@ -0,0 +1,121 @@
+/*
+Copyright (C) 2022 Traefik Labs
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package edgeingress
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	hubv1alpha1 "github.com/traefik/hub-agent-kubernetes/pkg/crd/api/hub/v1alpha1"
+	traefikv1alpha1 "github.com/traefik/hub-agent-kubernetes/pkg/crd/api/traefik/v1alpha1"
+	kerror "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// syncRedirectRoute makes sure plain HTTP requests received for the given EdgeIngress are redirected to HTTPS when
+// requested. As Traefik routers either serve TLS or plain HTTP requests, the redirection is done by a dedicated
+// IngressRoute without TLS.
+func (w *Watcher) syncRedirectRoute(ctx context.Context, edgeIng *hubv1alpha1.EdgeIngress, customDomains []string) error {
+	name := edgeIng.Name + "-redirect-https"
+
+	if edgeIng.Spec.Mode == hubv1alpha1.EdgeIngressModeTCP || edgeIng.Spec.TLS == nil || !edgeIng.Spec.TLS.RedirectHTTP {
+		err := w.traefikClientSet.IngressRoutes(edgeIng.Namespace).Delete(ctx, name, metav1.DeleteOptions{})
+		if err != nil && !kerror.IsNotFound(err) {
+			return fmt.Errorf("delete redirect ingress route: %w", err)
+		}
+
+		if _, err = w.syncMiddleware(ctx, edgeIng, name, nil); err != nil {
+			return fmt.Errorf("sync redirect middleware: %w", err)
+		}
+
+		return nil
+	}
+
+	ref, err := w.syncMiddleware(ctx, edgeIng, name, &traefikv1alpha1.MiddlewareSpec{
+		RedirectScheme: &traefikv1alpha1.RedirectScheme{Scheme: "https", Permanent: true},
+	})
+	if err != nil {
+		return fmt.Errorf("sync redirect middleware: %w", err)
+	}
+
+	ingRoute, err := w.traefikClientSet.IngressRoutes(edgeIng.Namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil && !kerror.IsNotFound(err) {
+		return fmt.Errorf("get redirect ingress route: %w", err)
+	}
+
+	if kerror.IsNotFound(err) {
+		ingRoute = buildRedirectRoute(edgeIng, &traefikv1alpha1.IngressRoute{}, w.config.TraefikEntryPoint, customDomains, *ref)
+		_, err = w.traefikClientSet.IngressRoutes(edgeIng.Namespace).Create(ctx, ingRoute, metav1.CreateOptions{})
+		if err != nil {
+			return fmt.Errorf("create redirect ingress route: %w", err)
+		}
+
+		log.Debug().
+			Str("name", ingRoute.Name).
+			Str("namespace", ingRoute.Namespace).
+			Msg("Redirect IngressRoute created")
+
+		return nil
+	}
+
+	ingRoute = buildRedirectRoute(edgeIng, ingRoute, w.config.TraefikEntryPoint, customDomains, *ref)
+	_, err = w.traefikClientSet.IngressRoutes(edgeIng.Namespace).Update(ctx, ingRoute, metav1.UpdateOptions{})
+	if err != nil {
+		return fmt.Errorf("update redirect ingress route: %w", err)
+	}
+
+	log.Debug().
+		Str("name", ingRoute.Name).
+		Str("namespace", ingRoute.Namespace).
+		Msg("Redirect IngressRoute updated")
+
+	return nil
+}
+
+func buildRedirectRoute(edgeIng *hubv1alpha1.EdgeIngress, ingRoute *traefikv1alpha1.IngressRoute, entryPoint string, customDomains []string, redirect traefikv1alpha1.MiddlewareRef) *traefikv1alpha1.IngressRoute {
+	ingRoute.ObjectMeta = metav1.ObjectMeta{
+		Name:            redirect.Name,
+		Namespace:       edgeIng.Namespace,
+		ResourceVersion: ingRoute.ResourceVersion,
+		Annotations:     generatedAnnotations(edgeIng, nil),
+		Labels:          generatedLabels(edgeIng),
+		OwnerReferences: ownerReferences(edgeIng),
+	}
+
+	hosts := make([]string, 0, len(customDomains)+1)
+	for _, host := range append([]string{edgeIng.Status.Domain}, customDomains...) {
+		hosts = append(hosts, "`"+host+"`")
+	}
+
+	// The service is never reached as every request is redirected, but Traefik requires routes to have one.
+	ingRoute.Spec = traefikv1alpha1.IngressRouteSpec{
+		EntryPoints: routeEntryPoints(edgeIng, entryPoint),
+		Routes: []traefikv1alpha1.Route{
+			{
+				Match:       "Host(" + strings.Join(hosts, ",") + ")",
+				Kind:        "Rule",
+				Middlewares: []traefikv1alpha1.MiddlewareRef{redirect},
+				Services:    []traefikv1alpha1.Service{routeService(edgeIng)},
+			},
+		},
+	}
+
+	return ingRoute
+}
@@ -0,0 +1,58 @@
+/*
+Copyright (C) 2022 Traefik Labs
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package edgeingress
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	hubv1alpha1 "github.com/traefik/hub-agent-kubernetes/pkg/crd/api/hub/v1alpha1"
+	traefikv1alpha1 "github.com/traefik/hub-agent-kubernetes/pkg/crd/api/traefik/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func TestBuildRedirectRoute(t *testing.T) {
+	edgeIng := &hubv1alpha1.EdgeIngress{
+		ObjectMeta: metav1.ObjectMeta{Name: "whoami", Namespace: "default", UID: "uid"},
+		Spec: hubv1alpha1.EdgeIngressSpec{
+			Service:     hubv1alpha1.EdgeIngressService{Name: "whoami", Port: 80},
+			EntryPoints: []string{"web", "websecure"},
+			TLS:         &hubv1alpha1.EdgeIngressTLS{RedirectHTTP: true},
+		},
+		Status: hubv1alpha1.EdgeIngressStatus{Domain: "sad-bat-123.hub-traefik.io"},
+	}
+	redirect := traefikv1alpha1.MiddlewareRef{Name: "whoami-redirect-https", Namespace: "default"}
+
+	got := buildRedirectRoute(edgeIng, &traefikv1alpha1.IngressRoute{}, "traefikhub-tunl", []string{"whoami.example.com"}, redirect)
+
+	assert.Equal(t, "whoami-redirect-https", got.Name)
+	assert.Equal(t, traefikv1alpha1.IngressRouteSpec{
+		EntryPoints: []string{"web", "websecure"},
+		Routes: []traefikv1alpha1.Route{
+			{
+				Match:       "Host(`sad-bat-123.hub-traefik.io`,`whoami.example.com`)",
+				Kind:        "Rule",
+				Middlewares: []traefikv1alpha1.MiddlewareRef{redirect},
+				Services: []traefikv1alpha1.Service{
+					{LoadBalancerSpec: traefikv1alpha1.LoadBalancerSpec{Name: "whoami", Kind: "Service", Port: intstr.FromInt(80)}},
+				},
+			},
+		},
+	}, got.Spec)
+}
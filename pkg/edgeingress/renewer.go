@@ -0,0 +1,224 @@
+/*
+Copyright (C) 2022 Traefik Labs
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package edgeingress
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	corev1 "k8s.io/api/core/v1"
+	kerror "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clientset "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/record"
+)
+
+// WildcardCertificateGetter gets the wildcard certificate of the workspace from the platform.
+type WildcardCertificateGetter interface {
+	GetWildcardCertificate(ctx context.Context) (Certificate, error)
+}
+
+// CertRenewerConfig holds the CertRenewer configuration.
+type CertRenewerConfig struct {
+	AgentNamespace string
+	// Namespaces are additional namespaces the wildcard certificate Secret is replicated to.
+	Namespaces []string
+
+	// RenewBefore is how long before its expiry the wildcard certificate is renewed.
+	RenewBefore   time.Duration
+	CheckInterval time.Duration
+	RetryInterval time.Duration
+
+	// Recorder records events about renewals, attached to the wildcard certificate Secret.
+	Recorder record.EventRecorder
+}
+
+// CertRenewer renews the wildcard certificate ahead of its expiry.
+type CertRenewer struct {
+	client    WildcardCertificateGetter
+	clientSet clientset.Interface
+	config    CertRenewerConfig
+
+	now func() time.Time
+}
+
+// NewCertRenewer returns a new CertRenewer.
+func NewCertRenewer(client WildcardCertificateGetter, clientSet clientset.Interface, config CertRenewerConfig) *CertRenewer {
+	return &CertRenewer{
+		client:    client,
+		clientSet: clientSet,
+		config:    config,
+		now:       time.Now,
+	}
+}
+
+// Run runs the CertRenewer until the given context is done.
+func (r *CertRenewer) Run(ctx context.Context) {
+	for {
+		ctxRenew, cancel := context.WithTimeout(ctx, 20*time.Second)
+		next, err := r.renew(ctxRenew)
+		cancel()
+
+		if err != nil {
+			log.Error().Err(err).Msg("Unable to renew wildcard certificate")
+			next = r.config.RetryInterval
+		}
+
+		select {
+		case <-ctx.Done():
+			log.Info().Msg("Stopping certificate renewer")
+			return
+		case <-time.After(next):
+		}
+	}
+}
+
+// renew renews the wildcard certificate if it expires soon and returns when it must be checked again.
+func (r *CertRenewer) renew(ctx context.Context) (time.Duration, error) {
+	notAfter, err := r.currentNotAfter(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	renewAt := notAfter.Add(-r.config.RenewBefore)
+	if !notAfter.IsZero() && r.now().Before(renewAt) {
+		certificateExpiryTimestamp.Set(float64(notAfter.Unix()))
+
+		return minDuration(r.config.CheckInterval, renewAt.Sub(r.now())), nil
+	}
+
+	cert, err := r.client.GetWildcardCertificate(ctx)
+	if err != nil {
+		r.renewalFailed(fmt.Sprintf("Unable to get wildcard certificate from the platform: %v", err))
+		return 0, fmt.Errorf("get certificate: %w", err)
+	}
+
+	newNotAfter, err := certificateNotAfter(cert)
+	if err != nil {
+		r.renewalFailed(fmt.Sprintf("Invalid wildcard certificate received from the platform: %v", err))
+		return 0, fmt.Errorf("invalid certificate: %w", err)
+	}
+
+	if !notAfter.IsZero() && !newNotAfter.After(notAfter) {
+		r.renewalFailed(fmt.Sprintf("The platform has not renewed the wildcard certificate yet, it expires at %s", newNotAfter.Format(time.RFC3339)))
+		return 0, fmt.Errorf("certificate expiring at %s not renewed", newNotAfter.Format(time.RFC3339))
+	}
+
+	// The certificate has been validated beforehand so every namespace ends up with the same, valid, certificate.
+	var failed []string
+	for _, namespace := range r.namespaces() {
+		if err = upsertSecret(ctx, r.clientSet, cert, secretName, namespace); err != nil {
+			log.Error().Err(err).Str("namespace", namespace).Msg("Unable to update wildcard certificate Secret")
+			failed = append(failed, namespace)
+		}
+	}
+	if len(failed) > 0 {
+		r.renewalFailed(fmt.Sprintf("Unable to update the wildcard certificate Secret in namespaces %s", strings.Join(failed, ", ")))
+		return 0, fmt.Errorf("update secret in namespaces %q", failed)
+	}
+
+	certificateExpiryTimestamp.Set(float64(newNotAfter.Unix()))
+	certificateRenewalsTotal.WithLabelValues("success").Inc()
+	r.event(corev1.EventTypeNormal, "CertificateRenewed", fmt.Sprintf("Wildcard certificate renewed, it expires at %s", newNotAfter.Format(time.RFC3339)))
+
+	return minDuration(r.config.CheckInterval, newNotAfter.Add(-r.config.RenewBefore).Sub(r.now())), nil
+}
+
+// currentNotAfter returns the expiry date of the wildcard certificate stored in the agent namespace, or a zero time if
+// there is no valid certificate.
+func (r *CertRenewer) currentNotAfter(ctx context.Context) (time.Time, error) {
+	secret, err := r.clientSet.CoreV1().Secrets(r.config.AgentNamespace).Get(ctx, secretName, metav1.GetOptions{})
+	if kerror.IsNotFound(err) {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, fmt.Errorf("get secret: %w", err)
+	}
+
+	notAfter, err := certificateNotAfter(Certificate{
+		Certificate: secret.Data["tls.crt"],
+		PrivateKey:  secret.Data["tls.key"],
+	})
+	if err != nil {
+		log.Warn().Err(err).Msg("Invalid wildcard certificate Secret, renewing it")
+		return time.Time{}, nil
+	}
+
+	return notAfter, nil
+}
+
+func (r *CertRenewer) namespaces() []string {
+	namespaces := []string{r.config.AgentNamespace}
+	for _, namespace := range r.config.Namespaces {
+		if namespace != r.config.AgentNamespace {
+			namespaces = append(namespaces, namespace)
+		}
+	}
+
+	return namespaces
+}
+
+func (r *CertRenewer) renewalFailed(msg string) {
+	certificateRenewalsTotal.WithLabelValues("failure").Inc()
+	r.event(corev1.EventTypeWarning, "CertificateRenewalFailed", msg)
+}
+
+func (r *CertRenewer) event(eventType, reason, msg string) {
+	if r.config.Recorder == nil {
+		return
+	}
+
+	r.config.Recorder.Event(&corev1.ObjectReference{
+		APIVersion: "v1",
+		Kind:       "Secret",
+		Name:       secretName,
+		Namespace:  r.config.AgentNamespace,
+	}, eventType, reason, msg)
+}
+
+// certificateNotAfter makes sure the given certificate is valid and returns its expiry date.
+func certificateNotAfter(cert Certificate) (time.Time, error) {
+	keyPair, err := tls.X509KeyPair(cert.Certificate, cert.PrivateKey)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("parse key pair: %w", err)
+	}
+	if len(keyPair.Certificate) == 0 {
+		return time.Time{}, errors.New("no certificate")
+	}
+
+	leaf, err := x509.ParseCertificate(keyPair.Certificate[0])
+	if err != nil {
+		return time.Time{}, fmt.Errorf("parse certificate: %w", err)
+	}
+
+	return leaf.NotAfter, nil
+}
+
+func minDuration(a, b time.Duration) time.Duration {
+	if a < b {
+		return a
+	}
+
+	return b
+}
@@ -0,0 +1,177 @@
+/*
+Copyright (C) 2022 Traefik Labs
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package edgeingress
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubemock "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+)
+
+type wildcardCertificateGetterFunc func(ctx context.Context) (Certificate, error)
+
+func (f wildcardCertificateGetterFunc) GetWildcardCertificate(ctx context.Context) (Certificate, error) {
+	return f(ctx)
+}
+
+func TestCertRenewer_renew(t *testing.T) {
+	now := time.Date(2022, 6, 1, 0, 0, 0, 0, time.UTC)
+	expiringCert := generateCertificate(t, now.Add(10*24*time.Hour))
+	renewedCert := generateCertificate(t, now.Add(90*24*time.Hour))
+
+	tests := []struct {
+		desc         string
+		current      *Certificate
+		platformCert *Certificate
+		wantErr      bool
+		wantCert     []byte
+		wantNext     time.Duration
+		wantEvent    string
+	}{
+		{
+			desc:     "certificate not expiring soon",
+			current:  &renewedCert,
+			wantCert: renewedCert.Certificate,
+			wantNext: time.Hour,
+		},
+		{
+			desc:         "certificate expiring soon",
+			current:      &expiringCert,
+			platformCert: &renewedCert,
+			wantCert:     renewedCert.Certificate,
+			wantNext:     time.Hour,
+			wantEvent:    "Normal CertificateRenewed Wildcard certificate renewed, it expires at 2022-08-30T00:00:00Z",
+		},
+		{
+			desc:         "no certificate",
+			platformCert: &renewedCert,
+			wantCert:     renewedCert.Certificate,
+			wantNext:     time.Hour,
+			wantEvent:    "Normal CertificateRenewed Wildcard certificate renewed, it expires at 2022-08-30T00:00:00Z",
+		},
+		{
+			desc:         "certificate not renewed by the platform",
+			current:      &expiringCert,
+			platformCert: &expiringCert,
+			wantErr:      true,
+			wantCert:     expiringCert.Certificate,
+			wantEvent:    "Warning CertificateRenewalFailed The platform has not renewed the wildcard certificate yet, it expires at 2022-06-11T00:00:00Z",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			var objects []runtime.Object
+			if test.current != nil {
+				objects = append(objects, &corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{Name: secretName, Namespace: "hub-agent"},
+					Type:       corev1.SecretTypeTLS,
+					Data: map[string][]byte{
+						"tls.crt": test.current.Certificate,
+						"tls.key": test.current.PrivateKey,
+					},
+				})
+			}
+			clientSet := kubemock.NewSimpleClientset(objects...)
+
+			client := wildcardCertificateGetterFunc(func(_ context.Context) (Certificate, error) {
+				require.NotNil(t, test.platformCert, "unexpected call to the platform")
+				return *test.platformCert, nil
+			})
+
+			recorder := record.NewFakeRecorder(10)
+			renewer := NewCertRenewer(client, clientSet, CertRenewerConfig{
+				AgentNamespace: "hub-agent",
+				Namespaces:     []string{"hub-agent", "other"},
+				RenewBefore:    30 * 24 * time.Hour,
+				CheckInterval:  time.Hour,
+				RetryInterval:  time.Minute,
+				Recorder:       recorder,
+			})
+			renewer.now = func() time.Time { return now }
+
+			next, err := renewer.renew(context.Background())
+			if test.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, test.wantNext, next)
+			}
+
+			secret, err := clientSet.CoreV1().Secrets("hub-agent").Get(context.Background(), secretName, metav1.GetOptions{})
+			require.NoError(t, err)
+			assert.Equal(t, test.wantCert, secret.Data["tls.crt"])
+
+			if test.platformCert != nil && !test.wantErr {
+				secret, err = clientSet.CoreV1().Secrets("other").Get(context.Background(), secretName, metav1.GetOptions{})
+				require.NoError(t, err)
+				assert.Equal(t, test.wantCert, secret.Data["tls.crt"])
+			}
+
+			if test.wantEvent == "" {
+				assert.Empty(t, recorder.Events)
+				return
+			}
+			require.Len(t, recorder.Events, 1)
+			assert.Equal(t, test.wantEvent, <-recorder.Events)
+		})
+	}
+}
+
+func generateCertificate(t *testing.T, notAfter time.Time) Certificate {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "*.hub-traefik.io"},
+		DNSNames:     []string{"*.hub-traefik.io"},
+		NotBefore:    notAfter.Add(-90 * 24 * time.Hour),
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	return Certificate{
+		Certificate: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		PrivateKey:  pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}),
+	}
+}
@@ -0,0 +1,125 @@
+/*
+Copyright (C) 2022 Traefik Labs
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package edgeingress
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	hubv1alpha1 "github.com/traefik/hub-agent-kubernetes/pkg/crd/api/hub/v1alpha1"
+	traefikv1alpha1 "github.com/traefik/hub-agent-kubernetes/pkg/crd/api/traefik/v1alpha1"
+	kerror "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// upsertTCPRoute exposes an EdgeIngress in TCP mode. The tunnel forwards the TLS connection, which is terminated by
+// Traefik and routed on its SNI through an IngressRouteTCP.
+func (w *Watcher) upsertTCPRoute(ctx context.Context, edgeIng *hubv1alpha1.EdgeIngress, customDomains []string) error {
+	ingRoute, err := w.traefikClientSet.IngressRouteTCPs(edgeIng.Namespace).Get(ctx, edgeIng.Name, metav1.GetOptions{})
+	if err != nil && !kerror.IsNotFound(err) {
+		return fmt.Errorf("get ingress route TCP: %w", err)
+	}
+
+	if kerror.IsNotFound(err) {
+		ingRoute = buildIngressRouteTCP(edgeIng, &traefikv1alpha1.IngressRouteTCP{}, w.config.TraefikEntryPoint, customDomains)
+		_, err = w.traefikClientSet.IngressRouteTCPs(edgeIng.Namespace).Create(ctx, ingRoute, metav1.CreateOptions{})
+		if err != nil {
+			return fmt.Errorf("create ingress route TCP: %w", err)
+		}
+
+		log.Debug().
+			Str("name", ingRoute.Name).
+			Str("namespace", ingRoute.Namespace).
+			Msg("IngressRouteTCP created")
+	} else {
+		ingRoute = buildIngressRouteTCP(edgeIng, ingRoute, w.config.TraefikEntryPoint, customDomains)
+		_, err = w.traefikClientSet.IngressRouteTCPs(edgeIng.Namespace).Update(ctx, ingRoute, metav1.UpdateOptions{})
+		if err != nil {
+			return fmt.Errorf("update ingress route TCP: %w", err)
+		}
+
+		log.Debug().
+			Str("name", ingRoute.Name).
+			Str("namespace", ingRoute.Namespace).
+			Msg("IngressRouteTCP updated")
+	}
+
+	// The EdgeIngress may have been exposed in HTTP mode before.
+	err = w.clientSet.NetworkingV1().Ingresses(edgeIng.Namespace).Delete(ctx, edgeIng.Name, metav1.DeleteOptions{})
+	if err != nil && !kerror.IsNotFound(err) {
+		return fmt.Errorf("delete ingress: %w", err)
+	}
+
+	return w.deleteRoute(ctx, edgeIng)
+}
+
+// deleteTCPRoute removes the IngressRouteTCP generated by upsertTCPRoute, if any.
+func (w *Watcher) deleteTCPRoute(ctx context.Context, edgeIng *hubv1alpha1.EdgeIngress) error {
+	err := w.traefikClientSet.IngressRouteTCPs(edgeIng.Namespace).Delete(ctx, edgeIng.Name, metav1.DeleteOptions{})
+	if err != nil && !kerror.IsNotFound(err) {
+		return fmt.Errorf("delete ingress route TCP: %w", err)
+	}
+
+	return nil
+}
+
+func buildIngressRouteTCP(edgeIng *hubv1alpha1.EdgeIngress, ingRoute *traefikv1alpha1.IngressRouteTCP, entryPoint string, customDomains []string) *traefikv1alpha1.IngressRouteTCP {
+	ingRoute.ObjectMeta = metav1.ObjectMeta{
+		Name:            edgeIng.Name,
+		Namespace:       edgeIng.Namespace,
+		ResourceVersion: ingRoute.ResourceVersion,
+		Annotations:     generatedAnnotations(edgeIng, nil),
+		Labels:          generatedLabels(edgeIng),
+		OwnerReferences: ownerReferences(edgeIng),
+	}
+
+	hosts := make([]string, 0, len(customDomains)+1)
+	for _, host := range append([]string{edgeIng.Status.Domain}, customDomains...) {
+		hosts = append(hosts, "`"+host+"`")
+	}
+
+	// The hub domain is served with the wildcard certificate configured in the catch-all ingress.
+	tls := &traefikv1alpha1.TLSTCP{}
+	if len(customDomains) > 0 {
+		tls.SecretName = secretCustomDomainsName + "-" + edgeIng.Name
+	}
+	if edgeIng.Spec.TLS != nil && edgeIng.Spec.TLS.Options != "" {
+		tls.Options = &traefikv1alpha1.TLSOptionTCPRef{Name: edgeIng.Spec.TLS.Options, Namespace: edgeIng.Namespace}
+	}
+
+	ingRoute.Spec = traefikv1alpha1.IngressRouteTCPSpec{
+		EntryPoints: routeEntryPoints(edgeIng, entryPoint),
+		Routes: []traefikv1alpha1.RouteTCP{
+			{
+				Match: "HostSNI(" + strings.Join(hosts, ",") + ")",
+				Services: []traefikv1alpha1.ServiceTCP{
+					{
+						Name: edgeIng.Spec.Service.Name,
+						Port: intstr.FromInt(edgeIng.Spec.Service.Port),
+					},
+				},
+			},
+		},
+		TLS: tls,
+	}
+
+	return ingRoute
+}
@@ -0,0 +1,95 @@
+/*
+Copyright (C) 2022 Traefik Labs
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package edgeingress
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	hubv1alpha1 "github.com/traefik/hub-agent-kubernetes/pkg/crd/api/hub/v1alpha1"
+	traefikv1alpha1 "github.com/traefik/hub-agent-kubernetes/pkg/crd/api/traefik/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func TestBuildIngressRouteTCP(t *testing.T) {
+	tests := []struct {
+		desc          string
+		customDomains []string
+		wantMatch     string
+		wantTLS       *traefikv1alpha1.TLSTCP
+	}{
+		{
+			desc:      "hub domain only",
+			wantMatch: "HostSNI(`sad-bat-123.hub-traefik.io`)",
+			wantTLS:   &traefikv1alpha1.TLSTCP{},
+		},
+		{
+			desc:          "with custom domains",
+			customDomains: []string{"db.example.com"},
+			wantMatch:     "HostSNI(`sad-bat-123.hub-traefik.io`,`db.example.com`)",
+			wantTLS:       &traefikv1alpha1.TLSTCP{SecretName: "hub-certificate-custom-domains-postgres"},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			edgeIng := &hubv1alpha1.EdgeIngress{
+				ObjectMeta: metav1.ObjectMeta{Name: "postgres", Namespace: "default", UID: "uid"},
+				Spec: hubv1alpha1.EdgeIngressSpec{
+					Mode:    hubv1alpha1.EdgeIngressModeTCP,
+					Service: hubv1alpha1.EdgeIngressService{Name: "postgres", Port: 5432},
+				},
+				Status: hubv1alpha1.EdgeIngressStatus{Domain: "sad-bat-123.hub-traefik.io"},
+			}
+
+			got := buildIngressRouteTCP(edgeIng, &traefikv1alpha1.IngressRouteTCP{}, "traefikhub-tunl", test.customDomains)
+
+			assert.Equal(t, metav1.ObjectMeta{
+				Name:      "postgres",
+				Namespace: "default",
+				Labels: map[string]string{
+					"app.kubernetes.io/managed-by": "traefik-hub",
+				},
+				OwnerReferences: []metav1.OwnerReference{
+					{
+						APIVersion: "hub.traefik.io/v1alpha1",
+						Kind:       "EdgeIngress",
+						Name:       "postgres",
+						UID:        "uid",
+					},
+				},
+			}, got.ObjectMeta)
+			assert.Equal(t, traefikv1alpha1.IngressRouteTCPSpec{
+				EntryPoints: []string{"traefikhub-tunl"},
+				Routes: []traefikv1alpha1.RouteTCP{
+					{
+						Match: test.wantMatch,
+						Services: []traefikv1alpha1.ServiceTCP{
+							{Name: "postgres", Port: intstr.FromInt(5432)},
+						},
+					},
+				},
+				TLS: test.wantTLS,
+			}, got.Spec)
+		})
+	}
+}
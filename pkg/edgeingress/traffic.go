@@ -0,0 +1,165 @@
+/*
+Copyright (C) 2022 Traefik Labs
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package edgeingress
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	hubv1alpha1 "github.com/traefik/hub-agent-kubernetes/pkg/crd/api/hub/v1alpha1"
+	hubclientset "github.com/traefik/hub-agent-kubernetes/pkg/crd/generated/client/hub/clientset/versioned"
+	"github.com/traefik/hub-agent-kubernetes/pkg/metrics"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// TrafficView gives access to the traffic metrics scraped from the ingress controller.
+type TrafficView interface {
+	FindByEdgeIngress(table, edgeIngress string, from, to time.Time) metrics.DataPoints
+}
+
+// TrafficReporterConfig holds the TrafficReporter configuration.
+type TrafficReporterConfig struct {
+	// Window is the period over which the traffic is summarized.
+	Window   time.Duration
+	Interval time.Duration
+
+	// Namespaces restricts the report to the EdgeIngresses of the given namespaces, listed one at a time. All
+	// namespaces are listed at once when empty.
+	Namespaces []string
+}
+
+// TrafficReporter reports the traffic received by EdgeIngresses in their status and in the agent metrics.
+type TrafficReporter struct {
+	view         TrafficView
+	hubClientSet hubclientset.Interface
+	config       TrafficReporterConfig
+
+	now func() time.Time
+}
+
+// NewTrafficReporter returns a new TrafficReporter.
+func NewTrafficReporter(view TrafficView, hubClientSet hubclientset.Interface, config TrafficReporterConfig) *TrafficReporter {
+	return &TrafficReporter{
+		view:         view,
+		hubClientSet: hubClientSet,
+		config:       config,
+		now:          time.Now,
+	}
+}
+
+// Run runs the TrafficReporter until the given context is done.
+func (r *TrafficReporter) Run(ctx context.Context) {
+	t := time.NewTicker(r.config.Interval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Info().Msg("Stopping EdgeIngress traffic reporter")
+			return
+
+		case <-t.C:
+			ctxReport, cancel := context.WithTimeout(ctx, 20*time.Second)
+			if err := r.report(ctxReport); err != nil {
+				log.Error().Err(err).Msg("Unable to report EdgeIngress traffic")
+			}
+			cancel()
+		}
+	}
+}
+
+func (r *TrafficReporter) listEdgeIngresses(ctx context.Context) ([]hubv1alpha1.EdgeIngress, error) {
+	namespaces := r.config.Namespaces
+	if len(namespaces) == 0 {
+		namespaces = []string{metav1.NamespaceAll}
+	}
+
+	var edgeIngs []hubv1alpha1.EdgeIngress
+	for _, namespace := range namespaces {
+		list, err := r.hubClientSet.HubV1alpha1().EdgeIngresses(namespace).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return nil, err
+		}
+
+		edgeIngs = append(edgeIngs, list.Items...)
+	}
+
+	return edgeIngs, nil
+}
+
+func (r *TrafficReporter) report(ctx context.Context) error {
+	edgeIngs, err := r.listEdgeIngresses(ctx)
+	if err != nil {
+		return fmt.Errorf("list edge ingresses: %w", err)
+	}
+
+	// EdgeIngresses may have been deleted since the last report.
+	edgeIngressRequestsPerSecond.Reset()
+	edgeIngressErrorRatio.Reset()
+	edgeIngressResponseTime.Reset()
+
+	now := r.now()
+	for _, item := range edgeIngs {
+		edgeIng := item
+
+		// Metrics are attributed to the routes generated for EdgeIngresses, which are named after them.
+		point := r.view.FindByEdgeIngress("1m", edgeIng.Name+"@"+edgeIng.Namespace, now.Add(-r.config.Window), now).Aggregate()
+
+		edgeIngressRequestsPerSecond.WithLabelValues(edgeIng.Namespace, edgeIng.Name).Set(point.ReqPerS)
+		edgeIngressErrorRatio.WithLabelValues(edgeIng.Namespace, edgeIng.Name, "server").Set(point.RequestErrPercent)
+		edgeIngressErrorRatio.WithLabelValues(edgeIng.Namespace, edgeIng.Name, "client").Set(point.RequestClientErrPercent)
+		edgeIngressResponseTime.WithLabelValues(edgeIng.Namespace, edgeIng.Name).Set(point.AvgResponseTime)
+
+		if err = r.updateStatus(ctx, &edgeIng, point, now); err != nil {
+			log.Error().Err(err).
+				Str("name", edgeIng.Name).
+				Str("namespace", edgeIng.Namespace).
+				Msg("Unable to update EdgeIngress traffic")
+		}
+	}
+
+	return nil
+}
+
+func (r *TrafficReporter) updateStatus(ctx context.Context, edgeIng *hubv1alpha1.EdgeIngress, point metrics.DataPoint, now time.Time) error {
+	traffic := &hubv1alpha1.EdgeIngressTraffic{
+		Window:              metav1.Duration{Duration: r.config.Window},
+		Requests:            point.Requests,
+		ServerErrors:        point.RequestErrs,
+		ClientErrors:        point.RequestClientErrs,
+		AverageResponseTime: metav1.Duration{Duration: time.Duration(point.AvgResponseTime * float64(time.Second)).Round(time.Millisecond)},
+	}
+
+	// Avoid updating EdgeIngresses when their traffic hasn't changed.
+	if current := edgeIng.Status.Traffic; current != nil {
+		traffic.UpdatedAt = current.UpdatedAt
+		if *current == *traffic {
+			return nil
+		}
+	}
+	traffic.UpdatedAt = metav1.NewTime(now)
+
+	edgeIng.Status.Traffic = traffic
+	if _, err := r.hubClientSet.HubV1alpha1().EdgeIngresses(edgeIng.Namespace).UpdateStatus(ctx, edgeIng, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("update status: %w", err)
+	}
+
+	return nil
+}
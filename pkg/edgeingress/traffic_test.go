@@ -0,0 +1,95 @@
+/*
+Copyright (C) 2022 Traefik Labs
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package edgeingress
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	hubv1alpha1 "github.com/traefik/hub-agent-kubernetes/pkg/crd/api/hub/v1alpha1"
+	hubkubemock "github.com/traefik/hub-agent-kubernetes/pkg/crd/generated/client/hub/clientset/versioned/fake"
+	"github.com/traefik/hub-agent-kubernetes/pkg/metrics"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type trafficViewFunc func(edgeIngress string, from, to time.Time) metrics.DataPoints
+
+func (f trafficViewFunc) FindByEdgeIngress(_, edgeIngress string, from, to time.Time) metrics.DataPoints {
+	return f(edgeIngress, from, to)
+}
+
+func TestTrafficReporter_report(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2022, 1, 1, 8, 0, 0, 0, time.UTC)
+
+	hubClientSet := hubkubemock.NewSimpleClientset(
+		&hubv1alpha1.EdgeIngress{ObjectMeta: metav1.ObjectMeta{Name: "whoami", Namespace: "default"}},
+		&hubv1alpha1.EdgeIngress{ObjectMeta: metav1.ObjectMeta{Name: "idle", Namespace: "default"}},
+	)
+
+	view := trafficViewFunc(func(edgeIngress string, from, to time.Time) metrics.DataPoints {
+		assert.Equal(t, now.Add(-10*time.Minute), from)
+		assert.Equal(t, now, to)
+
+		if edgeIngress != "whoami@default" {
+			return nil
+		}
+
+		return metrics.DataPoints{
+			{Seconds: 60, Requests: 100, RequestErrs: 2, RequestClientErrs: 10, ResponseTimeSum: 5, ResponseTimeCount: 100},
+			{Seconds: 60, Requests: 50, RequestErrs: 1, RequestClientErrs: 5, ResponseTimeSum: 2.5, ResponseTimeCount: 50},
+		}
+	})
+
+	r := NewTrafficReporter(view, hubClientSet, TrafficReporterConfig{Window: 10 * time.Minute, Interval: time.Minute})
+	r.now = func() time.Time { return now }
+
+	err := r.report(ctx)
+	require.NoError(t, err)
+
+	whoami, err := hubClientSet.HubV1alpha1().EdgeIngresses("default").Get(ctx, "whoami", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, &hubv1alpha1.EdgeIngressTraffic{
+		Window:              metav1.Duration{Duration: 10 * time.Minute},
+		Requests:            150,
+		ServerErrors:        3,
+		ClientErrors:        15,
+		AverageResponseTime: metav1.Duration{Duration: 50 * time.Millisecond},
+		UpdatedAt:           metav1.NewTime(now),
+	}, whoami.Status.Traffic)
+
+	idle, err := hubClientSet.HubV1alpha1().EdgeIngresses("default").Get(ctx, "idle", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, &hubv1alpha1.EdgeIngressTraffic{
+		Window:    metav1.Duration{Duration: 10 * time.Minute},
+		UpdatedAt: metav1.NewTime(now),
+	}, idle.Status.Traffic)
+
+	// The traffic hasn't changed, the status must be left untouched.
+	now = now.Add(time.Minute)
+
+	err = r.report(ctx)
+	require.NoError(t, err)
+
+	whoami, err = hubClientSet.HubV1alpha1().EdgeIngresses("default").Get(ctx, "whoami", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, metav1.NewTime(now.Add(-time.Minute)), whoami.Status.Traffic.UpdatedAt)
+}
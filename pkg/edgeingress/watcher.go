@@ -31,12 +31,14 @@ import (
 	hubclientset "github.com/traefik/hub-agent-kubernetes/pkg/crd/generated/client/hub/clientset/versioned"
 	hubinformer "github.com/traefik/hub-agent-kubernetes/pkg/crd/generated/client/hub/informers/externalversions"
 	"github.com/traefik/hub-agent-kubernetes/pkg/crd/generated/client/traefik/clientset/versioned/typed/traefik/v1alpha1"
+	"github.com/traefik/hub-agent-kubernetes/pkg/health"
 	corev1 "k8s.io/api/core/v1"
 	netv1 "k8s.io/api/networking/v1"
 	kerror "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	clientset "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/utils/pointer"
 )
 
@@ -51,6 +53,7 @@ type PlatformClient interface {
 	GetEdgeIngresses(ctx context.Context) ([]EdgeIngress, error)
 	GetWildcardCertificate(ctx context.Context) (Certificate, error)
 	GetCertificateByDomains(ctx context.Context, domains []string) (Certificate, error)
+	DeleteEdgeIngress(ctx context.Context, namespace, name, lastKnownVersion string) error
 }
 
 // WatcherConfig holds the watcher configuration.
@@ -62,6 +65,38 @@ type WatcherConfig struct {
 	EdgeIngressSyncInterval time.Duration
 	CertRetryInterval       time.Duration
 	CertSyncInterval        time.Duration
+
+	// Tunnels lists the tunnels opened for the cluster, to report the tunnel connectivity on EdgeIngresses.
+	Tunnels TunnelLister
+
+	// UseIngressRoute exposes EdgeIngresses through Traefik IngressRoutes instead of networking/v1 Ingresses.
+	UseIngressRoute bool
+
+	// FwdAuthMiddlewares sets up the middlewares enforcing the ACPs listed on EdgeIngresses.
+	FwdAuthMiddlewares FwdAuthMiddlewares
+
+	// AuthServerAddr is the address of the auth server, which serves the maintenance response of paused EdgeIngresses.
+	AuthServerAddr string
+
+	// Recorder records events about the reconciliation of EdgeIngresses, attached to them.
+	Recorder record.EventRecorder
+
+	// Fallback exposes EdgeIngresses locally while the platform or the tunnel is unavailable.
+	Fallback FallbackConfig
+
+	// Namespaces restricts the reconciliation to the EdgeIngresses of the given namespaces, which must include the agent
+	// namespace. All namespaces are reconciled if empty.
+	Namespaces []string
+
+	// Shard restricts the reconciliation to the namespaces owned by the agent replica, when the EdgeIngresses are
+	// reconciled by several replicas. The resources shared by all EdgeIngresses are reconciled by the replica owning
+	// the agent namespace. All namespaces are reconciled if nil.
+	Shard Shard
+}
+
+// Shard tells the namespaces owned by an agent replica.
+type Shard interface {
+	Owns(namespace string) bool
 }
 
 // Watcher watches hub EdgeIngresses and sync them with the cluster.
@@ -73,6 +108,18 @@ type Watcher struct {
 	hubInformer      hubinformer.SharedInformerFactory
 	clientSet        clientset.Interface
 	traefikClientSet v1alpha1.TraefikV1alpha1Interface
+
+	tunnelCondition *metav1.Condition
+	tunnelSummary   *hubv1alpha1.EdgeIngressTunnel
+
+	drifts *drifts
+
+	unavailableSince time.Time
+	fallbackActive   bool
+
+	ready health.Status
+
+	now func() time.Time
 }
 
 // NewWatcher returns a new Watcher.
@@ -85,9 +132,18 @@ func NewWatcher(client PlatformClient, hubClientSet hubclientset.Interface, clie
 		hubInformer:      hubInformer,
 		clientSet:        clientSet,
 		traefikClientSet: traefikClientSet,
+
+		drifts: newDrifts(),
+
+		now: time.Now,
 	}, nil
 }
 
+// Ready reports whether the watcher is able to synchronize edge ingresses with the platform.
+func (w *Watcher) Ready(ctx context.Context) error {
+	return w.ready.Check(ctx)
+}
+
 // Run runs Watcher.
 func (w *Watcher) Run(ctx context.Context) {
 	t := time.NewTicker(w.config.EdgeIngressSyncInterval)
@@ -95,7 +151,9 @@ func (w *Watcher) Run(ctx context.Context) {
 
 	certSyncInterval := time.After(w.config.CertSyncInterval)
 	ctxSync, cancel := context.WithTimeout(ctx, 20*time.Second)
-	if err := w.syncCertificate(ctxSync); err != nil {
+	if !w.owns(w.config.AgentNamespace) {
+		certSyncInterval = time.After(w.config.CertRetryInterval)
+	} else if err := w.syncCertificate(ctxSync); err != nil {
 		log.Error().Err(err).Msg("Unable to synchronize certificate with platform")
 		certSyncInterval = time.After(w.config.CertRetryInterval)
 	}
@@ -108,11 +166,25 @@ func (w *Watcher) Run(ctx context.Context) {
 			return
 
 		case <-t.C:
+			start := time.Now()
 			ctxSync, cancel = context.WithTimeout(ctx, 20*time.Second)
 			w.syncEdgeIngresses(ctxSync)
+			w.syncFallback(ctxSync)
+			cancel()
+			edgeIngressSyncDuration.Observe(time.Since(start).Seconds())
+
+		case <-w.drifts.notification:
+			ctxSync, cancel = context.WithTimeout(ctx, 20*time.Second)
+			w.revertDrifts(ctxSync)
 			cancel()
 
 		case <-certSyncInterval:
+			// Ownership may change over time, it's checked again at the next retry.
+			if !w.owns(w.config.AgentNamespace) {
+				certSyncInterval = time.After(w.config.CertRetryInterval)
+				continue
+			}
+
 			ctxSync, cancel = context.WithTimeout(ctx, 20*time.Second)
 			if err := w.syncCertificate(ctxSync); err != nil {
 				log.Error().Err(err).Msg("Unable to synchronize certificate with platform")
@@ -126,13 +198,22 @@ func (w *Watcher) Run(ctx context.Context) {
 	}
 }
 
+// owns reports whether the EdgeIngresses of the given namespace are reconciled by the agent replica.
+func (w *Watcher) owns(namespace string) bool {
+	if len(w.config.Namespaces) > 0 && !contains(w.config.Namespaces, namespace) {
+		return false
+	}
+
+	return w.config.Shard == nil || w.config.Shard.Owns(namespace)
+}
+
 func (w *Watcher) syncCertificate(ctx context.Context) error {
 	certificate, err := w.client.GetWildcardCertificate(ctx)
 	if err != nil {
 		return fmt.Errorf("get certificate: %w", err)
 	}
 
-	if err = w.upsertSecret(ctx, certificate, secretName, w.config.AgentNamespace); err != nil {
+	if err = upsertSecret(ctx, w.clientSet, certificate, secretName, w.config.AgentNamespace); err != nil {
 		return fmt.Errorf("upsert secret: %w", err)
 	}
 
@@ -143,29 +224,62 @@ func (w *Watcher) syncEdgeIngresses(ctx context.Context) {
 	platformEdgeIngresses, err := w.client.GetEdgeIngresses(ctx)
 	if err != nil {
 		log.Error().Err(err).Msg("Unable to fetch EdgeIngresses")
+		w.trackAvailability(false)
+		w.ready.Set(fmt.Errorf("fetch edge ingresses: %w", err))
 		return
 	}
 
 	clusterEdgeIngresses, err := w.hubInformer.Hub().V1alpha1().EdgeIngresses().Lister().List(labels.Everything())
 	if err != nil {
 		log.Error().Err(err).Msg("Unable to obtain EdgeIngresses")
+		w.ready.Set(fmt.Errorf("list edge ingresses: %w", err))
 		return
 	}
+	w.ready.SetReady()
+
+	w.updateTunnelCondition(ctx)
+	w.trackAvailability(w.tunnelCondition == nil || w.tunnelCondition.Status == metav1.ConditionTrue)
 
 	clusterEdgeIngressByID := map[string]*hubv1alpha1.EdgeIngress{}
+	terminating := map[string]struct{}{}
 	for _, edgeIng := range clusterEdgeIngresses {
+		if !w.owns(edgeIng.Namespace) {
+			continue
+		}
+
+		if edgeIng.DeletionTimestamp != nil {
+			terminating[edgeIng.Name+"@"+edgeIng.Namespace] = struct{}{}
+
+			if err := w.finalizeEdgeIngress(ctx, edgeIng.DeepCopy()); err != nil {
+				w.event(edgeIng, corev1.EventTypeWarning, reasonFinalizeFailed, err.Error())
+				log.Error().Err(err).
+					Str("name", edgeIng.Name).
+					Str("namespace", edgeIng.Namespace).
+					Msg("Unable to finalize EdgeIngress")
+			}
+			continue
+		}
+
 		clusterEdgeIngressByID[edgeIng.Name+"@"+edgeIng.Namespace] = edgeIng
 	}
 
 	for _, p := range platformEdgeIngresses {
 		platformEdgeIng := p
 
+		if !w.owns(platformEdgeIng.Namespace) {
+			continue
+		}
+
+		if _, ok := terminating[platformEdgeIng.Name+"@"+platformEdgeIng.Namespace]; ok {
+			continue
+		}
+
 		clusterEdgeIng, found := clusterEdgeIngressByID[platformEdgeIng.Name+"@"+platformEdgeIng.Namespace]
 		// We delete the policy from the map, since we use this map to delete unused policies.
 		delete(clusterEdgeIngressByID, platformEdgeIng.Name+"@"+platformEdgeIng.Namespace)
 
 		if !found {
-			if err := w.createEdgeIngress(ctx, &platformEdgeIng); err != nil {
+			if err := w.createOrCleanEdgeIngress(ctx, &platformEdgeIng); err != nil {
 				log.Error().Err(err).
 					Str("name", platformEdgeIng.Name).
 					Str("namespace", platformEdgeIng.Namespace).
@@ -174,11 +288,26 @@ func (w *Watcher) syncEdgeIngresses(ctx context.Context) {
 			continue
 		}
 
+		clusterEdgeIng, err = w.ensureFinalizer(ctx, clusterEdgeIng)
+		if err != nil {
+			log.Error().Err(err).
+				Str("name", platformEdgeIng.Name).
+				Str("namespace", platformEdgeIng.Namespace).
+				Msg("Unable to protect EdgeIngress from deletion")
+			continue
+		}
+
 		if platformEdgeIng.Version == clusterEdgeIng.Status.Version {
 			if clusterEdgeIng.Status.Connection == hubv1alpha1.EdgeIngressConnectionUp {
+				if err := w.refreshConditions(ctx, clusterEdgeIng); err != nil {
+					log.Error().Err(err).
+						Str("name", platformEdgeIng.Name).
+						Str("namespace", platformEdgeIng.Namespace).
+						Msg("Unable to refresh EdgeIngress conditions")
+				}
 				continue
 			}
-			if err := w.syncChildAndUpdateConnectionStatus(ctx, clusterEdgeIng, platformEdgeIng.CustomDomains); err != nil {
+			if err := w.syncChildAndUpdateConnectionStatus(ctx, clusterEdgeIng, platformEdgeIng.VerifiedCustomDomains()); err != nil {
 				log.Error().Err(err).
 					Str("name", platformEdgeIng.Name).
 					Str("namespace", platformEdgeIng.Namespace).
@@ -200,27 +329,99 @@ func (w *Watcher) syncEdgeIngresses(ctx context.Context) {
 	w.cleanEdgeIngresses(ctx, clusterEdgeIngressByID)
 }
 
-func (w *Watcher) syncChildAndUpdateConnectionStatus(ctx context.Context, edgeIngress *hubv1alpha1.EdgeIngress, customDomains []CustomDomain) error {
-	var customDomainsName []string
-	for _, customDomain := range customDomains {
-		if customDomain.Verified {
-			customDomainsName = append(customDomainsName, customDomain.Name)
-		}
+func (w *Watcher) syncChildAndUpdateConnectionStatus(ctx context.Context, edgeIngress *hubv1alpha1.EdgeIngress, customDomainsName []string) error {
+	if err := w.syncChild(ctx, edgeIngress, customDomainsName); err != nil {
+		w.event(edgeIngress, corev1.EventTypeWarning, reasonSyncFailed, err.Error())
+		return err
 	}
 
+	return nil
+}
+
+func (w *Watcher) syncChild(ctx context.Context, edgeIngress *hubv1alpha1.EdgeIngress, customDomainsName []string) error {
 	if len(customDomainsName) > 0 {
 		cert, err := w.client.GetCertificateByDomains(ctx, customDomainsName)
 		if err != nil {
 			return fmt.Errorf("get certificate by domains %q: %w", strings.Join(customDomainsName, ","), err)
 		}
 
-		if err := w.upsertSecret(ctx, cert, secretCustomDomainsName+"-"+edgeIngress.Name, edgeIngress.Namespace); err != nil {
+		if err := upsertSecret(ctx, w.clientSet, cert, secretCustomDomainsName+"-"+edgeIngress.Name, edgeIngress.Namespace); err != nil {
 			return fmt.Errorf("upsert secret: %w", err)
 		}
+
+		w.event(edgeIngress, corev1.EventTypeNormal, reasonCertificateFetched, "Certificate fetched for "+strings.Join(customDomainsName, ", "))
+	} else {
+		err := w.clientSet.CoreV1().Secrets(edgeIngress.Namespace).Delete(ctx, secretCustomDomainsName+"-"+edgeIngress.Name, metav1.DeleteOptions{})
+		if err != nil && !kerror.IsNotFound(err) {
+			return fmt.Errorf("delete custom domains secret: %w", err)
+		}
 	}
 
-	if err := w.upsertIngress(ctx, edgeIngress, customDomainsName); err != nil {
-		return fmt.Errorf("upsert ingress: %w", err)
+	if edgeIngress.Spec.Paused && removesRouteWhenPaused(edgeIngress) {
+		if err := w.deleteRoutes(ctx, edgeIngress); err != nil {
+			return fmt.Errorf("delete routes: %w", err)
+		}
+
+		if _, err := w.syncMiddleware(ctx, edgeIngress, edgeIngress.Name+"-maintenance", nil); err != nil {
+			return fmt.Errorf("delete maintenance middleware: %w", err)
+		}
+
+		w.event(edgeIngress, corev1.EventTypeNormal, reasonPaused, "Routes removed while the EdgeIngress is paused")
+
+		if err := w.setEdgeIngressConnectionStatusUP(ctx, edgeIngress); err != nil {
+			return fmt.Errorf("update edge ingress status: %w", err)
+		}
+
+		return nil
+	}
+
+	switch {
+	case edgeIngress.Spec.Mode == hubv1alpha1.EdgeIngressModeTCP:
+		if err := w.upsertTCPRoute(ctx, edgeIngress, customDomainsName); err != nil {
+			return fmt.Errorf("upsert TCP route: %w", err)
+		}
+
+		w.event(edgeIngress, corev1.EventTypeNormal, reasonIngressGenerated, "IngressRouteTCP "+edgeIngress.Name+" generated")
+	case w.config.UseIngressRoute || len(edgeIngress.Spec.Services) > 0 || edgeIngress.Spec.Sticky != nil || backendScheme(edgeIngress) != "":
+		middlewares, err := w.setupMiddlewares(ctx, edgeIngress)
+		if err != nil {
+			return fmt.Errorf("setup middlewares: %w", err)
+		}
+
+		if err = w.upsertRoute(ctx, edgeIngress, customDomainsName, middlewares); err != nil {
+			return fmt.Errorf("upsert route: %w", err)
+		}
+
+		w.event(edgeIngress, corev1.EventTypeNormal, reasonIngressGenerated, "IngressRoute "+edgeIngress.Name+" generated")
+		w.acpEvent(edgeIngress)
+
+		if err := w.deleteTCPRoute(ctx, edgeIngress); err != nil {
+			return fmt.Errorf("delete TCP route: %w", err)
+		}
+	default:
+		middlewares, err := w.setupMiddlewares(ctx, edgeIngress)
+		if err != nil {
+			return fmt.Errorf("setup middlewares: %w", err)
+		}
+
+		if err = w.upsertIngress(ctx, edgeIngress, customDomainsName, middlewares); err != nil {
+			return fmt.Errorf("upsert ingress: %w", err)
+		}
+
+		w.event(edgeIngress, corev1.EventTypeNormal, reasonIngressGenerated, "Ingress "+edgeIngress.Name+" generated")
+		w.acpEvent(edgeIngress)
+
+		if err := w.deleteRoute(ctx, edgeIngress); err != nil {
+			return fmt.Errorf("delete route: %w", err)
+		}
+
+		if err := w.deleteTCPRoute(ctx, edgeIngress); err != nil {
+			return fmt.Errorf("delete TCP route: %w", err)
+		}
+	}
+
+	if err := w.syncRedirectRoute(ctx, edgeIngress, customDomainsName); err != nil {
+		return fmt.Errorf("sync redirect route: %w", err)
 	}
 
 	if err := w.setEdgeIngressConnectionStatusUP(ctx, edgeIngress); err != nil {
@@ -230,14 +431,14 @@ func (w *Watcher) syncChildAndUpdateConnectionStatus(ctx context.Context, edgeIn
 	return nil
 }
 
-func (w *Watcher) upsertIngress(ctx context.Context, edgeIng *hubv1alpha1.EdgeIngress, customDomains []string) error {
+func (w *Watcher) upsertIngress(ctx context.Context, edgeIng *hubv1alpha1.EdgeIngress, customDomains []string, middlewares []traefikv1alpha1.MiddlewareRef) error {
 	ing, err := w.clientSet.NetworkingV1().Ingresses(edgeIng.Namespace).Get(ctx, edgeIng.Name, metav1.GetOptions{})
 	if err != nil && !kerror.IsNotFound(err) {
 		return fmt.Errorf("get ingress: %w", err)
 	}
 
 	if kerror.IsNotFound(err) {
-		ing = buildIngress(edgeIng, &netv1.Ingress{}, w.config.IngressClassName, w.config.TraefikEntryPoint, customDomains)
+		ing = buildIngress(edgeIng, &netv1.Ingress{}, w.config.IngressClassName, w.config.TraefikEntryPoint, customDomains, middlewares)
 		_, err = w.clientSet.NetworkingV1().Ingresses(edgeIng.Namespace).Create(ctx, ing, metav1.CreateOptions{})
 		if err != nil {
 			return fmt.Errorf("create ingress: %w", err)
@@ -251,7 +452,7 @@ func (w *Watcher) upsertIngress(ctx context.Context, edgeIng *hubv1alpha1.EdgeIn
 		return nil
 	}
 
-	ing = buildIngress(edgeIng, ing, w.config.IngressClassName, w.config.TraefikEntryPoint, customDomains)
+	ing = buildIngress(edgeIng, ing, w.config.IngressClassName, w.config.TraefikEntryPoint, customDomains, middlewares)
 	_, err = w.clientSet.NetworkingV1().Ingresses(edgeIng.Namespace).Update(ctx, ing, metav1.UpdateOptions{})
 	if err != nil {
 		return fmt.Errorf("update ingress: %w", err)
@@ -351,8 +552,8 @@ func (w *Watcher) createIngressCatchAll(ctx context.Context) error {
 	return nil
 }
 
-func (w *Watcher) upsertSecret(ctx context.Context, cert Certificate, name, namespace string) error {
-	secret, err := w.clientSet.CoreV1().Secrets(namespace).Get(ctx, name, metav1.GetOptions{})
+func upsertSecret(ctx context.Context, clientSet clientset.Interface, cert Certificate, name, namespace string) error {
+	secret, err := clientSet.CoreV1().Secrets(namespace).Get(ctx, name, metav1.GetOptions{})
 	if err != nil && !kerror.IsNotFound(err) {
 		return fmt.Errorf("get secret: %w", err)
 	}
@@ -365,6 +566,9 @@ func (w *Watcher) upsertSecret(ctx context.Context, cert Certificate, name, name
 				Annotations: map[string]string{
 					"app.kubernetes.io/managed-by": "traefik-hub",
 				},
+				Labels: map[string]string{
+					"app.kubernetes.io/managed-by": "traefik-hub",
+				},
 			},
 			Type: corev1.SecretTypeTLS,
 			Data: map[string][]byte{
@@ -373,7 +577,7 @@ func (w *Watcher) upsertSecret(ctx context.Context, cert Certificate, name, name
 			},
 		}
 
-		_, err = w.clientSet.CoreV1().Secrets(namespace).Create(ctx, secret, metav1.CreateOptions{})
+		_, err = clientSet.CoreV1().Secrets(namespace).Create(ctx, secret, metav1.CreateOptions{})
 		if err != nil {
 			return fmt.Errorf("create secret: %w", err)
 		}
@@ -386,15 +590,21 @@ func (w *Watcher) upsertSecret(ctx context.Context, cert Certificate, name, name
 		return nil
 	}
 
-	if bytes.Equal(secret.Data["tls.crt"], cert.Certificate) {
+	if bytes.Equal(secret.Data["tls.crt"], cert.Certificate) && bytes.Equal(secret.Data["tls.key"], cert.PrivateKey) &&
+		secret.Labels["app.kubernetes.io/managed-by"] == "traefik-hub" {
 		return nil
 	}
 
+	// Secrets created by previous versions of the agent are not labeled, which prevents watching them.
+	if secret.Labels == nil {
+		secret.Labels = make(map[string]string)
+	}
+	secret.Labels["app.kubernetes.io/managed-by"] = "traefik-hub"
 	secret.Data = map[string][]byte{
 		"tls.crt": cert.Certificate,
 		"tls.key": cert.PrivateKey,
 	}
-	_, err = w.clientSet.CoreV1().Secrets(namespace).Update(ctx, secret, metav1.UpdateOptions{})
+	_, err = clientSet.CoreV1().Secrets(namespace).Update(ctx, secret, metav1.UpdateOptions{})
 	if err != nil {
 		return fmt.Errorf("update secret: %w", err)
 	}
@@ -409,6 +619,7 @@ func (w *Watcher) upsertSecret(ctx context.Context, cert Certificate, name, name
 
 func (w *Watcher) setEdgeIngressConnectionStatusUP(ctx context.Context, edgeIngress *hubv1alpha1.EdgeIngress) error {
 	edgeIngress.Status.Connection = hubv1alpha1.EdgeIngressConnectionUp
+	w.setConditions(ctx, edgeIngress)
 
 	ctxUpdate, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
@@ -426,11 +637,27 @@ func (w *Watcher) setEdgeIngressConnectionStatusUP(ctx context.Context, edgeIngr
 	return nil
 }
 
+// createOrCleanEdgeIngress creates the EdgeIngress of the given platform edge ingress, unless its namespace is gone,
+// in which case the edge ingress is orphaned and deleted from the platform.
+func (w *Watcher) createOrCleanEdgeIngress(ctx context.Context, edgeIng *EdgeIngress) error {
+	orphan, err := w.isOrphan(ctx, edgeIng)
+	if err != nil {
+		return fmt.Errorf("check orphan: %w", err)
+	}
+
+	if orphan {
+		return w.cleanOrphan(ctx, edgeIng)
+	}
+
+	return w.createEdgeIngress(ctx, edgeIng)
+}
+
 func (w *Watcher) createEdgeIngress(ctx context.Context, edgeIng *EdgeIngress) error {
 	obj, err := edgeIng.Resource()
 	if err != nil {
 		return fmt.Errorf("build EdgeIngress resource: %w", err)
 	}
+	obj.Finalizers = []string{Finalizer}
 
 	obj, err = w.hubClientSet.HubV1alpha1().EdgeIngresses(obj.Namespace).Create(ctx, obj, metav1.CreateOptions{})
 	if err != nil {
@@ -442,7 +669,9 @@ func (w *Watcher) createEdgeIngress(ctx context.Context, edgeIng *EdgeIngress) e
 		Str("namespace", obj.Namespace).
 		Msg("EdgeIngress created")
 
-	return w.syncChildAndUpdateConnectionStatus(ctx, obj, edgeIng.CustomDomains)
+	w.event(obj, corev1.EventTypeNormal, reasonSynced, "Created from platform version "+edgeIng.Version)
+
+	return w.syncChildAndUpdateConnectionStatus(ctx, obj, edgeIng.VerifiedCustomDomains())
 }
 
 func (w *Watcher) updateEdgeIngress(ctx context.Context, oldEdgeIng *hubv1alpha1.EdgeIngress, newEdgeIng *EdgeIngress) error {
@@ -451,6 +680,10 @@ func (w *Watcher) updateEdgeIngress(ctx context.Context, oldEdgeIng *hubv1alpha1
 		return fmt.Errorf("build EdgeIngress resource: %w", err)
 	}
 
+	// The traffic and tunnel summaries are not known by the platform, they are maintained by the agent.
+	obj.Status.Traffic = oldEdgeIng.Status.Traffic
+	obj.Status.Tunnel = oldEdgeIng.Status.Tunnel
+
 	oldEdgeIng.Spec = obj.Spec
 	oldEdgeIng.Status = obj.Status
 
@@ -464,7 +697,9 @@ func (w *Watcher) updateEdgeIngress(ctx context.Context, oldEdgeIng *hubv1alpha1
 		Str("namespace", obj.Namespace).
 		Msg("EdgeIngress updated")
 
-	return w.syncChildAndUpdateConnectionStatus(ctx, obj, newEdgeIng.CustomDomains)
+	w.event(obj, corev1.EventTypeNormal, reasonSynced, "Updated to platform version "+newEdgeIng.Version)
+
+	return w.syncChildAndUpdateConnectionStatus(ctx, obj, newEdgeIng.VerifiedCustomDomains())
 }
 
 func (w *Watcher) cleanEdgeIngresses(ctx context.Context, edgeIngs map[string]*hubv1alpha1.EdgeIngress) {
@@ -491,9 +726,11 @@ func (w *Watcher) cleanEdgeIngresses(ctx context.Context, edgeIngs map[string]*h
 
 func buildResourceSpec(edgeIng *EdgeIngress) hubv1alpha1.EdgeIngressSpec {
 	spec := hubv1alpha1.EdgeIngressSpec{
+		Mode: hubv1alpha1.EdgeIngressMode(edgeIng.Mode),
 		Service: hubv1alpha1.EdgeIngressService{
-			Name: edgeIng.Service.Name,
-			Port: edgeIng.Service.Port,
+			Name:     edgeIng.Service.Name,
+			Port:     edgeIng.Service.Port,
+			Protocol: hubv1alpha1.EdgeIngressServiceProtocol(edgeIng.Service.Protocol),
 		},
 	}
 
@@ -503,34 +740,117 @@ func buildResourceSpec(edgeIng *EdgeIngress) hubv1alpha1.EdgeIngressSpec {
 		}
 	}
 
+	for _, customDomain := range edgeIng.CustomDomains {
+		spec.CustomDomains = append(spec.CustomDomains, customDomain.Name)
+	}
+
+	for _, service := range edgeIng.Services {
+		spec.Services = append(spec.Services, hubv1alpha1.EdgeIngressWeightedService{
+			Name:   service.Name,
+			Port:   service.Port,
+			Weight: service.Weight,
+		})
+	}
+
+	if edgeIng.Sticky != nil {
+		spec.Sticky = &hubv1alpha1.EdgeIngressSticky{
+			CookieName: edgeIng.Sticky.CookieName,
+			TTL:        edgeIng.Sticky.TTL,
+		}
+	}
+
+	for _, acp := range edgeIng.ACPs {
+		spec.ACPs = append(spec.ACPs, hubv1alpha1.EdgeIngressACP{Name: acp.Name})
+	}
+
+	spec.IngressAnnotations = edgeIng.IngressAnnotations
+	spec.IngressLabels = edgeIng.IngressLabels
+
+	if edgeIng.Middlewares != nil {
+		spec.Middlewares = &hubv1alpha1.EdgeIngressMiddlewares{}
+		if edgeIng.Middlewares.RateLimit != nil {
+			spec.Middlewares.RateLimit = &hubv1alpha1.EdgeIngressRateLimit{
+				Average: edgeIng.Middlewares.RateLimit.Average,
+				Burst:   edgeIng.Middlewares.RateLimit.Burst,
+			}
+		}
+		if edgeIng.Middlewares.Compress != nil {
+			spec.Middlewares.Compress = &hubv1alpha1.EdgeIngressCompression{
+				ExcludedContentTypes: edgeIng.Middlewares.Compress.ExcludedContentTypes,
+				MinResponseBodyBytes: edgeIng.Middlewares.Compress.MinResponseBodyBytes,
+			}
+		}
+	}
+
+	spec.EntryPoints = edgeIng.EntryPoints
+
+	if edgeIng.TLS != nil {
+		spec.TLS = &hubv1alpha1.EdgeIngressTLS{
+			Options:      edgeIng.TLS.Options,
+			RedirectHTTP: edgeIng.TLS.RedirectHTTP,
+		}
+	}
+
+	spec.AllowedSourceIPs = edgeIng.AllowedSourceIPs
+
+	if edgeIng.Headers != nil {
+		spec.Headers = &hubv1alpha1.EdgeIngressHeaders{
+			Request:  buildResourceHeaderRules(edgeIng.Headers.Request),
+			Response: buildResourceHeaderRules(edgeIng.Headers.Response),
+		}
+	}
+
+	spec.Paused = edgeIng.Paused
+
+	if edgeIng.Maintenance != nil {
+		spec.Maintenance = &hubv1alpha1.EdgeIngressMaintenance{
+			Message:     edgeIng.Maintenance.Message,
+			RemoveRoute: edgeIng.Maintenance.RemoveRoute,
+		}
+	}
+
+	if edgeIng.Bandwidth != nil {
+		spec.Bandwidth = &hubv1alpha1.EdgeIngressBandwidth{
+			EgressBytesPerSecond: edgeIng.Bandwidth.EgressBytesPerSecond,
+			BurstBytes:           edgeIng.Bandwidth.BurstBytes,
+		}
+	}
+
 	return spec
 }
 
-func buildIngress(edgeIng *hubv1alpha1.EdgeIngress, ing *netv1.Ingress, ingressClassName, entryPoint string, customDomains []string) *netv1.Ingress {
+func buildResourceHeaderRules(rules *HeaderRules) *hubv1alpha1.EdgeIngressHeaderRules {
+	if rules == nil {
+		return nil
+	}
+
+	return &hubv1alpha1.EdgeIngressHeaderRules{
+		Set:    rules.Set,
+		Remove: rules.Remove,
+	}
+}
+
+func buildIngress(edgeIng *hubv1alpha1.EdgeIngress, ing *netv1.Ingress, ingressClassName, entryPoint string, customDomains []string, middlewares []traefikv1alpha1.MiddlewareRef) *netv1.Ingress {
 	annotations := map[string]string{
 		"traefik.ingress.kubernetes.io/router.tls":         "true",
-		"traefik.ingress.kubernetes.io/router.entrypoints": entryPoint,
+		"traefik.ingress.kubernetes.io/router.entrypoints": strings.Join(routeEntryPoints(edgeIng, entryPoint), ","),
+	}
+	if edgeIng.Spec.TLS != nil && edgeIng.Spec.TLS.Options != "" {
+		annotations["traefik.ingress.kubernetes.io/router.tls.options"] = edgeIng.Namespace + "-" + edgeIng.Spec.TLS.Options + "@kubernetescrd"
+	}
+	if len(middlewares) > 0 {
+		annotations["traefik.ingress.kubernetes.io/router.middlewares"] = routerMiddlewares(middlewares)
 	}
 	if edgeIng.Spec.ACP != nil && edgeIng.Spec.ACP.Name != "" {
 		annotations[reviewer.AnnotationHubAuth] = edgeIng.Spec.ACP.Name
 	}
 
 	ing.ObjectMeta = metav1.ObjectMeta{
-		Name:        edgeIng.Name,
-		Namespace:   edgeIng.Namespace,
-		Annotations: annotations,
-		Labels: map[string]string{
-			"app.kubernetes.io/managed-by": "traefik-hub",
-		},
-		// Set OwnerReference allow us to delete ingresses owned by an edgeIngress.
-		OwnerReferences: []metav1.OwnerReference{
-			{
-				APIVersion: "hub.traefik.io/v1alpha1",
-				Kind:       "EdgeIngress",
-				Name:       edgeIng.Name,
-				UID:        edgeIng.UID,
-			},
-		},
+		Name:            edgeIng.Name,
+		Namespace:       edgeIng.Namespace,
+		Annotations:     generatedAnnotations(edgeIng, annotations),
+		Labels:          generatedLabels(edgeIng),
+		OwnerReferences: ownerReferences(edgeIng),
 	}
 
 	// No secret is needed for TLS because we will use the wildcard certificate configured in the catch-all ingress.
@@ -586,3 +906,50 @@ func buildIngress(edgeIng *hubv1alpha1.EdgeIngress, ing *netv1.Ingress, ingressC
 
 	return ing
 }
+
+// routeEntryPoints returns the entry points the routes generated for the given EdgeIngress listen on.
+func routeEntryPoints(edgeIng *hubv1alpha1.EdgeIngress, defaultEntryPoint string) []string {
+	if len(edgeIng.Spec.EntryPoints) > 0 {
+		return edgeIng.Spec.EntryPoints
+	}
+
+	return []string{defaultEntryPoint}
+}
+
+// generatedAnnotations returns the annotations of a resource generated for the given EdgeIngress: the ones requested
+// on the EdgeIngress, overridden by the ones managed by the agent.
+func generatedAnnotations(edgeIng *hubv1alpha1.EdgeIngress, managed map[string]string) map[string]string {
+	if len(edgeIng.Spec.IngressAnnotations) == 0 && len(managed) == 0 {
+		return nil
+	}
+
+	annotations := make(map[string]string, len(edgeIng.Spec.IngressAnnotations)+len(managed))
+	for k, v := range edgeIng.Spec.IngressAnnotations {
+		annotations[k] = v
+	}
+	for k, v := range managed {
+		annotations[k] = v
+	}
+
+	return annotations
+}
+
+// generatedLabels returns the labels of a resource generated for the given EdgeIngress.
+func generatedLabels(edgeIng *hubv1alpha1.EdgeIngress) map[string]string {
+	labels := make(map[string]string, len(edgeIng.Spec.IngressLabels)+1)
+	for k, v := range edgeIng.Spec.IngressLabels {
+		labels[k] = v
+	}
+	labels["app.kubernetes.io/managed-by"] = "traefik-hub"
+
+	return labels
+}
+
+func contains(slice []string, value string) bool {
+	for _, v := range slice {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -29,6 +29,7 @@ import (
 	hubkubemock "github.com/traefik/hub-agent-kubernetes/pkg/crd/generated/client/hub/clientset/versioned/fake"
 	hubinformer "github.com/traefik/hub-agent-kubernetes/pkg/crd/generated/client/hub/informers/externalversions"
 	traefikkubemock "github.com/traefik/hub-agent-kubernetes/pkg/crd/generated/client/traefik/clientset/versioned/fake"
+	corev1 "k8s.io/api/core/v1"
 	netv1 "k8s.io/api/networking/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -86,7 +87,7 @@ var toDelete = hubv1alpha1.EdgeIngress{
 
 func Test_WatcherRun(t *testing.T) {
 	clientSetHub := hubkubemock.NewSimpleClientset([]runtime.Object{&toUpdate, &toDelete}...)
-	clientSet := kubemock.NewSimpleClientset()
+	clientSet := kubemock.NewSimpleClientset(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "default"}})
 
 	ctx, cancel := context.WithCancel(context.Background())
 	hubInformer := hubinformer.NewSharedInformerFactory(clientSetHub, 0)
@@ -181,6 +182,13 @@ func Test_WatcherRun(t *testing.T) {
 		assert.WithinDuration(t, time.Now(), edgeIng.Status.SyncedAt.Time, 100*time.Millisecond)
 		edgeIng.Status.SyncedAt = metav1.Time{}
 
+		assertConditions(t, map[string]metav1.ConditionStatus{
+			hubv1alpha1.EdgeIngressConditionReady:            metav1.ConditionFalse,
+			hubv1alpha1.EdgeIngressConditionCertificateReady: metav1.ConditionTrue,
+			hubv1alpha1.EdgeIngressConditionBackendReady:     metav1.ConditionFalse,
+		}, edgeIng.Status.Conditions)
+		edgeIng.Status.Conditions = nil
+
 		assert.Equal(t, hubv1alpha1.EdgeIngressStatus{
 			Version:    edgeIngress.Version,
 			SyncedAt:   metav1.Time{},
@@ -249,9 +257,111 @@ func Test_WatcherRun(t *testing.T) {
 	}
 }
 
+func Test_WatcherRun_notOwnedNamespaces(t *testing.T) {
+	tests := []struct {
+		desc       string
+		namespaces []string
+		shard      Shard
+	}{
+		{
+			desc:  "namespaces owned by other replicas",
+			shard: shardMock{},
+		},
+		{
+			desc:       "namespaces not allowed",
+			namespaces: []string{"other"},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			runNotOwnedNamespaces(t, test.namespaces, test.shard)
+		})
+	}
+}
+
+func runNotOwnedNamespaces(t *testing.T, namespaces []string, shard Shard) {
+	t.Helper()
+
+	clientSetHub := hubkubemock.NewSimpleClientset([]runtime.Object{&toUpdate, &toDelete}...)
+	clientSet := kubemock.NewSimpleClientset(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "default"}})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	hubInformer := hubinformer.NewSharedInformerFactory(clientSetHub, 0)
+
+	edgeIngressInformer := hubInformer.Hub().V1alpha1().EdgeIngresses().Informer()
+
+	hubInformer.Start(ctx.Done())
+	cache.WaitForCacheSync(ctx.Done(), edgeIngressInformer.HasSynced)
+
+	client := newPlatformClientMock(t)
+
+	var callCount int
+	client.OnGetEdgeIngresses().
+		TypedReturns([]EdgeIngress{
+			{
+				Name:      "toCreate",
+				Namespace: "default",
+				Domain:    "majestic-beaver-123.hub-traefik.io",
+				Version:   "version-1",
+				Service:   Service{Name: "service-1", Port: 8080},
+			},
+		}, nil).
+		Run(func(_ mock.Arguments) {
+			callCount++
+			if callCount > 1 {
+				cancel()
+			}
+		})
+
+	traefikClientSet := traefikkubemock.NewSimpleClientset()
+
+	// Neither the agent namespace nor the EdgeIngresses namespace are reconciled.
+	w, err := NewWatcher(client, clientSetHub, clientSet, traefikClientSet.TraefikV1alpha1(), hubInformer, WatcherConfig{
+		IngressClassName:        "traefik-hub",
+		TraefikEntryPoint:       "traefikhub-tunl",
+		AgentNamespace:          "hub-agent",
+		EdgeIngressSyncInterval: time.Millisecond,
+		CertRetryInterval:       time.Millisecond,
+		CertSyncInterval:        time.Millisecond,
+		Namespaces:              namespaces,
+		Shard:                   shard,
+	})
+	require.NoError(t, err)
+
+	stop := make(chan struct{})
+	go func() {
+		w.Run(ctx)
+		close(stop)
+	}()
+
+	<-stop
+
+	ctx = context.Background()
+
+	_, err = clientSetHub.HubV1alpha1().EdgeIngresses("default").Get(ctx, "toDelete", metav1.GetOptions{})
+	require.NoError(t, err)
+
+	_, err = clientSetHub.HubV1alpha1().EdgeIngresses("default").Get(ctx, "toCreate", metav1.GetOptions{})
+	require.Error(t, err)
+
+	_, err = clientSet.CoreV1().Secrets("hub-agent").Get(ctx, secretName, metav1.GetOptions{})
+	require.Error(t, err)
+}
+
+type shardMock map[string]struct{}
+
+func (s shardMock) Owns(namespace string) bool {
+	_, ok := s[namespace]
+	return ok
+}
+
 func Test_WatcherRun_handle_custom_domains(t *testing.T) {
 	clientSetHub := hubkubemock.NewSimpleClientset(&toUpdate)
-	clientSet := kubemock.NewSimpleClientset()
+	clientSet := kubemock.NewSimpleClientset(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "default"}})
 
 	ctx, cancel := context.WithCancel(context.Background())
 	hubInformer := hubinformer.NewSharedInformerFactory(clientSetHub, 0)
@@ -339,18 +449,27 @@ func Test_WatcherRun_handle_custom_domains(t *testing.T) {
 		ACP: &hubv1alpha1.EdgeIngressACP{
 			Name: wantEdgeIngress.ACP.Name,
 		},
+		CustomDomains: []string{"customDomain.com", "unverified.com"},
 	}, edgeIng.Spec)
 
 	assert.WithinDuration(t, time.Now(), edgeIng.Status.SyncedAt.Time, 100*time.Millisecond)
 	edgeIng.Status.SyncedAt = metav1.Time{}
 
+	assertConditions(t, map[string]metav1.ConditionStatus{
+		hubv1alpha1.EdgeIngressConditionReady:            metav1.ConditionFalse,
+		hubv1alpha1.EdgeIngressConditionCertificateReady: metav1.ConditionTrue,
+		hubv1alpha1.EdgeIngressConditionBackendReady:     metav1.ConditionFalse,
+	}, edgeIng.Status.Conditions)
+	edgeIng.Status.Conditions = nil
+
 	assert.Equal(t, hubv1alpha1.EdgeIngressStatus{
-		Version:    wantEdgeIngress.Version,
-		SyncedAt:   metav1.Time{},
-		Domain:     wantEdgeIngress.Domain,
-		URL:        "https://" + wantEdgeIngress.Domain,
-		SpecHash:   "4vJBrpeDJLuGzikpIg0ZJTca9FQ=",
-		Connection: hubv1alpha1.EdgeIngressConnectionUp,
+		Version:       wantEdgeIngress.Version,
+		SyncedAt:      metav1.Time{},
+		Domain:        wantEdgeIngress.Domain,
+		URL:           "https://" + wantEdgeIngress.Domain,
+		CustomDomains: []string{"customDomain.com"},
+		SpecHash:      "OxYSOU0yEUcLM1RnjLL83wymkUU=",
+		Connection:    hubv1alpha1.EdgeIngressConnectionUp,
 	}, edgeIng.Status)
 
 	// Make sure secret related to the edgeIngress is created.
@@ -439,3 +558,46 @@ func Test_WatcherRun_handle_custom_domains(t *testing.T) {
 		},
 	}, ing.Spec)
 }
+
+func assertConditions(t *testing.T, want map[string]metav1.ConditionStatus, conditions []metav1.Condition) {
+	t.Helper()
+
+	got := make(map[string]metav1.ConditionStatus)
+	for _, condition := range conditions {
+		got[condition.Type] = condition.Status
+	}
+
+	assert.Equal(t, want, got)
+}
+
+func TestBuildIngress_metadataPassthrough(t *testing.T) {
+	edgeIng := &hubv1alpha1.EdgeIngress{
+		ObjectMeta: metav1.ObjectMeta{Name: "edge-ingress", Namespace: "default", UID: "uid"},
+		Spec: hubv1alpha1.EdgeIngressSpec{
+			Service: hubv1alpha1.EdgeIngressService{Name: "whoami", Port: 80},
+			ACP:     &hubv1alpha1.EdgeIngressACP{Name: "acp"},
+			IngressAnnotations: map[string]string{
+				"traefik.ingress.kubernetes.io/router.priority": "10",
+				"traefik.ingress.kubernetes.io/router.tls":      "false",
+			},
+			IngressLabels: map[string]string{
+				"team":                         "platform",
+				"app.kubernetes.io/managed-by": "someone-else",
+			},
+		},
+		Status: hubv1alpha1.EdgeIngressStatus{Domain: "sad-bat-123.hub-traefik.io"},
+	}
+
+	ing := buildIngress(edgeIng, &netv1.Ingress{}, "traefik-hub", "traefikhub-tunl", nil, nil)
+
+	assert.Equal(t, map[string]string{
+		"hub.traefik.io/access-control-policy":             "acp",
+		"traefik.ingress.kubernetes.io/router.priority":    "10",
+		"traefik.ingress.kubernetes.io/router.tls":         "true",
+		"traefik.ingress.kubernetes.io/router.entrypoints": "traefikhub-tunl",
+	}, ing.Annotations)
+	assert.Equal(t, map[string]string{
+		"team":                         "platform",
+		"app.kubernetes.io/managed-by": "traefik-hub",
+	}, ing.Labels)
+}
@@ -0,0 +1,84 @@
+/*
+Copyright (C) 2022 Traefik Labs
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+// Package fips restricts the cryptography used to authenticate requests to FIPS 140-2 approved algorithms and key
+// sizes. FIPS mode is enabled at runtime with Enable, or at build time with the "fips" build tag.
+package fips
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"fmt"
+	"sync/atomic"
+)
+
+const (
+	// minRSAKeySize is the minimum size, in bits, of RSA keys.
+	minRSAKeySize = 2048
+	// minHMACKeySize is the minimum size, in bytes, of HMAC keys, giving a security strength of 112 bits.
+	minHMACKeySize = 14
+)
+
+var enabled int32
+
+func init() {
+	if enabledByBuild {
+		Enable()
+	}
+}
+
+// Enable enables FIPS mode. It must be called before any ACP handler is created.
+func Enable() {
+	atomic.StoreInt32(&enabled, 1)
+}
+
+// Enabled tells whether FIPS mode is enabled.
+func Enabled() bool {
+	return atomic.LoadInt32(&enabled) == 1
+}
+
+// CheckPublicKey returns an error if the given public key can't be used to verify signatures in FIPS mode.
+func CheckPublicKey(key interface{}) error {
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		if size := k.N.BitLen(); size < minRSAKeySize {
+			return fmt.Errorf("RSA key of %d bits is too short, at least %d bits are required", size, minRSAKeySize)
+		}
+		return nil
+
+	case *ecdsa.PublicKey:
+		switch k.Curve {
+		case elliptic.P256(), elliptic.P384(), elliptic.P521():
+			return nil
+		default:
+			return fmt.Errorf("elliptic curve %q is not FIPS-approved", k.Curve.Params().Name)
+		}
+
+	default:
+		return fmt.Errorf("key type %T is not FIPS-approved", key)
+	}
+}
+
+// CheckHMACKey returns an error if the given key can't be used to verify HMAC signatures in FIPS mode.
+func CheckHMACKey(key []byte) error {
+	if len(key) < minHMACKeySize {
+		return fmt.Errorf("HMAC key of %d bytes is too short, at least %d bytes are required", len(key), minHMACKeySize)
+	}
+
+	return nil
+}
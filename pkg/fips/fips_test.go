@@ -0,0 +1,94 @@
+/*
+Copyright (C) 2022 Traefik Labs
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package fips
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckPublicKey(t *testing.T) {
+	rsa1024, err := rsa.GenerateKey(rand.Reader, 1024)
+	require.NoError(t, err)
+	rsa2048, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	p224, err := ecdsa.GenerateKey(elliptic.P224(), rand.Reader)
+	require.NoError(t, err)
+	p256, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	ed, _, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	tests := []struct {
+		desc    string
+		key     interface{}
+		wantErr assert.ErrorAssertionFunc
+	}{
+		{
+			desc:    "RSA 2048 bits",
+			key:     &rsa2048.PublicKey,
+			wantErr: assert.NoError,
+		},
+		{
+			desc:    "RSA 1024 bits",
+			key:     &rsa1024.PublicKey,
+			wantErr: assert.Error,
+		},
+		{
+			desc:    "ECDSA P-256",
+			key:     &p256.PublicKey,
+			wantErr: assert.NoError,
+		},
+		{
+			desc:    "ECDSA P-224",
+			key:     &p224.PublicKey,
+			wantErr: assert.Error,
+		},
+		{
+			desc:    "Ed25519",
+			key:     ed,
+			wantErr: assert.Error,
+		},
+		{
+			desc:    "symmetric key",
+			key:     []byte("secret"),
+			wantErr: assert.Error,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			test.wantErr(t, CheckPublicKey(test.key))
+		})
+	}
+}
+
+func TestCheckHMACKey(t *testing.T) {
+	assert.Error(t, CheckHMACKey([]byte("foobar")))
+	assert.NoError(t, CheckHMACKey([]byte("a-long-enough-secret")))
+}
@@ -0,0 +1,196 @@
+/*
+Copyright (C) 2022 Traefik Labs
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// checkTimeout is the maximum duration of a single readiness check.
+const checkTimeout = 5 * time.Second
+
+// Check checks whether a subsystem is ready. It returns an error describing the problem when it isn't.
+type Check func(ctx context.Context) error
+
+// Info describes the state of a subsystem, without affecting the readiness of the agent.
+type Info func() string
+
+// Registry holds the readiness checks of the agent subsystems.
+type Registry struct {
+	checksMu sync.RWMutex
+	checks   map[string]Check
+	infos    map[string]Info
+}
+
+// NewRegistry creates a Registry.
+func NewRegistry() *Registry {
+	return &Registry{
+		checks: make(map[string]Check),
+		infos:  make(map[string]Info),
+	}
+}
+
+// Register registers the readiness check of the given subsystem, replacing any previous one.
+func (r *Registry) Register(name string, check Check) {
+	r.checksMu.Lock()
+	defer r.checksMu.Unlock()
+
+	r.checks[name] = check
+}
+
+// RegisterInfo registers the information reported about the given subsystem, replacing any previous one.
+func (r *Registry) RegisterInfo(name string, info Info) {
+	r.checksMu.Lock()
+	defer r.checksMu.Unlock()
+
+	r.infos[name] = info
+}
+
+// Result is the result of the readiness check of a subsystem.
+type Result struct {
+	Name string
+	Err  error
+}
+
+// Check runs all the readiness checks and returns their results, sorted by subsystem name.
+func (r *Registry) Check(ctx context.Context) []Result {
+	r.checksMu.RLock()
+	checks := make(map[string]Check, len(r.checks))
+	for name, check := range r.checks {
+		checks[name] = check
+	}
+	r.checksMu.RUnlock()
+
+	results := make([]Result, 0, len(checks))
+	for name, check := range checks {
+		checkCtx, cancel := context.WithTimeout(ctx, checkTimeout)
+		results = append(results, Result{Name: name, Err: check(checkCtx)})
+		cancel()
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Name < results[j].Name
+	})
+
+	return results
+}
+
+type infoLine struct {
+	name  string
+	value string
+}
+
+// describe returns the registered information, sorted by subsystem name.
+func (r *Registry) describe() []infoLine {
+	r.checksMu.RLock()
+	infos := make(map[string]Info, len(r.infos))
+	for name, info := range r.infos {
+		infos[name] = info
+	}
+	r.checksMu.RUnlock()
+
+	lines := make([]infoLine, 0, len(infos))
+	for name, info := range infos {
+		lines = append(lines, infoLine{name: name, value: info()})
+	}
+
+	sort.Slice(lines, func(i, j int) bool {
+		return lines[i].name < lines[j].name
+	})
+
+	return lines
+}
+
+// ServeHTTP answers with a 200 status code when all subsystems are ready, and a 503 otherwise. Each subsystem state is
+// reported when the verbose query parameter is set, along with the registered information.
+func (r *Registry) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	results := r.Check(req.Context())
+
+	ready := true
+	var report strings.Builder
+	for _, result := range results {
+		if result.Err != nil {
+			ready = false
+			_, _ = fmt.Fprintf(&report, "[-]%s failed: %v\n", result.Name, result.Err)
+			continue
+		}
+		_, _ = fmt.Fprintf(&report, "[+]%s ok\n", result.Name)
+	}
+	for _, info := range r.describe() {
+		_, _ = fmt.Fprintf(&report, "[i]%s: %s\n", info.name, info.value)
+	}
+
+	rw.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	rw.Header().Set("X-Content-Type-Options", "nosniff")
+
+	status := "ok"
+	if !ready {
+		status = "failed"
+		rw.WriteHeader(http.StatusServiceUnavailable)
+	}
+
+	if _, verbose := req.URL.Query()["verbose"]; verbose {
+		_, _ = fmt.Fprintf(rw, "%sreadyz check %s\n", report.String(), status)
+		return
+	}
+
+	_, _ = fmt.Fprintln(rw, status)
+}
+
+// errNotStarted is reported by subsystems which haven't reported their state yet.
+var errNotStarted = errors.New("not started yet")
+
+// Status tracks the state reported by a subsystem. The zero value is a subsystem which hasn't reported its state yet.
+type Status struct {
+	mu       sync.RWMutex
+	reported bool
+	err      error
+}
+
+// SetReady reports the subsystem as ready.
+func (s *Status) SetReady() {
+	s.Set(nil)
+}
+
+// Set reports the subsystem as ready when err is nil, and as not ready because of err otherwise.
+func (s *Status) Set(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.reported = true
+	s.err = err
+}
+
+// Check is a Check returning the state last reported by the subsystem.
+func (s *Status) Check(_ context.Context) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if !s.reported {
+		return errNotStarted
+	}
+
+	return s.err
+}
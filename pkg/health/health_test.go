@@ -0,0 +1,160 @@
+/*
+Copyright (C) 2022 Traefik Labs
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package health
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistry_ServeHTTP(t *testing.T) {
+	tests := []struct {
+		desc       string
+		checks     map[string]Check
+		infos      map[string]Info
+		query      string
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			desc:       "no checks",
+			wantStatus: http.StatusOK,
+			wantBody:   "ok\n",
+		},
+		{
+			desc: "all subsystems ready",
+			checks: map[string]Check{
+				"metrics":  ready,
+				"topology": ready,
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   "ok\n",
+		},
+		{
+			desc: "one subsystem not ready",
+			checks: map[string]Check{
+				"metrics":  ready,
+				"topology": failing("boom"),
+			},
+			wantStatus: http.StatusServiceUnavailable,
+			wantBody:   "failed\n",
+		},
+		{
+			desc: "verbose with all subsystems ready",
+			checks: map[string]Check{
+				"topology": ready,
+				"metrics":  ready,
+			},
+			query:      "?verbose",
+			wantStatus: http.StatusOK,
+			wantBody:   "[+]metrics ok\n[+]topology ok\nreadyz check ok\n",
+		},
+		{
+			desc: "verbose with one subsystem not ready",
+			checks: map[string]Check{
+				"topology": failing("boom"),
+				"metrics":  ready,
+				"tunnel":   (&Status{}).Check,
+			},
+			query:      "?verbose",
+			wantStatus: http.StatusServiceUnavailable,
+			wantBody:   "[+]metrics ok\n[-]topology failed: boom\n[-]tunnel failed: not started yet\nreadyz check failed\n",
+		},
+		{
+			desc: "infos",
+			checks: map[string]Check{
+				"metrics": ready,
+			},
+			infos: map[string]Info{
+				"version": func() string { return "v1.0.0, update available: v1.1.0" },
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   "ok\n",
+		},
+		{
+			desc: "verbose with infos",
+			checks: map[string]Check{
+				"metrics": ready,
+			},
+			infos: map[string]Info{
+				"version": func() string { return "v1.0.0, update available: v1.1.0" },
+			},
+			query:      "?verbose",
+			wantStatus: http.StatusOK,
+			wantBody:   "[+]metrics ok\n[i]version: v1.0.0, update available: v1.1.0\nreadyz check ok\n",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			registry := NewRegistry()
+			for name, check := range test.checks {
+				registry.Register(name, check)
+			}
+			for name, info := range test.infos {
+				registry.RegisterInfo(name, info)
+			}
+
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/readyz"+test.query, http.NoBody)
+
+			registry.ServeHTTP(rec, req)
+
+			assert.Equal(t, test.wantStatus, rec.Code)
+
+			body, err := io.ReadAll(rec.Body)
+			require.NoError(t, err)
+			assert.Equal(t, test.wantBody, string(body))
+		})
+	}
+}
+
+func TestStatus_Check(t *testing.T) {
+	var status Status
+	assert.ErrorIs(t, status.Check(context.Background()), errNotStarted)
+
+	status.SetReady()
+	assert.NoError(t, status.Check(context.Background()))
+
+	wantErr := errors.New("boom")
+	status.Set(wantErr)
+	assert.ErrorIs(t, status.Check(context.Background()), wantErr)
+
+	status.SetReady()
+	assert.NoError(t, status.Check(context.Background()))
+}
+
+func ready(_ context.Context) error {
+	return nil
+}
+
+func failing(msg string) Check {
+	return func(_ context.Context) error {
+		return errors.New(msg)
+	}
+}
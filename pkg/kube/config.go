@@ -25,8 +25,37 @@ import (
 	"github.com/rs/zerolog/log"
 	"github.com/traefik/hub-agent-kubernetes/pkg/logger"
 	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
 )
 
+// LoadKubeConfig loads the Kubernetes client configuration from the given kubeconfig file, using the given context or
+// the current one if empty. When path is empty, the default loading rules are used: the KUBECONFIG environment
+// variable, then ~/.kube/config. Credentials provided by exec plugins are supported.
+func LoadKubeConfig(path, context string) (*rest.Config, error) {
+	rules := clientcmd.NewDefaultClientConfigLoadingRules()
+	rules.ExplicitPath = path
+
+	overrides := &clientcmd.ConfigOverrides{CurrentContext: context}
+
+	cfg, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(rules, overrides).ClientConfig()
+	if err != nil {
+		return nil, fmt.Errorf("load kubeconfig: %w", err)
+	}
+
+	return cfg, nil
+}
+
+// KubeConfigWithRetrier returns a new configuration loaded from the given kubeconfig file and context that will retry
+// requests that result in transient failures.
+func KubeConfigWithRetrier(path, context string, maxRetries int) (*rest.Config, error) {
+	cfg, err := LoadKubeConfig(path, context)
+	if err != nil {
+		return nil, err
+	}
+
+	return withRetrier(cfg, maxRetries)
+}
+
 // InClusterConfigWithRetrier returns a new in-cluster configuration that will retry requests that result in transient failures.
 func InClusterConfigWithRetrier(maxRetries int) (*rest.Config, error) {
 	cfg, err := rest.InClusterConfig()
@@ -34,6 +63,10 @@ func InClusterConfigWithRetrier(maxRetries int) (*rest.Config, error) {
 		return nil, fmt.Errorf("create Kubernetes in-cluster configuration: %w", err)
 	}
 
+	return withRetrier(cfg, maxRetries)
+}
+
+func withRetrier(cfg *rest.Config, maxRetries int) (*rest.Config, error) {
 	// We first need to get the TLS configuration since we
 	// are going to bypass Kubernetes' default HTTP client.
 	tlsCfg, err := rest.TLSConfigFor(cfg)
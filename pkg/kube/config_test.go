@@ -0,0 +1,114 @@
+/*
+Copyright (C) 2022 Traefik Labs
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package kube
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const kubeconfig = `apiVersion: v1
+kind: Config
+current-context: edge-1
+clusters:
+- name: edge-1
+  cluster:
+    server: https://edge-1.example.com:6443
+- name: edge-2
+  cluster:
+    server: https://edge-2.example.com:6443
+contexts:
+- name: edge-1
+  context:
+    cluster: edge-1
+    user: token
+- name: edge-2
+  context:
+    cluster: edge-2
+    user: exec
+users:
+- name: token
+  user:
+    token: my-token
+- name: exec
+  user:
+    exec:
+      apiVersion: client.authentication.k8s.io/v1beta1
+      command: get-token
+      args: ["edge-2"]
+`
+
+func TestKubeConfigWithRetrier(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "kubeconfig")
+	require.NoError(t, os.WriteFile(path, []byte(kubeconfig), 0o600))
+
+	tests := []struct {
+		desc        string
+		context     string
+		wantHost    string
+		wantToken   string
+		wantCommand string
+		wantErr     bool
+	}{
+		{
+			desc:      "current context",
+			wantHost:  "https://edge-1.example.com:6443",
+			wantToken: "my-token",
+		},
+		{
+			desc:        "exec credential plugin",
+			context:     "edge-2",
+			wantHost:    "https://edge-2.example.com:6443",
+			wantCommand: "get-token",
+		},
+		{
+			desc:    "unknown context",
+			context: "edge-3",
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			cfg, err := KubeConfigWithRetrier(path, test.context, 2)
+			if test.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			assert.Equal(t, test.wantHost, cfg.Host)
+			assert.Equal(t, test.wantToken, cfg.BearerToken)
+			assert.NotNil(t, cfg.WrapTransport)
+
+			if test.wantCommand == "" {
+				assert.Nil(t, cfg.ExecProvider)
+				return
+			}
+			require.NotNil(t, cfg.ExecProvider)
+			assert.Equal(t, test.wantCommand, cfg.ExecProvider.Command)
+		})
+	}
+}
@@ -0,0 +1,114 @@
+/*
+Copyright (C) 2022 Traefik Labs
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package kube
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clientset "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+)
+
+// LeaderElectionConfig configures the election of the replica leading the others, through a Lease.
+type LeaderElectionConfig struct {
+	// LeaseName and LeaseNamespace identify the Lease held by the leader.
+	LeaseName      string
+	LeaseNamespace string
+	// Identity identifies the replica taking part in the election, typically the name of its Pod.
+	Identity string
+
+	// LeaseDuration is the time followers wait before trying to acquire a Lease which hasn't been renewed.
+	LeaseDuration time.Duration
+	// RenewDeadline is the time during which the leader retries renewing the Lease before giving up the leadership.
+	RenewDeadline time.Duration
+	// RetryPeriod is the interval between attempts to acquire or renew the Lease.
+	RetryPeriod time.Duration
+}
+
+// ErrLeadershipLost is returned when the leadership is lost before the context is done.
+var ErrLeadershipLost = errors.New("leadership lost")
+
+// RunLeaderElection takes part in the election of the leader until ctx is done, calling lead with a context canceled
+// when the leadership is lost once elected. As the leader tasks can't be cleanly restarted, ErrLeadershipLost is
+// returned when the leadership is lost, and the error of lead when it fails.
+func RunLeaderElection(ctx context.Context, clientSet clientset.Interface, cfg LeaderElectionConfig, lead func(ctx context.Context) error) error {
+	electionCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	leading := make(chan struct{})
+	leadErr := make(chan error, 1)
+
+	elector, err := leaderelection.NewLeaderElector(leaderelection.LeaderElectionConfig{
+		Lock: &resourcelock.LeaseLock{
+			LeaseMeta: metav1.ObjectMeta{
+				Name:      cfg.LeaseName,
+				Namespace: cfg.LeaseNamespace,
+			},
+			Client:     clientSet.CoordinationV1(),
+			LockConfig: resourcelock.ResourceLockConfig{Identity: cfg.Identity},
+		},
+		LeaseDuration:   cfg.LeaseDuration,
+		RenewDeadline:   cfg.RenewDeadline,
+		RetryPeriod:     cfg.RetryPeriod,
+		ReleaseOnCancel: true,
+		Name:            cfg.LeaseName,
+		Callbacks: leaderelection.LeaderCallbacks{
+			OnStartedLeading: func(ctx context.Context) {
+				log.Info().Str("identity", cfg.Identity).Msg("Elected leader")
+				close(leading)
+
+				leadErr <- lead(ctx)
+				// Stop taking part in the election if the leader tasks fail.
+				cancel()
+			},
+			OnStoppedLeading: func() {
+				log.Info().Str("identity", cfg.Identity).Msg("Stopped leading")
+			},
+			OnNewLeader: func(identity string) {
+				if identity != cfg.Identity {
+					log.Info().Str("leader", identity).Msg("New leader elected")
+				}
+			},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("create leader elector: %w", err)
+	}
+
+	elector.Run(electionCtx)
+
+	select {
+	case <-leading:
+		if err = <-leadErr; err != nil {
+			return err
+		}
+	default:
+	}
+
+	if ctx.Err() != nil {
+		return nil
+	}
+
+	return ErrLeadershipLost
+}
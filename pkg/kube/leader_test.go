@@ -0,0 +1,93 @@
+/*
+Copyright (C) 2022 Traefik Labs
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package kube
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubemock "k8s.io/client-go/kubernetes/fake"
+)
+
+func TestRunLeaderElection(t *testing.T) {
+	clientSet := kubemock.NewSimpleClientset()
+	cfg := LeaderElectionConfig{
+		LeaseName:      "hub-agent-controller",
+		LeaseNamespace: "hub",
+		Identity:       "hub-agent-1",
+		LeaseDuration:  time.Second,
+		RenewDeadline:  500 * time.Millisecond,
+		RetryPeriod:    100 * time.Millisecond,
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	leading := make(chan struct{})
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- RunLeaderElection(ctx, clientSet, cfg, func(ctx context.Context) error {
+			close(leading)
+			<-ctx.Done()
+			return nil
+		})
+	}()
+
+	select {
+	case <-leading:
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "not elected")
+	}
+
+	lease, err := clientSet.CoordinationV1().Leases("hub").Get(ctx, "hub-agent-controller", metav1.GetOptions{})
+	require.NoError(t, err)
+	require.NotNil(t, lease.Spec.HolderIdentity)
+	assert.Equal(t, "hub-agent-1", *lease.Spec.HolderIdentity)
+
+	cancel()
+
+	select {
+	case err = <-errCh:
+		assert.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "leader election not stopped")
+	}
+}
+
+func TestRunLeaderElection_leadFails(t *testing.T) {
+	clientSet := kubemock.NewSimpleClientset()
+	cfg := LeaderElectionConfig{
+		LeaseName:      "hub-agent-controller",
+		LeaseNamespace: "hub",
+		Identity:       "hub-agent-1",
+		LeaseDuration:  time.Second,
+		RenewDeadline:  500 * time.Millisecond,
+		RetryPeriod:    100 * time.Millisecond,
+	}
+
+	wantErr := errors.New("boom")
+	err := RunLeaderElection(context.Background(), clientSet, cfg, func(context.Context) error {
+		return wantErr
+	})
+	assert.ErrorIs(t, err, wantErr)
+}
@@ -0,0 +1,241 @@
+/*
+Copyright (C) 2022 Traefik Labs
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package kube
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+)
+
+// NewNamespacedInformer creates an informer of the given namespaced resource which only lists and watches the given
+// namespaces, one at a time, so it only requires namespace-scoped permissions. tweak, if not nil, modifies the options
+// of the requests.
+func NewNamespacedInformer(client cache.Getter, resource string, obj runtime.Object, namespaces []string, resync time.Duration, tweak func(opts *metav1.ListOptions)) cache.SharedIndexInformer {
+	lw := NewMultiNamespaceListWatch(namespaces, func(namespace string) cache.ListerWatcher {
+		return cache.NewFilteredListWatchFromClient(client, resource, namespace, func(opts *metav1.ListOptions) {
+			if tweak != nil {
+				tweak(opts)
+			}
+		})
+	})
+
+	return cache.NewSharedIndexInformer(lw, obj, resync, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+}
+
+// NewMultiNamespaceListWatch creates a ListerWatcher listing and watching the given namespaces with the ListerWatchers
+// created by newListWatch. As resource versions can't be compared across namespaces, they are tracked per namespace
+// and the resource version given to Watch is ignored.
+func NewMultiNamespaceListWatch(namespaces []string, newListWatch func(namespace string) cache.ListerWatcher) cache.ListerWatcher {
+	lw := &multiNamespaceListWatch{
+		listWatches:      make(map[string]cache.ListerWatcher),
+		resourceVersions: make(map[string]string),
+	}
+
+	for _, namespace := range namespaces {
+		if _, ok := lw.listWatches[namespace]; ok {
+			continue
+		}
+
+		lw.namespaces = append(lw.namespaces, namespace)
+		lw.listWatches[namespace] = newListWatch(namespace)
+	}
+	sort.Strings(lw.namespaces)
+
+	return lw
+}
+
+type multiNamespaceListWatch struct {
+	namespaces  []string
+	listWatches map[string]cache.ListerWatcher
+
+	resourceVersionsMu sync.Mutex
+	resourceVersions   map[string]string
+}
+
+// List lists the resources of all the namespaces. Lists are not paginated, as continuation tokens can't span
+// namespaces.
+func (m *multiNamespaceListWatch) List(options metav1.ListOptions) (runtime.Object, error) {
+	options.Limit = 0
+	options.Continue = ""
+
+	var list runtime.Object
+	var items []runtime.Object
+	resourceVersions := make(map[string]string)
+	for _, namespace := range m.namespaces {
+		// Errors are returned as is, so expired resource versions are detected.
+		nsList, err := m.listWatches[namespace].List(options)
+		if err != nil {
+			return nil, err
+		}
+
+		nsItems, err := meta.ExtractList(nsList)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, nsItems...)
+
+		listMeta, err := meta.ListAccessor(nsList)
+		if err != nil {
+			return nil, err
+		}
+		resourceVersions[namespace] = listMeta.GetResourceVersion()
+
+		if list == nil {
+			list = nsList
+		}
+	}
+
+	if list == nil {
+		return &metav1.List{}, nil
+	}
+
+	if err := meta.SetList(list, items); err != nil {
+		return nil, err
+	}
+
+	listMeta, err := meta.ListAccessor(list)
+	if err != nil {
+		return nil, err
+	}
+	listMeta.SetResourceVersion("")
+
+	m.resourceVersionsMu.Lock()
+	m.resourceVersions = resourceVersions
+	m.resourceVersionsMu.Unlock()
+
+	return list, nil
+}
+
+// Watch watches the resources of all the namespaces, from the last resource version seen in each of them. The watch
+// ends as soon as the watch of one of the namespaces ends.
+func (m *multiNamespaceListWatch) Watch(options metav1.ListOptions) (watch.Interface, error) {
+	w := &multiNamespaceWatch{
+		result: make(chan watch.Event),
+		stop:   make(chan struct{}),
+	}
+
+	watchers := make(map[string]watch.Interface)
+	for _, namespace := range m.namespaces {
+		nsOptions := options
+		nsOptions.ResourceVersion = m.resourceVersion(namespace)
+
+		nsWatcher, err := m.listWatches[namespace].Watch(nsOptions)
+		if err != nil {
+			for _, watcher := range watchers {
+				watcher.Stop()
+			}
+			return nil, err
+		}
+
+		watchers[namespace] = nsWatcher
+		w.watchers = append(w.watchers, nsWatcher)
+	}
+
+	var wg sync.WaitGroup
+	for namespace, watcher := range watchers {
+		wg.Add(1)
+		go func(namespace string, watcher watch.Interface) {
+			defer wg.Done()
+			defer w.Stop()
+
+			w.forward(watcher, func(resourceVersion string) {
+				m.setResourceVersion(namespace, resourceVersion)
+			})
+		}(namespace, watcher)
+	}
+
+	go func() {
+		wg.Wait()
+		close(w.result)
+	}()
+
+	return w, nil
+}
+
+func (m *multiNamespaceListWatch) resourceVersion(namespace string) string {
+	m.resourceVersionsMu.Lock()
+	defer m.resourceVersionsMu.Unlock()
+
+	return m.resourceVersions[namespace]
+}
+
+func (m *multiNamespaceListWatch) setResourceVersion(namespace, resourceVersion string) {
+	m.resourceVersionsMu.Lock()
+	defer m.resourceVersionsMu.Unlock()
+
+	m.resourceVersions[namespace] = resourceVersion
+}
+
+// multiNamespaceWatch merges the events of the watches of several namespaces.
+type multiNamespaceWatch struct {
+	watchers []watch.Interface
+	result   chan watch.Event
+
+	stopOnce sync.Once
+	stop     chan struct{}
+}
+
+// Stop stops the watches of all the namespaces.
+func (w *multiNamespaceWatch) Stop() {
+	w.stopOnce.Do(func() {
+		close(w.stop)
+		for _, watcher := range w.watchers {
+			watcher.Stop()
+		}
+	})
+}
+
+// ResultChan returns the events of all the namespaces.
+func (w *multiNamespaceWatch) ResultChan() <-chan watch.Event {
+	return w.result
+}
+
+// forward forwards the events of the given watch until it ends or the watch is stopped, calling seen with the resource
+// version of each forwarded event.
+func (w *multiNamespaceWatch) forward(watcher watch.Interface, seen func(resourceVersion string)) {
+	for {
+		select {
+		case <-w.stop:
+			return
+		case event, ok := <-watcher.ResultChan():
+			if !ok {
+				return
+			}
+
+			select {
+			case <-w.stop:
+				return
+			case w.result <- event:
+			}
+
+			if event.Type == watch.Error {
+				continue
+			}
+			if accessor, err := meta.Accessor(event.Object); err == nil {
+				seen(accessor.GetResourceVersion())
+			}
+		}
+	}
+}
@@ -0,0 +1,124 @@
+/*
+Copyright (C) 2022 Traefik Labs
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package kube
+
+import (
+	"context"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	kubemock "k8s.io/client-go/kubernetes/fake"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+func TestMultiNamespaceListWatch(t *testing.T) {
+	clientSet := kubemock.NewSimpleClientset(
+		configMap("a", "cm-1"),
+		configMap("b", "cm-2"),
+		configMap("c", "cm-3"),
+	)
+
+	lw := NewMultiNamespaceListWatch([]string{"b", "a", "b"}, func(namespace string) cache.ListerWatcher {
+		return &cache.ListWatch{
+			ListFunc: func(opts metav1.ListOptions) (runtime.Object, error) {
+				return clientSet.CoreV1().ConfigMaps(namespace).List(context.Background(), opts)
+			},
+			WatchFunc: func(opts metav1.ListOptions) (watch.Interface, error) {
+				return clientSet.CoreV1().ConfigMaps(namespace).Watch(context.Background(), opts)
+			},
+		}
+	})
+
+	informer := cache.NewSharedIndexInformer(lw, &corev1.ConfigMap{}, 0, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	lister := corelisters.NewConfigMapLister(informer.GetIndexer())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go informer.Run(ctx.Done())
+	require.True(t, cache.WaitForCacheSync(ctx.Done(), informer.HasSynced))
+
+	assert.ElementsMatch(t, []string{"a/cm-1", "b/cm-2"}, configMapKeys(t, lister))
+
+	_, err := clientSet.CoreV1().ConfigMaps("b").Create(ctx, configMap("b", "cm-4"), metav1.CreateOptions{})
+	require.NoError(t, err)
+	_, err = clientSet.CoreV1().ConfigMaps("c").Create(ctx, configMap("c", "cm-5"), metav1.CreateOptions{})
+	require.NoError(t, err)
+	err = clientSet.CoreV1().ConfigMaps("a").Delete(ctx, "cm-1", metav1.DeleteOptions{})
+	require.NoError(t, err)
+
+	assert.Eventually(t, func() bool {
+		return assert.ObjectsAreEqual([]string{"b/cm-2", "b/cm-4"}, configMapKeys(t, lister))
+	}, time.Second, 10*time.Millisecond)
+
+	cm, err := lister.ConfigMaps("b").Get("cm-4")
+	require.NoError(t, err)
+	assert.Equal(t, "cm-4", cm.Name)
+}
+
+func TestMultiNamespaceWatch_Stop(t *testing.T) {
+	clientSet := kubemock.NewSimpleClientset()
+
+	lw := NewMultiNamespaceListWatch([]string{"a", "b"}, func(namespace string) cache.ListerWatcher {
+		return &cache.ListWatch{
+			WatchFunc: func(opts metav1.ListOptions) (watch.Interface, error) {
+				return clientSet.CoreV1().ConfigMaps(namespace).Watch(context.Background(), opts)
+			},
+		}
+	})
+
+	w, err := lw.Watch(metav1.ListOptions{})
+	require.NoError(t, err)
+
+	w.Stop()
+
+	select {
+	case _, ok := <-w.ResultChan():
+		assert.False(t, ok)
+	case <-time.After(time.Second):
+		require.Fail(t, "watch not stopped")
+	}
+}
+
+func configMap(namespace, name string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name}}
+}
+
+func configMapKeys(t *testing.T, lister corelisters.ConfigMapLister) []string {
+	t.Helper()
+
+	cms, err := lister.List(labels.Everything())
+	require.NoError(t, err)
+
+	var keys []string
+	for _, cm := range cms {
+		keys = append(keys, cm.Namespace+"/"+cm.Name)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
@@ -1,27 +1,89 @@
 package kubevers
 
 import (
+	"fmt"
+
 	"github.com/hashicorp/go-version"
+	kerror "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/discovery"
+)
+
+// MinVersion is the minimum Kubernetes version supported by the agent.
+// It is the first version serving Ingresses and IngressClasses in networking.k8s.io/v1.
+const MinVersion = "1.19"
+
+// gatewayAPIGroup is the API group of the Gateway API.
+const gatewayAPIGroup = "gateway.networking.k8s.io"
+
+// Capability names, as exposed in the topology.
+const (
+	CapabilityServerSideApply = "server-side-apply"
+	CapabilityCELValidation   = "cel-validation"
+	CapabilityGatewayAPI      = "gateway-api"
 )
 
-// SupportsNetV1Ingresses reports whether the Kubernetes cluster supports net v1 Ingresses.
-func SupportsNetV1Ingresses(ver string) bool {
-	return atLeast(ver, "1.19")
+// Capabilities are the optional features of a Kubernetes cluster used by the agent.
+type Capabilities struct {
+	// ServerSideApply reports whether server-side apply is generally available.
+	ServerSideApply bool
+	// CELValidation reports whether CRD validation rules written in CEL are enabled.
+	CELValidation bool
+	// GatewayAPI reports whether the Gateway API CRDs are installed.
+	GatewayAPI bool
 }
 
-// SupportsNetV1Beta1IngressClasses reports whether the Kubernetes cluster supports net v1beta1 Ingresses.
-func SupportsNetV1Beta1IngressClasses(ver string) bool {
-	return atLeast(ver, "1.18")
+// Names returns the names of the available capabilities.
+func (c Capabilities) Names() []string {
+	var names []string
+	if c.ServerSideApply {
+		names = append(names, CapabilityServerSideApply)
+	}
+	if c.CELValidation {
+		names = append(names, CapabilityCELValidation)
+	}
+	if c.GatewayAPI {
+		names = append(names, CapabilityGatewayAPI)
+	}
+
+	return names
 }
 
-// SupportsNetV1IngressClasses reports whether the Kubernetes cluster supports net v1 IngressClasses.
-func SupportsNetV1IngressClasses(ver string) bool {
-	return atLeast(ver, "1.19")
+// DetectCapabilities detects the capabilities of the Kubernetes cluster running the given version.
+func DetectCapabilities(client discovery.ServerGroupsInterface, ver string) (Capabilities, error) {
+	if !IsSupported(ver) {
+		return Capabilities{}, fmt.Errorf("unsupported Kubernetes version %s, the minimum supported version is %s", ver, MinVersion)
+	}
+
+	gatewayAPI, err := hasGroup(client, gatewayAPIGroup)
+	if err != nil {
+		return Capabilities{}, fmt.Errorf("detect Gateway API: %w", err)
+	}
+
+	return Capabilities{
+		ServerSideApply: SupportsServerSideApply(ver),
+		CELValidation:   SupportsCELValidation(ver),
+		GatewayAPI:      gatewayAPI,
+	}, nil
 }
 
-// SupportsIngressClasses reports whether the Kubernetes cluster supports IngressClasses.
-func SupportsIngressClasses(ver string) bool {
-	return atLeast(ver, "1.18")
+// IsSupported reports whether the Kubernetes version is supported by the agent.
+func IsSupported(ver string) bool {
+	kubeVersion, err := version.NewSemver(ver)
+	if err != nil {
+		return false
+	}
+
+	return kubeVersion.GreaterThanOrEqual(version.Must(version.NewSemver(MinVersion)))
+}
+
+// SupportsServerSideApply reports whether server-side apply is generally available in the Kubernetes cluster.
+func SupportsServerSideApply(ver string) bool {
+	return atLeast(ver, "1.22")
+}
+
+// SupportsCELValidation reports whether the Kubernetes cluster enables CRD validation rules written in CEL.
+func SupportsCELValidation(ver string) bool {
+	return atLeast(ver, "1.25")
 }
 
 func atLeast(ver, minVer string) bool {
@@ -30,3 +92,21 @@ func atLeast(ver, minVer string) bool {
 
 	return kubeVersion.GreaterThanOrEqual(minVersion)
 }
+
+func hasGroup(client discovery.ServerGroupsInterface, group string) (bool, error) {
+	groups, err := client.ServerGroups()
+	if err != nil {
+		if kerror.IsNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	for _, g := range groups.Groups {
+		if g.Name == group {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
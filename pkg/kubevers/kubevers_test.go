@@ -0,0 +1,73 @@
+package kubevers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	kubetesting "k8s.io/client-go/testing"
+)
+
+func TestDetectCapabilities(t *testing.T) {
+	tests := []struct {
+		desc      string
+		version   string
+		resources []*metav1.APIResourceList
+		want      Capabilities
+		wantErr   bool
+	}{
+		{
+			desc:    "unsupported version",
+			version: "v1.18.20",
+			wantErr: true,
+		},
+		{
+			desc:    "invalid version",
+			version: "invalid",
+			wantErr: true,
+		},
+		{
+			desc:    "v1.19",
+			version: "v1.19.16",
+			want:    Capabilities{},
+		},
+		{
+			desc:    "v1.22",
+			version: "v1.22.17",
+			want:    Capabilities{ServerSideApply: true},
+		},
+		{
+			desc:    "v1.28 with the Gateway API",
+			version: "v1.28.2",
+			resources: []*metav1.APIResourceList{
+				{GroupVersion: "gateway.networking.k8s.io/v1"},
+			},
+			want: Capabilities{ServerSideApply: true, CELValidation: true, GatewayAPI: true},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			client := &fakediscovery.FakeDiscovery{Fake: &kubetesting.Fake{Resources: test.resources}}
+
+			got, err := DetectCapabilities(client, test.version)
+			if test.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, test.want, got)
+		})
+	}
+}
+
+func TestCapabilities_Names(t *testing.T) {
+	assert.Nil(t, Capabilities{}.Names())
+	assert.Equal(t, []string{"server-side-apply", "gateway-api"}, Capabilities{ServerSideApply: true, GatewayAPI: true}.Names())
+}
@@ -18,35 +18,44 @@ along with this program. If not, see <https://www.gnu.org/licenses/>.
 package logger
 
 import (
+	"encoding/json"
+	"fmt"
 	"io"
+	"net/http"
 	"os"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 )
 
+// Config holds the logger configuration.
+type Config struct {
+	Level  string `json:"level"`
+	Format string `json:"format"`
+}
+
+var (
+	// output is the writer of all loggers. Its underlying writer is swapped when the format changes, so loggers
+	// derived from the global one follow the format changes too.
+	output = &switchWriter{w: os.Stderr}
+
+	configMu      sync.Mutex
+	startupConfig Config
+	currentConfig Config
+)
+
 // Setup configures the logger.
 func Setup(level, format string) {
 	zerolog.TimeFieldFormat = zerolog.TimeFormatUnix
 
-	var w io.Writer
-	switch format {
-	case "json":
-		w = os.Stderr
-	case "console":
-		w = zerolog.ConsoleWriter{
-			Out:        os.Stderr,
-			TimeFormat: time.RFC3339,
-		}
-	default:
-		w = os.Stderr
-	}
-
-	log.Logger = zerolog.New(w).With().Timestamp().Logger()
+	log.Logger = zerolog.New(output).With().Timestamp().Logger()
 	zerolog.DefaultContextLogger = &log.Logger
 
+	output.Set(newWriter(format))
+
 	logLevel := zerolog.InfoLevel
 	if level != "" {
 		var err error
@@ -60,5 +69,108 @@ func Setup(level, format string) {
 
 	zerolog.SetGlobalLevel(logLevel)
 
+	configMu.Lock()
+	startupConfig = Config{Level: logLevel.String(), Format: format}
+	currentConfig = startupConfig
+	configMu.Unlock()
+
 	log.Trace().Str("level", logLevel.String()).Msg("Log level set")
 }
+
+// Update changes the level and format of all loggers at runtime. An empty level or format restores the one given
+// to Setup.
+func Update(level, format string) error {
+	configMu.Lock()
+	defer configMu.Unlock()
+
+	cfg := startupConfig
+	if level != "" {
+		logLevel, err := zerolog.ParseLevel(strings.ToLower(level))
+		if err != nil {
+			return fmt.Errorf("parse log level: %w", err)
+		}
+		cfg.Level = logLevel.String()
+	}
+	if format != "" {
+		if format != "json" && format != "console" {
+			return fmt.Errorf("unsupported log format %q", format)
+		}
+		cfg.Format = format
+	}
+
+	if cfg == currentConfig {
+		return nil
+	}
+
+	logLevel, err := zerolog.ParseLevel(cfg.Level)
+	if err != nil {
+		return fmt.Errorf("parse log level: %w", err)
+	}
+
+	output.Set(newWriter(cfg.Format))
+	zerolog.SetGlobalLevel(logLevel)
+
+	currentConfig = cfg
+
+	log.Info().Str("level", cfg.Level).Str("format", cfg.Format).Msg("Logger configuration updated")
+
+	return nil
+}
+
+// Current returns the current logger configuration.
+func Current() Config {
+	configMu.Lock()
+	defer configMu.Unlock()
+
+	return currentConfig
+}
+
+// Handler returns an HTTP handler exposing the current logger configuration on GET requests. The handler is read-only:
+// the configuration is only updated from the platform configuration.
+func Handler() http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodGet {
+			rw.Header().Set("Allow", "GET")
+			http.Error(rw, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+
+		rw.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(rw).Encode(Current()); err != nil {
+			log.Error().Err(err).Msg("Unable to encode logger configuration")
+		}
+	})
+}
+
+func newWriter(format string) io.Writer {
+	if format == "console" {
+		return zerolog.ConsoleWriter{
+			Out:        os.Stderr,
+			TimeFormat: time.RFC3339,
+		}
+	}
+
+	return os.Stderr
+}
+
+// switchWriter is an io.Writer whose underlying writer can be swapped concurrently.
+type switchWriter struct {
+	mu sync.RWMutex
+	w  io.Writer
+}
+
+// Set swaps the underlying writer.
+func (s *switchWriter) Set(w io.Writer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.w = w
+}
+
+// Write writes p to the current underlying writer.
+func (s *switchWriter) Write(p []byte) (int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.w.Write(p)
+}
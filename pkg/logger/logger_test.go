@@ -0,0 +1,119 @@
+/*
+Copyright (C) 2022 Traefik Labs
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package logger
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// These tests update the global loggers configuration, they must not run in parallel.
+
+func TestUpdate(t *testing.T) {
+	Setup("info", "json")
+	t.Cleanup(func() { Setup("info", "json") })
+
+	err := Update("debug", "console")
+	require.NoError(t, err)
+	assert.Equal(t, Config{Level: "debug", Format: "console"}, Current())
+	assert.Equal(t, zerolog.DebugLevel, zerolog.GlobalLevel())
+
+	err = Update("WARN", "")
+	require.NoError(t, err)
+	assert.Equal(t, Config{Level: "warn", Format: "json"}, Current())
+	assert.Equal(t, zerolog.WarnLevel, zerolog.GlobalLevel())
+
+	err = Update("", "")
+	require.NoError(t, err)
+	assert.Equal(t, Config{Level: "info", Format: "json"}, Current())
+	assert.Equal(t, zerolog.InfoLevel, zerolog.GlobalLevel())
+
+	err = Update("verbose", "")
+	assert.Error(t, err)
+	err = Update("", "xml")
+	assert.Error(t, err)
+	assert.Equal(t, Config{Level: "info", Format: "json"}, Current())
+}
+
+func TestHandler(t *testing.T) {
+	Setup("info", "json")
+	t.Cleanup(func() { Setup("info", "json") })
+
+	tests := []struct {
+		desc       string
+		method     string
+		body       string
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			desc:       "get configuration",
+			method:     http.MethodGet,
+			wantStatus: http.StatusOK,
+			wantBody:   `{"level":"info","format":"json"}`,
+		},
+		{
+			desc:       "update not allowed",
+			method:     http.MethodPut,
+			body:       `{"level":"trace"}`,
+			wantStatus: http.StatusMethodNotAllowed,
+		},
+		{
+			desc:       "unsupported method",
+			method:     http.MethodPost,
+			wantStatus: http.StatusMethodNotAllowed,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(test.method, "/logger", strings.NewReader(test.body))
+
+			Handler().ServeHTTP(rec, req)
+
+			assert.Equal(t, test.wantStatus, rec.Code)
+			if test.wantBody != "" {
+				assert.JSONEq(t, test.wantBody, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestSwitchWriter(t *testing.T) {
+	var first, second bytes.Buffer
+
+	w := &switchWriter{w: &first}
+	logger := zerolog.New(w)
+
+	logger.Info().Msg("first")
+	w.Set(&second)
+	logger.Info().Msg("second")
+
+	assert.Contains(t, first.String(), `"message":"first"`)
+	assert.NotContains(t, first.String(), `"message":"second"`)
+	assert.Contains(t, second.String(), `"message":"second"`)
+}
@@ -17,6 +17,10 @@ along with this program. If not, see <https://www.gnu.org/licenses/>.
 
 package metrics
 
+// ResponseTimeBuckets are the upper bounds, in seconds, of the response time buckets held by data points. Response
+// times above the last bound are counted in an extra bucket.
+var ResponseTimeBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
 // DataPoints contains a slice of data points.
 type DataPoints []DataPoint
 
@@ -41,6 +45,7 @@ func (p DataPoints) Aggregate() DataPoint {
 		newPnt.RequestClientErrs += pnt.RequestClientErrs
 		newPnt.ResponseTimeSum += pnt.ResponseTimeSum
 		newPnt.ResponseTimeCount += pnt.ResponseTimeCount
+		newPnt.ResponseTimeBuckets = addBuckets(newPnt.ResponseTimeBuckets, pnt.ResponseTimeBuckets)
 	}
 
 	if newPnt.Seconds > 0 {
@@ -56,16 +61,19 @@ func (p DataPoints) Aggregate() DataPoint {
 		newPnt.RequestErrPercent = float64(newPnt.RequestErrs) / float64(newPnt.Requests)
 		newPnt.RequestClientErrPercent = float64(newPnt.RequestClientErrs) / float64(newPnt.Requests)
 	}
+	newPnt.ResponseTimeP95 = responseTimePercentile(newPnt.ResponseTimeBuckets, 0.95)
+	newPnt.ResponseTimeP99 = responseTimePercentile(newPnt.ResponseTimeBuckets, 0.99)
 
 	return newPnt
 }
 
 // DataPointGroup contains a unique group of data points (primary keys).
 type DataPointGroup struct {
-	Ingress     string      `avro:"ingress"`
-	EdgeIngress string      `avro:"edge_ingress"`
-	Service     string      `avro:"service"`
-	DataPoints  []DataPoint `avro:"data_points"`
+	Ingress      string      `avro:"ingress"`
+	EdgeIngress  string      `avro:"edge_ingress"`
+	IngressRoute string      `avro:"ingress_route"`
+	Service      string      `avro:"service"`
+	DataPoints   []DataPoint `avro:"data_points"`
 }
 
 // DataPoint contains fully aggregated metrics.
@@ -78,6 +86,8 @@ type DataPoint struct {
 	RequestClientErrPerS    float64 `avro:"request_client_error_per_s"`
 	RequestClientErrPercent float64 `avro:"request_client_error_per"`
 	AvgResponseTime         float64 `avro:"avg_response_time"`
+	ResponseTimeP95         float64 `avro:"response_time_p95"`
+	ResponseTimeP99         float64 `avro:"response_time_p99"`
 
 	Seconds           int64   `avro:"seconds"`
 	Requests          int64   `avro:"requests"`
@@ -85,13 +95,18 @@ type DataPoint struct {
 	RequestClientErrs int64   `avro:"request_client_errors"`
 	ResponseTimeSum   float64 `avro:"response_time_sum"`
 	ResponseTimeCount int64   `avro:"response_time_count"`
+
+	// ResponseTimeBuckets holds the number of responses in each of the ResponseTimeBuckets, plus the number of
+	// responses above the last bound. It's empty when the ingress controller doesn't expose response time histograms.
+	ResponseTimeBuckets []int64 `avro:"response_time_buckets"`
 }
 
 // SetKey contains the primary key of a metric set.
 type SetKey struct {
-	EdgeIngress string
-	Ingress     string
-	Service     string
+	EdgeIngress  string
+	Ingress      string
+	IngressRoute string
+	Service      string
 }
 
 // MetricSet contains assembled metrics for an ingress or service.
@@ -114,10 +129,24 @@ func (s MetricSet) RelativeTo(o MetricSet) MetricSet {
 	if !o.RequestDuration.Relative {
 		s.RequestDuration.Sum -= o.RequestDuration.Sum
 		s.RequestDuration.Count -= o.RequestDuration.Count
+		s.RequestDuration.Buckets = subBuckets(s.RequestDuration.Buckets, o.RequestDuration.Buckets)
 	}
 	return s
 }
 
+// add returns the sum of the metric sets s and o.
+func (s MetricSet) add(o MetricSet) MetricSet {
+	s.Requests += o.Requests
+	s.RequestErrors += o.RequestErrors
+	s.RequestClientErrors += o.RequestClientErrors
+	s.RequestDuration.Sum += o.RequestDuration.Sum
+	s.RequestDuration.Count += o.RequestDuration.Count
+	s.RequestDuration.Buckets = addBuckets(s.RequestDuration.Buckets, o.RequestDuration.Buckets)
+	s.RequestDuration.Relative = s.RequestDuration.Relative || o.RequestDuration.Relative
+
+	return s
+}
+
 // ToDataPoint returns a data point calculated from s.
 func (s MetricSet) ToDataPoint(secs int64) DataPoint {
 	var responseTime, errPercent, clientErrPercent float64
@@ -141,6 +170,9 @@ func (s MetricSet) ToDataPoint(secs int64) DataPoint {
 		RequestClientErrs:       s.RequestClientErrors,
 		ResponseTimeSum:         s.RequestDuration.Sum,
 		ResponseTimeCount:       s.RequestDuration.Count,
+		ResponseTimeBuckets:     s.RequestDuration.Buckets,
+		ResponseTimeP95:         responseTimePercentile(s.RequestDuration.Buckets, 0.95),
+		ResponseTimeP99:         responseTimePercentile(s.RequestDuration.Buckets, 0.99),
 	}
 }
 
@@ -149,6 +181,8 @@ type ServiceHistogram struct {
 	Relative bool
 	Sum      float64
 	Count    int64
+	// Buckets holds the number of requests in each of the ResponseTimeBuckets, plus the requests above the last bound.
+	Buckets []int64
 }
 
 // Aggregate aggregates metrics into a service metric set.
@@ -156,7 +190,12 @@ func Aggregate(m []Metric) map[SetKey]MetricSet {
 	svcs := map[SetKey]MetricSet{}
 
 	for _, metric := range m {
-		key := SetKey{Ingress: metric.IngressName(), Service: metric.ServiceName(), EdgeIngress: metric.EdgeIngressName()}
+		key := SetKey{
+			Ingress:      metric.IngressName(),
+			Service:      metric.ServiceName(),
+			EdgeIngress:  metric.EdgeIngressName(),
+			IngressRoute: metric.IngressRouteName(),
+		}
 		svc := svcs[key]
 
 		switch val := metric.(type) {
@@ -180,6 +219,7 @@ func Aggregate(m []Metric) map[SetKey]MetricSet {
 			dur := svc.RequestDuration
 			dur.Sum += val.Sum
 			dur.Count += int64(val.Count)
+			dur.Buckets = addBuckets(dur.Buckets, val.normalizedBuckets())
 			dur.Relative = val.Relative
 			svc.RequestDuration = dur
 		}
@@ -189,3 +229,109 @@ func Aggregate(m []Metric) map[SetKey]MetricSet {
 
 	return svcs
 }
+
+// AggregateServices rolls the given ingress and ingress route metric sets up into per-service metric sets, keyed by
+// service only, services being identified as in the topology (name@namespace). The sets of all the ingresses and ingress
+// routes of a service are summed. The metrics of an ingress can only be attributed to a service when the ingress routes
+// all its traffic to it, ingresses routing to several services are therefore left out.
+func AggregateServices(sets map[SetKey]MetricSet, ingressServices, ingressRouteServices map[string][]string) map[SetKey]MetricSet {
+	res := make(map[SetKey]MetricSet, len(sets))
+	svcSets := make(map[SetKey]MetricSet)
+	for key, set := range sets {
+		res[key] = set
+
+		if key.Service != "" {
+			continue
+		}
+
+		var svcs []string
+		switch {
+		case key.EdgeIngress != "":
+			svcs = ingressServices[key.EdgeIngress]
+		case key.IngressRoute != "":
+			svcs = ingressRouteServices[key.IngressRoute]
+		}
+		if len(svcs) != 1 {
+			continue
+		}
+
+		svcKey := SetKey{Service: svcs[0]}
+		svcSets[svcKey] = svcSets[svcKey].add(set)
+	}
+
+	for key, set := range svcSets {
+		res[key] = set
+	}
+
+	return res
+}
+
+// ResponseTimePercentile estimates the q-quantile of the response times of the data point. It's zero when the ingress
+// controller doesn't expose response time histograms.
+func (d DataPoint) ResponseTimePercentile(q float64) float64 {
+	return responseTimePercentile(d.ResponseTimeBuckets, q)
+}
+
+// addBuckets returns the sum of the given response time buckets.
+func addBuckets(a, b []int64) []int64 {
+	if len(b) == 0 {
+		return a
+	}
+
+	sum := make([]int64, len(ResponseTimeBuckets)+1)
+	copy(sum, a)
+	for i := range b {
+		sum[i] += b[i]
+	}
+
+	return sum
+}
+
+// subBuckets returns the difference of the given response time buckets.
+func subBuckets(a, b []int64) []int64 {
+	if len(a) == 0 || len(b) == 0 {
+		return a
+	}
+
+	diff := make([]int64, len(a))
+	for i := range a {
+		diff[i] = a[i] - b[i]
+	}
+
+	return diff
+}
+
+// responseTimePercentile estimates the q-quantile of response times from the given buckets, assuming response times
+// are evenly distributed within each bucket. Response times above the last bound are estimated at the last bound.
+func responseTimePercentile(buckets []int64, q float64) float64 {
+	var total int64
+	for _, count := range buckets {
+		total += count
+	}
+	if total == 0 {
+		return 0
+	}
+
+	rank := q * float64(total)
+
+	var cumulative int64
+	for i, count := range buckets {
+		if count == 0 || float64(cumulative+count) < rank {
+			cumulative += count
+			continue
+		}
+
+		if i == len(ResponseTimeBuckets) {
+			return ResponseTimeBuckets[i-1]
+		}
+
+		var lower float64
+		if i > 0 {
+			lower = ResponseTimeBuckets[i-1]
+		}
+
+		return lower + (ResponseTimeBuckets[i]-lower)*(rank-float64(cumulative))/float64(count)
+	}
+
+	return ResponseTimeBuckets[len(ResponseTimeBuckets)-1]
+}
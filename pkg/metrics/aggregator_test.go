@@ -18,6 +18,7 @@ along with this program. If not, see <https://www.gnu.org/licenses/>.
 package metrics_test
 
 import (
+	"math"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -166,3 +167,150 @@ func TestAggregator_Aggregate(t *testing.T) {
 		},
 	})
 }
+
+func TestAggregator_AggregateResponseTimeBuckets(t *testing.T) {
+	ms := []metrics.Metric{
+		&metrics.Histogram{
+			Name:    metrics.MetricRequestDuration,
+			Ingress: "myIngress",
+			Sum:     1,
+			Count:   10,
+			Buckets: map[float64]uint64{0.1: 6, 0.3: 9, math.Inf(1): 10},
+		},
+		&metrics.Histogram{
+			Name:    metrics.MetricRequestDuration,
+			Ingress: "myIngress",
+			Sum:     0.5,
+			Count:   10,
+			Buckets: map[float64]uint64{0.1: 10, math.Inf(1): 10},
+		},
+	}
+
+	svcs := metrics.Aggregate(ms)
+
+	require.Len(t, svcs, 1)
+
+	set := svcs[metrics.SetKey{Ingress: "myIngress"}]
+	// Bounds not matching the normalized ones count their observations in the next normalized bucket.
+	assert.Equal(t, []int64{0, 0, 0, 0, 16, 0, 3, 0, 0, 0, 0, 1}, set.RequestDuration.Buckets)
+
+	pnt := set.ToDataPoint(60)
+	assert.Equal(t, set.RequestDuration.Buckets, pnt.ResponseTimeBuckets)
+	assert.InDelta(t, 0.5, pnt.ResponseTimeP95, 1e-9)
+	// Response times above the last bound are estimated at the last bound.
+	assert.InDelta(t, 10, pnt.ResponseTimeP99, 1e-9)
+}
+
+func TestDataPoints_AggregateResponseTimeBuckets(t *testing.T) {
+	pnts := metrics.DataPoints{
+		{ResponseTimeBuckets: []int64{0, 0, 0, 0, 50, 0, 0, 0, 0, 0, 0, 0}},
+		{ResponseTimeBuckets: []int64{0, 0, 0, 0, 0, 40, 10, 0, 0, 0, 0, 0}},
+		{},
+	}
+
+	got := pnts.Aggregate()
+
+	assert.Equal(t, []int64{0, 0, 0, 0, 50, 40, 10, 0, 0, 0, 0, 0}, got.ResponseTimeBuckets)
+	assert.InDelta(t, 0.375, got.ResponseTimeP95, 1e-9)
+	assert.InDelta(t, 0.475, got.ResponseTimeP99, 1e-9)
+}
+
+func TestAggregateServices(t *testing.T) {
+	sets := map[metrics.SetKey]metrics.MetricSet{
+		{EdgeIngress: "myIngress@default"}: {
+			Requests:      10,
+			RequestErrors: 2,
+			RequestDuration: metrics.ServiceHistogram{
+				Sum:   1,
+				Count: 10,
+			},
+		},
+		{EdgeIngress: "multi@default"}:                             {Requests: 5},
+		{EdgeIngress: "unknown@default"}:                           {Requests: 3},
+		{Ingress: "other@default", Service: "whoami@default"}:      {Requests: 1},
+		{EdgeIngress: "myIngress@default", Service: "foo@default"}: {Requests: 4},
+		{IngressRoute: "myIngress@default"}:                        {Requests: 7},
+		{EdgeIngress: "shared@default"}: {
+			Requests:            6,
+			RequestErrors:       1,
+			RequestClientErrors: 2,
+			RequestDuration: metrics.ServiceHistogram{
+				Sum:     0.5,
+				Count:   6,
+				Buckets: []int64{0, 0, 0, 0, 6, 0, 0, 0, 0, 0, 0, 0},
+			},
+		},
+		{EdgeIngress: "shared2@default"}: {
+			Requests:      4,
+			RequestErrors: 3,
+			RequestDuration: metrics.ServiceHistogram{
+				Sum:     2,
+				Count:   4,
+				Buckets: []int64{0, 0, 0, 0, 0, 0, 0, 4, 0, 0, 0, 0},
+			},
+		},
+	}
+
+	got := metrics.AggregateServices(sets, map[string][]string{
+		"myIngress@default": {"whoami@default"},
+		"multi@default":     {"whoami@default", "whoami2@default"},
+		"shared@default":    {"shared@default"},
+		"shared2@default":   {"shared@default"},
+	}, map[string][]string{
+		"myIngress@default": {"whoami3@default"},
+	})
+
+	assert.Equal(t, map[metrics.SetKey]metrics.MetricSet{
+		{EdgeIngress: "myIngress@default"}: {
+			Requests:      10,
+			RequestErrors: 2,
+			RequestDuration: metrics.ServiceHistogram{
+				Sum:   1,
+				Count: 10,
+			},
+		},
+		{Service: "whoami@default"}: {
+			Requests:      10,
+			RequestErrors: 2,
+			RequestDuration: metrics.ServiceHistogram{
+				Sum:   1,
+				Count: 10,
+			},
+		},
+		{EdgeIngress: "multi@default"}:                             {Requests: 5},
+		{EdgeIngress: "unknown@default"}:                           {Requests: 3},
+		{Ingress: "other@default", Service: "whoami@default"}:      {Requests: 1},
+		{EdgeIngress: "myIngress@default", Service: "foo@default"}: {Requests: 4},
+		{IngressRoute: "myIngress@default"}:                        {Requests: 7},
+		{Service: "whoami3@default"}:                               {Requests: 7},
+		{EdgeIngress: "shared@default"}: {
+			Requests:            6,
+			RequestErrors:       1,
+			RequestClientErrors: 2,
+			RequestDuration: metrics.ServiceHistogram{
+				Sum:     0.5,
+				Count:   6,
+				Buckets: []int64{0, 0, 0, 0, 6, 0, 0, 0, 0, 0, 0, 0},
+			},
+		},
+		{EdgeIngress: "shared2@default"}: {
+			Requests:      4,
+			RequestErrors: 3,
+			RequestDuration: metrics.ServiceHistogram{
+				Sum:     2,
+				Count:   4,
+				Buckets: []int64{0, 0, 0, 0, 0, 0, 0, 4, 0, 0, 0, 0},
+			},
+		},
+		{Service: "shared@default"}: {
+			Requests:            10,
+			RequestErrors:       4,
+			RequestClientErrors: 2,
+			RequestDuration: metrics.ServiceHistogram{
+				Sum:     2.5,
+				Count:   10,
+				Buckets: []int64{0, 0, 0, 0, 6, 0, 0, 4, 0, 0, 0, 0},
+			},
+		},
+	}, got)
+}
@@ -0,0 +1,217 @@
+/*
+Copyright (C) 2022 Traefik Labs
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package metrics
+
+import (
+	"fmt"
+	"sort"
+)
+
+// OtherGroup is the name of the ingresses, edge ingresses and services under which the data points of the groups
+// beyond the cardinality limit are aggregated. It can't collide with actual names, which are namespaced.
+const OtherGroup = "other"
+
+// CardinalityStrategy is the criteria by which data point groups are ranked when limiting cardinality.
+type CardinalityStrategy string
+
+// Cardinality strategies.
+const (
+	// CardinalityStrategyRequests keeps the groups receiving the most requests.
+	CardinalityStrategyRequests CardinalityStrategy = "requests"
+	// CardinalityStrategyErrors keeps the groups returning the most errors, including client errors.
+	CardinalityStrategyErrors CardinalityStrategy = "errors"
+	// CardinalityStrategyResponseTime keeps the groups spending the most time answering requests.
+	CardinalityStrategyResponseTime CardinalityStrategy = "responseTime"
+)
+
+// Validate checks the strategy is supported.
+func (s CardinalityStrategy) Validate() error {
+	switch s {
+	case CardinalityStrategyRequests, CardinalityStrategyErrors, CardinalityStrategyResponseTime:
+		return nil
+	default:
+		return fmt.Errorf("unsupported cardinality strategy %q", s)
+	}
+}
+
+// CardinalityLimit limits the number of data point groups sent, to keep payloads small on clusters with lots of
+// ingresses and services.
+type CardinalityLimit struct {
+	// MaxGroups is the number of top groups kept in full detail, for each kind of group (services, ingresses,
+	// ingresses of services...). Other groups are aggregated into a single group. Zero means no limit.
+	MaxGroups int
+	// Strategy is how groups are ranked. Defaults to CardinalityStrategyRequests.
+	Strategy CardinalityStrategy
+}
+
+// Apply returns the given groups, keeping the top groups of each kind and aggregating the others into a group named
+// after OtherGroup.
+func (l CardinalityLimit) Apply(groups []DataPointGroup) []DataPointGroup {
+	if l.MaxGroups <= 0 || len(groups) <= l.MaxGroups {
+		return groups
+	}
+
+	byKind := make(map[groupKind][]DataPointGroup)
+	var kinds []groupKind
+	for _, group := range groups {
+		kind := kindOf(group)
+		if _, ok := byKind[kind]; !ok {
+			kinds = append(kinds, kind)
+		}
+		byKind[kind] = append(byKind[kind], group)
+	}
+
+	limited := make([]DataPointGroup, 0, len(kinds)*(l.MaxGroups+1))
+	for _, kind := range kinds {
+		kindGroups := byKind[kind]
+		if len(kindGroups) <= l.MaxGroups {
+			limited = append(limited, kindGroups...)
+			continue
+		}
+
+		scores := make(map[tableKey]float64, len(kindGroups))
+		for _, group := range kindGroups {
+			scores[toTableKey(group)] = l.score(group.DataPoints)
+		}
+
+		sort.SliceStable(kindGroups, func(i, j int) bool {
+			si, sj := scores[toTableKey(kindGroups[i])], scores[toTableKey(kindGroups[j])]
+			if si != sj {
+				return si > sj
+			}
+			return lessTableKey(toTableKey(kindGroups[i]), toTableKey(kindGroups[j]))
+		})
+
+		limited = append(limited, kindGroups[:l.MaxGroups]...)
+		limited = append(limited, kind.otherGroup(kindGroups[l.MaxGroups:]))
+	}
+
+	return limited
+}
+
+func (l CardinalityLimit) score(pnts []DataPoint) float64 {
+	var score float64
+	for _, pnt := range pnts {
+		switch l.Strategy {
+		case CardinalityStrategyErrors:
+			score += float64(pnt.RequestErrs + pnt.RequestClientErrs)
+		case CardinalityStrategyResponseTime:
+			score += pnt.ResponseTimeSum
+		default:
+			score += float64(pnt.Requests)
+		}
+	}
+
+	return score
+}
+
+// groupKind tells which keys of a data point group are set.
+type groupKind struct {
+	edgeIngress  bool
+	ingress      bool
+	ingressRoute bool
+	service      bool
+}
+
+func kindOf(group DataPointGroup) groupKind {
+	return groupKind{
+		edgeIngress:  group.EdgeIngress != "",
+		ingress:      group.Ingress != "",
+		ingressRoute: group.IngressRoute != "",
+		service:      group.Service != "",
+	}
+}
+
+// otherGroup aggregates the given groups, of this kind, into a single group.
+func (k groupKind) otherGroup(groups []DataPointGroup) DataPointGroup {
+	var other DataPointGroup
+	if k.edgeIngress {
+		other.EdgeIngress = OtherGroup
+	}
+	if k.ingress {
+		other.Ingress = OtherGroup
+	}
+	if k.ingressRoute {
+		other.IngressRoute = OtherGroup
+	}
+	if k.service {
+		other.Service = OtherGroup
+	}
+
+	sums := make(map[int64]DataPoint)
+	for _, group := range groups {
+		for _, pnt := range group.DataPoints {
+			sum, ok := sums[pnt.Timestamp]
+			if !ok {
+				sum = DataPoint{Timestamp: pnt.Timestamp, Seconds: pnt.Seconds}
+			}
+
+			sum.Requests += pnt.Requests
+			sum.RequestErrs += pnt.RequestErrs
+			sum.RequestClientErrs += pnt.RequestClientErrs
+			sum.ResponseTimeSum += pnt.ResponseTimeSum
+			sum.ResponseTimeCount += pnt.ResponseTimeCount
+			sum.ResponseTimeBuckets = addBuckets(sum.ResponseTimeBuckets, pnt.ResponseTimeBuckets)
+
+			sums[pnt.Timestamp] = sum
+		}
+	}
+
+	for _, sum := range sums {
+		// Aggregating a single point computes its rates and percentiles from its counts.
+		pnt := DataPoints{sum}.Aggregate()
+		pnt.Timestamp = sum.Timestamp
+
+		other.DataPoints = append(other.DataPoints, pnt)
+	}
+
+	sort.Slice(other.DataPoints, func(i, j int) bool {
+		return other.DataPoints[i].Timestamp < other.DataPoints[j].Timestamp
+	})
+
+	return other
+}
+
+// withoutOtherGroups returns the given groups, except the ones aggregating groups beyond the cardinality limit, which
+// must not be populated back into the store.
+func withoutOtherGroups(groups []DataPointGroup) []DataPointGroup {
+	kept := make([]DataPointGroup, 0, len(groups))
+	for _, group := range groups {
+		if group.EdgeIngress == OtherGroup || group.Ingress == OtherGroup || group.IngressRoute == OtherGroup ||
+			group.Service == OtherGroup {
+			continue
+		}
+		kept = append(kept, group)
+	}
+
+	return kept
+}
+
+func lessTableKey(a, b tableKey) bool {
+	if a.EdgeIngress != b.EdgeIngress {
+		return a.EdgeIngress < b.EdgeIngress
+	}
+	if a.Ingress != b.Ingress {
+		return a.Ingress < b.Ingress
+	}
+	if a.IngressRoute != b.IngressRoute {
+		return a.IngressRoute < b.IngressRoute
+	}
+
+	return a.Service < b.Service
+}
@@ -0,0 +1,158 @@
+/*
+Copyright (C) 2022 Traefik Labs
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package metrics_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/traefik/hub-agent-kubernetes/pkg/metrics"
+)
+
+func TestCardinalityLimit_Apply(t *testing.T) {
+	groups := []metrics.DataPointGroup{
+		{Service: "whoami@default", DataPoints: []metrics.DataPoint{{Timestamp: 60, Seconds: 60, Requests: 10, RequestErrs: 5}}},
+		{Service: "api@default", DataPoints: []metrics.DataPoint{{Timestamp: 60, Seconds: 60, Requests: 100}}},
+		{Service: "web@default", DataPoints: []metrics.DataPoint{{Timestamp: 60, Seconds: 60, Requests: 50, ResponseTimeSum: 60, ResponseTimeCount: 50}}},
+		{Ingress: "api@default.ingress.networking.k8s.io", DataPoints: []metrics.DataPoint{{Timestamp: 60, Seconds: 60, Requests: 100}}},
+	}
+
+	tests := []struct {
+		desc  string
+		limit metrics.CardinalityLimit
+		want  []string
+	}{
+		{
+			desc:  "no limit",
+			limit: metrics.CardinalityLimit{},
+			want:  []string{"whoami@default", "api@default", "web@default", "api@default.ingress.networking.k8s.io"},
+		},
+		{
+			desc:  "requests",
+			limit: metrics.CardinalityLimit{MaxGroups: 1, Strategy: metrics.CardinalityStrategyRequests},
+			want:  []string{"api@default", "other", "api@default.ingress.networking.k8s.io"},
+		},
+		{
+			desc:  "errors",
+			limit: metrics.CardinalityLimit{MaxGroups: 1, Strategy: metrics.CardinalityStrategyErrors},
+			want:  []string{"whoami@default", "other", "api@default.ingress.networking.k8s.io"},
+		},
+		{
+			desc:  "response time",
+			limit: metrics.CardinalityLimit{MaxGroups: 2, Strategy: metrics.CardinalityStrategyResponseTime},
+			// Groups with the same score are ordered by name.
+			want: []string{"web@default", "api@default", "other", "api@default.ingress.networking.k8s.io"},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			input := make([]metrics.DataPointGroup, len(groups))
+			copy(input, groups)
+
+			got := test.limit.Apply(input)
+
+			var names []string
+			for _, group := range got {
+				names = append(names, group.Service+group.Ingress)
+			}
+			assert.Equal(t, test.want, names)
+		})
+	}
+}
+
+func TestCardinalityLimit_Apply_aggregatesOtherGroups(t *testing.T) {
+	groups := []metrics.DataPointGroup{
+		{
+			Ingress: "api@default.ingress.networking.k8s.io",
+			Service: "api@default",
+			DataPoints: []metrics.DataPoint{
+				{Timestamp: 60, Seconds: 60, Requests: 600},
+			},
+		},
+		{
+			Ingress: "web@default.ingress.networking.k8s.io",
+			Service: "web@default",
+			DataPoints: []metrics.DataPoint{
+				{Timestamp: 0, Seconds: 60, Requests: 60, RequestErrs: 6, ResponseTimeSum: 30, ResponseTimeCount: 60, ResponseTimeBuckets: []int64{0, 0, 0, 0, 0, 0, 60, 0, 0, 0, 0, 0}},
+				{Timestamp: 60, Seconds: 60, Requests: 120, RequestClientErrs: 12, ResponseTimeSum: 60, ResponseTimeCount: 120},
+			},
+		},
+		{
+			Ingress: "whoami@default.ingress.networking.k8s.io",
+			Service: "whoami@default",
+			DataPoints: []metrics.DataPoint{
+				{Timestamp: 60, Seconds: 60, Requests: 60, RequestErrs: 6, ResponseTimeSum: 60, ResponseTimeCount: 60},
+			},
+		},
+	}
+
+	limit := metrics.CardinalityLimit{MaxGroups: 1}
+
+	got := limit.Apply(groups)
+	require.Len(t, got, 2)
+
+	assert.Equal(t, groups[0], got[0])
+	assert.Equal(t, metrics.DataPointGroup{
+		Ingress: metrics.OtherGroup,
+		Service: metrics.OtherGroup,
+		DataPoints: []metrics.DataPoint{
+			{
+				Timestamp:           0,
+				ReqPerS:             1,
+				RequestErrPerS:      0.1,
+				RequestErrPercent:   0.1,
+				AvgResponseTime:     0.5,
+				ResponseTimeP95:     0.4875,
+				ResponseTimeP99:     0.4975,
+				Seconds:             60,
+				Requests:            60,
+				RequestErrs:         6,
+				ResponseTimeSum:     30,
+				ResponseTimeCount:   60,
+				ResponseTimeBuckets: []int64{0, 0, 0, 0, 0, 0, 60, 0, 0, 0, 0, 0},
+			},
+			{
+				Timestamp:               60,
+				ReqPerS:                 3,
+				RequestErrPerS:          0.1,
+				RequestErrPercent:       6.0 / 180,
+				RequestClientErrPerS:    0.2,
+				RequestClientErrPercent: 12.0 / 180,
+				AvgResponseTime:         0.6666666666666666,
+				Seconds:                 60,
+				Requests:                180,
+				RequestErrs:             6,
+				RequestClientErrs:       12,
+				ResponseTimeSum:         120,
+				ResponseTimeCount:       180,
+			},
+		},
+	}, got[1])
+}
+
+func TestCardinalityStrategy_Validate(t *testing.T) {
+	assert.NoError(t, metrics.CardinalityStrategyRequests.Validate())
+	assert.NoError(t, metrics.CardinalityStrategyErrors.Validate())
+	assert.NoError(t, metrics.CardinalityStrategyResponseTime.Validate())
+	assert.Error(t, metrics.CardinalityStrategy("random").Validate())
+}
@@ -25,17 +25,29 @@ import (
 	"net/http"
 	"net/url"
 	"path"
+	"sync"
 
 	"github.com/hamba/avro"
+	"github.com/rs/zerolog/log"
 	"github.com/traefik/hub-agent-kubernetes/pkg/metrics/protocol"
 )
 
+// Metrics transport schema versions. The agent falls back to the v2 schema when the platform doesn't accept the v3
+// one.
+const (
+	schemaV2 = "v2"
+	schemaV3 = "v3"
+)
+
 // Client for the token service.
 type Client struct {
 	baseURL    *url.URL
 	httpClient *http.Client
 
-	metricsSchema avro.Schema
+	schemas map[string]avro.Schema
+
+	versionMu sync.RWMutex
+	version   string
 
 	token string
 }
@@ -47,89 +59,166 @@ func NewClient(client *http.Client, baseURL, token string) (*Client, error) {
 		return nil, fmt.Errorf("invalid metrics client url: %w", err)
 	}
 
-	metricsSchema, err := avro.Parse(protocol.MetricsV2Schema)
+	schemaV2Def, err := avro.Parse(protocol.MetricsV2Schema)
+	if err != nil {
+		return nil, fmt.Errorf("invalid metrics v2 schema: %w", err)
+	}
+
+	schemaV3Def, err := avro.Parse(protocol.MetricsV3Schema)
 	if err != nil {
-		return nil, fmt.Errorf("invalid metrics schema: %w", err)
+		return nil, fmt.Errorf("invalid metrics v3 schema: %w", err)
 	}
 
 	return &Client{
-		baseURL:       base,
-		httpClient:    client,
-		metricsSchema: metricsSchema,
-		token:         token,
+		baseURL:    base,
+		httpClient: client,
+		schemas: map[string]avro.Schema{
+			schemaV2: schemaV2Def,
+			schemaV3: schemaV3Def,
+		},
+		version: schemaV3,
+		token:   token,
 	}, nil
 }
 
 // GetPreviousData gets the agent configuration.
 func (c *Client) GetPreviousData(ctx context.Context, startup bool) (map[string][]DataPointGroup, error) {
+	version := c.getVersion()
+
+	data, status, err := c.getPreviousData(ctx, version)
+	if err != nil && c.fallBack(version, status) {
+		data, _, err = c.getPreviousData(ctx, schemaV2)
+	}
+
+	return data, err
+}
+
+func (c *Client) getPreviousData(ctx context.Context, version string) (map[string][]DataPointGroup, int, error) {
 	endpoint, err := c.baseURL.Parse(path.Join(c.baseURL.Path, "data"))
 	if err != nil {
-		return nil, fmt.Errorf("creating metrics previous data url: %w", err)
+		return nil, 0, fmt.Errorf("creating metrics previous data url: %w", err)
 	}
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint.String(), http.NoBody)
 	if err != nil {
-		return nil, fmt.Errorf("creating request: %w", err)
+		return nil, 0, fmt.Errorf("creating request: %w", err)
 	}
 
 	c.setAuthHeader(req)
-	req.Header.Set("Accept", "avro/binary;v2")
+	req.Header.Set("Accept", "avro/binary;"+version)
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("getting metrics previous data: %w", err)
+		return nil, 0, fmt.Errorf("getting metrics previous data: %w", err)
 	}
 	defer func() { _ = resp.Body.Close() }()
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, fmt.Errorf("reading response body: %w", err)
+		return nil, 0, fmt.Errorf("reading response body: %w", err)
 	}
 
 	if resp.StatusCode/100 != 2 {
-		return nil, fmt.Errorf("getting metrics previous data got %d: %s", resp.StatusCode, string(body))
+		return nil, resp.StatusCode, fmt.Errorf("getting metrics previous data got %d: %s", resp.StatusCode, string(body))
 	}
 
 	data := map[string][]DataPointGroup{}
-	if err = avro.Unmarshal(c.metricsSchema, body, &data); err != nil {
-		return nil, fmt.Errorf("unmarshalling response: %w: %s", err, string(body))
+	if err = avro.Unmarshal(c.schemas[version], body, &data); err != nil {
+		return nil, resp.StatusCode, fmt.Errorf("unmarshalling response: %w: %s", err, string(body))
 	}
 
-	return data, nil
+	return data, resp.StatusCode, nil
 }
 
 // Send sends metrics to the metrics service.
 func (c *Client) Send(ctx context.Context, data map[string][]DataPointGroup) error {
+	version := c.getVersion()
+
+	status, err := c.send(ctx, version, data)
+	if err != nil && c.fallBack(version, status) {
+		_, err = c.send(ctx, schemaV2, data)
+	}
+
+	return err
+}
+
+func (c *Client) send(ctx context.Context, version string, data map[string][]DataPointGroup) (int, error) {
 	endpoint, err := c.baseURL.Parse(path.Join(c.baseURL.Path, "metrics"))
 	if err != nil {
-		return fmt.Errorf("creating metrics url: %w", err)
+		return 0, fmt.Errorf("creating metrics url: %w", err)
 	}
 
-	raw, err := avro.Marshal(c.metricsSchema, data)
+	if version == schemaV2 {
+		data = withoutIngressRouteGroups(data)
+	}
+
+	raw, err := avro.Marshal(c.schemas[version], data)
 	if err != nil {
-		return err
+		return 0, err
 	}
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint.String(), bytes.NewReader(raw))
 	if err != nil {
-		return fmt.Errorf("creating request: %w", err)
+		return 0, fmt.Errorf("creating request: %w", err)
 	}
 
 	c.setAuthHeader(req)
-	req.Header.Set("Content-Type", "avro/binary;v2")
+	req.Header.Set("Content-Type", "avro/binary;"+version)
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return fmt.Errorf("sending metrics: %w", err)
+		return 0, fmt.Errorf("sending metrics: %w", err)
 	}
 
 	defer func() { _ = resp.Body.Close() }()
 
 	if resp.StatusCode/100 != 2 {
 		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("sending metrics got %d: %s", resp.StatusCode, string(body))
+		return resp.StatusCode, fmt.Errorf("sending metrics got %d: %s", resp.StatusCode, string(body))
+	}
+
+	return resp.StatusCode, nil
+}
+
+// fallBack switches to the v2 schema if the platform rejected the given schema version with the given status code. It
+// returns whether the request must be retried.
+func (c *Client) fallBack(version string, status int) bool {
+	if version == schemaV2 || (status != http.StatusNotAcceptable && status != http.StatusUnsupportedMediaType) {
+		return false
+	}
+
+	c.versionMu.Lock()
+	defer c.versionMu.Unlock()
+
+	if c.version != schemaV2 {
+		log.Warn().Str("version", version).Msg("Metrics schema not supported by the platform, falling back to v2")
+		c.version = schemaV2
+	}
+
+	return true
+}
+
+func (c *Client) getVersion() string {
+	c.versionMu.RLock()
+	defer c.versionMu.RUnlock()
+
+	return c.version
+}
+
+// withoutIngressRouteGroups returns the given data without the groups of ingress routes, which can't be represented
+// in the v2 schema.
+func withoutIngressRouteGroups(data map[string][]DataPointGroup) map[string][]DataPointGroup {
+	filtered := make(map[string][]DataPointGroup, len(data))
+	for tbl, groups := range data {
+		for _, group := range groups {
+			if group.IngressRoute != "" {
+				continue
+			}
+
+			filtered[tbl] = append(filtered[tbl], group)
+		}
 	}
 
-	return nil
+	return filtered
 }
 
 func (c *Client) setAuthHeader(req *http.Request) {
@@ -31,13 +31,13 @@ import (
 )
 
 func TestClient_GetPreviousData(t *testing.T) {
-	schema, err := avro.Parse(protocol.MetricsV2Schema)
+	schema, err := avro.Parse(protocol.MetricsV3Schema)
 	require.NoError(t, err)
 
 	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		assert.Equal(t, "/data", r.URL.Path)
 		assert.Equal(t, "Bearer some_test_token", r.Header.Get("Authorization"))
-		assert.Equal(t, "avro/binary;v2", r.Header.Get("Accept"))
+		assert.Equal(t, "avro/binary;v3", r.Header.Get("Accept"))
 
 		data := map[string][]metrics.DataPointGroup{
 			"1m": {
@@ -81,6 +81,44 @@ func TestClient_GetPreviousData(t *testing.T) {
 	assert.Equal(t, want, got)
 }
 
+func TestClient_GetPreviousDataFallsBackToV2(t *testing.T) {
+	schema, err := avro.Parse(protocol.MetricsV2Schema)
+	require.NoError(t, err)
+
+	var accepts []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		accepts = append(accepts, r.Header.Get("Accept"))
+		if r.Header.Get("Accept") != "avro/binary;v2" {
+			w.WriteHeader(http.StatusNotAcceptable)
+			return
+		}
+
+		data := map[string][]metrics.DataPointGroup{
+			"1m": {{Ingress: "bar", Service: "baz", DataPoints: []metrics.DataPoint{{Timestamp: 21}}}},
+		}
+		err = avro.NewEncoderForSchema(schema, w).Encode(data)
+		require.NoError(t, err)
+	}))
+	t.Cleanup(srv.Close)
+
+	client, err := metrics.NewClient(http.DefaultClient, srv.URL, "some_test_token")
+	require.NoError(t, err)
+
+	got, err := client.GetPreviousData(context.Background(), true)
+	require.NoError(t, err)
+
+	want := map[string][]metrics.DataPointGroup{
+		"1m": {{Ingress: "bar", Service: "baz", DataPoints: []metrics.DataPoint{{Timestamp: 21}}}},
+	}
+	assert.Equal(t, want, got)
+
+	// The v2 schema is kept for the next requests.
+	_, err = client.GetPreviousData(context.Background(), false)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"avro/binary;v3", "avro/binary;v2", "avro/binary;v2"}, accepts)
+}
+
 func TestClient_GetPreviousDataHandlesHTTPError(t *testing.T) {
 	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "test error", http.StatusInternalServerError)
@@ -98,7 +136,7 @@ func TestClient_GetPreviousDataHandlesHTTPError(t *testing.T) {
 }
 
 func TestClient_Send(t *testing.T) {
-	schema, err := avro.Parse(protocol.MetricsV2Schema)
+	schema, err := avro.Parse(protocol.MetricsV3Schema)
 	require.NoError(t, err)
 
 	data := map[string][]metrics.DataPointGroup{
@@ -112,13 +150,22 @@ func TestClient_Send(t *testing.T) {
 					},
 				},
 			},
+			{
+				IngressRoute: "qux",
+				Service:      "baz",
+				DataPoints: []metrics.DataPoint{
+					{
+						Timestamp: 21,
+					},
+				},
+			},
 		},
 	}
 
 	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		assert.Equal(t, "/metrics", r.URL.Path)
 		assert.Equal(t, "Bearer some_test_token", r.Header.Get("Authorization"))
-		assert.Equal(t, "avro/binary;v2", r.Header.Get("Content-Type"))
+		assert.Equal(t, "avro/binary;v3", r.Header.Get("Content-Type"))
 
 		got := map[string][]metrics.DataPointGroup{}
 		err = avro.NewDecoderForSchema(schema, r.Body).Decode(&got)
@@ -139,6 +186,53 @@ func TestClient_Send(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestClient_SendFallsBackToV2(t *testing.T) {
+	schema, err := avro.Parse(protocol.MetricsV2Schema)
+	require.NoError(t, err)
+
+	data := map[string][]metrics.DataPointGroup{
+		"1m": {
+			{Ingress: "bar", Service: "baz", DataPoints: []metrics.DataPoint{{Timestamp: 21}}},
+			{IngressRoute: "qux", Service: "baz", DataPoints: []metrics.DataPoint{{Timestamp: 21}}},
+		},
+	}
+
+	var (
+		contentTypes []string
+		got          map[string][]metrics.DataPointGroup
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		contentTypes = append(contentTypes, r.Header.Get("Content-Type"))
+		if r.Header.Get("Content-Type") != "avro/binary;v2" {
+			w.WriteHeader(http.StatusUnsupportedMediaType)
+			return
+		}
+
+		got = map[string][]metrics.DataPointGroup{}
+		err = avro.NewDecoderForSchema(schema, r.Body).Decode(&got)
+		assert.NoError(t, err)
+	}))
+	t.Cleanup(srv.Close)
+
+	client, err := metrics.NewClient(http.DefaultClient, srv.URL, "some_test_token")
+	require.NoError(t, err)
+
+	err = client.Send(context.Background(), data)
+	require.NoError(t, err)
+
+	// Ingress routes can't be represented in the v2 schema.
+	want := map[string][]metrics.DataPointGroup{
+		"1m": {{Ingress: "bar", Service: "baz", DataPoints: []metrics.DataPoint{{Timestamp: 21}}}},
+	}
+	assert.Equal(t, want, got)
+
+	// The v2 schema is kept for the next requests.
+	err = client.Send(context.Background(), data)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"avro/binary;v3", "avro/binary;v2", "avro/binary;v2"}, contentTypes)
+}
+
 func TestClient_SendHandlesHTTPError(t *testing.T) {
 	data := map[string][]metrics.DataPointGroup{
 		"1m": {
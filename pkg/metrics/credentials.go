@@ -0,0 +1,224 @@
+/*
+Copyright (C) 2022 Traefik Labs
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package metrics
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ktypes "k8s.io/apimachinery/pkg/types"
+	clientset "k8s.io/client-go/kubernetes"
+)
+
+// credentialsTTL is the duration during which resolved credentials are reused, after which Secrets are read again to
+// pick up rotated credentials.
+const credentialsTTL = 5 * time.Minute
+
+// Credentials authenticate scrape requests.
+type Credentials struct {
+	// BearerToken is sent in the Authorization header, if set.
+	BearerToken string
+	// TLS is the TLS configuration used to reach the metrics endpoint, if set.
+	TLS *tls.Config
+}
+
+// CredentialsProvider provides the credentials to scrape the metrics of ingress controllers.
+type CredentialsProvider interface {
+	// Credentials returns the credentials to scrape the ingress controller of the given type and name
+	// (name@namespace), or nil if its metrics endpoint is not authenticated.
+	Credentials(ctx context.Context, ctrlType, ctrlName string) (*Credentials, error)
+}
+
+// SecretKey references a key of a Secret.
+type SecretKey struct {
+	Namespace string
+	Name      string
+	Key       string
+}
+
+// ScrapeAuth configures the authentication to the metrics endpoints of ingress controllers, with credentials taken
+// from Secrets.
+type ScrapeAuth struct {
+	// IngressControllerType is the type of the ingress controllers the configuration applies to.
+	IngressControllerType string
+	// IngressController restricts the configuration to the ingress controller with this name (name@namespace).
+	IngressController string
+
+	BearerToken *SecretKey
+	CA          *SecretKey
+	// ClientCertSecret is a kubernetes.io/tls Secret holding the client certificate.
+	ClientCertSecret   *ktypes.NamespacedName
+	ServerName         string
+	InsecureSkipVerify bool
+}
+
+// SecretCredentials provides credentials read from Secrets.
+type SecretCredentials struct {
+	kubeClient clientset.Interface
+
+	mu    sync.Mutex
+	auths []ScrapeAuth
+	cache map[int]cachedCredentials
+
+	nowFunc func() time.Time
+}
+
+type cachedCredentials struct {
+	creds     *Credentials
+	expiresAt time.Time
+}
+
+// NewSecretCredentials returns a credentials provider reading Secrets with the given client.
+func NewSecretCredentials(kubeClient clientset.Interface) *SecretCredentials {
+	return &SecretCredentials{
+		kubeClient: kubeClient,
+		cache:      make(map[int]cachedCredentials),
+		nowFunc:    time.Now,
+	}
+}
+
+// SetConfig sets the authentication configurations. It can be called while metrics are scraped.
+func (c *SecretCredentials) SetConfig(auths []ScrapeAuth) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.auths = auths
+	c.cache = make(map[int]cachedCredentials)
+}
+
+// Credentials returns the credentials to scrape the ingress controller of the given type and name.
+// Configurations restricted to the named ingress controller prevail over the ones applying to its type.
+func (c *SecretCredentials) Credentials(ctx context.Context, ctrlType, ctrlName string) (*Credentials, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	idx := -1
+	for i, auth := range c.auths {
+		if auth.IngressControllerType != ctrlType {
+			continue
+		}
+
+		if auth.IngressController == ctrlName && ctrlName != "" {
+			idx = i
+			break
+		}
+		if auth.IngressController == "" && idx < 0 {
+			idx = i
+		}
+	}
+	if idx < 0 {
+		return nil, nil
+	}
+
+	now := c.nowFunc()
+	if cached, ok := c.cache[idx]; ok && now.Before(cached.expiresAt) {
+		return cached.creds, nil
+	}
+
+	creds, err := c.resolve(ctx, c.auths[idx])
+	if err != nil {
+		return nil, err
+	}
+
+	c.cache[idx] = cachedCredentials{creds: creds, expiresAt: now.Add(credentialsTTL)}
+
+	return creds, nil
+}
+
+func (c *SecretCredentials) resolve(ctx context.Context, auth ScrapeAuth) (*Credentials, error) {
+	var creds Credentials
+
+	if auth.BearerToken != nil {
+		token, err := c.getSecretKey(ctx, *auth.BearerToken)
+		if err != nil {
+			return nil, fmt.Errorf("get bearer token: %w", err)
+		}
+
+		creds.BearerToken = strings.TrimSpace(string(token))
+	}
+
+	if auth.CA == nil && auth.ClientCertSecret == nil && auth.ServerName == "" && !auth.InsecureSkipVerify {
+		return &creds, nil
+	}
+
+	creds.TLS = &tls.Config{
+		ServerName: auth.ServerName,
+		// Skipping the verification of metrics endpoints is an explicit choice of the user.
+		InsecureSkipVerify: auth.InsecureSkipVerify,
+		MinVersion:         tls.VersionTLS12,
+	}
+
+	if auth.CA != nil {
+		ca, err := c.getSecretKey(ctx, *auth.CA)
+		if err != nil {
+			return nil, fmt.Errorf("get CA: %w", err)
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(ca) {
+			return nil, errors.New("no valid PEM encoded certificate found in CA")
+		}
+		creds.TLS.RootCAs = pool
+	}
+
+	if auth.ClientCertSecret != nil {
+		secret, err := c.getSecret(ctx, auth.ClientCertSecret.Namespace, auth.ClientCertSecret.Name)
+		if err != nil {
+			return nil, fmt.Errorf("get client certificate: %w", err)
+		}
+
+		cert, err := tls.X509KeyPair(secret.Data[corev1.TLSCertKey], secret.Data[corev1.TLSPrivateKeyKey])
+		if err != nil {
+			return nil, fmt.Errorf("parse client certificate of secret %s/%s: %w", secret.Namespace, secret.Name, err)
+		}
+		creds.TLS.Certificates = []tls.Certificate{cert}
+	}
+
+	return &creds, nil
+}
+
+func (c *SecretCredentials) getSecretKey(ctx context.Context, ref SecretKey) ([]byte, error) {
+	secret, err := c.getSecret(ctx, ref.Namespace, ref.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	value, ok := secret.Data[ref.Key]
+	if !ok {
+		return nil, fmt.Errorf("key %q not found in secret %s/%s", ref.Key, ref.Namespace, ref.Name)
+	}
+
+	return value, nil
+}
+
+func (c *SecretCredentials) getSecret(ctx context.Context, namespace, name string) (*corev1.Secret, error) {
+	secret, err := c.kubeClient.CoreV1().Secrets(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("get secret %s/%s: %w", namespace, name, err)
+	}
+
+	return secret, nil
+}
@@ -0,0 +1,104 @@
+/*
+Copyright (C) 2022 Traefik Labs
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package metrics_test
+
+import (
+	"context"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/traefik/hub-agent-kubernetes/pkg/metrics"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubemock "k8s.io/client-go/kubernetes/fake"
+)
+
+func TestSecretCredentials_Credentials(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	t.Cleanup(srv.Close)
+
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+
+	kubeClient := kubemock.NewSimpleClientset(
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "tokens", Namespace: "monitoring"},
+			Data: map[string][]byte{
+				"nginx":   []byte("nginx-token\n"),
+				"haproxy": []byte("haproxy-token"),
+			},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "ca", Namespace: "monitoring"},
+			Data:       map[string][]byte{"ca.crt": caPEM},
+		},
+	)
+
+	creds := metrics.NewSecretCredentials(kubeClient)
+	creds.SetConfig([]metrics.ScrapeAuth{
+		{
+			IngressControllerType: metrics.ParserNginx,
+			BearerToken:           &metrics.SecretKey{Namespace: "monitoring", Name: "tokens", Key: "nginx"},
+		},
+		{
+			IngressControllerType: metrics.ParserNginx,
+			IngressController:     "private@ingress",
+			BearerToken:           &metrics.SecretKey{Namespace: "monitoring", Name: "tokens", Key: "haproxy"},
+			CA:                    &metrics.SecretKey{Namespace: "monitoring", Name: "ca", Key: "ca.crt"},
+			ServerName:            "example.com",
+		},
+		{
+			IngressControllerType: metrics.ParserHAProxy,
+			BearerToken:           &metrics.SecretKey{Namespace: "monitoring", Name: "tokens", Key: "missing"},
+		},
+	})
+
+	ctx := context.Background()
+
+	got, err := creds.Credentials(ctx, metrics.ParserNginx, "public@ingress")
+	require.NoError(t, err)
+	assert.Equal(t, &metrics.Credentials{BearerToken: "nginx-token"}, got)
+
+	// Credentials are cached.
+	again, err := creds.Credentials(ctx, metrics.ParserNginx, "public@ingress")
+	require.NoError(t, err)
+	assert.Same(t, got, again)
+
+	got, err = creds.Credentials(ctx, metrics.ParserNginx, "private@ingress")
+	require.NoError(t, err)
+	assert.Equal(t, "haproxy-token", got.BearerToken)
+	require.NotNil(t, got.TLS)
+	assert.Equal(t, "example.com", got.TLS.ServerName)
+	assert.NotNil(t, got.TLS.RootCAs)
+
+	_, err = creds.Credentials(ctx, metrics.ParserHAProxy, "haproxy@ingress")
+	assert.Error(t, err)
+
+	got, err = creds.Credentials(ctx, metrics.ParserTraefik, "")
+	require.NoError(t, err)
+	assert.Nil(t, got)
+
+	// Updating the configuration resets the cache.
+	creds.SetConfig(nil)
+	got, err = creds.Credentials(ctx, metrics.ParserNginx, "public@ingress")
+	require.NoError(t, err)
+	assert.Nil(t, got)
+}
@@ -0,0 +1,326 @@
+/*
+Copyright (C) 2022 Traefik Labs
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package metrics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/rs/zerolog/log"
+)
+
+// DogStatsD metric names sent by Traefik, without prefix.
+const (
+	dogStatsDRouterRequests        = "router.request.total"
+	dogStatsDRouterRequestDuration = "router.request.duration"
+	dogStatsDOpenConnections       = "open.connections"
+)
+
+// dogStatsDMaxPacketSize is the maximum size of the UDP packets read.
+const dogStatsDMaxPacketSize = 65535
+
+type dogStatsDKey struct {
+	router string
+	code   string
+}
+
+type dogStatsDHistogram struct {
+	sum   float64
+	count float64
+	// buckets holds the number of observations lower or equal to each of the ResponseTimeBuckets.
+	buckets []float64
+}
+
+// DogStatsD receives the metrics pushed in the DogStatsD format by Traefik instances configured with the Datadog
+// metrics provider. Received metrics are accumulated into cumulative metric families, as scraped from Prometheus
+// endpoints, so they can be parsed and stored like any other metrics.
+type DogStatsD struct {
+	addr   string
+	prefix string
+
+	mu              sync.Mutex
+	requests        map[dogStatsDKey]float64
+	durations       map[dogStatsDKey]*dogStatsDHistogram
+	openConnections bool
+}
+
+// NewDogStatsD returns a DogStatsD listener listening on the given UDP address for metrics with the given prefix.
+func NewDogStatsD(addr, prefix string) *DogStatsD {
+	if prefix != "" && !strings.HasSuffix(prefix, ".") {
+		prefix += "."
+	}
+
+	return &DogStatsD{
+		addr:      addr,
+		prefix:    prefix,
+		requests:  make(map[dogStatsDKey]float64),
+		durations: make(map[dogStatsDKey]*dogStatsDHistogram),
+	}
+}
+
+// Run listens for metrics until the context is canceled.
+func (d *DogStatsD) Run(ctx context.Context) error {
+	conn, err := net.ListenPacket("udp", d.addr)
+	if err != nil {
+		return fmt.Errorf("listen on %s: %w", d.addr, err)
+	}
+
+	go func() {
+		<-ctx.Done()
+		_ = conn.Close()
+	}()
+
+	log.Info().Str("addr", d.addr).Msg("Listening for DogStatsD metrics")
+
+	buf := make([]byte, dogStatsDMaxPacketSize)
+	for {
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+
+			return fmt.Errorf("read DogStatsD packet: %w", err)
+		}
+
+		d.Handle(buf[:n])
+	}
+}
+
+// Handle handles a packet of DogStatsD metrics, one per line.
+func (d *DogStatsD) Handle(packet []byte) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for _, line := range strings.Split(string(packet), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		if err := d.handleLine(line); err != nil {
+			log.Debug().Err(err).Str("line", line).Msg("Ignoring DogStatsD metric")
+		}
+	}
+}
+
+// handleLine handles a metric formatted as name:value[:value...]|type[|@sample_rate][|#tag:value,...].
+func (d *DogStatsD) handleLine(line string) error {
+	fields := strings.Split(line, "|")
+	if len(fields) < 2 {
+		return errors.New("missing metric type")
+	}
+
+	nameValues := strings.Split(fields[0], ":")
+	if len(nameValues) < 2 {
+		return errors.New("missing metric value")
+	}
+
+	name := nameValues[0]
+	if !strings.HasPrefix(name, d.prefix) {
+		return nil
+	}
+	name = strings.TrimPrefix(name, d.prefix)
+
+	typ := fields[1]
+	rate := 1.0
+	tags := map[string]string{}
+	for _, field := range fields[2:] {
+		switch {
+		case strings.HasPrefix(field, "@"):
+			r, err := strconv.ParseFloat(field[1:], 64)
+			if err != nil || r <= 0 || r > 1 {
+				return fmt.Errorf("invalid sample rate %q", field[1:])
+			}
+			rate = r
+		case strings.HasPrefix(field, "#"):
+			for _, tag := range strings.Split(field[1:], ",") {
+				parts := strings.SplitN(tag, ":", 2)
+				if len(parts) == 2 {
+					tags[parts[0]] = parts[1]
+				}
+			}
+		}
+	}
+
+	values := make([]float64, 0, len(nameValues)-1)
+	for _, raw := range nameValues[1:] {
+		value, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return fmt.Errorf("invalid value %q", raw)
+		}
+		values = append(values, value)
+	}
+
+	key := dogStatsDKey{router: tags["router"], code: tags["code"]}
+
+	switch name {
+	case dogStatsDRouterRequests:
+		if typ != "c" {
+			return fmt.Errorf("unexpected type %q for counter %s", typ, name)
+		}
+		if key.router == "" {
+			return errors.New("missing router tag")
+		}
+
+		for _, value := range values {
+			d.requests[key] += value / rate
+		}
+
+	case dogStatsDRouterRequestDuration:
+		var scale float64
+		switch typ {
+		case "ms":
+			scale = 0.001
+		case "h", "d":
+			scale = 1
+		default:
+			return fmt.Errorf("unexpected type %q for histogram %s", typ, name)
+		}
+		if key.router == "" {
+			return errors.New("missing router tag")
+		}
+
+		hist, ok := d.durations[key]
+		if !ok {
+			hist = &dogStatsDHistogram{buckets: make([]float64, len(ResponseTimeBuckets))}
+			d.durations[key] = hist
+		}
+
+		for _, value := range values {
+			hist.observe(value*scale, 1/rate)
+		}
+
+	case dogStatsDOpenConnections:
+		// Only sent by Traefik v3, which names its routers differently.
+		d.openConnections = true
+	}
+
+	return nil
+}
+
+func (h *dogStatsDHistogram) observe(value, weight float64) {
+	h.sum += value * weight
+	h.count += weight
+
+	for i, bound := range ResponseTimeBuckets {
+		if value <= bound {
+			h.buckets[i] += weight
+		}
+	}
+}
+
+// MetricFamilies returns the metrics received so far, as cumulative Traefik Prometheus metric families.
+func (d *DogStatsD) MetricFamilies() []*dto.MetricFamily {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var families []*dto.MetricFamily
+
+	if len(d.requests) > 0 {
+		family := newDogStatsDFamily("traefik_router_requests_total", dto.MetricType_COUNTER)
+		keys := make([]dogStatsDKey, 0, len(d.requests))
+		for key := range d.requests {
+			keys = append(keys, key)
+		}
+
+		for _, key := range sortDogStatsDKeys(keys) {
+			family.Metric = append(family.Metric, &dto.Metric{
+				Label:   key.labels(),
+				Counter: &dto.Counter{Value: float64Ptr(d.requests[key])},
+			})
+		}
+		families = append(families, family)
+	}
+
+	if len(d.durations) > 0 {
+		family := newDogStatsDFamily("traefik_router_request_duration_seconds", dto.MetricType_HISTOGRAM)
+		keys := make([]dogStatsDKey, 0, len(d.durations))
+		for key := range d.durations {
+			keys = append(keys, key)
+		}
+
+		for _, key := range sortDogStatsDKeys(keys) {
+			hist := d.durations[key]
+
+			buckets := make([]*dto.Bucket, 0, len(ResponseTimeBuckets))
+			for i, bound := range ResponseTimeBuckets {
+				buckets = append(buckets, &dto.Bucket{
+					UpperBound:      float64Ptr(bound),
+					CumulativeCount: uint64Ptr(uint64(hist.buckets[i])),
+				})
+			}
+
+			family.Metric = append(family.Metric, &dto.Metric{
+				Label: key.labels(),
+				Histogram: &dto.Histogram{
+					SampleSum:   float64Ptr(hist.sum),
+					SampleCount: uint64Ptr(uint64(hist.count)),
+					Bucket:      buckets,
+				},
+			})
+		}
+		families = append(families, family)
+	}
+
+	if d.openConnections {
+		families = append(families, newDogStatsDFamily("traefik_open_connections", dto.MetricType_GAUGE))
+	}
+
+	return families
+}
+
+func (k dogStatsDKey) labels() []*dto.LabelPair {
+	lbls := []*dto.LabelPair{{Name: stringPtr("router"), Value: stringPtr(k.router)}}
+	if k.code != "" {
+		lbls = append(lbls, &dto.LabelPair{Name: stringPtr("code"), Value: stringPtr(k.code)})
+	}
+
+	return lbls
+}
+
+func sortDogStatsDKeys(keys []dogStatsDKey) []dogStatsDKey {
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].router != keys[j].router {
+			return keys[i].router < keys[j].router
+		}
+		return keys[i].code < keys[j].code
+	})
+
+	return keys
+}
+
+func newDogStatsDFamily(name string, typ dto.MetricType) *dto.MetricFamily {
+	return &dto.MetricFamily{Name: stringPtr(name), Type: &typ}
+}
+
+func stringPtr(s string) *string { return &s }
+
+func float64Ptr(f float64) *float64 { return &f }
+
+func uint64Ptr(u uint64) *uint64 { return &u }
@@ -0,0 +1,111 @@
+/*
+Copyright (C) 2022 Traefik Labs
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package metrics_test
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/traefik/hub-agent-kubernetes/pkg/metrics"
+)
+
+func TestDogStatsD_MetricFamilies(t *testing.T) {
+	d := metrics.NewDogStatsD("", "traefik")
+
+	d.Handle([]byte("traefik.router.request.total:2|c|#router:default-myIngress-default-example-com@kubernetes,code:200\n" +
+		"traefik.router.request.total:1|c|@0.5|#router:default-myIngress-default-example-com@kubernetes,code:500\n" +
+		"traefik.router.request.duration:0.003:0.2|h|#router:default-myIngress-default-example-com@kubernetes,code:200\n" +
+		"traefik.router.request.duration:40|ms|#router:default-myIngress-default-example-com@kubernetes,code:500\n" +
+		"traefik.entrypoint.request.total:3|c|#entrypoint:web\n" +
+		"other.router.request.total:3|c|#router:foo@kubernetes\n" +
+		"malformed\n"))
+
+	s := metrics.NewScraper(http.DefaultClient)
+	got, err := s.Parse(metrics.ParserTraefik, d.MetricFamilies(), metrics.ScrapeState{
+		Ingresses: map[string]struct{}{"myIngress@default.ingress.networking.k8s.io": {}},
+	})
+	require.NoError(t, err)
+
+	assert.ElementsMatch(t, []metrics.Metric{
+		&metrics.Counter{Name: metrics.MetricRequests, EdgeIngress: "myIngress@default", Value: 2},
+		&metrics.Counter{Name: metrics.MetricRequests, EdgeIngress: "myIngress@default", Value: 2},
+		&metrics.Counter{Name: metrics.MetricRequestErrors, EdgeIngress: "myIngress@default", Value: 2},
+		&metrics.Histogram{
+			Name:        metrics.MetricRequestDuration,
+			EdgeIngress: "myIngress@default",
+			Sum:         0.203,
+			Count:       2,
+			Buckets:     dogStatsDBuckets(0.003, 0.2),
+		},
+		&metrics.Histogram{
+			Name:        metrics.MetricRequestDuration,
+			EdgeIngress: "myIngress@default",
+			Sum:         0.04,
+			Count:       1,
+			Buckets:     dogStatsDBuckets(0.04),
+		},
+	}, got)
+}
+
+func TestDogStatsD_Run(t *testing.T) {
+	// Reserve a free UDP port.
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := conn.LocalAddr().String()
+	require.NoError(t, conn.Close())
+
+	d := metrics.NewDogStatsD(addr, "traefik")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+	go func() { errCh <- d.Run(ctx) }()
+
+	client, err := net.Dial("udp", addr)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = client.Close() })
+
+	assert.Eventually(t, func() bool {
+		_, err = client.Write([]byte("traefik.router.request.total:1|c|#router:foo@kubernetes,code:200"))
+		require.NoError(t, err)
+
+		return len(d.MetricFamilies()) == 1
+	}, 5*time.Second, 50*time.Millisecond)
+
+	cancel()
+	require.NoError(t, <-errCh)
+}
+
+// dogStatsDBuckets returns the cumulative counts of the given observations in each of the ResponseTimeBuckets.
+func dogStatsDBuckets(values ...float64) map[float64]uint64 {
+	buckets := make(map[float64]uint64, len(metrics.ResponseTimeBuckets))
+	for _, bound := range metrics.ResponseTimeBuckets {
+		buckets[bound] = 0
+		for _, value := range values {
+			if value <= bound {
+				buckets[bound]++
+			}
+		}
+	}
+
+	return buckets
+}
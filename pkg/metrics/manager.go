@@ -20,11 +20,13 @@ package metrics
 import (
 	"context"
 	"fmt"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/rs/zerolog/log"
+	"github.com/traefik/hub-agent-kubernetes/pkg/health"
 	"github.com/traefik/hub-agent-kubernetes/pkg/topology/state"
 )
 
@@ -37,11 +39,25 @@ type Manager struct {
 	traefikURL string
 	scraper    *Scraper
 
-	sendMu     sync.Mutex
-	sendIntvl  time.Duration
-	sendTables []string
+	otlpExporter  *OTLPExporter
+	otlpExclusive bool
+
+	cardinality CardinalityLimit
+
+	credentials CredentialsProvider
+
+	dogStatsD *DogStatsD
+
+	sendMu           sync.Mutex
+	sendIntvl        time.Duration
+	sendIntvlChanged chan struct{}
+	sendTables       []string
+
+	flushTimeout time.Duration
 
 	state atomic.Value
+
+	ready health.Status
 }
 
 // NewManager returns a manager.
@@ -50,23 +66,71 @@ func NewManager(client *Client, traefikURL string, store *Store, scraper *Scrape
 	st.Store(&state.Cluster{})
 
 	return &Manager{
-		store:      store,
-		client:     client,
-		traefikURL: traefikURL,
-		scraper:    scraper,
-		sendIntvl:  time.Minute,
-		sendTables: []string{"1m", "10m", "1h", "1d"},
-		state:      st,
+		store:            store,
+		client:           client,
+		traefikURL:       traefikURL,
+		scraper:          scraper,
+		sendIntvl:        time.Minute,
+		sendIntvlChanged: make(chan struct{}, 1),
+		sendTables:       []string{"1m", "10m", "1h", "1d"},
+		state:            st,
 	}
 }
 
-// SetConfig updates the configuration of the metrics manager.
+// SetConfig updates the configuration of the metrics manager. It can be called while the manager runs, in which case
+// the next metrics are sent after the new interval.
 func (m *Manager) SetConfig(sendInterval time.Duration, sendTables []string) {
 	m.sendMu.Lock()
 	defer m.sendMu.Unlock()
 
-	m.sendIntvl = sendInterval
 	m.sendTables = sendTables
+
+	// A null interval would make the sender loop endlessly.
+	if sendInterval <= 0 {
+		log.Error().Dur("interval", sendInterval).Msg("Invalid metrics send interval, keeping the current one")
+		return
+	}
+	if sendInterval == m.sendIntvl {
+		return
+	}
+
+	m.sendIntvl = sendInterval
+
+	select {
+	case m.sendIntvlChanged <- struct{}{}:
+	default:
+	}
+}
+
+// SetOTLPExporter makes the manager export metrics through OTLP in addition to sending them to the platform. When
+// exclusive is true, metrics are only exported through OTLP. It must be called before running the manager.
+func (m *Manager) SetOTLPExporter(exporter *OTLPExporter, exclusive bool) {
+	m.otlpExporter = exporter
+	m.otlpExclusive = exclusive
+}
+
+// SetCardinalityLimit limits the number of data point groups sent to the platform and exported through OTLP. The
+// store keeps every group in full detail. It must be called before running the manager.
+func (m *Manager) SetCardinalityLimit(limit CardinalityLimit) {
+	m.cardinality = limit
+}
+
+// SetCredentialsProvider sets the provider of the credentials used to scrape authenticated metrics endpoints. It must
+// be called before running the manager.
+func (m *Manager) SetCredentialsProvider(provider CredentialsProvider) {
+	m.credentials = provider
+}
+
+// SetDogStatsD makes the manager collect the Traefik metrics received by the given DogStatsD listener, in addition to
+// the scraped ones. It must be called before running the manager.
+func (m *Manager) SetDogStatsD(listener *DogStatsD) {
+	m.dogStatsD = listener
+}
+
+// SetFlushTimeout makes the manager send the metrics not sent yet when it stops, within the given timeout. It must be
+// called before running the manager.
+func (m *Manager) SetFlushTimeout(timeout time.Duration) {
+	m.flushTimeout = timeout
 }
 
 // TopologyStateChanged is called every time the topology state changes.
@@ -78,37 +142,78 @@ func (m *Manager) TopologyStateChanged(_ context.Context, cluster *state.Cluster
 	m.state.Store(cluster)
 }
 
+// Ready reports whether the manager is able to scrape metrics.
+func (m *Manager) Ready(ctx context.Context) error {
+	return m.ready.Check(ctx)
+}
+
 // Run runs the metrics manager. This is a blocking method.
 func (m *Manager) Run(ctx context.Context) error {
 	prevData, err := m.client.GetPreviousData(ctx, true)
 	if err != nil {
+		m.ready.Set(fmt.Errorf("get previous data: %w", err))
 		return err
 	}
 
 	for tbl, data := range prevData {
-		if err = m.store.Populate(tbl, data); err != nil {
+		if err = m.store.Populate(tbl, withoutOtherGroups(data)); err != nil {
 			return fmt.Errorf("unable to populate table: %w", err)
 		}
 	}
 
 	go m.startScraper(ctx)
-	go m.runSender(ctx)
+
+	senderDone := make(chan struct{})
+	go func() {
+		m.runSender(ctx)
+		close(senderDone)
+	}()
 
 	<-ctx.Done()
+	<-senderDone
+
+	m.flush()
 
 	return nil
 }
 
+// flush sends the metrics not sent yet, within the flush timeout.
+func (m *Manager) flush() {
+	if m.flushTimeout <= 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), m.flushTimeout)
+	defer cancel()
+
+	if err := m.send(ctx, m.getSendTables()); err != nil {
+		log.Error().Err(err).Msg("Unable to flush metrics")
+	}
+}
+
 func (m *Manager) runSender(ctx context.Context) {
+	timer := time.NewTimer(m.getSendInterval())
+	defer timer.Stop()
+
 	for {
 		select {
 		case <-ctx.Done():
 			return
 
-		case <-time.After(m.getSendInterval()):
+		case <-m.sendIntvlChanged:
+			if !timer.Stop() {
+				select {
+				case <-timer.C:
+				default:
+				}
+			}
+			timer.Reset(m.getSendInterval())
+
+		case <-timer.C:
 			if err := m.send(ctx, m.getSendTables()); err != nil {
 				log.Error().Err(err).Msg("Unable to send metrics")
 			}
+			timer.Reset(m.getSendInterval())
 		}
 	}
 }
@@ -135,12 +240,13 @@ func (m *Manager) send(ctx context.Context, tbls []string) error {
 	for _, name := range tbls {
 		tbl := name
 
-		tblMarks[tbl] = m.store.ForEachUnmarked(tbl, func(edgeIngr, ingr, svc string, pnts DataPoints) {
+		tblMarks[tbl] = m.store.ForEachUnmarked(tbl, func(edgeIngr, ingr, ingRoute, svc string, pnts DataPoints) {
 			toSend[tbl] = append(toSend[tbl], DataPointGroup{
-				EdgeIngress: edgeIngr,
-				Ingress:     ingr,
-				Service:     svc,
-				DataPoints:  pnts,
+				EdgeIngress:  edgeIngr,
+				Ingress:      ingr,
+				IngressRoute: ingRoute,
+				Service:      svc,
+				DataPoints:   pnts,
 			})
 		})
 	}
@@ -149,8 +255,25 @@ func (m *Manager) send(ctx context.Context, tbls []string) error {
 		return nil
 	}
 
-	if err := m.client.Send(ctx, toSend); err != nil {
-		return err
+	for tbl, grps := range toSend {
+		toSend[tbl] = m.cardinality.Apply(grps)
+	}
+
+	if m.otlpExporter != nil {
+		if err := m.otlpExporter.Export(ctx, toSend[otlpTable]); err != nil {
+			if m.otlpExclusive {
+				return err
+			}
+
+			// The platform remains the reference, a failed export must not prevent metrics from being sent to it.
+			log.Error().Err(err).Msg("Unable to export metrics through OTLP")
+		}
+	}
+
+	if !m.otlpExclusive {
+		if err := m.client.Send(ctx, toSend); err != nil {
+			return err
+		}
 	}
 
 	for tbl, marks := range tblMarks {
@@ -162,18 +285,15 @@ func (m *Manager) send(ctx context.Context, tbls []string) error {
 }
 
 func (m *Manager) startScraper(ctx context.Context) {
-	mtrcs, err := m.scraper.Scrape(ctx, ParserTraefik, m.traefikURL, ScrapeState{
-		Ingresses:           m.getIngresses(),
-		IngressRoutes:       m.getIngressRoutes(),
-		ServiceIngresses:    m.getSvcIngresses(),
-		TraefikServiceNames: m.getTraefikServiceNames(),
-	})
+	mtrcs, err := m.scrape(ctx)
 	if err != nil {
 		log.Error().Err(err).Msg("Unable to scrape metrics")
+		m.ready.Set(fmt.Errorf("scraper stopped: %w", err))
 		return
 	}
+	m.ready.SetReady()
 
-	ref := Aggregate(mtrcs)
+	ref := AggregateServices(Aggregate(mtrcs), m.getIngressServices(), m.getIngressRouteServices())
 
 	tick := time.NewTicker(scrapeInterval)
 	defer tick.Stop()
@@ -185,18 +305,14 @@ func (m *Manager) startScraper(ctx context.Context) {
 			return
 
 		case <-tick.C:
-			mtrcs, err = m.scraper.Scrape(ctx, ParserTraefik, m.traefikURL, ScrapeState{
-				Ingresses:           m.getIngresses(),
-				IngressRoutes:       m.getIngressRoutes(),
-				ServiceIngresses:    m.getSvcIngresses(),
-				TraefikServiceNames: m.getTraefikServiceNames(),
-			})
+			mtrcs, err = m.scrape(ctx)
 			if err != nil {
 				log.Error().Err(err).Msg("Unable to scrape metrics")
+				m.ready.Set(fmt.Errorf("scraper stopped: %w", err))
 				return
 			}
 
-			mtrcSet := Aggregate(mtrcs)
+			mtrcSet := AggregateServices(Aggregate(mtrcs), m.getIngressServices(), m.getIngressRouteServices())
 
 			ts := time.Now().UTC().Truncate(time.Minute).Unix()
 
@@ -218,6 +334,72 @@ func (m *Manager) startScraper(ctx context.Context) {
 	}
 }
 
+// scrape scrapes the metrics of Traefik and of the other ingress controllers found in the topology.
+func (m *Manager) scrape(ctx context.Context) ([]Metric, error) {
+	scrapeState := ScrapeState{
+		Ingresses:           m.getIngresses(),
+		IngressRoutes:       m.getIngressRoutes(),
+		ServiceIngresses:    m.getSvcIngresses(),
+		TraefikServiceNames: m.getTraefikServiceNames(),
+	}
+
+	var mtrcs []Metric
+	if m.traefikURL != "" {
+		creds, err := m.getCredentials(ctx, ParserTraefik, "")
+		if err != nil {
+			return nil, fmt.Errorf("get Traefik credentials: %w", err)
+		}
+
+		mtrcs, err = m.scraper.Scrape(ctx, ParserTraefik, m.traefikURL, creds, scrapeState)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if m.dogStatsD != nil {
+		pushed, err := m.scraper.Parse(ParserTraefik, m.dogStatsD.MetricFamilies(), scrapeState)
+		if err != nil {
+			return nil, fmt.Errorf("parse DogStatsD metrics: %w", err)
+		}
+
+		mtrcs = append(mtrcs, pushed...)
+	}
+
+	cluster := m.state.Load().(*state.Cluster)
+	for name, ctrl := range cluster.IngressControllers {
+		// Traefik metrics are scraped from the configured URL.
+		if ctrl.Type != ParserNginx && ctrl.Type != ParserHAProxy {
+			continue
+		}
+
+		creds, err := m.getCredentials(ctx, ctrl.Type, name)
+		if err != nil {
+			log.Error().Err(err).Str("ingress_controller", name).Msg("Unable to get ingress controller credentials")
+			continue
+		}
+
+		for _, target := range ctrl.MetricsURLs {
+			ctrlMtrcs, err := m.scraper.Scrape(ctx, ctrl.Type, target, creds, scrapeState)
+			if err != nil {
+				log.Error().Err(err).Str("ingress_controller", name).Msg("Unable to scrape ingress controller metrics")
+				continue
+			}
+
+			mtrcs = append(mtrcs, ctrlMtrcs...)
+		}
+	}
+
+	return mtrcs, nil
+}
+
+func (m *Manager) getCredentials(ctx context.Context, ctrlType, ctrlName string) (*Credentials, error) {
+	if m.credentials == nil {
+		return nil, nil
+	}
+
+	return m.credentials.Credentials(ctx, ctrlType, ctrlName)
+}
+
 func (m *Manager) getSvcIngresses() map[string][]string {
 	cluster := m.state.Load().(*state.Cluster)
 
@@ -233,6 +415,33 @@ func (m *Manager) getSvcIngresses() map[string][]string {
 	return svcIngresses
 }
 
+// getIngressServices returns the services of ingresses, indexed by name@namespace as used by the metrics parsers.
+func (m *Manager) getIngressServices() map[string][]string {
+	cluster := m.state.Load().(*state.Cluster)
+
+	ingrSvcs := make(map[string][]string, len(cluster.Ingresses))
+	for name, ingr := range cluster.Ingresses {
+		// Remove the `.kind.group` from the namespace.
+		ingrSvcs[strings.SplitN(name, ".", 2)[0]] = ingr.Services
+	}
+
+	return ingrSvcs
+}
+
+// getIngressRouteServices returns the services of ingress routes, indexed by name@namespace as used by the metrics
+// parsers.
+func (m *Manager) getIngressRouteServices() map[string][]string {
+	cluster := m.state.Load().(*state.Cluster)
+
+	ingRouteSvcs := make(map[string][]string, len(cluster.IngressRoutes))
+	for name, ingRoute := range cluster.IngressRoutes {
+		// Remove the `.kind.group` from the namespace.
+		ingRouteSvcs[strings.SplitN(name, ".", 2)[0]] = ingRoute.Services
+	}
+
+	return ingRouteSvcs
+}
+
 func (m *Manager) getTraefikServiceNames() map[string]string {
 	cluster := m.state.Load().(*state.Cluster)
 
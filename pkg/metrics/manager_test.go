@@ -0,0 +1,82 @@
+/*
+Copyright (C) 2022 Traefik Labs
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestManager_SetConfig(t *testing.T) {
+	sent := make(chan struct{}, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		select {
+		case sent <- struct{}{}:
+		default:
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	client, err := NewClient(http.DefaultClient, srv.URL, "token")
+	require.NoError(t, err)
+
+	store := NewStore()
+	store.Insert(map[SetKey]DataPoint{
+		{Ingress: "ing@ns", Service: "svc@ns"}: {Timestamp: time.Now().Unix(), Seconds: 60, Requests: 10},
+	})
+
+	m := NewManager(client, "", store, nil)
+	m.SetConfig(time.Hour, []string{"1m"})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	senderDone := make(chan struct{})
+	go func() {
+		m.runSender(ctx)
+		close(senderDone)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-senderDone
+	})
+
+	// A non-positive interval is ignored.
+	m.SetConfig(0, []string{"1m"})
+	m.SetConfig(-time.Second, []string{"1m"})
+	assert.Equal(t, time.Hour, m.getSendInterval())
+
+	select {
+	case <-sent:
+		require.Fail(t, "metrics sent before the interval elapsed")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	// A changed interval takes effect without waiting for the current one to elapse.
+	m.SetConfig(10*time.Millisecond, []string{"1m"})
+
+	select {
+	case <-sent:
+	case <-time.After(time.Second):
+		require.Fail(t, "metrics not sent after the interval changed")
+	}
+}
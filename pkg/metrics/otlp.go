@@ -0,0 +1,182 @@
+/*
+Copyright (C) 2022 Traefik Labs
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/traefik/hub-agent-kubernetes/pkg/otlp"
+)
+
+// otlpTable is the table whose data points are exported through OTLP. Other tables are roll-ups of it, which OTLP
+// backends compute on their own.
+const otlpTable = "1m"
+
+// OTLP aggregation temporality of sums, see opentelemetry/proto/metrics/v1/metrics.proto.
+const otlpAggregationTemporalityDelta = 1
+
+// OTLPExporter exports metrics to an OpenTelemetry collector using OTLP/HTTP with the JSON encoding.
+type OTLPExporter struct {
+	client *otlp.Client
+}
+
+// NewOTLPExporter creates an OTLP exporter sending metrics to the collector reachable at the given base URL
+// (e.g. http://otel-collector:4318).
+func NewOTLPExporter(client *http.Client, baseURL string, headers map[string]string) (*OTLPExporter, error) {
+	c, err := otlp.NewClient(client, baseURL, "metrics", headers)
+	if err != nil {
+		return nil, err
+	}
+
+	return &OTLPExporter{client: c}, nil
+}
+
+// Export exports the given data point groups.
+func (e *OTLPExporter) Export(ctx context.Context, groups []DataPointGroup) error {
+	if len(groups) == 0 {
+		return nil
+	}
+
+	return e.client.Send(ctx, buildOTLPRequest(groups))
+}
+
+// The following types are the JSON representation of an OTLP ExportMetricsServiceRequest. As defined by the protobuf
+// JSON mapping, 64 bits integers are encoded as strings.
+type otlpRequest struct {
+	ResourceMetrics []otlpResourceMetrics `json:"resourceMetrics"`
+}
+
+type otlpResourceMetrics struct {
+	Resource     otlp.Resource      `json:"resource"`
+	ScopeMetrics []otlpScopeMetrics `json:"scopeMetrics"`
+}
+
+type otlpScopeMetrics struct {
+	Scope   otlp.Scope   `json:"scope"`
+	Metrics []otlpMetric `json:"metrics"`
+}
+
+type otlpMetric struct {
+	Name  string     `json:"name"`
+	Unit  string     `json:"unit,omitempty"`
+	Sum   *otlpSum   `json:"sum,omitempty"`
+	Gauge *otlpGauge `json:"gauge,omitempty"`
+}
+
+type otlpSum struct {
+	AggregationTemporality int             `json:"aggregationTemporality"`
+	IsMonotonic            bool            `json:"isMonotonic"`
+	DataPoints             []otlpDataPoint `json:"dataPoints"`
+}
+
+type otlpGauge struct {
+	DataPoints []otlpDataPoint `json:"dataPoints"`
+}
+
+type otlpDataPoint struct {
+	Attributes        []otlp.KeyValue `json:"attributes,omitempty"`
+	StartTimeUnixNano string          `json:"startTimeUnixNano,omitempty"`
+	TimeUnixNano      string          `json:"timeUnixNano"`
+	AsInt             *string         `json:"asInt,omitempty"`
+	AsDouble          *float64        `json:"asDouble,omitempty"`
+}
+
+func buildOTLPRequest(groups []DataPointGroup) otlpRequest {
+	requests := otlpMetric{Name: "hub.ingress.requests", Unit: "{request}", Sum: newOTLPDeltaSum()}
+	requestErrs := otlpMetric{Name: "hub.ingress.request_errors", Unit: "{request}", Sum: newOTLPDeltaSum()}
+	requestClientErrs := otlpMetric{Name: "hub.ingress.request_client_errors", Unit: "{request}", Sum: newOTLPDeltaSum()}
+	responseTime := otlpMetric{Name: "hub.ingress.response_time", Unit: "s", Gauge: &otlpGauge{}}
+
+	for _, group := range groups {
+		attrs := otlpGroupAttributes(group)
+
+		for _, pnt := range group.DataPoints {
+			start := time.Unix(pnt.Timestamp, 0)
+			startNano := otlp.UnixNano(start)
+			endNano := otlp.UnixNano(start.Add(time.Duration(pnt.Seconds) * time.Second))
+
+			requests.Sum.DataPoints = append(requests.Sum.DataPoints, newOTLPIntDataPoint(attrs, startNano, endNano, pnt.Requests))
+			requestErrs.Sum.DataPoints = append(requestErrs.Sum.DataPoints, newOTLPIntDataPoint(attrs, startNano, endNano, pnt.RequestErrs))
+			requestClientErrs.Sum.DataPoints = append(requestClientErrs.Sum.DataPoints, newOTLPIntDataPoint(attrs, startNano, endNano, pnt.RequestClientErrs))
+
+			// A response time can't be told without any response.
+			if pnt.ResponseTimeCount == 0 {
+				continue
+			}
+			avg := pnt.AvgResponseTime
+			responseTime.Gauge.DataPoints = append(responseTime.Gauge.DataPoints, otlpDataPoint{
+				Attributes:   attrs,
+				TimeUnixNano: endNano,
+				AsDouble:     &avg,
+			})
+		}
+	}
+
+	return otlpRequest{
+		ResourceMetrics: []otlpResourceMetrics{
+			{
+				Resource: otlp.NewServiceResource("hub-agent-kubernetes"),
+				ScopeMetrics: []otlpScopeMetrics{
+					{
+						Scope:   otlp.Scope{Name: "github.com/traefik/hub-agent-kubernetes/pkg/metrics"},
+						Metrics: []otlpMetric{requests, requestErrs, requestClientErrs, responseTime},
+					},
+				},
+			},
+		},
+	}
+}
+
+func otlpGroupAttributes(group DataPointGroup) []otlp.KeyValue {
+	var attrs []otlp.KeyValue
+	if group.EdgeIngress != "" {
+		attrs = append(attrs, otlp.NewAttribute("edge_ingress", group.EdgeIngress))
+	}
+	if group.Ingress != "" {
+		attrs = append(attrs, otlp.NewAttribute("ingress", group.Ingress))
+	}
+	if group.IngressRoute != "" {
+		attrs = append(attrs, otlp.NewAttribute("ingress_route", group.IngressRoute))
+	}
+	if group.Service != "" {
+		attrs = append(attrs, otlp.NewAttribute("service", group.Service))
+	}
+
+	return attrs
+}
+
+func newOTLPDeltaSum() *otlpSum {
+	return &otlpSum{
+		AggregationTemporality: otlpAggregationTemporalityDelta,
+		IsMonotonic:            true,
+	}
+}
+
+func newOTLPIntDataPoint(attrs []otlp.KeyValue, startNano, endNano string, value int64) otlpDataPoint {
+	v := strconv.FormatInt(value, 10)
+
+	return otlpDataPoint{
+		Attributes:        attrs,
+		StartTimeUnixNano: startNano,
+		TimeUnixNano:      endNano,
+		AsInt:             &v,
+	}
+}
@@ -0,0 +1,147 @@
+/*
+Copyright (C) 2022 Traefik Labs
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package metrics_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/traefik/hub-agent-kubernetes/pkg/metrics"
+)
+
+func TestOTLPExporter_Export(t *testing.T) {
+	groups := []metrics.DataPointGroup{
+		{
+			EdgeIngress: "my-edge-ingress@default",
+			DataPoints: []metrics.DataPoint{
+				{
+					Timestamp:         60,
+					Seconds:           60,
+					Requests:          12,
+					RequestErrs:       2,
+					RequestClientErrs: 1,
+					AvgResponseTime:   0.5,
+					ResponseTimeSum:   6,
+					ResponseTimeCount: 12,
+				},
+			},
+		},
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/otlp/v1/metrics", r.URL.Path)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		assert.Equal(t, "secret", r.Header.Get("X-Api-Key"))
+
+		body, err := io.ReadAll(r.Body)
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		assert.JSONEq(t, `{
+			"resourceMetrics": [{
+				"resource": {"attributes": [{"key": "service.name", "value": {"stringValue": "hub-agent-kubernetes"}}]},
+				"scopeMetrics": [{
+					"scope": {"name": "github.com/traefik/hub-agent-kubernetes/pkg/metrics"},
+					"metrics": [
+						{
+							"name": "hub.ingress.requests",
+							"unit": "{request}",
+							"sum": {
+								"aggregationTemporality": 1,
+								"isMonotonic": true,
+								"dataPoints": [{
+									"attributes": [{"key": "edge_ingress", "value": {"stringValue": "my-edge-ingress@default"}}],
+									"startTimeUnixNano": "60000000000",
+									"timeUnixNano": "120000000000",
+									"asInt": "12"
+								}]
+							}
+						},
+						{
+							"name": "hub.ingress.request_errors",
+							"unit": "{request}",
+							"sum": {
+								"aggregationTemporality": 1,
+								"isMonotonic": true,
+								"dataPoints": [{
+									"attributes": [{"key": "edge_ingress", "value": {"stringValue": "my-edge-ingress@default"}}],
+									"startTimeUnixNano": "60000000000",
+									"timeUnixNano": "120000000000",
+									"asInt": "2"
+								}]
+							}
+						},
+						{
+							"name": "hub.ingress.request_client_errors",
+							"unit": "{request}",
+							"sum": {
+								"aggregationTemporality": 1,
+								"isMonotonic": true,
+								"dataPoints": [{
+									"attributes": [{"key": "edge_ingress", "value": {"stringValue": "my-edge-ingress@default"}}],
+									"startTimeUnixNano": "60000000000",
+									"timeUnixNano": "120000000000",
+									"asInt": "1"
+								}]
+							}
+						},
+						{
+							"name": "hub.ingress.response_time",
+							"unit": "s",
+							"gauge": {
+								"dataPoints": [{
+									"attributes": [{"key": "edge_ingress", "value": {"stringValue": "my-edge-ingress@default"}}],
+									"timeUnixNano": "120000000000",
+									"asDouble": 0.5
+								}]
+							}
+						}
+					]
+				}]
+			}]
+		}`, string(body))
+	}))
+	t.Cleanup(srv.Close)
+
+	exporter, err := metrics.NewOTLPExporter(http.DefaultClient, srv.URL+"/otlp", map[string]string{"X-Api-Key": "secret"})
+	require.NoError(t, err)
+
+	err = exporter.Export(context.Background(), groups)
+	assert.NoError(t, err)
+}
+
+func TestOTLPExporter_ExportHandlesHTTPError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "test error", http.StatusBadRequest)
+	}))
+	t.Cleanup(srv.Close)
+
+	exporter, err := metrics.NewOTLPExporter(http.DefaultClient, srv.URL, nil)
+	require.NoError(t, err)
+
+	err = exporter.Export(context.Background(), []metrics.DataPointGroup{
+		{Ingress: "bar", DataPoints: []metrics.DataPoint{{Timestamp: 21, Seconds: 60}}},
+	})
+	assert.Error(t, err)
+}
@@ -23,18 +23,44 @@ import (
 	dto "github.com/prometheus/client_model/go"
 )
 
+// Traefik major versions, which name their routers differently.
+const (
+	traefikV2 = 2
+	traefikV3 = 3
+)
+
 // TraefikParser parses Traefik metrics into a common form.
 type TraefikParser struct {
-	cache map[string][]string
+	cache   map[string][]string
+	version int
 }
 
 // NewTraefikParser returns an Traefik metrics parser.
 func NewTraefikParser() TraefikParser {
 	return TraefikParser{
-		cache: map[string][]string{},
+		cache:   map[string][]string{},
+		version: traefikV2,
 	}
 }
 
+// withVersion returns a copy of the parser handling the metrics exposed by the given Traefik major version.
+func (p TraefikParser) withVersion(version int) TraefikParser {
+	p.version = version
+	return p
+}
+
+// detectTraefikVersion guesses the major version of the Traefik instance exposing the given metrics. Traefik v3
+// replaced the open connections metrics of entry points, routers and services by a single traefik_open_connections.
+func detectTraefikVersion(families []*dto.MetricFamily) int {
+	for _, family := range families {
+		if family.GetName() == "traefik_open_connections" {
+			return traefikV3
+		}
+	}
+
+	return traefikV2
+}
+
 // Parse parses metrics into a common form.
 func (p TraefikParser) Parse(m *dto.MetricFamily, state ScrapeState) []Metric {
 	if m == nil || m.Name == nil {
@@ -62,8 +88,8 @@ func (p TraefikParser) parseRouterRequestDuration(metrics []*dto.Metric, state S
 			continue
 		}
 
-		edgeIngress := p.guessEdgeIngress(metric.Label, state)
-		if edgeIngress == "" {
+		edgeIngress, ingRoute := p.guessRouter(metric.Label, state)
+		if edgeIngress == "" && ingRoute == "" {
 			continue
 		}
 
@@ -71,6 +97,7 @@ func (p TraefikParser) parseRouterRequestDuration(metrics []*dto.Metric, state S
 		// router will deliver the traffic, not the leaf node of the service tree (e.g. load-balancer, wrr).
 		hist.Name = MetricRequestDuration
 		hist.EdgeIngress = edgeIngress
+		hist.IngressRoute = ingRoute
 
 		enrichedMetrics = append(enrichedMetrics, hist)
 	}
@@ -87,17 +114,18 @@ func (p TraefikParser) parseRouterRequestTotal(metrics []*dto.Metric, state Scra
 			continue
 		}
 
-		edgeIngress := p.guessEdgeIngress(metric.Label, state)
-		if edgeIngress == "" {
+		edgeIngress, ingRoute := p.guessRouter(metric.Label, state)
+		if edgeIngress == "" && ingRoute == "" {
 			continue
 		}
 
 		// Service can't be accurately obtained on router metrics. The service label holds the service name to which the
 		// router will deliver the traffic, not the leaf node of the service tree (e.g. load-balancer, wrr).
 		enrichedMetrics = append(enrichedMetrics, &Counter{
-			Name:        MetricRequests,
-			EdgeIngress: edgeIngress,
-			Value:       counter,
+			Name:         MetricRequests,
+			EdgeIngress:  edgeIngress,
+			IngressRoute: ingRoute,
+			Value:        counter,
 		})
 
 		metricErrorName := getMetricErrorName(metric.Label, "code")
@@ -105,27 +133,40 @@ func (p TraefikParser) parseRouterRequestTotal(metrics []*dto.Metric, state Scra
 			continue
 		}
 		enrichedMetrics = append(enrichedMetrics, &Counter{
-			Name:        metricErrorName,
-			EdgeIngress: edgeIngress,
-			Value:       counter,
+			Name:         metricErrorName,
+			EdgeIngress:  edgeIngress,
+			IngressRoute: ingRoute,
+			Value:        counter,
 		})
 	}
 
 	return enrichedMetrics
 }
 
-func (p TraefikParser) guessEdgeIngress(lbls []*dto.LabelPair, state ScrapeState) string {
+// guessRouter returns the ingress or the ingress route from which the router of the given metric labels was built.
+func (p TraefikParser) guessRouter(lbls []*dto.LabelPair, state ScrapeState) (edgeIngress, ingRoute string) {
 	name := getLabel(lbls, "router")
 
 	parts := strings.SplitN(name, "@", 2)
 	if len(parts) != 2 {
-		return ""
+		return "", ""
 	}
 	name, typ := parts[0], parts[1]
 
-	if typ != "kubernetes" {
-		return ""
+	switch typ {
+	case "kubernetes":
+		if p.version == traefikV3 {
+			return guessIngressV3(name, state), ""
+		}
+		return guessIngress(name, state), ""
+	case "kubernetescrd":
+		return "", guessIngressRoute(name, state)
+	default:
+		return "", ""
 	}
+}
+
+func guessIngress(name string, state ScrapeState) string {
 	for ingressName := range state.Ingresses {
 		guess := strings.ReplaceAll(ingressName, "@", "-")
 		// Remove the `.kind.group` from the namespace.
@@ -143,6 +184,50 @@ func (p TraefikParser) guessEdgeIngress(lbls []*dto.LabelPair, state ScrapeState
 	return ""
 }
 
+func guessIngressV3(name string, state ScrapeState) string {
+	var guessed string
+	for ingressName := range state.Ingresses {
+		// Remove the `.kind.group` from the namespace.
+		ingressName = strings.SplitN(ingressName, ".", 2)[0]
+
+		parts := strings.SplitN(ingressName, "@", 2)
+		if len(parts) != 2 {
+			continue
+		}
+
+		// Traefik v3 puts the namespace first in the name of ingresses:
+		//     [entrypointName-]ingressNamespace-ingressName-ingressHost-ingressPath[-hash]@kubernetes
+		// As an ingress name can be the prefix of another one, the longest matching name is kept.
+		if strings.Contains(name, parts[1]+"-"+parts[0]+"-") && len(ingressName) > len(guessed) {
+			guessed = ingressName
+		}
+	}
+
+	return guessed
+}
+
+func guessIngressRoute(name string, state ScrapeState) string {
+	var guessed string
+	for ingRouteName := range state.IngressRoutes {
+		// Remove the `.kind.group` from the namespace.
+		ingRouteName = strings.SplitN(ingRouteName, ".", 2)[0]
+
+		parts := strings.SplitN(ingRouteName, "@", 2)
+		if len(parts) != 2 {
+			continue
+		}
+
+		// The name of ingress routes follow this rule:
+		//     ingressRouteNamespace-ingressRouteName-hash@kubernetescrd
+		// As an ingress route name can be the prefix of another one, the longest matching name is kept.
+		if strings.HasPrefix(name, parts[1]+"-"+parts[0]+"-") && len(ingRouteName) > len(guessed) {
+			guessed = ingRouteName
+		}
+	}
+
+	return guessed
+}
+
 func getMetricErrorName(lbls []*dto.LabelPair, statusName string) string {
 	status := getLabel(lbls, statusName)
 	if status == "" {
@@ -0,0 +1,132 @@
+/*
+Copyright (C) 2022 Traefik Labs
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package metrics
+
+import (
+	"strings"
+
+	dto "github.com/prometheus/client_model/go"
+)
+
+// HAProxyParser parses HAProxy ingress controller metrics into a common form.
+type HAProxyParser struct{}
+
+// NewHAProxyParser returns an HAProxy metrics parser.
+func NewHAProxyParser() HAProxyParser {
+	return HAProxyParser{}
+}
+
+// Parse parses metrics into a common form.
+func (p HAProxyParser) Parse(m *dto.MetricFamily, state ScrapeState) []Metric {
+	if m == nil || m.Name == nil {
+		return nil
+	}
+
+	var metrics []Metric
+	switch *m.Name {
+	case "haproxy_backend_total_time_average_seconds":
+		metrics = append(metrics, p.parseBackendTotalTime(m.Metric, state)...)
+
+	case "haproxy_backend_http_responses_total":
+		metrics = append(metrics, p.parseBackendResponses(m.Metric, state)...)
+	}
+
+	return metrics
+}
+
+// parseBackendTotalTime parses the average response time of backends. HAProxy doesn't expose histograms but an
+// average computed over the last requests, hence the relative histogram holding a single sample.
+func (p HAProxyParser) parseBackendTotalTime(metrics []*dto.Metric, state ScrapeState) []Metric {
+	var enrichedMetrics []Metric
+
+	for _, metric := range metrics {
+		if metric.Gauge == nil || metric.Gauge.GetValue() == 0 {
+			continue
+		}
+
+		ingress := p.guessIngress(metric.Label, state)
+		if ingress == "" {
+			continue
+		}
+
+		enrichedMetrics = append(enrichedMetrics, &Histogram{
+			Name:        MetricRequestDuration,
+			Relative:    true,
+			EdgeIngress: ingress,
+			Sum:         metric.Gauge.GetValue(),
+			Count:       1,
+		})
+	}
+
+	return enrichedMetrics
+}
+
+func (p HAProxyParser) parseBackendResponses(metrics []*dto.Metric, state ScrapeState) []Metric {
+	var enrichedMetrics []Metric
+
+	for _, metric := range metrics {
+		counter := CounterFromMetric(metric)
+		if counter == 0 {
+			continue
+		}
+
+		ingress := p.guessIngress(metric.Label, state)
+		if ingress == "" {
+			continue
+		}
+
+		enrichedMetrics = append(enrichedMetrics, &Counter{
+			Name:        MetricRequests,
+			EdgeIngress: ingress,
+			Value:       counter,
+		})
+
+		// Response codes are grouped by class (e.g. 5xx).
+		metricErrorName := getMetricErrorName(metric.Label, "code")
+		if metricErrorName == "" {
+			continue
+		}
+		enrichedMetrics = append(enrichedMetrics, &Counter{
+			Name:        metricErrorName,
+			EdgeIngress: ingress,
+			Value:       counter,
+		})
+	}
+
+	return enrichedMetrics
+}
+
+// guessIngress returns the name of the ingress routing to the backend the metric labels refer to.
+func (p HAProxyParser) guessIngress(lbls []*dto.LabelPair, state ScrapeState) string {
+	// Backends are named after the service they balance the traffic to:
+	//     serviceNamespace_serviceName_servicePort
+	// As Kubernetes names can't contain underscores, the name can be safely split.
+	parts := strings.SplitN(getLabel(lbls, "proxy"), "_", 3)
+	if len(parts) != 3 {
+		return ""
+	}
+
+	// Metrics can't be attributed when the service is used by several ingresses, as HAProxy shares the backend.
+	ingresses := state.ServiceIngresses[parts[1]+"@"+parts[0]]
+	if len(ingresses) != 1 {
+		return ""
+	}
+
+	// Remove the `.kind.group` from the namespace.
+	return strings.SplitN(ingresses[0], ".", 2)[0]
+}
@@ -0,0 +1,126 @@
+/*
+Copyright (C) 2022 Traefik Labs
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package metrics
+
+import (
+	"strings"
+
+	dto "github.com/prometheus/client_model/go"
+)
+
+// NginxParser parses ingress-nginx metrics into a common form.
+type NginxParser struct{}
+
+// NewNginxParser returns an ingress-nginx metrics parser.
+func NewNginxParser() NginxParser {
+	return NginxParser{}
+}
+
+// Parse parses metrics into a common form.
+func (p NginxParser) Parse(m *dto.MetricFamily, state ScrapeState) []Metric {
+	if m == nil || m.Name == nil {
+		return nil
+	}
+
+	var metrics []Metric
+	switch *m.Name {
+	case "nginx_ingress_controller_request_duration_seconds":
+		metrics = append(metrics, p.parseRequestDuration(m.Metric, state)...)
+
+	case "nginx_ingress_controller_requests":
+		metrics = append(metrics, p.parseRequests(m.Metric, state)...)
+	}
+
+	return metrics
+}
+
+func (p NginxParser) parseRequestDuration(metrics []*dto.Metric, state ScrapeState) []Metric {
+	var enrichedMetrics []Metric
+
+	for _, metric := range metrics {
+		hist := HistogramFromMetric(metric)
+		if hist == nil {
+			continue
+		}
+
+		ingress := p.guessIngress(metric.Label, state)
+		if ingress == "" {
+			continue
+		}
+
+		hist.Name = MetricRequestDuration
+		hist.EdgeIngress = ingress
+
+		enrichedMetrics = append(enrichedMetrics, hist)
+	}
+
+	return enrichedMetrics
+}
+
+func (p NginxParser) parseRequests(metrics []*dto.Metric, state ScrapeState) []Metric {
+	var enrichedMetrics []Metric
+
+	for _, metric := range metrics {
+		counter := CounterFromMetric(metric)
+		if counter == 0 {
+			continue
+		}
+
+		ingress := p.guessIngress(metric.Label, state)
+		if ingress == "" {
+			continue
+		}
+
+		enrichedMetrics = append(enrichedMetrics, &Counter{
+			Name:        MetricRequests,
+			EdgeIngress: ingress,
+			Value:       counter,
+		})
+
+		metricErrorName := getMetricErrorName(metric.Label, "status")
+		if metricErrorName == "" {
+			continue
+		}
+		enrichedMetrics = append(enrichedMetrics, &Counter{
+			Name:        metricErrorName,
+			EdgeIngress: ingress,
+			Value:       counter,
+		})
+	}
+
+	return enrichedMetrics
+}
+
+// guessIngress returns the name of the ingress the metric labels refer to. Unlike Traefik, ingress-nginx labels its
+// metrics with the ingress name and namespace, so there is nothing to guess as long as the ingress is known.
+func (p NginxParser) guessIngress(lbls []*dto.LabelPair, state ScrapeState) string {
+	name, ns := getLabel(lbls, "ingress"), getLabel(lbls, "namespace")
+	if name == "" || ns == "" {
+		return ""
+	}
+
+	key := name + "@" + ns
+	for ingressName := range state.Ingresses {
+		// Remove the `.kind.group` from the namespace.
+		if strings.SplitN(ingressName, ".", 2)[0] == key {
+			return key
+		}
+	}
+
+	return ""
+}
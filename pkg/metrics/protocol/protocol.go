@@ -5,3 +5,8 @@ import _ "embed" // Needed for go embed.
 // MetricsV2Schema is the metrics v2 transport schema.
 //go:embed metrics-v2.avsc
 var MetricsV2Schema string
+
+// MetricsV3Schema is the metrics v3 transport schema, adding response time percentiles and buckets, and ingress
+// routes to data point groups.
+//go:embed metrics-v3.avsc
+var MetricsV3Schema string
@@ -19,10 +19,12 @@ package metrics
 
 import (
 	"context"
+	"crypto/tls"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"sync"
 
 	dto "github.com/prometheus/client_model/go"
 	"github.com/prometheus/common/expfmt"
@@ -32,6 +34,8 @@ import (
 // This should match the topology types.
 const (
 	ParserTraefik = "traefik"
+	ParserNginx   = "nginx"
+	ParserHAProxy = "haproxy"
 )
 
 // Metric names.
@@ -46,16 +50,18 @@ const (
 type Metric interface {
 	EdgeIngressName() string
 	IngressName() string
+	IngressRouteName() string
 	ServiceName() string
 }
 
 // Counter represents a counter metric.
 type Counter struct {
-	Name        string
-	EdgeIngress string
-	Ingress     string
-	Service     string
-	Value       uint64
+	Name         string
+	EdgeIngress  string
+	Ingress      string
+	IngressRoute string
+	Service      string
+	Value        uint64
 }
 
 // CounterFromMetric returns a counter metric from a prometheus
@@ -79,6 +85,11 @@ func (c Counter) IngressName() string {
 	return c.Ingress
 }
 
+// IngressRouteName returns the metric ingress route name.
+func (c Counter) IngressRouteName() string {
+	return c.IngressRoute
+}
+
 // ServiceName returns the metric service name.
 func (c Counter) ServiceName() string {
 	return c.Service
@@ -86,13 +97,16 @@ func (c Counter) ServiceName() string {
 
 // Histogram represents a histogram metric.
 type Histogram struct {
-	Name        string
-	Relative    bool
-	EdgeIngress string
-	Ingress     string
-	Service     string
-	Sum         float64
-	Count       uint64
+	Name         string
+	Relative     bool
+	EdgeIngress  string
+	Ingress      string
+	IngressRoute string
+	Service      string
+	Sum          float64
+	Count        uint64
+	// Buckets maps the upper bounds of the histogram buckets to the cumulative count of observations.
+	Buckets map[float64]uint64
 }
 
 // HistogramFromMetric returns a histogram metric from a prometheus
@@ -103,10 +117,48 @@ func HistogramFromMetric(m *dto.Metric) *Histogram {
 		return nil
 	}
 
+	var buckets map[float64]uint64
+	if len(hist.Bucket) > 0 {
+		buckets = make(map[float64]uint64, len(hist.Bucket))
+		for _, bucket := range hist.Bucket {
+			buckets[bucket.GetUpperBound()] = bucket.GetCumulativeCount()
+		}
+	}
+
 	return &Histogram{
-		Sum:   hist.GetSampleSum(),
-		Count: hist.GetSampleCount(),
+		Sum:     hist.GetSampleSum(),
+		Count:   hist.GetSampleCount(),
+		Buckets: buckets,
+	}
+}
+
+// normalizedBuckets returns the number of observations in each of the ResponseTimeBuckets, plus the observations
+// above the last bound. As ingress controllers use their own bucket bounds, the observations of a bucket are counted in
+// the first ResponseTimeBuckets bucket including its whole range, which over-estimates response times.
+func (h Histogram) normalizedBuckets() []int64 {
+	if len(h.Buckets) == 0 {
+		return nil
+	}
+
+	buckets := make([]int64, len(ResponseTimeBuckets)+1)
+
+	var prev uint64
+	for i, bound := range ResponseTimeBuckets {
+		// Cumulative count of the largest bound lower or equal to the normalized bound.
+		var cumulative uint64
+		var largest float64
+		for srcBound, count := range h.Buckets {
+			if srcBound <= bound && srcBound >= largest {
+				largest, cumulative = srcBound, count
+			}
+		}
+
+		buckets[i] = int64(cumulative - prev)
+		prev = cumulative
 	}
+	buckets[len(ResponseTimeBuckets)] = int64(h.Count - prev)
+
+	return buckets
 }
 
 // EdgeIngressName returns the metric edge ingress name.
@@ -119,6 +171,11 @@ func (h Histogram) IngressName() string {
 	return h.Ingress
 }
 
+// IngressRouteName returns the metric ingress route name.
+func (h Histogram) IngressRouteName() string {
+	return h.IngressRoute
+}
+
 // ServiceName returns the metric service name.
 func (h Histogram) ServiceName() string {
 	return h.Service
@@ -141,37 +198,67 @@ type Parser interface {
 type Scraper struct {
 	client *http.Client
 
+	tlsClientsMu sync.Mutex
+	tlsClients   map[string]tlsClient
+
 	traefikParser TraefikParser
+	nginxParser   NginxParser
+	haproxyParser HAProxyParser
 }
 
 // NewScraper returns a scraper instance with parser p.
 func NewScraper(c *http.Client) *Scraper {
 	return &Scraper{
 		client:        c,
+		tlsClients:    make(map[string]tlsClient),
 		traefikParser: NewTraefikParser(),
+		nginxParser:   NewNginxParser(),
+		haproxyParser: NewHAProxyParser(),
 	}
 }
 
+type tlsClient struct {
+	config *tls.Config
+	client *http.Client
+}
+
 // Scrape returns metrics scraped from all targets.
-func (s *Scraper) Scrape(ctx context.Context, parser, target string, state ScrapeState) ([]Metric, error) {
+// Requests are authenticated with the given credentials, if any.
+func (s *Scraper) Scrape(ctx context.Context, parser, target string, creds *Credentials, state ScrapeState) ([]Metric, error) {
 	// This is a naive approach and should be dealt with
 	// as an iterator later to control the amount of RAM
 	// used while scraping many targets with many services.
 	// e.g. 100 pods * 4000 services * 4 metrics = bad news bears (1.6 million)
 
-	var p Parser
 	switch parser {
-	case ParserTraefik:
-		p = s.traefikParser
+	case ParserTraefik, ParserNginx, ParserHAProxy:
 	default:
 		return nil, fmt.Errorf("invalid parser %q", parser)
 	}
 
-	raw, err := s.scrapeMetrics(ctx, target)
+	raw, err := s.scrapeMetrics(ctx, target, creds)
 	if err != nil {
 		return nil, fmt.Errorf("unable to get metrics from target %s", target)
 	}
 
+	return s.Parse(parser, raw, state)
+}
+
+// Parse returns the metrics parsed from the given metric families, obtained other than by scraping.
+func (s *Scraper) Parse(parser string, raw []*dto.MetricFamily, state ScrapeState) ([]Metric, error) {
+	var p Parser
+	switch parser {
+	case ParserNginx:
+		p = s.nginxParser
+	case ParserHAProxy:
+		p = s.haproxyParser
+	case ParserTraefik:
+		// Routers are named differently depending on the Traefik version, which can only be told from its metrics.
+		p = s.traefikParser.withVersion(detectTraefikVersion(raw))
+	default:
+		return nil, fmt.Errorf("invalid parser %q", parser)
+	}
+
 	var m []Metric
 	for _, v := range raw {
 		m = append(m, p.Parse(v, state)...)
@@ -180,12 +267,23 @@ func (s *Scraper) Scrape(ctx context.Context, parser, target string, state Scrap
 	return m, nil
 }
 
-func (s *Scraper) scrapeMetrics(ctx context.Context, target string) ([]*dto.MetricFamily, error) {
+func (s *Scraper) scrapeMetrics(ctx context.Context, target string, creds *Credentials) ([]*dto.MetricFamily, error) {
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, http.NoBody)
 	if err != nil {
 		return nil, err
 	}
-	resp, err := s.client.Do(req)
+
+	client := s.client
+	if creds != nil {
+		if creds.BearerToken != "" {
+			req.Header.Set("Authorization", "Bearer "+creds.BearerToken)
+		}
+		if creds.TLS != nil {
+			client = s.tlsClient(target, creds.TLS)
+		}
+	}
+
+	resp, err := client.Do(req)
 	if err != nil {
 		return nil, err
 	}
@@ -211,3 +309,31 @@ func (s *Scraper) scrapeMetrics(ctx context.Context, target string) ([]*dto.Metr
 		m = append(m, &fam)
 	}
 }
+
+// tlsClient returns the client to use to reach the target with the given TLS configuration.
+// Clients are kept per target to reuse connections, and replaced when the TLS configuration changes.
+func (s *Scraper) tlsClient(target string, cfg *tls.Config) *http.Client {
+	s.tlsClientsMu.Lock()
+	defer s.tlsClientsMu.Unlock()
+
+	if c, ok := s.tlsClients[target]; ok {
+		if c.config == cfg {
+			return c.client
+		}
+		c.client.CloseIdleConnections()
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if t, ok := s.client.Transport.(*http.Transport); ok {
+		transport = t.Clone()
+	}
+	transport.TLSClientConfig = cfg
+
+	client := &http.Client{
+		Transport: transport,
+		Timeout:   s.client.Timeout,
+	}
+	s.tlsClients[target] = tlsClient{config: cfg, client: client}
+
+	return client
+}
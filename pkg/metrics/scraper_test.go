@@ -19,6 +19,9 @@ package metrics_test
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"math"
 	"net/http"
 	"net/http/httptest"
 	"os"
@@ -36,7 +39,7 @@ func TestScraper_ScrapeTraefik(t *testing.T) {
 
 	s := metrics.NewScraper(http.DefaultClient)
 
-	got, err := s.Scrape(context.Background(), metrics.ParserTraefik, srvURL, metrics.ScrapeState{
+	got, err := s.Scrape(context.Background(), metrics.ParserTraefik, srvURL, nil, metrics.ScrapeState{
 		Ingresses:        map[string]struct{}{"myIngress@default.ingress.networking.k8s.io": {}, "app-obe@whoami.ingress.networking.k8s.io": {}},
 		IngressRoutes:    map[string]struct{}{"myIngressRoute@default.ingressroute.traefik.containo.us": {}, "app-traefik@whoami.ingressroute.traefik.containo.us": {}},
 		ServiceIngresses: map[string][]string{"whoami@default": {"myIngress@default.ingress.networking.k8s.io"}, "whoami2@default": {"myIngress@default.ingress.networking.k8s.io"}},
@@ -51,12 +54,121 @@ func TestScraper_ScrapeTraefik(t *testing.T) {
 	require.NoError(t, err)
 
 	// router
-	assert.Contains(t, got, &metrics.Histogram{Name: metrics.MetricRequestDuration, EdgeIngress: "myIngress@default", Sum: 0.0137623, Count: 1})
+	assert.Contains(t, got, &metrics.Histogram{Name: metrics.MetricRequestDuration, EdgeIngress: "myIngress@default", Sum: 0.0137623, Count: 1, Buckets: map[float64]uint64{0.1: 1, 0.3: 1, 1.2: 1, 5: 1, math.Inf(1): 1}})
 	assert.Contains(t, got, &metrics.Counter{Name: metrics.MetricRequests, EdgeIngress: "myIngress@default", Value: 2})
 	// edge cases, TLS/middleware enable on entrypoint
 	assert.Contains(t, got, &metrics.Counter{Name: metrics.MetricRequests, EdgeIngress: "app-obe@whoami", Value: 38})
+	// ingress route
+	assert.Contains(t, got, &metrics.Histogram{Name: metrics.MetricRequestDuration, IngressRoute: "myIngressRoute@default", Sum: 0.0216373, Count: 1, Buckets: map[float64]uint64{0.1: 1, 0.3: 1, 1.2: 1, 5: 1, math.Inf(1): 1}})
+	assert.Contains(t, got, &metrics.Counter{Name: metrics.MetricRequests, IngressRoute: "myIngressRoute@default", Value: 1})
 
-	require.Len(t, got, 3)
+	require.Len(t, got, 5)
+}
+
+func TestScraper_ScrapeTraefikV3(t *testing.T) {
+	srvURL := startServer(t, "testdata/traefik-v3-metrics.txt")
+
+	s := metrics.NewScraper(http.DefaultClient)
+
+	got, err := s.Scrape(context.Background(), metrics.ParserTraefik, srvURL, nil, metrics.ScrapeState{
+		Ingresses:     map[string]struct{}{"myIngress@default.ingress.networking.k8s.io": {}, "app-obe@whoami.ingress.networking.k8s.io": {}},
+		IngressRoutes: map[string]struct{}{"myIngressRoute@default.ingressroute.traefik.io": {}},
+	})
+	require.NoError(t, err)
+
+	// Traefik v3 puts the namespace first in the name of ingress routers.
+	assert.Contains(t, got, &metrics.Histogram{Name: metrics.MetricRequestDuration, EdgeIngress: "myIngress@default", Sum: 0.0137623, Count: 1, Buckets: map[float64]uint64{0.1: 1, 0.3: 1, 1.2: 1, 5: 1, math.Inf(1): 1}})
+	assert.Contains(t, got, &metrics.Counter{Name: metrics.MetricRequests, EdgeIngress: "myIngress@default", Value: 2})
+	assert.Contains(t, got, &metrics.Counter{Name: metrics.MetricRequests, EdgeIngress: "myIngress@default", Value: 1})
+	assert.Contains(t, got, &metrics.Counter{Name: metrics.MetricRequestErrors, EdgeIngress: "myIngress@default", Value: 1})
+	// edge cases, TLS/middleware enable on entrypoint
+	assert.Contains(t, got, &metrics.Counter{Name: metrics.MetricRequests, EdgeIngress: "app-obe@whoami", Value: 38})
+	// ingress route
+	assert.Contains(t, got, &metrics.Counter{Name: metrics.MetricRequests, IngressRoute: "myIngressRoute@default", Value: 1})
+
+	require.Len(t, got, 6)
+}
+
+func TestScraper_ScrapeNginx(t *testing.T) {
+	srvURL := startServer(t, "testdata/nginx-metrics.txt")
+
+	s := metrics.NewScraper(http.DefaultClient)
+
+	got, err := s.Scrape(context.Background(), metrics.ParserNginx, srvURL, nil, metrics.ScrapeState{
+		Ingresses: map[string]struct{}{"myIngress@default.ingress.networking.k8s.io": {}},
+	})
+	require.NoError(t, err)
+
+	assert.Contains(t, got, &metrics.Histogram{Name: metrics.MetricRequestDuration, EdgeIngress: "myIngress@default", Sum: 0.027, Count: 2, Buckets: map[float64]uint64{0.005: 0, math.Inf(1): 2}})
+	assert.Contains(t, got, &metrics.Counter{Name: metrics.MetricRequests, EdgeIngress: "myIngress@default", Value: 2})
+	assert.Contains(t, got, &metrics.Counter{Name: metrics.MetricRequests, EdgeIngress: "myIngress@default", Value: 3})
+	assert.Contains(t, got, &metrics.Counter{Name: metrics.MetricRequestErrors, EdgeIngress: "myIngress@default", Value: 3})
+
+	require.Len(t, got, 4)
+}
+
+func TestScraper_ScrapeHAProxy(t *testing.T) {
+	srvURL := startServer(t, "testdata/haproxy-metrics.txt")
+
+	s := metrics.NewScraper(http.DefaultClient)
+
+	got, err := s.Scrape(context.Background(), metrics.ParserHAProxy, srvURL, nil, metrics.ScrapeState{
+		Ingresses: map[string]struct{}{
+			"myIngress@default.ingress.networking.k8s.io": {},
+			"foo@default.ingress.networking.k8s.io":       {},
+			"bar@default.ingress.networking.k8s.io":       {},
+		},
+		ServiceIngresses: map[string][]string{
+			"whoami@default": {"myIngress@default.ingress.networking.k8s.io"},
+			"shared@default": {"foo@default.ingress.networking.k8s.io", "bar@default.ingress.networking.k8s.io"},
+		},
+	})
+	require.NoError(t, err)
+
+	assert.Contains(t, got, &metrics.Histogram{Name: metrics.MetricRequestDuration, Relative: true, EdgeIngress: "myIngress@default", Sum: 0.012, Count: 1})
+	assert.Contains(t, got, &metrics.Counter{Name: metrics.MetricRequests, EdgeIngress: "myIngress@default", Value: 12})
+	assert.Contains(t, got, &metrics.Counter{Name: metrics.MetricRequests, EdgeIngress: "myIngress@default", Value: 2})
+	assert.Contains(t, got, &metrics.Counter{Name: metrics.MetricRequestClientErrors, EdgeIngress: "myIngress@default", Value: 2})
+	assert.Contains(t, got, &metrics.Counter{Name: metrics.MetricRequests, EdgeIngress: "myIngress@default", Value: 1})
+	assert.Contains(t, got, &metrics.Counter{Name: metrics.MetricRequestErrors, EdgeIngress: "myIngress@default", Value: 1})
+
+	require.Len(t, got, 6)
+}
+
+func TestScraper_ScrapeAuthenticated(t *testing.T) {
+	data, err := os.ReadFile("testdata/nginx-metrics.txt")
+	require.NoError(t, err)
+
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		_, _ = w.Write(data)
+	}))
+	t.Cleanup(srv.Close)
+
+	pool := x509.NewCertPool()
+	pool.AddCert(srv.Certificate())
+
+	s := metrics.NewScraper(http.DefaultClient)
+	state := metrics.ScrapeState{
+		Ingresses: map[string]struct{}{"myIngress@default.ingress.networking.k8s.io": {}},
+	}
+
+	_, err = s.Scrape(context.Background(), metrics.ParserNginx, srv.URL, &metrics.Credentials{
+		TLS: &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12},
+	}, state)
+	require.Error(t, err)
+
+	got, err := s.Scrape(context.Background(), metrics.ParserNginx, srv.URL, &metrics.Credentials{
+		BearerToken: "secret",
+		TLS:         &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12},
+	}, state)
+	require.NoError(t, err)
+
+	assert.Len(t, got, 4)
 }
 
 func startServer(t *testing.T, file string) string {
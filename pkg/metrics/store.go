@@ -32,16 +32,18 @@ type tableInfo struct {
 }
 
 type tableKey struct {
-	EdgeIngress string
-	Ingress     string
-	Service     string
+	EdgeIngress  string
+	Ingress      string
+	IngressRoute string
+	Service      string
 }
 
 func toTableKey(grp DataPointGroup) tableKey {
 	return tableKey{
-		EdgeIngress: grp.EdgeIngress,
-		Ingress:     grp.Ingress,
-		Service:     grp.Service,
+		EdgeIngress:  grp.EdgeIngress,
+		Ingress:      grp.Ingress,
+		IngressRoute: grp.IngressRoute,
+		Service:      grp.Service,
 	}
 }
 
@@ -127,9 +129,9 @@ func (s *Store) Insert(svcs map[SetKey]DataPoint) {
 }
 
 // ForEachFunc represents a function that will be called while iterating over a table.
-// Each time this function is called, a unique ingress and service will
+// Each time this function is called, a unique ingress, ingress route and service will
 // be given with their set of points.
-type ForEachFunc func(edgeIngr, ingr, svc string, pnts DataPoints)
+type ForEachFunc func(edgeIngr, ingr, ingRoute, svc string, pnts DataPoints)
 
 // ForEach iterates over a table, executing fn for each row.
 func (s *Store) ForEach(tbl string, fn ForEachFunc) {
@@ -142,7 +144,7 @@ func (s *Store) ForEach(tbl string, fn ForEachFunc) {
 	}
 
 	for k, v := range table {
-		fn(k.EdgeIngress, k.Ingress, k.Service, v)
+		fn(k.EdgeIngress, k.Ingress, k.IngressRoute, k.Service, v)
 	}
 }
 
@@ -166,7 +168,7 @@ func (s *Store) ForEachUnmarked(tbl string, fn ForEachFunc) WaterMarks {
 			continue
 		}
 
-		fn(k.EdgeIngress, k.Ingress, k.Service, v[mark:])
+		fn(k.EdgeIngress, k.Ingress, k.IngressRoute, k.Service, v[mark:])
 	}
 
 	return newMarks
@@ -78,7 +78,7 @@ func TestStore_Insert(t *testing.T) {
 	})
 
 	var got []DataPoint
-	store.ForEach("1m", func(_, ingr, svc string, pnts DataPoints) {
+	store.ForEach("1m", func(_, ingr, _, svc string, pnts DataPoints) {
 		got = append(got, pnts...)
 	})
 
@@ -114,15 +114,15 @@ func TestStore_RollUp(t *testing.T) {
 
 	store.RollUp()
 
-	store.ForEach("1m", func(_, ingr, svc string, pnts DataPoints) {
+	store.ForEach("1m", func(_, ingr, _, svc string, pnts DataPoints) {
 		assert.Len(t, pnts, numPnts)
 	})
 
-	store.ForEach("10m", func(_, ingr, svc string, pnts DataPoints) {
+	store.ForEach("10m", func(_, ingr, _, svc string, pnts DataPoints) {
 		assert.Len(t, pnts, 11)
 	})
 
-	store.ForEach("1h", func(_, ingr, svc string, pnts DataPoints) {
+	store.ForEach("1h", func(_, ingr, _, svc string, pnts DataPoints) {
 		assert.Len(t, pnts, 2)
 	})
 }
@@ -154,11 +154,11 @@ func TestStore_Cleanup(t *testing.T) {
 
 	store.Cleanup()
 
-	store.ForEach("1m", func(_, ingr, svc string, pnts DataPoints) {
+	store.ForEach("1m", func(_, ingr, _, svc string, pnts DataPoints) {
 		assert.Len(t, pnts, 10)
 	})
 
-	store.ForEach("10m", func(_, ingr, svc string, pnts DataPoints) {
+	store.ForEach("10m", func(_, ingr, _, svc string, pnts DataPoints) {
 		assert.Len(t, pnts, 6)
 	})
 }
@@ -180,7 +180,7 @@ func TestStore_CleanupDoesntRemoveUnmarked(t *testing.T) {
 
 	store.Cleanup()
 
-	store.ForEach("1m", func(_, ingr, svc string, pnts DataPoints) {
+	store.ForEach("1m", func(_, ingr, _, svc string, pnts DataPoints) {
 		assert.Len(t, pnts, 103)
 	})
 }
@@ -43,8 +43,8 @@ func NewDataPointView(s DataPointGroupIterator) *DataPointView {
 	}
 }
 
-// FindByIngressAndService finds the data points for the traffic on the given service via the given ingress for the
-// specified time range (inclusive).
+// FindByIngressAndService finds the data points for the traffic on the given service via the given ingress, or ingress
+// route, for the specified time range (inclusive).
 func (v *DataPointView) FindByIngressAndService(table, ingress, service string, from, to time.Time) (DataPoints, error) {
 	if to.Before(from) || to == from {
 		return nil, nil
@@ -57,8 +57,8 @@ func (v *DataPointView) FindByIngressAndService(table, ingress, service string,
 		groupFound    bool
 		err           error
 	)
-	v.store.ForEach(table, func(_, ingr, svc string, points DataPoints) {
-		if ingr != ingress || svc != service {
+	v.store.ForEach(table, func(_, ingr, ingRoute, svc string, points DataPoints) {
+		if (ingr != ingress && ingRoute != ingress) || svc != service {
 			return
 		}
 		if groupFound {
@@ -96,7 +96,7 @@ func (v *DataPointView) FindByService(table, service string, from, to time.Time)
 	fromTS, toTS := from.Unix(), to.Unix()
 
 	var groups []DataPoints
-	v.store.ForEach(table, func(_, _, svc string, points DataPoints) {
+	v.store.ForEach(table, func(_, _, _, svc string, points DataPoints) {
 		if svc != service {
 			return
 		}
@@ -117,7 +117,8 @@ func (v *DataPointView) FindByService(table, service string, from, to time.Time)
 	return mergeGroups(groups)
 }
 
-// FindByIngress finds the data points for the traffic on the given ingress for the specified time range (inclusive).
+// FindByIngress finds the data points for the traffic on the given ingress, or ingress route, for the specified time
+// range (inclusive).
 func (v *DataPointView) FindByIngress(table, ingress string, from, to time.Time) DataPoints {
 	if to.Before(from) || to == from {
 		return nil
@@ -126,8 +127,39 @@ func (v *DataPointView) FindByIngress(table, ingress string, from, to time.Time)
 	fromTS, toTS := from.Unix(), to.Unix()
 
 	var groups []DataPoints
-	v.store.ForEach(table, func(_, ingr, _ string, points DataPoints) {
-		if ingr != ingress {
+	v.store.ForEach(table, func(_, ingr, ingRoute, _ string, points DataPoints) {
+		if ingr != ingress && ingRoute != ingress {
+			return
+		}
+
+		// Filter points to only keep those in the given time range.
+		var pointsInRange DataPoints
+		for _, point := range points {
+			if point.Timestamp < fromTS || point.Timestamp > toTS {
+				continue
+			}
+
+			pointsInRange = append(pointsInRange, point)
+		}
+
+		groups = append(groups, pointsInRange)
+	})
+
+	return mergeGroups(groups)
+}
+
+// FindByEdgeIngress finds the data points for the traffic on the given edge ingress for the specified time range
+// (inclusive).
+func (v *DataPointView) FindByEdgeIngress(table, edgeIngress string, from, to time.Time) DataPoints {
+	if to.Before(from) || to == from {
+		return nil
+	}
+
+	fromTS, toTS := from.Unix(), to.Unix()
+
+	var groups []DataPoints
+	v.store.ForEach(table, func(edgeIngr, _, _, _ string, points DataPoints) {
+		if edgeIngr != edgeIngress {
 			return
 		}
 
@@ -167,6 +199,7 @@ func mergeGroups(groups []DataPoints) DataPoints {
 			sum.RequestClientErrs += point.RequestClientErrs
 			sum.ResponseTimeSum += point.ResponseTimeSum
 			sum.ResponseTimeCount += point.ResponseTimeCount
+			sum.ResponseTimeBuckets = addBuckets(sum.ResponseTimeBuckets, point.ResponseTimeBuckets)
 
 			pointSums[point.Timestamp] = sum
 			counts[point.Timestamp]++
@@ -197,6 +230,8 @@ func mergeGroups(groups []DataPoints) DataPoints {
 		if point.ResponseTimeCount > 0 {
 			point.AvgResponseTime = point.ResponseTimeSum / float64(point.ResponseTimeCount)
 		}
+		point.ResponseTimeP95 = responseTimePercentile(point.ResponseTimeBuckets, 0.95)
+		point.ResponseTimeP99 = responseTimePercentile(point.ResponseTimeBuckets, 0.99)
 
 		points = append(points, point)
 	}
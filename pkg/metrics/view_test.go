@@ -273,7 +273,7 @@ func TestDataPointView_FindByIngressAndService(t *testing.T) {
 			store.OnForEachRaw(test.input.table, mock.Anything).
 				TypedRun(func(_ string, fn ForEachFunc) {
 					for _, group := range test.groups {
-						fn(group.EdgeIngress, group.Ingress, group.Service, group.DataPoints)
+						fn(group.EdgeIngress, group.Ingress, group.IngressRoute, group.Service, group.DataPoints)
 					}
 				}).
 				Maybe()
@@ -632,7 +632,7 @@ func TestDataPointView_FindByService(t *testing.T) {
 			store.OnForEachRaw(test.input.table, mock.Anything).
 				TypedRun(func(s string, fn ForEachFunc) {
 					for _, group := range test.groups {
-						fn(group.EdgeIngress, group.Ingress, group.Service, group.DataPoints)
+						fn(group.EdgeIngress, group.Ingress, group.IngressRoute, group.Service, group.DataPoints)
 					}
 				}).
 				Maybe()
@@ -756,6 +756,50 @@ func TestDataPointView_FindByIngress(t *testing.T) {
 				},
 			},
 		},
+		{
+			desc: "data points found for ingress route",
+			groups: []DataPointGroup{
+				{
+					Ingress: "ingress-2",
+					Service: "service-1",
+					DataPoints: DataPoints{
+						genPoint(now.Add(-3*time.Minute), 1, 1, 1, 1, 1, 1),
+					},
+				},
+				{
+					IngressRoute: "ingress-1",
+					Service:      "service-1",
+					DataPoints: DataPoints{
+						genPoint(now.Add(-3*time.Minute), 60, 120, 30, 30, 12, 120),
+					},
+				},
+			},
+			input: input{
+				table:   "1m",
+				ingress: "ingress-1",
+				from:    now.Add(-3 * time.Minute),
+				to:      now.Add(-2 * time.Minute),
+			},
+			expected: DataPoints{
+				{
+					Timestamp: now.Add(-3 * time.Minute).Unix(),
+
+					Seconds:           60,
+					Requests:          120,
+					RequestErrs:       30,
+					RequestClientErrs: 30,
+					ResponseTimeSum:   12,
+					ResponseTimeCount: 120,
+
+					ReqPerS:                 2,
+					RequestErrPerS:          0.5,
+					RequestErrPercent:       0.25,
+					RequestClientErrPerS:    0.5,
+					RequestClientErrPercent: 0.25,
+					AvgResponseTime:         0.1,
+				},
+			},
+		},
 		{
 			desc: "data point group found but no data points",
 			groups: []DataPointGroup{
@@ -982,7 +1026,7 @@ func TestDataPointView_FindByIngress(t *testing.T) {
 			store.OnForEachRaw(test.input.table, mock.Anything).
 				TypedRun(func(_ string, fn ForEachFunc) {
 					for _, group := range test.groups {
-						fn(group.EdgeIngress, group.Ingress, group.Service, group.DataPoints)
+						fn(group.EdgeIngress, group.Ingress, group.IngressRoute, group.Service, group.DataPoints)
 					}
 				}).
 				Maybe()
@@ -995,6 +1039,109 @@ func TestDataPointView_FindByIngress(t *testing.T) {
 	}
 }
 
+func TestDataPointView_FindByEdgeIngress(t *testing.T) {
+	now := time.Date(2021, 1, 1, 8, 21, 43, 0, time.UTC)
+
+	type input struct {
+		table       string
+		edgeIngress string
+		from        time.Time
+		to          time.Time
+	}
+
+	tests := []struct {
+		desc     string
+		groups   []DataPointGroup
+		input    input
+		expected DataPoints
+	}{
+		{
+			desc: "unknown table",
+			input: input{
+				table:       "unknown",
+				edgeIngress: "whoami@default",
+				from:        now.Add(-10 * time.Minute),
+				to:          now.Add(-time.Minute),
+			},
+		},
+		{
+			desc: "data points found for edge ingress",
+			groups: []DataPointGroup{
+				{
+					EdgeIngress: "other@default",
+					DataPoints: DataPoints{
+						genPoint(now.Add(-3*time.Minute), 1, 1, 1, 1, 1, 1),
+					},
+				},
+				{
+					EdgeIngress: "whoami@default",
+					DataPoints: DataPoints{
+						genPoint(now.Add(-4*time.Minute), 60, 60, 15, 15, 6, 60),
+						genPoint(now.Add(-3*time.Minute), 60, 120, 30, 30, 12, 120),
+						genPoint(now.Add(-2*time.Minute), 60, 240, 60, 60, 24, 240),
+					},
+				},
+				{
+					Ingress: "whoami@default",
+					Service: "whoami@default",
+					DataPoints: DataPoints{
+						genPoint(now.Add(-3*time.Minute), 1, 1, 1, 1, 1, 1),
+					},
+				},
+			},
+			input: input{
+				table:       "1m",
+				edgeIngress: "whoami@default",
+				from:        now.Add(-3 * time.Minute),
+				to:          now.Add(-2 * time.Minute),
+			},
+			expected: DataPoints{
+				genPoint(now.Add(-3*time.Minute), 60, 120, 30, 30, 12, 120),
+				genPoint(now.Add(-2*time.Minute), 60, 240, 60, 60, 24, 240),
+			},
+		},
+		{
+			desc: "no data points in range",
+			groups: []DataPointGroup{
+				{
+					EdgeIngress: "whoami@default",
+					DataPoints: DataPoints{
+						genPoint(now.Add(-3*time.Minute), 60, 60, 15, 15, 6, 60),
+					},
+				},
+			},
+			input: input{
+				table:       "1m",
+				edgeIngress: "whoami@default",
+				from:        now.Add(-2 * time.Minute),
+				to:          now.Add(-time.Minute),
+			},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			store := newDataPointGroupIteratorMock(t)
+			store.OnForEachRaw(test.input.table, mock.Anything).
+				TypedRun(func(_ string, fn ForEachFunc) {
+					for _, group := range test.groups {
+						fn(group.EdgeIngress, group.Ingress, group.IngressRoute, group.Service, group.DataPoints)
+					}
+				}).
+				Maybe()
+
+			view := DataPointView{store: store, nowFunc: func() time.Time { return now }}
+			gotPoints := view.FindByEdgeIngress(test.input.table, test.input.edgeIngress, test.input.from, test.input.to)
+
+			assert.Equal(t, test.expected, gotPoints)
+		})
+	}
+}
+
 func genPoint(ts time.Time, secs, reqs, reqErrs, reqClientErrs int64, respTimeSum float64, respTimeCount int64) DataPoint {
 	return DataPoint{
 		Timestamp: ts.Unix(),
@@ -0,0 +1,145 @@
+/*
+Copyright (C) 2022 Traefik Labs
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+// Package otlp implements the parts of the OTLP/HTTP protocol with the JSON encoding shared by the metrics and traces
+// exporters.
+package otlp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"time"
+)
+
+// Client sends export requests of a given signal to an OpenTelemetry collector.
+type Client struct {
+	signal     string
+	endpoint   string
+	headers    map[string]string
+	httpClient *http.Client
+}
+
+// NewClient creates a client sending export requests of the given signal (e.g. metrics or traces) to the collector
+// reachable at the given base URL (e.g. http://otel-collector:4318).
+func NewClient(client *http.Client, baseURL, signal string, headers map[string]string) (*Client, error) {
+	base, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid otlp exporter url: %w", err)
+	}
+	base.Path = path.Join(base.Path, "v1", signal)
+
+	return &Client{
+		signal:     signal,
+		endpoint:   base.String(),
+		headers:    headers,
+		httpClient: client,
+	}, nil
+}
+
+// Send sends the given export request, which must be the JSON representation of an OTLP export service request.
+func (c *Client) Send(ctx context.Context, request interface{}) error {
+	raw, err := json.Marshal(request)
+	if err != nil {
+		return fmt.Errorf("marshal otlp request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint, bytes.NewReader(raw))
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+
+	for key, value := range c.headers {
+		req.Header.Set(key, value)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("exporting %s: %w", c.signal, err)
+	}
+
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode/100 != 2 {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("exporting %s got %d: %s", c.signal, resp.StatusCode, string(body))
+	}
+
+	return nil
+}
+
+// The following types are the JSON representation of the OTLP common and resource messages, see
+// opentelemetry/proto/common/v1/common.proto. As defined by the protobuf JSON mapping, 64 bits integers are encoded as
+// strings.
+
+// Resource is the entity producing telemetry.
+type Resource struct {
+	Attributes []KeyValue `json:"attributes"`
+}
+
+// Scope is the instrumentation scope producing telemetry.
+type Scope struct {
+	Name string `json:"name"`
+}
+
+// KeyValue is an attribute.
+type KeyValue struct {
+	Key   string   `json:"key"`
+	Value AnyValue `json:"value"`
+}
+
+// AnyValue is the value of an attribute.
+type AnyValue struct {
+	StringValue *string `json:"stringValue,omitempty"`
+	IntValue    *string `json:"intValue,omitempty"`
+	BoolValue   *bool   `json:"boolValue,omitempty"`
+}
+
+// NewServiceResource returns a resource identifying the given service.
+func NewServiceResource(serviceName string) Resource {
+	return Resource{Attributes: []KeyValue{NewAttribute("service.name", serviceName)}}
+}
+
+// NewAttribute returns an attribute with the given key and value. Booleans and 64 bits integers keep their type,
+// any other value is formatted as a string.
+func NewAttribute(key string, value interface{}) KeyValue {
+	var v AnyValue
+	switch val := value.(type) {
+	case bool:
+		v.BoolValue = &val
+	case int64:
+		i := strconv.FormatInt(val, 10)
+		v.IntValue = &i
+	default:
+		s := fmt.Sprint(val)
+		v.StringValue = &s
+	}
+
+	return KeyValue{Key: key, Value: v}
+}
+
+// UnixNano returns the given time as an OTLP timestamp.
+func UnixNano(t time.Time) string {
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
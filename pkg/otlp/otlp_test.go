@@ -0,0 +1,111 @@
+/*
+Copyright (C) 2022 Traefik Labs
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package otlp_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/traefik/hub-agent-kubernetes/pkg/otlp"
+)
+
+func TestClient_Send(t *testing.T) {
+	var got map[string]interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, http.MethodPost, req.Method)
+		assert.Equal(t, "/otlp/v1/metrics", req.URL.Path)
+		assert.Equal(t, "application/json", req.Header.Get("Content-Type"))
+		assert.Equal(t, "secret", req.Header.Get("X-Api-Key"))
+
+		require.NoError(t, json.NewDecoder(req.Body).Decode(&got))
+	}))
+	t.Cleanup(srv.Close)
+
+	client, err := otlp.NewClient(http.DefaultClient, srv.URL+"/otlp", "metrics", map[string]string{"X-Api-Key": "secret"})
+	require.NoError(t, err)
+
+	err = client.Send(context.Background(), otlp.NewServiceResource("hub-agent-kubernetes"))
+	require.NoError(t, err)
+
+	want := map[string]interface{}{
+		"attributes": []interface{}{
+			map[string]interface{}{"key": "service.name", "value": map[string]interface{}{"stringValue": "hub-agent-kubernetes"}},
+		},
+	}
+	assert.Equal(t, want, got)
+}
+
+func TestClient_SendHandlesHTTPError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		http.Error(rw, "test error", http.StatusBadRequest)
+	}))
+	t.Cleanup(srv.Close)
+
+	client, err := otlp.NewClient(http.DefaultClient, srv.URL, "traces", nil)
+	require.NoError(t, err)
+
+	err = client.Send(context.Background(), struct{}{})
+	assert.EqualError(t, err, "exporting traces got 400: test error\n")
+}
+
+func TestNewAttribute(t *testing.T) {
+	t.Parallel()
+
+	str, i, b := "value", "3", true
+
+	tests := []struct {
+		desc  string
+		value interface{}
+		want  otlp.AnyValue
+	}{
+		{
+			desc:  "string",
+			value: "value",
+			want:  otlp.AnyValue{StringValue: &str},
+		},
+		{
+			desc:  "int64",
+			value: int64(3),
+			want:  otlp.AnyValue{IntValue: &i},
+		},
+		{
+			desc:  "bool",
+			value: true,
+			want:  otlp.AnyValue{BoolValue: &b},
+		},
+		{
+			desc:  "other types are formatted as strings",
+			value: 3,
+			want:  otlp.AnyValue{StringValue: &i},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, otlp.KeyValue{Key: "key", Value: test.want}, otlp.NewAttribute("key", test.value))
+		})
+	}
+}
@@ -35,6 +35,7 @@ import (
 	hubv1alpha1 "github.com/traefik/hub-agent-kubernetes/pkg/crd/api/hub/v1alpha1"
 	"github.com/traefik/hub-agent-kubernetes/pkg/edgeingress"
 	"github.com/traefik/hub-agent-kubernetes/pkg/logger"
+	"github.com/traefik/hub-agent-kubernetes/pkg/tracing"
 )
 
 // APIError represents an error returned by the API.
@@ -64,6 +65,7 @@ func NewClient(baseURL, token string) (*Client, error) {
 	rc := retryablehttp.NewClient()
 	rc.RetryMax = 4
 	rc.Logger = logger.NewWrappedLogger(log.Logger.With().Str("component", "platform_client").Logger())
+	rc.HTTPClient.Transport = tracing.Transport(instrumentRoundTripper(rc.HTTPClient.Transport))
 
 	return &Client{
 		baseURL:    u,
@@ -129,8 +131,11 @@ func (c *Client) Link(ctx context.Context, kubeID string) (string, error) {
 
 // Config holds the configuration of the offer.
 type Config struct {
-	Topology TopologyConfig `json:"topology"`
-	Metrics  MetricsConfig  `json:"metrics"`
+	Topology    TopologyConfig    `json:"topology"`
+	Metrics     MetricsConfig     `json:"metrics"`
+	EdgeIngress EdgeIngressConfig `json:"edgeIngress"`
+	Logging     LoggingConfig     `json:"logging"`
+	Agent       AgentConfig       `json:"agent"`
 }
 
 // TopologyConfig holds the topology part of the offer config.
@@ -144,6 +149,63 @@ type TopologyConfig struct {
 type MetricsConfig struct {
 	Interval time.Duration `json:"interval"`
 	Tables   []string      `json:"tables"`
+	// ScrapeAuth configures how to authenticate to the metrics endpoints of ingress controllers.
+	ScrapeAuth []ScrapeAuthConfig `json:"scrapeAuth,omitempty"`
+}
+
+// ScrapeAuthConfig configures the authentication to the metrics endpoints of ingress controllers, with credentials
+// taken from Secrets.
+type ScrapeAuthConfig struct {
+	// IngressControllerType is the type of the ingress controllers (traefik, nginx or haproxy) the configuration
+	// applies to.
+	IngressControllerType string `json:"ingressControllerType"`
+	// IngressController restricts the configuration to the ingress controller with this name (name@namespace).
+	IngressController string `json:"ingressController,omitempty"`
+
+	// BearerToken is the Secret key holding the token sent in the Authorization header.
+	BearerToken *SecretKeyRef `json:"bearerToken,omitempty"`
+	// CA is the Secret key holding the PEM encoded CA certificates used to verify the endpoint certificate.
+	// System CAs are used if unset.
+	CA *SecretKeyRef `json:"ca,omitempty"`
+	// ClientCertSecret is the kubernetes.io/tls Secret holding the client certificate presented to the endpoint.
+	ClientCertSecret *SecretRef `json:"clientCertSecret,omitempty"`
+	// ServerName is the name used to verify the endpoint certificate, instead of the host of the metrics URL.
+	ServerName string `json:"serverName,omitempty"`
+	// InsecureSkipVerify disables the verification of the endpoint certificate.
+	InsecureSkipVerify bool `json:"insecureSkipVerify,omitempty"`
+}
+
+// SecretRef references a Secret.
+type SecretRef struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+}
+
+// SecretKeyRef references a key of a Secret.
+type SecretKeyRef struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	Key       string `json:"key"`
+}
+
+// EdgeIngressConfig holds the edge ingress part of the offer config.
+type EdgeIngressConfig struct {
+	// NamespaceQuota is the maximum number of edge ingresses per namespace. Zero means no limit.
+	NamespaceQuota int `json:"namespaceQuota,omitempty"`
+	// NamespaceQuotas overrides NamespaceQuota for specific namespaces.
+	NamespaceQuotas map[string]int `json:"namespaceQuotas,omitempty"`
+}
+
+// LoggingConfig holds the logging part of the offer config. Empty values leave the level and format given at startup.
+type LoggingConfig struct {
+	Level  string `json:"level,omitempty"`
+	Format string `json:"format,omitempty"`
+}
+
+// AgentConfig holds the agent part of the offer config.
+type AgentConfig struct {
+	// LatestVersion is the latest agent version available.
+	LatestVersion string `json:"latestVersion,omitempty"`
 }
 
 // GetConfig returns the agent configuration.
@@ -293,14 +355,49 @@ func (c *Client) ListVerifiedDomains(ctx context.Context) ([]string, error) {
 type CreateEdgeIngressReq struct {
 	Name      string  `json:"name"`
 	Namespace string  `json:"namespace"`
+	Mode      string  `json:"mode,omitempty"`
 	Service   Service `json:"service"`
 	ACP       *ACP    `json:"acp,omitempty"`
+
+	CustomDomains []string          `json:"customDomains,omitempty"`
+	Services      []WeightedService `json:"services,omitempty"`
+	Sticky        *Sticky           `json:"sticky,omitempty"`
+	ACPs          []ACP             `json:"acps,omitempty"`
+	Middlewares   *Middlewares      `json:"middlewares,omitempty"`
+
+	IngressAnnotations map[string]string `json:"ingressAnnotations,omitempty"`
+	IngressLabels      map[string]string `json:"ingressLabels,omitempty"`
+
+	EntryPoints []string `json:"entryPoints,omitempty"`
+	TLS         *TLS     `json:"tls,omitempty"`
+
+	AllowedSourceIPs []string `json:"allowedSourceIPs,omitempty"`
+	Headers          *Headers `json:"headers,omitempty"`
+
+	Paused      bool         `json:"paused,omitempty"`
+	Maintenance *Maintenance `json:"maintenance,omitempty"`
+
+	Bandwidth *Bandwidth `json:"bandwidth,omitempty"`
 }
 
 // Service defines the service being exposed by the edge ingress.
 type Service struct {
-	Name string `json:"name"`
-	Port int    `json:"port"`
+	Name     string `json:"name"`
+	Port     int    `json:"port"`
+	Protocol string `json:"protocol,omitempty"`
+}
+
+// WeightedService defines a service receiving a share of the traffic of the edge ingress.
+type WeightedService struct {
+	Name   string `json:"name"`
+	Port   int    `json:"port"`
+	Weight int    `json:"weight"`
+}
+
+// Sticky defines the sticky sessions configuration of the edge ingress.
+type Sticky struct {
+	CookieName string `json:"cookieName,omitempty"`
+	TTL        int    `json:"ttl,omitempty"`
 }
 
 // ACP defines the ACP attached to the edge ingress.
@@ -308,6 +405,54 @@ type ACP struct {
 	Name string `json:"name"`
 }
 
+// Middlewares defines the middlewares applied by the edge ingress.
+type Middlewares struct {
+	RateLimit *RateLimit   `json:"rateLimit,omitempty"`
+	Compress  *Compression `json:"compress,omitempty"`
+}
+
+// Compression defines the compression of responses applied by the edge ingress.
+type Compression struct {
+	ExcludedContentTypes []string `json:"excludedContentTypes,omitempty"`
+	MinResponseBodyBytes int      `json:"minResponseBodyBytes,omitempty"`
+}
+
+// RateLimit defines the rate limiting applied by the edge ingress.
+type RateLimit struct {
+	Average int64 `json:"average"`
+	Burst   int64 `json:"burst,omitempty"`
+}
+
+// TLS defines how TLS is handled by the edge ingress.
+type TLS struct {
+	Options      string `json:"options,omitempty"`
+	RedirectHTTP bool   `json:"redirectHTTP,omitempty"`
+}
+
+// Headers defines the headers manipulated by the edge ingress.
+type Headers struct {
+	Request  *HeaderRules `json:"request,omitempty"`
+	Response *HeaderRules `json:"response,omitempty"`
+}
+
+// HeaderRules defines the headers to set and remove.
+type HeaderRules struct {
+	Set    map[string]string `json:"set,omitempty"`
+	Remove []string          `json:"remove,omitempty"`
+}
+
+// Bandwidth defines the bandwidth caps of the edge ingress on the tunnel.
+type Bandwidth struct {
+	EgressBytesPerSecond int64 `json:"egressBytesPerSecond"`
+	BurstBytes           int64 `json:"burstBytes,omitempty"`
+}
+
+// Maintenance defines how requests are handled while the edge ingress is paused.
+type Maintenance struct {
+	Message     string `json:"message,omitempty"`
+	RemoveRoute bool   `json:"removeRoute,omitempty"`
+}
+
 // ErrVersionConflict indicates a conflict error on the EdgeIngress resource being modified.
 var ErrVersionConflict = errors.New("version conflict")
 
@@ -360,8 +505,29 @@ func (c *Client) CreateEdgeIngress(ctx context.Context, createReq *CreateEdgeIng
 
 // UpdateEdgeIngressReq is a request for updating an edge ingress.
 type UpdateEdgeIngressReq struct {
+	Mode    string  `json:"mode,omitempty"`
 	Service Service `json:"service"`
 	ACP     *ACP    `json:"acp,omitempty"`
+
+	CustomDomains []string          `json:"customDomains,omitempty"`
+	Services      []WeightedService `json:"services,omitempty"`
+	Sticky        *Sticky           `json:"sticky,omitempty"`
+	ACPs          []ACP             `json:"acps,omitempty"`
+	Middlewares   *Middlewares      `json:"middlewares,omitempty"`
+
+	IngressAnnotations map[string]string `json:"ingressAnnotations,omitempty"`
+	IngressLabels      map[string]string `json:"ingressLabels,omitempty"`
+
+	EntryPoints []string `json:"entryPoints,omitempty"`
+	TLS         *TLS     `json:"tls,omitempty"`
+
+	AllowedSourceIPs []string `json:"allowedSourceIPs,omitempty"`
+	Headers          *Headers `json:"headers,omitempty"`
+
+	Paused      bool         `json:"paused,omitempty"`
+	Maintenance *Maintenance `json:"maintenance,omitempty"`
+
+	Bandwidth *Bandwidth `json:"bandwidth,omitempty"`
 }
 
 // UpdateEdgeIngress updated an edge ingress.
@@ -413,7 +579,7 @@ func (c *Client) UpdateEdgeIngress(ctx context.Context, namespace, name, lastKno
 	}
 }
 
-// DeleteEdgeIngress deletes an edge ingress.
+// DeleteEdgeIngress deletes an edge ingress. Deleting an edge ingress which doesn't exist is not an error.
 func (c *Client) DeleteEdgeIngress(ctx context.Context, namespace, name, lastKnownVersion string) error {
 	id := name + "@" + namespace
 
@@ -439,7 +605,7 @@ func (c *Client) DeleteEdgeIngress(ctx context.Context, namespace, name, lastKno
 	switch resp.StatusCode {
 	case http.StatusConflict:
 		return ErrVersionConflict
-	case http.StatusNoContent:
+	case http.StatusNoContent, http.StatusNotFound:
 		return nil
 	default:
 		all, _ := io.ReadAll(resp.Body)
@@ -134,6 +134,14 @@ func TestClient_GetConfig(t *testing.T) {
 					Interval: time.Minute,
 					Tables:   []string{"1m", "10m"},
 				},
+				EdgeIngress: EdgeIngressConfig{
+					NamespaceQuota:  10,
+					NamespaceQuotas: map[string]int{"team-a": 20},
+				},
+				Logging: LoggingConfig{
+					Level:  "debug",
+					Format: "console",
+				},
 			},
 			wantErr: assert.NoError,
 		},
@@ -566,6 +574,14 @@ func TestClient_DeleteEdgeIngress(t *testing.T) {
 			returnStatusCode: http.StatusConflict,
 			wantErr:          assertErrorIs(ErrVersionConflict),
 		},
+		{
+			desc:             "already deleted",
+			version:          "version-1",
+			name:             "name",
+			namespace:        "namespace",
+			returnStatusCode: http.StatusNotFound,
+			wantErr:          assert.NoError,
+		},
 	}
 
 	for _, test := range tests {
@@ -0,0 +1,60 @@
+/*
+Copyright (C) 2022 Traefik Labs
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package platform
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const (
+	metricsNamespace = "hub_agent"
+	metricsSubsystem = "platform_client"
+)
+
+var (
+	clientRequestsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Subsystem: metricsSubsystem,
+		Name:      "requests_total",
+		Help:      "Number of requests sent to the Hub platform, retries included, by method and status code.",
+	}, []string{"method", "code"})
+	clientRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Subsystem: metricsSubsystem,
+		Name:      "request_duration_seconds",
+		Help:      "Time taken by the Hub platform to answer requests, by method.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method"})
+)
+
+func init() {
+	prometheus.MustRegister(
+		clientRequestsTotal,
+		clientRequestDuration,
+	)
+}
+
+// instrumentRoundTripper records the requests sent through the given round tripper.
+func instrumentRoundTripper(next http.RoundTripper) http.RoundTripper {
+	return promhttp.InstrumentRoundTripperCounter(clientRequestsTotal,
+		promhttp.InstrumentRoundTripperDuration(clientRequestDuration, next),
+	)
+}
@@ -0,0 +1,205 @@
+/*
+Copyright (C) 2022 Traefik Labs
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package shard
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	kerror "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clientset "k8s.io/client-go/kubernetes"
+)
+
+const labelGroup = "hub.traefik.io/shard-group"
+
+// Config configures the membership of a replica to a shard group.
+type Config struct {
+	// Group is the name of the group of replicas the namespaces are distributed among.
+	Group string
+	// Namespace is the namespace in which the Leases of the members are stored.
+	Namespace string
+	// Identity identifies the replica, typically the name of its Pod.
+	Identity string
+
+	// LeaseDuration is the time after which a member which stopped renewing its Lease leaves the group.
+	LeaseDuration time.Duration
+	// RenewInterval is the interval at which the Lease of the replica is renewed and the members are refreshed.
+	RenewInterval time.Duration
+}
+
+// Membership maintains the membership of a replica to a shard group, through a Lease renewed by each member, and
+// distributes the namespaces among the live members.
+type Membership struct {
+	clientSet clientset.Interface
+	config    Config
+
+	mu      sync.RWMutex
+	ring    *Ring
+	members []string
+
+	now func() time.Time
+}
+
+// NewMembership creates a Membership.
+func NewMembership(clientSet clientset.Interface, config Config) *Membership {
+	return &Membership{
+		clientSet: clientSet,
+		config:    config,
+		now:       time.Now,
+	}
+}
+
+// Owns reports whether the given namespace is owned by the replica. No namespace is owned until the members have been
+// listed, so namespaces are not reconciled twice while the replica joins the group.
+func (m *Membership) Owns(namespace string) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if m.ring == nil {
+		return false
+	}
+
+	return m.ring.Owner(namespace) == m.config.Identity
+}
+
+// Run renews the Lease of the replica and refreshes the members of the group until ctx is done. The Lease is deleted
+// when stopping, so the other members take over without waiting for it to expire.
+func (m *Membership) Run(ctx context.Context) {
+	ticker := time.NewTicker(m.config.RenewInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := m.sync(ctx); err != nil {
+			log.Error().Err(err).Str("group", m.config.Group).Msg("Unable to synchronize shard membership")
+		}
+
+		select {
+		case <-ctx.Done():
+			m.leave()
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (m *Membership) sync(ctx context.Context) error {
+	if err := m.renew(ctx); err != nil {
+		return fmt.Errorf("renew lease: %w", err)
+	}
+
+	leases, err := m.clientSet.CoordinationV1().Leases(m.config.Namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: labelGroup + "=" + m.config.Group,
+	})
+	if err != nil {
+		return fmt.Errorf("list leases: %w", err)
+	}
+
+	var members []string
+	for _, lease := range leases.Items {
+		if lease.Spec.HolderIdentity == nil || !m.alive(lease) {
+			continue
+		}
+
+		members = append(members, *lease.Spec.HolderIdentity)
+	}
+	sort.Strings(members)
+
+	m.setMembers(members)
+
+	return nil
+}
+
+func (m *Membership) setMembers(members []string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.ring != nil && strings.Join(members, ",") == strings.Join(m.members, ",") {
+		return
+	}
+
+	log.Info().Str("group", m.config.Group).Strs("members", members).Msg("Shard members changed")
+
+	m.members = members
+	m.ring = NewRing(members)
+}
+
+func (m *Membership) alive(lease coordinationv1.Lease) bool {
+	if lease.Spec.RenewTime == nil || lease.Spec.LeaseDurationSeconds == nil {
+		return false
+	}
+
+	expiry := lease.Spec.RenewTime.Add(time.Duration(*lease.Spec.LeaseDurationSeconds) * time.Second)
+
+	return m.now().Before(expiry)
+}
+
+func (m *Membership) renew(ctx context.Context) error {
+	leases := m.clientSet.CoordinationV1().Leases(m.config.Namespace)
+
+	identity := m.config.Identity
+	duration := int32(m.config.LeaseDuration.Seconds())
+	renewTime := metav1.NewMicroTime(m.now())
+	spec := coordinationv1.LeaseSpec{
+		HolderIdentity:       &identity,
+		LeaseDurationSeconds: &duration,
+		RenewTime:            &renewTime,
+	}
+
+	lease, err := leases.Get(ctx, m.leaseName(), metav1.GetOptions{})
+	if kerror.IsNotFound(err) {
+		_, err = leases.Create(ctx, &coordinationv1.Lease{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      m.leaseName(),
+				Namespace: m.config.Namespace,
+				Labels:    map[string]string{labelGroup: m.config.Group},
+			},
+			Spec: spec,
+		}, metav1.CreateOptions{})
+
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	lease.Spec = spec
+	_, err = leases.Update(ctx, lease, metav1.UpdateOptions{})
+
+	return err
+}
+
+func (m *Membership) leave() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	err := m.clientSet.CoordinationV1().Leases(m.config.Namespace).Delete(ctx, m.leaseName(), metav1.DeleteOptions{})
+	if err != nil && !kerror.IsNotFound(err) {
+		log.Error().Err(err).Str("group", m.config.Group).Msg("Unable to leave shard group")
+	}
+}
+
+func (m *Membership) leaseName() string {
+	return m.config.Group + "-" + m.config.Identity
+}
@@ -0,0 +1,82 @@
+/*
+Copyright (C) 2022 Traefik Labs
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package shard
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubemock "k8s.io/client-go/kubernetes/fake"
+)
+
+func TestMembership_Owns(t *testing.T) {
+	clientSet := kubemock.NewSimpleClientset()
+
+	newMembership := func(identity string) *Membership {
+		return NewMembership(clientSet, Config{
+			Group:         "hub-agent-controller",
+			Namespace:     "hub",
+			Identity:      identity,
+			LeaseDuration: 30 * time.Second,
+			RenewInterval: 10 * time.Second,
+		})
+	}
+
+	member0 := newMembership("agent-0")
+	member1 := newMembership("agent-1")
+
+	// Nothing is owned until the members are known.
+	assert.False(t, member0.Owns("default"))
+
+	ctx := context.Background()
+	require.NoError(t, member0.sync(ctx))
+	require.NoError(t, member1.sync(ctx))
+	require.NoError(t, member0.sync(ctx))
+
+	var owned0, owned1 int
+	for i := 0; i < 100; i++ {
+		ns := "namespace-" + strconv.Itoa(i)
+
+		// Each namespace is owned by exactly one member.
+		assert.NotEqual(t, member0.Owns(ns), member1.Owns(ns), ns)
+		if member0.Owns(ns) {
+			owned0++
+		} else {
+			owned1++
+		}
+	}
+	assert.Greater(t, owned0, 0)
+	assert.Greater(t, owned1, 0)
+
+	// A member which stopped renewing its Lease leaves the group once it expired.
+	member0.now = func() time.Time { return time.Now().Add(time.Minute) }
+	require.NoError(t, member0.sync(ctx))
+	for i := 0; i < 100; i++ {
+		assert.True(t, member0.Owns("namespace-"+strconv.Itoa(i)))
+	}
+
+	// A member leaving deletes its Lease.
+	member1.leave()
+	_, err := clientSet.CoordinationV1().Leases("hub").Get(ctx, "hub-agent-controller-agent-1", metav1.GetOptions{})
+	assert.Error(t, err)
+}
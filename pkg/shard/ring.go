@@ -0,0 +1,81 @@
+/*
+Copyright (C) 2022 Traefik Labs
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package shard
+
+import (
+	"hash/fnv"
+	"sort"
+	"strconv"
+)
+
+// virtualNodes is the number of points each member has on the ring, to spread keys evenly between members.
+const virtualNodes = 100
+
+// Ring distributes keys among members using consistent hashing: when a member joins or leaves the ring, only the keys
+// it gains or owned are moved.
+type Ring struct {
+	points []uint32
+	owners map[uint32]string
+}
+
+// NewRing creates a Ring distributing keys among the given members.
+func NewRing(members []string) *Ring {
+	r := &Ring{owners: make(map[uint32]string, len(members)*virtualNodes)}
+
+	// Members are sorted so collisions between points are resolved the same way on all replicas.
+	sorted := append([]string(nil), members...)
+	sort.Strings(sorted)
+
+	for _, member := range sorted {
+		for i := 0; i < virtualNodes; i++ {
+			point := hash(member + "#" + strconv.Itoa(i))
+			if _, exists := r.owners[point]; exists {
+				continue
+			}
+
+			r.owners[point] = member
+			r.points = append(r.points, point)
+		}
+	}
+
+	sort.Slice(r.points, func(i, j int) bool { return r.points[i] < r.points[j] })
+
+	return r
+}
+
+// Owner returns the member owning the given key, empty if the ring has no members.
+func (r *Ring) Owner(key string) string {
+	if len(r.points) == 0 {
+		return ""
+	}
+
+	h := hash(key)
+	i := sort.Search(len(r.points), func(i int) bool { return r.points[i] >= h })
+	if i == len(r.points) {
+		i = 0
+	}
+
+	return r.owners[r.points[i]]
+}
+
+func hash(s string) uint32 {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(s))
+
+	return h.Sum32()
+}
@@ -0,0 +1,68 @@
+/*
+Copyright (C) 2022 Traefik Labs
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package shard
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRing_Owner(t *testing.T) {
+	assert.Empty(t, NewRing(nil).Owner("default"))
+	assert.Equal(t, "agent-0", NewRing([]string{"agent-0"}).Owner("default"))
+
+	ring := NewRing([]string{"agent-0", "agent-1", "agent-2"})
+
+	owned := make(map[string]int)
+	for i := 0; i < 3000; i++ {
+		owned[ring.Owner("namespace-"+strconv.Itoa(i))]++
+	}
+
+	// Namespaces are spread among all members.
+	assert.Len(t, owned, 3)
+	for member, count := range owned {
+		assert.Greater(t, count, 500, member)
+	}
+
+	// Members are ordered the same way whatever the order they are given in.
+	assert.Equal(t, ring.Owner("default"), NewRing([]string{"agent-2", "agent-0", "agent-1"}).Owner("default"))
+}
+
+func TestRing_Owner_memberJoins(t *testing.T) {
+	before := NewRing([]string{"agent-0", "agent-1", "agent-2"})
+	after := NewRing([]string{"agent-0", "agent-1", "agent-2", "agent-3"})
+
+	var moved int
+	for i := 0; i < 3000; i++ {
+		ns := "namespace-" + strconv.Itoa(i)
+
+		owner := after.Owner(ns)
+		if owner == before.Owner(ns) {
+			continue
+		}
+
+		// Only the namespaces taken over by the new member move.
+		assert.Equal(t, "agent-3", owner)
+		moved++
+	}
+
+	assert.Greater(t, moved, 0)
+	assert.Less(t, moved, 1500)
+}
@@ -0,0 +1,91 @@
+/*
+Copyright (C) 2022 Traefik Labs
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package topology
+
+import (
+	"reflect"
+	"time"
+
+	"github.com/traefik/hub-agent-kubernetes/pkg/topology/state"
+)
+
+// debouncer decides when a cluster state must be written, so that bursts of changes are coalesced into a single write.
+// A changed state is written once it has been stable for the debounce window, or at the latest once it has been
+// pending for the max staleness duration.
+type debouncer struct {
+	window       time.Duration
+	maxStaleness time.Duration
+
+	lastSeen     *state.Cluster
+	lastWritten  *state.Cluster
+	lastChangeAt time.Time
+	pendingSince time.Time
+}
+
+func newDebouncer(window, maxStaleness time.Duration) *debouncer {
+	return &debouncer{
+		window:       window,
+		maxStaleness: maxStaleness,
+	}
+}
+
+// shouldWrite tells whether the given state, fetched at the given time, must be written.
+func (d *debouncer) shouldWrite(s *state.Cluster, now time.Time) bool {
+	if d.window <= 0 {
+		d.lastSeen = s
+		if d.pendingSince.IsZero() {
+			d.pendingSince = now
+		}
+		return true
+	}
+
+	if d.lastWritten != nil && reflect.DeepEqual(s, d.lastWritten) {
+		d.lastSeen = s
+		d.pendingSince = time.Time{}
+		return false
+	}
+
+	if d.lastSeen == nil || !reflect.DeepEqual(s, d.lastSeen) {
+		d.lastChangeAt = now
+		if d.pendingSince.IsZero() {
+			d.pendingSince = now
+		}
+	}
+	d.lastSeen = s
+
+	if now.Sub(d.lastChangeAt) >= d.window {
+		return true
+	}
+
+	return d.maxStaleness > 0 && now.Sub(d.pendingSince) >= d.maxStaleness
+}
+
+// pending returns the last seen state if it hasn't been written yet, nil otherwise.
+func (d *debouncer) pending() *state.Cluster {
+	if d.pendingSince.IsZero() {
+		return nil
+	}
+
+	return d.lastSeen
+}
+
+// written records that the given state has been successfully written.
+func (d *debouncer) written(s *state.Cluster) {
+	d.lastWritten = s
+	d.pendingSince = time.Time{}
+}
@@ -0,0 +1,144 @@
+/*
+Copyright (C) 2022 Traefik Labs
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package topology
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/traefik/hub-agent-kubernetes/pkg/topology/state"
+)
+
+func TestDebouncer_shouldWrite(t *testing.T) {
+	start := time.Date(2022, time.June, 1, 10, 0, 0, 0, time.UTC)
+
+	stateA := &state.Cluster{ID: "cluster", Namespaces: []string{"a"}}
+	stateB := &state.Cluster{ID: "cluster", Namespaces: []string{"b"}}
+	stateC := &state.Cluster{ID: "cluster", Namespaces: []string{"c"}}
+
+	type tick struct {
+		at    time.Duration
+		state *state.Cluster
+		want  bool
+	}
+
+	tests := []struct {
+		desc         string
+		window       time.Duration
+		maxStaleness time.Duration
+		ticks        []tick
+	}{
+		{
+			desc: "no debounce",
+			ticks: []tick{
+				{at: 0, state: stateA, want: true},
+				{at: 5 * time.Second, state: stateA, want: true},
+			},
+		},
+		{
+			desc:   "stable state is written after the window",
+			window: 10 * time.Second,
+			ticks: []tick{
+				{at: 0, state: stateA, want: false},
+				{at: 5 * time.Second, state: stateA, want: false},
+				{at: 10 * time.Second, state: stateA, want: true},
+				{at: 15 * time.Second, state: stateA, want: false},
+			},
+		},
+		{
+			desc:   "changes postpone the write",
+			window: 10 * time.Second,
+			ticks: []tick{
+				{at: 0, state: stateA, want: false},
+				{at: 5 * time.Second, state: stateB, want: false},
+				{at: 10 * time.Second, state: stateC, want: false},
+				{at: 15 * time.Second, state: stateC, want: false},
+				{at: 20 * time.Second, state: stateC, want: true},
+			},
+		},
+		{
+			desc:         "max staleness forces the write",
+			window:       10 * time.Second,
+			maxStaleness: 12 * time.Second,
+			ticks: []tick{
+				{at: 0, state: stateA, want: false},
+				{at: 5 * time.Second, state: stateB, want: false},
+				{at: 10 * time.Second, state: stateC, want: false},
+				{at: 15 * time.Second, state: stateA, want: true},
+			},
+		},
+		{
+			desc:   "reverting to the written state cancels the pending write",
+			window: 10 * time.Second,
+			ticks: []tick{
+				{at: 0, state: stateA, want: false},
+				{at: 10 * time.Second, state: stateA, want: true},
+				{at: 15 * time.Second, state: stateB, want: false},
+				{at: 20 * time.Second, state: stateA, want: false},
+				{at: 40 * time.Second, state: stateA, want: false},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			d := newDebouncer(test.window, test.maxStaleness)
+
+			for _, tick := range test.ticks {
+				got := d.shouldWrite(tick.state, start.Add(tick.at))
+				assert.Equal(t, tick.want, got, "tick at %s", tick.at)
+
+				if got {
+					d.written(tick.state)
+				}
+			}
+		})
+	}
+}
+
+func TestDebouncer_pending(t *testing.T) {
+	start := time.Date(2022, time.June, 1, 10, 0, 0, 0, time.UTC)
+
+	stateA := &state.Cluster{ID: "cluster", Namespaces: []string{"a"}}
+	stateB := &state.Cluster{ID: "cluster", Namespaces: []string{"b"}}
+
+	d := newDebouncer(10*time.Second, 0)
+	assert.Nil(t, d.pending())
+
+	assert.False(t, d.shouldWrite(stateA, start))
+	assert.Equal(t, stateA, d.pending())
+
+	assert.True(t, d.shouldWrite(stateA, start.Add(10*time.Second)))
+	d.written(stateA)
+	assert.Nil(t, d.pending())
+
+	assert.False(t, d.shouldWrite(stateB, start.Add(15*time.Second)))
+	assert.Equal(t, stateB, d.pending())
+
+	// A state which failed to be written remains pending when writes are not debounced.
+	d = newDebouncer(0, 0)
+	assert.True(t, d.shouldWrite(stateA, start))
+	assert.Equal(t, stateA, d.pending())
+
+	d.written(stateA)
+	assert.Nil(t, d.pending())
+}
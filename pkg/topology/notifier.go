@@ -0,0 +1,74 @@
+/*
+Copyright (C) 2022 Traefik Labs
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package topology
+
+import (
+	"context"
+
+	"github.com/rs/zerolog/log"
+	"github.com/traefik/hub-agent-kubernetes/pkg/topology/state"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+// ChangeNotifier reports significant topology changes as Kubernetes events and structured logs.
+// It is meant to be registered as a Watcher listener.
+type ChangeNotifier struct {
+	recorder record.EventRecorder
+
+	prev *state.Cluster
+}
+
+// NewChangeNotifier returns a new ChangeNotifier which records events using the given recorder.
+func NewChangeNotifier(recorder record.EventRecorder) *ChangeNotifier {
+	return &ChangeNotifier{recorder: recorder}
+}
+
+// TopologyStateChanged is called every time the topology state changes.
+func (n *ChangeNotifier) TopologyStateChanged(_ context.Context, cluster *state.Cluster) {
+	if cluster == nil {
+		return
+	}
+
+	for _, change := range state.DetectChanges(n.prev, cluster) {
+		log.Info().
+			Str("change", change.Kind).
+			Str("kind", change.Object.Kind).
+			Str("name", change.Object.Name).
+			Str("namespace", change.Object.Namespace).
+			Msg(change.Message)
+
+		ref := &corev1.ObjectReference{
+			Kind:      change.Object.Kind,
+			Name:      change.Object.Name,
+			Namespace: change.Object.Namespace,
+		}
+		n.recorder.Event(ref, eventType(change.Kind), change.Kind, change.Message)
+	}
+
+	n.prev = cluster
+}
+
+func eventType(kind string) string {
+	switch kind {
+	case state.ChangeServiceEndpointsLost, state.ChangeIngressControllerRestarted:
+		return corev1.EventTypeWarning
+	default:
+		return corev1.EventTypeNormal
+	}
+}
@@ -0,0 +1,172 @@
+/*
+Copyright (C) 2022 Traefik Labs
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package s3store
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	"github.com/rs/zerolog/log"
+	"github.com/traefik/hub-agent-kubernetes/pkg/topology/state"
+)
+
+// Config represents the S3 store config.
+type Config struct {
+	// Endpoint is the URL of the S3-compatible service, e.g. https://s3.eu-west-1.amazonaws.com.
+	Endpoint string
+	Region   string
+	Bucket   string
+	// Prefix is prepended to every object key.
+	Prefix string
+
+	AccessKeyID     string
+	SecretAccessKey string
+
+	// FullSnapshotInterval is the interval at which a full snapshot is written.
+	// In between, only the differences with the previous state are written.
+	FullSnapshotInterval time.Duration
+}
+
+// Store writes versioned topology snapshots to an S3-compatible bucket.
+// A full snapshot is written periodically and each change in between is written as a JSON merge patch
+// against the previous state, so that the state at any point in time can be rebuilt.
+type Store struct {
+	cfg      Config
+	endpoint *url.URL
+	client   *http.Client
+	now      func() time.Time
+
+	mu             sync.Mutex
+	lastState      []byte
+	lastSnapshotAt time.Time
+}
+
+// New instantiates a new Store.
+func New(cfg Config) (*Store, error) {
+	if cfg.Bucket == "" {
+		return nil, errors.New("bucket is required")
+	}
+
+	endpoint, err := url.Parse(cfg.Endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("parse endpoint: %w", err)
+	}
+	if endpoint.Host == "" {
+		return nil, fmt.Errorf("invalid endpoint %q", cfg.Endpoint)
+	}
+
+	if cfg.Region == "" {
+		cfg.Region = "us-east-1"
+	}
+
+	return &Store{
+		cfg:      cfg,
+		endpoint: endpoint,
+		client:   &http.Client{Timeout: time.Minute},
+		now:      time.Now,
+	}, nil
+}
+
+// Write writes the given cluster state, if it changed since the last write.
+func (s *Store) Write(ctx context.Context, st *state.Cluster) error {
+	if st == nil {
+		return nil
+	}
+
+	data, err := json.Marshal(st)
+	if err != nil {
+		return fmt.Errorf("marshal cluster state: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if bytes.Equal(data, s.lastState) {
+		return nil
+	}
+
+	now := s.now().UTC()
+	version := now.Format("20060102T150405.000000000Z")
+
+	if s.lastState == nil || now.Sub(s.lastSnapshotAt) >= s.cfg.FullSnapshotInterval {
+		if err = s.putObject(ctx, s.key(st.ID, "snapshots", version), data); err != nil {
+			return fmt.Errorf("write snapshot: %w", err)
+		}
+
+		s.lastState = data
+		s.lastSnapshotAt = now
+
+		log.Debug().Str("version", version).Msg("Topology snapshot written to S3")
+		return nil
+	}
+
+	patch, err := jsonpatch.CreateMergePatch(s.lastState, data)
+	if err != nil {
+		return fmt.Errorf("create merge patch: %w", err)
+	}
+
+	if err = s.putObject(ctx, s.key(st.ID, "diffs", version), patch); err != nil {
+		return fmt.Errorf("write diff: %w", err)
+	}
+
+	s.lastState = data
+
+	log.Debug().Str("version", version).Msg("Topology diff written to S3")
+	return nil
+}
+
+func (s *Store) key(clusterID, kind, version string) string {
+	return strings.TrimPrefix(path.Join(s.cfg.Prefix, clusterID, kind, version+".json"), "/")
+}
+
+func (s *Store) putObject(ctx context.Context, key string, data []byte) error {
+	objectURL := *s.endpoint
+	objectURL.Path = path.Join("/", s.endpoint.Path, s.cfg.Bucket, key)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, objectURL.String(), bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("build request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	signRequest(req, hashHex(data), s.cfg.Region, s.cfg.AccessKeyID, s.cfg.SecretAccessKey, s.now())
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("put object %q: %w", key, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("put object %q: unexpected status code %d: %s", key, resp.StatusCode, string(body))
+	}
+
+	return nil
+}
@@ -0,0 +1,103 @@
+/*
+Copyright (C) 2022 Traefik Labs
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package s3store
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/traefik/hub-agent-kubernetes/pkg/topology/state"
+)
+
+func TestStore_Write(t *testing.T) {
+	var (
+		objectsMu sync.Mutex
+		objects   = make(map[string]string)
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodPut || !strings.HasPrefix(req.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=key/") {
+			rw.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		body, err := io.ReadAll(req.Body)
+		require.NoError(t, err)
+
+		objectsMu.Lock()
+		objects[req.URL.Path] = string(body)
+		objectsMu.Unlock()
+	}))
+	t.Cleanup(srv.Close)
+
+	s, err := New(Config{
+		Endpoint:             srv.URL,
+		Bucket:               "bucket",
+		Prefix:               "topology",
+		AccessKeyID:          "key",
+		SecretAccessKey:      "secret",
+		FullSnapshotInterval: time.Hour,
+	})
+	require.NoError(t, err)
+
+	now := time.Date(2022, time.June, 1, 10, 0, 0, 0, time.UTC)
+	s.now = func() time.Time { return now }
+
+	cluster := &state.Cluster{ID: "cluster-id", Namespaces: []string{"default"}}
+	require.NoError(t, s.Write(context.Background(), cluster))
+
+	// Writing the same state again must be a no-op.
+	now = now.Add(time.Minute)
+	require.NoError(t, s.Write(context.Background(), cluster))
+
+	cluster = &state.Cluster{ID: "cluster-id", Namespaces: []string{"default", "myns"}}
+	require.NoError(t, s.Write(context.Background(), cluster))
+
+	// Once the snapshot interval elapsed, a full snapshot is written again.
+	now = now.Add(time.Hour)
+	cluster = &state.Cluster{ID: "cluster-id", Namespaces: []string{"myns"}}
+	require.NoError(t, s.Write(context.Background(), cluster))
+
+	require.Len(t, objects, 3)
+	assert.Contains(t, objects["/bucket/topology/cluster-id/snapshots/20220601T100000.000000000Z.json"], `"Namespaces":["default"]`)
+	assert.JSONEq(t, `{"Namespaces":["default","myns"]}`, objects["/bucket/topology/cluster-id/diffs/20220601T100100.000000000Z.json"])
+	assert.Contains(t, objects["/bucket/topology/cluster-id/snapshots/20220601T110100.000000000Z.json"], `"Namespaces":["myns"]`)
+}
+
+func TestStore_Write_error(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.WriteHeader(http.StatusForbidden)
+	}))
+	t.Cleanup(srv.Close)
+
+	s, err := New(Config{Endpoint: srv.URL, Bucket: "bucket", FullSnapshotInterval: time.Hour})
+	require.NoError(t, err)
+
+	err = s.Write(context.Background(), &state.Cluster{ID: "cluster-id"})
+	require.Error(t, err)
+
+	// The state must be written again on the next call since the previous write failed.
+	assert.Nil(t, s.lastState)
+}
@@ -154,7 +154,7 @@ func TestFetcher_GetAccessControlPolicies(t *testing.T) {
 			hubClient := hubkubemock.NewSimpleClientset(test.objects...)
 			traefikClient := traefikkubemock.NewSimpleClientset()
 
-			f, err := watchAll(context.Background(), kubeClient, hubClient, traefikClient, "v1.20.1", clusterID)
+			f, err := watchAll(context.Background(), kubeClient, hubClient, traefikClient, "v1.20.1", clusterID, nil)
 			require.NoError(t, err)
 
 			got, err := f.getAccessControlPolicies(clusterID)
@@ -150,7 +150,7 @@ func TestFetcher_GetApps(t *testing.T) {
 			hubClient := hubkubemock.NewSimpleClientset()
 			traefikClient := traefikkubemock.NewSimpleClientset()
 
-			f, err := watchAll(context.Background(), kubeClient, hubClient, traefikClient, "v1.20.1", "cluster-id")
+			f, err := watchAll(context.Background(), kubeClient, hubClient, traefikClient, "v1.20.1", "cluster-id", nil)
 			require.NoError(t, err)
 
 			got, err := f.getApps()
@@ -0,0 +1,121 @@
+/*
+Copyright (C) 2022 Traefik Labs
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package state
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/rs/zerolog/log"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// GetCertificates returns the TLS certificates used by the Ingresses and IngressRoutes of the cluster, along with the
+// ones managed by the agent.
+func (f *Fetcher) GetCertificates() (map[string]*Certificate, error) {
+	ingresses, err := f.getIngresses(f.clusterID)
+	if err != nil {
+		return nil, fmt.Errorf("get ingresses: %w", err)
+	}
+
+	ingressRoutes, _, err := f.getIngressRoutes(f.clusterID)
+	if err != nil {
+		return nil, fmt.Errorf("get ingress routes: %w", err)
+	}
+
+	return f.getCertificates(ingresses, ingressRoutes)
+}
+
+func (f *Fetcher) getCertificates(ingresses map[string]*Ingress, ingressRoutes map[string]*IngressRoute) (map[string]*Certificate, error) {
+	secrets, err := f.tlsSecrets.Core().V1().Secrets().Lister().List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+
+	// Index the Ingresses and IngressRoutes using each Secret.
+	usedBy := make(map[string][]string)
+	for key, ing := range ingresses {
+		for _, tls := range ing.TLS {
+			if tls.SecretName == "" {
+				continue
+			}
+
+			secretKey := objectKey(tls.SecretName, ing.Namespace)
+			usedBy[secretKey] = append(usedBy[secretKey], key)
+		}
+	}
+	for key, ingRoute := range ingressRoutes {
+		if ingRoute.TLS == nil || ingRoute.TLS.SecretName == "" {
+			continue
+		}
+
+		secretKey := objectKey(ingRoute.TLS.SecretName, ingRoute.Namespace)
+		usedBy[secretKey] = append(usedBy[secretKey], key)
+	}
+
+	result := make(map[string]*Certificate)
+	for _, secret := range secrets {
+		if secret.Type != corev1.SecretTypeTLS {
+			continue
+		}
+
+		key := objectKey(secret.Name, secret.Namespace)
+
+		ingKeys, used := usedBy[key]
+		if !used && secret.Labels["app.kubernetes.io/managed-by"] != "traefik-hub" {
+			continue
+		}
+
+		cert, err := parseCertificate(secret.Data[corev1.TLSCertKey])
+		if err != nil {
+			log.Debug().Err(err).Str("secret", key).Msg("Unable to parse TLS certificate")
+			continue
+		}
+
+		domains := cert.DNSNames
+		if len(domains) == 0 && cert.Subject.CommonName != "" {
+			domains = []string{cert.Subject.CommonName}
+		}
+
+		sort.Strings(ingKeys)
+
+		result[key] = &Certificate{
+			Name:      secret.Name,
+			Namespace: secret.Namespace,
+			Domains:   domains,
+			NotAfter:  cert.NotAfter.UTC(),
+			Ingresses: ingKeys,
+		}
+	}
+
+	return result, nil
+}
+
+// parseCertificate parses the leaf certificate of the given PEM encoded certificate chain.
+func parseCertificate(data []byte) (*x509.Certificate, error) {
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, errors.New("no PEM encoded certificate found")
+	}
+
+	return x509.ParseCertificate(block.Bytes)
+}
@@ -0,0 +1,148 @@
+/*
+Copyright (C) 2022 Traefik Labs
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package state
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	hubkubemock "github.com/traefik/hub-agent-kubernetes/pkg/crd/generated/client/hub/clientset/versioned/fake"
+	traefikkubemock "github.com/traefik/hub-agent-kubernetes/pkg/crd/generated/client/traefik/clientset/versioned/fake"
+	corev1 "k8s.io/api/core/v1"
+	netv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubemock "k8s.io/client-go/kubernetes/fake"
+)
+
+func TestFetcher_getCertificates(t *testing.T) {
+	notAfter := time.Date(2022, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	objects := []runtime.Object{
+		tlsSecret(t, "used-by-ingress", "myns", nil, generateCertificate(t, notAfter, "whoami.example.com")),
+		tlsSecret(t, "used-by-ingress-route", "myns", nil, generateCertificate(t, notAfter, "whoami.example.org")),
+		tlsSecret(t, "hub-certificate", "hub-agent", map[string]string{"app.kubernetes.io/managed-by": "traefik-hub"}, generateCertificate(t, notAfter, "*.hub.example.com")),
+		tlsSecret(t, "unused", "myns", nil, generateCertificate(t, notAfter, "unused.example.com")),
+		tlsSecret(t, "invalid", "myns", nil, []byte("not a certificate")),
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "opaque", Namespace: "myns"},
+			Type:       corev1.SecretTypeOpaque,
+		},
+	}
+
+	kubeClient := kubemock.NewSimpleClientset(objects...)
+	hubClient := hubkubemock.NewSimpleClientset()
+	traefikClient := traefikkubemock.NewSimpleClientset()
+
+	f, err := watchAll(context.Background(), kubeClient, hubClient, traefikClient, "v1.20.1", "cluster-id", nil)
+	require.NoError(t, err)
+
+	ingresses := map[string]*Ingress{
+		"myIngress@myns.ingress.networking.k8s.io": {
+			ResourceMeta: ResourceMeta{Kind: "Ingress", Group: netv1.GroupName, Name: "myIngress", Namespace: "myns"},
+			TLS: []netv1.IngressTLS{
+				{SecretName: "used-by-ingress"},
+				{SecretName: "invalid"},
+				{SecretName: "opaque"},
+			},
+		},
+	}
+	ingressRoutes := map[string]*IngressRoute{
+		"myIngressRoute@myns.ingressroute.traefik.containo.us": {
+			ResourceMeta: ResourceMeta{Kind: "IngressRoute", Group: "traefik.containo.us", Name: "myIngressRoute", Namespace: "myns"},
+			TLS:          &IngressRouteTLS{SecretName: "used-by-ingress-route"},
+		},
+		"noTLS@myns.ingressroute.traefik.containo.us": {
+			ResourceMeta: ResourceMeta{Kind: "IngressRoute", Group: "traefik.containo.us", Name: "noTLS", Namespace: "myns"},
+		},
+	}
+
+	got, err := f.getCertificates(ingresses, ingressRoutes)
+	require.NoError(t, err)
+
+	want := map[string]*Certificate{
+		"used-by-ingress@myns": {
+			Name:      "used-by-ingress",
+			Namespace: "myns",
+			Domains:   []string{"whoami.example.com"},
+			NotAfter:  notAfter,
+			Ingresses: []string{"myIngress@myns.ingress.networking.k8s.io"},
+		},
+		"used-by-ingress-route@myns": {
+			Name:      "used-by-ingress-route",
+			Namespace: "myns",
+			Domains:   []string{"whoami.example.org"},
+			NotAfter:  notAfter,
+			Ingresses: []string{"myIngressRoute@myns.ingressroute.traefik.containo.us"},
+		},
+		"hub-certificate@hub-agent": {
+			Name:      "hub-certificate",
+			Namespace: "hub-agent",
+			Domains:   []string{"*.hub.example.com"},
+			NotAfter:  notAfter,
+		},
+	}
+
+	assert.Equal(t, want, got)
+}
+
+func tlsSecret(t *testing.T, name, namespace string, labels map[string]string, cert []byte) *corev1.Secret {
+	t.Helper()
+
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels:    labels,
+		},
+		Type: corev1.SecretTypeTLS,
+		Data: map[string][]byte{
+			corev1.TLSCertKey: cert,
+		},
+	}
+}
+
+func generateCertificate(t *testing.T, notAfter time.Time, domain string) []byte {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: domain},
+		DNSNames:     []string{domain},
+		NotBefore:    notAfter.Add(-90 * 24 * time.Hour),
+		NotAfter:     notAfter,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
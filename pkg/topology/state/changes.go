@@ -0,0 +1,168 @@
+/*
+Copyright (C) 2022 Traefik Labs
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package state
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Change kinds.
+const (
+	ChangeIngressAdded               = "IngressAdded"
+	ChangeIngressRemoved             = "IngressRemoved"
+	ChangeServiceEndpointsLost       = "ServiceEndpointsLost"
+	ChangeIngressControllerRestarted = "IngressControllerRestarted"
+)
+
+// Change describes a significant change between two cluster states.
+type Change struct {
+	Kind    string
+	Object  ResourceMeta
+	Message string
+}
+
+// DetectChanges returns the significant changes that happened between the previous and the current cluster states.
+func DetectChanges(prev, curr *Cluster) []Change {
+	if prev == nil || curr == nil {
+		return nil
+	}
+
+	var changes []Change
+
+	changes = append(changes, detectIngressChanges(ingressMetas(prev), ingressMetas(curr))...)
+	changes = append(changes, detectServiceChanges(prev.Services, curr.Services)...)
+	changes = append(changes, detectIngressControllerChanges(prev.IngressControllers, curr.IngressControllers)...)
+
+	sort.SliceStable(changes, func(i, j int) bool {
+		if changes[i].Kind != changes[j].Kind {
+			return changes[i].Kind < changes[j].Kind
+		}
+		return ingressKey(changes[i].Object) < ingressKey(changes[j].Object)
+	})
+
+	return changes
+}
+
+func ingressMetas(cluster *Cluster) map[string]ResourceMeta {
+	metas := make(map[string]ResourceMeta, len(cluster.Ingresses)+len(cluster.IngressRoutes))
+	for key, ing := range cluster.Ingresses {
+		metas[key] = ing.ResourceMeta
+	}
+	for key, ingRoute := range cluster.IngressRoutes {
+		metas[key] = ingRoute.ResourceMeta
+	}
+
+	return metas
+}
+
+func detectIngressChanges(prev, curr map[string]ResourceMeta) []Change {
+	var changes []Change
+	for key, meta := range curr {
+		if _, ok := prev[key]; ok {
+			continue
+		}
+
+		changes = append(changes, Change{
+			Kind:    ChangeIngressAdded,
+			Object:  meta,
+			Message: fmt.Sprintf("%s %s/%s added", meta.Kind, meta.Namespace, meta.Name),
+		})
+	}
+
+	for key, meta := range prev {
+		if _, ok := curr[key]; ok {
+			continue
+		}
+
+		changes = append(changes, Change{
+			Kind:    ChangeIngressRemoved,
+			Object:  meta,
+			Message: fmt.Sprintf("%s %s/%s removed", meta.Kind, meta.Namespace, meta.Name),
+		})
+	}
+
+	return changes
+}
+
+func detectServiceChanges(prev, curr map[string]*Service) []Change {
+	var changes []Change
+	for key, svc := range curr {
+		prevSvc, ok := prev[key]
+		if !ok || prevSvc.readyEndpoints == 0 || svc.readyEndpoints > 0 {
+			continue
+		}
+
+		changes = append(changes, Change{
+			Kind: ChangeServiceEndpointsLost,
+			Object: ResourceMeta{
+				Kind:      "Service",
+				Name:      svc.Name,
+				Namespace: svc.Namespace,
+			},
+			Message: fmt.Sprintf("Service %s/%s lost all its endpoints (previously %d ready)", svc.Namespace, svc.Name, prevSvc.readyEndpoints),
+		})
+	}
+
+	return changes
+}
+
+func detectIngressControllerChanges(prev, curr map[string]*IngressController) []Change {
+	var changes []Change
+	for key, ctrl := range curr {
+		prevCtrl, ok := prev[key]
+		if !ok || !hasRestarted(prevCtrl.podRestarts, ctrl.podRestarts) {
+			continue
+		}
+
+		changes = append(changes, Change{
+			Kind: ChangeIngressControllerRestarted,
+			Object: ResourceMeta{
+				Kind:      ctrl.Kind,
+				Group:     "apps",
+				Name:      ctrl.Name,
+				Namespace: ctrl.Namespace,
+			},
+			Message: fmt.Sprintf("Ingress controller %s %s/%s restarted", ctrl.Kind, ctrl.Namespace, ctrl.Name),
+		})
+	}
+
+	return changes
+}
+
+// hasRestarted reports whether one of the previously known pods has restarted or if all of them have been replaced.
+func hasRestarted(prev, curr map[string]int32) bool {
+	if len(prev) == 0 || len(curr) == 0 {
+		return false
+	}
+
+	var kept bool
+	for pod, restarts := range curr {
+		prevRestarts, ok := prev[pod]
+		if !ok {
+			continue
+		}
+
+		if restarts > prevRestarts {
+			return true
+		}
+		kept = true
+	}
+
+	return !kept
+}
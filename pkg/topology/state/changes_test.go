@@ -0,0 +1,163 @@
+/*
+Copyright (C) 2022 Traefik Labs
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package state
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDetectChanges(t *testing.T) {
+	ingress := &Ingress{
+		ResourceMeta: ResourceMeta{
+			Kind:      "Ingress",
+			Group:     "networking.k8s.io",
+			Name:      "myIngress",
+			Namespace: "myns",
+		},
+	}
+	ingressRoute := &IngressRoute{
+		ResourceMeta: ResourceMeta{
+			Kind:      "IngressRoute",
+			Group:     "traefik.containo.us",
+			Name:      "myIngressRoute",
+			Namespace: "myns",
+		},
+	}
+
+	tests := []struct {
+		desc string
+		prev *Cluster
+		curr *Cluster
+		want []Change
+	}{
+		{
+			desc: "no previous state",
+			curr: &Cluster{Ingresses: map[string]*Ingress{"myIngress@myns.ingress.networking.k8s.io": ingress}},
+		},
+		{
+			desc: "no changes",
+			prev: &Cluster{Ingresses: map[string]*Ingress{"myIngress@myns.ingress.networking.k8s.io": ingress}},
+			curr: &Cluster{Ingresses: map[string]*Ingress{"myIngress@myns.ingress.networking.k8s.io": ingress}},
+		},
+		{
+			desc: "ingresses added and removed",
+			prev: &Cluster{
+				Ingresses: map[string]*Ingress{"myIngress@myns.ingress.networking.k8s.io": ingress},
+			},
+			curr: &Cluster{
+				IngressRoutes: map[string]*IngressRoute{"myIngressRoute@myns.ingressroute.traefik.containo.us": ingressRoute},
+			},
+			want: []Change{
+				{
+					Kind:    ChangeIngressAdded,
+					Object:  ingressRoute.ResourceMeta,
+					Message: "IngressRoute myns/myIngressRoute added",
+				},
+				{
+					Kind:    ChangeIngressRemoved,
+					Object:  ingress.ResourceMeta,
+					Message: "Ingress myns/myIngress removed",
+				},
+			},
+		},
+		{
+			desc: "service lost all endpoints",
+			prev: &Cluster{
+				Services: map[string]*Service{
+					"svc@myns":   {Name: "svc", Namespace: "myns", readyEndpoints: 2},
+					"other@myns": {Name: "other", Namespace: "myns", readyEndpoints: 2},
+					"empty@myns": {Name: "empty", Namespace: "myns"},
+				},
+			},
+			curr: &Cluster{
+				Services: map[string]*Service{
+					"svc@myns":   {Name: "svc", Namespace: "myns"},
+					"other@myns": {Name: "other", Namespace: "myns", readyEndpoints: 1},
+					"empty@myns": {Name: "empty", Namespace: "myns"},
+				},
+			},
+			want: []Change{
+				{
+					Kind:    ChangeServiceEndpointsLost,
+					Object:  ResourceMeta{Kind: "Service", Name: "svc", Namespace: "myns"},
+					Message: "Service myns/svc lost all its endpoints (previously 2 ready)",
+				},
+			},
+		},
+		{
+			desc: "ingress controller restarted",
+			prev: &Cluster{
+				IngressControllers: map[string]*IngressController{
+					"restarted@myns": {
+						App:         App{Name: "restarted", Namespace: "myns", Kind: "Deployment"},
+						podRestarts: map[string]int32{"restarted-1": 0},
+					},
+					"replaced@myns": {
+						App:         App{Name: "replaced", Namespace: "myns", Kind: "DaemonSet"},
+						podRestarts: map[string]int32{"replaced-1": 3},
+					},
+					"scaled@myns": {
+						App:         App{Name: "scaled", Namespace: "myns", Kind: "Deployment"},
+						podRestarts: map[string]int32{"scaled-1": 1},
+					},
+				},
+			},
+			curr: &Cluster{
+				IngressControllers: map[string]*IngressController{
+					"restarted@myns": {
+						App:         App{Name: "restarted", Namespace: "myns", Kind: "Deployment"},
+						podRestarts: map[string]int32{"restarted-1": 1},
+					},
+					"replaced@myns": {
+						App:         App{Name: "replaced", Namespace: "myns", Kind: "DaemonSet"},
+						podRestarts: map[string]int32{"replaced-2": 0},
+					},
+					"scaled@myns": {
+						App:         App{Name: "scaled", Namespace: "myns", Kind: "Deployment"},
+						podRestarts: map[string]int32{"scaled-1": 1, "scaled-2": 0},
+					},
+				},
+			},
+			want: []Change{
+				{
+					Kind:    ChangeIngressControllerRestarted,
+					Object:  ResourceMeta{Kind: "DaemonSet", Group: "apps", Name: "replaced", Namespace: "myns"},
+					Message: "Ingress controller DaemonSet myns/replaced restarted",
+				},
+				{
+					Kind:    ChangeIngressControllerRestarted,
+					Object:  ResourceMeta{Kind: "Deployment", Group: "apps", Name: "restarted", Namespace: "myns"},
+					Message: "Ingress controller Deployment myns/restarted restarted",
+				},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			got := DetectChanges(test.prev, test.curr)
+
+			assert.Equal(t, test.want, got)
+		})
+	}
+}
@@ -18,6 +18,8 @@ along with this program. If not, see <https://www.gnu.org/licenses/>.
 package state
 
 import (
+	"time"
+
 	traefikv1alpha1 "github.com/traefik/hub-agent-kubernetes/pkg/crd/api/traefik/v1alpha1"
 	corev1 "k8s.io/api/core/v1"
 	netv1 "k8s.io/api/networking/v1"
@@ -35,8 +37,10 @@ type Cluster struct {
 	IngressControllers    map[string]*IngressController
 	AccessControlPolicies map[string]*AccessControlPolicy
 	TLSOptions            map[string]*TLSOptions
+	ReachabilityProbes    map[string]*ReachabilityProbe
+	Certificates          map[string]*Certificate
 
-	TraefikServiceNames map[string]string `dir:"-"`
+	TraefikServiceNames map[string]string `dir:"-" json:"-"`
 }
 
 // Overview represents an overview of the cluster resources.
@@ -44,6 +48,10 @@ type Overview struct {
 	IngressCount           int      `json:"ingressCount"`
 	ServiceCount           int      `json:"serviceCount"`
 	IngressControllerTypes []string `json:"ingressControllerTypes"`
+	// Capabilities lists the optional features of the cluster used by the agent.
+	Capabilities []string `json:"capabilities,omitempty"`
+	// Truncated lists the sections dropped from the topology to fit in its size budget.
+	Truncated []string `json:"truncated,omitempty"`
 }
 
 // ResourceMeta represents the metadata which identify a Kubernetes resource.
@@ -77,6 +85,43 @@ type IngressController struct {
 	MetricsURLs     []string `json:"metricsURLs,omitempty"`
 	PublicEndpoints []string `json:"publicEndpoints,omitempty"`
 	Endpoints       []string `json:"endpoints,omitempty"`
+
+	Runtime *TraefikRuntime `json:"runtime,omitempty"`
+
+	podRestarts map[string]int32
+}
+
+// TraefikRuntime holds the effective configuration of a Traefik ingress controller, as reported by its API.
+type TraefikRuntime struct {
+	Routers     map[string]TraefikRouter     `json:"routers,omitempty"`
+	Services    map[string]TraefikService    `json:"services,omitempty"`
+	Middlewares map[string]TraefikMiddleware `json:"middlewares,omitempty"`
+}
+
+// TraefikRouter describes a router running in Traefik.
+type TraefikRouter struct {
+	EntryPoints []string `json:"entryPoints,omitempty"`
+	Middlewares []string `json:"middlewares,omitempty"`
+	Service     string   `json:"service,omitempty"`
+	Rule        string   `json:"rule,omitempty"`
+	Priority    int      `json:"priority,omitempty"`
+	Status      string   `json:"status,omitempty"`
+	Errors      []string `json:"error,omitempty"`
+}
+
+// TraefikService describes a service running in Traefik.
+type TraefikService struct {
+	Status       string            `json:"status,omitempty"`
+	Errors       []string          `json:"error,omitempty"`
+	UsedBy       []string          `json:"usedBy,omitempty"`
+	ServerStatus map[string]string `json:"serverStatus,omitempty"`
+}
+
+// TraefikMiddleware describes a middleware running in Traefik.
+type TraefikMiddleware struct {
+	Status string   `json:"status,omitempty"`
+	Errors []string `json:"error,omitempty"`
+	UsedBy []string `json:"usedBy,omitempty"`
 }
 
 // Service describes a Service.
@@ -91,7 +136,8 @@ type Service struct {
 	ExternalIPs   []string           `json:"externalIPs,omitempty"`
 	ExternalPorts []int              `json:"externalPorts,omitempty"`
 
-	status corev1.ServiceStatus
+	status         corev1.ServiceStatus
+	readyEndpoints int
 }
 
 // IngressMeta represents the common Ingress metadata properties.
@@ -193,3 +239,34 @@ type TLSOptions struct {
 	SniStrict                bool                       `json:"sniStrict"`
 	PreferServerCipherSuites bool                       `json:"preferServerCipherSuites"`
 }
+
+// ReachabilityProbe holds the results of probing the public endpoints of an Ingress or an EdgeIngress.
+type ReachabilityProbe struct {
+	ResourceMeta
+
+	Results []ProbeResult `json:"results"`
+}
+
+// Certificate describes a TLS certificate stored in a Secret used by an Ingress or an IngressRoute, or managed by
+// the agent, such as the wildcard and the edge ingress certificates.
+type Certificate struct {
+	Name      string    `json:"name"`
+	Namespace string    `json:"namespace"`
+	Domains   []string  `json:"domains,omitempty"`
+	NotAfter  time.Time `json:"notAfter"`
+	// Ingresses lists the keys of the Ingresses and IngressRoutes using the certificate.
+	Ingresses []string `json:"ingresses,omitempty"`
+}
+
+// ProbeResult is the result of probing a public endpoint.
+// A result without ProbedAt date means the endpoint has not been probed yet. A result is only updated when its
+// outcome changes, hence ProbedAt and LatencyMs are those of the first probe which got this outcome.
+type ProbeResult struct {
+	URL          string     `json:"url"`
+	StatusCode   int        `json:"statusCode,omitempty"`
+	LatencyMs    int64      `json:"latencyMs,omitempty"`
+	TLSValid     *bool      `json:"tlsValid,omitempty"`
+	TLSExpiresAt *time.Time `json:"tlsExpiresAt,omitempty"`
+	Error        string     `json:"error,omitempty"`
+	ProbedAt     time.Time  `json:"probedAt,omitempty"`
+}
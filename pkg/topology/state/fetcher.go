@@ -24,8 +24,8 @@ import (
 	"strings"
 	"time"
 
-	"github.com/hashicorp/go-version"
 	"github.com/rs/zerolog/log"
+	hubv1alpha1 "github.com/traefik/hub-agent-kubernetes/pkg/crd/api/hub/v1alpha1"
 	traefikv1alpha1 "github.com/traefik/hub-agent-kubernetes/pkg/crd/api/traefik/v1alpha1"
 	hubclientset "github.com/traefik/hub-agent-kubernetes/pkg/crd/generated/client/hub/clientset/versioned"
 	hubinformer "github.com/traefik/hub-agent-kubernetes/pkg/crd/generated/client/hub/informers/externalversions"
@@ -33,30 +33,69 @@ import (
 	traefikinformer "github.com/traefik/hub-agent-kubernetes/pkg/crd/generated/client/traefik/informers/externalversions"
 	"github.com/traefik/hub-agent-kubernetes/pkg/kube"
 	"github.com/traefik/hub-agent-kubernetes/pkg/kubevers"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	netv1 "k8s.io/api/networking/v1"
 	kerror "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/discovery"
 	"k8s.io/client-go/informers"
 	clientset "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
 )
 
+// FetcherConfig holds the Fetcher configuration.
+type FetcherConfig struct {
+	// TraefikAPIPort is the port on which Traefik ingress controllers expose their API.
+	// When set, the runtime configuration of Traefik ingress controllers is captured in the topology.
+	TraefikAPIPort string
+	// TraefikAPIInterval is the interval at which the runtime configuration of Traefik ingress controllers is captured.
+	TraefikAPIInterval time.Duration
+
+	// ProbeNamespaces lists the namespaces in which the public endpoints of Ingresses and EdgeIngresses are probed.
+	// Probing is disabled when empty.
+	ProbeNamespaces []string
+	// ProbeInterval is the interval at which public endpoints are probed.
+	ProbeInterval time.Duration
+
+	// Namespaces restricts the topology to the given namespaces, which are watched one at a time so only
+	// namespace-scoped permissions are required. All namespaces are watched when empty.
+	Namespaces []string
+}
+
 // Fetcher fetches Kubernetes resources and converts them into a filtered and simplified state.
 type Fetcher struct {
 	clusterID     string
 	serverVersion string
-
-	k8s       informers.SharedInformerFactory
-	hub       hubinformer.SharedInformerFactory
-	traefik   traefikinformer.SharedInformerFactory
-	clientSet clientset.Interface
+	capabilities  kubevers.Capabilities
+	config        FetcherConfig
+	prober        *prober
+	runtimes      *runtimeCollector
+
+	k8s        informers.SharedInformerFactory
+	tlsSecrets informers.SharedInformerFactory
+	hub        hubinformer.SharedInformerFactory
+	traefik    traefikinformer.SharedInformerFactory
+	clientSet  clientset.Interface
+
+	// namespaces are the namespaces the topology is restricted to, if any.
+	namespaces []string
 }
 
 // NewFetcher creates a new Fetcher.
-func NewFetcher(ctx context.Context, clusterID string) (*Fetcher, error) {
+func NewFetcher(ctx context.Context, clusterID string, cfg FetcherConfig) (*Fetcher, error) {
 	config, err := kube.InClusterConfigWithRetrier(2)
 	if err != nil {
 		return nil, fmt.Errorf("create Kubernetes in-cluster configuration: %w", err)
 	}
 
+	return NewFetcherWithKubeConfig(ctx, config, clusterID, cfg)
+}
+
+// NewFetcherWithKubeConfig creates a new Fetcher using the given Kubernetes client configuration.
+func NewFetcherWithKubeConfig(ctx context.Context, config *rest.Config, clusterID string, cfg FetcherConfig) (*Fetcher, error) {
 	clientSet, err := clientset.NewForConfig(config)
 	if err != nil {
 		return nil, err
@@ -78,44 +117,67 @@ func NewFetcher(ctx context.Context, clusterID string) (*Fetcher, error) {
 		return nil, fmt.Errorf("get server version: %w", err)
 	}
 
-	return watchAll(ctx, clientSet, hubClientSet, traefikClientSet, serverVersion.GitVersion, clusterID)
-}
-
-func watchAll(ctx context.Context, clientSet clientset.Interface, hubClientSet hubclientset.Interface, traefikClientSet traefikclientset.Interface, serverVersion, clusterID string) (*Fetcher, error) {
-	serverSemVer, err := version.NewVersion(serverVersion)
+	f, err := watchAll(ctx, clientSet, hubClientSet, traefikClientSet, serverVersion.GitVersion, clusterID, cfg.Namespaces)
 	if err != nil {
-		return nil, fmt.Errorf("parse server version: %w", err)
+		return nil, err
+	}
+
+	f.config = cfg
+
+	if len(cfg.ProbeNamespaces) > 0 {
+		if cfg.ProbeInterval <= 0 {
+			return nil, fmt.Errorf("invalid probe interval: %s", cfg.ProbeInterval)
+		}
+
+		f.prober = newProber(cfg.ProbeNamespaces, cfg.ProbeInterval)
+		go f.prober.run(ctx)
+	}
+
+	if cfg.TraefikAPIPort != "" {
+		if cfg.TraefikAPIInterval <= 0 {
+			return nil, fmt.Errorf("invalid Traefik API interval: %s", cfg.TraefikAPIInterval)
+		}
+
+		f.runtimes = newRuntimeCollector(cfg.TraefikAPIPort, cfg.TraefikAPIInterval)
+		go f.runtimes.run(ctx)
 	}
 
-	if serverSemVer.LessThan(version.Must(version.NewVersion("1.14"))) {
-		return nil, fmt.Errorf("unsupported version: %s", serverSemVer)
+	return f, nil
+}
+
+func watchAll(ctx context.Context, clientSet clientset.Interface, hubClientSet hubclientset.Interface, traefikClientSet traefikclientset.Interface, serverVersion, clusterID string, namespaces []string) (*Fetcher, error) {
+	capabilities, err := kubevers.DetectCapabilities(clientSet.Discovery(), serverVersion)
+	if err != nil {
+		return nil, fmt.Errorf("detect cluster capabilities: %w", err)
 	}
 
 	kubernetesFactory := informers.NewSharedInformerFactoryWithOptions(clientSet, 5*time.Minute)
+	tlsSecretsFactory := informers.NewSharedInformerFactoryWithOptions(clientSet, 5*time.Minute,
+		informers.WithTweakListOptions(tlsSecretsOptions),
+	)
+	traefikFactory := traefikinformer.NewSharedInformerFactoryWithOptions(traefikClientSet, 5*time.Minute)
+	hubFactory := hubinformer.NewSharedInformerFactoryWithOptions(hubClientSet, 5*time.Minute)
+
+	if len(namespaces) > 0 {
+		restrictToNamespaces(namespaces, clientSet, hubClientSet, traefikClientSet, kubernetesFactory, tlsSecretsFactory, traefikFactory, hubFactory)
+	}
 
 	kubernetesFactory.Apps().V1().DaemonSets().Informer()
 	kubernetesFactory.Apps().V1().Deployments().Informer()
 	kubernetesFactory.Apps().V1().ReplicaSets().Informer()
 	kubernetesFactory.Apps().V1().StatefulSets().Informer()
 	kubernetesFactory.Core().V1().Endpoints().Informer()
-	kubernetesFactory.Core().V1().Namespaces().Informer()
+	// Namespaces are cluster-scoped, the allowed ones are used instead when restricted.
+	if len(namespaces) == 0 {
+		kubernetesFactory.Core().V1().Namespaces().Informer()
+	}
 	kubernetesFactory.Core().V1().Pods().Informer()
 	kubernetesFactory.Core().V1().Services().Informer()
 
-	if kubevers.SupportsNetV1IngressClasses(serverVersion) {
-		kubernetesFactory.Networking().V1().IngressClasses().Informer()
-	} else if kubevers.SupportsNetV1Beta1IngressClasses(serverVersion) {
-		kubernetesFactory.Networking().V1beta1().IngressClasses().Informer()
-	}
-
-	if kubevers.SupportsNetV1Ingresses(serverVersion) {
-		kubernetesFactory.Networking().V1().Ingresses().Informer()
-	} else {
-		// Since we only support Kubernetes v1.14 and up, we always have at least net v1beta1 Ingresses.
-		kubernetesFactory.Networking().V1beta1().Ingresses().Informer()
-	}
+	kubernetesFactory.Networking().V1().IngressClasses().Informer()
+	kubernetesFactory.Networking().V1().Ingresses().Informer()
 
-	traefikFactory := traefikinformer.NewSharedInformerFactoryWithOptions(traefikClientSet, 5*time.Minute)
+	tlsSecretsFactory.Core().V1().Secrets().Informer()
 
 	hasTraefikCRDs, err := hasTraefikCRDs(clientSet.Discovery())
 	if err != nil {
@@ -133,10 +195,11 @@ func watchAll(ctx context.Context, clientSet clientset.Interface, hubClientSet h
 		log.Info().Msg(msg)
 	}
 
-	hubFactory := hubinformer.NewSharedInformerFactoryWithOptions(hubClientSet, 5*time.Minute)
 	hubFactory.Hub().V1alpha1().AccessControlPolicies().Informer()
+	hubFactory.Hub().V1alpha1().EdgeIngresses().Informer()
 
 	kubernetesFactory.Start(ctx.Done())
+	tlsSecretsFactory.Start(ctx.Done())
 	hubFactory.Start(ctx.Done())
 	traefikFactory.Start(ctx.Done())
 
@@ -146,6 +209,12 @@ func watchAll(ctx context.Context, clientSet clientset.Interface, hubClientSet h
 		}
 	}
 
+	for typ, ok := range tlsSecretsFactory.WaitForCacheSync(ctx.Done()) {
+		if !ok {
+			return nil, fmt.Errorf("timed out waiting for TLS secrets caches to sync %s", typ)
+		}
+	}
+
 	for typ, ok := range hubFactory.WaitForCacheSync(ctx.Done()) {
 		if !ok {
 			return nil, fmt.Errorf("timed out waiting for access control policies caches to sync %s", typ)
@@ -161,13 +230,70 @@ func watchAll(ctx context.Context, clientSet clientset.Interface, hubClientSet h
 	return &Fetcher{
 		clusterID:     clusterID,
 		serverVersion: serverVersion,
+		capabilities:  capabilities,
 		k8s:           kubernetesFactory,
+		tlsSecrets:    tlsSecretsFactory,
 		hub:           hubFactory,
 		traefik:       traefikFactory,
 		clientSet:     clientSet,
+		namespaces:    namespaces,
 	}, nil
 }
 
+// tlsSecretsOptions restricts the watched Secrets to the TLS ones, to read the expiry of the certificates they hold.
+func tlsSecretsOptions(opts *metav1.ListOptions) {
+	opts.FieldSelector = "type=" + string(corev1.SecretTypeTLS)
+}
+
+// restrictToNamespaces makes the factories watch their namespaced resources in the given namespaces only.
+// Cluster-scoped resources are still watched cluster-wide.
+func restrictToNamespaces(namespaces []string, clientSet clientset.Interface, hubClientSet hubclientset.Interface, traefikClientSet traefikclientset.Interface, kubernetesFactory, tlsSecretsFactory informers.SharedInformerFactory, traefikFactory traefikinformer.SharedInformerFactory, hubFactory hubinformer.SharedInformerFactory) {
+	kubernetesResources := []namespacedResource{
+		{obj: &appsv1.DaemonSet{}, client: clientSet.AppsV1().RESTClient(), resource: "daemonsets"},
+		{obj: &appsv1.Deployment{}, client: clientSet.AppsV1().RESTClient(), resource: "deployments"},
+		{obj: &appsv1.ReplicaSet{}, client: clientSet.AppsV1().RESTClient(), resource: "replicasets"},
+		{obj: &appsv1.StatefulSet{}, client: clientSet.AppsV1().RESTClient(), resource: "statefulsets"},
+		{obj: &corev1.Endpoints{}, client: clientSet.CoreV1().RESTClient(), resource: "endpoints"},
+		{obj: &corev1.Pod{}, client: clientSet.CoreV1().RESTClient(), resource: "pods"},
+		{obj: &corev1.Service{}, client: clientSet.CoreV1().RESTClient(), resource: "services"},
+		{obj: &netv1.Ingress{}, client: clientSet.NetworkingV1().RESTClient(), resource: "ingresses"},
+	}
+
+	for _, res := range kubernetesResources {
+		res := res
+		kubernetesFactory.InformerFor(res.obj, func(_ clientset.Interface, resync time.Duration) cache.SharedIndexInformer {
+			return kube.NewNamespacedInformer(res.client, res.resource, res.obj, namespaces, resync, nil)
+		})
+	}
+
+	tlsSecretsFactory.InformerFor(&corev1.Secret{}, func(_ clientset.Interface, resync time.Duration) cache.SharedIndexInformer {
+		return kube.NewNamespacedInformer(clientSet.CoreV1().RESTClient(), "secrets", &corev1.Secret{}, namespaces, resync, tlsSecretsOptions)
+	})
+
+	traefikResources := []namespacedResource{
+		{obj: &traefikv1alpha1.IngressRoute{}, client: traefikClientSet.TraefikV1alpha1().RESTClient(), resource: "ingressroutes"},
+		{obj: &traefikv1alpha1.TraefikService{}, client: traefikClientSet.TraefikV1alpha1().RESTClient(), resource: "traefikservices"},
+		{obj: &traefikv1alpha1.TLSOption{}, client: traefikClientSet.TraefikV1alpha1().RESTClient(), resource: "tlsoptions"},
+	}
+	for _, res := range traefikResources {
+		res := res
+		traefikFactory.InformerFor(res.obj, func(_ traefikclientset.Interface, resync time.Duration) cache.SharedIndexInformer {
+			return kube.NewNamespacedInformer(res.client, res.resource, res.obj, namespaces, resync, nil)
+		})
+	}
+
+	hubFactory.InformerFor(&hubv1alpha1.EdgeIngress{}, func(_ hubclientset.Interface, resync time.Duration) cache.SharedIndexInformer {
+		return kube.NewNamespacedInformer(hubClientSet.HubV1alpha1().RESTClient(), "edgeingresses", &hubv1alpha1.EdgeIngress{}, namespaces, resync, nil)
+	})
+}
+
+// namespacedResource is a namespaced resource, watched through the given REST client.
+type namespacedResource struct {
+	obj      runtime.Object
+	client   cache.Getter
+	resource string
+}
+
 // FetchState assembles a cluster state from Kubernetes resources.
 func (f *Fetcher) FetchState() (*Cluster, error) {
 	cluster := &Cluster{
@@ -222,7 +348,20 @@ func (f *Fetcher) FetchState() (*Cluster, error) {
 		return nil, err
 	}
 
+	cluster.Certificates, err = f.getCertificates(cluster.Ingresses, cluster.IngressRoutes)
+	if err != nil {
+		return nil, err
+	}
+
+	if f.prober != nil {
+		cluster.ReachabilityProbes, err = f.getReachabilityProbes(cluster.Ingresses)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	cluster.Overview = getOverview(cluster)
+	cluster.Overview.Capabilities = f.capabilities.Names()
 
 	return cluster, nil
 }
@@ -49,12 +49,12 @@ func Test_watchAll_handlesUnsupportedVersions(t *testing.T) {
 		},
 		{
 			desc:          "Unsupported version",
-			serverVersion: "v1.13",
+			serverVersion: "v1.18",
 			wantErr:       assert.Error,
 		},
 		{
 			desc:          "Supported version",
-			serverVersion: "v1.16",
+			serverVersion: "v1.19",
 			wantErr:       assert.NoError,
 		},
 	}
@@ -68,7 +68,7 @@ func Test_watchAll_handlesUnsupportedVersions(t *testing.T) {
 			hubClient := hubkubemock.NewSimpleClientset()
 			traefikClient := traefikkubemock.NewSimpleClientset()
 
-			_, err := watchAll(context.Background(), kubeClient, hubClient, traefikClient, test.serverVersion, "cluster-id")
+			_, err := watchAll(context.Background(), kubeClient, hubClient, traefikClient, test.serverVersion, "cluster-id", nil)
 
 			test.wantErr(t, err)
 		})
@@ -81,57 +81,6 @@ func Test_watchAll_handlesAllIngressAPIVersions(t *testing.T) {
 		serverVersion string
 		want          map[string]*Ingress
 	}{
-		{
-			desc:          "v1.16",
-			serverVersion: "v1.16",
-			want: map[string]*Ingress{
-				"myIngress_netv1beta1@myns.ingress.networking.k8s.io": {
-					ResourceMeta: ResourceMeta{
-						Kind:      "Ingress",
-						Group:     "networking.k8s.io",
-						Name:      "myIngress_netv1beta1",
-						Namespace: "myns",
-					},
-					IngressMeta: IngressMeta{
-						ClusterID: "cluster-id",
-					},
-				},
-			},
-		},
-		{
-			desc:          "v1.18",
-			serverVersion: "v1.18",
-			want: map[string]*Ingress{
-				"myIngress_netv1beta1@myns.ingress.networking.k8s.io": {
-					ResourceMeta: ResourceMeta{
-						Kind:      "Ingress",
-						Group:     "networking.k8s.io",
-						Name:      "myIngress_netv1beta1",
-						Namespace: "myns",
-					},
-					IngressMeta: IngressMeta{
-						ClusterID: "cluster-id",
-					},
-				},
-			},
-		},
-		{
-			desc:          "v1.18.10",
-			serverVersion: "v1.18.10",
-			want: map[string]*Ingress{
-				"myIngress_netv1beta1@myns.ingress.networking.k8s.io": {
-					ResourceMeta: ResourceMeta{
-						Kind:      "Ingress",
-						Group:     "networking.k8s.io",
-						Name:      "myIngress_netv1beta1",
-						Namespace: "myns",
-					},
-					IngressMeta: IngressMeta{
-						ClusterID: "cluster-id",
-					},
-				},
-			},
-		},
 		{
 			desc:          "v1.19",
 			serverVersion: "v1.19",
@@ -192,7 +141,7 @@ func Test_watchAll_handlesAllIngressAPIVersions(t *testing.T) {
 			hubClient := hubkubemock.NewSimpleClientset()
 			traefikClient := traefikkubemock.NewSimpleClientset()
 
-			f, err := watchAll(context.Background(), kubeClient, hubClient, traefikClient, test.serverVersion, "cluster-id")
+			f, err := watchAll(context.Background(), kubeClient, hubClient, traefikClient, test.serverVersion, "cluster-id", nil)
 			require.NoError(t, err)
 
 			got, err := f.getIngresses("cluster-id")
@@ -19,9 +19,7 @@ package state
 
 import (
 	netv1 "k8s.io/api/networking/v1"
-	netv1beta1 "k8s.io/api/networking/v1beta1"
 	"k8s.io/apimachinery/pkg/labels"
-	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
 func (f *Fetcher) getIngresses(clusterID string) (map[string]*Ingress, error) {
@@ -63,25 +61,7 @@ func (f *Fetcher) getIngresses(clusterID string) (map[string]*Ingress, error) {
 }
 
 func (f *Fetcher) fetchIngresses() ([]*netv1.Ingress, error) {
-	ingresses, err := f.k8s.Networking().V1().Ingresses().Lister().List(labels.Everything())
-	if err != nil {
-		return nil, err
-	}
-
-	v1beta1Ingresses, err := f.k8s.Networking().V1beta1().Ingresses().Lister().List(labels.Everything())
-	if err != nil {
-		return nil, err
-	}
-
-	for _, ingress := range v1beta1Ingresses {
-		ing, err := toNetworkingV1(ingress)
-		if err != nil {
-			return nil, err
-		}
-		ingresses = append(ingresses, ing)
-	}
-
-	return ingresses, nil
+	return f.k8s.Networking().V1().Ingresses().Lister().List(labels.Everything())
 }
 
 func getIngressServices(ingress *netv1.Ingress) []string {
@@ -144,88 +124,13 @@ func getControllerType(ingress *netv1.Ingress, ingressClasses []*netv1.IngressCl
 	case ControllerTypeTraefik:
 		return IngressControllerTypeTraefik
 
-	default:
-		return ingressClass.Spec.Controller
-	}
-}
-
-func toNetworkingV1(ing *netv1beta1.Ingress) (*netv1.Ingress, error) {
-	netv1Ingress, err := marshalToIngressNetworkingV1(ing)
-	if err != nil {
-		return nil, err
-	}
+	case ControllerTypeNginx:
+		return IngressControllerTypeNginx
 
-	if ing.Spec.Backend != nil {
-		var port netv1.ServiceBackendPort
-		switch ing.Spec.Backend.ServicePort.Type {
-		case intstr.Int:
-			port.Number = ing.Spec.Backend.ServicePort.IntVal
-		case intstr.String:
-			port.Name = ing.Spec.Backend.ServicePort.StrVal
-		}
+	case ControllerTypeHAProxy:
+		return IngressControllerTypeHAProxy
 
-		netv1Ingress.Spec.DefaultBackend = &netv1.IngressBackend{
-			Service: &netv1.IngressServiceBackend{
-				Name: ing.Spec.Backend.ServiceName,
-				Port: port,
-			},
-			Resource: ing.Spec.Backend.Resource,
-		}
-	}
-
-	for _, rule := range netv1Ingress.Spec.Rules {
-		for index, path := range rule.HTTP.Paths {
-			var backend *netv1beta1.IngressBackend
-			if backend = findBackend(ing, rule.Host, path.Path); backend == nil {
-				continue
-			}
-
-			var port netv1.ServiceBackendPort
-			switch backend.ServicePort.Type {
-			case intstr.Int:
-				port.Number = backend.ServicePort.IntVal
-			case intstr.String:
-				port.Name = backend.ServicePort.StrVal
-			}
-
-			rule.HTTP.Paths[index].Backend = netv1.IngressBackend{
-				Service: &netv1.IngressServiceBackend{
-					Name: backend.ServiceName,
-					Port: port,
-				},
-			}
-		}
-	}
-
-	return netv1Ingress, nil
-}
-
-func findBackend(ingress *netv1beta1.Ingress, host, path string) *netv1beta1.IngressBackend {
-	for _, rule := range ingress.Spec.Rules {
-		if rule.Host != host {
-			continue
-		}
-
-		for _, rulePath := range rule.HTTP.Paths {
-			if rulePath.Path == path {
-				return &rulePath.Backend
-			}
-		}
-	}
-
-	return nil
-}
-
-func marshalToIngressNetworkingV1(ing *netv1beta1.Ingress) (*netv1.Ingress, error) {
-	data, err := ing.Marshal()
-	if err != nil {
-		return nil, err
-	}
-
-	ni := &netv1.Ingress{}
-	if err := ni.Unmarshal(data); err != nil {
-		return nil, err
+	default:
+		return ingressClass.Spec.Controller
 	}
-
-	return ni, nil
 }
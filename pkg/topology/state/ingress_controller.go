@@ -21,23 +21,32 @@ import (
 	"fmt"
 	"net"
 	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/rs/zerolog/log"
-	"github.com/traefik/hub-agent-kubernetes/pkg/kubevers"
 	corev1 "k8s.io/api/core/v1"
 	netv1 "k8s.io/api/networking/v1"
-	netv1beta1 "k8s.io/api/networking/v1beta1"
 	"k8s.io/apimachinery/pkg/labels"
 )
 
 // AnnotationHubIngressController is the annotation to add to a Deployment/ReplicaSet/StatefulSet/DaemonSet to specify the Ingress controller type.
 const AnnotationHubIngressController = "hub.traefik.io/ingress-controller"
 
+// Standard Prometheus annotations describing the metrics endpoint of a pod.
+const (
+	AnnotationPrometheusScrape = "prometheus.io/scrape"
+	AnnotationPrometheusScheme = "prometheus.io/scheme"
+	AnnotationPrometheusPort   = "prometheus.io/port"
+	AnnotationPrometheusPath   = "prometheus.io/path"
+)
+
 // Supported Ingress controller types.
 const (
 	IngressControllerTypeNone    = "none"
 	IngressControllerTypeTraefik = "traefik"
+	IngressControllerTypeNginx   = "nginx"
+	IngressControllerTypeHAProxy = "haproxy"
 )
 
 // Supported Ingress Controllers.
@@ -45,6 +54,10 @@ const (
 const (
 	// ControllerTypeTraefik Traefik Ingress Controllers type.
 	ControllerTypeTraefik = "traefik.io/ingress-controller"
+	// ControllerTypeNginx ingress-nginx Ingress Controllers type.
+	ControllerTypeNginx = "k8s.io/ingress-nginx"
+	// ControllerTypeHAProxy HAProxy Ingress Controllers type.
+	ControllerTypeHAProxy = "haproxy.org/ingress-controller/haproxy"
 )
 
 func (f *Fetcher) getIngressControllers(services map[string]*Service, apps map[string]*App) (map[string]*IngressController, error) {
@@ -59,6 +72,7 @@ func (f *Fetcher) getIngressControllers(services map[string]*Service, apps map[s
 	})
 
 	result := make(map[string]*IngressController)
+	runtimeTargets := make(map[string]runtimeTarget)
 	for _, pod := range pods {
 		if pod.Status.Phase != corev1.PodRunning {
 			continue
@@ -89,24 +103,33 @@ func (f *Fetcher) getIngressControllers(services map[string]*Service, apps map[s
 				// TODO What should we do if an IngressController does not have a service, log, status field?
 				PublicEndpoints: findPublicEndpoints(services, pod),
 				Endpoints:       findEndpoints(services, pod),
+
+				podRestarts: make(map[string]int32),
+			}
+
+			if ctrlType == IngressControllerTypeTraefik && f.runtimes != nil {
+				runtimeTargets[key] = f.runtimes.target(pod)
 			}
 
 			result[key] = ic
 		}
 
+		ic.podRestarts[pod.Name] = restartCount(pod)
+
 		metricsURL := guessMetricsURL(ctrlType, pod)
 		if metricsURL != "" {
 			ic.MetricsURLs = append(ic.MetricsURLs, metricsURL)
 		}
 	}
 
-	// Stop early if no IngressController was found.
-	if len(result) == 0 {
-		return result, nil
+	if f.runtimes != nil {
+		for key, runtime := range f.runtimes.setTargets(runtimeTargets) {
+			result[key].Runtime = runtime
+		}
 	}
 
-	// Stop early if server does not support IngressClasses.
-	if !kubevers.SupportsIngressClasses(f.serverVersion) {
+	// Stop early if no IngressController was found.
+	if len(result) == 0 {
 		return result, nil
 	}
 
@@ -121,26 +144,7 @@ func (f *Fetcher) getIngressControllers(services map[string]*Service, apps map[s
 }
 
 func (f *Fetcher) fetchIngressClasses() ([]*netv1.IngressClass, error) {
-	ingressClasses, err := f.k8s.Networking().V1().IngressClasses().Lister().List(labels.Everything())
-	if err != nil {
-		return nil, err
-	}
-
-	v1beta1IngressClasses, err := f.k8s.Networking().V1beta1().IngressClasses().Lister().List(labels.Everything())
-	if err != nil {
-		return nil, err
-	}
-
-	for _, ingressClass := range v1beta1IngressClasses {
-		networking, err := marshalToIngressClassNetworkingV1(ingressClass)
-		if err != nil {
-			return nil, err
-		}
-
-		ingressClasses = append(ingressClasses, networking)
-	}
-
-	return ingressClasses, nil
+	return f.k8s.Networking().V1().IngressClasses().Lister().List(labels.Everything())
 }
 
 func (f *Fetcher) getIngressControllerType(pod *corev1.Pod) (string, error) {
@@ -164,6 +168,14 @@ func (f *Fetcher) getIngressControllerType(pod *corev1.Pod) (string, error) {
 		if strings.HasSuffix(parts[0], "traefikee") && contains(container.Command, "proxy") {
 			return IngressControllerTypeTraefik, nil
 		}
+
+		if strings.HasSuffix(parts[0], "ingress-nginx/controller") || strings.HasSuffix(parts[0], "nginx-ingress-controller") {
+			return IngressControllerTypeNginx, nil
+		}
+
+		if strings.HasSuffix(parts[0], "haproxytech/kubernetes-ingress") || strings.HasSuffix(parts[0], "haproxy-ingress") {
+			return IngressControllerTypeHAProxy, nil
+		}
 	}
 
 	return IngressControllerTypeNone, nil
@@ -232,6 +244,10 @@ func setIngressClasses(controllers map[string]*IngressController, ingressClasses
 		switch ingressClass.Spec.Controller {
 		case ControllerTypeTraefik:
 			ctrlType = IngressControllerTypeTraefik
+		case ControllerTypeNginx:
+			ctrlType = IngressControllerTypeNginx
+		case ControllerTypeHAProxy:
+			ctrlType = IngressControllerTypeHAProxy
 		default:
 			continue
 		}
@@ -245,30 +261,78 @@ func setIngressClasses(controllers map[string]*IngressController, ingressClasses
 }
 
 // guessMetricsURL builds the metrics endpoint URL based on simple assumptions for a given pod.
-// For instance, this will not work if someone use a specific configuration to expose the prometheus metrics endpoint.
+// For instance, this will not work if someone use a specific configuration to expose the prometheus metrics endpoint,
+// in which case the standard Prometheus pod annotations are used to discover it.
 // TODO we can try to use the IngressController configuration to be more accurate.
 func guessMetricsURL(ctrl string, pod *corev1.Pod) string {
-	var port string
-	if ctrl == IngressControllerTypeTraefik {
-		port = "8080"
+	if pod.Annotations[AnnotationPrometheusScrape] == "false" {
+		return ""
 	}
 
-	if pod.Annotations["prometheus.io/port"] != "" {
-		port = pod.Annotations["prometheus.io/port"]
+	port := pod.Annotations[AnnotationPrometheusPort]
+	if port == "" {
+		port = defaultMetricsPort(ctrl, pod)
+	}
+	if port == "" {
+		log.Debug().
+			Str("pod", objectKey(pod.Name, pod.Namespace)).
+			Str("ingress_controller_type", ctrl).
+			Msgf("Unable to find the metrics endpoint, consider adding the %s annotation", AnnotationPrometheusPort)
+		return ""
+	}
+
+	scheme := "http"
+	if pod.Annotations[AnnotationPrometheusScheme] == "https" {
+		scheme = "https"
 	}
 
 	path := "metrics"
-	if pod.Annotations["prometheus.io/path"] != "" {
-		path = pod.Annotations["prometheus.io/path"]
+	if pod.Annotations[AnnotationPrometheusPath] != "" {
+		path = pod.Annotations[AnnotationPrometheusPath]
 	}
 	path = strings.TrimPrefix(path, "/")
 
-	return fmt.Sprintf("http://%s/%s", net.JoinHostPort(pod.Status.PodIP, port), path)
+	return fmt.Sprintf("%s://%s/%s", scheme, net.JoinHostPort(pod.Status.PodIP, port), path)
+}
+
+// defaultMetricsPort returns the port on which the given type of ingress controller serves metrics by default. An empty
+// string is returned when the pod declares its container ports but not the default one, as the controller is then
+// most likely configured to serve metrics elsewhere.
+func defaultMetricsPort(ctrl string, pod *corev1.Pod) string {
+	var port int32
+	switch ctrl {
+	case IngressControllerTypeTraefik:
+		port = 8080
+	case IngressControllerTypeNginx:
+		port = 10254
+	case IngressControllerTypeHAProxy:
+		// The HAProxy Prometheus exporter is served on the stats port.
+		port = 1024
+	default:
+		return ""
+	}
+
+	var declared bool
+	for _, container := range pod.Spec.Containers {
+		for _, containerPort := range container.Ports {
+			if containerPort.ContainerPort == port {
+				return strconv.Itoa(int(port))
+			}
+			declared = true
+		}
+	}
+	if declared {
+		return ""
+	}
+
+	return strconv.Itoa(int(port))
 }
 
 func isSupportedIngressControllerType(value string) bool {
 	switch value {
 	case IngressControllerTypeTraefik:
+	case IngressControllerTypeNginx:
+	case IngressControllerTypeHAProxy:
 	case IngressControllerTypeNone:
 	default:
 		return false
@@ -382,19 +446,14 @@ func findPublicEndpoints(svcs map[string]*Service, pod *corev1.Pod) []string {
 	return endpoints
 }
 
-func marshalToIngressClassNetworkingV1(ing *netv1beta1.IngressClass) (*netv1.IngressClass, error) {
-	data, err := ing.Marshal()
-	if err != nil {
-		return nil, err
-	}
-
-	ni := &netv1.IngressClass{}
-	err = ni.Unmarshal(data)
-	if err != nil {
-		return nil, err
+// restartCount returns the total number of container restarts of the given pod.
+func restartCount(pod *corev1.Pod) int32 {
+	var count int32
+	for _, status := range pod.Status.ContainerStatuses {
+		count += status.RestartCount
 	}
 
-	return ni, nil
+	return count
 }
 
 func contains(slice []string, value string) bool {
@@ -97,6 +97,8 @@ func TestFetcher_GetIngressControllers(t *testing.T) {
 					IngressClasses:  []string{"myIngressClass"},
 					MetricsURLs:     []string{"http://1.2.3.4:9090/custom"},
 					PublicEndpoints: []string{"1.2.3.4", "4.5.6.7"},
+
+					podRestarts: map[string]int32{"foo": 0},
 				},
 			},
 		},
@@ -154,6 +156,8 @@ func TestFetcher_GetIngressControllers(t *testing.T) {
 					IngressClasses:  []string{"myIngressClass"},
 					MetricsURLs:     []string{"http://1.2.3.4:9090/custom"},
 					PublicEndpoints: []string{"1.2.3.4", "hostname.traefik.io"},
+
+					podRestarts: map[string]int32{"foo": 0},
 				},
 			},
 		},
@@ -191,6 +195,8 @@ func TestFetcher_GetIngressControllers(t *testing.T) {
 					IngressClasses:  []string{"myIngressClass"},
 					MetricsURLs:     []string{"http://1.2.3.4:9090/custom"},
 					PublicEndpoints: nil,
+
+					podRestarts: map[string]int32{"foo": 0},
 				},
 			},
 		},
@@ -248,6 +254,8 @@ func TestFetcher_GetIngressControllers(t *testing.T) {
 					IngressClasses:  []string{"myIngressClass"},
 					MetricsURLs:     []string{"http://1.2.3.4:9090/custom", "http://4.5.6.7:9090/custom"},
 					PublicEndpoints: []string{"1.2.3.4", "4.5.6.7"},
+
+					podRestarts: map[string]int32{"foo-1": 0, "foo-2": 0},
 				},
 			},
 		},
@@ -305,6 +313,8 @@ func TestFetcher_GetIngressControllers(t *testing.T) {
 					IngressClasses:  []string{"myIngressClass", "myIngressClass2"},
 					MetricsURLs:     []string{"http://1.2.3.4:9090/custom"},
 					PublicEndpoints: []string{"1.2.3.4", "4.5.6.7"},
+
+					podRestarts: map[string]int32{"foo": 0},
 				},
 			},
 		},
@@ -362,6 +372,8 @@ func TestFetcher_GetIngressControllers(t *testing.T) {
 					IngressClasses:  []string{"myIngressClass"},
 					MetricsURLs:     []string{"http://1.2.3.4:9090/custom"},
 					PublicEndpoints: []string{"1.2.3.4", "4.5.6.7"},
+
+					podRestarts: map[string]int32{"foo": 0},
 				},
 			},
 		},
@@ -419,6 +431,8 @@ func TestFetcher_GetIngressControllers(t *testing.T) {
 					IngressClasses:  []string{"myIngressClass"},
 					MetricsURLs:     []string{"http://1.2.3.4:9090/custom"},
 					PublicEndpoints: []string{"1.2.3.4", "4.5.6.7"},
+
+					podRestarts: map[string]int32{"foo": 0},
 				},
 			},
 		},
@@ -476,6 +490,8 @@ func TestFetcher_GetIngressControllers(t *testing.T) {
 					IngressClasses:  []string{"myIngressClass"},
 					MetricsURLs:     []string{"http://1.2.3.4:9090/custom"},
 					PublicEndpoints: []string{"1.2.3.4", "4.5.6.7"},
+
+					podRestarts: map[string]int32{"foo": 0},
 				},
 			},
 		},
@@ -563,6 +579,8 @@ func TestFetcher_GetIngressControllers(t *testing.T) {
 					IngressClasses:  []string{"barIngressClass", "fooIngressClass"},
 					MetricsURLs:     []string{"http://1.2.3.4:9090/custom"},
 					PublicEndpoints: []string{"1.2.3.4", "4.5.6.7"},
+
+					podRestarts: map[string]int32{"foo": 0},
 				},
 				"traefik-2@myns": {
 					App: App{
@@ -580,6 +598,8 @@ func TestFetcher_GetIngressControllers(t *testing.T) {
 					IngressClasses:  []string{"barIngressClass", "fooIngressClass"},
 					MetricsURLs:     []string{"http://1.2.3.4:9090/custom"},
 					PublicEndpoints: []string{"11.12.13.14", "7.8.9.10"},
+
+					podRestarts: map[string]int32{"bar": 0},
 				},
 			},
 		},
@@ -596,7 +616,7 @@ func TestFetcher_GetIngressControllers(t *testing.T) {
 			hubClient := hubkubemock.NewSimpleClientset()
 			traefikClient := traefikkubemock.NewSimpleClientset()
 
-			f, err := watchAll(context.Background(), kubeClient, hubClient, traefikClient, "v1.20.1", "cluster-id")
+			f, err := watchAll(context.Background(), kubeClient, hubClient, traefikClient, "v1.20.1", "cluster-id", nil)
 			require.NoError(t, err)
 
 			got, err := f.getIngressControllers(test.services, test.apps)
@@ -713,6 +733,40 @@ func TestFetcher_GetIngressControllerType(t *testing.T) {
 			},
 			wantType: IngressControllerTypeNone,
 		},
+		{
+			desc: "Valid ingress-nginx controller image",
+			pod: &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{
+							Image: "registry.k8s.io/ingress-nginx/controller:v1.3.0",
+						},
+					},
+				},
+				Status: corev1.PodStatus{
+					Phase: corev1.PodRunning,
+				},
+			},
+			wantType: IngressControllerTypeNginx,
+		},
+		{
+			desc: "Valid HAProxy controller image",
+			pod: &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{
+							Image: "haproxytech/kubernetes-ingress:1.8.3",
+						},
+					},
+				},
+				Status: corev1.PodStatus{
+					Phase: corev1.PodRunning,
+				},
+			},
+			wantType: IngressControllerTypeHAProxy,
+		},
 		{
 			desc: "Ingress controller type defined by annotation",
 			pod: &corev1.Pod{
@@ -766,7 +820,7 @@ func TestFetcher_GetIngressControllerType(t *testing.T) {
 			hubClient := hubkubemock.NewSimpleClientset()
 			traefikClient := traefikkubemock.NewSimpleClientset()
 
-			f, err := watchAll(context.Background(), kubeClient, hubClient, traefikClient, "v1.20.1", "cluster-id")
+			f, err := watchAll(context.Background(), kubeClient, hubClient, traefikClient, "v1.20.1", "cluster-id", nil)
 			require.NoError(t, err)
 
 			controller, err := f.getIngressControllerType(test.pod)
@@ -857,7 +911,7 @@ func TestFetcher_GetAnnotation(t *testing.T) {
 			hubClient := hubkubemock.NewSimpleClientset()
 			traefikClient := traefikkubemock.NewSimpleClientset()
 
-			f, err := watchAll(context.Background(), kubeClient, hubClient, traefikClient, "v1.20.1", "cluster-id")
+			f, err := watchAll(context.Background(), kubeClient, hubClient, traefikClient, "v1.20.1", "cluster-id", nil)
 			require.NoError(t, err)
 
 			pod, err := kubeClient.CoreV1().Pods("ns").Get(context.Background(), "whoami", metav1.GetOptions{})
@@ -888,6 +942,26 @@ func TestGuessMetricsURL(t *testing.T) {
 			},
 			wantURL: "http://1.2.3.4:8080/metrics",
 		},
+		{
+			desc: "Pod with nginx controller defaults",
+			ctrl: IngressControllerTypeNginx,
+			pod: &corev1.Pod{
+				Status: corev1.PodStatus{
+					PodIP: "1.2.3.4",
+				},
+			},
+			wantURL: "http://1.2.3.4:10254/metrics",
+		},
+		{
+			desc: "Pod with haproxy controller defaults",
+			ctrl: IngressControllerTypeHAProxy,
+			pod: &corev1.Pod{
+				Status: corev1.PodStatus{
+					PodIP: "1.2.3.4",
+				},
+			},
+			wantURL: "http://1.2.3.4:1024/metrics",
+		},
 		{
 			desc: "Pod with annotations",
 			ctrl: "unknown_controller",
@@ -903,6 +977,72 @@ func TestGuessMetricsURL(t *testing.T) {
 			},
 			wantURL: "http://1.2.3.4:8443/metrics",
 		},
+		{
+			desc: "Pod declaring the default metrics port",
+			ctrl: IngressControllerTypeNginx,
+			pod: &corev1.Pod{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{Ports: []corev1.ContainerPort{{ContainerPort: 80}, {ContainerPort: 10254}}},
+					},
+				},
+				Status: corev1.PodStatus{
+					PodIP: "1.2.3.4",
+				},
+			},
+			wantURL: "http://1.2.3.4:10254/metrics",
+		},
+		{
+			desc: "Pod not declaring the default metrics port",
+			ctrl: IngressControllerTypeTraefik,
+			pod: &corev1.Pod{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{Ports: []corev1.ContainerPort{{ContainerPort: 8000}, {ContainerPort: 9100}}},
+					},
+				},
+				Status: corev1.PodStatus{
+					PodIP: "1.2.3.4",
+				},
+			},
+		},
+		{
+			desc: "Pod not declaring the default metrics port with annotations",
+			ctrl: IngressControllerTypeTraefik,
+			pod: &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: map[string]string{
+						"prometheus.io/scrape": "true",
+						"prometheus.io/scheme": "https",
+						"prometheus.io/port":   "9100",
+						"prometheus.io/path":   "/stats/prometheus",
+					},
+				},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{Ports: []corev1.ContainerPort{{ContainerPort: 8000}, {ContainerPort: 9100}}},
+					},
+				},
+				Status: corev1.PodStatus{
+					PodIP: "1.2.3.4",
+				},
+			},
+			wantURL: "https://1.2.3.4:9100/stats/prometheus",
+		},
+		{
+			desc: "Pod excluded from scraping",
+			ctrl: IngressControllerTypeTraefik,
+			pod: &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: map[string]string{
+						"prometheus.io/scrape": "false",
+					},
+				},
+				Status: corev1.PodStatus{
+					PodIP: "1.2.3.4",
+				},
+			},
+		},
 	}
 
 	for _, test := range tests {
@@ -301,7 +301,7 @@ func TestFetcher_GetIngressRoutes(t *testing.T) {
 			hubClient := hubkubemock.NewSimpleClientset()
 			traefikClient := traefikkubemock.NewSimpleClientset(objects...)
 
-			f, err := watchAll(context.Background(), kubeClient, hubClient, traefikClient, "v1.20.1", "cluster-id")
+			f, err := watchAll(context.Background(), kubeClient, hubClient, traefikClient, "v1.20.1", "cluster-id", nil)
 			require.NoError(t, err)
 
 			got, gotTraefikService, err := f.getIngressRoutes("cluster-id")
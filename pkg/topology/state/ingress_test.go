@@ -25,7 +25,6 @@ import (
 	"github.com/stretchr/testify/require"
 	hubkubemock "github.com/traefik/hub-agent-kubernetes/pkg/crd/generated/client/hub/clientset/versioned/fake"
 	traefikkubemock "github.com/traefik/hub-agent-kubernetes/pkg/crd/generated/client/traefik/clientset/versioned/fake"
-	corev1 "k8s.io/api/core/v1"
 	netv1 "k8s.io/api/networking/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	kubemock "k8s.io/client-go/kubernetes/fake"
@@ -87,7 +86,7 @@ func TestFetcher_GetIngresses(t *testing.T) {
 	hubClient := hubkubemock.NewSimpleClientset()
 	traefikClient := traefikkubemock.NewSimpleClientset()
 
-	f, err := watchAll(context.Background(), kubeClient, hubClient, traefikClient, "v1.20.1", "cluster-id")
+	f, err := watchAll(context.Background(), kubeClient, hubClient, traefikClient, "v1.20.1", "cluster-id", nil)
 	require.NoError(t, err)
 
 	got, err := f.getIngresses("cluster-id")
@@ -96,87 +95,6 @@ func TestFetcher_GetIngresses(t *testing.T) {
 	assert.Equal(t, want, got)
 }
 
-func TestFetcher_FetchIngresses(t *testing.T) {
-	want := []*netv1.Ingress{
-		{
-			ObjectMeta: metav1.ObjectMeta{
-				Namespace: "myns",
-				Name:      "myIngress_v1beta1",
-			},
-			Spec: netv1.IngressSpec{
-				IngressClassName: stringPtr("myIngressClass"),
-				DefaultBackend: &netv1.IngressBackend{
-					Service: &netv1.IngressServiceBackend{
-						Name: "myService",
-						Port: netv1.ServiceBackendPort{
-							Number: 443,
-						},
-					},
-				},
-				Rules: []netv1.IngressRule{
-					{
-						Host: "foo.bar",
-						IngressRuleValue: netv1.IngressRuleValue{
-							HTTP: &netv1.HTTPIngressRuleValue{
-								Paths: []netv1.HTTPIngressPath{
-									{
-										Path:     "/foobar",
-										PathType: netv1PathType(netv1.PathTypePrefix),
-										Backend: netv1.IngressBackend{
-											Service: &netv1.IngressServiceBackend{
-												Name: "myService",
-												Port: netv1.ServiceBackendPort{
-													Number: 443,
-												},
-											},
-										},
-									},
-								},
-							},
-						},
-					},
-				},
-				TLS: []netv1.IngressTLS{
-					{
-						Hosts:      []string{"foo.com"},
-						SecretName: "mySecret",
-					},
-				},
-			},
-			Status: netv1.IngressStatus{
-				LoadBalancer: corev1.LoadBalancerStatus{
-					Ingress: []corev1.LoadBalancerIngress{
-						{
-							IP:       "1.2.3.4",
-							Hostname: "foo.bar",
-							Ports: []corev1.PortStatus{
-								{
-									Port:     8080,
-									Protocol: "TCP",
-								},
-							},
-						},
-					},
-				},
-			},
-		},
-	}
-
-	objects := loadK8sObjects(t, "fixtures/ingress/v1.18-ingress.yml")
-
-	kubeClient := kubemock.NewSimpleClientset(objects...)
-	hubClient := hubkubemock.NewSimpleClientset()
-	traefikClient := traefikkubemock.NewSimpleClientset()
-
-	f, err := watchAll(context.Background(), kubeClient, hubClient, traefikClient, "v1.18", "cluster-id")
-	require.NoError(t, err)
-
-	got, err := f.fetchIngresses()
-	require.NoError(t, err)
-
-	assert.Equal(t, want, got)
-}
-
 func Test_GetControllerType(t *testing.T) {
 	tests := []struct {
 		desc           string
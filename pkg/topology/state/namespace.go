@@ -1,8 +1,20 @@
 package state
 
-import "k8s.io/apimachinery/pkg/labels"
+import (
+	"sort"
+
+	"k8s.io/apimachinery/pkg/labels"
+)
 
 func (f *Fetcher) getNamespaces() ([]string, error) {
+	if len(f.namespaces) > 0 {
+		result := make([]string, len(f.namespaces))
+		copy(result, f.namespaces)
+		sort.Strings(result)
+
+		return result, nil
+	}
+
 	ns, err := f.k8s.Core().V1().Namespaces().Lister().List(labels.Everything())
 	if err != nil {
 		return nil, err
@@ -48,7 +48,7 @@ func TestFetcher_GetNamespaces(t *testing.T) {
 	hubClient := hubkubemock.NewSimpleClientset()
 	traefikClient := traefikkubemock.NewSimpleClientset()
 
-	f, err := watchAll(context.Background(), kubeClient, hubClient, traefikClient, "v1.20.1", "cluster-id")
+	f, err := watchAll(context.Background(), kubeClient, hubClient, traefikClient, "v1.20.1", "cluster-id", nil)
 	require.NoError(t, err)
 
 	got, err := f.getNamespaces()
@@ -58,3 +58,12 @@ func TestFetcher_GetNamespaces(t *testing.T) {
 
 	assert.Equal(t, []string{"myns", "otherns"}, got)
 }
+
+func TestFetcher_GetNamespaces_restricted(t *testing.T) {
+	f := &Fetcher{namespaces: []string{"otherns", "myns"}}
+
+	got, err := f.getNamespaces()
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"myns", "otherns"}, got)
+}
@@ -0,0 +1,323 @@
+/*
+Copyright (C) 2022 Traefik Labs
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package state
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	hubv1alpha1 "github.com/traefik/hub-agent-kubernetes/pkg/crd/api/hub/v1alpha1"
+	netv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// prober periodically probes the public endpoints of Ingresses and EdgeIngresses from inside the cluster.
+// Only resources living in one of the allowed namespaces are probed.
+type prober struct {
+	client     *http.Client
+	interval   time.Duration
+	namespaces map[string]struct{}
+
+	mu      sync.RWMutex
+	targets map[string]ReachabilityProbe
+	results map[string]ProbeResult
+}
+
+func newProber(namespaces []string, interval time.Duration) *prober {
+	nss := make(map[string]struct{}, len(namespaces))
+	for _, ns := range namespaces {
+		nss[ns] = struct{}{}
+	}
+
+	return &prober{
+		client: &http.Client{
+			Timeout: 10 * time.Second,
+			// Redirections are reported as is, following them could lead to probing arbitrary endpoints.
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		interval:   interval,
+		namespaces: nss,
+		targets:    make(map[string]ReachabilityProbe),
+		results:    make(map[string]ProbeResult),
+	}
+}
+
+// run probes the known targets at each interval until the given context is done.
+func (p *prober) run(ctx context.Context) {
+	tick := time.NewTicker(p.interval)
+	defer tick.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-tick.C:
+			p.probeAll(ctx)
+		}
+	}
+}
+
+func (p *prober) probeAll(ctx context.Context) {
+	p.mu.RLock()
+	urls := make(map[string]struct{})
+	for _, target := range p.targets {
+		for _, result := range target.Results {
+			urls[result.URL] = struct{}{}
+		}
+	}
+	lastResults := p.results
+	p.mu.RUnlock()
+
+	// URLs are probed sequentially to keep the load put on the probed endpoints low.
+	results := make(map[string]ProbeResult, len(urls))
+	for u := range urls {
+		if ctx.Err() != nil {
+			return
+		}
+
+		result := p.probe(ctx, u)
+
+		// The last result is kept as long as the outcome is the same, so the topology only changes when the
+		// reachability of an endpoint does, and not at every probe.
+		if last, ok := lastResults[u]; ok && sameProbeOutcome(last, result) {
+			result = last
+		}
+
+		results[u] = result
+	}
+
+	p.mu.Lock()
+	p.results = results
+	p.mu.Unlock()
+}
+
+func (p *prober) probe(ctx context.Context, u string) ProbeResult {
+	result := ProbeResult{
+		URL:      u,
+		ProbedAt: time.Now().UTC().Truncate(time.Second),
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, http.NoBody)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	start := time.Now()
+	resp, err := p.client.Do(req)
+	result.LatencyMs = time.Since(start).Milliseconds()
+	if err != nil {
+		if isTLSError(err) {
+			result.TLSValid = boolPtr(false)
+		}
+
+		log.Debug().Err(err).Str("url", u).Msg("Unable to reach public endpoint")
+		result.Error = err.Error()
+		return result
+	}
+	_ = resp.Body.Close()
+
+	result.StatusCode = resp.StatusCode
+
+	if resp.TLS != nil && len(resp.TLS.PeerCertificates) > 0 {
+		result.TLSValid = boolPtr(true)
+		notAfter := resp.TLS.PeerCertificates[0].NotAfter.UTC()
+		result.TLSExpiresAt = &notAfter
+	}
+
+	return result
+}
+
+// sameProbeOutcome returns whether the given results report the same status, TLS validity, certificate expiry and
+// error, regardless of when they were made and how long they took.
+func sameProbeOutcome(a, b ProbeResult) bool {
+	if a.StatusCode != b.StatusCode || a.Error != b.Error {
+		return false
+	}
+
+	if (a.TLSValid == nil) != (b.TLSValid == nil) || a.TLSValid != nil && *a.TLSValid != *b.TLSValid {
+		return false
+	}
+
+	if (a.TLSExpiresAt == nil) != (b.TLSExpiresAt == nil) || a.TLSExpiresAt != nil && !a.TLSExpiresAt.Equal(*b.TLSExpiresAt) {
+		return false
+	}
+
+	return true
+}
+
+// setTargets replaces the probed targets, indexed by resource key, and returns them along with the last known results.
+func (p *prober) setTargets(targets map[string]ReachabilityProbe) map[string]*ReachabilityProbe {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.targets = targets
+
+	probes := make(map[string]*ReachabilityProbe, len(targets))
+	for key, target := range targets {
+		probe := &ReachabilityProbe{ResourceMeta: target.ResourceMeta}
+
+		for _, result := range target.Results {
+			if last, ok := p.results[result.URL]; ok {
+				result = last
+			}
+
+			probe.Results = append(probe.Results, result)
+		}
+
+		probes[key] = probe
+	}
+
+	return probes
+}
+
+func (p *prober) allowed(namespace string) bool {
+	_, ok := p.namespaces[namespace]
+	return ok
+}
+
+func (f *Fetcher) getReachabilityProbes(ingresses map[string]*Ingress) (map[string]*ReachabilityProbe, error) {
+	targets := make(map[string]ReachabilityProbe)
+
+	for key, ing := range ingresses {
+		if !f.prober.allowed(ing.Namespace) {
+			continue
+		}
+
+		urls := getIngressProbeURLs(ing)
+		if len(urls) == 0 {
+			continue
+		}
+
+		targets[key] = newReachabilityProbe(ing.ResourceMeta, urls)
+	}
+
+	edgeIngresses, err := f.hub.Hub().V1alpha1().EdgeIngresses().Lister().List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+
+	for _, edgeIng := range edgeIngresses {
+		if !f.prober.allowed(edgeIng.Namespace) || edgeIng.Status.URL == "" {
+			continue
+		}
+
+		meta := ResourceMeta{
+			Kind:      "EdgeIngress",
+			Group:     hubv1alpha1.SchemeGroupVersion.Group,
+			Name:      edgeIng.Name,
+			Namespace: edgeIng.Namespace,
+		}
+		targets[ingressKey(meta)] = newReachabilityProbe(meta, []string{edgeIng.Status.URL})
+	}
+
+	return f.prober.setTargets(targets), nil
+}
+
+func newReachabilityProbe(meta ResourceMeta, urls []string) ReachabilityProbe {
+	probe := ReachabilityProbe{ResourceMeta: meta}
+	for _, u := range urls {
+		probe.Results = append(probe.Results, ProbeResult{URL: u})
+	}
+
+	return probe
+}
+
+// getIngressProbeURLs returns the URLs of the routes exposed by the given Ingress.
+// Rules without host or with a wildcard host are ignored since they cannot be reached deterministically.
+func getIngressProbeURLs(ing *Ingress) []string {
+	tlsHosts := make(map[string]struct{})
+	for _, t := range ing.TLS {
+		for _, host := range t.Hosts {
+			tlsHosts[host] = struct{}{}
+		}
+	}
+
+	seen := make(map[string]struct{})
+	var urls []string
+	for _, rule := range ing.Rules {
+		if rule.Host == "" || strings.HasPrefix(rule.Host, "*") {
+			continue
+		}
+
+		scheme := "http"
+		if _, ok := tlsHosts[rule.Host]; ok {
+			scheme = "https"
+		}
+
+		paths := []string{"/"}
+		if rule.HTTP != nil {
+			paths = paths[:0]
+			for _, path := range rule.HTTP.Paths {
+				if path.PathType != nil && *path.PathType == netv1.PathTypeImplementationSpecific {
+					// Implementation specific paths may be regular expressions and can't be probed as is.
+					continue
+				}
+
+				p := path.Path
+				if p == "" {
+					p = "/"
+				}
+				paths = append(paths, p)
+			}
+		}
+
+		for _, path := range paths {
+			u := fmt.Sprintf("%s://%s%s", scheme, rule.Host, path)
+			if _, ok := seen[u]; ok {
+				continue
+			}
+
+			seen[u] = struct{}{}
+			urls = append(urls, u)
+		}
+	}
+
+	sort.Strings(urls)
+
+	return urls
+}
+
+func isTLSError(err error) bool {
+	var (
+		unknownAuthorityErr x509.UnknownAuthorityError
+		hostnameErr         x509.HostnameError
+		certInvalidErr      x509.CertificateInvalidError
+		recordHeaderErr     tls.RecordHeaderError
+	)
+
+	return errors.As(err, &unknownAuthorityErr) ||
+		errors.As(err, &hostnameErr) ||
+		errors.As(err, &certInvalidErr) ||
+		errors.As(err, &recordHeaderErr)
+}
+
+func boolPtr(b bool) *bool {
+	return &b
+}
@@ -0,0 +1,179 @@
+/*
+Copyright (C) 2022 Traefik Labs
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package state
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	hubv1alpha1 "github.com/traefik/hub-agent-kubernetes/pkg/crd/api/hub/v1alpha1"
+	hubkubemock "github.com/traefik/hub-agent-kubernetes/pkg/crd/generated/client/hub/clientset/versioned/fake"
+	traefikkubemock "github.com/traefik/hub-agent-kubernetes/pkg/crd/generated/client/traefik/clientset/versioned/fake"
+	netv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubemock "k8s.io/client-go/kubernetes/fake"
+)
+
+func Test_getIngressProbeURLs(t *testing.T) {
+	prefix := netv1.PathTypePrefix
+	implementationSpecific := netv1.PathTypeImplementationSpecific
+
+	ing := &Ingress{
+		TLS: []netv1.IngressTLS{{Hosts: []string{"secure.foo.com"}}},
+		Rules: []netv1.IngressRule{
+			{Host: ""},
+			{Host: "*.foo.com"},
+			{Host: "foo.com"},
+			{
+				Host: "secure.foo.com",
+				IngressRuleValue: netv1.IngressRuleValue{
+					HTTP: &netv1.HTTPIngressRuleValue{
+						Paths: []netv1.HTTPIngressPath{
+							{Path: "/api", PathType: &prefix},
+							{Path: "/api", PathType: &prefix},
+							{Path: "/re[g]ex", PathType: &implementationSpecific},
+							{PathType: &prefix},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	got := getIngressProbeURLs(ing)
+
+	assert.Equal(t, []string{"http://foo.com/", "https://secure.foo.com/", "https://secure.foo.com/api"}, got)
+}
+
+func TestFetcher_getReachabilityProbes(t *testing.T) {
+	kubeClient := kubemock.NewSimpleClientset()
+	traefikClient := traefikkubemock.NewSimpleClientset()
+	hubClient := hubkubemock.NewSimpleClientset(
+		&hubv1alpha1.EdgeIngress{
+			ObjectMeta: metav1.ObjectMeta{Name: "edge", Namespace: "probed"},
+			Status:     hubv1alpha1.EdgeIngressStatus{URL: "https://edge.hub.example.com"},
+		},
+		&hubv1alpha1.EdgeIngress{
+			ObjectMeta: metav1.ObjectMeta{Name: "edge", Namespace: "other"},
+			Status:     hubv1alpha1.EdgeIngressStatus{URL: "https://other.hub.example.com"},
+		},
+	)
+
+	f, err := watchAll(context.Background(), kubeClient, hubClient, traefikClient, "v1.20.1", "cluster-id", nil)
+	require.NoError(t, err)
+
+	f.prober = newProber([]string{"probed"}, time.Minute)
+	f.prober.results["http://foo.com/"] = ProbeResult{URL: "http://foo.com/", StatusCode: http.StatusOK}
+
+	ingresses := map[string]*Ingress{
+		"ing@probed.ingress.networking.k8s.io": {
+			ResourceMeta: ResourceMeta{Kind: "Ingress", Group: "networking.k8s.io", Name: "ing", Namespace: "probed"},
+			Rules:        []netv1.IngressRule{{Host: "foo.com"}},
+		},
+		"ing@other.ingress.networking.k8s.io": {
+			ResourceMeta: ResourceMeta{Kind: "Ingress", Group: "networking.k8s.io", Name: "ing", Namespace: "other"},
+			Rules:        []netv1.IngressRule{{Host: "bar.com"}},
+		},
+	}
+
+	got, err := f.getReachabilityProbes(ingresses)
+	require.NoError(t, err)
+
+	want := map[string]*ReachabilityProbe{
+		"ing@probed.ingress.networking.k8s.io": {
+			ResourceMeta: ResourceMeta{Kind: "Ingress", Group: "networking.k8s.io", Name: "ing", Namespace: "probed"},
+			Results:      []ProbeResult{{URL: "http://foo.com/", StatusCode: http.StatusOK}},
+		},
+		"edge@probed.edgeingress.hub.traefik.io": {
+			ResourceMeta: ResourceMeta{Kind: "EdgeIngress", Group: "hub.traefik.io", Name: "edge", Namespace: "probed"},
+			Results:      []ProbeResult{{URL: "https://edge.hub.example.com"}},
+		},
+	}
+	assert.Equal(t, want, got)
+}
+
+func TestProber_probe(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.WriteHeader(http.StatusTeapot)
+	}))
+	t.Cleanup(srv.Close)
+
+	tlsSrv := httptest.NewTLSServer(http.NotFoundHandler())
+	t.Cleanup(tlsSrv.Close)
+
+	p := newProber(nil, time.Minute)
+
+	got := p.probe(context.Background(), srv.URL)
+	assert.Equal(t, srv.URL, got.URL)
+	assert.Equal(t, http.StatusTeapot, got.StatusCode)
+	assert.Nil(t, got.TLSValid)
+	assert.Empty(t, got.Error)
+	assert.False(t, got.ProbedAt.IsZero())
+
+	// The test server certificate is self-signed, hence not trusted.
+	got = p.probe(context.Background(), tlsSrv.URL)
+	assert.Zero(t, got.StatusCode)
+	require.NotNil(t, got.TLSValid)
+	assert.False(t, *got.TLSValid)
+	assert.NotEmpty(t, got.Error)
+
+	p.client = tlsSrv.Client()
+	got = p.probe(context.Background(), tlsSrv.URL)
+	assert.Equal(t, http.StatusNotFound, got.StatusCode)
+	require.NotNil(t, got.TLSValid)
+	assert.True(t, *got.TLSValid)
+	assert.NotNil(t, got.TLSExpiresAt)
+}
+
+func TestProber_probeAll(t *testing.T) {
+	var statusCode int64 = http.StatusOK
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.WriteHeader(int(atomic.LoadInt64(&statusCode)))
+	}))
+	t.Cleanup(srv.Close)
+
+	p := newProber(nil, time.Minute)
+	p.setTargets(map[string]ReachabilityProbe{
+		"ing@ns.ingress.networking.k8s.io": newReachabilityProbe(ResourceMeta{Kind: "Ingress", Name: "ing", Namespace: "ns"}, []string{srv.URL}),
+	})
+
+	p.probeAll(context.Background())
+	require.Contains(t, p.results, srv.URL)
+	assert.Equal(t, http.StatusOK, p.results[srv.URL].StatusCode)
+
+	// Pretend the endpoint was probed a while ago.
+	last := p.results[srv.URL]
+	last.ProbedAt = last.ProbedAt.Add(-time.Hour)
+	p.results[srv.URL] = last
+
+	// The last result is kept when the outcome is the same.
+	p.probeAll(context.Background())
+	assert.Equal(t, last, p.results[srv.URL])
+
+	// The result is updated when the outcome changes.
+	atomic.StoreInt64(&statusCode, http.StatusServiceUnavailable)
+	p.probeAll(context.Background())
+	assert.Equal(t, http.StatusServiceUnavailable, p.results[srv.URL].StatusCode)
+	assert.True(t, p.results[srv.URL].ProbedAt.After(last.ProbedAt))
+}
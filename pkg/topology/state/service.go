@@ -27,6 +27,7 @@ import (
 	"sort"
 
 	corev1 "k8s.io/api/core/v1"
+	kerror "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/labels"
 )
 
@@ -61,18 +62,24 @@ func (f *Fetcher) getServices(clusterID string, apps map[string]*App) (map[strin
 
 		sort.Strings(externalIPs)
 
+		readyEndpoints, err := f.countReadyEndpoints(service.Namespace, service.Name)
+		if err != nil {
+			return nil, nil, err
+		}
+
 		svcName := objectKey(service.Name, service.Namespace)
 		svcs[svcName] = &Service{
-			Name:          service.Name,
-			Namespace:     service.Namespace,
-			ClusterID:     clusterID,
-			Annotations:   sanitizeAnnotations(service.Annotations),
-			Selector:      service.Spec.Selector,
-			Apps:          selectApps(apps, service),
-			Type:          service.Spec.Type,
-			ExternalIPs:   externalIPs,
-			ExternalPorts: externalPorts,
-			status:        service.Status,
+			Name:           service.Name,
+			Namespace:      service.Namespace,
+			ClusterID:      clusterID,
+			Annotations:    sanitizeAnnotations(service.Annotations),
+			Selector:       service.Spec.Selector,
+			Apps:           selectApps(apps, service),
+			Type:           service.Spec.Type,
+			ExternalIPs:    externalIPs,
+			ExternalPorts:  externalPorts,
+			status:         service.Status,
+			readyEndpoints: readyEndpoints,
 		}
 
 		for _, key := range traefikServiceNames(service) {
@@ -83,6 +90,24 @@ func (f *Fetcher) getServices(clusterID string, apps map[string]*App) (map[strin
 	return svcs, traefikNames, nil
 }
 
+// countReadyEndpoints returns the number of ready addresses backing the given service.
+func (f *Fetcher) countReadyEndpoints(namespace, name string) (int, error) {
+	endpoints, err := f.k8s.Core().V1().Endpoints().Lister().Endpoints(namespace).Get(name)
+	if err != nil {
+		if kerror.IsNotFound(err) {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("get endpoints %s/%s: %w", namespace, name, err)
+	}
+
+	var count int
+	for _, subset := range endpoints.Subsets {
+		count += len(subset.Addresses)
+	}
+
+	return count, nil
+}
+
 func traefikServiceNames(svc *corev1.Service) []string {
 	var result []string
 	for _, port := range svc.Spec.Ports {
@@ -62,7 +62,8 @@ func TestFetcher_GetServices(t *testing.T) {
 					},
 				},
 			},
-			ExternalPorts: []int{443},
+			ExternalPorts:  []int{443},
+			readyEndpoints: 2,
 		},
 	}
 	wantNames := map[string]string{
@@ -127,13 +128,25 @@ func TestFetcher_GetServices(t *testing.T) {
 				},
 			},
 		},
+		&corev1.Endpoints{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "myService",
+				Namespace: "myns",
+			},
+			Subsets: []corev1.EndpointSubset{
+				{
+					Addresses:         []corev1.EndpointAddress{{IP: "10.0.0.1"}, {IP: "10.0.0.2"}},
+					NotReadyAddresses: []corev1.EndpointAddress{{IP: "10.0.0.3"}},
+				},
+			},
+		},
 	}
 
 	kubeClient := kubemock.NewSimpleClientset(objects...)
 	hubClient := hubkubemock.NewSimpleClientset()
 	traefikClient := traefikkubemock.NewSimpleClientset()
 
-	f, err := watchAll(context.Background(), kubeClient, hubClient, traefikClient, "v1.20.1", "cluster-id")
+	f, err := watchAll(context.Background(), kubeClient, hubClient, traefikClient, "v1.20.1", "cluster-id", nil)
 	require.NoError(t, err)
 
 	gotSvcs, gotNames, err := f.getServices("cluster-id", apps)
@@ -248,7 +261,7 @@ func TestFetcher_GetServicesWithExternalIPs(t *testing.T) {
 	hubClient := hubkubemock.NewSimpleClientset()
 	traefikClient := traefikkubemock.NewSimpleClientset()
 
-	f, err := watchAll(context.Background(), kubeClient, hubClient, traefikClient, "v1.20.1", "cluster-id")
+	f, err := watchAll(context.Background(), kubeClient, hubClient, traefikClient, "v1.20.1", "cluster-id", nil)
 	require.NoError(t, err)
 
 	gotSvcs, gotNames, err := f.getServices("cluster-id", apps)
@@ -456,7 +469,7 @@ func TestFetcher_GetServiceLogs(t *testing.T) {
 	hubClient := hubkubemock.NewSimpleClientset()
 	traefikClient := traefikkubemock.NewSimpleClientset()
 
-	f, err := watchAll(context.Background(), kubeClient, hubClient, traefikClient, "v1.20.1", "cluster-id")
+	f, err := watchAll(context.Background(), kubeClient, hubClient, traefikClient, "v1.20.1", "cluster-id", nil)
 	require.NoError(t, err)
 
 	got, err := f.GetServiceLogs(context.Background(), "myns", "myService", 20, 200)
@@ -541,7 +554,7 @@ func TestFetcher_GetServiceLogsHandlesTooManyPods(t *testing.T) {
 	hubClient := hubkubemock.NewSimpleClientset()
 	traefikClient := traefikkubemock.NewSimpleClientset()
 
-	f, err := watchAll(context.Background(), kubeClient, hubClient, traefikClient, "v1.20.1", "cluster-id")
+	f, err := watchAll(context.Background(), kubeClient, hubClient, traefikClient, "v1.20.1", "cluster-id", nil)
 	require.NoError(t, err)
 
 	got, err := f.GetServiceLogs(context.Background(), "myns", "myService", 2, 200)
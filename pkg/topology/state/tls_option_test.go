@@ -86,7 +86,7 @@ func TestFetcher_GetTLSOptions(t *testing.T) {
 		},
 	}...)
 
-	f, err := watchAll(context.Background(), kubeClient, hubClient, traefikClient, "v1.20.1", "cluster-id")
+	f, err := watchAll(context.Background(), kubeClient, hubClient, traefikClient, "v1.20.1", "cluster-id", nil)
 	require.NoError(t, err)
 
 	got, err := f.getTLSOptions()
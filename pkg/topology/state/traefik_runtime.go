@@ -0,0 +1,158 @@
+/*
+Copyright (C) 2022 Traefik Labs
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package state
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// runtimeCollector periodically captures the runtime configuration exposed by the API of Traefik ingress controllers.
+// It runs independently of the topology fetch, which only reads the last captured configurations, so a slow or
+// unresponsive Traefik API never delays the topology.
+type runtimeCollector struct {
+	client   *http.Client
+	port     string
+	interval time.Duration
+
+	mu       sync.RWMutex
+	targets  map[string]runtimeTarget
+	runtimes map[string]*TraefikRuntime
+}
+
+// runtimeTarget is a Traefik pod whose API is queried.
+type runtimeTarget struct {
+	pod       string
+	namespace string
+	url       string
+}
+
+func newRuntimeCollector(port string, interval time.Duration) *runtimeCollector {
+	return &runtimeCollector{
+		client:   &http.Client{Timeout: 5 * time.Second},
+		port:     port,
+		interval: interval,
+		targets:  make(map[string]runtimeTarget),
+		runtimes: make(map[string]*TraefikRuntime),
+	}
+}
+
+// run captures the runtime configuration of the known targets at each interval until the given context is done.
+func (c *runtimeCollector) run(ctx context.Context) {
+	tick := time.NewTicker(c.interval)
+	defer tick.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-tick.C:
+			c.collectAll(ctx)
+		}
+	}
+}
+
+func (c *runtimeCollector) collectAll(ctx context.Context) {
+	c.mu.RLock()
+	targets := make([]runtimeTarget, 0, len(c.targets))
+	for _, target := range c.targets {
+		targets = append(targets, target)
+	}
+	c.mu.RUnlock()
+
+	runtimes := make(map[string]*TraefikRuntime, len(targets))
+	for _, target := range targets {
+		if ctx.Err() != nil {
+			return
+		}
+
+		runtime, err := c.getTraefikRuntime(ctx, target.url)
+		if err != nil {
+			log.Warn().Err(err).
+				Str("pod", target.pod).
+				Str("namespace", target.namespace).
+				Msg("Unable to capture Traefik runtime configuration")
+			continue
+		}
+
+		runtimes[target.url] = runtime
+	}
+
+	c.mu.Lock()
+	c.runtimes = runtimes
+	c.mu.Unlock()
+}
+
+func (c *runtimeCollector) getTraefikRuntime(ctx context.Context, apiURL string) (*TraefikRuntime, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, apiURL, http.NoBody)
+	if err != nil {
+		return nil, fmt.Errorf("build request: %w", err)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request %q: %w", apiURL, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("request %q: unexpected status code %d", apiURL, resp.StatusCode)
+	}
+
+	var runtime TraefikRuntime
+	if err = json.NewDecoder(resp.Body).Decode(&runtime); err != nil {
+		return nil, fmt.Errorf("decode Traefik runtime configuration: %w", err)
+	}
+
+	return &runtime, nil
+}
+
+// target returns the target querying the API of the given pod.
+func (c *runtimeCollector) target(pod *corev1.Pod) runtimeTarget {
+	return runtimeTarget{
+		pod:       pod.Name,
+		namespace: pod.Namespace,
+		url:       fmt.Sprintf("http://%s/api/rawdata", net.JoinHostPort(pod.Status.PodIP, c.port)),
+	}
+}
+
+// setTargets replaces the queried targets, indexed by ingress controller key, and returns the last captured runtime
+// configuration of each of them. Targets not captured yet are left out.
+func (c *runtimeCollector) setTargets(targets map[string]runtimeTarget) map[string]*TraefikRuntime {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.targets = targets
+
+	runtimes := make(map[string]*TraefikRuntime, len(targets))
+	for key, target := range targets {
+		if runtime, ok := c.runtimes[target.url]; ok {
+			runtimes[key] = runtime
+		}
+	}
+
+	return runtimes
+}
@@ -0,0 +1,221 @@
+/*
+Copyright (C) 2022 Traefik Labs
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package state
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	hubkubemock "github.com/traefik/hub-agent-kubernetes/pkg/crd/generated/client/hub/clientset/versioned/fake"
+	traefikkubemock "github.com/traefik/hub-agent-kubernetes/pkg/crd/generated/client/traefik/clientset/versioned/fake"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubemock "k8s.io/client-go/kubernetes/fake"
+)
+
+func TestRuntimeCollector_collectAll(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/rawdata", func(rw http.ResponseWriter, req *http.Request) {
+		_, _ = rw.Write([]byte(`{
+			"routers": {
+				"myns-myingress@kubernetes": {
+					"entryPoints": ["websecure"],
+					"middlewares": ["myns-auth@kubernetescrd"],
+					"service": "myns-mysvc-80",
+					"rule": "Host(` + "`foo.bar`" + `)",
+					"status": "enabled",
+					"using": ["websecure"]
+				}
+			},
+			"middlewares": {
+				"myns-auth@kubernetescrd": {
+					"forwardAuth": {"address": "http://auth"},
+					"status": "disabled",
+					"error": ["boom"],
+					"usedBy": ["myns-myingress@kubernetes"]
+				}
+			},
+			"services": {
+				"myns-mysvc-80@kubernetes": {
+					"loadBalancer": {"servers": [{"url": "http://10.0.0.1:80"}]},
+					"status": "enabled",
+					"usedBy": ["myns-myingress@kubernetes"],
+					"serverStatus": {"http://10.0.0.1:80": "UP"}
+				}
+			}
+		}`))
+	})
+
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	srvURL, err := url.Parse(srv.URL)
+	require.NoError(t, err)
+
+	host, port, err := net.SplitHostPort(srvURL.Host)
+	require.NoError(t, err)
+
+	c := newRuntimeCollector(port, time.Minute)
+	c.client = srv.Client()
+
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "traefik", Namespace: "myns"},
+		Status:     corev1.PodStatus{PodIP: host},
+	}
+	targets := map[string]runtimeTarget{"traefik@myns": c.target(pod)}
+
+	assert.Empty(t, c.setTargets(targets))
+
+	c.collectAll(context.Background())
+
+	got := c.setTargets(targets)
+
+	want := map[string]*TraefikRuntime{"traefik@myns": {
+		Routers: map[string]TraefikRouter{
+			"myns-myingress@kubernetes": {
+				EntryPoints: []string{"websecure"},
+				Middlewares: []string{"myns-auth@kubernetescrd"},
+				Service:     "myns-mysvc-80",
+				Rule:        "Host(`foo.bar`)",
+				Status:      "enabled",
+			},
+		},
+		Services: map[string]TraefikService{
+			"myns-mysvc-80@kubernetes": {
+				Status:       "enabled",
+				UsedBy:       []string{"myns-myingress@kubernetes"},
+				ServerStatus: map[string]string{"http://10.0.0.1:80": "UP"},
+			},
+		},
+		Middlewares: map[string]TraefikMiddleware{
+			"myns-auth@kubernetescrd": {
+				Status: "disabled",
+				Errors: []string{"boom"},
+				UsedBy: []string{"myns-myingress@kubernetes"},
+			},
+		},
+	}}
+	assert.Equal(t, want, got)
+}
+
+func TestRuntimeCollector_collectAll_unavailable(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	t.Cleanup(srv.Close)
+
+	srvURL, err := url.Parse(srv.URL)
+	require.NoError(t, err)
+
+	host, port, err := net.SplitHostPort(srvURL.Host)
+	require.NoError(t, err)
+
+	c := newRuntimeCollector(port, time.Minute)
+	c.client = srv.Client()
+
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "traefik", Namespace: "myns"},
+		Status:     corev1.PodStatus{PodIP: host},
+	}
+	targets := map[string]runtimeTarget{"traefik@myns": c.target(pod)}
+	c.setTargets(targets)
+
+	c.collectAll(context.Background())
+
+	assert.Empty(t, c.setTargets(targets))
+}
+
+func TestFetcher_getIngressControllers_hangingTraefikAPI(t *testing.T) {
+	requested := make(chan struct{}, 1)
+	release := make(chan struct{})
+
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		select {
+		case requested <- struct{}{}:
+		default:
+		}
+
+		select {
+		case <-release:
+		case <-req.Context().Done():
+		}
+	}))
+	t.Cleanup(srv.Close)
+	t.Cleanup(func() { close(release) })
+
+	srvURL, err := url.Parse(srv.URL)
+	require.NoError(t, err)
+
+	host, port, err := net.SplitHostPort(srvURL.Host)
+	require.NoError(t, err)
+
+	objects := loadK8sObjects(t, filepath.Join("fixtures", "ingress-controller", "one-ingress-controller-from-deployment.yml"))
+	for _, object := range objects {
+		if pod, ok := object.(*corev1.Pod); ok {
+			pod.Status.PodIP = host
+		}
+	}
+
+	kubeClient := kubemock.NewSimpleClientset(objects...)
+	hubClient := hubkubemock.NewSimpleClientset()
+	traefikClient := traefikkubemock.NewSimpleClientset()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	f, err := watchAll(ctx, kubeClient, hubClient, traefikClient, "v1.20.1", "cluster-id", nil)
+	require.NoError(t, err)
+
+	f.runtimes = newRuntimeCollector(port, 10*time.Millisecond)
+	go f.runtimes.run(ctx)
+
+	apps := map[string]*App{
+		"Deployment/myApp@myns": {
+			Name:      "myApp",
+			Namespace: "myns",
+			Kind:      "Deployment",
+			podLabels: map[string]string{"my.label": "foo"},
+		},
+	}
+
+	got, err := f.getIngressControllers(map[string]*Service{}, apps)
+	require.NoError(t, err)
+	require.Contains(t, got, "myApp@myns")
+	assert.Nil(t, got["myApp@myns"].Runtime)
+
+	select {
+	case <-requested:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Traefik API not requested")
+	}
+
+	// The Traefik API is now hanging: getting the ingress controllers must not wait for it.
+	start := time.Now()
+	got, err = f.getIngressControllers(map[string]*Service{}, apps)
+	require.NoError(t, err)
+	assert.Less(t, time.Since(start), time.Second)
+
+	require.Contains(t, got, "myApp@myns")
+	assert.Nil(t, got["myApp@myns"].Runtime)
+}
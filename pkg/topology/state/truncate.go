@@ -0,0 +1,113 @@
+/*
+Copyright (C) 2022 Traefik Labs
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package state
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Truncatable topology sections, in the order in which they are dropped when the topology exceeds its size budget.
+const (
+	SectionTraefikRuntime     = "ingressControllers.runtime"
+	SectionReachabilityProbes = "reachabilityProbes"
+	SectionAnnotations        = "annotations"
+	SectionLabels             = "apps.labels"
+	SectionImages             = "apps.images"
+)
+
+type truncation struct {
+	section string
+	apply   func(c *Cluster)
+}
+
+var truncations = []truncation{
+	{section: SectionTraefikRuntime, apply: func(c *Cluster) {
+		for _, ic := range c.IngressControllers {
+			ic.Runtime = nil
+		}
+	}},
+	{section: SectionReachabilityProbes, apply: func(c *Cluster) {
+		c.ReachabilityProbes = nil
+	}},
+	{section: SectionAnnotations, apply: func(c *Cluster) {
+		for _, ing := range c.Ingresses {
+			ing.Annotations = nil
+		}
+		for _, ingRoute := range c.IngressRoutes {
+			ingRoute.Annotations = nil
+		}
+		for _, svc := range c.Services {
+			svc.Annotations = nil
+		}
+	}},
+	{section: SectionLabels, apply: func(c *Cluster) {
+		for _, app := range c.Apps {
+			app.Labels = nil
+		}
+		for _, ic := range c.IngressControllers {
+			ic.Labels = nil
+		}
+	}},
+	{section: SectionImages, apply: func(c *Cluster) {
+		for _, app := range c.Apps {
+			app.Images = nil
+		}
+		for _, ic := range c.IngressControllers {
+			ic.Images = nil
+		}
+	}},
+}
+
+// Truncate drops lower-priority data from the given cluster until its marshaled size fits in the given budget, in bytes.
+// The dropped sections are recorded in the cluster overview and returned. The cluster is left as is if budget is zero.
+// If the cluster still exceeds the budget once every truncatable section has been dropped, it is returned as is,
+// since failing the whole synchronization is worse than sending an oversized topology.
+func Truncate(c *Cluster, budget int) ([]string, error) {
+	if budget <= 0 {
+		return nil, nil
+	}
+
+	var truncated []string
+	for _, t := range truncations {
+		size, err := marshaledSize(c)
+		if err != nil {
+			return nil, err
+		}
+
+		if size <= budget {
+			break
+		}
+
+		t.apply(c)
+		truncated = append(truncated, t.section)
+	}
+
+	c.Overview.Truncated = truncated
+
+	return truncated, nil
+}
+
+func marshaledSize(c *Cluster) (int, error) {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return 0, fmt.Errorf("marshal cluster: %w", err)
+	}
+
+	return len(data), nil
+}
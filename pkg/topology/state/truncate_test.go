@@ -0,0 +1,110 @@
+/*
+Copyright (C) 2022 Traefik Labs
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package state
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTruncate(t *testing.T) {
+	newCluster := func() *Cluster {
+		return &Cluster{
+			ID: "cluster-id",
+			Apps: map[string]*App{
+				"deployment/app@ns": {
+					Name:   "app",
+					Images: []string{"image:" + strings.Repeat("x", 100)},
+					Labels: map[string]string{"label": strings.Repeat("x", 100)},
+				},
+			},
+			Services: map[string]*Service{
+				"svc@ns": {
+					Name:        "svc",
+					Annotations: map[string]string{"annotation": strings.Repeat("x", 100)},
+				},
+			},
+			IngressControllers: map[string]*IngressController{
+				"traefik@ns": {
+					App:     App{Name: "traefik"},
+					Runtime: &TraefikRuntime{Routers: map[string]TraefikRouter{"router": {Rule: strings.Repeat("x", 100)}}},
+				},
+			},
+		}
+	}
+
+	fullSize, err := marshaledSize(newCluster())
+	require.NoError(t, err)
+
+	tests := []struct {
+		desc   string
+		budget int
+		want   []string
+	}{
+		{
+			desc:   "disabled",
+			budget: 0,
+		},
+		{
+			desc:   "fits in the budget",
+			budget: fullSize,
+		},
+		{
+			desc:   "drops the runtime only",
+			budget: fullSize - 100,
+			want:   []string{SectionTraefikRuntime},
+		},
+		{
+			desc:   "drops up to the annotations",
+			budget: fullSize - 250,
+			want:   []string{SectionTraefikRuntime, SectionReachabilityProbes, SectionAnnotations},
+		},
+		{
+			desc:   "drops everything possible",
+			budget: 1,
+			want:   []string{SectionTraefikRuntime, SectionReachabilityProbes, SectionAnnotations, SectionLabels, SectionImages},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			cluster := newCluster()
+
+			got, err := Truncate(cluster, test.budget)
+			require.NoError(t, err)
+
+			assert.Equal(t, test.want, got)
+			assert.Equal(t, test.want, cluster.Overview.Truncated)
+
+			if test.budget > 1 {
+				// The truncation record itself is not accounted in the budget.
+				cluster.Overview.Truncated = nil
+
+				size, err := marshaledSize(cluster)
+				require.NoError(t, err)
+				assert.LessOrEqual(t, size, test.budget)
+			}
+		})
+	}
+}
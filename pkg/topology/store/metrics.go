@@ -0,0 +1,84 @@
+/*
+Copyright (C) 2022 Traefik Labs
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package store
+
+import "github.com/prometheus/client_golang/prometheus"
+
+const (
+	metricsNamespace = "hub_agent"
+	metricsSubsystem = "topology"
+)
+
+var (
+	topologySizeBytes = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Subsystem: metricsSubsystem,
+		Name:      "size_bytes",
+		Help:      "Size in bytes of the last written topology.",
+	})
+	buildDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Subsystem: metricsSubsystem,
+		Name:      "build_duration_seconds",
+		Help:      "Time taken to write the topology files.",
+		Buckets:   prometheus.DefBuckets,
+	})
+	pushDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Subsystem: metricsSubsystem,
+		Name:      "push_duration_seconds",
+		Help:      "Time taken to push the topology, retries included.",
+		Buckets:   []float64{0.1, 0.5, 1, 2.5, 5, 10, 30, 60, 120},
+	})
+	pushRetriesTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Subsystem: metricsSubsystem,
+		Name:      "push_retries_total",
+		Help:      "Number of times pushing the topology has been retried.",
+	})
+	pushConflictsTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Subsystem: metricsSubsystem,
+		Name:      "push_conflicts_total",
+		Help:      "Number of topology pushes rejected because the remote branch has diverged.",
+	})
+	pushGiveUpsTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Subsystem: metricsSubsystem,
+		Name:      "push_give_ups_total",
+		Help:      "Number of times pushing the topology has been given up after exhausting retries.",
+	})
+	lastSyncTimestamp = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Subsystem: metricsSubsystem,
+		Name:      "last_sync_timestamp_seconds",
+		Help:      "Unix timestamp of the last successful topology synchronization.",
+	})
+)
+
+func init() {
+	prometheus.MustRegister(
+		topologySizeBytes,
+		buildDuration,
+		pushDuration,
+		pushRetriesTotal,
+		pushConflictsTotal,
+		pushGiveUpsTotal,
+		lastSyncTimestamp,
+	)
+}
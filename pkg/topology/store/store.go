@@ -19,9 +19,11 @@ package store
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
+	"strconv"
 	"strings"
 	"time"
 
@@ -32,6 +34,8 @@ import (
 	"github.com/ldez/go-git-cmd-wrapper/v2/types"
 	"github.com/rs/zerolog/log"
 	"github.com/traefik/hub-agent-kubernetes/pkg/platform"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
 )
 
 // Config represents the topology store config.
@@ -39,33 +43,108 @@ type Config struct {
 	platform.TopologyConfig
 
 	Token string
+	Retry RetryConfig
+	Git   GitConfig
+
+	// WriteTimeout is the overall deadline of a topology write, retries included. There is no deadline when zero.
+	WriteTimeout time.Duration
+
+	// Recorder records warning events about the store, attached to AgentRef.
+	Recorder record.EventRecorder
+	AgentRef *corev1.ObjectReference
+}
+
+// GitConfig configures the Git repository the topology is pushed to.
+// By default, the topology is pushed over HTTPS to the repository provided by the platform.
+type GitConfig struct {
+	// RemoteURL is the URL of the repository to push the topology to, instead of the platform one.
+	RemoteURL string
+	// Branch is the branch the topology is pushed to. It defaults to the cluster ID.
+	Branch string
+	// SSHKeyFile is the path of the private key used to authenticate against an SSH remote.
+	SSHKeyFile string
+	// SSHKnownHostsFile is the path of the known_hosts file used to verify the SSH remote host key.
+	SSHKnownHostsFile string
+	// CloneDepth is the depth of the clone of the repository. Zero means a full clone.
+	CloneDepth int
+
+	// AuthorName is the name of the author of the topology commits.
+	AuthorName string
+	// AuthorEmail is the email of the author of the topology commits.
+	AuthorEmail string
+	// SigningFormat is the format of the key used to sign commits: "openpgp" or "ssh".
+	SigningFormat string
+	// SigningKeyFile is the path of the key used to sign commits. Commits are not signed when empty.
+	// OpenPGP keys are imported in the GPG keyring and selected by Git from the author email.
+	SigningKeyFile string
+}
+
+// Commit signing formats.
+const (
+	SigningFormatOpenPGP = "openpgp"
+	SigningFormatSSH     = "ssh"
+)
+
+// RetryConfig configures how the store retries failing operations against the topology repository.
+type RetryConfig struct {
+	// MaxWriteRetries is the number of times pushing the topology is retried before giving up.
+	MaxWriteRetries int
+	// InitialInterval is the interval before the first retry. It grows exponentially between retries.
+	InitialInterval time.Duration
+	// MaxElapsedTime is the time after which retrying stops. Zero means retrying never stops.
+	MaxElapsedTime time.Duration
+}
+
+// DefaultRetryConfig returns the default retry configuration.
+func DefaultRetryConfig() RetryConfig {
+	return RetryConfig{
+		MaxWriteRetries: 3,
+		InitialInterval: time.Second,
+		MaxElapsedTime:  15 * time.Minute,
+	}
 }
 
 // Store stores a state in a Git repository.
 type Store struct {
+	gitCfg      GitConfig
 	gitRepo     string
+	gitBranch   string
+	gitDepth    int
 	gitExecutor types.Executor
+	cloneEnv    []string
 	workingDir  string
+	retry       RetryConfig
+
+	writeTimeout time.Duration
+
+	recorder record.EventRecorder
+	agentRef *corev1.ObjectReference
 }
 
 // New instantiates a new Store.
 func New(ctx context.Context, cfg Config) (*Store, error) {
-	repoURL := fmt.Sprintf("https://%s:@%s/%s/%s.git", cfg.Token, cfg.GitProxyHost, cfg.GitOrgName, cfg.GitRepoName)
-
-	s := &Store{
-		gitRepo:    repoURL,
-		workingDir: cfg.GitRepoName,
-		gitExecutor: func(ctx context.Context, name string, debug bool, args ...string) (string, error) {
-			cmd := exec.CommandContext(ctx, name, args...)
-			cmd.Dir = cfg.GitRepoName
-
-			out, err := cmd.CombinedOutput()
-			output := string(out)
+	repoURL := cfg.Git.RemoteURL
+	if repoURL == "" {
+		repoURL = fmt.Sprintf("https://%s:@%s/%s/%s.git", cfg.Token, cfg.GitProxyHost, cfg.GitOrgName, cfg.GitRepoName)
+	}
 
-			log.Trace().Str("cmd", name).Strs("args", args).Str("output", output).Send()
+	env, err := gitEnv(cfg.Git)
+	if err != nil {
+		return nil, err
+	}
 
-			return output, err
-		},
+	s := &Store{
+		gitRepo:      repoURL,
+		gitBranch:    cfg.Git.Branch,
+		gitDepth:     cfg.Git.CloneDepth,
+		gitCfg:       cfg.Git,
+		workingDir:   cfg.GitRepoName,
+		retry:        cfg.Retry,
+		writeTimeout: cfg.WriteTimeout,
+		recorder:     cfg.Recorder,
+		agentRef:     cfg.AgentRef,
+		cloneEnv:     env,
+		gitExecutor:  newGitExecutor(cfg.GitRepoName, env),
 	}
 
 	if err := s.cloneRepository(ctx); err != nil {
@@ -75,6 +154,71 @@ func New(ctx context.Context, cfg Config) (*Store, error) {
 	return s, nil
 }
 
+// newGitExecutor returns a Git executor running commands in the given directory with the given additional environment.
+func newGitExecutor(dir string, env []string) types.Executor {
+	return func(ctx context.Context, name string, debug bool, args ...string) (string, error) {
+		cmd := exec.CommandContext(ctx, name, args...)
+		cmd.Dir = dir
+		if len(env) > 0 {
+			cmd.Env = append(os.Environ(), env...)
+		}
+
+		out, err := cmd.CombinedOutput()
+		output := string(out)
+
+		log.Trace().Str("cmd", name).Strs("args", args).Str("output", output).Send()
+
+		return output, err
+	}
+}
+
+// gitEnv returns the environment needed by Git to authenticate against the configured remote, and to verify its host
+// key when it's an SSH remote.
+func gitEnv(cfg GitConfig) ([]string, error) {
+	if cfg.SSHKeyFile == "" && !isSSHURL(cfg.RemoteURL) {
+		return nil, nil
+	}
+
+	// The agent has no persistent known_hosts file: without one, the host key of the remote would be trusted again on
+	// every restart.
+	if cfg.SSHKnownHostsFile == "" {
+		return nil, errors.New("an SSH known hosts file is required to verify the host key of the SSH remote")
+	}
+
+	if _, err := os.Stat(cfg.SSHKnownHostsFile); err != nil {
+		return nil, fmt.Errorf("stat SSH known hosts file: %w", err)
+	}
+
+	sshCmd := "ssh"
+	if cfg.SSHKeyFile != "" {
+		if _, err := os.Stat(cfg.SSHKeyFile); err != nil {
+			return nil, fmt.Errorf("stat SSH key file: %w", err)
+		}
+
+		sshCmd += fmt.Sprintf(" -i %s -o IdentitiesOnly=yes", cfg.SSHKeyFile)
+	}
+	sshCmd += fmt.Sprintf(" -o UserKnownHostsFile=%s -o StrictHostKeyChecking=yes", cfg.SSHKnownHostsFile)
+
+	return []string{"GIT_SSH_COMMAND=" + sshCmd}, nil
+}
+
+// isSSHURL reports whether the given Git remote URL is an SSH one, either an ssh:// URL or an scp-like address such as
+// git@github.com:org/repo.git.
+func isSSHURL(rawURL string) bool {
+	if strings.HasPrefix(rawURL, "ssh://") || strings.HasPrefix(rawURL, "git+ssh://") {
+		return true
+	}
+
+	if strings.Contains(rawURL, "://") {
+		return false
+	}
+
+	colon := strings.Index(rawURL, ":")
+	slash := strings.Index(rawURL, "/")
+
+	return colon > 0 && (slash < 0 || colon < slash)
+}
+
 func (s *Store) cloneRepository(ctx context.Context) error {
 	if disableGitSSLVerify() {
 		output, err := git.Config(config.Global, config.Add("http.sslVerify", "false"))
@@ -85,14 +229,9 @@ func (s *Store) cloneRepository(ctx context.Context) error {
 	}
 
 	// Since repository creation is asynchronous, it is possible that it is not created just yet, so retry a bit.
-	exp := backoff.NewExponentialBackOff()
-	exp.InitialInterval = time.Second
-	exp.MaxInterval = 15 * time.Second
-	exp.RandomizationFactor = 0
-
 	if err := backoff.RetryNotify(func() error {
 		// Setup local repo for topology files, by cloning hub distant repository.
-		output, err := git.CloneWithContext(ctx, clone.Repository(s.gitRepo), clone.Depth("1"))
+		output, err := git.CloneWithContext(ctx, s.cloneOptions()...)
 		if err != nil {
 			switch {
 			case strings.Contains(output, "already exists and is not an empty directory"):
@@ -104,25 +243,101 @@ func (s *Store) cloneRepository(ctx context.Context) error {
 			}
 		}
 		return nil
-	}, exp, func(err error, retryIn time.Duration) {
+	}, backoff.WithContext(s.newBackOff(), ctx), func(err error, retryIn time.Duration) {
 		log.Warn().Err(err).Dur("retry_in", retryIn).Msg("Unable to clone topology repository")
 	}); err != nil {
 		return err
 	}
 
-	output, err := git.Config(config.Local, config.Add("user.email", "hubagent@traefik.io"), git.CmdExecutor(s.gitExecutor))
-	if err != nil {
-		return fmt.Errorf("%w: %s", err, output)
+	return s.configureRepository(ctx)
+}
+
+// configureRepository configures the commit author and signing in the local repository.
+func (s *Store) configureRepository(ctx context.Context) error {
+	authorName, authorEmail := s.gitCfg.AuthorName, s.gitCfg.AuthorEmail
+	if authorName == "" {
+		authorName = "Hub Agent"
+	}
+	if authorEmail == "" {
+		authorEmail = "hubagent@traefik.io"
 	}
 
-	output, err = git.Config(config.Local, config.Add("user.name", "Hub Agent"), git.CmdExecutor(s.gitExecutor))
-	if err != nil {
-		return fmt.Errorf("%w: %s", err, output)
+	settings := [][2]string{
+		{"user.email", authorEmail},
+		{"user.name", authorName},
+	}
+
+	switch s.gitCfg.SigningFormat {
+	case "", SigningFormatOpenPGP:
+		if s.gitCfg.SigningKeyFile == "" {
+			break
+		}
+
+		output, err := s.gitExecutor(ctx, "gpg", false, "--batch", "--import", s.gitCfg.SigningKeyFile)
+		if err != nil {
+			return fmt.Errorf("import signing key: %w: %s", err, output)
+		}
+
+		// Git selects the signing key from the author email, check it matches now rather than failing on the first commit.
+		output, err = s.gitExecutor(ctx, "gpg", false, "--batch", "--with-colons", "--list-secret-keys", "<"+authorEmail+">")
+		if err != nil {
+			return fmt.Errorf("no OpenPGP signing key with a UID matching the author email %q: %w: %s", authorEmail, err, output)
+		}
+
+		settings = append(settings, [2]string{"commit.gpgsign", "true"})
+	case SigningFormatSSH:
+		if s.gitCfg.SigningKeyFile == "" {
+			return errors.New("SSH commit signing requires a signing key")
+		}
+
+		settings = append(settings,
+			[2]string{"gpg.format", "ssh"},
+			[2]string{"user.signingkey", s.gitCfg.SigningKeyFile},
+			[2]string{"commit.gpgsign", "true"},
+		)
+	default:
+		return fmt.Errorf("unsupported signing format %q", s.gitCfg.SigningFormat)
+	}
+
+	for _, setting := range settings {
+		output, err := git.Config(config.Local, config.Entry(setting[0], setting[1]), git.CmdExecutor(s.gitExecutor))
+		if err != nil {
+			return fmt.Errorf("%w: %s", err, output)
+		}
 	}
 
 	return nil
 }
 
+func (s *Store) cloneOptions() []types.Option {
+	opts := []types.Option{clone.Repository(s.gitRepo), clone.Directory(s.workingDir), git.CmdExecutor(newGitExecutor("", s.cloneEnv))}
+	if s.gitDepth > 0 {
+		opts = append(opts, clone.Depth(strconv.Itoa(s.gitDepth)))
+	}
+
+	return opts
+}
+
+// branch returns the branch the topology of the given cluster is pushed to.
+func (s *Store) branch(clusterID string) string {
+	if s.gitBranch != "" {
+		return s.gitBranch
+	}
+
+	return clusterID
+}
+
+func (s *Store) newBackOff() *backoff.ExponentialBackOff {
+	exp := backoff.NewExponentialBackOff()
+	exp.InitialInterval = s.retry.InitialInterval
+	exp.MaxInterval = 15 * time.Second
+	exp.MaxElapsedTime = s.retry.MaxElapsedTime
+	exp.RandomizationFactor = 0
+	exp.Reset()
+
+	return exp
+}
+
 func disableGitSSLVerify() bool {
 	_, exists := os.LookupEnv("DISABLE_GIT_SSL_VERIFY")
 	return exists
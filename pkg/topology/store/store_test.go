@@ -0,0 +1,215 @@
+/*
+Copyright (C) 2022 Traefik Labs
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package store
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_gitEnv(t *testing.T) {
+	keyFile := filepath.Join(t.TempDir(), "id_ed25519")
+	require.NoError(t, os.WriteFile(keyFile, []byte("key"), 0o600))
+
+	knownHostsFile := filepath.Join(t.TempDir(), "known_hosts")
+	require.NoError(t, os.WriteFile(knownHostsFile, []byte("github.com ssh-ed25519 AAAA"), 0o600))
+
+	tests := []struct {
+		desc    string
+		cfg     GitConfig
+		want    []string
+		wantErr assert.ErrorAssertionFunc
+	}{
+		{
+			desc:    "no SSH key",
+			cfg:     GitConfig{},
+			wantErr: assert.NoError,
+		},
+		{
+			desc:    "HTTPS remote",
+			cfg:     GitConfig{RemoteURL: "https://github.com/org/topology.git"},
+			wantErr: assert.NoError,
+		},
+		{
+			desc: "SSH key with known hosts",
+			cfg: GitConfig{
+				SSHKeyFile:        keyFile,
+				SSHKnownHostsFile: knownHostsFile,
+			},
+			want:    []string{"GIT_SSH_COMMAND=ssh -i " + keyFile + " -o IdentitiesOnly=yes -o UserKnownHostsFile=" + knownHostsFile + " -o StrictHostKeyChecking=yes"},
+			wantErr: assert.NoError,
+		},
+		{
+			desc: "SSH remote without key",
+			cfg: GitConfig{
+				RemoteURL:         "git@github.com:org/topology.git",
+				SSHKnownHostsFile: knownHostsFile,
+			},
+			want:    []string{"GIT_SSH_COMMAND=ssh -o UserKnownHostsFile=" + knownHostsFile + " -o StrictHostKeyChecking=yes"},
+			wantErr: assert.NoError,
+		},
+		{
+			desc:    "SSH key without known hosts",
+			cfg:     GitConfig{SSHKeyFile: keyFile},
+			wantErr: assert.Error,
+		},
+		{
+			desc:    "SSH remote without known hosts",
+			cfg:     GitConfig{RemoteURL: "ssh://git@github.com/org/topology.git"},
+			wantErr: assert.Error,
+		},
+		{
+			desc:    "missing known hosts",
+			cfg:     GitConfig{SSHKeyFile: keyFile, SSHKnownHostsFile: "/does/not/exist"},
+			wantErr: assert.Error,
+		},
+		{
+			desc:    "missing SSH key",
+			cfg:     GitConfig{SSHKeyFile: "/does/not/exist", SSHKnownHostsFile: knownHostsFile},
+			wantErr: assert.Error,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := gitEnv(test.cfg)
+			test.wantErr(t, err)
+
+			assert.Equal(t, test.want, got)
+		})
+	}
+}
+
+func TestStore_configureRepository(t *testing.T) {
+	tests := []struct {
+		desc     string
+		cfg      GitConfig
+		failCmd  string
+		wantCmds []string
+		wantErr  assert.ErrorAssertionFunc
+	}{
+		{
+			desc: "default author",
+			cfg:  GitConfig{},
+			wantCmds: []string{
+				"git config --local user.email hubagent@traefik.io",
+				"git config --local user.name Hub Agent",
+			},
+			wantErr: assert.NoError,
+		},
+		{
+			desc: "OpenPGP signing with custom author",
+			cfg: GitConfig{
+				AuthorName:     "Bot",
+				AuthorEmail:    "bot@example.com",
+				SigningKeyFile: "/keys/gpg.asc",
+			},
+			wantCmds: []string{
+				"gpg --batch --import /keys/gpg.asc",
+				"gpg --batch --with-colons --list-secret-keys <bot@example.com>",
+				"git config --local user.email bot@example.com",
+				"git config --local user.name Bot",
+				"git config --local commit.gpgsign true",
+			},
+			wantErr: assert.NoError,
+		},
+		{
+			desc: "OpenPGP signing key not matching the author",
+			cfg: GitConfig{
+				AuthorEmail:    "bot@example.com",
+				SigningKeyFile: "/keys/gpg.asc",
+			},
+			failCmd: "gpg --batch --with-colons --list-secret-keys <bot@example.com>",
+			wantCmds: []string{
+				"gpg --batch --import /keys/gpg.asc",
+				"gpg --batch --with-colons --list-secret-keys <bot@example.com>",
+			},
+			wantErr: assert.Error,
+		},
+		{
+			desc: "SSH signing",
+			cfg: GitConfig{
+				SigningFormat:  SigningFormatSSH,
+				SigningKeyFile: "/keys/id_ed25519",
+			},
+			wantCmds: []string{
+				"git config --local user.email hubagent@traefik.io",
+				"git config --local user.name Hub Agent",
+				"git config --local gpg.format ssh",
+				"git config --local user.signingkey /keys/id_ed25519",
+				"git config --local commit.gpgsign true",
+			},
+			wantErr: assert.NoError,
+		},
+		{
+			desc:    "SSH signing without key",
+			cfg:     GitConfig{SigningFormat: SigningFormatSSH},
+			wantErr: assert.Error,
+		},
+		{
+			desc:    "unsupported signing format",
+			cfg:     GitConfig{SigningFormat: "x509"},
+			wantErr: assert.Error,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			var cmds []string
+			s := &Store{
+				gitCfg: test.cfg,
+				gitExecutor: func(_ context.Context, name string, _ bool, args ...string) (string, error) {
+					cmd := name + " " + strings.Join(args, " ")
+					cmds = append(cmds, cmd)
+					if cmd == test.failCmd {
+						return "gpg: error reading key: No secret key", errors.New("exit status 2")
+					}
+					return "", nil
+				},
+			}
+
+			err := s.configureRepository(context.Background())
+			test.wantErr(t, err)
+
+			assert.Equal(t, test.wantCmds, cmds)
+		})
+	}
+}
+
+func Test_isSSHURL(t *testing.T) {
+	assert.True(t, isSSHURL("git@github.com:org/topology.git"))
+	assert.True(t, isSSHURL("ssh://git@github.com/org/topology.git"))
+	assert.True(t, isSSHURL("git+ssh://git@github.com/org/topology.git"))
+	assert.False(t, isSSHURL("https://github.com/org/topology.git"))
+	assert.False(t, isSSHURL("/var/lib/topology.git"))
+	assert.False(t, isSSHURL("./topology:repo"))
+	assert.False(t, isSSHURL(""))
+}
@@ -21,53 +21,77 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"reflect"
 	"strings"
 	"time"
 
+	"github.com/cenkalti/backoff/v4"
 	"github.com/ldez/go-git-cmd-wrapper/v2/add"
 	"github.com/ldez/go-git-cmd-wrapper/v2/branch"
 	"github.com/ldez/go-git-cmd-wrapper/v2/checkout"
 	"github.com/ldez/go-git-cmd-wrapper/v2/commit"
+	"github.com/ldez/go-git-cmd-wrapper/v2/fetch"
 	"github.com/ldez/go-git-cmd-wrapper/v2/git"
 	"github.com/ldez/go-git-cmd-wrapper/v2/pull"
 	"github.com/ldez/go-git-cmd-wrapper/v2/push"
+	"github.com/ldez/go-git-cmd-wrapper/v2/reset"
 	"github.com/rs/zerolog/log"
 	"github.com/traefik/hub-agent-kubernetes/pkg/topology/state"
+	corev1 "k8s.io/api/core/v1"
 )
 
 // Write writes the given cluster state in the current git repository.
+// The write is aborted, including any in-flight Git command, as soon as the given context is done
+// or the configured write timeout is reached.
 func (s *Store) Write(ctx context.Context, st *state.Cluster) error {
-	output, err := git.Branch(branch.List, branch.Format("%(refname:short)"), git.CmdExecutor(s.gitExecutor))
+	if s.writeTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.writeTimeout)
+		defer cancel()
+	}
+
+	branchName := s.branch(st.ID)
+
+	output, err := git.BranchWithContext(ctx, branch.List, branch.Format("%(refname:short)"), git.CmdExecutor(s.gitExecutor))
 	if err != nil {
 		return fmt.Errorf("list branches: %w %s", err, output)
 	}
 
-	if strings.Contains(output, st.ID) {
+	if strings.Contains(output, branchName) {
 		// The branch already exists.
-		output, err = git.CheckoutWithContext(ctx, checkout.Branch(st.ID), git.CmdExecutor(s.gitExecutor))
+		output, err = git.CheckoutWithContext(ctx, checkout.Branch(branchName), git.CmdExecutor(s.gitExecutor))
 		if err != nil {
 			return fmt.Errorf("checkout local branch: %w %s", err, output)
 		}
 	} else {
 		// Creating new branch from checkout.
-		output, err = git.CheckoutWithContext(ctx, checkout.NewBranch(st.ID), git.CmdExecutor(s.gitExecutor))
+		output, err = git.CheckoutWithContext(ctx, checkout.NewBranch(branchName), git.CmdExecutor(s.gitExecutor))
 		if err != nil {
 			return fmt.Errorf("checkout new local branch: %w %s", err, output)
 		}
 
-		output, err = git.PullWithContext(ctx, pull.FfOnly, pull.Repository("origin"), pull.Refspec(st.ID), git.CmdExecutor(s.gitExecutor))
-		if err != nil && !strings.Contains(output, fmt.Sprintf("couldn't find remote ref %s", st.ID)) {
+		output, err = git.PullWithContext(ctx, pull.FfOnly, pull.Repository("origin"), pull.Refspec(branchName), git.CmdExecutor(s.gitExecutor))
+		if err != nil && !strings.Contains(output, fmt.Sprintf("couldn't find remote ref %s", branchName)) {
 			return fmt.Errorf("git pull: %w: %s", err, output)
 		}
 	}
 
+	start := time.Now()
 	err = s.write(st)
 	if err != nil {
 		return err
 	}
+	buildDuration.Observe(time.Since(start).Seconds())
+
+	size, err := dirSize(s.workingDir)
+	if err != nil {
+		log.Warn().Err(err).Msg("Unable to compute topology size")
+	} else {
+		topologySizeBytes.Set(float64(size))
+	}
 
 	output, err = git.AddWithContext(ctx, add.PathSpec("./"), git.CmdExecutor(s.gitExecutor))
 	if err != nil {
@@ -77,20 +101,91 @@ func (s *Store) Write(ctx context.Context, st *state.Cluster) error {
 	output, err = git.CommitWithContext(ctx, commit.Message(time.Now().String()), git.CmdExecutor(s.gitExecutor))
 	if err != nil {
 		if strings.Contains(output, "nothing to commit") {
+			lastSyncTimestamp.SetToCurrentTime()
 			return nil
 		}
 
 		return fmt.Errorf("git commit: %w: %s", err, output)
 	}
 
-	output, err = git.PushWithContext(ctx, push.All, push.SetUpstream, git.CmdExecutor(s.gitExecutor))
+	return s.push(ctx, branchName)
+}
+
+// push pushes the local commits, retrying according to the store retry configuration.
+// When the push is rejected because the remote branch moved, the local commit is moved on top of it before retrying.
+func (s *Store) push(ctx context.Context, branchName string) error {
+	exp := backoff.WithContext(backoff.WithMaxRetries(s.newBackOff(), uint64(s.retry.MaxWriteRetries)), ctx)
+
+	start := time.Now()
+	defer func() { pushDuration.Observe(time.Since(start).Seconds()) }()
+
+	var attempts int
+	err := backoff.RetryNotify(func() error {
+		attempts++
+
+		output, err := git.PushWithContext(ctx, push.All, push.SetUpstream, git.CmdExecutor(s.gitExecutor))
+		if err != nil {
+			if strings.Contains(output, "[rejected]") {
+				pushConflictsTotal.Inc()
+
+				if rebaseErr := s.rebaseOnRemote(ctx, branchName); rebaseErr != nil {
+					log.Warn().Err(rebaseErr).Msg("Unable to rebase topology on the remote branch")
+				}
+			}
+
+			return fmt.Errorf("git push: %w: %s", err, output)
+		}
+
+		return nil
+	}, exp, func(err error, retryIn time.Duration) {
+		pushRetriesTotal.Inc()
+		log.Warn().Err(err).Dur("retry_in", retryIn).Msg("Unable to push topology")
+	})
 	if err != nil {
-		return fmt.Errorf("git push: %w: %s", err, output)
+		if ctx.Err() != nil {
+			return fmt.Errorf("push aborted: %w", err)
+		}
+
+		pushGiveUpsTotal.Inc()
+		log.Error().Err(err).Int("attempts", attempts).Msg("Giving up pushing topology")
+		s.recordEvent(corev1.EventTypeWarning, "TopologyPushFailed",
+			fmt.Sprintf("Giving up pushing topology after %d attempts: %v", attempts, err))
+		return err
 	}
 
+	lastSyncTimestamp.SetToCurrentTime()
+
 	return nil
 }
 
+// rebaseOnRemote moves the local commit on top of the remote branch, after another writer pushed to it.
+// Each commit holds the whole topology, so the local tree is committed as is on top of the remote branch
+// instead of being merged with it.
+func (s *Store) rebaseOnRemote(ctx context.Context, branchName string) error {
+	output, err := git.FetchWithContext(ctx, fetch.Remote("origin"), fetch.RefSpec(branchName), git.CmdExecutor(s.gitExecutor))
+	if err != nil {
+		return fmt.Errorf("git fetch: %w: %s", err, output)
+	}
+
+	output, err = git.ResetWithContext(ctx, reset.Soft, reset.Commit("FETCH_HEAD"), git.CmdExecutor(s.gitExecutor))
+	if err != nil {
+		return fmt.Errorf("git reset: %w: %s", err, output)
+	}
+
+	output, err = git.CommitWithContext(ctx, commit.Message(time.Now().String()), git.CmdExecutor(s.gitExecutor))
+	if err != nil && !strings.Contains(output, "nothing to commit") {
+		return fmt.Errorf("git commit: %w: %s", err, output)
+	}
+
+	return nil
+}
+
+func (s *Store) recordEvent(eventType, reason, msg string) {
+	if s.recorder != nil && s.agentRef != nil {
+		s.recorder.Event(s.agentRef, eventType, reason, msg)
+	}
+}
+
 // write writes the cluster resource into files.
 // It uses reflect to have a common way to create a file tree.
 // For each public cluster field a directory is created with the field name.
@@ -226,6 +321,33 @@ func writeFile(filePath string, data []byte) error {
 	return os.WriteFile(filePath, data, 0o600)
 }
 
+// dirSize returns the size in bytes of the files contained in the given directory, the Git directory excepted.
+func dirSize(dir string) (int64, error) {
+	var size int64
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if entry.IsDir() {
+			if entry.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		size += info.Size()
+
+		return nil
+	})
+
+	return size, err
+}
+
 func cleanDir(dir string) error {
 	entries, err := os.ReadDir(dir)
 	if err != nil {
@@ -25,11 +25,14 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/traefik/hub-agent-kubernetes/pkg/topology/state"
+	corev1 "k8s.io/api/core/v1"
 	netv1 "k8s.io/api/networking/v1"
+	"k8s.io/client-go/tools/record"
 )
 
 const (
@@ -86,6 +89,152 @@ func TestWrite_GitChanges(t *testing.T) {
 	assert.Equal(t, 1, pushCallCount)
 }
 
+func TestWrite_GitCustomBranch(t *testing.T) {
+	var checkoutArgs []string
+	s := &Store{
+		workingDir: t.TempDir(),
+		gitBranch:  "topology",
+		gitExecutor: func(_ context.Context, _ string, _ bool, args ...string) (string, error) {
+			if args[0] == "checkout" {
+				checkoutArgs = args
+			}
+			return "", nil
+		},
+	}
+
+	err := s.Write(context.Background(), &state.Cluster{ID: "myclusterID"})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"checkout", "-b", "topology"}, checkoutArgs)
+}
+
+func TestWrite_Timeout(t *testing.T) {
+	s := &Store{
+		workingDir:   t.TempDir(),
+		writeTimeout: 50 * time.Millisecond,
+		retry: RetryConfig{
+			MaxWriteRetries: 10,
+			InitialInterval: time.Minute,
+		},
+		gitExecutor: func(ctx context.Context, _ string, _ bool, args ...string) (string, error) {
+			if args[0] == pushCommand {
+				<-ctx.Done()
+				return "", ctx.Err()
+			}
+			return "", nil
+		},
+	}
+
+	start := time.Now()
+	err := s.Write(context.Background(), &state.Cluster{ID: "myclusterID"})
+	require.Error(t, err)
+
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
+
+func TestWrite_GitPushRetries(t *testing.T) {
+	tests := []struct {
+		desc          string
+		failingPushes int
+		wantErr       assert.ErrorAssertionFunc
+		wantPushCount int
+		wantEvents    int
+	}{
+		{
+			desc:          "succeeds after retrying",
+			failingPushes: 2,
+			wantErr:       assert.NoError,
+			wantPushCount: 3,
+		},
+		{
+			desc:          "gives up after max retries",
+			failingPushes: 10,
+			wantErr:       assert.Error,
+			wantPushCount: 3,
+			wantEvents:    1,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			recorder := record.NewFakeRecorder(10)
+
+			var pushCallCount int
+			s := &Store{
+				workingDir: t.TempDir(),
+				retry: RetryConfig{
+					MaxWriteRetries: 2,
+					InitialInterval: time.Millisecond,
+				},
+				recorder: recorder,
+				agentRef: &corev1.ObjectReference{Kind: "Pod", Name: "hub-agent", Namespace: "hub-agent"},
+				gitExecutor: func(_ context.Context, _ string, _ bool, args ...string) (string, error) {
+					if args[0] == pushCommand {
+						pushCallCount++
+						if pushCallCount <= test.failingPushes {
+							return "connection reset", errors.New("fake error")
+						}
+					}
+					return "", nil
+				},
+			}
+
+			err := s.Write(context.Background(), &state.Cluster{ID: "myclusterID"})
+			test.wantErr(t, err)
+
+			assert.Equal(t, test.wantPushCount, pushCallCount)
+			require.Len(t, recorder.Events, test.wantEvents)
+			for i := 0; i < test.wantEvents; i++ {
+				assert.True(t, strings.HasPrefix(<-recorder.Events, "Warning TopologyPushFailed Giving up pushing topology after 3 attempts"))
+			}
+		})
+	}
+}
+
+func TestWrite_GitPushRejected(t *testing.T) {
+	var (
+		pushCallCount int
+		calls         []string
+	)
+	s := &Store{
+		workingDir: t.TempDir(),
+		retry: RetryConfig{
+			MaxWriteRetries: 2,
+			InitialInterval: time.Millisecond,
+		},
+		gitExecutor: func(_ context.Context, _ string, _ bool, args ...string) (string, error) {
+			calls = append(calls, strings.Join(args, " "))
+
+			if args[0] == pushCommand {
+				pushCallCount++
+				if pushCallCount == 1 {
+					return " ! [rejected]        myclusterID -> myclusterID (fetch first)", errors.New("fake error")
+				}
+			}
+			return "", nil
+		},
+	}
+
+	err := s.Write(context.Background(), &state.Cluster{ID: "myclusterID"})
+	require.NoError(t, err)
+
+	assert.Equal(t, 2, pushCallCount)
+
+	require.GreaterOrEqual(t, len(calls), 5)
+	wantCalls := []string{
+		"push --all --set-upstream",
+		"fetch origin myclusterID",
+		"reset --soft FETCH_HEAD",
+	}
+	assert.Equal(t, wantCalls, calls[len(calls)-5:len(calls)-2])
+	assert.True(t, strings.HasPrefix(calls[len(calls)-2], "commit "))
+	assert.Equal(t, "push --all --set-upstream", calls[len(calls)-1])
+}
+
 func TestWrite_Apps(t *testing.T) {
 	tmpDir := t.TempDir()
 
@@ -19,33 +19,68 @@ package topology
 
 import (
 	"context"
+	"fmt"
+	"reflect"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/rs/zerolog/log"
+	"github.com/traefik/hub-agent-kubernetes/pkg/health"
 	"github.com/traefik/hub-agent-kubernetes/pkg/topology/state"
 	"github.com/traefik/hub-agent-kubernetes/pkg/topology/store"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
 )
 
 // ListenerFunc is a function called by the watcher with the
 // current state.
 type ListenerFunc func(ctx context.Context, state *state.Cluster)
 
+// WatcherConfig holds the watcher configuration.
+type WatcherConfig struct {
+	// WriteDebounce is the duration during which a changed state must remain stable before being written.
+	// Changes are written at each tick when zero.
+	WriteDebounce time.Duration
+	// WriteMaxStaleness is the maximum duration a changed state can wait before being written, whatever the debounce.
+	WriteMaxStaleness time.Duration
+
+	// FlushTimeout is the time given to write the pending state when the watcher stops. The pending state is dropped
+	// when zero.
+	FlushTimeout time.Duration
+
+	// SizeBudget is the maximum size, in bytes, of the marshaled topology. Beyond, lower-priority data is dropped.
+	// The size is not limited when zero.
+	SizeBudget int
+	// Recorder records warning events about the topology, attached to AgentRef.
+	Recorder record.EventRecorder
+	AgentRef *corev1.ObjectReference
+}
+
 // Watcher is a process from the Hub agent that watches the topology for changes and
 // stores them over time to make them accessible from the SaaS.
 type Watcher struct {
-	k8s   *state.Fetcher
-	store *store.Store
+	k8s       *state.Fetcher
+	store     *store.Store
+	debouncer *debouncer
+	config    WatcherConfig
+
+	lastTruncated []string
 
 	listenersMu sync.Mutex
 	listeners   []ListenerFunc
+
+	ready    health.Status
+	writeErr error
 }
 
 // NewWatcher instantiates a new watcher that uses a fetcher to periodically get the K8S state and a store to write it.
-func NewWatcher(f *state.Fetcher, s *store.Store) *Watcher {
+func NewWatcher(f *state.Fetcher, s *store.Store, cfg WatcherConfig) *Watcher {
 	return &Watcher{
-		k8s:   f,
-		store: s,
+		k8s:       f,
+		store:     s,
+		debouncer: newDebouncer(cfg.WriteDebounce, cfg.WriteMaxStaleness),
+		config:    cfg,
 	}
 }
 
@@ -57,6 +92,11 @@ func (w *Watcher) AddListener(listener ListenerFunc) {
 	w.listeners = append(w.listeners, listener)
 }
 
+// Ready reports whether the watcher is able to fetch and write the topology.
+func (w *Watcher) Ready(ctx context.Context) error {
+	return w.ready.Check(ctx)
+}
+
 // Start runs the watcher process.
 func (w *Watcher) Start(ctx context.Context) {
 	tick := time.NewTicker(5 * time.Second)
@@ -66,11 +106,13 @@ func (w *Watcher) Start(ctx context.Context) {
 		select {
 		case <-ctx.Done():
 			log.Info().Msg("Stopping topology watcher")
+			w.flush()
 			return
 		case <-tick.C:
 			s, err := w.k8s.FetchState()
 			if err != nil {
 				log.Error().Err(err).Msg("create state")
+				w.ready.Set(fmt.Errorf("fetch state: %w", err))
 				continue
 			}
 
@@ -80,9 +122,68 @@ func (w *Watcher) Start(ctx context.Context) {
 			}
 			w.listenersMu.Unlock()
 
+			w.truncate(s)
+
+			if !w.debouncer.shouldWrite(s, time.Now()) {
+				w.ready.Set(w.writeErr)
+				continue
+			}
+
 			if err = w.store.Write(ctx, s); err != nil {
 				log.Error().Err(err).Msg("commit cluster state changes")
+				w.writeErr = fmt.Errorf("write state: %w", err)
+				w.ready.Set(w.writeErr)
+				continue
 			}
+			w.writeErr = nil
+			w.ready.SetReady()
+
+			w.debouncer.written(s)
 		}
 	}
 }
+
+// flush writes the state which hasn't been written yet, if any, within the flush timeout.
+func (w *Watcher) flush() {
+	s := w.debouncer.pending()
+	if s == nil || w.config.FlushTimeout <= 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), w.config.FlushTimeout)
+	defer cancel()
+
+	if err := w.store.Write(ctx, s); err != nil {
+		log.Error().Err(err).Msg("Unable to flush cluster state changes")
+		return
+	}
+
+	w.debouncer.written(s)
+}
+
+// truncate drops lower-priority data from the given state if it exceeds the size budget,
+// and warns whenever the truncated sections change.
+func (w *Watcher) truncate(s *state.Cluster) {
+	truncated, err := state.Truncate(s, w.config.SizeBudget)
+	if err != nil {
+		log.Error().Err(err).Msg("Unable to truncate topology")
+		return
+	}
+
+	if reflect.DeepEqual(truncated, w.lastTruncated) {
+		return
+	}
+	w.lastTruncated = truncated
+
+	if len(truncated) == 0 {
+		log.Info().Msg("Topology fits in its size budget again")
+		return
+	}
+
+	msg := fmt.Sprintf("Topology exceeds its size budget of %d bytes, dropped sections: %s", w.config.SizeBudget, strings.Join(truncated, ", "))
+	log.Warn().Strs("sections", truncated).Int("budget", w.config.SizeBudget).Msg("Topology exceeds its size budget")
+
+	if w.config.Recorder != nil && w.config.AgentRef != nil {
+		w.config.Recorder.Event(w.config.AgentRef, corev1.EventTypeWarning, "TopologyTruncated", msg)
+	}
+}
@@ -0,0 +1,163 @@
+/*
+Copyright (C) 2022 Traefik Labs
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package tracing
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// traceParentHeader is the W3C Trace Context header propagating the parent span.
+const traceParentHeader = "traceparent"
+
+// Handler traces the requests served by the given handler with server spans named after the given name.
+// Spans are children of the span propagated by the traceparent header, if any.
+func Handler(name string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
+		if remote, ok := parseTraceParent(req.Header.Get(traceParentHeader)); ok {
+			ctx = context.WithValue(ctx, remoteSpanKey{}, remote)
+		}
+
+		ctx, span := start(ctx, name, spanKindServer)
+		if span == nil {
+			next.ServeHTTP(rw, req)
+			return
+		}
+		defer span.End()
+
+		span.SetAttribute("http.method", req.Method)
+		span.SetAttribute("http.target", req.URL.Path)
+
+		srw := &statusResponseWriter{ResponseWriter: rw, code: http.StatusOK}
+		next.ServeHTTP(srw, req.WithContext(ctx))
+
+		span.SetAttribute("http.status_code", srw.code)
+		if srw.code >= http.StatusInternalServerError {
+			span.RecordError(fmt.Errorf("status code %d", srw.code))
+		}
+	})
+}
+
+type statusResponseWriter struct {
+	http.ResponseWriter
+
+	code        int
+	wroteHeader bool
+}
+
+func (w *statusResponseWriter) WriteHeader(code int) {
+	if !w.wroteHeader {
+		w.code = code
+		w.wroteHeader = true
+	}
+
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *statusResponseWriter) Write(b []byte) (int, error) {
+	w.wroteHeader = true
+
+	return w.ResponseWriter.Write(b)
+}
+
+// Transport traces the requests sent through the given round tripper with client spans, and propagates them to
+// the server using the traceparent header.
+func Transport(next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		ctx, span := start(req.Context(), "HTTP "+req.Method, spanKindClient)
+		if span == nil {
+			return next.RoundTrip(req)
+		}
+		defer span.End()
+
+		span.SetAttribute("http.method", req.Method)
+		span.SetAttribute("http.url", req.URL.Scheme+"://"+req.URL.Host+req.URL.Path)
+
+		// Requests must not be modified by round trippers.
+		req = req.Clone(ctx)
+		req.Header.Set(traceParentHeader, formatTraceParent(span.Context()))
+
+		resp, err := next.RoundTrip(req)
+		if err != nil {
+			span.RecordError(err)
+			return nil, err
+		}
+
+		span.SetAttribute("http.status_code", resp.StatusCode)
+		if resp.StatusCode >= http.StatusInternalServerError {
+			span.RecordError(fmt.Errorf("status code %d", resp.StatusCode))
+		}
+
+		return resp, nil
+	})
+}
+
+type roundTripperFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// formatTraceParent formats the given span context as a traceparent header value: version-traceid-spanid-flags.
+func formatTraceParent(c SpanContext) string {
+	flags := "00"
+	if c.Sampled {
+		flags = "01"
+	}
+
+	return "00-" + hex.EncodeToString(c.TraceID[:]) + "-" + hex.EncodeToString(c.SpanID[:]) + "-" + flags
+}
+
+func parseTraceParent(value string) (SpanContext, bool) {
+	parts := strings.Split(strings.TrimSpace(value), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" {
+		return SpanContext{}, false
+	}
+	// Version 00 defines exactly four fields, later versions may add more.
+	if parts[0] == "00" && len(parts) != 4 {
+		return SpanContext{}, false
+	}
+
+	var c SpanContext
+	if len(parts[1]) != 2*len(c.TraceID) || len(parts[2]) != 2*len(c.SpanID) || len(parts[3]) != 2 {
+		return SpanContext{}, false
+	}
+
+	if _, err := hex.Decode(c.TraceID[:], []byte(parts[1])); err != nil {
+		return SpanContext{}, false
+	}
+	if _, err := hex.Decode(c.SpanID[:], []byte(parts[2])); err != nil {
+		return SpanContext{}, false
+	}
+
+	flags, err := hex.DecodeString(parts[3])
+	if err != nil {
+		return SpanContext{}, false
+	}
+	c.Sampled = flags[0]&1 == 1
+
+	return c, c.valid()
+}
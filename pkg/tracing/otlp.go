@@ -0,0 +1,137 @@
+/*
+Copyright (C) 2022 Traefik Labs
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package tracing
+
+import (
+	"context"
+	"encoding/hex"
+	"net/http"
+
+	"github.com/traefik/hub-agent-kubernetes/pkg/otlp"
+)
+
+// OTLPExporter exports spans to an OpenTelemetry collector using OTLP/HTTP with the JSON encoding.
+type OTLPExporter struct {
+	client      *otlp.Client
+	serviceName string
+}
+
+// NewOTLPExporter creates an OTLP exporter sending spans to the collector reachable at the given base URL
+// (e.g. http://otel-collector:4318). Spans are attributed to the given service name.
+func NewOTLPExporter(client *http.Client, baseURL string, headers map[string]string, serviceName string) (*OTLPExporter, error) {
+	c, err := otlp.NewClient(client, baseURL, "traces", headers)
+	if err != nil {
+		return nil, err
+	}
+
+	return &OTLPExporter{
+		client:      c,
+		serviceName: serviceName,
+	}, nil
+}
+
+// Export exports the given spans.
+func (e *OTLPExporter) Export(ctx context.Context, spans []*Span) error {
+	if len(spans) == 0 {
+		return nil
+	}
+
+	return e.client.Send(ctx, buildOTLPRequest(e.serviceName, spans))
+}
+
+// The following types are the JSON representation of an OTLP ExportTraceServiceRequest. As defined by the OTLP
+// specification, trace and span IDs are hex encoded, and as defined by the protobuf JSON mapping, 64 bits integers are
+// encoded as strings.
+type otlpRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlp.Resource    `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlp.Scope `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpSpan struct {
+	TraceID           string          `json:"traceId"`
+	SpanID            string          `json:"spanId"`
+	ParentSpanID      string          `json:"parentSpanId,omitempty"`
+	Name              string          `json:"name"`
+	Kind              int             `json:"kind"`
+	StartTimeUnixNano string          `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string          `json:"endTimeUnixNano"`
+	Attributes        []otlp.KeyValue `json:"attributes,omitempty"`
+	Status            *otlpStatus     `json:"status,omitempty"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+func buildOTLPRequest(serviceName string, spans []*Span) otlpRequest {
+	otlpSpans := make([]otlpSpan, 0, len(spans))
+	for _, span := range spans {
+		otlpSpans = append(otlpSpans, newOTLPSpan(span))
+	}
+
+	return otlpRequest{
+		ResourceSpans: []otlpResourceSpans{
+			{
+				Resource: otlp.NewServiceResource(serviceName),
+				ScopeSpans: []otlpScopeSpans{
+					{
+						Scope: otlp.Scope{Name: "github.com/traefik/hub-agent-kubernetes/pkg/tracing"},
+						Spans: otlpSpans,
+					},
+				},
+			},
+		},
+	}
+}
+
+func newOTLPSpan(span *Span) otlpSpan {
+	span.mu.Lock()
+	defer span.mu.Unlock()
+
+	s := otlpSpan{
+		TraceID:           hex.EncodeToString(span.ctx.TraceID[:]),
+		SpanID:            hex.EncodeToString(span.ctx.SpanID[:]),
+		Name:              span.name,
+		Kind:              span.kind,
+		StartTimeUnixNano: otlp.UnixNano(span.start),
+		EndTimeUnixNano:   otlp.UnixNano(span.end),
+	}
+	if span.parentID != [8]byte{} {
+		s.ParentSpanID = hex.EncodeToString(span.parentID[:])
+	}
+
+	for _, attr := range span.attrs {
+		s.Attributes = append(s.Attributes, otlp.NewAttribute(attr.key, attr.value))
+	}
+
+	if span.statusCode != 0 {
+		s.Status = &otlpStatus{Code: span.statusCode, Message: span.statusMessage}
+	}
+
+	return s
+}
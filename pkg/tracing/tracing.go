@@ -0,0 +1,307 @@
+/*
+Copyright (C) 2022 Traefik Labs
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Span kinds, see opentelemetry/proto/trace/v1/trace.proto.
+const (
+	spanKindInternal = 1
+	spanKindServer   = 2
+	spanKindClient   = 3
+)
+
+// statusCodeError is the status code of failed spans, see opentelemetry/proto/trace/v1/trace.proto.
+const statusCodeError = 2
+
+const (
+	maxQueuedSpans = 2048
+	maxBatchSize   = 512
+	flushInterval  = 5 * time.Second
+)
+
+var globalTracer atomic.Value
+
+// SetTracer sets the tracer recording the spans started by Start. No span is recorded until a tracer is set.
+func SetTracer(t *Tracer) {
+	globalTracer.Store(t)
+}
+
+func getTracer() *Tracer {
+	t, _ := globalTracer.Load().(*Tracer)
+	return t
+}
+
+// Exporter exports spans.
+type Exporter interface {
+	Export(ctx context.Context, spans []*Span) error
+}
+
+// Tracer records spans and exports them in batches.
+type Tracer struct {
+	exporter    Exporter
+	sampleRatio float64
+
+	spans chan *Span
+}
+
+// NewTracer returns a tracer exporting spans with the given exporter.
+// The sampleRatio is the ratio of traces recorded when they are not started by a sampled remote parent.
+func NewTracer(exporter Exporter, sampleRatio float64) *Tracer {
+	return &Tracer{
+		exporter:    exporter,
+		sampleRatio: sampleRatio,
+		spans:       make(chan *Span, maxQueuedSpans),
+	}
+}
+
+// Run exports the ended spans until the given context is done.
+func (t *Tracer) Run(ctx context.Context) {
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+
+	var batch []*Span
+	for {
+		select {
+		case <-ctx.Done():
+			// Export the queued spans with a fresh context, as the given one is done.
+			for len(t.spans) > 0 {
+				batch = append(batch, <-t.spans)
+			}
+
+			flushCtx, cancel := context.WithTimeout(context.Background(), flushInterval)
+			t.export(flushCtx, batch)
+			cancel()
+			return
+
+		case span := <-t.spans:
+			batch = append(batch, span)
+			if len(batch) < maxBatchSize {
+				continue
+			}
+
+			t.export(ctx, batch)
+			batch = nil
+
+		case <-ticker.C:
+			t.export(ctx, batch)
+			batch = nil
+		}
+	}
+}
+
+func (t *Tracer) export(ctx context.Context, spans []*Span) {
+	if len(spans) == 0 {
+		return
+	}
+
+	if err := t.exporter.Export(ctx, spans); err != nil {
+		log.Error().Err(err).Int("count", len(spans)).Msg("Unable to export spans")
+	}
+}
+
+func (t *Tracer) start(ctx context.Context, name string, kind int, parent SpanContext) (context.Context, *Span) {
+	span := &Span{
+		tracer: t,
+		name:   name,
+		kind:   kind,
+		start:  time.Now(),
+	}
+
+	if parent.valid() {
+		span.ctx.TraceID = parent.TraceID
+		span.ctx.Sampled = parent.Sampled
+		span.parentID = parent.SpanID
+	} else {
+		span.ctx.TraceID = newTraceID()
+		span.ctx.Sampled = t.sample(span.ctx.TraceID)
+	}
+	span.ctx.SpanID = newSpanID()
+
+	return context.WithValue(ctx, spanKey{}, span), span
+}
+
+// sample tells whether a new trace is recorded, using the trace ID as a source of randomness so that the decision
+// is consistent for a given trace.
+func (t *Tracer) sample(traceID [16]byte) bool {
+	if t.sampleRatio >= 1 {
+		return true
+	}
+	if t.sampleRatio <= 0 {
+		return false
+	}
+
+	v := binary.BigEndian.Uint64(traceID[8:]) >> 1
+	return float64(v) < t.sampleRatio*float64(1<<63)
+}
+
+func (t *Tracer) record(span *Span) {
+	select {
+	case t.spans <- span:
+	default:
+		log.Debug().Str("span", span.name).Msg("Span queue full, dropping span")
+	}
+}
+
+// SpanContext identifies a span within a trace.
+type SpanContext struct {
+	TraceID [16]byte
+	SpanID  [8]byte
+	Sampled bool
+}
+
+func (c SpanContext) valid() bool {
+	return c.TraceID != [16]byte{} && c.SpanID != [8]byte{}
+}
+
+type spanKey struct{}
+
+type remoteSpanKey struct{}
+
+// Span is an operation of a trace. A nil span, returned when no tracer is set, can safely be used and records nothing.
+type Span struct {
+	tracer *Tracer
+
+	ctx      SpanContext
+	parentID [8]byte
+	name     string
+	kind     int
+	start    time.Time
+
+	mu            sync.Mutex
+	end           time.Time
+	attrs         []attribute
+	statusCode    int
+	statusMessage string
+}
+
+type attribute struct {
+	key   string
+	value interface{}
+}
+
+// Start starts a span with the given name, child of the span held by the given context if any.
+// The returned context holds the started span, which must be ended by calling End.
+func Start(ctx context.Context, name string) (context.Context, *Span) {
+	return start(ctx, name, spanKindInternal)
+}
+
+func start(ctx context.Context, name string, kind int) (context.Context, *Span) {
+	t := getTracer()
+	if t == nil {
+		return ctx, nil
+	}
+
+	var parent SpanContext
+	if span, ok := ctx.Value(spanKey{}).(*Span); ok {
+		parent = span.ctx
+	} else if remote, ok := ctx.Value(remoteSpanKey{}).(SpanContext); ok {
+		parent = remote
+	}
+
+	return t.start(ctx, name, kind, parent)
+}
+
+// FromContext returns the span held by the given context, or nil if there is none.
+func FromContext(ctx context.Context) *Span {
+	span, _ := ctx.Value(spanKey{}).(*Span)
+	return span
+}
+
+// Context returns the span context of the span.
+func (s *Span) Context() SpanContext {
+	if s == nil {
+		return SpanContext{}
+	}
+
+	return s.ctx
+}
+
+// SetAttribute sets an attribute on the span. Values are recorded as strings, integers or booleans.
+func (s *Span) SetAttribute(key string, value interface{}) {
+	if s == nil || !s.ctx.Sampled {
+		return
+	}
+
+	switch v := value.(type) {
+	case string, bool, int64:
+	case int:
+		value = int64(v)
+	default:
+		value = fmt.Sprint(v)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.attrs = append(s.attrs, attribute{key: key, value: value})
+}
+
+// RecordError marks the span as failed with the given error. It does nothing if err is nil.
+func (s *Span) RecordError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.statusCode = statusCodeError
+	s.statusMessage = err.Error()
+}
+
+// End ends the span. Only the first call records the span.
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+
+	s.mu.Lock()
+	if !s.end.IsZero() {
+		s.mu.Unlock()
+		return
+	}
+	s.end = time.Now()
+	s.mu.Unlock()
+
+	if s.ctx.Sampled {
+		s.tracer.record(s)
+	}
+}
+
+func newTraceID() [16]byte {
+	var id [16]byte
+	_, _ = rand.Read(id[:])
+	return id
+}
+
+func newSpanID() [8]byte {
+	var id [8]byte
+	_, _ = rand.Read(id[:])
+	return id
+}
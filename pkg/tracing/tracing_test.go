@@ -0,0 +1,336 @@
+/*
+Copyright (C) 2022 Traefik Labs
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package tracing
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type exporterMock struct {
+	mu    sync.Mutex
+	spans []*Span
+}
+
+func (e *exporterMock) Export(_ context.Context, spans []*Span) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.spans = append(e.spans, spans...)
+	return nil
+}
+
+func (e *exporterMock) exported() []*Span {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return e.spans
+}
+
+// setupTracer sets a tracer exporting spans to the returned exporter until the end of the test.
+func setupTracer(t *testing.T, sampleRatio float64) (*Tracer, *exporterMock) {
+	t.Helper()
+
+	exporter := &exporterMock{}
+	tracer := NewTracer(exporter, sampleRatio)
+	SetTracer(tracer)
+	t.Cleanup(func() { SetTracer(nil) })
+
+	return tracer, exporter
+}
+
+// run runs the given tracer until the given function returns, so that all the spans are exported.
+func run(tracer *Tracer, fn func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		tracer.Run(ctx)
+		close(done)
+	}()
+
+	fn()
+
+	cancel()
+	<-done
+}
+
+func TestStart_noTracer(t *testing.T) {
+	ctx, span := Start(context.Background(), "span")
+
+	assert.Nil(t, span)
+	assert.Nil(t, FromContext(ctx))
+
+	// A nil span must be usable.
+	span.SetAttribute("key", "value")
+	span.RecordError(errors.New("boom"))
+	span.End()
+}
+
+func TestStart(t *testing.T) {
+	tracer, exporter := setupTracer(t, 1)
+
+	run(tracer, func() {
+		ctx, parent := Start(context.Background(), "parent")
+		parent.SetAttribute("count", 2)
+
+		_, child := Start(ctx, "child")
+		child.RecordError(errors.New("boom"))
+		child.End()
+
+		parent.End()
+		// Spans are recorded once.
+		parent.End()
+	})
+
+	spans := exporter.exported()
+	require.Len(t, spans, 2)
+
+	child, parent := spans[0], spans[1]
+	assert.Equal(t, "child", child.name)
+	assert.Equal(t, "parent", parent.name)
+	assert.Equal(t, parent.ctx.TraceID, child.ctx.TraceID)
+	assert.Equal(t, parent.ctx.SpanID, child.parentID)
+	assert.Equal(t, [8]byte{}, parent.parentID)
+	assert.Equal(t, statusCodeError, child.statusCode)
+	assert.Equal(t, "boom", child.statusMessage)
+	assert.Equal(t, []attribute{{key: "count", value: int64(2)}}, parent.attrs)
+}
+
+func TestStart_notSampled(t *testing.T) {
+	tracer, exporter := setupTracer(t, 0)
+
+	run(tracer, func() {
+		ctx, parent := Start(context.Background(), "parent")
+
+		_, child := Start(ctx, "child")
+		assert.False(t, child.Context().Sampled)
+		child.End()
+
+		parent.End()
+	})
+
+	assert.Empty(t, exporter.exported())
+}
+
+func TestHandler(t *testing.T) {
+	tracer, exporter := setupTracer(t, 0)
+
+	var serverSpan SpanContext
+	handler := Handler("server", http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		serverSpan = FromContext(req.Context()).Context()
+		rw.WriteHeader(http.StatusBadGateway)
+	}))
+
+	run(tracer, func() {
+		req := httptest.NewRequest(http.MethodGet, "/path", http.NoBody)
+		// The remote parent is sampled, so is the trace whatever the sample ratio.
+		req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	})
+
+	spans := exporter.exported()
+	require.Len(t, spans, 1)
+
+	span := spans[0]
+	assert.Equal(t, serverSpan, span.ctx)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", hex.EncodeToString(span.ctx.TraceID[:]))
+	assert.Equal(t, "00f067aa0ba902b7", hex.EncodeToString(span.parentID[:]))
+	assert.Equal(t, spanKindServer, span.kind)
+	assert.Equal(t, statusCodeError, span.statusCode)
+	assert.Equal(t, []attribute{
+		{key: "http.method", value: http.MethodGet},
+		{key: "http.target", value: "/path"},
+		{key: "http.status_code", value: int64(http.StatusBadGateway)},
+	}, span.attrs)
+}
+
+func TestTransport(t *testing.T) {
+	tracer, exporter := setupTracer(t, 1)
+
+	var traceParent string
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		traceParent = req.Header.Get("traceparent")
+	}))
+	t.Cleanup(srv.Close)
+
+	client := &http.Client{Transport: Transport(http.DefaultTransport)}
+
+	run(tracer, func() {
+		ctx, parent := Start(context.Background(), "parent")
+		defer parent.End()
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/path?query=value", http.NoBody)
+		require.NoError(t, err)
+
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+	})
+
+	spans := exporter.exported()
+	require.Len(t, spans, 2)
+
+	span, parent := spans[0], spans[1]
+	assert.Equal(t, formatTraceParent(span.ctx), traceParent)
+	assert.Equal(t, parent.ctx.SpanID, span.parentID)
+	assert.Equal(t, "HTTP GET", span.name)
+	assert.Equal(t, spanKindClient, span.kind)
+	assert.Equal(t, []attribute{
+		{key: "http.method", value: http.MethodGet},
+		{key: "http.url", value: srv.URL + "/path"},
+		{key: "http.status_code", value: int64(http.StatusOK)},
+	}, span.attrs)
+}
+
+func TestParseTraceParent(t *testing.T) {
+	tests := []struct {
+		desc  string
+		value string
+		want  string
+		ok    bool
+	}{
+		{
+			desc:  "sampled",
+			value: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+			want:  "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+			ok:    true,
+		},
+		{
+			desc:  "not sampled",
+			value: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00",
+			want:  "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00",
+			ok:    true,
+		},
+		{
+			desc:  "future version with more fields",
+			value: "01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+			want:  "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+			ok:    true,
+		},
+		{
+			desc:  "empty",
+			value: "",
+		},
+		{
+			desc:  "invalid version",
+			value: "ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		},
+		{
+			desc:  "invalid trace ID",
+			value: "00-4bf92f3577b34da6a3ce929d0e0e47zz-00f067aa0ba902b7-01",
+		},
+		{
+			desc:  "zero span ID",
+			value: "00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			got, ok := parseTraceParent(test.value)
+			require.Equal(t, test.ok, ok)
+			if !ok {
+				return
+			}
+
+			assert.Equal(t, test.want, formatTraceParent(got))
+		})
+	}
+}
+
+func TestOTLPExporter_Export(t *testing.T) {
+	var got map[string]interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, "/v1/traces", req.URL.Path)
+		assert.Equal(t, "application/json", req.Header.Get("Content-Type"))
+		assert.Equal(t, "secret", req.Header.Get("Authorization"))
+
+		require.NoError(t, json.NewDecoder(req.Body).Decode(&got))
+	}))
+	t.Cleanup(srv.Close)
+
+	exporter, err := NewOTLPExporter(http.DefaultClient, srv.URL, map[string]string{"Authorization": "secret"}, "hub-agent-kubernetes")
+	require.NoError(t, err)
+
+	start := time.Unix(1600000000, 0)
+	span := &Span{
+		ctx: SpanContext{
+			TraceID: [16]byte{0x4b, 0xf9, 0x2f, 0x35, 0x77, 0xb3, 0x4d, 0xa6, 0xa3, 0xce, 0x92, 0x9d, 0x0e, 0x0e, 0x47, 0x36},
+			SpanID:  [8]byte{0x00, 0xf0, 0x67, 0xaa, 0x0b, 0xa9, 0x02, 0xb7},
+			Sampled: true,
+		},
+		name:          "admission.review",
+		kind:          spanKindServer,
+		start:         start,
+		end:           start.Add(time.Second),
+		attrs:         []attribute{{key: "webhook", value: "ingress"}, {key: "allowed", value: true}, {key: "count", value: int64(3)}},
+		statusCode:    statusCodeError,
+		statusMessage: "boom",
+	}
+
+	err = exporter.Export(context.Background(), []*Span{span})
+	require.NoError(t, err)
+
+	want := map[string]interface{}{
+		"resourceSpans": []interface{}{
+			map[string]interface{}{
+				"resource": map[string]interface{}{
+					"attributes": []interface{}{
+						map[string]interface{}{"key": "service.name", "value": map[string]interface{}{"stringValue": "hub-agent-kubernetes"}},
+					},
+				},
+				"scopeSpans": []interface{}{
+					map[string]interface{}{
+						"scope": map[string]interface{}{"name": "github.com/traefik/hub-agent-kubernetes/pkg/tracing"},
+						"spans": []interface{}{
+							map[string]interface{}{
+								"traceId":           "4bf92f3577b34da6a3ce929d0e0e4736",
+								"spanId":            "00f067aa0ba902b7",
+								"name":              "admission.review",
+								"kind":              float64(2),
+								"startTimeUnixNano": "1600000000000000000",
+								"endTimeUnixNano":   "1600000001000000000",
+								"attributes": []interface{}{
+									map[string]interface{}{"key": "webhook", "value": map[string]interface{}{"stringValue": "ingress"}},
+									map[string]interface{}{"key": "allowed", "value": map[string]interface{}{"boolValue": true}},
+									map[string]interface{}{"key": "count", "value": map[string]interface{}{"intValue": "3"}},
+								},
+								"status": map[string]interface{}{"code": float64(2), "message": "boom"},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	assert.Equal(t, want, got)
+}
@@ -0,0 +1,309 @@
+/*
+Copyright (C) 2022 Traefik Labs
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package tunnel
+
+import (
+	"bytes"
+	"crypto/tls"
+	"errors"
+	"io"
+	"net"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// maxClientHelloSize is the number of bytes read from a stream after which it's considered not to start with a TLS
+// client hello.
+const maxClientHelloSize = 64 * 1024
+
+// BandwidthLimit is the egress bandwidth cap of an edge ingress, identified by its domains.
+type BandwidthLimit struct {
+	Domains              []string `json:"domains"`
+	EgressBytesPerSecond int64    `json:"egressBytesPerSecond"`
+	// BurstBytes is the number of bytes which can be sent at once above the rate. Defaults to EgressBytesPerSecond.
+	BurstBytes int64 `json:"burstBytes,omitempty"`
+}
+
+// bandwidthLimiters holds the token buckets shared by the streams of each limited edge ingress.
+type bandwidthLimiters struct {
+	mu       sync.RWMutex
+	limits   []BandwidthLimit
+	byDomain map[string]*rate.Limiter
+}
+
+// update sets the bandwidth limits to enforce. Limiters are kept as long as the limits are unchanged, so updates
+// don't refill the buckets.
+func (l *bandwidthLimiters) update(limits []BandwidthLimit) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if reflect.DeepEqual(l.limits, limits) {
+		return
+	}
+
+	byDomain := make(map[string]*rate.Limiter)
+	for _, limit := range limits {
+		if limit.EgressBytesPerSecond <= 0 {
+			continue
+		}
+
+		burst := limit.BurstBytes
+		if burst <= 0 {
+			burst = limit.EgressBytesPerSecond
+		}
+
+		limiter := rate.NewLimiter(rate.Limit(limit.EgressBytesPerSecond), int(burst))
+		for _, domain := range limit.Domains {
+			byDomain[strings.ToLower(domain)] = limiter
+		}
+	}
+
+	l.limits = limits
+	l.byDomain = byDomain
+}
+
+func (l *bandwidthLimiters) limiter(domain string) *rate.Limiter {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	return l.byDomain[strings.ToLower(domain)]
+}
+
+func (l *bandwidthLimiters) empty() bool {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	return len(l.byDomain) == 0
+}
+
+// limit returns the stream with its egress limited by the bandwidth limit of the edge ingress it's opened for.
+func (l *bandwidthLimiters) limit(stream net.Conn) net.Conn {
+	if l.empty() {
+		return stream
+	}
+
+	return &sniffingConn{Conn: stream, limiters: l}
+}
+
+// sniffingConn is a stream whose egress is limited once the edge ingress it's opened for has been identified, by the
+// server name of the TLS client hello read from it. The client hello is parsed from the bytes read by the proxy, so the
+// stream is never delayed. Streams which don't start with a client hello, including the ones whose server speaks first,
+// are left unlimited.
+type sniffingConn struct {
+	net.Conn
+
+	limiters *bandwidthLimiters
+
+	mu sync.Mutex
+	// hello holds the bytes read so far, until the stream has been identified.
+	hello      []byte
+	identified bool
+	// limited is the stream with its egress limited, once identified as the stream of a limited edge ingress.
+	limited *limitedConn
+}
+
+func (c *sniffingConn) Read(p []byte) (int, error) {
+	n, err := c.Conn.Read(p)
+	if n > 0 {
+		c.sniff(p[:n])
+	}
+
+	return n, err
+}
+
+func (c *sniffingConn) sniff(data []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.identified {
+		return
+	}
+
+	c.hello = append(c.hello, data...)
+
+	serverName, complete := parseServerName(c.hello)
+	if !complete && len(c.hello) < maxClientHelloSize {
+		return
+	}
+
+	c.identified = true
+	c.hello = nil
+
+	if limiter := c.limiters.limiter(serverName); limiter != nil {
+		c.limited = &limitedConn{Conn: c.Conn, limiter: limiter, closed: make(chan struct{})}
+	}
+}
+
+func (c *sniffingConn) Write(p []byte) (int, error) {
+	c.mu.Lock()
+	// A TLS server doesn't write before having read the client hello, the stream is not a TLS one otherwise.
+	c.identified = true
+	c.hello = nil
+	limited := c.limited
+	c.mu.Unlock()
+
+	if limited == nil {
+		return c.Conn.Write(p)
+	}
+
+	return limited.Write(p)
+}
+
+func (c *sniffingConn) Close() error {
+	c.mu.Lock()
+	limited := c.limited
+	c.mu.Unlock()
+
+	if limited == nil {
+		return c.Conn.Close()
+	}
+
+	return limited.Close()
+}
+
+var errClientHelloRead = errors.New("client hello read")
+
+// parseServerName parses the server name of the TLS client hello held by data. It reports whether the parsing is
+// complete: it isn't if data holds the beginning of a client hello only. The server name is empty if data doesn't
+// start with a client hello.
+func parseServerName(data []byte) (string, bool) {
+	// TLS handshake records start with 0x16.
+	if len(data) == 0 || data[0] != 0x16 {
+		return "", true
+	}
+
+	var serverName string
+
+	// The handshake is aborted as soon as the client hello has been read.
+	err := tls.Server(readOnlyConn{r: bytes.NewReader(data)}, &tls.Config{
+		GetConfigForClient: func(info *tls.ClientHelloInfo) (*tls.Config, error) {
+			serverName = info.ServerName
+			return nil, errClientHelloRead
+		},
+	}).Handshake()
+
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return "", false
+	}
+
+	return serverName, true
+}
+
+// readOnlyConn is a connection which can only be read, used to parse a TLS client hello without answering it.
+type readOnlyConn struct {
+	r io.Reader
+}
+
+func (c readOnlyConn) Read(p []byte) (int, error) {
+	return c.r.Read(p)
+}
+
+func (readOnlyConn) Write([]byte) (int, error) {
+	return 0, io.ErrClosedPipe
+}
+
+func (readOnlyConn) Close() error {
+	return nil
+}
+
+func (readOnlyConn) LocalAddr() net.Addr {
+	return nil
+}
+
+func (readOnlyConn) RemoteAddr() net.Addr {
+	return nil
+}
+
+func (readOnlyConn) SetDeadline(time.Time) error {
+	return nil
+}
+
+func (readOnlyConn) SetReadDeadline(time.Time) error {
+	return nil
+}
+
+func (readOnlyConn) SetWriteDeadline(time.Time) error {
+	return nil
+}
+
+// limitedConn is a connection whose writes are limited by a token bucket.
+type limitedConn struct {
+	net.Conn
+
+	limiter   *rate.Limiter
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+func (c *limitedConn) Write(p []byte) (int, error) {
+	var written int
+	for len(p) > 0 {
+		chunk := p
+		if burst := c.limiter.Burst(); len(chunk) > burst {
+			chunk = chunk[:burst]
+		}
+
+		if err := c.wait(len(chunk)); err != nil {
+			return written, err
+		}
+
+		n, err := c.Conn.Write(chunk)
+		written += n
+		if err != nil {
+			return written, err
+		}
+
+		p = p[n:]
+	}
+
+	return written, nil
+}
+
+// wait waits for n tokens to be available, or for the connection to be closed.
+func (c *limitedConn) wait(n int) error {
+	reservation := c.limiter.ReserveN(time.Now(), n)
+	if !reservation.OK() {
+		return errors.New("write exceeds the bandwidth burst")
+	}
+
+	delay := reservation.Delay()
+	if delay == 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-c.closed:
+		reservation.Cancel()
+		return net.ErrClosed
+	}
+}
+
+func (c *limitedConn) Close() error {
+	c.closeOnce.Do(func() { close(c.closed) })
+
+	return c.Conn.Close()
+}
@@ -0,0 +1,262 @@
+/*
+Copyright (C) 2022 Traefik Labs
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package tunnel
+
+import (
+	"crypto/tls"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/time/rate"
+)
+
+func TestBandwidthLimiters_limit(t *testing.T) {
+	tests := []struct {
+		desc        string
+		limits      []BandwidthLimit
+		serverName  string
+		wantLimited bool
+	}{
+		{
+			desc:       "no limits",
+			serverName: "app.example.com",
+		},
+		{
+			desc: "limited edge ingress",
+			limits: []BandwidthLimit{
+				{Domains: []string{"other.example.com"}, EgressBytesPerSecond: 1024},
+				{Domains: []string{"custom.example.org", "app.example.com"}, EgressBytesPerSecond: 2048},
+			},
+			serverName:  "APP.example.com",
+			wantLimited: true,
+		},
+		{
+			desc: "unlimited edge ingress",
+			limits: []BandwidthLimit{
+				{Domains: []string{"other.example.com"}, EgressBytesPerSecond: 1024},
+			},
+			serverName: "app.example.com",
+		},
+		{
+			desc: "limit without rate",
+			limits: []BandwidthLimit{
+				{Domains: []string{"app.example.com"}},
+			},
+			serverName: "app.example.com",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			limiters := &bandwidthLimiters{}
+			limiters.update(test.limits)
+
+			clientConn, serverConn := net.Pipe()
+			t.Cleanup(func() {
+				_ = clientConn.Close()
+				_ = serverConn.Close()
+			})
+
+			go func() {
+				_ = tls.Client(clientConn, &tls.Config{ServerName: test.serverName}).Handshake()
+			}()
+
+			stream := limiters.limit(serverConn)
+
+			// The client hello is read from the stream unchanged.
+			buf := make([]byte, 1024)
+			n, err := stream.Read(buf)
+			require.NoError(t, err)
+			require.Greater(t, n, 0)
+			assert.Equal(t, byte(0x16), buf[0])
+
+			for !identified(stream) {
+				_, err = stream.Read(buf)
+				require.NoError(t, err)
+			}
+
+			sniffing, ok := stream.(*sniffingConn)
+			assert.Equal(t, test.wantLimited, ok && sniffing.limited != nil)
+		})
+	}
+}
+
+func TestBandwidthLimiters_limit_serverFirst(t *testing.T) {
+	limiters := &bandwidthLimiters{}
+	limiters.update([]BandwidthLimit{{Domains: []string{"app.example.com"}, EgressBytesPerSecond: 1024}})
+
+	clientConn, serverConn := net.Pipe()
+	t.Cleanup(func() {
+		_ = clientConn.Close()
+		_ = serverConn.Close()
+	})
+
+	stream := limiters.limit(serverConn)
+
+	// The server speaks first, as SMTP or MySQL servers do: its greeting must not wait for a client hello.
+	go func() {
+		_, _ = stream.Write([]byte("220 smtp.example.com ESMTP\r\n"))
+	}()
+
+	start := time.Now()
+
+	greeting := make([]byte, 28)
+	_, err := io.ReadFull(clientConn, greeting)
+	require.NoError(t, err)
+	assert.Equal(t, "220 smtp.example.com ESMTP\r\n", string(greeting))
+	assert.Less(t, time.Since(start), time.Second)
+
+	go func() {
+		_, _ = clientConn.Write([]byte("EHLO client\r\n"))
+	}()
+
+	command := make([]byte, 13)
+	_, err = io.ReadFull(stream, command)
+	require.NoError(t, err)
+	assert.Equal(t, "EHLO client\r\n", string(command))
+
+	assert.True(t, identified(stream))
+	assert.Nil(t, stream.(*sniffingConn).limited)
+}
+
+func TestParseServerName(t *testing.T) {
+	clientConn, serverConn := net.Pipe()
+	t.Cleanup(func() {
+		_ = clientConn.Close()
+		_ = serverConn.Close()
+	})
+
+	go func() {
+		_ = tls.Client(clientConn, &tls.Config{ServerName: "app.example.com"}).Handshake()
+	}()
+
+	hello := make([]byte, 4096)
+	n, err := serverConn.Read(hello)
+	require.NoError(t, err)
+	hello = hello[:n]
+
+	serverName, complete := parseServerName(hello[:10])
+	assert.False(t, complete)
+	assert.Empty(t, serverName)
+
+	serverName, complete = parseServerName(hello)
+	assert.True(t, complete)
+	assert.Equal(t, "app.example.com", serverName)
+
+	serverName, complete = parseServerName([]byte("GET / HTTP/1.1\r\n"))
+	assert.True(t, complete)
+	assert.Empty(t, serverName)
+}
+
+func identified(stream net.Conn) bool {
+	sniffing, ok := stream.(*sniffingConn)
+	if !ok {
+		return true
+	}
+
+	sniffing.mu.Lock()
+	defer sniffing.mu.Unlock()
+
+	return sniffing.identified
+}
+
+func TestBandwidthLimiters_update_keepsLimiters(t *testing.T) {
+	limits := []BandwidthLimit{{Domains: []string{"app.example.com"}, EgressBytesPerSecond: 1024}}
+
+	limiters := &bandwidthLimiters{}
+	limiters.update(limits)
+	limiter := limiters.limiter("app.example.com")
+	require.NotNil(t, limiter)
+
+	limiters.update([]BandwidthLimit{{Domains: []string{"app.example.com"}, EgressBytesPerSecond: 1024}})
+	assert.Same(t, limiter, limiters.limiter("app.example.com"))
+
+	limiters.update([]BandwidthLimit{{Domains: []string{"app.example.com"}, EgressBytesPerSecond: 2048, BurstBytes: 4096}})
+	limiter = limiters.limiter("app.example.com")
+	assert.Equal(t, rate.Limit(2048), limiter.Limit())
+	assert.Equal(t, 4096, limiter.Burst())
+
+	limiters.update(nil)
+	assert.Nil(t, limiters.limiter("app.example.com"))
+	assert.True(t, limiters.empty())
+}
+
+func TestLimitedConn_Write(t *testing.T) {
+	clientConn, serverConn := net.Pipe()
+	t.Cleanup(func() {
+		_ = clientConn.Close()
+		_ = serverConn.Close()
+	})
+
+	go func() {
+		_, _ = io.Copy(io.Discard, clientConn)
+	}()
+
+	conn := &limitedConn{
+		Conn:    serverConn,
+		limiter: rate.NewLimiter(100*1024, 10*1024),
+		closed:  make(chan struct{}),
+	}
+
+	start := time.Now()
+
+	// The burst is sent at once, the remaining 20KiB at 100KiB/s.
+	n, err := conn.Write(make([]byte, 30*1024))
+	require.NoError(t, err)
+	assert.Equal(t, 30*1024, n)
+
+	assert.GreaterOrEqual(t, time.Since(start), 150*time.Millisecond)
+}
+
+func TestLimitedConn_Close(t *testing.T) {
+	clientConn, serverConn := net.Pipe()
+	t.Cleanup(func() { _ = clientConn.Close() })
+
+	go func() {
+		_, _ = io.Copy(io.Discard, clientConn)
+	}()
+
+	conn := &limitedConn{
+		Conn:    serverConn,
+		limiter: rate.NewLimiter(1, 1024),
+		closed:  make(chan struct{}),
+	}
+
+	writeErr := make(chan error, 1)
+	go func() {
+		_, err := conn.Write(make([]byte, 2048))
+		writeErr <- err
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+	require.NoError(t, conn.Close())
+
+	select {
+	case err := <-writeErr:
+		assert.ErrorIs(t, err, net.ErrClosed)
+	case <-time.After(time.Second):
+		require.Fail(t, "write not interrupted")
+	}
+}
@@ -0,0 +1,325 @@
+/*
+Copyright (C) 2022 Traefik Labs
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package tunnel
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/yamux"
+	"github.com/rs/zerolog/log"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// brokerProbeTimeout is the time given to a broker to accept a probe connection.
+	brokerProbeTimeout = 3 * time.Second
+	// brokerSwitchMargin is the relative latency gain required to switch to another broker, so tunnels don't flap
+	// between brokers with similar latencies.
+	brokerSwitchMargin = 0.2
+	// brokerSwitchTimeout is the time given to a tunnel to connect to a new broker before giving up the switch.
+	brokerSwitchTimeout = 30 * time.Second
+	// brokerDrainTimeout is the time given to the streams of a tunnel to complete once it has been replaced.
+	brokerDrainTimeout = 30 * time.Second
+)
+
+// probeFunc measures the latency to the given broker endpoint.
+type probeFunc func(ctx context.Context, endpoint string) (time.Duration, error)
+
+// brokerCandidates returns the broker endpoints the tunnel can connect to, in the order given by the platform.
+func (e Endpoint) brokerCandidates() []string {
+	seen := make(map[string]struct{})
+
+	var candidates []string
+	for _, endpoint := range append([]string{e.BrokerEndpoint}, e.BrokerEndpoints...) {
+		if _, ok := seen[endpoint]; ok || endpoint == "" {
+			continue
+		}
+
+		seen[endpoint] = struct{}{}
+		candidates = append(candidates, endpoint)
+	}
+
+	return candidates
+}
+
+// sameBrokers reports whether the given endpoints target the same brokers.
+func (e Endpoint) sameBrokers(other Endpoint) bool {
+	candidates, otherCandidates := e.brokerCandidates(), other.brokerCandidates()
+	if len(candidates) != len(otherCandidates) || e.FallbackBrokerEndpoint != other.FallbackBrokerEndpoint {
+		return false
+	}
+
+	for i := range candidates {
+		if candidates[i] != otherCandidates[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// probeBroker measures the time taken to open a TCP connection with the given broker endpoint.
+func probeBroker(ctx context.Context, endpoint string) (time.Duration, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return 0, fmt.Errorf("parse broker endpoint: %w", err)
+	}
+
+	port := u.Port()
+	if port == "" {
+		port = "80"
+		if u.Scheme == "wss" {
+			port = "443"
+		}
+	}
+
+	var dialer net.Dialer
+
+	start := time.Now()
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(u.Hostname(), port))
+	if err != nil {
+		return 0, fmt.Errorf("dial: %w", err)
+	}
+	latency := time.Since(start)
+
+	_ = conn.Close()
+
+	return latency, nil
+}
+
+// brokerSelectionEnabled reports whether the given brokers can be probed to select the lowest-latency one. They can't
+// when any of them is reached through a proxy, configured or given by the environment, as the latency measured would
+// be the one of the proxy.
+func (m *Manager) brokerSelectionEnabled(candidates []string) bool {
+	for _, candidate := range candidates {
+		u, err := url.Parse(candidate)
+		if err != nil {
+			return false
+		}
+
+		proxyURL, err := m.config.Proxy.proxyURL(u)
+		if err != nil || proxyURL != nil {
+			return false
+		}
+	}
+
+	return true
+}
+
+// probeBrokers measures concurrently the latency to the given brokers. Brokers which can't be reached are omitted.
+func (m *Manager) probeBrokers(candidates []string) map[string]time.Duration {
+	ctx, cancel := context.WithTimeout(context.Background(), brokerProbeTimeout)
+	defer cancel()
+
+	var mu sync.Mutex
+	latencies := make(map[string]time.Duration)
+
+	var wg sync.WaitGroup
+	for _, candidate := range candidates {
+		wg.Add(1)
+
+		go func(candidate string) {
+			defer wg.Done()
+
+			latency, err := m.probe(ctx, candidate)
+			if err != nil {
+				log.Debug().Err(err).Str("broker_endpoint", candidate).Msg("Unable to probe broker")
+				return
+			}
+
+			mu.Lock()
+			latencies[candidate] = latency
+			mu.Unlock()
+		}(candidate)
+	}
+	wg.Wait()
+
+	return latencies
+}
+
+// selectBroker returns the reachable broker with the lowest latency among the given candidates. The avoided broker
+// is only selected when no other broker can be reached, and the first candidate when none can.
+func (m *Manager) selectBroker(candidates []string, avoid string) string {
+	if len(candidates) == 0 {
+		return ""
+	}
+	if len(candidates) < 2 || !m.brokerSelectionEnabled(candidates) {
+		return candidates[0]
+	}
+
+	latencies := m.probeBrokers(candidates)
+
+	reachable := make([]string, 0, len(latencies))
+	for _, candidate := range candidates {
+		if _, ok := latencies[candidate]; ok && candidate != avoid {
+			reachable = append(reachable, candidate)
+		}
+	}
+
+	switch {
+	case len(reachable) > 0:
+		// The stable sort keeps the platform order between brokers with the same latency.
+		sort.SliceStable(reachable, func(i, j int) bool {
+			return latencies[reachable[i]] < latencies[reachable[j]]
+		})
+
+		return reachable[0]
+	case avoid != "":
+		return avoid
+	default:
+		return candidates[0]
+	}
+}
+
+// reevaluateBroker periodically probes the brokers of the given tunnel, and switches it to another broker when it's
+// significantly faster, until the tunnel is closed.
+func (m *Manager) reevaluateBroker(t *tunnel, tunnelID string) {
+	candidates := t.endpoint.brokerCandidates()
+	if len(candidates) < 2 || !m.brokerSelectionEnabled(candidates) || t.Config.BrokerProbeInterval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(t.Config.BrokerProbeInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-t.done:
+			return
+		}
+
+		current := t.broker()
+		latencies := m.probeBrokers(candidates)
+
+		best := current
+		for _, candidate := range candidates {
+			latency, ok := latencies[candidate]
+			if !ok {
+				continue
+			}
+
+			bestLatency, bestOK := latencies[best]
+			if !bestOK || float64(latency) < float64(bestLatency)*(1-brokerSwitchMargin) {
+				best = candidate
+			}
+		}
+
+		if best == current {
+			continue
+		}
+
+		log.Info().
+			Str("tunnel_id", tunnelID).
+			Str("broker_endpoint", current).
+			Str("new_broker_endpoint", best).
+			Msg("Switching tunnel to a lower-latency broker")
+
+		if m.switchBroker(t, tunnelID, best) {
+			return
+		}
+	}
+}
+
+// switchBroker replaces the given tunnel by a tunnel connected to the given broker. The current tunnel is drained
+// once the new one is connected, and kept if it can't connect. It reports whether the tunnel has been replaced.
+func (m *Manager) switchBroker(t *tunnel, tunnelID, broker string) bool {
+	next := newTunnel(t.endpoint, t.ClusterEndpoint, t.Config)
+	next.setBroker(broker)
+	// Streams keep sharing the same token buckets across the switch.
+	next.bandwidth = t.bandwidth
+
+	go m.runTunnel(next, tunnelID)
+
+	select {
+	case <-next.connected:
+	case <-time.After(brokerSwitchTimeout):
+		log.Warn().Str("tunnel_id", tunnelID).Str("broker_endpoint", broker).Msg("Unable to switch tunnel broker, keeping the current one")
+		_ = next.Close()
+		return false
+	case <-t.done:
+		_ = next.Close()
+		return false
+	}
+
+	m.tunnelsMu.Lock()
+	if m.tunnels[tunnelID] != t {
+		// The tunnel has been closed or replaced in the meantime.
+		m.tunnelsMu.Unlock()
+		_ = next.Close()
+		return false
+	}
+	m.tunnels[tunnelID] = next
+	tunnelStatsCollector.add(tunnelID, next)
+	m.tunnelsMu.Unlock()
+
+	go m.reevaluateBroker(next, tunnelID)
+
+	m.recordEvent(corev1.EventTypeNormal, "TunnelBrokerSwitched",
+		fmt.Sprintf("Tunnel %s switched to the lower-latency broker %s", tunnelID, broker))
+
+	if err := t.drain(brokerDrainTimeout); err != nil {
+		log.Error().Err(err).Str("tunnel_id", tunnelID).Msg("Unable to close tunnel")
+	}
+
+	return true
+}
+
+// drain stops the broker from opening new streams on the connections of the tunnel, and closes the tunnel once its
+// current streams completed or the given timeout elapsed.
+func (t *tunnel) drain(timeout time.Duration) error {
+	t.clientsMu.Lock()
+	var sessions []*yamux.Session
+	for _, client := range t.clients {
+		if session, ok := client.Listener.(*yamux.Session); ok {
+			_ = session.GoAway()
+			sessions = append(sessions, session)
+		}
+	}
+	t.clientsMu.Unlock()
+
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+
+	deadline := time.After(timeout)
+	for !allDrained(sessions) {
+		select {
+		case <-ticker.C:
+		case <-deadline:
+			return t.Close()
+		}
+	}
+
+	return t.Close()
+}
+
+func allDrained(sessions []*yamux.Session) bool {
+	for _, session := range sessions {
+		if session.NumStreams() > 0 {
+			return false
+		}
+	}
+
+	return true
+}
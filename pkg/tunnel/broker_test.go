@@ -0,0 +1,232 @@
+/*
+Copyright (C) 2022 Traefik Labs
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package tunnel
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/hashicorp/yamux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEndpoint_brokerCandidates(t *testing.T) {
+	endpoint := Endpoint{
+		BrokerEndpoint:  "wss://broker-1.example.com",
+		BrokerEndpoints: []string{"wss://broker-2.example.com", "", "wss://broker-1.example.com"},
+	}
+
+	assert.Equal(t, []string{"wss://broker-1.example.com", "wss://broker-2.example.com"}, endpoint.brokerCandidates())
+
+	assert.True(t, endpoint.sameBrokers(Endpoint{
+		BrokerEndpoint:  "wss://broker-1.example.com",
+		BrokerEndpoints: []string{"wss://broker-2.example.com"},
+	}))
+	assert.False(t, endpoint.sameBrokers(Endpoint{
+		BrokerEndpoint:  "wss://broker-2.example.com",
+		BrokerEndpoints: []string{"wss://broker-1.example.com"},
+	}))
+	assert.False(t, endpoint.sameBrokers(Endpoint{BrokerEndpoint: "wss://broker-1.example.com"}))
+}
+
+func TestManager_selectBroker(t *testing.T) {
+	candidates := []string{"wss://broker-1", "wss://broker-2", "wss://broker-3"}
+
+	tests := []struct {
+		desc      string
+		latencies map[string]time.Duration
+		avoid     string
+		proxy     bool
+		// envProxied is the broker host reached through the proxy given by the environment, if any.
+		envProxied string
+		want       string
+	}{
+		{
+			desc:      "lowest latency",
+			latencies: map[string]time.Duration{"wss://broker-1": 80 * time.Millisecond, "wss://broker-2": 20 * time.Millisecond, "wss://broker-3": 40 * time.Millisecond},
+			want:      "wss://broker-2",
+		},
+		{
+			desc:      "unreachable brokers are skipped",
+			latencies: map[string]time.Duration{"wss://broker-3": 40 * time.Millisecond},
+			want:      "wss://broker-3",
+		},
+		{
+			desc:      "avoided broker is skipped",
+			latencies: map[string]time.Duration{"wss://broker-1": 80 * time.Millisecond, "wss://broker-2": 20 * time.Millisecond},
+			avoid:     "wss://broker-2",
+			want:      "wss://broker-1",
+		},
+		{
+			desc:      "avoided broker is the only one reachable",
+			latencies: map[string]time.Duration{"wss://broker-2": 20 * time.Millisecond},
+			avoid:     "wss://broker-2",
+			want:      "wss://broker-2",
+		},
+		{
+			desc: "no broker reachable",
+			want: "wss://broker-1",
+		},
+		{
+			desc:      "brokers are not probed through a proxy",
+			latencies: map[string]time.Duration{"wss://broker-2": 20 * time.Millisecond},
+			proxy:     true,
+			want:      "wss://broker-1",
+		},
+		{
+			desc:       "brokers are not probed through a proxy from the environment",
+			latencies:  map[string]time.Duration{"wss://broker-2": 20 * time.Millisecond},
+			envProxied: "broker-3",
+			want:       "wss://broker-1",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			cfg := DefaultConfig()
+			if test.proxy {
+				cfg.Proxy.URL = "http://proxy.example.com:3128"
+			}
+			cfg.Proxy.fromEnvironment = func(req *http.Request) (*url.URL, error) {
+				if req.URL.Host != test.envProxied {
+					return nil, nil
+				}
+
+				return url.Parse("http://env-proxy.example.com:3128")
+			}
+
+			manager := NewManager(&clientMock{}, "127.0.0.1:0", "token", cfg)
+			manager.probe = func(_ context.Context, endpoint string) (time.Duration, error) {
+				latency, ok := test.latencies[endpoint]
+				if !ok {
+					return 0, errors.New("unreachable")
+				}
+
+				return latency, nil
+			}
+
+			assert.Equal(t, test.want, manager.selectBroker(candidates, test.avoid))
+		})
+	}
+}
+
+func TestManager_reevaluateBroker(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	primary := idleBroker(t)
+	secondary := idleBroker(t)
+	primaryURL := "ws://" + primary.Listener.Addr().String()
+	secondaryURL := "ws://" + secondary.Listener.Addr().String()
+
+	client := &clientMock{
+		listClusterTunnelEndpoints: func() ([]Endpoint, error) {
+			return []Endpoint{{TunnelID: "tunnel-id", BrokerEndpoint: primaryURL, BrokerEndpoints: []string{secondaryURL}}}, nil
+		},
+	}
+
+	cfg := DefaultConfig()
+	cfg.BrokerProbeInterval = 20 * time.Millisecond
+	manager := NewManager(client, "127.0.0.1:0", "token", cfg)
+
+	// The secondary broker becomes faster once the tunnel is connected to the primary one.
+	var latenciesMu sync.Mutex
+	latencies := map[string]time.Duration{primaryURL: 10 * time.Millisecond, secondaryURL: 50 * time.Millisecond}
+	manager.probe = func(_ context.Context, endpoint string) (time.Duration, error) {
+		latenciesMu.Lock()
+		defer latenciesMu.Unlock()
+
+		return latencies[endpoint], nil
+	}
+
+	go manager.Run(ctx)
+
+	current := func() *tunnel {
+		manager.tunnelsMu.Lock()
+		defer manager.tunnelsMu.Unlock()
+
+		return manager.tunnels["tunnel-id"]
+	}
+
+	require.Eventually(t, func() bool { return current() != nil }, 5*time.Second, 10*time.Millisecond)
+	first := current()
+
+	select {
+	case <-first.connected:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for the tunnel to connect")
+	}
+	assert.Equal(t, primaryURL, first.broker())
+
+	latenciesMu.Lock()
+	latencies[secondaryURL] = time.Millisecond
+	latenciesMu.Unlock()
+
+	assert.Eventually(t, func() bool {
+		tun := current()
+		return tun != first && tun.broker() == secondaryURL
+	}, 5*time.Second, 10*time.Millisecond)
+
+	select {
+	case <-first.done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for the previous tunnel to be drained")
+	}
+}
+
+// idleBroker returns a broker accepting tunnel connections without opening streams.
+func idleBroker(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	broker := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		upgrader := &websocket.Upgrader{}
+		websocketConn, err := upgrader.Upgrade(rw, req, nil)
+		if !assert.NoError(t, err) {
+			return
+		}
+		defer func() { _ = websocketConn.Close() }()
+
+		cfg := yamux.DefaultConfig()
+		cfg.LogOutput = io.Discard
+		server, err := yamux.Server(&websocketNetConn{Conn: websocketConn}, cfg)
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		select {
+		case <-req.Context().Done():
+		case <-server.CloseChan():
+		}
+		_ = server.Close()
+	}))
+	t.Cleanup(broker.Close)
+
+	return broker
+}
@@ -72,6 +72,23 @@ func (a APIError) Error() string {
 type Endpoint struct {
 	TunnelID       string `json:"tunnelId"`
 	BrokerEndpoint string `json:"brokerEndpoint"`
+	// BrokerEndpoints are alternative broker endpoints of the tunnel. The broker with the lowest latency is used.
+	BrokerEndpoints []string `json:"brokerEndpoints,omitempty"`
+	// FallbackBrokerEndpoint is the WebSocket over TLS endpoint, on port 443, used when the broker endpoint can't be
+	// reached. It's derived from the broker endpoint if empty.
+	FallbackBrokerEndpoint string `json:"fallbackBrokerEndpoint,omitempty"`
+	// BandwidthLimits are the egress bandwidth caps of the edge ingresses exposed through the tunnel.
+	BandwidthLimits []BandwidthLimit `json:"bandwidthLimits,omitempty"`
+	// Status is the status of the tunnel known by the platform, if any.
+	Status *Status `json:"status,omitempty"`
+}
+
+// Status represents the status of a tunnel.
+type Status struct {
+	// Transport is the transport used by the tunnel to reach the broker.
+	Transport string `json:"transport"`
+	// Stats are the statistics of the tunnel since it's been launched.
+	Stats *Stats `json:"stats,omitempty"`
 }
 
 // ListClusterTunnelEndpoints lists all tunnels the agent needs to open.
@@ -26,53 +26,290 @@ import (
 	"net/http"
 	"net/url"
 	"path"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/cenkalti/backoff/v4"
 	"github.com/gorilla/websocket"
 	"github.com/hashicorp/yamux"
 	"github.com/rs/zerolog/log"
+	"github.com/traefik/hub-agent-kubernetes/pkg/health"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
 )
 
+// Transports used by tunnels to reach brokers.
+const (
+	// TransportDirect is the transport reaching the broker endpoint.
+	TransportDirect = "direct"
+	// TransportFallback is the WebSocket over TLS transport on port 443, used when the broker endpoint is blocked.
+	TransportFallback = "websocket-443"
+)
+
+// directHandshakeTimeout is the time given to the broker endpoint to answer before falling back. It's kept short as
+// egress firewalls usually drop packets rather than rejecting connections.
+const directHandshakeTimeout = 10 * time.Second
+
 // Backend is able to call hub-tunnel API.
 type Backend interface {
 	ListClusterTunnelEndpoints(ctx context.Context) ([]Endpoint, error)
 }
 
+// Config configures the connections of tunnels to brokers.
+type Config struct {
+	// PoolSize is the number of connections opened with the broker for each tunnel. Streams are multiplexed across
+	// them, so the flow control of a single connection doesn't bound the throughput of a tunnel.
+	PoolSize int
+	// MaxStreamsPerConnection is the maximum number of streams proxied concurrently on a connection, 0 for no limit.
+	// Streams beyond the limit wait for a slot to be freed.
+	MaxStreamsPerConnection int
+	// KeepAliveInterval is the interval at which connections are pinged to check they are alive.
+	KeepAliveInterval time.Duration
+	// KeepAliveTimeout is the time after which a connection not answering a ping is considered dead and replaced.
+	// Connections silently dropped by a NAT or a firewall are then detected without waiting for the TCP timeouts.
+	KeepAliveTimeout time.Duration
+	// StreamWindowSize is the maximum receive window of a stream, in bytes. It bounds the data buffered for a stream
+	// whose reader is slow, so a single stream can't starve the others of the connection.
+	StreamWindowSize uint32
+	// StreamIdleTimeout is the time after which a stream with no traffic in either direction is closed, 0 for no
+	// timeout. Raw TCP streams, unlike HTTP ones, aren't bounded by request timeouts.
+	StreamIdleTimeout time.Duration
+	// DrainTimeout is the time given to the streams in flight to complete when the manager stops, during which brokers
+	// are asked not to open new streams. Connections are closed right away when zero.
+	DrainTimeout time.Duration
+
+	// ReconnectInitialInterval and ReconnectMaxInterval bound the jittered exponential backoff applied between
+	// reconnection attempts, so a broker restart doesn't cause all agents to reconnect at once.
+	ReconnectInitialInterval time.Duration
+	ReconnectMaxInterval     time.Duration
+	// MaxOutage is the time a tunnel may stay disconnected before the outage is reported as a warning event.
+	MaxOutage time.Duration
+
+	// Proxy is the HTTP(S) proxy through which brokers are reached.
+	Proxy ProxyConfig
+
+	// BrokerProbeInterval is the interval at which the latency to the brokers of a tunnel is measured, to switch to
+	// a significantly faster one. 0 disables the periodic re-evaluation.
+	BrokerProbeInterval time.Duration
+
+	// Recorder records events about tunnel disconnections and reconnections, attached to AgentRef.
+	Recorder record.EventRecorder
+	AgentRef *corev1.ObjectReference
+}
+
+// defaultStreamWindowSize is the default, and minimum, receive window of a stream.
+const defaultStreamWindowSize = 256 * 1024
+
+// DefaultConfig returns the default tunnel configuration.
+func DefaultConfig() Config {
+	return Config{
+		PoolSize:                 1,
+		KeepAliveInterval:        10 * time.Second,
+		KeepAliveTimeout:         5 * time.Second,
+		StreamWindowSize:         defaultStreamWindowSize,
+		StreamIdleTimeout:        time.Hour,
+		ReconnectInitialInterval: time.Second,
+		ReconnectMaxInterval:     time.Minute,
+		MaxOutage:                5 * time.Minute,
+		BrokerProbeInterval:      5 * time.Minute,
+	}
+}
+
+// Validate validates the configuration.
+func (c Config) Validate() error {
+	if c.PoolSize < 1 {
+		return fmt.Errorf("invalid pool size %d, must be at least 1", c.PoolSize)
+	}
+	if c.MaxStreamsPerConnection < 0 {
+		return fmt.Errorf("invalid max streams per connection %d, must be positive", c.MaxStreamsPerConnection)
+	}
+	if c.KeepAliveInterval <= 0 {
+		return fmt.Errorf("invalid keepalive interval %s, must be positive", c.KeepAliveInterval)
+	}
+	if c.KeepAliveTimeout <= 0 {
+		return fmt.Errorf("invalid keepalive timeout %s, must be positive", c.KeepAliveTimeout)
+	}
+	if c.StreamWindowSize < defaultStreamWindowSize {
+		return fmt.Errorf("invalid stream window size %d, must be at least %d", c.StreamWindowSize, defaultStreamWindowSize)
+	}
+	if c.DrainTimeout < 0 {
+		return fmt.Errorf("invalid drain timeout %s, must be positive", c.DrainTimeout)
+	}
+	if c.StreamIdleTimeout < 0 {
+		return fmt.Errorf("invalid stream idle timeout %s, must be positive", c.StreamIdleTimeout)
+	}
+	if c.ReconnectInitialInterval <= 0 {
+		return fmt.Errorf("invalid reconnect initial interval %s, must be positive", c.ReconnectInitialInterval)
+	}
+	if c.ReconnectMaxInterval < c.ReconnectInitialInterval {
+		return fmt.Errorf("invalid reconnect max interval %s, must be at least the initial interval", c.ReconnectMaxInterval)
+	}
+	if c.MaxOutage <= 0 {
+		return fmt.Errorf("invalid max outage %s, must be positive", c.MaxOutage)
+	}
+	if err := c.Proxy.Validate(); err != nil {
+		return err
+	}
+	if c.BrokerProbeInterval < 0 {
+		return fmt.Errorf("invalid broker probe interval %s, must be positive", c.BrokerProbeInterval)
+	}
+
+	return nil
+}
+
 // Manager manages tunnels.
 type Manager struct {
 	client            Backend
 	token             string
 	traefikTunnelAddr string
+	config            Config
 
 	tunnelsMu sync.Mutex
 	tunnels   map[string]*tunnel
+
+	probe probeFunc
+
+	ready health.Status
 }
 
 type tunnel struct {
-	BrokerEndpoint  string
-	ClusterEndpoint string
-	Client          *closeAwareListener
+	// stats is first to be 64-bit aligned for atomic operations.
+	stats tunnelStats
+
+	// BrokerEndpoint is the broker the tunnel connects to, selected among the candidates of the endpoint. It's
+	// guarded by clientsMu as it changes on failover.
+	BrokerEndpoint         string
+	FallbackBrokerEndpoint string
+	ClusterEndpoint        string
+	Config                 Config
+
+	endpoint Endpoint
+	// connected is closed once the tunnel has been connected.
+	connected     chan struct{}
+	connectedOnce sync.Once
+
+	clientsMu sync.Mutex
+	clients   []*closeAwareListener
+	closed    bool
+	// transport is the transport used by the connections of the tunnel, empty until connected.
+	transport string
+	// disconnected is set when the connections of the current launch are closed, so connections still being
+	// established are not registered.
+	disconnected bool
+	// done is closed when the tunnel is closed, to interrupt reconnection attempts.
+	done chan struct{}
+
+	// bandwidth limits the egress of the streams of the edge ingresses having a bandwidth limit.
+	bandwidth *bandwidthLimiters
 }
 
+func newTunnel(endpoint Endpoint, clusterEndpoint string, cfg Config) *tunnel {
+	bandwidth := &bandwidthLimiters{}
+	bandwidth.update(endpoint.BandwidthLimits)
+
+	return &tunnel{
+		BrokerEndpoint:         endpoint.BrokerEndpoint,
+		FallbackBrokerEndpoint: endpoint.FallbackBrokerEndpoint,
+		ClusterEndpoint:        clusterEndpoint,
+		Config:                 cfg,
+		endpoint:               endpoint,
+		connected:              make(chan struct{}),
+		done:                   make(chan struct{}),
+		bandwidth:              bandwidth,
+	}
+}
+
+func (t *tunnel) broker() string {
+	t.clientsMu.Lock()
+	defer t.clientsMu.Unlock()
+
+	return t.BrokerEndpoint
+}
+
+func (t *tunnel) setBroker(broker string) {
+	t.clientsMu.Lock()
+	defer t.clientsMu.Unlock()
+
+	t.BrokerEndpoint = broker
+}
+
+func (t *tunnel) markConnected() {
+	t.connectedOnce.Do(func() { close(t.connected) })
+}
+
+// Close closes the tunnel for good: its connections are closed and it won't reconnect.
 func (t *tunnel) Close() error {
-	if t.Client != nil {
-		return t.Client.Close()
+	t.clientsMu.Lock()
+	defer t.clientsMu.Unlock()
+
+	if !t.closed && t.done != nil {
+		close(t.done)
+	}
+	t.closed = true
+
+	return t.closeClients()
+}
+
+// disconnect closes the connections of the tunnel, which may then reconnect.
+func (t *tunnel) disconnect() error {
+	t.clientsMu.Lock()
+	defer t.clientsMu.Unlock()
+
+	t.disconnected = true
+
+	return t.closeClients()
+}
+
+// closeClients closes the connections of the tunnel. The clientsMu lock must be held.
+func (t *tunnel) closeClients() error {
+	var errs []string
+	for _, client := range t.clients {
+		if err := client.Close(); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	t.clients = nil
+
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, ", "))
 	}
 
 	return nil
 }
 
+// addClient registers a connection of the tunnel, unless the tunnel is closed or disconnected.
+func (t *tunnel) addClient(client *closeAwareListener) error {
+	t.clientsMu.Lock()
+	defer t.clientsMu.Unlock()
+
+	if t.closed || t.disconnected {
+		return errListenerClosed
+	}
+
+	t.clients = append(t.clients, client)
+
+	return nil
+}
+
 // NewManager returns a new manager instance.
-func NewManager(tunnels Backend, traefikTunnelAddr, token string) Manager {
+func NewManager(tunnels Backend, traefikTunnelAddr, token string, cfg Config) Manager {
 	return Manager{
 		client:            tunnels,
 		traefikTunnelAddr: traefikTunnelAddr,
 		token:             token,
+		config:            cfg,
 		tunnels:           make(map[string]*tunnel),
+		probe:             probeBroker,
 	}
 }
 
+// Ready reports whether the manager is able to fetch the tunnels of this cluster.
+func (m *Manager) Ready(ctx context.Context) error {
+	return m.ready.Check(ctx)
+}
+
 // Run runs the manager.
 // While running, the manager fetches every minute the tunnels available for
 // this cluster and create/delete tunnels accordingly.
@@ -82,6 +319,9 @@ func (m *Manager) Run(ctx context.Context) {
 
 	if err := m.updateTunnels(ctx); err != nil {
 		log.Error().Err(err).Msg("Unable to update tunnels")
+		m.ready.Set(fmt.Errorf("update tunnels: %w", err))
+	} else {
+		m.ready.SetReady()
 	}
 
 	for {
@@ -89,8 +329,10 @@ func (m *Manager) Run(ctx context.Context) {
 		case <-ticker.C:
 			if err := m.updateTunnels(ctx); err != nil {
 				log.Error().Err(err).Msg("Unable to update tunnels")
+				m.ready.Set(fmt.Errorf("update tunnels: %w", err))
 				continue
 			}
+			m.ready.SetReady()
 
 		case <-ctx.Done():
 			m.stop()
@@ -103,14 +345,26 @@ func (m *Manager) stop() {
 	m.tunnelsMu.Lock()
 	defer m.tunnelsMu.Unlock()
 
-	for id, tun := range m.tunnels {
-		if err := tun.Close(); err != nil {
-			log.Error().Err(err).
-				Str("tunnel_id", id).
-				Msg("Unable to close tunnel")
+	// Brokers stop opening new streams while the streams in flight complete.
+	if m.config.DrainTimeout > 0 {
+		var wg sync.WaitGroup
+		for id, t := range m.tunnels {
+			wg.Add(1)
+			go func(id string, t *tunnel) {
+				defer wg.Done()
+
+				if err := t.drain(m.config.DrainTimeout); err != nil {
+					log.Error().Err(err).Str("tunnel_id", id).Msg("Unable to close tunnel")
+				}
+			}(id, t)
 		}
-		delete(m.tunnels, id)
+		wg.Wait()
 	}
+
+	for id := range m.tunnels {
+		m.closeTunnel(id)
+	}
+	openTunnels.Set(0)
 }
 
 func (m *Manager) updateTunnels(ctx context.Context) error {
@@ -124,10 +378,6 @@ func (m *Manager) updateTunnels(ctx context.Context) error {
 
 	currentTunnels := make(map[string]struct{})
 	for _, endpoint := range endpoints {
-		logger := log.With().
-			Str("broker_endpoint", endpoint.BrokerEndpoint).
-			Str("tunnel_id", endpoint.TunnelID).
-			Logger()
 		currentTunnels[endpoint.TunnelID] = struct{}{}
 
 		tun, found := m.tunnels[endpoint.TunnelID]
@@ -137,87 +387,270 @@ func (m *Manager) updateTunnels(ctx context.Context) error {
 			continue
 		}
 
-		if tun.BrokerEndpoint != endpoint.BrokerEndpoint {
-			if err = tun.Close(); err != nil {
-				logger.Error().Err(err).Msg("Unable to close tunnel")
-			}
-			delete(m.tunnels, endpoint.TunnelID)
-
+		if !tun.endpoint.sameBrokers(endpoint) {
+			m.closeTunnel(endpoint.TunnelID)
 			m.launchTunnel(endpoint)
+
+			continue
 		}
+
+		tun.bandwidth.update(endpoint.BandwidthLimits)
 	}
 
 	for id := range m.tunnels {
 		if _, found := currentTunnels[id]; !found {
-			if err = m.tunnels[id].Close(); err != nil {
-				log.Error().Err(err).Msg("Unable to close tunnel")
-			}
-			delete(m.tunnels, id)
+			m.closeTunnel(id)
 		}
 	}
+	openTunnels.Set(float64(len(m.tunnels)))
 
 	return nil
 }
 
 func (m *Manager) launchTunnel(endpoint Endpoint) {
-	t := &tunnel{BrokerEndpoint: endpoint.BrokerEndpoint, ClusterEndpoint: m.traefikTunnelAddr}
+	t := newTunnel(endpoint, m.traefikTunnelAddr, m.config)
 	m.tunnels[endpoint.TunnelID] = t
+	tunnelStatsCollector.add(endpoint.TunnelID, t)
 
 	go func(t *tunnel, tunnelID string) {
-		err := t.launch(tunnelID, m.token)
-		if err != nil {
-			log.Error().Err(err).Msg("Launch tunnel")
-		}
+		t.setBroker(m.selectBroker(t.endpoint.brokerCandidates(), ""))
 
-		m.tunnelsMu.Lock()
-		delete(m.tunnels, tunnelID)
-		m.tunnelsMu.Unlock()
+		go m.reevaluateBroker(t, tunnelID)
+		m.runTunnel(t, tunnelID)
 	}(t, endpoint.TunnelID)
 }
 
-func (t *tunnel) launch(tunnelID, token string) error {
-	u, err := url.Parse(t.BrokerEndpoint)
-	if err != nil {
-		return fmt.Errorf("parse broker endpoint: %w", err)
+// closeTunnel closes the given tunnel and stops managing it. The tunnelsMu lock must be held.
+func (m *Manager) closeTunnel(tunnelID string) {
+	t := m.tunnels[tunnelID]
+	if err := t.Close(); err != nil {
+		log.Error().Err(err).Str("tunnel_id", tunnelID).Msg("Unable to close tunnel")
 	}
-	u.Path = path.Join(u.Path, tunnelID)
 
-	dialer := websocket.Dialer{
-		Proxy:            http.ProxyFromEnvironment,
-		HandshakeTimeout: 30 * time.Second,
+	delete(m.tunnels, tunnelID)
+	tunnelStatsCollector.remove(tunnelID, t)
+}
+
+// runTunnel launches the given tunnel and relaunches it each time it gets disconnected, until it's closed. Attempts are
+// spaced by a jittered exponential backoff, reset once the tunnel is connected again.
+func (m *Manager) runTunnel(t *tunnel, tunnelID string) {
+	logger := log.With().Str("tunnel_id", tunnelID).Logger()
+
+	exp := newReconnectBackOff(t.Config)
+
+	// Neither is accessed concurrently: the connected callback is called while launch is running.
+	var disconnectedAt time.Time
+	var outageReported bool
+
+	for {
+		err := t.launch(tunnelID, m.token, func(transport string) {
+			exp.Reset()
+
+			if !disconnectedAt.IsZero() {
+				outage := time.Since(disconnectedAt)
+				disconnectedAt = time.Time{}
+				outageReported = false
+
+				atomic.AddInt64(&t.stats.reconnections, 1)
+				tunnelOutageDuration.Observe(outage.Seconds())
+				m.recordEvent(corev1.EventTypeNormal, "TunnelReconnected",
+					fmt.Sprintf("Tunnel %s reconnected using %s transport after %s", tunnelID, transport, outage.Round(time.Second)))
+			}
+
+			t.setTransport(transport)
+			t.markConnected()
+			log.Info().
+				Str("tunnel_id", tunnelID).
+				Str("broker_endpoint", t.broker()).
+				Str("transport", transport).
+				Msg("Tunnel connected")
+		})
+
+		select {
+		case <-t.done:
+			return
+		default:
+		}
+
+		if disconnectedAt.IsZero() {
+			disconnectedAt = time.Now()
+
+			tunnelFailuresTotal.Inc()
+			msg := fmt.Sprintf("Tunnel %s disconnected", tunnelID)
+			if err != nil {
+				msg = fmt.Sprintf("Tunnel %s disconnected: %v", tunnelID, err)
+			}
+			m.recordEvent(corev1.EventTypeWarning, "TunnelDisconnected", msg)
+		}
+
+		outage := time.Since(disconnectedAt)
+		if !outageReported && outage > t.Config.MaxOutage {
+			outageReported = true
+
+			logger.Error().Err(err).Dur("outage", outage).Msg("Tunnel disconnected for longer than the maximum outage")
+			m.recordEvent(corev1.EventTypeWarning, "TunnelOutageBudgetExceeded",
+				fmt.Sprintf("Tunnel %s has been disconnected for more than %s", tunnelID, t.Config.MaxOutage))
+		}
+
+		retryIn := exp.NextBackOff()
+		logger.Warn().Err(err).Dur("retry_in", retryIn).Msg("Tunnel disconnected, reconnecting")
+
+		timer := time.NewTimer(retryIn)
+		select {
+		case <-timer.C:
+		case <-t.done:
+			timer.Stop()
+			return
+		}
+
+		// Fail over to another broker, if any can be reached.
+		t.setBroker(m.selectBroker(t.endpoint.brokerCandidates(), t.broker()))
 	}
-	connSocket, resp, err := dialer.Dial(u.String(), http.Header{"Authorization": []string{"Bearer " + token}})
-	if err != nil {
-		return fmt.Errorf("dial: %w", err)
+}
+
+func newReconnectBackOff(cfg Config) *backoff.ExponentialBackOff {
+	exp := backoff.NewExponentialBackOff()
+	if cfg.ReconnectInitialInterval > 0 {
+		exp.InitialInterval = cfg.ReconnectInitialInterval
 	}
+	if cfg.ReconnectMaxInterval > 0 {
+		exp.MaxInterval = cfg.ReconnectMaxInterval
+	}
+	exp.MaxElapsedTime = 0
+	exp.RandomizationFactor = 0.5
+	exp.Reset()
 
-	if resp.StatusCode != http.StatusSwitchingProtocols {
-		return fmt.Errorf("expected protocol switching, got: %d", resp.StatusCode)
+	return exp
+}
+
+// recordEvent records an event about tunnels on the agent, if a recorder is configured.
+func (m *Manager) recordEvent(eventType, reason, msg string) {
+	if m.config.Recorder != nil && m.config.AgentRef != nil {
+		m.config.Recorder.Event(m.config.AgentRef, eventType, reason, msg)
+	}
+}
+
+func (t *tunnel) setTransport(transport string) {
+	t.clientsMu.Lock()
+	defer t.clientsMu.Unlock()
+
+	t.transport = transport
+}
+
+// currentTransport returns the transport used by the connections of the tunnel, empty until connected.
+func (t *tunnel) currentTransport() string {
+	t.clientsMu.Lock()
+	defer t.clientsMu.Unlock()
+
+	return t.transport
+}
+
+// launch opens the pool of connections of the tunnel and proxies the streams opened by the broker on them to the
+// cluster endpoint. It returns when the tunnel is closed, or when one of its connections fails, in which case all the
+// connections of the tunnel are closed for it to be launched again. The connected callback is called once, when the
+// first connection is established.
+func (t *tunnel) launch(tunnelID, token string, connected func(transport string)) error {
+	poolSize := t.Config.PoolSize
+	if poolSize < 1 {
+		poolSize = 1
+	}
+
+	t.clientsMu.Lock()
+	t.disconnected = false
+	t.clientsMu.Unlock()
+
+	var reportOnce sync.Once
+	errCh := make(chan error, poolSize)
+	for i := 0; i < poolSize; i++ {
+		go func() {
+			errCh <- t.serve(tunnelID, token, func(transport string) {
+				reportOnce.Do(func() { connected(transport) })
+			})
+		}()
+	}
+
+	var err error
+	for i := 0; i < poolSize; i++ {
+		connErr := <-errCh
+		if connErr == nil || err != nil {
+			continue
+		}
+
+		err = connErr
+		if closeErr := t.disconnect(); closeErr != nil {
+			log.Error().Err(closeErr).Str("tunnel_id", tunnelID).Msg("Unable to close tunnel connections")
+		}
+	}
+
+	return err
+}
+
+// serve opens a connection with the broker and proxies the streams opened on it until it's closed.
+func (t *tunnel) serve(tunnelID, token string, connected func(transport string)) error {
+	connSocket, transport, err := t.dial(tunnelID, token)
+	if err != nil {
+		atomic.AddInt64(&t.stats.handshakeFailures, 1)
+		return err
 	}
 
 	conn := &websocketNetConn{
 		Conn: connSocket,
 	}
 
+	keepAliveInterval := t.Config.KeepAliveInterval
+	if keepAliveInterval <= 0 {
+		keepAliveInterval = 10 * time.Second
+	}
+
+	keepAliveTimeout := t.Config.KeepAliveTimeout
+	if keepAliveTimeout <= 0 {
+		keepAliveTimeout = 5 * time.Second
+	}
+
+	streamWindowSize := t.Config.StreamWindowSize
+	if streamWindowSize < defaultStreamWindowSize {
+		streamWindowSize = defaultStreamWindowSize
+	}
+
 	cfg := &yamux.Config{
-		AcceptBacklog:          256,
-		EnableKeepAlive:        true,
-		KeepAliveInterval:      30 * time.Second,
+		AcceptBacklog: 256,
+		// Connections are kept alive by the tunnel itself, which also bounds the time to wait for a pong.
+		EnableKeepAlive:        false,
+		KeepAliveInterval:      keepAliveInterval,
 		ConnectionWriteTimeout: 10 * time.Second,
-		MaxStreamWindowSize:    256 * 1024,
+		MaxStreamWindowSize:    streamWindowSize,
 		StreamOpenTimeout:      75 * time.Second,
 		StreamCloseTimeout:     5 * time.Minute,
 		LogOutput:              io.Discard,
 	}
-	client, err := yamux.Client(conn, cfg)
+	session, err := yamux.Client(conn, cfg)
 	if err != nil {
+		_ = conn.Close()
 		return fmt.Errorf("new yamux client: %w", err)
 	}
 
-	t.Client = &closeAwareListener{Listener: client}
+	client := &closeAwareListener{Listener: session}
+	if err = t.addClient(client); err != nil {
+		_ = session.Close()
+		return nil
+	}
+
+	connected(transport)
+
+	go t.keepAlive(tunnelID, session, keepAliveInterval, keepAliveTimeout)
+
+	// Slots of the streams proxied concurrently, if limited.
+	var slots chan struct{}
+	if t.Config.MaxStreamsPerConnection > 0 {
+		slots = make(chan struct{}, t.Config.MaxStreamsPerConnection)
+	}
 
 	for {
-		brokerConn, acceptErr := t.Client.Accept()
+		if slots != nil {
+			slots <- struct{}{}
+		}
+
+		brokerConn, acceptErr := client.Accept()
 		if acceptErr != nil {
 			if errors.Is(acceptErr, errListenerClosed) {
 				return nil
@@ -227,26 +660,194 @@ func (t *tunnel) launch(tunnelID, token string) error {
 		}
 
 		go func(brokerConn net.Conn) {
-			if err = proxy(brokerConn, t.ClusterEndpoint); err != nil {
+			if slots != nil {
+				defer func() { <-slots }()
+			}
+
+			atomic.AddInt64(&t.stats.activeStreams, 1)
+			defer atomic.AddInt64(&t.stats.activeStreams, -1)
+
+			if err := proxy(t.bandwidth.limit(brokerConn), t.ClusterEndpoint, &t.stats, t.Config.StreamIdleTimeout); err != nil {
 				log.Error().Err(err).Msg("Unable to proxy the tunnel traffic to the cluster endpoint")
 			}
 		}(brokerConn)
 	}
 }
 
-func proxy(sourceConn net.Conn, addr string) error {
+// keepAlive pings periodically the broker on the given session, until it's closed. The round-trip time of the pings
+// is recorded, and the session is closed if a ping isn't answered within timeout so the tunnel reconnects.
+func (t *tunnel) keepAlive(tunnelID string, session *yamux.Session, interval, timeout time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		rtt, err := ping(session, timeout)
+		switch {
+		case err == nil:
+			atomic.StoreInt64(&t.stats.rtt, int64(rtt))
+		case errors.Is(err, errPingTimeout) || errors.Is(err, yamux.ErrTimeout):
+			deadConnectionsTotal.Inc()
+			log.Warn().Str("tunnel_id", tunnelID).Dur("timeout", timeout).Msg("Tunnel connection not answering pings, closing it")
+
+			_ = session.Close()
+			return
+		}
+
+		select {
+		case <-ticker.C:
+		case <-session.CloseChan():
+			return
+		}
+	}
+}
+
+var errPingTimeout = errors.New("ping timeout")
+
+// ping pings the broker on the given session, waiting for the pong at most timeout.
+func ping(session *yamux.Session, timeout time.Duration) (time.Duration, error) {
+	type result struct {
+		rtt time.Duration
+		err error
+	}
+
+	// The ping keeps waiting in the background if it times out, until the session is closed.
+	resultCh := make(chan result, 1)
+	go func() {
+		rtt, err := session.Ping()
+		resultCh <- result{rtt: rtt, err: err}
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case res := <-resultCh:
+		return res.rtt, res.err
+	case <-timer.C:
+		return 0, errPingTimeout
+	}
+}
+
+// dial connects to the broker endpoint, falling back to WebSocket over TLS on port 443 when it can't be reached.
+func (t *tunnel) dial(tunnelID, token string) (*websocket.Conn, string, error) {
+	conn, reached, err := dialBroker(t.broker(), tunnelID, token, t.Config.Proxy, directHandshakeTimeout)
+	if err == nil {
+		return conn, TransportDirect, nil
+	}
+
+	// A broker answering, even with an error, is not blocked.
+	fallback := t.fallbackEndpoint()
+	if reached || fallback == "" {
+		return nil, "", err
+	}
+
+	log.Warn().Err(err).
+		Str("tunnel_id", tunnelID).
+		Str("fallback_broker_endpoint", fallback).
+		Msg("Unable to reach the broker endpoint, falling back to WebSocket over TLS")
+
+	conn, _, fallbackErr := dialBroker(fallback, tunnelID, token, t.Config.Proxy, 30*time.Second)
+	if fallbackErr != nil {
+		return nil, "", fmt.Errorf("%v, fallback: %w", err, fallbackErr)
+	}
+
+	return conn, TransportFallback, nil
+}
+
+// fallbackEndpoint returns the endpoint used when the broker endpoint can't be reached. It's the broker endpoint over
+// WebSocket/TLS on port 443 unless specified, or empty if the broker endpoint already is.
+func (t *tunnel) fallbackEndpoint() string {
+	if t.FallbackBrokerEndpoint != "" {
+		return t.FallbackBrokerEndpoint
+	}
+
+	u, err := url.Parse(t.broker())
+	if err != nil || u.Hostname() == "" {
+		return ""
+	}
+
+	if u.Scheme == "wss" && (u.Port() == "" || u.Port() == "443") {
+		return ""
+	}
+
+	u.Scheme = "wss"
+	u.Host = net.JoinHostPort(u.Hostname(), "443")
+
+	return u.String()
+}
+
+// dialBroker opens a WebSocket connection to the given broker endpoint for the given tunnel, through the configured
+// proxy if any. It reports whether the broker answered, in which case the error is not caused by the network.
+func dialBroker(endpoint, tunnelID, token string, proxyCfg ProxyConfig, handshakeTimeout time.Duration) (*websocket.Conn, bool, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, false, fmt.Errorf("parse broker endpoint: %w", err)
+	}
+	u.Path = path.Join(u.Path, tunnelID)
+
+	proxyURL, err := proxyCfg.proxyURL(u)
+	if err != nil {
+		return nil, false, err
+	}
+
+	dialer := websocket.Dialer{
+		HandshakeTimeout: handshakeTimeout,
+	}
+	if proxyURL != nil {
+		dialer.NetDialContext = func(ctx context.Context, _, addr string) (net.Conn, error) {
+			return dialThroughProxy(ctx, proxyURL, addr)
+		}
+	}
+	conn, resp, err := dialer.Dial(u.String(), http.Header{"Authorization": []string{"Bearer " + token}})
+	if err != nil {
+		return nil, resp != nil, fmt.Errorf("dial: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		_ = conn.Close()
+		return nil, true, fmt.Errorf("expected protocol switching, got: %d", resp.StatusCode)
+	}
+
+	return conn, true, nil
+}
+
+// proxy proxies the raw bytes of the given broker connection to the given cluster address, in both directions, counting
+// the bytes exchanged in the given statistics. Each direction is closed independently once drained, so protocols
+// relying on TCP half-close work. The stream is closed when no traffic flowed in either direction for idleTimeout, if
+// positive.
+func proxy(sourceConn net.Conn, addr string, stats *tunnelStats, idleTimeout time.Duration) error {
 	targetConn, err := net.Dial("tcp", addr)
 	if err != nil {
 		return fmt.Errorf("dial: %w", err)
 	}
 
-	errCh := make(chan error)
+	lastActivity := time.Now().UnixNano()
+	errCh := make(chan error, 2)
 
-	go connCopy(errCh, targetConn, sourceConn)
-	go connCopy(errCh, sourceConn, targetConn)
+	go connCopy(errCh, targetConn, sourceConn, &stats.bytesReceived, &lastActivity)
+	go connCopy(errCh, sourceConn, targetConn, &stats.bytesSent, &lastActivity)
+
+	done := make(chan struct{})
+	idled := make(chan struct{})
+	if idleTimeout > 0 {
+		go closeOnIdle(done, idled, idleTimeout, &lastActivity, sourceConn, targetConn)
+	}
 
 	err = <-errCh
-	<-errCh
+	if copyErr := <-errCh; err == nil {
+		err = copyErr
+	}
+	close(done)
+
+	_ = sourceConn.Close()
+	_ = targetConn.Close()
+
+	select {
+	case <-idled:
+		log.Debug().Str("addr", addr).Dur("idle_timeout", idleTimeout).Msg("Tunnel stream closed after being idle")
+		return nil
+	default:
+	}
 
 	if err != nil {
 		return fmt.Errorf("copy conn: %w", err)
@@ -255,11 +856,44 @@ func proxy(sourceConn net.Conn, addr string) error {
 	return nil
 }
 
-func connCopy(errCh chan<- error, dst io.WriteCloser, src io.Reader) {
-	_, err := io.Copy(dst, src)
+// closeOnIdle closes the given connections and idled when no activity was recorded for timeout, until done is closed.
+func closeOnIdle(done <-chan struct{}, idled chan<- struct{}, timeout time.Duration, lastActivity *int64, conns ...net.Conn) {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-timer.C:
+			idle := time.Since(time.Unix(0, atomic.LoadInt64(lastActivity)))
+			if idle < timeout {
+				timer.Reset(timeout - idle)
+				continue
+			}
+
+			close(idled)
+			for _, conn := range conns {
+				// Closing a multiplexed stream only half-closes it: the deadline unblocks its pending reads.
+				_ = conn.SetDeadline(time.Now())
+				_ = conn.Close()
+			}
+			return
+		}
+	}
+}
+
+func connCopy(errCh chan<- error, dst net.Conn, src io.Reader, n, lastActivity *int64) {
+	_, err := io.Copy(countingWriter{Writer: dst, n: n, lastActivity: lastActivity}, src)
 	errCh <- err
 
-	if err = dst.Close(); err != nil {
+	// Only close the write side of the destination when possible, the other direction may still be in use.
+	if cw, ok := dst.(interface{ CloseWrite() error }); ok {
+		err = cw.CloseWrite()
+	} else {
+		err = dst.Close()
+	}
+	if err != nil && !errors.Is(err, net.ErrClosed) {
 		log.Error().Err(err).Msg("Unable to close destination connection")
 	}
 }
@@ -19,11 +19,16 @@ package tunnel
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"io"
 	"net"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -31,6 +36,8 @@ import (
 	"github.com/hashicorp/yamux"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
 )
 
 func TestManager_updateTunnels(t *testing.T) {
@@ -72,16 +79,16 @@ func TestManager_updateTunnels(t *testing.T) {
 	}
 
 	c := fakeClient(t)
-	manager := NewManager(client, ingCtrlServiceURL, "token")
+	manager := NewManager(client, ingCtrlServiceURL, "token", DefaultConfig())
 	manager.tunnels["current-tunnel-new-broker"] = &tunnel{
 		BrokerEndpoint:  "old-endpoint",
 		ClusterEndpoint: ingCtrlServiceURL,
-		Client:          &closeAwareListener{Listener: c},
+		clients:         []*closeAwareListener{{Listener: c}},
 	}
 	manager.tunnels["unused-tunnel"] = &tunnel{
 		BrokerEndpoint:  "old-endpoint",
 		ClusterEndpoint: ingCtrlServiceURL,
-		Client:          &closeAwareListener{Listener: c},
+		clients:         []*closeAwareListener{{Listener: c}},
 	}
 
 	stopped := make(chan struct{})
@@ -118,6 +125,374 @@ func TestManager_updateTunnels(t *testing.T) {
 	manager.tunnelsMu.Unlock()
 }
 
+func TestManager_updateTunnels_fallback(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	wait := make(chan struct{})
+	ingCtrlServiceURL := createIngCtrlService(t, wait, "fTunnel")
+
+	broker := buildBroker(t, []byte("fTunnel"), "fallback-tunnel")
+	brokerURL, err := url.Parse(broker.URL)
+	require.NoError(t, err)
+
+	// Reserve a port on which nothing listens to simulate a blocked broker endpoint.
+	blocked, err := net.Listen("tcp", net.JoinHostPort("127.0.0.1", "0"))
+	require.NoError(t, err)
+	blockedAddr := blocked.Addr().String()
+	require.NoError(t, blocked.Close())
+
+	client := &clientMock{
+		listClusterTunnelEndpoints: func() ([]Endpoint, error) {
+			return []Endpoint{
+				{
+					TunnelID:               "fallback-tunnel",
+					BrokerEndpoint:         "ws://" + blockedAddr,
+					FallbackBrokerEndpoint: "ws://" + brokerURL.Host,
+				},
+			}, nil
+		},
+	}
+
+	manager := NewManager(client, ingCtrlServiceURL, "token", DefaultConfig())
+	go manager.Run(ctx)
+
+	select {
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout")
+	case <-wait:
+	}
+
+	manager.tunnelsMu.Lock()
+	tun := manager.tunnels["fallback-tunnel"]
+	manager.tunnelsMu.Unlock()
+	require.NotNil(t, tun)
+
+	assert.Eventually(t, func() bool {
+		return tun.currentTransport() == TransportFallback
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Zero(t, atomic.LoadInt64(&tun.stats.handshakeFailures))
+}
+
+func TestManager_updateTunnels_pool(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var connsMu sync.Mutex
+	var conns int
+	broker := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		upgrader := &websocket.Upgrader{}
+		websocketConn, err := upgrader.Upgrade(rw, req, nil)
+		if !assert.NoError(t, err) {
+			return
+		}
+		defer func() { _ = websocketConn.Close() }()
+
+		connsMu.Lock()
+		conns++
+		connsMu.Unlock()
+
+		cfg := yamux.DefaultConfig()
+		cfg.LogOutput = io.Discard
+		server, err := yamux.Server(&websocketNetConn{Conn: websocketConn}, cfg)
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		<-req.Context().Done()
+		_ = server.Close()
+	}))
+	t.Cleanup(broker.Close)
+
+	brokerURL, err := url.Parse(broker.URL)
+	require.NoError(t, err)
+
+	client := &clientMock{
+		listClusterTunnelEndpoints: func() ([]Endpoint, error) {
+			return []Endpoint{{TunnelID: "pooled-tunnel", BrokerEndpoint: "ws://" + brokerURL.Host}}, nil
+		},
+	}
+
+	cfg := DefaultConfig()
+	cfg.PoolSize = 3
+	manager := NewManager(client, "127.0.0.1:0", "token", cfg)
+	go manager.Run(ctx)
+
+	assert.Eventually(t, func() bool {
+		connsMu.Lock()
+		defer connsMu.Unlock()
+
+		return conns == 3
+	}, 5*time.Second, 10*time.Millisecond)
+
+	assert.Eventually(t, func() bool {
+		manager.tunnelsMu.Lock()
+		defer manager.tunnelsMu.Unlock()
+
+		tun, ok := manager.tunnels["pooled-tunnel"]
+		if !ok {
+			return false
+		}
+
+		tun.clientsMu.Lock()
+		defer tun.clientsMu.Unlock()
+
+		return len(tun.clients) == 3
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestConfig_Validate(t *testing.T) {
+	assert.NoError(t, DefaultConfig().Validate())
+	assert.Error(t, Config{PoolSize: 0, KeepAliveInterval: time.Second}.Validate())
+	assert.Error(t, Config{PoolSize: 1, MaxStreamsPerConnection: -1, KeepAliveInterval: time.Second}.Validate())
+	assert.Error(t, Config{PoolSize: 1}.Validate())
+
+	cfg := DefaultConfig()
+	cfg.ReconnectMaxInterval = cfg.ReconnectInitialInterval / 2
+	assert.Error(t, cfg.Validate())
+
+	cfg = DefaultConfig()
+	cfg.MaxOutage = 0
+	assert.Error(t, cfg.Validate())
+
+	cfg = DefaultConfig()
+	cfg.KeepAliveTimeout = 0
+	assert.Error(t, cfg.Validate())
+}
+
+func TestTunnel_keepAlive(t *testing.T) {
+	tests := []struct {
+		desc       string
+		answering  bool
+		wantClosed bool
+	}{
+		{
+			desc:      "broker answering pings",
+			answering: true,
+		},
+		{
+			desc:       "half-open connection",
+			wantClosed: true,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			clientConn, brokerConn := net.Pipe()
+			t.Cleanup(func() { _ = brokerConn.Close() })
+
+			if test.answering {
+				broker, err := yamux.Server(brokerConn, nil)
+				require.NoError(t, err)
+				t.Cleanup(func() { _ = broker.Close() })
+			} else {
+				// Pings are received but never answered, like on a connection dropped by a NAT.
+				go func() { _, _ = io.Copy(io.Discard, brokerConn) }()
+			}
+
+			cfg := yamux.DefaultConfig()
+			cfg.EnableKeepAlive = false
+			cfg.LogOutput = io.Discard
+			session, err := yamux.Client(clientConn, cfg)
+			require.NoError(t, err)
+			t.Cleanup(func() { _ = session.Close() })
+
+			tun := &tunnel{}
+			go tun.keepAlive("tunnel-id", session, 10*time.Millisecond, 50*time.Millisecond)
+
+			if test.wantClosed {
+				assert.Eventually(t, session.IsClosed, time.Second, 10*time.Millisecond)
+				return
+			}
+
+			assert.Eventually(t, func() bool {
+				return atomic.LoadInt64(&tun.stats.rtt) > 0
+			}, time.Second, 10*time.Millisecond)
+
+			time.Sleep(100 * time.Millisecond)
+			assert.False(t, session.IsClosed())
+		})
+	}
+}
+
+func TestManager_stop_drainsStreams(t *testing.T) {
+	clientConn, brokerConn := net.Pipe()
+	t.Cleanup(func() { _ = brokerConn.Close() })
+
+	broker, err := yamux.Server(brokerConn, nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = broker.Close() })
+
+	cfg := yamux.DefaultConfig()
+	cfg.LogOutput = io.Discard
+	session, err := yamux.Client(clientConn, cfg)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = session.Close() })
+
+	tun := newTunnel(Endpoint{}, "", Config{})
+	require.NoError(t, tun.addClient(&closeAwareListener{Listener: session}))
+
+	m := NewManager(nil, "", "", Config{DrainTimeout: time.Minute})
+	m.tunnels["tunnel-id"] = tun
+
+	// A stream is in flight.
+	brokerStream, err := broker.Open()
+	require.NoError(t, err)
+	stream, err := session.Accept()
+	require.NoError(t, err)
+
+	stopped := make(chan struct{})
+	go func() {
+		m.stop()
+		close(stopped)
+	}()
+
+	assert.Eventually(t, func() bool {
+		_, openErr := broker.Open()
+		return errors.Is(openErr, yamux.ErrRemoteGoAway)
+	}, time.Second, 10*time.Millisecond)
+
+	select {
+	case <-stopped:
+		require.Fail(t, "manager stopped with a stream in flight")
+	case <-time.After(200 * time.Millisecond):
+	}
+	assert.False(t, session.IsClosed())
+
+	_ = brokerStream.Close()
+	_ = stream.Close()
+
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		require.Fail(t, "manager not stopped once the streams completed")
+	}
+
+	assert.True(t, session.IsClosed())
+	assert.Empty(t, m.tunnels)
+}
+
+func TestManager_runTunnel_reconnects(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var connsMu sync.Mutex
+	var conns int
+	broker := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		upgrader := &websocket.Upgrader{}
+		websocketConn, err := upgrader.Upgrade(rw, req, nil)
+		if !assert.NoError(t, err) {
+			return
+		}
+		defer func() { _ = websocketConn.Close() }()
+
+		connsMu.Lock()
+		conns++
+		first := conns == 1
+		connsMu.Unlock()
+
+		// Simulate a broker restart by dropping the first connection.
+		if first {
+			return
+		}
+
+		cfg := yamux.DefaultConfig()
+		cfg.LogOutput = io.Discard
+		server, err := yamux.Server(&websocketNetConn{Conn: websocketConn}, cfg)
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		<-req.Context().Done()
+		_ = server.Close()
+	}))
+	t.Cleanup(broker.Close)
+
+	brokerURL, err := url.Parse(broker.URL)
+	require.NoError(t, err)
+
+	client := &clientMock{
+		listClusterTunnelEndpoints: func() ([]Endpoint, error) {
+			return []Endpoint{{TunnelID: "flaky-tunnel", BrokerEndpoint: "ws://" + brokerURL.Host}}, nil
+		},
+	}
+
+	recorder := record.NewFakeRecorder(10)
+
+	cfg := DefaultConfig()
+	cfg.ReconnectInitialInterval = 10 * time.Millisecond
+	cfg.ReconnectMaxInterval = 50 * time.Millisecond
+	cfg.Recorder = recorder
+	cfg.AgentRef = &corev1.ObjectReference{Kind: "Pod", Name: "hub-agent", Namespace: "hub"}
+	manager := NewManager(client, "127.0.0.1:0", "token", cfg)
+	go manager.Run(ctx)
+
+	for _, expected := range []string{
+		"Warning TunnelDisconnected Tunnel flaky-tunnel disconnected",
+		"Normal TunnelReconnected Tunnel flaky-tunnel reconnected using direct transport",
+	} {
+		select {
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timeout waiting for event %q", expected)
+		case event := <-recorder.Events:
+			assert.True(t, strings.HasPrefix(event, expected), event)
+		}
+	}
+
+	connsMu.Lock()
+	defer connsMu.Unlock()
+	assert.Equal(t, 2, conns)
+}
+
+func TestTunnel_fallbackEndpoint(t *testing.T) {
+	tests := []struct {
+		desc     string
+		tunnel   *tunnel
+		expected string
+	}{
+		{
+			desc:     "explicit fallback endpoint",
+			tunnel:   &tunnel{BrokerEndpoint: "ws://broker.example.com:8080", FallbackBrokerEndpoint: "wss://fallback.example.com/tunnels"},
+			expected: "wss://fallback.example.com/tunnels",
+		},
+		{
+			desc:     "derived from a WebSocket broker endpoint",
+			tunnel:   &tunnel{BrokerEndpoint: "ws://broker.example.com:8080/tunnels"},
+			expected: "wss://broker.example.com:443/tunnels",
+		},
+		{
+			desc:     "derived from a WebSocket/TLS broker endpoint on another port",
+			tunnel:   &tunnel{BrokerEndpoint: "wss://broker.example.com:8443"},
+			expected: "wss://broker.example.com:443",
+		},
+		{
+			desc:     "derived from an IPv6 broker endpoint",
+			tunnel:   &tunnel{BrokerEndpoint: "ws://[2001:db8::1]:8080/tunnels"},
+			expected: "wss://[2001:db8::1]:443/tunnels",
+		},
+		{
+			desc:   "broker endpoint already on port 443",
+			tunnel: &tunnel{BrokerEndpoint: "wss://broker.example.com"},
+		},
+		{
+			desc:   "broker endpoint explicitly on port 443",
+			tunnel: &tunnel{BrokerEndpoint: "wss://broker.example.com:443"},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, test.expected, test.tunnel.fallbackEndpoint())
+		})
+	}
+}
+
 func Test_proxy(t *testing.T) {
 	echoListener, err := net.Listen("tcp", net.JoinHostPort("127.0.0.1", "0"))
 	require.NoError(t, err)
@@ -140,11 +515,12 @@ func Test_proxy(t *testing.T) {
 	require.NoError(t, err)
 
 	// Start proxy server.
+	var stats tunnelStats
 	go func() {
 		conn, aerr := proxyListener.Accept()
 		require.NoError(t, aerr)
 
-		perr := proxy(conn, echoListener.Addr().String())
+		perr := proxy(conn, echoListener.Addr().String(), &stats, 0)
 		require.NoError(t, perr)
 	}()
 
@@ -168,6 +544,11 @@ func Test_proxy(t *testing.T) {
 	assert.Equal(t, len(message), read)
 
 	assert.Equal(t, message, received[:read])
+
+	assert.Eventually(t, func() bool {
+		return atomic.LoadInt64(&stats.bytesReceived) == int64(len(message)) &&
+			atomic.LoadInt64(&stats.bytesSent) == int64(len(message))
+	}, time.Second, 10*time.Millisecond)
 }
 
 func Test_proxy_targetUnreachable(t *testing.T) {
@@ -197,10 +578,109 @@ func Test_proxy_targetUnreachable(t *testing.T) {
 
 	<-ready
 
-	err = proxy(proxyConn, "127.0.0.1:44444")
+	err = proxy(proxyConn, "127.0.0.1:44444", &tunnelStats{}, 0)
 	require.Error(t, err)
 }
 
+func Test_proxy_halfClose(t *testing.T) {
+	targetListener, err := net.Listen("tcp", net.JoinHostPort("127.0.0.1", "0"))
+	require.NoError(t, err)
+
+	// Start a server replying with the size of the request once it has been fully received.
+	go func() {
+		conn, aerr := targetListener.Accept()
+		require.NoError(t, aerr)
+		defer func() { _ = conn.Close() }()
+
+		request, rerr := io.ReadAll(conn)
+		require.NoError(t, rerr)
+
+		_, werr := fmt.Fprintf(conn, "%d", len(request))
+		require.NoError(t, werr)
+	}()
+
+	proxyListener, err := net.Listen("tcp", net.JoinHostPort("127.0.0.1", "0"))
+	require.NoError(t, err)
+
+	proxyErr := make(chan error, 1)
+	go func() {
+		conn, aerr := proxyListener.Accept()
+		require.NoError(t, aerr)
+
+		proxyErr <- proxy(conn, targetListener.Addr().String(), &tunnelStats{}, 0)
+	}()
+
+	conn, err := net.Dial("tcp", proxyListener.Addr().String())
+	require.NoError(t, err)
+	err = conn.SetDeadline(time.Now().Add(time.Second))
+	require.NoError(t, err)
+
+	_, err = conn.Write([]byte("hello"))
+	require.NoError(t, err)
+
+	// Signal the end of the request while still expecting the response.
+	err = conn.(*net.TCPConn).CloseWrite()
+	require.NoError(t, err)
+
+	response, err := io.ReadAll(conn)
+	require.NoError(t, err)
+	assert.Equal(t, "5", string(response))
+
+	select {
+	case err = <-proxyErr:
+		require.NoError(t, err)
+	case <-time.After(time.Second):
+		require.Fail(t, "proxy didn't return")
+	}
+}
+
+func Test_proxy_idleTimeout(t *testing.T) {
+	targetListener, err := net.Listen("tcp", net.JoinHostPort("127.0.0.1", "0"))
+	require.NoError(t, err)
+
+	// Start a server which never replies.
+	go func() {
+		conn, aerr := targetListener.Accept()
+		require.NoError(t, aerr)
+
+		_, _ = io.Copy(io.Discard, conn)
+	}()
+
+	proxyListener, err := net.Listen("tcp", net.JoinHostPort("127.0.0.1", "0"))
+	require.NoError(t, err)
+
+	proxyErr := make(chan error, 1)
+	go func() {
+		conn, aerr := proxyListener.Accept()
+		require.NoError(t, aerr)
+
+		proxyErr <- proxy(conn, targetListener.Addr().String(), &tunnelStats{}, 100*time.Millisecond)
+	}()
+
+	conn, err := net.Dial("tcp", proxyListener.Addr().String())
+	require.NoError(t, err)
+	err = conn.SetDeadline(time.Now().Add(time.Second))
+	require.NoError(t, err)
+
+	// Traffic postpones the idle timeout.
+	for i := 0; i < 3; i++ {
+		_, err = conn.Write([]byte("ping"))
+		require.NoError(t, err)
+
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	select {
+	case err = <-proxyErr:
+		require.NoError(t, err)
+	case <-time.After(time.Second):
+		require.Fail(t, "idle stream not closed")
+	}
+
+	_, err = conn.Read(make([]byte, 1))
+	assert.ErrorIs(t, err, io.EOF)
+}
+
 func createIngCtrlService(t *testing.T, wait chan struct{}, messages ...string) string {
 	t.Helper()
 
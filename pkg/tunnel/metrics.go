@@ -0,0 +1,152 @@
+/*
+Copyright (C) 2022 Traefik Labs
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package tunnel
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	metricsNamespace = "hub_agent"
+	metricsSubsystem = "tunnel"
+)
+
+var (
+	openTunnels = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Subsystem: metricsSubsystem,
+		Name:      "open",
+		Help:      "Number of tunnels currently open with the Hub platform.",
+	})
+	tunnelFailuresTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Subsystem: metricsSubsystem,
+		Name:      "failures_total",
+		Help:      "Number of tunnels closed because of an error.",
+	})
+	deadConnectionsTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Subsystem: metricsSubsystem,
+		Name:      "dead_connections_total",
+		Help:      "Number of tunnel connections closed because they stopped answering pings.",
+	})
+	tunnelOutageDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Subsystem: metricsSubsystem,
+		Name:      "outage_duration_seconds",
+		Help:      "Time tunnels stayed disconnected before being reconnected.",
+		Buckets:   []float64{1, 5, 15, 30, 60, 120, 300, 600, 1800},
+	})
+)
+
+func init() {
+	prometheus.MustRegister(
+		openTunnels,
+		tunnelFailuresTotal,
+		deadConnectionsTotal,
+		tunnelOutageDuration,
+		tunnelStatsCollector,
+	)
+}
+
+var tunnelStatsCollector = newStatsCollector()
+
+// statsCollector exposes the statistics of the tunnels currently managed, labeled by tunnel.
+type statsCollector struct {
+	bytesReceived     *prometheus.Desc
+	bytesSent         *prometheus.Desc
+	activeStreams     *prometheus.Desc
+	rtt               *prometheus.Desc
+	reconnections     *prometheus.Desc
+	handshakeFailures *prometheus.Desc
+	transport         *prometheus.Desc
+
+	tunnelsMu sync.Mutex
+	tunnels   map[string]*tunnel
+}
+
+func newStatsCollector() *statsCollector {
+	desc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(metricsNamespace, metricsSubsystem, name), help, []string{"tunnel_id"}, nil)
+	}
+
+	return &statsCollector{
+		bytesReceived:     desc("received_bytes_total", "Number of bytes received from the broker and forwarded to the cluster."),
+		bytesSent:         desc("sent_bytes_total", "Number of bytes received from the cluster and sent to the broker."),
+		activeStreams:     desc("active_streams", "Number of streams currently proxied."),
+		rtt:               desc("rtt_seconds", "Last round-trip time measured to the broker."),
+		reconnections:     desc("reconnections_total", "Number of times the tunnel reconnected after being disconnected."),
+		handshakeFailures: desc("handshake_failures_total", "Number of connections to the broker which couldn't be established."),
+		transport: prometheus.NewDesc(prometheus.BuildFQName(metricsNamespace, metricsSubsystem, "transport_info"),
+			"Transport used by the tunnel to reach the broker, once connected.", []string{"tunnel_id", "transport"}, nil),
+		tunnels: make(map[string]*tunnel),
+	}
+}
+
+func (c *statsCollector) add(tunnelID string, t *tunnel) {
+	c.tunnelsMu.Lock()
+	defer c.tunnelsMu.Unlock()
+
+	c.tunnels[tunnelID] = t
+}
+
+// remove removes the given tunnel, unless it has been replaced in the meantime.
+func (c *statsCollector) remove(tunnelID string, t *tunnel) {
+	c.tunnelsMu.Lock()
+	defer c.tunnelsMu.Unlock()
+
+	if c.tunnels[tunnelID] == t {
+		delete(c.tunnels, tunnelID)
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (c *statsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.bytesReceived
+	ch <- c.bytesSent
+	ch <- c.activeStreams
+	ch <- c.rtt
+	ch <- c.reconnections
+	ch <- c.handshakeFailures
+	ch <- c.transport
+}
+
+// Collect implements prometheus.Collector.
+func (c *statsCollector) Collect(ch chan<- prometheus.Metric) {
+	c.tunnelsMu.Lock()
+	defer c.tunnelsMu.Unlock()
+
+	for id, t := range c.tunnels {
+		s := &t.stats
+
+		ch <- prometheus.MustNewConstMetric(c.bytesReceived, prometheus.CounterValue, float64(atomic.LoadInt64(&s.bytesReceived)), id)
+		ch <- prometheus.MustNewConstMetric(c.bytesSent, prometheus.CounterValue, float64(atomic.LoadInt64(&s.bytesSent)), id)
+		ch <- prometheus.MustNewConstMetric(c.activeStreams, prometheus.GaugeValue, float64(atomic.LoadInt64(&s.activeStreams)), id)
+		ch <- prometheus.MustNewConstMetric(c.rtt, prometheus.GaugeValue, time.Duration(atomic.LoadInt64(&s.rtt)).Seconds(), id)
+		ch <- prometheus.MustNewConstMetric(c.reconnections, prometheus.CounterValue, float64(atomic.LoadInt64(&s.reconnections)), id)
+		ch <- prometheus.MustNewConstMetric(c.handshakeFailures, prometheus.CounterValue, float64(atomic.LoadInt64(&s.handshakeFailures)), id)
+
+		if transport := t.currentTransport(); transport != "" {
+			ch <- prometheus.MustNewConstMetric(c.transport, prometheus.GaugeValue, 1, id, transport)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+/*
+Copyright (C) 2022 Traefik Labs
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package tunnel
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatsCollector(t *testing.T) {
+	collector := newStatsCollector()
+
+	tun := &tunnel{stats: tunnelStats{
+		bytesReceived:     1024,
+		bytesSent:         2048,
+		activeStreams:     3,
+		rtt:               int64(25 * time.Millisecond),
+		reconnections:     2,
+		handshakeFailures: 1,
+	}, transport: TransportFallback}
+	collector.add("tunnel-id", tun)
+
+	// Removing a tunnel replaced in the meantime is a no-op.
+	collector.remove("tunnel-id", &tunnel{})
+
+	got := collect(t, collector)
+	assert.Equal(t, map[string]float64{
+		"hub_agent_tunnel_received_bytes_total":     1024,
+		"hub_agent_tunnel_sent_bytes_total":         2048,
+		"hub_agent_tunnel_active_streams":           3,
+		"hub_agent_tunnel_rtt_seconds":              0.025,
+		"hub_agent_tunnel_reconnections_total":      2,
+		"hub_agent_tunnel_handshake_failures_total": 1,
+		"hub_agent_tunnel_transport_info":           1,
+	}, got)
+
+	collector.remove("tunnel-id", tun)
+	assert.Empty(t, collect(t, collector))
+}
+
+func collect(t *testing.T, collector prometheus.Collector) map[string]float64 {
+	t.Helper()
+
+	reg := prometheus.NewPedanticRegistry()
+	require.NoError(t, reg.Register(collector))
+
+	families, err := reg.Gather()
+	require.NoError(t, err)
+
+	values := make(map[string]float64)
+	for _, family := range families {
+		for _, m := range family.GetMetric() {
+			wantLabels := []*dto.LabelPair{{Name: strPtr("tunnel_id"), Value: strPtr("tunnel-id")}}
+			if family.GetName() == "hub_agent_tunnel_transport_info" {
+				wantLabels = append([]*dto.LabelPair{{Name: strPtr("transport"), Value: strPtr(TransportFallback)}}, wantLabels...)
+			}
+			require.Equal(t, wantLabels, m.GetLabel())
+
+			switch family.GetType() {
+			case dto.MetricType_COUNTER:
+				values[family.GetName()] = m.GetCounter().GetValue()
+			case dto.MetricType_GAUGE:
+				values[family.GetName()] = m.GetGauge().GetValue()
+			}
+		}
+	}
+
+	return values
+}
+
+func strPtr(s string) *string {
+	return &s
+}
@@ -0,0 +1,181 @@
+/*
+Copyright (C) 2022 Traefik Labs
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package tunnel
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// ProxyConfig configures the HTTP(S) proxy through which tunnels reach brokers.
+type ProxyConfig struct {
+	// URL is the URL of the proxy, using the http or https scheme. When empty, the proxy is read from the
+	// HTTPS_PROXY, HTTP_PROXY and NO_PROXY environment variables.
+	URL string
+	// Username and Password authenticate the agent on the proxy. They take precedence over the user info of the URL.
+	Username string
+	Password string
+
+	// fromEnvironment looks up the proxy of a request in the environment. It defaults to http.ProxyFromEnvironment.
+	fromEnvironment func(req *http.Request) (*url.URL, error)
+}
+
+// Validate validates the proxy configuration.
+func (c ProxyConfig) Validate() error {
+	if c.URL == "" {
+		return nil
+	}
+
+	u, err := url.Parse(c.URL)
+	if err != nil {
+		return fmt.Errorf("invalid proxy URL: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("invalid proxy URL scheme %q, must be http or https", u.Scheme)
+	}
+	if u.Host == "" {
+		return fmt.Errorf("invalid proxy URL %q, missing host", c.URL)
+	}
+
+	return nil
+}
+
+// proxyURL returns the URL of the proxy to use to reach the given broker URL, or nil if it must be reached directly.
+func (c ProxyConfig) proxyURL(brokerURL *url.URL) (*url.URL, error) {
+	var (
+		u   *url.URL
+		err error
+	)
+	if c.URL != "" {
+		u, err = url.Parse(c.URL)
+	} else {
+		// The environment is looked up with the HTTP scheme matching the WebSocket one.
+		target := *brokerURL
+		target.Scheme = "http"
+		if brokerURL.Scheme == "wss" {
+			target.Scheme = "https"
+		}
+
+		fromEnvironment := c.fromEnvironment
+		if fromEnvironment == nil {
+			fromEnvironment = http.ProxyFromEnvironment
+		}
+
+		u, err = fromEnvironment(&http.Request{URL: &target})
+	}
+	if err != nil {
+		return nil, fmt.Errorf("proxy URL: %w", err)
+	}
+	if u == nil {
+		return nil, nil
+	}
+
+	if c.Username != "" {
+		u.User = url.UserPassword(c.Username, c.Password)
+	}
+
+	return u, nil
+}
+
+// dialThroughProxy opens a connection to the given address through an HTTP CONNECT tunnel established with the given
+// proxy.
+func dialThroughProxy(ctx context.Context, proxyURL *url.URL, addr string) (net.Conn, error) {
+	proxyAddr := proxyURL.Host
+	if proxyURL.Port() == "" {
+		port := "80"
+		if proxyURL.Scheme == "https" {
+			port = "443"
+		}
+		proxyAddr = net.JoinHostPort(proxyURL.Hostname(), port)
+	}
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", proxyAddr)
+	if err != nil {
+		return nil, fmt.Errorf("dial proxy: %w", err)
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	if proxyURL.Scheme == "https" {
+		tlsConn := tls.Client(conn, &tls.Config{ServerName: proxyURL.Hostname(), MinVersion: tls.VersionTLS12})
+		if err = tlsConn.HandshakeContext(ctx); err != nil {
+			_ = conn.Close()
+			return nil, fmt.Errorf("proxy TLS handshake: %w", err)
+		}
+		conn = tlsConn
+	}
+
+	req := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Opaque: addr},
+		Host:   addr,
+		Header: make(http.Header),
+	}
+	if user := proxyURL.User; user != nil {
+		password, _ := user.Password()
+		credentials := base64.StdEncoding.EncodeToString([]byte(user.Username() + ":" + password))
+		req.Header.Set("Proxy-Authorization", "Basic "+credentials)
+	}
+
+	if err = req.Write(conn); err != nil {
+		_ = conn.Close()
+		return nil, fmt.Errorf("write CONNECT request: %w", err)
+	}
+
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, req)
+	if err != nil {
+		_ = conn.Close()
+		return nil, fmt.Errorf("read CONNECT response: %w", err)
+	}
+	_ = resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		_ = conn.Close()
+		return nil, fmt.Errorf("proxy CONNECT to %s: unexpected status %d", addr, resp.StatusCode)
+	}
+
+	_ = conn.SetDeadline(time.Time{})
+
+	if br.Buffered() > 0 {
+		return &bufferedConn{Conn: conn, r: br}, nil
+	}
+
+	return conn, nil
+}
+
+// bufferedConn is a connection whose first bytes were already read in a buffer.
+type bufferedConn struct {
+	net.Conn
+
+	r *bufio.Reader
+}
+
+func (c *bufferedConn) Read(p []byte) (int, error) {
+	return c.r.Read(p)
+}
@@ -0,0 +1,68 @@
+/*
+Copyright (C) 2022 Traefik Labs
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package tunnel
+
+import (
+	"io"
+	"sync/atomic"
+	"time"
+)
+
+// Stats are the statistics of a tunnel.
+type Stats struct {
+	// BytesReceived is the number of bytes received from the broker and forwarded to the cluster.
+	BytesReceived int64 `json:"bytesReceived"`
+	// BytesSent is the number of bytes received from the cluster and sent to the broker.
+	BytesSent int64 `json:"bytesSent"`
+	// ActiveStreams is the number of streams currently proxied.
+	ActiveStreams int64 `json:"activeStreams"`
+	// RTTMilliseconds is the last round-trip time measured to the broker.
+	RTTMilliseconds int64 `json:"rttMs"`
+	// Reconnections is the number of times the tunnel reconnected after being disconnected.
+	Reconnections int64 `json:"reconnections"`
+	// HandshakeFailures is the number of connections to the broker which couldn't be established.
+	HandshakeFailures int64 `json:"handshakeFailures"`
+}
+
+// tunnelStats collects the statistics of a tunnel. Fields are accessed atomically.
+type tunnelStats struct {
+	bytesReceived     int64
+	bytesSent         int64
+	activeStreams     int64
+	rtt               int64
+	reconnections     int64
+	handshakeFailures int64
+}
+
+// countingWriter counts the bytes written through it, and records the time of the last write if lastActivity is set.
+type countingWriter struct {
+	io.Writer
+
+	n            *int64
+	lastActivity *int64
+}
+
+func (w countingWriter) Write(p []byte) (int, error) {
+	n, err := w.Writer.Write(p)
+	atomic.AddInt64(w.n, int64(n))
+	if w.lastActivity != nil {
+		atomic.StoreInt64(w.lastActivity, time.Now().UnixNano())
+	}
+
+	return n, err
+}
@@ -0,0 +1,131 @@
+/*
+Copyright (C) 2022 Traefik Labs
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package version
+
+import (
+	"fmt"
+	"sync"
+
+	goversion "github.com/hashicorp/go-version"
+	"github.com/rs/zerolog/log"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+// Checker compares the running agent version with the latest one available on the platform, and reports outdated
+// agents.
+type Checker struct {
+	current  string
+	recorder record.EventRecorder
+	ref      *corev1.ObjectReference
+
+	mu       sync.RWMutex
+	latest   string
+	outdated bool
+}
+
+// NewChecker creates a Checker. When the agent is outdated, a Kubernetes event is published on the given object, if
+// not nil.
+func NewChecker(recorder record.EventRecorder, ref *corev1.ObjectReference) *Checker {
+	return newChecker(version, recorder, ref)
+}
+
+func newChecker(current string, recorder record.EventRecorder, ref *corev1.ObjectReference) *Checker {
+	return &Checker{
+		current:  current,
+		recorder: recorder,
+		ref:      ref,
+	}
+}
+
+// SetLatest sets the latest agent version available. Development builds and unknown latest versions are never
+// considered outdated. Outdated agents are reported once per latest version.
+func (c *Checker) SetLatest(latest string) {
+	outdated := isOutdated(c.current, latest)
+
+	c.mu.Lock()
+	changed := latest != c.latest
+	c.latest = latest
+	c.outdated = outdated
+	c.mu.Unlock()
+
+	if !changed {
+		return
+	}
+
+	versionOutdated.Reset()
+	if latest != "" {
+		versionOutdated.WithLabelValues(c.current, latest).Set(boolToFloat(outdated))
+	}
+
+	if !outdated {
+		return
+	}
+
+	log.Warn().Str("version", c.current).Str("latest_version", latest).Msg("The agent is outdated")
+
+	if c.ref != nil {
+		c.recorder.Eventf(c.ref, corev1.EventTypeWarning, "AgentOutdated", "Hub agent %s is outdated, version %s is available", c.current, latest)
+	}
+}
+
+// Outdated tells whether a more recent agent version is available.
+func (c *Checker) Outdated() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.outdated
+}
+
+// Info describes the running agent version, and the latest one when it is outdated.
+func (c *Checker) Info() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if c.outdated {
+		return fmt.Sprintf("%s, update available: %s", c.current, c.latest)
+	}
+
+	return c.current
+}
+
+func isOutdated(current, latest string) bool {
+	if latest == "" {
+		return false
+	}
+
+	currentVersion, err := goversion.NewVersion(current)
+	if err != nil {
+		return false
+	}
+
+	latestVersion, err := goversion.NewVersion(latest)
+	if err != nil {
+		log.Debug().Err(err).Str("latest_version", latest).Msg("Unable to parse latest agent version")
+		return false
+	}
+
+	return currentVersion.LessThan(latestVersion)
+}
+
+func boolToFloat(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,101 @@
+/*
+Copyright (C) 2022 Traefik Labs
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package version
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestIsOutdated(t *testing.T) {
+	tests := []struct {
+		desc    string
+		current string
+		latest  string
+		want    bool
+	}{
+		{
+			desc:    "older version",
+			current: "v1.2.0",
+			latest:  "v1.3.0",
+			want:    true,
+		},
+		{
+			desc:    "latest version",
+			current: "v1.3.0",
+			latest:  "v1.3.0",
+		},
+		{
+			desc:    "newer version",
+			current: "v1.4.0-rc.1",
+			latest:  "v1.3.0",
+		},
+		{
+			desc:    "pre-release of the latest version",
+			current: "v1.3.0-rc.1",
+			latest:  "v1.3.0",
+			want:    true,
+		},
+		{
+			desc:    "development build",
+			current: "dev",
+			latest:  "v1.3.0",
+		},
+		{
+			desc:    "unknown latest version",
+			current: "v1.2.0",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, test.want, isOutdated(test.current, test.latest))
+		})
+	}
+}
+
+func TestChecker_SetLatest(t *testing.T) {
+	recorder := record.NewFakeRecorder(10)
+	ref := &corev1.ObjectReference{Kind: "Pod", Name: "hub-agent-controller-0", Namespace: "hub"}
+
+	checker := newChecker("v1.2.0", recorder, ref)
+	assert.False(t, checker.Outdated())
+	assert.Equal(t, "v1.2.0", checker.Info())
+
+	checker.SetLatest("v1.2.0")
+	assert.False(t, checker.Outdated())
+	assert.Empty(t, recorder.Events)
+
+	checker.SetLatest("v1.3.0")
+	assert.True(t, checker.Outdated())
+	assert.Equal(t, "v1.2.0, update available: v1.3.0", checker.Info())
+	assert.Equal(t, "Warning AgentOutdated Hub agent v1.2.0 is outdated, version v1.3.0 is available", <-recorder.Events)
+
+	// The same latest version is only reported once.
+	checker.SetLatest("v1.3.0")
+	assert.Empty(t, recorder.Events)
+
+	checker.SetLatest("v1.4.0")
+	assert.Equal(t, "Warning AgentOutdated Hub agent v1.2.0 is outdated, version v1.4.0 is available", <-recorder.Events)
+}
@@ -0,0 +1,31 @@
+/*
+Copyright (C) 2022 Traefik Labs
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package version
+
+import "github.com/prometheus/client_golang/prometheus"
+
+var versionOutdated = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Namespace: "hub_agent",
+	Subsystem: "version",
+	Name:      "outdated",
+	Help:      "Whether a more recent agent version is available (1) or not (0), by running and latest version.",
+}, []string{"version", "latest_version"})
+
+func init() {
+	prometheus.MustRegister(versionOutdated)
+}
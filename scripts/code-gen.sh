@@ -29,7 +29,15 @@ docker run --rm \
            "${IMAGE_NAME}" $cmd
 
 
-cmd="controller-gen crd:crdVersions=v1 paths=./pkg/crd/api/hub/v1alpha1/... output:dir=."
+cmd="/go/src/k8s.io/code-generator/generate-groups.sh deepcopy $PROJECT_MODULE/pkg/crd/generated/client/hub $PROJECT_MODULE/pkg/crd/api hub:v1alpha2"
+
+echo "Generating Hub v1alpha2 deepcopy code ..."
+docker run --rm \
+           -v "$(pwd):/go/src/${PROJECT_MODULE}" \
+           -w "/go/src/${PROJECT_MODULE}" \
+           "${IMAGE_NAME}" $cmd
+
+cmd="controller-gen crd:crdVersions=v1 paths=./pkg/crd/api/hub/... output:dir=."
 
 echo "Generating the CRD definitions ..."
 docker run --rm \